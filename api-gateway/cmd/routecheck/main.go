@@ -0,0 +1,51 @@
+// Команда routecheck проверяет, что все эндпоинты сервиса из его спецификации swag доступны
+// через шлюз. Завершается с кодом 1, если есть эндпоинты без маршрута.
+//
+//	go run ./api-gateway/cmd/routecheck -config api-gateway/configs/config.yaml -spec backend-svc/docs/swagger.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"lmsmodule/api-gateway/internal/routecheck"
+	"lmsmodule/api-gateway/internal/utils"
+)
+
+func main() {
+	configPath := flag.String("config", "./api-gateway/configs/config.yaml", "Path to gateway configuration file")
+	specPath := flag.String("spec", "./backend-svc/docs/swagger.json", "Path to service OpenAPI spec generated by swag")
+	service := flag.String("service", "BACKEND-SERVICE", "Service name used in gateway routes")
+	// swag дополняет basePath (/api) все пути спецификации, в том числе служебные /internal
+	ignore := flag.String("ignore", "/api/internal/", "Comma-separated path prefixes of endpoints not exposed through the gateway")
+	flag.Parse()
+
+	config, err := utils.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	spec, err := routecheck.LoadSpec(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to load spec: %v", err)
+	}
+
+	report := routecheck.Check(config.Routes, *service, spec, strings.Split(*ignore, ","))
+
+	if len(report.Undocumented) > 0 {
+		fmt.Printf("Gateway routes to %s missing from the spec (%d):\n", *service, len(report.Undocumented))
+		for _, route := range report.Undocumented {
+			fmt.Printf("  %s\n", route)
+		}
+	}
+	if len(report.Unmapped) > 0 {
+		fmt.Printf("Endpoints of %s without a gateway route (%d):\n", *service, len(report.Unmapped))
+		for _, endpoint := range report.Unmapped {
+			fmt.Printf("  %s\n", endpoint)
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("All %d endpoints of %s are routed\n", len(spec.Endpoints), *service)
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"lmsmodule/api-gateway/internal/balancer"
+	"lmsmodule/api-gateway/internal/circuitbreaker"
+	"lmsmodule/api-gateway/internal/metrics"
+	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/api-gateway/pkg/tracing"
+)
+
+// maxRetryBody - тело запроса до этого размера сохраняется в памяти, чтобы запрос можно было повторить.
+// Запросы с телом больше или неизвестной длины не повторяются.
+const maxRetryBody = 1 << 20
+
+// balancingTransport отправляет запрос экземпляру, выбранному балансировщиком, в обход экземпляров
+// с разомкнутым выключателем, сообщает им об исходе запроса и повторяет идемпотентные запросы
+// на другом экземпляре
+type balancingTransport struct {
+	base     http.RoundTripper
+	balancer *balancer.Balancer
+	breakers *circuitbreaker.Registry
+	service  string
+	// key - ключ пользователя для стратегии consistent_hash
+	key     string
+	retries utils.Retries
+	metrics *metrics.ServiceMetrics
+	log     *logger.Logger
+	span    *tracing.Span
+}
+
+func (t *balancingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	var body []byte
+	if t.retries.Retryable(req.Method) {
+		var replayable bool
+		var err error
+		if body, replayable, err = replayableBody(req); err != nil {
+			return nil, err
+		}
+		if replayable {
+			attempts = t.retries.MaxAttempts()
+		}
+	}
+
+	tried := make(map[string]bool, attempts)
+	var (
+		resp     *http.Response
+		err      error
+		rejected bool
+	)
+	for attempt := 0; attempt < attempts; {
+		endpoint, pickErr := t.balancer.Next(t.key, tried)
+		if pickErr != nil {
+			if attempt > 0 {
+				// Других экземпляров нет, клиент получает ответ последней попытки
+				break
+			}
+			if rejected {
+				return nil, circuitbreaker.ErrOpen
+			}
+			return nil, pickErr
+		}
+		tried[endpoint.URL] = true
+		done, allowed := t.breakers.Get(t.service, endpoint.URL).Allow()
+		if !allowed {
+			// Выключатель экземпляра разомкнут, попытка не тратится
+			rejected = true
+			continue
+		}
+
+		attempt++
+		if resp != nil {
+			drain(resp)
+		}
+		if attempt > 1 {
+			t.metrics.RecordRetry(t.service)
+			t.span.SetAttribute("http.retry_count", attempt-1)
+		}
+
+		resp, err = t.send(req, endpoint, body, attempt, done)
+		if !retryable(req, resp, err) {
+			break
+		}
+	}
+	return resp, err
+}
+
+func (t *balancingTransport) send(req *http.Request, endpoint *balancer.Endpoint, body []byte, attempt int, done func(circuitbreaker.Result)) (*http.Response, error) {
+	target, err := url.Parse(endpoint.URL)
+	if err != nil {
+		done(circuitbreaker.Ignored)
+		return nil, err
+	}
+	outreq := req.Clone(req.Context())
+	outreq.URL.Scheme = target.Scheme
+	outreq.URL.Host = target.Host
+	if body != nil {
+		outreq.Body = io.NopCloser(bytes.NewReader(body))
+		outreq.ContentLength = int64(len(body))
+	}
+
+	t.span.SetAttribute("net.peer.name", target.Host)
+	t.span.SetAttribute("http.url", target.String()+outreq.URL.Path)
+	t.log.WithFields(map[string]interface{}{
+		"service": t.service,
+		"method":  outreq.Method,
+		"target":  target.String() + outreq.URL.Path,
+		"attempt": attempt,
+	}).Debug("proxying request")
+
+	release := endpoint.Acquire()
+	resp, err := t.base.RoundTrip(outreq)
+	t.report(req, endpoint, resp, err, done)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		// Тело ответа 101 - соединение WebSocket: ReverseProxy требует его без обертки
+		release()
+	} else {
+		resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	}
+	return resp, nil
+}
+
+// report сообщает исход запроса балансировщику и выключателю экземпляра. Балансировщик
+// засчитывает как сбои ошибки соединения и ответы 502, 503 и 504, выключатель - ошибки
+// и любые ответы 5xx. Запросы, отмененные клиентом, не засчитываются.
+func (t *balancingTransport) report(req *http.Request, endpoint *balancer.Endpoint, resp *http.Response, err error, done func(circuitbreaker.Result)) {
+	if err != nil && errors.Is(req.Context().Err(), context.Canceled) {
+		done(circuitbreaker.Ignored)
+		return
+	}
+	if err != nil || resp.StatusCode >= 500 {
+		done(circuitbreaker.Failure)
+	} else {
+		done(circuitbreaker.Success)
+	}
+	if err == nil && !failedStatus(resp.StatusCode) {
+		t.balancer.ReportSuccess(endpoint)
+		return
+	}
+	if t.balancer.ReportFailure(endpoint) {
+		t.log.Warn("Instance %s of %s ejected from load balancing after consecutive failures", endpoint.URL, t.service)
+		t.metrics.RecordEjection(t.service)
+	}
+}
+
+func failedStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// retryable сообщает, стоит ли повторить запрос на другом экземпляре. 504 не повторяется:
+// экземпляр мог выполнить запрос, просто не успев ответить.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// replayableBody читает тело запроса, чтобы отправить его повторно. Без тела возвращает nil.
+func replayableBody(req *http.Request) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true, nil
+	}
+	if req.ContentLength <= 0 || req.ContentLength > maxRetryBody {
+		return nil, false, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, req.ContentLength))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Body.Close()
+	return body, true, nil
+}
+
+// drain дочитывает ответ неудачной попытки, чтобы соединение вернулось в пул
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+// releasingBody завершает запрос к экземпляру, когда ReverseProxy закрывает тело ответа
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
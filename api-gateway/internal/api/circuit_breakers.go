@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"lmsmodule/api-gateway/internal/circuitbreaker"
+	"lmsmodule/api-gateway/internal/middleware"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/api-gateway/pkg/tracing"
+)
+
+// setupCircuitBreakerRoutes подключает просмотр и ручное управление выключателями.
+// Доступ есть только у администратора с проверенным токеном, права которого подтвердил сервис,
+// поэтому без jwt.enabled маршруты отвечают 403 на любой запрос.
+func (s *Server) setupCircuitBreakerRoutes() {
+	if s.Verifier == nil {
+		s.Logger.Warn("Token verification is disabled (jwt.enabled: false): /api/admin/circuit-breakers rejects all requests")
+	}
+	breakers := s.Router.Group("/api/admin/circuit-breakers", middleware.RequireAdmin(s.Verifier, s.checkAdmin))
+	{
+		breakers.GET("", s.listCircuitBreakersHandler)
+		breakers.GET("/:service", s.serviceCircuitBreakersHandler)
+		breakers.POST("/:service/:action", s.controlCircuitBreakersHandler)
+	}
+}
+
+// checkAdmin спрашивает сервис из jwt.admin_check, администратор ли владелец токена
+func (s *Server) checkAdmin(ctx context.Context, authorization string) (bool, error) {
+	target := s.Config.AdminCheckTarget()
+	serviceURL := s.Discovery.GetServiceURL(target.Service)
+	if serviceURL == "" {
+		return false, fmt.Errorf("no instances of %s", target.Service)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serviceURL+target.Path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", authorization)
+	if requestID := logger.RequestID(ctx); requestID != "" {
+		req.Header.Set(logger.RequestIDHeader, requestID)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := s.HttpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, nil
+	default:
+		return false, fmt.Errorf("admin check %s returned %d", target.Path, resp.StatusCode)
+	}
+}
+
+func (s *Server) listCircuitBreakersHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"circuit_breakers": s.CircuitBreakers.List()})
+}
+
+func (s *Server) serviceCircuitBreakersHandler(c *gin.Context) {
+	service := strings.ToUpper(c.Param("service"))
+	c.JSON(http.StatusOK, gin.H{"service": service, "circuit_breakers": s.serviceBreakerStatus(service)})
+}
+
+// controlCircuitBreakersHandler выполняет action для выключателя экземпляра из параметра instance
+// или, без него, для выключателей всех текущих экземпляров сервиса:
+// open и close закрепляют состояние, reset возвращает автоматическое управление
+func (s *Server) controlCircuitBreakersHandler(c *gin.Context) {
+	service := strings.ToUpper(c.Param("service"))
+	action := c.Param("action")
+	if action != "open" && action != "close" && action != "reset" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Action must be open, close or reset"})
+		return
+	}
+
+	var breakers []*circuitbreaker.CircuitBreaker
+	instance := c.Query("instance")
+	if lb := s.Discovery.Balancer(service); lb != nil {
+		for _, endpoint := range lb.Endpoints() {
+			if instance == "" || instance == endpoint.URL {
+				breakers = append(breakers, s.CircuitBreakers.Get(service, endpoint.URL))
+			}
+		}
+	}
+	if len(breakers) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service instance not found"})
+		return
+	}
+
+	for _, breaker := range breakers {
+		switch action {
+		case "open":
+			_ = breaker.Force(circuitbreaker.Open)
+		case "close":
+			_ = breaker.Force(circuitbreaker.Closed)
+		case "reset":
+			breaker.Reset()
+		}
+	}
+	s.Logger.WithContext(c.Request.Context()).WithFields(map[string]interface{}{
+		"service":  service,
+		"instance": instance,
+		"action":   action,
+		"user_id":  c.GetString(middleware.ContextUserID),
+	}).Warn("circuit breakers changed by administrator")
+
+	c.JSON(http.StatusOK, gin.H{"service": service, "circuit_breakers": s.serviceBreakerStatus(service)})
+}
+
+func (s *Server) serviceBreakerStatus(service string) []circuitbreaker.Status {
+	statuses := []circuitbreaker.Status{}
+	for _, status := range s.CircuitBreakers.List() {
+		if status.Service == service {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
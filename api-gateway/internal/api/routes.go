@@ -2,80 +2,43 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"lmsmodule/api-gateway/internal/auth"
 	"lmsmodule/api-gateway/internal/middleware"
+	"lmsmodule/api-gateway/internal/ratelimit"
+	"lmsmodule/api-gateway/internal/utils"
 )
 
-type ProxyHandlerFunc func(string) gin.HandlerFunc
-
-func SetupRoutes(router *gin.Engine, proxyHandler ProxyHandlerFunc) {
-	public := router.Group("/api")
-	{
-		public.Any("/register", proxyHandler("BACKEND-SERVICE"))
-		public.Any("/login", proxyHandler("BACKEND-SERVICE"))
-		public.Any("/verify-otp", proxyHandler("BACKEND-SERVICE"))
-		public.Any("/forgot-password", proxyHandler("BACKEND-SERVICE"))
-		public.Any("/reset-password", proxyHandler("BACKEND-SERVICE"))
-		public.Any("/health", proxyHandler("BACKEND-SERVICE"))
+// RouteHandlerFunc возвращает обработчик, который передает запросы маршрута сервису
+type RouteHandlerFunc func(utils.RouteConfig) gin.HandlerFunc
+
+// SetupRoutes регистрирует маршруты из конфигурации. Перед обработчиком маршрута ставятся
+// проверка токена и ограничение частоты запросов по политике маршрута: проверка идет первой,
+// чтобы лимит считался на проверенного пользователя. Без verifier шлюз проверяет только
+// наличие заголовка Authorization. Лимиты всех политик хранятся в store; маршруты с одной
+// политикой делят один лимит.
+func SetupRoutes(router *gin.Engine, config *utils.Config, verifier *auth.Verifier, store ratelimit.Store, routeHandler RouteHandlerFunc) {
+	limiters := make(map[string]gin.HandlerFunc)
+	for name, policy := range config.RateLimits {
+		limiters[name] = middleware.RateLimit(ratelimit.NewLimiter(name, policy.Policy(), store))
+	}
+	authenticate := map[bool]gin.HandlerFunc{
+		true:  middleware.Authenticate(verifier, true),
+		false: middleware.Authenticate(verifier, false),
 	}
 
-	api := router.Group("/api")
-	api.Use(middleware.RateLimiterMiddleware())
-	{
-		api.Any("/courses", proxyHandler("BACKEND-SERVICE"))
-		api.Any("/courses/:id", proxyHandler("BACKEND-SERVICE"))
-		api.GET("/courses/:id/tasks/:task_id", proxyHandler("BACKEND-SERVICE"))
-
-		api.Any("/progress/:user_id", proxyHandler("BACKEND-SERVICE"))
-		api.Any("/progress/:user_id/tasks/:task_id/complete", proxyHandler("BACKEND-SERVICE"))
-
-		api.Any("/profile", proxyHandler("BACKEND-SERVICE"))
-
-		account := api.Group("/account")
-		{
-			account.Any("/2fa/enable", proxyHandler("BACKEND-SERVICE"))
-			account.Any("/profile/image", proxyHandler("BACKEND-SERVICE"))
-			account.Any("/change-password", proxyHandler("BACKEND-SERVICE"))
-			account.Any("/delete", proxyHandler("BACKEND-SERVICE"))
-			account.Any("/delete/confirm", proxyHandler("BACKEND-SERVICE"))
-		}
-
-		analytics := api.Group("/analytics")
-		{
-			analytics.GET("/users/:user_id/statistics", proxyHandler("BACKEND-SERVICE"))
+	for _, route := range config.Routes {
+		handlers := []gin.HandlerFunc{authenticate[route.Auth == utils.AuthRequired]}
+		if limiter, ok := limiters[route.RateLimit]; ok {
+			handlers = append(handlers, limiter)
 		}
+		handlers = append(handlers, routeHandler(route))
 
-		teacher := api.Group("/teacher")
-		{
-			teacher.POST("/courses", proxyHandler("BACKEND-SERVICE"))
-			teacher.PUT("/courses", proxyHandler("BACKEND-SERVICE"))
-			teacher.DELETE("/courses", proxyHandler("BACKEND-SERVICE"))
-			teacher.POST("/courses/:course_id/tasks", proxyHandler("BACKEND-SERVICE"))
-			teacher.PUT("/courses/:course_id/tasks/:task_id", proxyHandler("BACKEND-SERVICE"))
-			teacher.DELETE("/courses/:course_id/tasks/:task_id", proxyHandler("BACKEND-SERVICE"))
-			teacher.GET("/courses/:id/statistics", proxyHandler("BACKEND-SERVICE"))
+		if len(route.Methods) == 0 {
+			router.Any(route.Path, handlers...)
+			continue
 		}
-
-		admin := api.Group("/admin")
-		{
-			admin.POST("/reload-templates", proxyHandler("BACKEND-SERVICE"))
-
-			admin.GET("/users", proxyHandler("BACKEND-SERVICE"))
-			admin.GET("/users/:id", proxyHandler("BACKEND-SERVICE"))
-			admin.GET("/users/by-role", proxyHandler("BACKEND-SERVICE"))
-			admin.GET("/users/search", proxyHandler("BACKEND-SERVICE"))
-			admin.PUT("/users/:id/status", proxyHandler("BACKEND-SERVICE"))
-			admin.POST("/users/:id/promote", proxyHandler("BACKEND-SERVICE"))
-			admin.POST("/users/:id/demote", proxyHandler("BACKEND-SERVICE"))
-			admin.GET("/analytics/courses/:course_id/statistics", proxyHandler("BACKEND-SERVICE"))
-		}
-
-		executor := api.Group("/executor")
-		{
-			executor.POST("/prewarm", proxyHandler("EXECUTOR-SVC"))
-			executor.POST("/execute", proxyHandler("EXECUTOR-SVC"))
-			executor.POST("/execute_pytest", proxyHandler("EXECUTOR-SVC"))
-			executor.GET("/result/:session_id", proxyHandler("EXECUTOR-SVC"))
-			executor.POST("/cleanup/:session_id", proxyHandler("EXECUTOR-SVC"))
+		for _, method := range route.Methods {
+			router.Handle(method, route.Path, handlers...)
 		}
 	}
 
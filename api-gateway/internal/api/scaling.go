@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -35,6 +36,8 @@ func (s *Server) getServiceInstancesHandler(c *gin.Context) {
 		"service":   serviceName,
 		"instances": instances,
 		"count":     len(instances),
+		// Состояние балансировки: запросы в работе, сбои, исключенные экземпляры
+		"endpoints": s.Discovery.BalancerStatus()[strings.ToUpper(serviceName)],
 	})
 }
 
@@ -1,23 +1,42 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"lmsmodule/api-gateway/internal/auth"
 	"lmsmodule/api-gateway/internal/circuitbreaker"
 	"lmsmodule/api-gateway/internal/metrics"
+	"net"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"lmsmodule/api-gateway/internal/discovery"
 	"lmsmodule/api-gateway/internal/middleware"
+	"lmsmodule/api-gateway/internal/ratelimit"
+	"lmsmodule/api-gateway/internal/rewrite"
 	"lmsmodule/api-gateway/internal/utils"
 	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/api-gateway/pkg/tracing"
 )
 
+// proxyTransport ограничивает только установку соединения и ожидание заголовков ответа:
+// общий таймаут оборвал бы долгие потоки событий
+var proxyTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	MaxIdleConns:          100,
+	IdleConnTimeout:       90 * time.Second,
+	ResponseHeaderTimeout: 60 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
 type Server struct {
 	Router          *gin.Engine
 	Config          *utils.Config
@@ -25,12 +44,24 @@ type Server struct {
 	HttpClient      *http.Client
 	Discovery       *discovery.ServiceDiscovery
 	Metrics         *metrics.ServiceMetrics
-	CircuitBreakers map[string]*circuitbreaker.CircuitBreaker
+	CircuitBreakers *circuitbreaker.Registry
+	// RateLimitStore хранит лимиты политик rate_limits
+	RateLimitStore ratelimit.Store
+	// Verifier проверяет JWT на шлюзе; nil, если проверка выключена
+	Verifier *auth.Verifier
+	// Tracer записывает спаны запросов; nil, если трассировка выключена
+	Tracer *tracing.Tracer
 }
 
-func NewServer(config *utils.Config, logger *logger.Logger) *Server {
+func NewServer(config *utils.Config, log *logger.Logger) *Server {
+	serviceMetrics := metrics.NewServiceMetrics()
+	tracer := newTracer(config.Tracing, log)
+
 	router := gin.New()
-	router.Use(middleware.LoggerMiddleware(logger))
+	router.Use(logger.RequestIDMiddleware())
+	router.Use(tracing.Middleware(tracer))
+	router.Use(middleware.LoggerMiddleware(log))
+	router.Use(serviceMetrics.HTTP.Middleware())
 	router.Use(gin.Recovery())
 	router.Use(middleware.SecurityHeadersMiddleware())
 
@@ -38,50 +69,122 @@ func NewServer(config *utils.Config, logger *logger.Logger) *Server {
 		Timeout: time.Duration(30) * time.Second,
 	}
 
-	serviceDiscovery, err := discovery.NewServiceDiscovery(config, logger)
+	serviceDiscovery, err := discovery.NewServiceDiscovery(config, log)
 	if err != nil {
-		logger.Error("Failed to initialize service discovery: %v", err)
+		// Без реестра шлюзу некуда проксировать запросы
+		log.Fatal("Failed to initialize service discovery: %v", err)
 	}
 
 	server := &Server{
 		Router:          router,
 		Config:          config,
-		Logger:          logger,
+		Logger:          log,
 		HttpClient:      httpClient,
 		Discovery:       serviceDiscovery,
-		Metrics:         metrics.NewServiceMetrics(),
-		CircuitBreakers: make(map[string]*circuitbreaker.CircuitBreaker),
+		Metrics:         serviceMetrics,
+		CircuitBreakers: circuitbreaker.NewRegistry(config.CircuitBreaker.Policy, breakerTransitionHandler(log, serviceMetrics)),
+		RateLimitStore:  ratelimit.NewMemoryStore(),
+		Tracer:          tracer,
+	}
+
+	if config.JWT.Enabled {
+		if server.Verifier, err = auth.NewVerifier(config.JWT.AuthConfig()); err != nil {
+			// Без проверки шлюз пропускал бы токены, которые должен отклонять
+			log.Fatal("Failed to initialize JWT verification: %v", err)
+		}
 	}
 
-	server.CircuitBreakers["BACKEND-SERVICE"] = circuitbreaker.NewCircuitBreaker("BACKEND-SERVICE", 5, 30*time.Second)
-	server.CircuitBreakers["EXECUTOR-SVC"] = circuitbreaker.NewCircuitBreaker("EXECUTOR-SVC", 5, 30*time.Second)
+	serviceMetrics.WatchCircuitBreakers(server.CircuitBreakers.List)
+	serviceMetrics.WatchInstances(serviceDiscovery.GetAllServices)
+	// Выключатели экземпляров, которых больше нет в реестре, удаляются
+	serviceDiscovery.OnUpdate(func(serviceName string) {
+		server.CircuitBreakers.Prune(serviceName, serviceDiscovery.ActiveInstances(serviceName))
+	})
 
 	server.setupRoutes()
 	return server
 }
 
+// newTracer создает трассировщик по настройкам; ошибка экспортера выключает трассировку,
+// но не мешает шлюзу работать
+func newTracer(config utils.Tracing, log *logger.Logger) *tracing.Tracer {
+	exporter, err := tracing.NewExporter(config.Exporter, config.OTLPEndpoint, config.File)
+	if err != nil {
+		log.Error("Tracing disabled: %v", err)
+		return nil
+	}
+	service := config.ServiceName
+	if service == "" {
+		service = "api-gateway"
+	}
+	return tracing.NewTracer(service, exporter, tracing.Options{
+		OnError: func(err error) { log.Error("Tracing export failed: %v", err) },
+	})
+}
+
 func (s *Server) setupRoutes() {
-	SetupRoutes(s.Router, s.ProxyRequest)
+	SetupRoutes(s.Router, s.Config, s.Verifier, s.RateLimitStore, s.ProxyRoute)
 	s.setupScalingRoutes()
+	s.setupCircuitBreakerRoutes()
+	s.Router.GET("/metrics", gin.WrapH(s.Metrics.Registry.Handler()))
+	// Адрес проверки здоровья шлюза при регистрации в реестре
+	s.Router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
 }
 
-func (s *Server) ProxyRequest(targetServiceName string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		circuitBreaker, exists := s.CircuitBreakers[targetServiceName]
-		if !exists {
-			circuitBreaker = circuitbreaker.NewCircuitBreaker(targetServiceName, 5, 30*time.Second)
-			s.CircuitBreakers[targetServiceName] = circuitBreaker
+// breakerTransitionHandler записывает смены состояния выключателей в журнал и метрики
+func breakerTransitionHandler(log *logger.Logger, serviceMetrics *metrics.ServiceMetrics) func(circuitbreaker.Transition) {
+	return func(transition circuitbreaker.Transition) {
+		entry := log.WithFields(map[string]interface{}{
+			"service":  transition.Service,
+			"instance": transition.Instance,
+			"from":     transition.From.String(),
+			"to":       transition.To.String(),
+			"reason":   transition.Reason,
+		})
+		if transition.To == circuitbreaker.Open {
+			entry.Warn("circuit breaker opened")
+		} else {
+			entry.Info("circuit breaker state changed")
 		}
+		serviceMetrics.RecordBreakerTransition(transition)
+	}
+}
 
-		if !circuitBreaker.IsAllowed() {
-			s.Logger.Error("Circuit open for service %s, request rejected", targetServiceName)
-			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service temporarily unavailable"})
-			return
+// ProxyRoute передает запросы маршрута сервису route.Service: запрос переписывается по правилам
+// rewrite маршрута, а ответ ожидается не дольше таймаута маршрута
+func (s *Server) ProxyRoute(route utils.RouteConfig) gin.HandlerFunc {
+	var rewriter *rewrite.Rewriter
+	if !route.Rewrite.IsZero() {
+		var err error
+		if rewriter, err = rewrite.New(route.Path, route.Rewrite); err != nil {
+			// Правила проверяются при загрузке конфигурации, сюда попадает только собранный вручную маршрут
+			s.Logger.Error("Invalid rewrite rules for route %s: %v", route, err)
+			return func(c *gin.Context) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			}
 		}
+	}
+	proxy := s.proxy(route.Service, rewriter)
+	timeout := time.Duration(route.TimeoutSeconds()) * time.Second
+
+	return func(c *gin.Context) {
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+		}
+		proxy(c)
+	}
+}
 
-		serviceURL := s.Discovery.GetServiceURL(targetServiceName)
-		if serviceURL == "" {
-			s.Logger.Error("Service not found: %s", targetServiceName)
+func (s *Server) proxy(targetServiceName string, rewriter *rewrite.Rewriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := s.Logger.WithContext(c.Request.Context())
+		lb := s.Discovery.Balancer(targetServiceName)
+		if lb == nil {
+			log.Error("Service not found: %s", targetServiceName)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Service unavailable"})
 			return
 		}
@@ -89,81 +192,90 @@ func (s *Server) ProxyRequest(targetServiceName string) gin.HandlerFunc {
 		startTime := time.Now()
 		s.Metrics.RecordRequest(targetServiceName)
 
-		var targetPath string
-		if targetServiceName == "EXECUTOR-SVC" {
-			path := c.Request.URL.Path
-			segments := strings.Split(path, "/")
-			if len(segments) >= 3 {
-				targetPath = "/" + segments[len(segments)-1]
+		ctx, span := tracing.StartSpan(c.Request.Context(), "proxy "+targetServiceName, tracing.SpanKindClient)
+		defer span.End()
+		span.SetAttribute("peer.service", targetServiceName)
 
-				if len(segments) >= 4 && (segments[len(segments)-2] == "result" || segments[len(segments)-2] == "cleanup") {
-					targetPath = "/" + segments[len(segments)-2] + "/" + segments[len(segments)-1]
-				}
-			}
-		} else {
-			targetPath = c.Request.URL.Path
+		proxy := &httputil.ReverseProxy{
+			// Экземпляр сервиса выбирается заново при каждой попытке
+			Transport: &balancingTransport{
+				base:     proxyTransport,
+				balancer: lb,
+				breakers: s.CircuitBreakers,
+				service:  targetServiceName,
+				key:      middleware.UserKey(c),
+				retries:  s.Config.LoadBalancing.Retries,
+				metrics:  s.Metrics,
+				log:      log,
+				span:     span,
+			},
+			// Ответ передается клиенту без буферизации, иначе события потока /events/stream задерживаются
+			FlushInterval: -1,
 		}
 
-		remote, err := url.Parse(serviceURL)
-		if err != nil {
-			s.Logger.Error("Failed to parse target URL: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
-			s.Metrics.RecordError(targetServiceName)
-			return
-		}
-
-		proxy := httputil.NewSingleHostReverseProxy(remote)
-
-		originalHandler := proxy.Director
 		proxy.Director = func(req *http.Request) {
-			originalHandler(req)
-
-			req.URL.Scheme = remote.Scheme
-			req.URL.Host = remote.Host
-
-			if targetServiceName == "EXECUTOR-SVC" {
-				req.URL.Path = targetPath
-			} else {
-				req.URL.Path = c.Request.URL.Path
-			}
-
+			// Заголовки уже скопированы из входящего запроса в req
+			req.URL.Path = c.Request.URL.Path
+			req.URL.RawPath = c.Request.URL.RawPath
 			req.URL.RawQuery = c.Request.URL.RawQuery
-
-			for key, values := range c.Request.Header {
-				for _, value := range values {
-					req.Header.Add(key, value)
-				}
+			if _, ok := req.Header["User-Agent"]; !ok {
+				// Без этого net/http подставит свой User-Agent
+				req.Header.Set("User-Agent", "")
 			}
-
-			s.Logger.Info("Proxying request: %s %s -> %s%s",
-				req.Method,
-				c.Request.URL.Path,
-				remote,
-				req.URL.Path)
+			if rewriter != nil {
+				rewriter.Apply(req, c.Param)
+			}
+			// Правила rewrite не должны подменять идентификатор, по которому связаны журналы
+			if requestID := logger.RequestID(c.Request.Context()); requestID != "" {
+				req.Header.Set(logger.RequestIDHeader, requestID)
+			}
+			// Без трассировки на шлюзе traceparent клиента передается сервису без изменений
+			tracing.Inject(req.Context(), req.Header)
 		}
 
 		proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
-			s.Metrics.RecordError(targetServiceName)
-			s.Logger.Error("Proxy error: %v", err)
-			circuitBreaker.Failure()
+			if errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+				log.Error("Service %s did not respond in time: %s", targetServiceName, c.Request.URL.Path)
+				rw.WriteHeader(http.StatusGatewayTimeout)
+				_, _ = rw.Write([]byte("Service timeout"))
+				return
+			}
+			if req.Context().Err() != nil {
+				// Клиент закрыл соединение, например отключился от потока событий; сервис исправен
+				return
+			}
+			span.RecordError(err)
+			if errors.Is(err, circuitbreaker.ErrOpen) {
+				log.Error("Circuit open for all instances of service %s, request rejected", targetServiceName)
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service temporarily unavailable"})
+				return
+			}
+			// Ошибка попадает в метрики ниже по статусу 502
+			log.Error("Proxy error: %v", err)
 			rw.WriteHeader(http.StatusBadGateway)
 			_, _ = rw.Write([]byte("Service unavailable"))
 		}
 
-		proxy.ServeHTTP(c.Writer, c.Request)
+		proxy.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
 
 		duration := time.Since(startTime)
 		s.Metrics.RecordResponseTime(targetServiceName, duration)
 
+		span.SetAttribute("http.status_code", c.Writer.Status())
 		if c.Writer.Status() >= 500 {
-			circuitBreaker.Failure()
+			span.RecordError(fmt.Errorf("HTTP %d", c.Writer.Status()))
 			s.Metrics.RecordError(targetServiceName)
-		} else {
-			circuitBreaker.Success()
 		}
 	}
 }
 
 func (s *Server) Run() error {
-	return s.Router.Run(fmt.Sprintf(":%d", s.Config.Port))
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.Config.Port),
+		Handler:           s.Router,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		// WriteTimeout не задан: потоки событий открыты, пока клиент подключен
+	}
+	return server.ListenAndServe()
 }
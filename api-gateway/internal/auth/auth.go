@@ -0,0 +1,206 @@
+// Package auth проверяет JWT на шлюзе. Токены подписываются общим с сервисами секретом
+// (HS256/384/512) или ключами из файла JWKS (RS*, PS*, ES*); проверенные ID пользователя
+// и роли передаются сервисам в заголовках X-User-ID и X-User-Roles.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Заголовки с проверенной личностью пользователя. Шлюз удаляет их из входящих запросов,
+// поэтому сервис может им доверять, только если запросы к нему идут через шлюз.
+const (
+	HeaderUserID    = "X-User-ID"
+	HeaderUserRoles = "X-User-Roles"
+)
+
+// IdentityHeaders - все заголовки, которые выставляет шлюз
+var IdentityHeaders = []string{HeaderUserID, HeaderUserRoles}
+
+// Config - источники ключей и требования к токену
+type Config struct {
+	// Secret - общий с сервисами секрет для HS256/384/512
+	Secret string
+	// JWKSFile - файл JWKS с открытыми ключами RSA и EC; ключи oct используются как секреты HMAC
+	JWKSFile string
+	// Issuer и Audience проверяются, если заданы
+	Issuer   string
+	Audience string
+	// UserIDClaim - утверждение с ID пользователя, по умолчанию sub
+	UserIDClaim string
+	// RolesClaim - утверждение с ролями: массив строк или строка через запятую, по умолчанию roles
+	RolesClaim string
+	// Leeway - допустимое расхождение часов при проверке exp, nbf и iat
+	Leeway time.Duration
+}
+
+// Identity - пользователь из проверенного токена
+type Identity struct {
+	UserID string
+	Roles  []string
+}
+
+// Verifier проверяет подпись и срок действия токенов
+type Verifier struct {
+	config Config
+	parser *jwt.Parser
+	hmac   [][]byte
+	keys   *keySet
+}
+
+var (
+	// ErrNoKeys - в конфигурации нет ни секрета, ни файла JWKS
+	ErrNoKeys = errors.New("jwt secret or jwks file is required")
+	// ErrNoUserID - в токене нет ID пользователя
+	ErrNoUserID = errors.New("token has no user id")
+)
+
+// validValue - ID и роли с другими символами отбрасываются: значения уходят в заголовки запроса
+var validValue = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// NewVerifier загружает ключи и готовит проверку
+func NewVerifier(config Config) (*Verifier, error) {
+	if config.UserIDClaim == "" {
+		config.UserIDClaim = "sub"
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = "roles"
+	}
+
+	v := &Verifier{config: config, keys: &keySet{}}
+	if config.Secret != "" {
+		v.hmac = append(v.hmac, []byte(config.Secret))
+	}
+	if config.JWKSFile != "" {
+		keys, err := loadJWKS(config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+		v.hmac = append(v.hmac, keys.secrets...)
+	}
+	if len(v.hmac) == 0 && v.keys.empty() {
+		return nil, ErrNoKeys
+	}
+
+	var methods []string
+	if len(v.hmac) > 0 {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if len(v.keys.rsa) > 0 {
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512")
+	}
+	if len(v.keys.ecdsa) > 0 {
+		methods = append(methods, "ES256", "ES384", "ES512")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+		jwt.WithIssuedAt(),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	v.parser = jwt.NewParser(options...)
+	return v, nil
+}
+
+// Verify проверяет токен и возвращает пользователя
+func (v *Verifier) Verify(tokenString string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	var err error
+	// Секретов HMAC может быть несколько: из конфигурации и из JWKS без kid
+	for _, secret := range v.secrets() {
+		_, err = v.parser.ParseWithClaims(tokenString, claims, v.keyFunc(secret))
+		if err == nil || !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+
+	userID := claimString(claims[v.config.UserIDClaim])
+	if userID == "" {
+		return Identity{}, ErrNoUserID
+	}
+	return Identity{UserID: userID, Roles: claimRoles(claims[v.config.RolesClaim])}, nil
+}
+
+// secrets возвращает секреты HMAC для перебора; без них токен проверяется один раз ключами JWKS
+func (v *Verifier) secrets() [][]byte {
+	if len(v.hmac) == 0 {
+		return [][]byte{nil}
+	}
+	return v.hmac
+}
+
+func (v *Verifier) keyFunc(secret []byte) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if secret == nil {
+				return nil, errors.New("no hmac secret configured")
+			}
+			return secret, nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return v.keys.rsaKey(kid)
+		case *jwt.SigningMethodECDSA:
+			return v.keys.ecdsaKey(kid)
+		}
+		return nil, fmt.Errorf("unsupported signing method %s", token.Method.Alg())
+	}
+}
+
+// RolesHeader возвращает значение заголовка X-User-Roles
+func (i Identity) RolesHeader() string {
+	return strings.Join(i.Roles, ",")
+}
+
+func claimString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if validValue.MatchString(v) {
+			return v
+		}
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func claimRoles(value interface{}) []string {
+	var raw []string
+	switch v := value.(type) {
+	case string:
+		raw = strings.Split(v, ",")
+	case []interface{}:
+		for _, item := range v {
+			if role, ok := item.(string); ok {
+				raw = append(raw, role)
+			}
+		}
+	}
+
+	roles := []string{}
+	for _, role := range raw {
+		role = strings.TrimSpace(role)
+		if validValue.MatchString(role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
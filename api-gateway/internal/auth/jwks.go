@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// keySet - открытые ключи из JWKS по kid. Ключ без kid подходит к токенам без kid.
+type keySet struct {
+	rsa     map[string]*rsa.PublicKey
+	ecdsa   map[string]*ecdsa.PublicKey
+	secrets [][]byte
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// loadJWKS читает ключи из файла JWKS. Ключи с use, отличным от sig, пропускаются.
+func loadJWKS(path string) (*keySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("parse jwks %s: %w", path, err)
+	}
+
+	set := &keySet{rsa: make(map[string]*rsa.PublicKey), ecdsa: make(map[string]*ecdsa.PublicKey)}
+	for i, key := range document.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if err := set.add(key); err != nil {
+			return nil, fmt.Errorf("jwks %s: key %d: %w", path, i, err)
+		}
+	}
+	if set.empty() {
+		return nil, fmt.Errorf("jwks %s: no signing keys", path)
+	}
+	return set, nil
+}
+
+func (s *keySet) add(key jsonWebKey) error {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(key.E)
+		if err != nil || !e.IsInt64() {
+			return errors.New("invalid e")
+		}
+		s.rsa[key.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return fmt.Errorf("unsupported curve %q", key.Crv)
+		}
+		x, err := decodeBigInt(key.X)
+		if err != nil {
+			return fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(key.Y)
+		if err != nil {
+			return fmt.Errorf("y: %w", err)
+		}
+		s.ecdsa[key.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(key.K)
+		if err != nil || len(secret) == 0 {
+			return errors.New("invalid k")
+		}
+		s.secrets = append(s.secrets, secret)
+	default:
+		return fmt.Errorf("unsupported key type %q", key.Kty)
+	}
+	return nil
+}
+
+func (s *keySet) empty() bool {
+	return len(s.rsa) == 0 && len(s.ecdsa) == 0 && len(s.secrets) == 0
+}
+
+func (s *keySet) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if key, ok := s.rsa[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown rsa key %q", kid)
+}
+
+func (s *keySet) ecdsaKey(kid string) (*ecdsa.PublicKey, error) {
+	if key, ok := s.ecdsa[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown ec key %q", kid)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid base64url value")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
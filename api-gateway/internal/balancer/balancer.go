@@ -0,0 +1,235 @@
+// Package balancer выбирает экземпляр сервиса для запроса: стратегия балансировки
+// распределяет запросы, а экземпляры с ошибками исключаются из выбора на время.
+package balancer
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoInstances - у сервиса нет экземпляров, которые еще не пробовали в этом запросе
+var ErrNoInstances = errors.New("no service instances available")
+
+// Instance - экземпляр сервиса из реестра
+type Instance struct {
+	URL string
+	// HealthCheckURL - адрес активной проверки; пустой - экземпляр не проверяется
+	HealthCheckURL string
+	// Weight - доля запросов для стратегии weighted; 0 означает 1
+	Weight int
+}
+
+// Endpoint - экземпляр с текущим состоянием: запросы в работе, сбои, исключение
+type Endpoint struct {
+	Instance
+
+	outstanding int64
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	ejections           int
+	ejectedUntil        time.Time
+	// unhealthy выставляет активная проверка
+	unhealthy     bool
+	checkFailures int
+	checkSuccess  int
+	// currentWeight - состояние плавного взвешенного выбора
+	currentWeight int
+}
+
+// Outstanding возвращает число запросов к экземпляру, ответ на которые еще не дочитан
+func (e *Endpoint) Outstanding() int64 {
+	return atomic.LoadInt64(&e.outstanding)
+}
+
+// Acquire отмечает начало запроса к экземпляру, возвращенная функция - его окончание
+func (e *Endpoint) Acquire() (release func()) {
+	atomic.AddInt64(&e.outstanding, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { atomic.AddInt64(&e.outstanding, -1) })
+	}
+}
+
+func (e *Endpoint) weight() int {
+	if e.Weight <= 0 {
+		return 1
+	}
+	return e.Weight
+}
+
+func (e *Endpoint) available(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !e.unhealthy && !now.Before(e.ejectedUntil)
+}
+
+// EjectionPolicy - когда исключать экземпляр по ошибкам проксирования
+type EjectionPolicy struct {
+	// ConsecutiveFailures - после скольких сбоев подряд экземпляр исключается; 0 - не исключать
+	ConsecutiveFailures int
+	// BaseTime - время первого исключения; каждое следующее подряд вдвое дольше
+	BaseTime time.Duration
+	// MaxTime ограничивает время исключения
+	MaxTime time.Duration
+}
+
+// DefaultEjectionPolicy - 5 сбоев подряд, исключение от 30 секунд до 5 минут
+var DefaultEjectionPolicy = EjectionPolicy{ConsecutiveFailures: 5, BaseTime: 30 * time.Second, MaxTime: 5 * time.Minute}
+
+// EndpointStatus - состояние экземпляра для администраторов и метрик
+type EndpointStatus struct {
+	URL          string    `json:"url"`
+	Weight       int       `json:"weight"`
+	Available    bool      `json:"available"`
+	Healthy      bool      `json:"healthy"`
+	Outstanding  int64     `json:"outstanding"`
+	Failures     int       `json:"consecutive_failures"`
+	EjectedUntil time.Time `json:"ejected_until,omitempty"`
+}
+
+// Balancer хранит экземпляры одного сервиса. Методы безопасны для параллельного вызова.
+type Balancer struct {
+	strategy Strategy
+	policy   EjectionPolicy
+	now      func() time.Time
+
+	mu        sync.RWMutex
+	endpoints []*Endpoint
+}
+
+// New создает балансировщик со стратегией strategy
+func New(strategy Strategy, policy EjectionPolicy) *Balancer {
+	return &Balancer{strategy: strategy, policy: policy, now: time.Now}
+}
+
+// Update заменяет список экземпляров. Состояние экземпляров, оставшихся в списке, сохраняется,
+// поэтому обновление реестра не сбрасывает исключения и очередь выбора.
+func (b *Balancer) Update(instances []Instance) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	existing := make(map[string]*Endpoint, len(b.endpoints))
+	for _, endpoint := range b.endpoints {
+		existing[endpoint.URL] = endpoint
+	}
+	endpoints := make([]*Endpoint, 0, len(instances))
+	seen := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		if instance.URL == "" || seen[instance.URL] {
+			continue
+		}
+		seen[instance.URL] = true
+		if endpoint, ok := existing[instance.URL]; ok {
+			endpoint.HealthCheckURL = instance.HealthCheckURL
+			endpoint.Weight = instance.Weight
+			endpoints = append(endpoints, endpoint)
+			continue
+		}
+		endpoints = append(endpoints, &Endpoint{Instance: instance})
+	}
+	// Порядок не зависит от ответа реестра: round-robin и хеширование устойчивы между обновлениями
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].URL < endpoints[j].URL })
+	b.endpoints = endpoints
+	b.strategy.Update(endpoints)
+}
+
+// Endpoints возвращает текущие экземпляры
+func (b *Balancer) Endpoints() []*Endpoint {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]*Endpoint(nil), b.endpoints...)
+}
+
+// Next выбирает экземпляр для запроса с ключом key (пользователь для consistent_hash),
+// пропуская уже опробованные в этом запросе. Если доступных экземпляров не осталось,
+// выбор идет среди исключенных: лучше попытка к сбоящему экземпляру, чем отказ без попытки.
+func (b *Balancer) Next(key string, tried map[string]bool) (*Endpoint, error) {
+	b.mu.RLock()
+	endpoints := b.endpoints
+	b.mu.RUnlock()
+
+	now := b.now()
+	candidates := make([]*Endpoint, 0, len(endpoints))
+	fallback := make([]*Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if tried[endpoint.URL] {
+			continue
+		}
+		fallback = append(fallback, endpoint)
+		if endpoint.available(now) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = fallback
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoInstances
+	}
+	return b.strategy.Pick(candidates, key), nil
+}
+
+// ReportSuccess сбрасывает счетчик сбоев экземпляра
+func (b *Balancer) ReportSuccess(endpoint *Endpoint) {
+	endpoint.mu.Lock()
+	defer endpoint.mu.Unlock()
+	endpoint.consecutiveFailures = 0
+	if !b.now().Before(endpoint.ejectedUntil) {
+		endpoint.ejections = 0
+	}
+}
+
+// ReportFailure засчитывает сбой запроса к экземпляру и исключает его по политике.
+// Возвращает true, если экземпляр исключен этим вызовом.
+func (b *Balancer) ReportFailure(endpoint *Endpoint) bool {
+	if b.policy.ConsecutiveFailures <= 0 {
+		return false
+	}
+	endpoint.mu.Lock()
+	defer endpoint.mu.Unlock()
+	now := b.now()
+	if now.Before(endpoint.ejectedUntil) {
+		return false
+	}
+	endpoint.consecutiveFailures++
+	if endpoint.consecutiveFailures < b.policy.ConsecutiveFailures {
+		return false
+	}
+
+	duration := b.policy.BaseTime << endpoint.ejections
+	if duration <= 0 || (b.policy.MaxTime > 0 && duration > b.policy.MaxTime) {
+		duration = b.policy.MaxTime
+	}
+	endpoint.ejectedUntil = now.Add(duration)
+	endpoint.ejections++
+	endpoint.consecutiveFailures = 0
+	return true
+}
+
+// Status возвращает состояние экземпляров
+func (b *Balancer) Status() []EndpointStatus {
+	now := b.now()
+	endpoints := b.Endpoints()
+	status := make([]EndpointStatus, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		endpoint.mu.Lock()
+		s := EndpointStatus{
+			URL:         endpoint.URL,
+			Weight:      endpoint.weight(),
+			Available:   !endpoint.unhealthy && !now.Before(endpoint.ejectedUntil),
+			Healthy:     !endpoint.unhealthy,
+			Outstanding: endpoint.Outstanding(),
+			Failures:    endpoint.consecutiveFailures,
+		}
+		if now.Before(endpoint.ejectedUntil) {
+			s.EjectedUntil = endpoint.ejectedUntil
+		}
+		endpoint.mu.Unlock()
+		status = append(status, s)
+	}
+	return status
+}
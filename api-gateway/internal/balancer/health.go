@@ -0,0 +1,105 @@
+package balancer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheckPolicy - активная проверка экземпляров по их HealthCheckURL
+type HealthCheckPolicy struct {
+	Timeout time.Duration
+	// UnhealthyThreshold - после скольких неудачных проверок подряд экземпляр выводится из выбора
+	UnhealthyThreshold int
+	// HealthyThreshold - после скольких успешных проверок подряд экземпляр возвращается
+	HealthyThreshold int
+}
+
+// HealthChange - экземпляр сменил состояние по результатам проверки
+type HealthChange struct {
+	URL     string
+	Healthy bool
+	Err     error
+}
+
+// CheckHealth проверяет параллельно все экземпляры с HealthCheckURL. Экземпляр здоров,
+// если проверка ответила 2xx. Возвращает экземпляры, сменившие состояние.
+func (b *Balancer) CheckHealth(ctx context.Context, client *http.Client, policy HealthCheckPolicy) []HealthChange {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		changes []HealthChange
+	)
+	for _, endpoint := range b.Endpoints() {
+		if endpoint.HealthCheckURL == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+			err := probe(ctx, client, endpoint.HealthCheckURL, policy.Timeout)
+			if changed, healthy := endpoint.recordCheck(err == nil, policy); changed {
+				mu.Lock()
+				changes = append(changes, HealthChange{URL: endpoint.URL, Healthy: healthy, Err: err})
+				mu.Unlock()
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+	return changes
+}
+
+func probe(ctx context.Context, client *http.Client, url string, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// StatusError - проверка здоровья ответила не 2xx
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return "health check responded " + http.StatusText(e.StatusCode)
+}
+
+// recordCheck засчитывает результат проверки и сообщает, сменилось ли состояние
+func (e *Endpoint) recordCheck(ok bool, policy HealthCheckPolicy) (changed bool, healthy bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if ok {
+		e.checkFailures = 0
+		e.checkSuccess++
+		if e.unhealthy && e.checkSuccess >= max(policy.HealthyThreshold, 1) {
+			e.unhealthy = false
+			return true, true
+		}
+		return false, !e.unhealthy
+	}
+	e.checkSuccess = 0
+	e.checkFailures++
+	if !e.unhealthy && e.checkFailures >= max(policy.UnhealthyThreshold, 1) {
+		e.unhealthy = true
+		return true, false
+	}
+	return false, !e.unhealthy
+}
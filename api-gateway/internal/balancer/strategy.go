@@ -0,0 +1,162 @@
+package balancer
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Названия стратегий для настроек load_balancing
+const (
+	StrategyRoundRobin       = "round_robin"
+	StrategyLeastOutstanding = "least_outstanding"
+	StrategyWeighted         = "weighted"
+	StrategyConsistentHash   = "consistent_hash"
+)
+
+// Strategy выбирает экземпляр из доступных. Update получает полный список экземпляров сервиса,
+// Pick - непустое подмножество этого списка в том же порядке.
+type Strategy interface {
+	Update(endpoints []*Endpoint)
+	Pick(candidates []*Endpoint, key string) *Endpoint
+}
+
+// NewStrategy создает стратегию по названию; пустое название означает round_robin
+func NewStrategy(name string) (Strategy, error) {
+	switch strings.ToLower(name) {
+	case "", StrategyRoundRobin:
+		return &roundRobin{}, nil
+	case StrategyLeastOutstanding:
+		return &leastOutstanding{}, nil
+	case StrategyWeighted:
+		return &weighted{}, nil
+	case StrategyConsistentHash:
+		return newConsistentHash(), nil
+	}
+	return nil, fmt.Errorf("unknown load balancing strategy %q", name)
+}
+
+// roundRobin выбирает экземпляры по очереди
+type roundRobin struct {
+	next uint64
+}
+
+func (r *roundRobin) Update([]*Endpoint) {}
+
+func (r *roundRobin) Pick(candidates []*Endpoint, _ string) *Endpoint {
+	n := atomic.AddUint64(&r.next, 1) - 1
+	return candidates[n%uint64(len(candidates))]
+}
+
+// leastOutstanding выбирает экземпляр с наименьшим числом запросов в работе.
+// При равенстве выбор идет по очереди, чтобы простаивающие экземпляры нагружались поровну.
+type leastOutstanding struct {
+	next uint64
+}
+
+func (l *leastOutstanding) Update([]*Endpoint) {}
+
+func (l *leastOutstanding) Pick(candidates []*Endpoint, _ string) *Endpoint {
+	start := int(atomic.AddUint64(&l.next, 1) % uint64(len(candidates)))
+	best := candidates[start]
+	for i := 1; i < len(candidates); i++ {
+		endpoint := candidates[(start+i)%len(candidates)]
+		if endpoint.Outstanding() < best.Outstanding() {
+			best = endpoint
+		}
+	}
+	return best
+}
+
+// weighted - плавный взвешенный round-robin: экземпляр с весом 3 получает три запроса из четырех
+// рядом с экземпляром веса 1, но не три подряд
+type weighted struct {
+	mu sync.Mutex
+}
+
+func (w *weighted) Update([]*Endpoint) {}
+
+func (w *weighted) Pick(candidates []*Endpoint, _ string) *Endpoint {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	total := 0
+	var best *Endpoint
+	for _, endpoint := range candidates {
+		endpoint.mu.Lock()
+		endpoint.currentWeight += endpoint.weight()
+		total += endpoint.weight()
+		if best == nil || endpoint.currentWeight > best.currentWeight {
+			best = endpoint
+		}
+		endpoint.mu.Unlock()
+	}
+	best.mu.Lock()
+	best.currentWeight -= total
+	best.mu.Unlock()
+	return best
+}
+
+// virtualNodes - точек на кольце у каждого экземпляра: без них ключи распределяются неравномерно
+const virtualNodes = 100
+
+type ringPoint struct {
+	hash     uint32
+	endpoint *Endpoint
+}
+
+// consistentHash направляет запросы одного ключа (пользователя) на один экземпляр.
+// При исключении экземпляра на другие уходят только его ключи. Без ключа - round-robin.
+type consistentHash struct {
+	mu       sync.RWMutex
+	ring     []ringPoint
+	fallback roundRobin
+}
+
+func newConsistentHash() *consistentHash {
+	return &consistentHash{}
+}
+
+func (h *consistentHash) Update(endpoints []*Endpoint) {
+	ring := make([]ringPoint, 0, len(endpoints)*virtualNodes)
+	for _, endpoint := range endpoints {
+		for i := 0; i < virtualNodes; i++ {
+			ring = append(ring, ringPoint{hash: hashKey(endpoint.URL + "#" + strconv.Itoa(i)), endpoint: endpoint})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+
+	h.mu.Lock()
+	h.ring = ring
+	h.mu.Unlock()
+}
+
+func (h *consistentHash) Pick(candidates []*Endpoint, key string) *Endpoint {
+	if key == "" {
+		return h.fallback.Pick(candidates, key)
+	}
+	allowed := make(map[*Endpoint]bool, len(candidates))
+	for _, endpoint := range candidates {
+		allowed[endpoint] = true
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	hash := hashKey(key)
+	start := sort.Search(len(h.ring), func(i int) bool { return h.ring[i].hash >= hash })
+	for i := 0; i < len(h.ring); i++ {
+		point := h.ring[(start+i)%len(h.ring)]
+		if allowed[point.endpoint] {
+			return point.endpoint
+		}
+	}
+	return h.fallback.Pick(candidates, key)
+}
+
+func hashKey(key string) uint32 {
+	return crc32.ChecksumIEEE([]byte(key))
+}
@@ -1,6 +1,9 @@
+// Package circuitbreaker останавливает запросы к экземпляру сервиса, доля сбоев которого
+// превысила порог, и после паузы пропускает ограниченное число пробных запросов.
 package circuitbreaker
 
 import (
+	"errors"
 	"sync"
 	"time"
 )
@@ -13,91 +16,302 @@ const (
 	HalfOpen
 )
 
+// States - все состояния по порядку значений
+var States = []State{Closed, Open, HalfOpen}
+
+// String возвращает название состояния для журналов и метрик
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	}
+	return "unknown"
+}
+
+// ErrOpen - выключатель не пропускает запрос
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Result - исход разрешенного запроса
+type Result int
+
+const (
+	Success Result = iota
+	Failure
+	// Ignored - запрос не говорит о здоровье экземпляра, например его отменил клиент
+	Ignored
+)
+
+// Policy - когда размыкать выключатель
+type Policy struct {
+	// Window - за какой период считается доля сбоев
+	Window time.Duration
+	// FailureRate - доля сбоев от 0 до 1, при которой выключатель размыкается
+	FailureRate float64
+	// MinRequests - меньше запросов за окно недостаточно, чтобы судить о доле сбоев
+	MinRequests int
+	// HalfOpenRequests - сколько пробных запросов пропускается одновременно в полуоткрытом
+	// состоянии; столько же успехов подряд замыкают выключатель
+	HalfOpenRequests int
+	// Timeout - сколько выключатель разомкнут до первых пробных запросов
+	Timeout time.Duration
+}
+
+// DefaultPolicy - размыкание при половине сбоев из не менее 5 запросов за 30 секунд,
+// один пробный запрос через 30 секунд
+var DefaultPolicy = Policy{
+	Window:           30 * time.Second,
+	FailureRate:      0.5,
+	MinRequests:      5,
+	HalfOpenRequests: 1,
+	Timeout:          30 * time.Second,
+}
+
+// Transition - смена состояния выключателя
+type Transition struct {
+	Service  string
+	Instance string
+	From     State
+	To       State
+	// Reason - почему сменилось состояние: failure_rate, timeout, probe_failed, probes_succeeded, forced, reset
+	Reason string
+}
+
+// Status - состояние выключателя для администраторов и метрик
+type Status struct {
+	Service     string    `json:"service"`
+	Instance    string    `json:"instance"`
+	State       string    `json:"state"`
+	Forced      bool      `json:"forced"`
+	Requests    int       `json:"requests"`
+	Failures    int       `json:"failures"`
+	FailureRate float64   `json:"failure_rate"`
+	Since       time.Time `json:"since"`
+}
+
+// CircuitBreaker - выключатель одного экземпляра сервиса. Методы безопасны для параллельного вызова.
 type CircuitBreaker struct {
-	mutex            sync.RWMutex
-	state            State
-	failureCount     int
-	failureThreshold int
-	resetTimeout     time.Duration
-	lastStateChange  time.Time
-	serviceName      string
+	service  string
+	instance string
+	policy   Policy
+	onChange func(Transition)
+	now      func() time.Time
+
+	mutex           sync.Mutex
+	state           State
+	forced          bool
+	lastStateChange time.Time
+	// generation меняется при каждой смене состояния: исходы запросов, начатых
+	// в прошлом состоянии, не учитываются
+	generation uint64
+	window     *window
+	probes     int
+	successes  int
 }
 
-func NewCircuitBreaker(serviceName string, failureThreshold int, resetTimeout time.Duration) *CircuitBreaker {
+// NewCircuitBreaker создает замкнутый выключатель экземпляра instance сервиса service.
+// onChange вызывается после каждой смены состояния, может быть nil.
+func NewCircuitBreaker(service, instance string, policy Policy, onChange func(Transition)) *CircuitBreaker {
+	if policy.HalfOpenRequests <= 0 {
+		policy.HalfOpenRequests = 1
+	}
 	return &CircuitBreaker{
-		state:            Closed,
-		failureCount:     0,
-		failureThreshold: failureThreshold,
-		resetTimeout:     resetTimeout,
-		lastStateChange:  time.Now(),
-		serviceName:      serviceName,
+		service:         service,
+		instance:        instance,
+		policy:          policy,
+		onChange:        onChange,
+		now:             time.Now,
+		state:           Closed,
+		lastStateChange: time.Now(),
+		window:          newWindow(policy.Window),
 	}
 }
 
-func (cb *CircuitBreaker) IsAllowed() bool {
-	cb.mutex.RLock()
-	defer cb.mutex.RUnlock()
+// Allow сообщает, можно ли отправить запрос. Разрешенный запрос завершается вызовом done
+// с его исходом; повторные вызовы done ничего не делают.
+func (cb *CircuitBreaker) Allow() (done func(Result), allowed bool) {
+	cb.mutex.Lock()
+	var transition *Transition
+	defer func() {
+		cb.mutex.Unlock()
+		cb.notify(transition)
+	}()
 
+	now := cb.now()
 	switch cb.state {
-	case Closed:
-		return true
 	case Open:
-		if time.Since(cb.lastStateChange) > cb.resetTimeout {
-			cb.mutex.RUnlock()
-			cb.mutex.Lock()
-			cb.state = HalfOpen
-			cb.lastStateChange = time.Now()
-			cb.mutex.Unlock()
-			cb.mutex.RLock()
-			return true
+		if cb.forced || now.Sub(cb.lastStateChange) < cb.policy.Timeout {
+			return nil, false
 		}
-		return false
+		transition = cb.setState(HalfOpen, "timeout")
+		fallthrough
 	case HalfOpen:
-		return true
-	default:
-		return true
+		if cb.probes >= cb.policy.HalfOpenRequests {
+			return nil, false
+		}
+		cb.probes++
 	}
+
+	generation := cb.generation
+	var once sync.Once
+	return func(result Result) {
+		once.Do(func() { cb.record(generation, result) })
+	}, true
 }
 
-func (cb *CircuitBreaker) Success() {
+func (cb *CircuitBreaker) record(generation uint64, result Result) {
 	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	var transition *Transition
+	defer func() {
+		cb.mutex.Unlock()
+		cb.notify(transition)
+	}()
+
+	if generation != cb.generation || cb.forced {
+		return
+	}
+	switch cb.state {
+	case Closed:
+		if result == Ignored {
+			return
+		}
+		now := cb.now()
+		cb.window.add(now, result == Failure)
+		requests, failures := cb.window.totals(now)
+		if requests >= cb.policy.MinRequests && requests > 0 &&
+			float64(failures)/float64(requests) >= cb.policy.FailureRate {
+			transition = cb.setState(Open, "failure_rate")
+		}
+	case HalfOpen:
+		cb.probes--
+		switch result {
+		case Failure:
+			transition = cb.setState(Open, "probe_failed")
+		case Success:
+			cb.successes++
+			if cb.successes >= cb.policy.HalfOpenRequests {
+				transition = cb.setState(Closed, "probes_succeeded")
+			}
+		}
+	}
+}
 
-	if cb.state == HalfOpen {
-		cb.state = Closed
-		cb.failureCount = 0
-		cb.lastStateChange = time.Now()
-	} else if cb.state == Closed {
-		cb.failureCount = 0
+// setState меняет состояние под mutex и возвращает переход для notify
+func (cb *CircuitBreaker) setState(state State, reason string) *Transition {
+	transition := &Transition{Service: cb.service, Instance: cb.instance, From: cb.state, To: state, Reason: reason}
+	cb.state = state
+	cb.lastStateChange = cb.now()
+	cb.generation++
+	cb.probes = 0
+	cb.successes = 0
+	if state == Closed {
+		cb.window = newWindow(cb.policy.Window)
 	}
+	return transition
 }
 
-func (cb *CircuitBreaker) Failure() {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+// notify вызывается без mutex: обработчик может читать состояние выключателя
+func (cb *CircuitBreaker) notify(transition *Transition) {
+	if transition != nil && cb.onChange != nil {
+		cb.onChange(*transition)
+	}
+}
 
-	if cb.state == HalfOpen {
-		cb.state = Open
-		cb.lastStateChange = time.Now()
-	} else if cb.state == Closed {
-		cb.failureCount++
-		if cb.failureCount >= cb.failureThreshold {
-			cb.state = Open
-			cb.lastStateChange = time.Now()
-		}
+// Force закрепляет состояние Open или Closed до вызова Reset: разомкнутый выключатель
+// не пропускает пробные запросы, замкнутый не размыкается по сбоям
+func (cb *CircuitBreaker) Force(state State) error {
+	if state != Open && state != Closed {
+		return errors.New("only open and closed states can be forced")
 	}
+	cb.mutex.Lock()
+	transition := cb.setState(state, "forced")
+	cb.forced = true
+	cb.mutex.Unlock()
+	cb.notify(transition)
+	return nil
+}
+
+// Reset замыкает выключатель и возвращает ему автоматическое управление
+func (cb *CircuitBreaker) Reset() {
+	cb.mutex.Lock()
+	transition := cb.setState(Closed, "reset")
+	cb.forced = false
+	cb.mutex.Unlock()
+	cb.notify(transition)
 }
 
 func (cb *CircuitBreaker) GetState() State {
-	cb.mutex.RLock()
-	defer cb.mutex.RUnlock()
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
 	return cb.state
 }
 
-func (cb *CircuitBreaker) Reset() {
+// Status возвращает состояние выключателя и счетчики текущего окна
+func (cb *CircuitBreaker) Status() Status {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
-	cb.state = Closed
-	cb.failureCount = 0
-	cb.lastStateChange = time.Now()
+	requests, failures := cb.window.totals(cb.now())
+	status := Status{
+		Service:  cb.service,
+		Instance: cb.instance,
+		State:    cb.state.String(),
+		Forced:   cb.forced,
+		Requests: requests,
+		Failures: failures,
+		Since:    cb.lastStateChange,
+	}
+	if requests > 0 {
+		status.FailureRate = float64(failures) / float64(requests)
+	}
+	return status
+}
+
+// windowBuckets - на сколько интервалов делится окно: сбои выходят из окна по частям
+const windowBuckets = 10
+
+type bucket struct {
+	// stamp - номер интервала, к которому относятся счетчики
+	stamp    int64
+	requests int
+	failures int
+}
+
+// window считает запросы и сбои за последний период скользящим окном из интервалов
+type window struct {
+	width   int64
+	buckets [windowBuckets]bucket
+}
+
+func newWindow(size time.Duration) *window {
+	width := int64(size) / windowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &window{width: width}
+}
+
+func (w *window) add(now time.Time, failed bool) {
+	stamp := now.UnixNano() / w.width
+	b := &w.buckets[stamp%windowBuckets]
+	if b.stamp != stamp {
+		*b = bucket{stamp: stamp}
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+}
+
+func (w *window) totals(now time.Time) (requests, failures int) {
+	stamp := now.UnixNano() / w.width
+	for _, b := range w.buckets {
+		if b.stamp > stamp-windowBuckets && b.stamp <= stamp {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	return requests, failures
 }
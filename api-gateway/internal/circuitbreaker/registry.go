@@ -0,0 +1,92 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type key struct {
+	service  string
+	instance string
+}
+
+// Registry хранит выключатели экземпляров всех сервисов и создает их при первом запросе
+type Registry struct {
+	policy   func(service string) Policy
+	onChange func(Transition)
+
+	mu       sync.RWMutex
+	breakers map[key]*CircuitBreaker
+}
+
+// NewRegistry создает реестр; policy возвращает политику сервиса, onChange получает
+// смены состояния всех выключателей и может быть nil
+func NewRegistry(policy func(service string) Policy, onChange func(Transition)) *Registry {
+	if policy == nil {
+		policy = func(string) Policy { return DefaultPolicy }
+	}
+	return &Registry{policy: policy, onChange: onChange, breakers: make(map[key]*CircuitBreaker)}
+}
+
+// Get возвращает выключатель экземпляра, создавая его
+func (r *Registry) Get(service, instance string) *CircuitBreaker {
+	k := key{service: service, instance: instance}
+	r.mu.RLock()
+	breaker, ok := r.breakers[k]
+	r.mu.RUnlock()
+	if ok {
+		return breaker
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if breaker, ok = r.breakers[k]; !ok {
+		breaker = NewCircuitBreaker(service, instance, r.policy(service), r.onChange)
+		r.breakers[k] = breaker
+	}
+	return breaker
+}
+
+// Prune удаляет выключатели экземпляров сервиса, которых нет среди instances, например
+// после того как экземпляр пропал из реестра или вернулся с другим портом. Возвращает
+// число удаленных выключателей.
+func (r *Registry) Prune(service string, instances []string) int {
+	keep := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		keep[instance] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	removed := 0
+	for k := range r.breakers {
+		if strings.EqualFold(k.service, service) && !keep[k.instance] {
+			delete(r.breakers, k)
+			removed++
+		}
+	}
+	return removed
+}
+
+// List возвращает состояние всех выключателей по сервисам и экземплярам
+func (r *Registry) List() []Status {
+	r.mu.RLock()
+	breakers := make([]*CircuitBreaker, 0, len(r.breakers))
+	for _, breaker := range r.breakers {
+		breakers = append(breakers, breaker)
+	}
+	r.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(breakers))
+	for _, breaker := range breakers {
+		statuses = append(statuses, breaker.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Service != statuses[j].Service {
+			return statuses[i].Service < statuses[j].Service
+		}
+		return statuses[i].Instance < statuses[j].Instance
+	})
+	return statuses
+}
@@ -0,0 +1,314 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"lmsmodule/api-gateway/internal/balancer"
+	"lmsmodule/api-gateway/internal/utils"
+	registry "lmsmodule/api-gateway/pkg/discovery"
+	"lmsmodule/api-gateway/pkg/logger"
+)
+
+type ServiceDiscovery struct {
+	provider       registry.Discovery
+	services       map[string][]string
+	servicesMutex  sync.RWMutex
+	fallbackConfig *utils.Config
+	logger         *logger.Logger
+	// balancers - экземпляры сервисов с состоянием балансировки; защищены servicesMutex
+	balancers map[string]*balancer.Balancer
+	// fallbacks - балансировщики адресов из конфигурации для сервисов, не найденных в реестре
+	fallbacks map[string]*balancer.Balancer
+	// onUpdate получает имена сервисов после обновления их экземпляров; защищен servicesMutex
+	onUpdate func(serviceName string)
+}
+
+func NewServiceDiscovery(config *utils.Config, logger *logger.Logger) (*ServiceDiscovery, error) {
+	provider, err := registry.New(config.DiscoveryConfig())
+	if err != nil {
+		return nil, err
+	}
+	sd := &ServiceDiscovery{
+		provider:       provider,
+		services:       make(map[string][]string),
+		fallbackConfig: config,
+		logger:         logger,
+		balancers:      make(map[string]*balancer.Balancer),
+		fallbacks:      make(map[string]*balancer.Balancer),
+	}
+
+	go sd.refreshServices()
+	if config.LoadBalancing.HealthCheck.Enabled {
+		go sd.checkHealth()
+	}
+
+	return sd, nil
+}
+
+// Provider возвращает реестр, через который шлюз регистрирует себя
+func (sd *ServiceDiscovery) Provider() registry.Discovery {
+	return sd.provider
+}
+
+// refreshServices опрашивает реестр раз в refresh_interval, а реестр, который сам сообщает
+// об изменениях (файл), - еще и после каждого изменения
+func (sd *ServiceDiscovery) refreshServices() {
+	var changes <-chan struct{}
+	if watcher, ok := sd.provider.(registry.Watcher); ok {
+		changes = watcher.Watch(context.Background())
+	}
+	ticker := time.NewTicker(sd.fallbackConfig.Discovery.RefreshDuration())
+	defer ticker.Stop()
+
+	for {
+		sd.refresh()
+		select {
+		case <-ticker.C:
+		case <-changes:
+		}
+	}
+}
+
+func (sd *ServiceDiscovery) refresh() {
+	services, err := sd.provider.Instances(context.Background())
+	if err != nil {
+		sd.logger.Error("Failed to get service instances from discovery: %v", err)
+		return
+	}
+
+	newServices := make(map[string][]balancer.Instance, len(services))
+	for serviceName, instances := range services {
+		for _, instance := range instances {
+			sd.logger.Debug("Discovered instance of %s at %s", serviceName, instance.URL)
+		}
+		newServices[serviceName] = balancerInstances(instances)
+	}
+	sd.setServices(newServices)
+}
+
+func balancerInstances(instances []registry.Instance) []balancer.Instance {
+	result := make([]balancer.Instance, 0, len(instances))
+	for _, instance := range instances {
+		result = append(result, balancer.Instance{
+			URL:            instance.URL,
+			HealthCheckURL: instance.HealthCheckURL,
+			Weight:         instance.Weight,
+		})
+	}
+	return result
+}
+
+// setServices заменяет экземпляры всех сервисов. Балансировщики сервисов, оставшихся в реестре,
+// сохраняют состояние экземпляров: исключения и счетчики запросов.
+func (sd *ServiceDiscovery) setServices(instances map[string][]balancer.Instance) {
+	sd.servicesMutex.Lock()
+	sd.services = make(map[string][]string, len(instances))
+	for serviceName, serviceInstances := range instances {
+		for _, instance := range serviceInstances {
+			sd.services[serviceName] = append(sd.services[serviceName], instance.URL)
+		}
+		sd.balancerLocked(sd.balancers, serviceName).Update(serviceInstances)
+	}
+	updated := make([]string, 0, len(sd.balancers))
+	for serviceName, lb := range sd.balancers {
+		if _, ok := instances[serviceName]; !ok {
+			lb.Update(nil)
+		}
+		updated = append(updated, serviceName)
+	}
+	onUpdate := sd.onUpdate
+	sd.servicesMutex.Unlock()
+
+	if onUpdate != nil {
+		for _, serviceName := range updated {
+			onUpdate(serviceName)
+		}
+	}
+}
+
+// SetServiceInstances заменяет экземпляры одного сервиса, например заданные вручную
+func (sd *ServiceDiscovery) SetServiceInstances(serviceName string, instances []balancer.Instance) {
+	serviceName = strings.ToUpper(serviceName)
+
+	sd.servicesMutex.Lock()
+
+	urls := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		urls = append(urls, instance.URL)
+	}
+	sd.services[serviceName] = urls
+	sd.balancerLocked(sd.balancers, serviceName).Update(instances)
+	onUpdate := sd.onUpdate
+	sd.servicesMutex.Unlock()
+
+	if onUpdate != nil {
+		onUpdate(serviceName)
+	}
+}
+
+// OnUpdate задает функцию, которую реестр вызывает для каждого сервиса после обновления
+// его экземпляров
+func (sd *ServiceDiscovery) OnUpdate(fn func(serviceName string)) {
+	sd.servicesMutex.Lock()
+	defer sd.servicesMutex.Unlock()
+	sd.onUpdate = fn
+}
+
+// ActiveInstances возвращает адреса экземпляров, между которыми сейчас распределяются запросы
+// к сервису: найденных в реестре, а без них - из discovery.static
+func (sd *ServiceDiscovery) ActiveInstances(serviceName string) []string {
+	serviceName = strings.ToUpper(serviceName)
+
+	sd.servicesMutex.RLock()
+	lb, ok := sd.balancers[serviceName]
+	sd.servicesMutex.RUnlock()
+
+	var urls []string
+	if ok {
+		for _, endpoint := range lb.Endpoints() {
+			urls = append(urls, endpoint.URL)
+		}
+	}
+	if len(urls) > 0 {
+		return urls
+	}
+	for _, instance := range sd.fallbackConfig.Discovery.StaticInstances(serviceName) {
+		urls = append(urls, instance.URL)
+	}
+	return urls
+}
+
+// balancerLocked возвращает балансировщик сервиса из balancers, создавая его по настройкам
+// load_balancing. Вызывается под servicesMutex.
+func (sd *ServiceDiscovery) balancerLocked(balancers map[string]*balancer.Balancer, serviceName string) *balancer.Balancer {
+	if lb, ok := balancers[serviceName]; ok {
+		return lb
+	}
+	config := sd.fallbackConfig.LoadBalancing
+	strategy, err := balancer.NewStrategy(config.StrategyFor(serviceName))
+	if err != nil {
+		// Стратегии проверяются при загрузке конфигурации
+		sd.logger.Error("Invalid load balancing strategy for %s: %v", serviceName, err)
+		strategy, _ = balancer.NewStrategy(balancer.StrategyRoundRobin)
+	}
+	lb := balancer.New(strategy, config.Ejection.EjectionPolicy())
+	balancers[serviceName] = lb
+	return lb
+}
+
+// Balancer возвращает балансировщик экземпляров сервиса. Если реестр не знает экземпляров
+// сервиса, используются экземпляры из discovery.static; для неизвестного сервиса возвращает nil.
+func (sd *ServiceDiscovery) Balancer(serviceName string) *balancer.Balancer {
+	serviceName = strings.ToUpper(serviceName)
+
+	sd.servicesMutex.RLock()
+	lb, ok := sd.balancers[serviceName]
+	fallback, fallbackOK := sd.fallbacks[serviceName]
+	sd.servicesMutex.RUnlock()
+
+	if ok && len(lb.Endpoints()) > 0 {
+		return lb
+	}
+	if fallbackOK {
+		return fallback
+	}
+
+	instances := sd.fallbackConfig.Discovery.StaticInstances(serviceName)
+	if len(instances) == 0 {
+		sd.logger.Error("Unknown service requested: %s", serviceName)
+		return nil
+	}
+	sd.servicesMutex.Lock()
+	defer sd.servicesMutex.Unlock()
+	if fallback, ok := sd.fallbacks[serviceName]; ok {
+		return fallback
+	}
+	fallback = sd.balancerLocked(sd.fallbacks, serviceName)
+	fallback.Update(balancerInstances(instances))
+	return fallback
+}
+
+// GetServiceURL выбирает экземпляр сервиса стратегией балансировки
+func (sd *ServiceDiscovery) GetServiceURL(serviceName string) string {
+	lb := sd.Balancer(serviceName)
+	if lb == nil {
+		return ""
+	}
+	endpoint, err := lb.Next("", nil)
+	if err != nil {
+		return ""
+	}
+	return endpoint.URL
+}
+
+// BalancerStatus возвращает состояние экземпляров всех сервисов реестра
+func (sd *ServiceDiscovery) BalancerStatus() map[string][]balancer.EndpointStatus {
+	sd.servicesMutex.RLock()
+	defer sd.servicesMutex.RUnlock()
+
+	status := make(map[string][]balancer.EndpointStatus, len(sd.balancers))
+	for serviceName, lb := range sd.balancers {
+		if endpoints := lb.Status(); len(endpoints) > 0 {
+			status[serviceName] = endpoints
+		}
+	}
+	return status
+}
+
+// checkHealth периодически проверяет экземпляры сервисов по их HealthCheckUrl.
+// Адреса из конфигурации не проверяются: других экземпляров у сервиса все равно нет.
+func (sd *ServiceDiscovery) checkHealth() {
+	config := sd.fallbackConfig.LoadBalancing.HealthCheck
+	policy := config.Policy()
+	client := &http.Client{}
+	ticker := time.NewTicker(config.IntervalDuration())
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sd.servicesMutex.RLock()
+		balancers := make(map[string]*balancer.Balancer, len(sd.balancers))
+		for serviceName, lb := range sd.balancers {
+			balancers[serviceName] = lb
+		}
+		sd.servicesMutex.RUnlock()
+
+		for serviceName, lb := range balancers {
+			for _, change := range lb.CheckHealth(context.Background(), client, policy) {
+				if change.Healthy {
+					sd.logger.Info("Instance %s of %s passed health checks and is back in rotation", change.URL, serviceName)
+				} else {
+					sd.logger.Warn("Instance %s of %s failed health checks and is out of rotation: %v", change.URL, serviceName, change.Err)
+				}
+			}
+		}
+	}
+}
+
+func (sd *ServiceDiscovery) GetServiceInstances(serviceName string) []string {
+	serviceName = strings.ToUpper(serviceName)
+
+	sd.servicesMutex.RLock()
+	defer sd.servicesMutex.RUnlock()
+
+	if urls, ok := sd.services[serviceName]; ok {
+		return urls
+	}
+
+	return []string{}
+}
+
+func (sd *ServiceDiscovery) GetAllServices() map[string]int {
+	sd.servicesMutex.RLock()
+	defer sd.servicesMutex.RUnlock()
+
+	result := make(map[string]int)
+	for name, urls := range sd.services {
+		result[name] = len(urls)
+	}
+
+	return result
+}
@@ -1,98 +1,123 @@
 package metrics
 
 import (
-	"sync"
 	"time"
+
+	"lmsmodule/api-gateway/internal/circuitbreaker"
+	prom "lmsmodule/api-gateway/pkg/metrics"
 )
 
+// ServiceMetrics - метрики шлюза: входящие запросы по маршрутам и запросы к сервисам.
+// Значения накапливаются с запуска; длительности хранятся только корзинами гистограмм.
 type ServiceMetrics struct {
-	mutex           sync.RWMutex
-	requestCounts   map[string]int
-	responseTimes   map[string][]time.Duration
-	errorCounts     map[string]int
-	reportingPeriod time.Duration
-	lastResetTime   time.Time
+	Registry *prom.Registry
+	HTTP     *prom.HTTPMetrics
+
+	upstreamRequests *prom.CounterVec
+	upstreamErrors   *prom.CounterVec
+	upstreamDuration *prom.HistogramVec
+	upstreamRetries  *prom.CounterVec
+	ejections        *prom.CounterVec
+	// breakerTransitions - переходы выключателей по новому состоянию
+	breakerTransitions *prom.CounterVec
 }
 
 func NewServiceMetrics() *ServiceMetrics {
-	sm := &ServiceMetrics{
-		requestCounts:   make(map[string]int),
-		responseTimes:   make(map[string][]time.Duration),
-		errorCounts:     make(map[string]int),
-		reportingPeriod: 1 * time.Minute,
-		lastResetTime:   time.Now(),
+	registry := prom.NewRegistry()
+	return &ServiceMetrics{
+		Registry: registry,
+		HTTP:     prom.NewHTTPMetrics(registry, "gateway"),
+		upstreamRequests: registry.NewCounterVec("gateway_upstream_requests_total",
+			"Requests proxied to a service.", "service"),
+		upstreamErrors: registry.NewCounterVec("gateway_upstream_errors_total",
+			"Proxied requests that failed or returned 5xx.", "service"),
+		upstreamDuration: registry.NewHistogramVec("gateway_upstream_request_duration_seconds",
+			"Duration of proxied requests.", prom.DefaultBuckets, "service"),
+		upstreamRetries: registry.NewCounterVec("gateway_upstream_retries_total",
+			"Proxied requests retried on another instance.", "service"),
+		ejections: registry.NewCounterVec("gateway_upstream_ejections_total",
+			"Service instances ejected from load balancing after proxy errors.", "service"),
+		breakerTransitions: registry.NewCounterVec("gateway_circuit_breaker_transitions_total",
+			"Circuit breaker state changes by the new state.", "service", "instance", "state"),
 	}
-	go sm.periodicReset()
-	return sm
 }
 
 func (sm *ServiceMetrics) RecordRequest(serviceName string) {
-	sm.mutex.Lock()
-	defer sm.mutex.Unlock()
-
-	sm.requestCounts[serviceName]++
+	sm.upstreamRequests.Inc(serviceName)
 }
 
 func (sm *ServiceMetrics) RecordResponseTime(serviceName string, duration time.Duration) {
-	sm.mutex.Lock()
-	defer sm.mutex.Unlock()
-
-	sm.responseTimes[serviceName] = append(sm.responseTimes[serviceName], duration)
+	sm.upstreamDuration.Observe(duration.Seconds(), serviceName)
 }
 
 func (sm *ServiceMetrics) RecordError(serviceName string) {
-	sm.mutex.Lock()
-	defer sm.mutex.Unlock()
+	sm.upstreamErrors.Inc(serviceName)
+}
 
-	sm.errorCounts[serviceName]++
+// RecordRetry засчитывает повтор запроса на другом экземпляре
+func (sm *ServiceMetrics) RecordRetry(serviceName string) {
+	sm.upstreamRetries.Inc(serviceName)
 }
 
-func (sm *ServiceMetrics) GetRequestCount(serviceName string) int {
-	sm.mutex.RLock()
-	defer sm.mutex.RUnlock()
+// RecordEjection засчитывает исключение экземпляра сервиса
+func (sm *ServiceMetrics) RecordEjection(serviceName string) {
+	sm.ejections.Inc(serviceName)
+}
 
-	return sm.requestCounts[serviceName]
+func (sm *ServiceMetrics) GetRequestCount(serviceName string) int {
+	return int(sm.upstreamRequests.Value(serviceName))
 }
 
 func (sm *ServiceMetrics) GetAverageResponseTime(serviceName string) time.Duration {
-	sm.mutex.RLock()
-	defer sm.mutex.RUnlock()
-
-	times := sm.responseTimes[serviceName]
-	if len(times) == 0 {
+	count, sum := sm.upstreamDuration.Snapshot(serviceName)
+	if count == 0 {
 		return 0
 	}
-
-	var total time.Duration
-	for _, t := range times {
-		total += t
-	}
-
-	return total / time.Duration(len(times))
+	return time.Duration(sum / float64(count) * float64(time.Second))
 }
 
 func (sm *ServiceMetrics) GetErrorRate(serviceName string) float64 {
-	sm.mutex.RLock()
-	defer sm.mutex.RUnlock()
-
-	requests := sm.requestCounts[serviceName]
+	requests := sm.upstreamRequests.Value(serviceName)
 	if requests == 0 {
 		return 0
 	}
+	return sm.upstreamErrors.Value(serviceName) / requests
+}
 
-	errors := sm.errorCounts[serviceName]
-	return float64(errors) / float64(requests)
+// WatchCircuitBreakers экспортирует состояние выключателей: для каждого экземпляра ряд со
+// значением 1 у текущего состояния и 0 у остальных
+func (sm *ServiceMetrics) WatchCircuitBreakers(list func() []circuitbreaker.Status) {
+	sm.Registry.NewGaugeFunc("gateway_circuit_breaker_state",
+		"Circuit breaker state per service instance: 1 for the current state.", []string{"service", "instance", "state"},
+		func() []prom.Sample {
+			var samples []prom.Sample
+			for _, status := range list() {
+				for _, state := range circuitbreaker.States {
+					value := 0.0
+					if state.String() == status.State {
+						value = 1
+					}
+					samples = append(samples, prom.Sample{Labels: []string{status.Service, status.Instance, state.String()}, Value: value})
+				}
+			}
+			return samples
+		})
 }
 
-func (sm *ServiceMetrics) periodicReset() {
-	for {
-		time.Sleep(sm.reportingPeriod)
+// RecordBreakerTransition засчитывает переход выключателя в состояние to
+func (sm *ServiceMetrics) RecordBreakerTransition(transition circuitbreaker.Transition) {
+	sm.breakerTransitions.Inc(transition.Service, transition.Instance, transition.To.String())
+}
 
-		sm.mutex.Lock()
-		sm.requestCounts = make(map[string]int)
-		sm.responseTimes = make(map[string][]time.Duration)
-		sm.errorCounts = make(map[string]int)
-		sm.lastResetTime = time.Now()
-		sm.mutex.Unlock()
-	}
+// WatchInstances экспортирует число экземпляров сервисов, найденных в реестре
+func (sm *ServiceMetrics) WatchInstances(instances func() map[string]int) {
+	sm.Registry.NewGaugeFunc("gateway_discovered_instances",
+		"Service instances known from discovery.", []string{"service"},
+		func() []prom.Sample {
+			var samples []prom.Sample
+			for service, count := range instances() {
+				samples = append(samples, prom.Sample{Labels: []string{service}, Value: float64(count)})
+			}
+			return samples
+		})
 }
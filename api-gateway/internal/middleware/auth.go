@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"lmsmodule/api-gateway/internal/auth"
+)
+
+// ContextUserID - ключ контекста gin с ID пользователя из проверенного токена
+const ContextUserID = "gatewayUserID"
+
+// Authenticate удаляет из запроса заголовки личности, которые мог подставить клиент, и при
+// заданном verifier проверяет JWT: ID и роли проверенного пользователя передаются сервису
+// в заголовках X-User-ID и X-User-Roles. На маршрутах с required запрос без токена, а при
+// проверке и с недействительным токеном отклоняется без обращения к сервису. На публичных
+// маршрутах недействительный токен не мешает запросу, но личность не передается.
+// Без verifier токен проверяет сам сервис. Предварительные CORS-запросы OPTIONS
+// пропускаются: браузер не добавляет к ним заголовок.
+func Authenticate(verifier *auth.Verifier, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, header := range auth.IdentityHeaders {
+			c.Request.Header.Del(header)
+		}
+		if c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		authorization := c.GetHeader("Authorization")
+		if authorization == "" {
+			if required {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+				return
+			}
+			c.Next()
+			return
+		}
+		if verifier == nil {
+			c.Next()
+			return
+		}
+
+		tokenString := strings.TrimPrefix(authorization, "Bearer ")
+		identity, err := verifier.Verify(tokenString)
+		if err != nil {
+			if required {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Request.Header.Set(auth.HeaderUserID, identity.UserID)
+		c.Request.Header.Set(auth.HeaderUserRoles, identity.RolesHeader())
+		c.Set(ContextUserID, identity.UserID)
+		c.Next()
+	}
+}
+
+// AdminCheck подтверждает у сервиса, что владелец токена из заголовка authorization -
+// администратор
+type AdminCheck func(ctx context.Context, authorization string) (bool, error)
+
+// RequireAdmin пропускает запрос только с проверенным токеном администратора. Роли в токене
+// могли устареть за время его жизни, поэтому права подтверждает check. Без verifier шлюз
+// не может проверить токен и отклоняет любые запросы.
+func RequireAdmin(verifier *auth.Verifier, check AdminCheck) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if verifier == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token verification on the gateway is required"})
+			return
+		}
+
+		authorization := c.GetHeader("Authorization")
+		tokenString, ok := strings.CutPrefix(authorization, "Bearer ")
+		if !ok || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			return
+		}
+		identity, err := verifier.Verify(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+		isAdmin, err := check(c.Request.Context(), authorization)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify admin rights"})
+			return
+		}
+		if !isAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+		c.Set(ContextUserID, identity.UserID)
+		c.Next()
+	}
+}
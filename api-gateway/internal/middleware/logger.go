@@ -1,26 +1,12 @@
 package middleware
 
 import (
-	"time"
-
 	"github.com/gin-gonic/gin"
 
 	"lmsmodule/api-gateway/pkg/logger"
 )
 
-func LoggerMiddleware(logger *logger.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		startTime := time.Now()
-		c.Next()
-		endTime := time.Now()
-		latency := endTime.Sub(startTime)
-
-		logger.Info("%s %s %s %d %s",
-			c.Request.Method,
-			c.Request.URL.Path,
-			c.ClientIP(),
-			c.Writer.Status(),
-			latency,
-		)
-	}
+// LoggerMiddleware записывает запросы к шлюзу с полями маршрута, статуса и request_id
+func LoggerMiddleware(log *logger.Logger) gin.HandlerFunc {
+	return logger.AccessLogMiddleware(log)
 }
@@ -1,58 +1,72 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"lmsmodule/api-gateway/internal/ratelimit"
 )
 
-type RateLimiter struct {
-	sync.Mutex
-	requests map[string][]time.Time
-	limit    int
-	window   time.Duration
-}
-
-func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	return &RateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
-	}
-}
-
-func RateLimiterMiddleware() gin.HandlerFunc {
-	limiter := NewRateLimiter(100, time.Minute)
-
+// RateLimit ограничивает частоту запросов по политике limiter и сообщает клиенту остаток
+// лимита в заголовках X-RateLimit-*. Если хранилище лимитов недоступно, запрос пропускается:
+// отказ хранилища не должен останавливать шлюз.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-
-		limiter.Lock()
-		defer limiter.Unlock()
-
-		now := time.Now()
-		if _, exists := limiter.requests[ip]; !exists {
-			limiter.requests[ip] = []time.Time{now}
+		decision, err := limiter.Allow(rateLimitKey(c, limiter.Policy.Key), time.Now())
+		if err != nil {
+			_ = c.Error(fmt.Errorf("rate limit %s: %w", limiter.Name, err))
 			c.Next()
 			return
 		}
 
-		var requests []time.Time
-		for _, reqTime := range limiter.requests[ip] {
-			if now.Sub(reqTime) < limiter.window {
-				requests = append(requests, reqTime)
-			}
-		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("X-RateLimit-Reset", headerSeconds(decision.Reset))
 
-		if len(requests) >= limiter.limit {
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
-			c.Abort()
+		if !decision.Allowed {
+			c.Header("Retry-After", headerSeconds(decision.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
 			return
 		}
 
-		limiter.requests[ip] = append(requests, now)
+		// Лимит расходуется до обработки запроса, блокировок на время запроса нет, поэтому
+		// долгие запросы, например поток событий, не задерживают остальные
 		c.Next()
 	}
 }
+
+// rateLimitKey возвращает ключ клиента. Ключи разных видов не пересекаются.
+// Для KeyUser берется только пользователь, проверенный Authenticate: субъекту непроверенного
+// токена верить нельзя, клиент подставил бы чужой или новый ID. Без него ключом служит IP.
+func rateLimitKey(c *gin.Context, key string) string {
+	switch key {
+	case ratelimit.KeyUser:
+		if userID := c.GetString(ContextUserID); userID != "" {
+			return "user:" + userID
+		}
+	case ratelimit.KeyAPIKey:
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			// Сам ключ в памяти шлюза не хранится
+			sum := sha256.Sum256([]byte(apiKey))
+			return "api_key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// UserKey возвращает ключ пользователя запроса для привязки к экземпляру сервиса:
+// ID из проверенного токена или IP клиента
+func UserKey(c *gin.Context) string {
+	return rateLimitKey(c, ratelimit.KeyUser)
+}
+
+// headerSeconds округляет длительность вверх до целых секунд
+func headerSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
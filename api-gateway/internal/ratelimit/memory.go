@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто MemoryStore удаляет состояние клиентов, лимит которых восстановился
+const sweepInterval = time.Minute
+
+// MemoryStore хранит лимиты в памяти экземпляра шлюза. Блокировка держится только на время
+// расчета, а состояние удаляется, как только лимит клиента восстанавливается полностью:
+// такой клиент неотличим от нового, поэтому память не растет с числом клиентов за все время.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	// TokenBucket
+	tokens  float64
+	updated time.Time
+
+	// SlidingWindow
+	windowStart time.Time
+	previous    int
+	current     int
+
+	expires time.Time
+}
+
+// NewMemoryStore создает пустое хранилище
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*entry)}
+}
+
+// Len возвращает число клиентов, состояние которых хранится
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Allow реализует Store
+func (s *MemoryStore) Allow(key string, policy Policy, now time.Time) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	e, ok := s.entries[key]
+	if ok && !now.Before(e.expires) {
+		ok = false
+	}
+	if !ok {
+		e = &entry{tokens: float64(policy.Capacity()), updated: now, windowStart: now.Truncate(policy.Window)}
+		s.entries[key] = e
+	}
+
+	switch policy.Algorithm {
+	case TokenBucket:
+		return e.takeToken(policy, now), nil
+	case SlidingWindow:
+		return e.countRequest(policy, now), nil
+	default:
+		return Decision{}, fmt.Errorf("unknown rate limit algorithm %q", policy.Algorithm)
+	}
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+func (e *entry) takeToken(policy Policy, now time.Time) Decision {
+	capacity := float64(policy.Capacity())
+	// Токенов в секунду
+	rate := float64(policy.Requests) / policy.Window.Seconds()
+
+	if elapsed := now.Sub(e.updated).Seconds(); elapsed > 0 {
+		e.tokens = math.Min(capacity, e.tokens+elapsed*rate)
+	}
+	e.updated = now
+
+	decision := Decision{Limit: policy.Capacity()}
+	if e.tokens >= 1 {
+		e.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - e.tokens) / rate)
+	}
+	decision.Remaining = int(e.tokens)
+	decision.Reset = seconds((capacity - e.tokens) / rate)
+	e.expires = now.Add(decision.Reset)
+	return decision
+}
+
+func (e *entry) countRequest(policy Policy, now time.Time) Decision {
+	window := policy.Window
+	start := now.Truncate(window)
+	if !start.Equal(e.windowStart) {
+		if start.Sub(e.windowStart) == window {
+			e.previous = e.current
+		} else {
+			e.previous = 0
+		}
+		e.current = 0
+		e.windowStart = start
+	}
+
+	elapsed := now.Sub(start)
+	// Доля предыдущего окна, которая еще попадает в скользящее окно
+	weight := 1 - float64(elapsed)/float64(window)
+	limit := float64(policy.Requests)
+	estimated := float64(e.previous)*weight + float64(e.current)
+
+	decision := Decision{Limit: policy.Requests, Reset: window - elapsed}
+	if estimated+1 <= limit {
+		e.current++
+		decision.Allowed = true
+		estimated++
+	} else {
+		decision.RetryAfter = e.retryAfter(limit, window, elapsed)
+	}
+	decision.Remaining = int(math.Max(0, math.Floor(limit-estimated)))
+	e.expires = start.Add(2 * window)
+	return decision
+}
+
+// retryAfter - через сколько оценка скользящего окна опустится настолько, что запрос пройдет
+func (e *entry) retryAfter(limit float64, window, elapsed time.Duration) time.Duration {
+	free := limit - 1 - float64(e.current)
+	if free >= 0 && e.previous > 0 {
+		// Освобождается место за счет уходящего предыдущего окна
+		share := 1 - free/float64(e.previous)
+		return seconds(share*window.Seconds() - elapsed.Seconds())
+	}
+	// В текущем окне лимит исчерпан: ждем, пока оно станет предыдущим и частично уйдет
+	share := 1 - (limit-1)/float64(e.current)
+	return seconds((window - elapsed).Seconds() + share*window.Seconds())
+}
+
+func seconds(value float64) time.Duration {
+	if value < 0 {
+		return 0
+	}
+	return time.Duration(value * float64(time.Second))
+}
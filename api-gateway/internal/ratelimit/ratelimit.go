@@ -0,0 +1,97 @@
+// Package ratelimit ограничивает частоту запросов к шлюзу. Политика задает алгоритм, лимит
+// и то, по чему различаются клиенты; состояние лимитов хранится в Store, который можно
+// заменить общим хранилищем, когда экземпляров шлюза несколько.
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Алгоритмы ограничения
+const (
+	// TokenBucket пополняет корзину на Requests токенов за Window и допускает всплеск до Burst запросов
+	TokenBucket = "token_bucket"
+	// SlidingWindow допускает не больше Requests запросов за любые Window, считая по двум
+	// соседним окнам с весом
+	SlidingWindow = "sliding_window"
+)
+
+// Ключи, по которым различаются клиенты
+const (
+	KeyIP = "ip"
+	// KeyUser - пользователь из токена, проверенного шлюзом; остальные запросы считаются по IP
+	KeyUser = "user"
+	// KeyAPIKey - заголовок X-API-Key; запросы без него считаются по IP
+	KeyAPIKey = "api_key"
+)
+
+// Policy - политика ограничения
+type Policy struct {
+	Algorithm string
+	Requests  int
+	Window    time.Duration
+	// Burst - емкость корзины TokenBucket; 0 - равна Requests
+	Burst int
+	Key   string
+}
+
+// Validate проверяет политику
+func (p Policy) Validate() error {
+	if p.Algorithm != TokenBucket && p.Algorithm != SlidingWindow {
+		return fmt.Errorf("algorithm must be %q or %q", TokenBucket, SlidingWindow)
+	}
+	if p.Requests < 1 || p.Window <= 0 {
+		return fmt.Errorf("requests and window must be positive")
+	}
+	if p.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	if p.Key != KeyIP && p.Key != KeyUser && p.Key != KeyAPIKey {
+		return fmt.Errorf("key must be %q, %q or %q", KeyIP, KeyUser, KeyAPIKey)
+	}
+	return nil
+}
+
+// Capacity - сколько запросов подряд допускает политика
+func (p Policy) Capacity() int {
+	if p.Algorithm == TokenBucket && p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Requests
+}
+
+// Decision - решение по запросу и данные для заголовков X-RateLimit-*
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset - через сколько лимит восстановится полностью
+	Reset time.Duration
+	// RetryAfter - через сколько стоит повторить отклоненный запрос
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние лимитов. Allow должен проверять и расходовать лимит атомарно:
+// один Store делят все маршруты и все запросы шлюза.
+type Store interface {
+	Allow(key string, policy Policy, now time.Time) (Decision, error)
+}
+
+// Limiter применяет именованную политику. Ключи клиентов дополняются именем политики,
+// поэтому политики не делят лимит в общем Store.
+type Limiter struct {
+	Name   string
+	Policy Policy
+	Store  Store
+}
+
+// NewLimiter создает ограничитель политики name
+func NewLimiter(name string, policy Policy, store Store) *Limiter {
+	return &Limiter{Name: name, Policy: policy, Store: store}
+}
+
+// Allow расходует лимит клиента key
+func (l *Limiter) Allow(key string, now time.Time) (Decision, error) {
+	return l.Store.Allow(l.Name+"|"+key, l.Policy, now)
+}
@@ -0,0 +1,161 @@
+// Package rewrite переписывает запросы, которые шлюз передает сервисам: путь, заголовки
+// и параметры строки запроса. Правила задаются для каждого маршрута в конфигурации.
+package rewrite
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Rules - правила маршрута. Путь переписывается либо шаблоном Path, либо отбрасыванием
+// префикса StripPrefix и заменой по регулярному выражению Regex, в этом порядке.
+type Rules struct {
+	// Path - путь в сервисе, параметры пути маршрута подставляются по имени: /result/:session_id
+	Path string `yaml:"path"`
+	// StripPrefix отбрасывается от начала пути: /api/executor/execute -> /execute
+	StripPrefix string `yaml:"strip_prefix"`
+	// Regex применяется к пути после StripPrefix, Replacement может ссылаться на группы: $1, ${name}
+	Regex       string `yaml:"regex"`
+	Replacement string `yaml:"replacement"`
+
+	// SetHeaders заменяют значения заголовков запроса, RemoveHeaders удаляются из него
+	SetHeaders    map[string]string `yaml:"set_headers"`
+	RemoveHeaders []string          `yaml:"remove_headers"`
+	// SetQuery и RemoveQuery - то же для параметров строки запроса
+	SetQuery    map[string]string `yaml:"set_query"`
+	RemoveQuery []string          `yaml:"remove_query"`
+}
+
+// IsZero сообщает, что правил нет и запрос передается без изменений
+func (r Rules) IsZero() bool {
+	return r.Path == "" && r.StripPrefix == "" && r.Regex == "" && r.Replacement == "" &&
+		len(r.SetHeaders) == 0 && len(r.RemoveHeaders) == 0 && len(r.SetQuery) == 0 && len(r.RemoveQuery) == 0
+}
+
+// Rewriter - проверенные правила маршрута с разобранным регулярным выражением
+type Rewriter struct {
+	rules Rules
+	regex *regexp.Regexp
+}
+
+var pathParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// PathParams возвращает имена параметров шаблона пути
+func PathParams(path string) []string {
+	var params []string
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		params = append(params, match[1])
+	}
+	return params
+}
+
+// ExpandPath подставляет в шаблон значения параметров пути. Значение параметра *name
+// приходит от gin с ведущим /, который в шаблоне уже есть.
+func ExpandPath(template string, param func(name string) string) string {
+	return pathParam.ReplaceAllStringFunc(template, func(match string) string {
+		value := param(match[1:])
+		if match[0] == '*' {
+			value = strings.TrimPrefix(value, "/")
+		}
+		return value
+	})
+}
+
+// New проверяет правила маршрута с шаблоном пути routePath
+func New(routePath string, rules Rules) (*Rewriter, error) {
+	rewriter := &Rewriter{rules: rules}
+
+	if rules.Path != "" {
+		if rules.StripPrefix != "" || rules.Regex != "" {
+			return nil, errors.New("path cannot be combined with strip_prefix or regex")
+		}
+		if !strings.HasPrefix(rules.Path, "/") {
+			return nil, errors.New("path must start with /")
+		}
+		params := make(map[string]bool)
+		for _, param := range PathParams(routePath) {
+			params[param] = true
+		}
+		for _, param := range PathParams(rules.Path) {
+			if !params[param] {
+				return nil, fmt.Errorf("path uses unknown path parameter %q", param)
+			}
+		}
+	}
+
+	if prefix := rules.StripPrefix; prefix != "" {
+		if !strings.HasPrefix(prefix, "/") || strings.HasSuffix(prefix, "/") {
+			return nil, errors.New("strip_prefix must start and must not end with /")
+		}
+		if routePath != prefix && !strings.HasPrefix(routePath, prefix+"/") {
+			return nil, fmt.Errorf("strip_prefix %q is not a prefix of the route path", prefix)
+		}
+	}
+
+	if rules.Regex != "" {
+		regex, err := regexp.Compile(rules.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("regex: %w", err)
+		}
+		rewriter.regex = regex
+	} else if rules.Replacement != "" {
+		return nil, errors.New("replacement requires regex")
+	}
+
+	for name, value := range rules.SetHeaders {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("header %s: value must not contain line breaks", name)
+		}
+	}
+	return rewriter, nil
+}
+
+// Path переписывает путь запроса. param возвращает значение параметра пути маршрута.
+func (r *Rewriter) Path(path string, param func(name string) string) string {
+	if r.rules.Path != "" {
+		return ExpandPath(r.rules.Path, param)
+	}
+	if r.rules.StripPrefix != "" {
+		path = strings.TrimPrefix(path, r.rules.StripPrefix)
+	}
+	if r.regex != nil {
+		path = r.regex.ReplaceAllString(path, r.rules.Replacement)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// Apply переписывает запрос к сервису: путь, заголовки и строку запроса
+func (r *Rewriter) Apply(req *http.Request, param func(name string) string) {
+	path := r.Path(req.URL.Path, param)
+	if path != req.URL.Path {
+		req.URL.Path = path
+		req.URL.RawPath = ""
+	}
+
+	for _, name := range r.rules.RemoveHeaders {
+		req.Header.Del(name)
+	}
+	for name, value := range r.rules.SetHeaders {
+		req.Header.Set(name, value)
+	}
+
+	if len(r.rules.RemoveQuery) > 0 || len(r.rules.SetQuery) > 0 {
+		query := req.URL.Query()
+		for _, name := range r.rules.RemoveQuery {
+			query.Del(name)
+		}
+		for name, value := range r.rules.SetQuery {
+			query.Set(name, value)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+}
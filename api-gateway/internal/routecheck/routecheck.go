@@ -0,0 +1,177 @@
+// Package routecheck сверяет таблицу маршрутов шлюза со спецификацией OpenAPI, которую swag
+// генерирует для сервиса, и находит эндпоинты сервиса, недоступные через шлюз.
+package routecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"lmsmodule/api-gateway/internal/rewrite"
+	"lmsmodule/api-gateway/internal/utils"
+)
+
+// Endpoint - метод и путь в сервисе
+type Endpoint struct {
+	Method string
+	Path   string
+}
+
+func (e Endpoint) String() string {
+	return e.Method + " " + e.Path
+}
+
+// Spec - эндпоинты из спецификации сервиса
+type Spec struct {
+	Endpoints []Endpoint
+}
+
+// Report - результат сверки
+type Report struct {
+	// Unmapped - эндпоинты сервиса, на которые не ведет ни один маршрут шлюза
+	Unmapped []Endpoint
+	// Undocumented - маршруты шлюза к сервису, которых нет в спецификации
+	Undocumented []utils.RouteConfig
+}
+
+var specMethods = map[string]bool{
+	"get": true, "post": true, "put": true, "patch": true, "delete": true, "head": true, "options": true,
+}
+
+// LoadSpec читает swagger.json (Swagger 2.0 или OpenAPI 3). Пути дополняются basePath
+// или путем первого адреса из servers.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		BasePath string `json:"basePath"`
+		Servers  []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	base := document.BasePath
+	if base == "" && len(document.Servers) > 0 {
+		base = serverPath(document.Servers[0].URL)
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	spec := &Spec{}
+	for specPath, operations := range document.Paths {
+		for method := range operations {
+			if !specMethods[strings.ToLower(method)] {
+				continue
+			}
+			spec.Endpoints = append(spec.Endpoints, Endpoint{
+				Method: strings.ToUpper(method),
+				Path:   base + specPath,
+			})
+		}
+	}
+	sortEndpoints(spec.Endpoints)
+	return spec, nil
+}
+
+// Check сверяет маршруты шлюза к сервису service со спецификацией. Эндпоинты, путь которых
+// начинается с одного из ignore, не считаются недоступными.
+func Check(routes []utils.RouteConfig, service string, spec *Spec, ignore []string) Report {
+	var report Report
+
+	mapped := make(map[string]bool)
+	for _, route := range routes {
+		if route.Service != service {
+			continue
+		}
+		path := Normalize(targetPath(route))
+		documented := false
+		for _, endpoint := range spec.Endpoints {
+			if Normalize(endpoint.Path) == path && routeHasMethod(route, endpoint.Method) {
+				mapped[endpoint.String()] = true
+				documented = true
+			}
+		}
+		if !documented {
+			report.Undocumented = append(report.Undocumented, route)
+		}
+	}
+
+	for _, endpoint := range spec.Endpoints {
+		if !mapped[endpoint.String()] && !ignored(endpoint.Path, ignore) {
+			report.Unmapped = append(report.Unmapped, endpoint)
+		}
+	}
+	return report
+}
+
+// Normalize приводит шаблон пути к общему виду: параметры {id}, :id и *path заменяются на {}
+func Normalize(path string) string {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") ||
+			(strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")) {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// targetPath возвращает шаблон пути маршрута в сервисе после правил rewrite
+func targetPath(route utils.RouteConfig) string {
+	rewriter, err := rewrite.New(route.Path, route.Rewrite)
+	if err != nil {
+		return route.Path
+	}
+	return rewriter.Path(route.Path, func(name string) string { return ":" + name })
+}
+
+func routeHasMethod(route utils.RouteConfig, method string) bool {
+	if len(route.Methods) == 0 {
+		return true
+	}
+	for _, routeMethod := range route.Methods {
+		if routeMethod == method {
+			return true
+		}
+	}
+	return method == http.MethodHead && routeHasMethod(route, http.MethodGet)
+}
+
+func ignored(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// serverPath возвращает путь из адреса сервера OpenAPI 3: http://host/api -> /api
+func serverPath(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		if j := strings.Index(url, "/"); j >= 0 {
+			return url[j:]
+		}
+		return ""
+	}
+	return url
+}
+
+func sortEndpoints(endpoints []Endpoint) {
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Path != endpoints[j].Path {
+			return endpoints[i].Path < endpoints[j].Path
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"lmsmodule/api-gateway/internal/balancer"
+)
+
+// LoadBalancing - выбор экземпляра сервиса, исключение сбоящих экземпляров и повтор запросов.
+// Нулевые значения заменяются значениями по умолчанию.
+type LoadBalancing struct {
+	// Strategy - round_robin (по умолчанию), least_outstanding, weighted (вес из метаданных
+	// экземпляра weight) или consistent_hash (запросы пользователя идут на один экземпляр)
+	Strategy string `yaml:"strategy"`
+	// Services - стратегия для отдельных сервисов по имени в Eureka
+	Services    map[string]string `yaml:"services"`
+	Ejection    Ejection          `yaml:"ejection"`
+	HealthCheck HealthCheck       `yaml:"health_check"`
+	Retries     Retries           `yaml:"retries"`
+}
+
+// Ejection - экземпляр исключается из выбора после consecutive_failures ошибок проксирования
+// подряд на base_time секунд, каждое следующее исключение вдвое дольше, но не дольше max_time
+type Ejection struct {
+	ConsecutiveFailures int `yaml:"consecutive_failures"`
+	BaseTime            int `yaml:"base_time"`
+	MaxTime             int `yaml:"max_time"`
+}
+
+// HealthCheck - активная проверка HealthCheckUrl экземпляров раз в interval секунд
+type HealthCheck struct {
+	Enabled            bool `yaml:"enabled"`
+	Interval           int  `yaml:"interval"`
+	Timeout            int  `yaml:"timeout"`
+	UnhealthyThreshold int  `yaml:"unhealthy_threshold"`
+	HealthyThreshold   int  `yaml:"healthy_threshold"`
+}
+
+// Retries - повтор идемпотентных запросов на другом экземпляре. Attempts - число попыток
+// вместе с первой, 1 отключает повторы; Methods - повторяемые методы.
+type Retries struct {
+	Attempts int      `yaml:"attempts"`
+	Methods  []string `yaml:"methods"`
+}
+
+// StrategyFor возвращает стратегию сервиса
+func (lb LoadBalancing) StrategyFor(serviceName string) string {
+	for name, strategy := range lb.Services {
+		if strings.EqualFold(name, serviceName) {
+			return strategy
+		}
+	}
+	return lb.Strategy
+}
+
+// EjectionPolicy возвращает политику исключения для balancer
+func (e Ejection) EjectionPolicy() balancer.EjectionPolicy {
+	policy := balancer.DefaultEjectionPolicy
+	if e.ConsecutiveFailures > 0 {
+		policy.ConsecutiveFailures = e.ConsecutiveFailures
+	}
+	if e.BaseTime > 0 {
+		policy.BaseTime = time.Duration(e.BaseTime) * time.Second
+	}
+	if e.MaxTime > 0 {
+		policy.MaxTime = time.Duration(e.MaxTime) * time.Second
+	}
+	if policy.MaxTime < policy.BaseTime {
+		policy.MaxTime = policy.BaseTime
+	}
+	return policy
+}
+
+// IntervalDuration возвращает период проверки, по умолчанию 10 секунд
+func (h HealthCheck) IntervalDuration() time.Duration {
+	if h.Interval <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(h.Interval) * time.Second
+}
+
+// Policy возвращает настройки проверки для balancer: по умолчанию таймаут 2 секунды,
+// исключение после 3 неудач и возврат после 2 успехов подряд
+func (h HealthCheck) Policy() balancer.HealthCheckPolicy {
+	policy := balancer.HealthCheckPolicy{Timeout: 2 * time.Second, UnhealthyThreshold: 3, HealthyThreshold: 2}
+	if h.Timeout > 0 {
+		policy.Timeout = time.Duration(h.Timeout) * time.Second
+	}
+	if h.UnhealthyThreshold > 0 {
+		policy.UnhealthyThreshold = h.UnhealthyThreshold
+	}
+	if h.HealthyThreshold > 0 {
+		policy.HealthyThreshold = h.HealthyThreshold
+	}
+	return policy
+}
+
+// MaxAttempts возвращает число попыток запроса, по умолчанию 2
+func (r Retries) MaxAttempts() int {
+	if r.Attempts <= 0 {
+		return 2
+	}
+	return r.Attempts
+}
+
+// Retryable сообщает, можно ли повторить запрос с методом method.
+// По умолчанию повторяются GET, HEAD и OPTIONS.
+func (r Retries) Retryable(method string) bool {
+	if len(r.Methods) == 0 {
+		return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	}
+	for _, m := range r.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// idempotentMethods - методы, повтор которых по RFC 9110 не меняет результат
+var idempotentMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodOptions: true,
+	http.MethodPut: true, http.MethodDelete: true, http.MethodTrace: true,
+}
+
+// ValidateLoadBalancing проверяет названия стратегий и повторяемые методы
+func (c *Config) ValidateLoadBalancing() error {
+	if _, err := balancer.NewStrategy(c.LoadBalancing.Strategy); err != nil {
+		return fmt.Errorf("load_balancing: %w", err)
+	}
+	for service, strategy := range c.LoadBalancing.Services {
+		if _, err := balancer.NewStrategy(strategy); err != nil {
+			return fmt.Errorf("load_balancing: service %s: %w", service, err)
+		}
+	}
+	for _, method := range c.LoadBalancing.Retries.Methods {
+		if !idempotentMethods[strings.ToUpper(method)] {
+			return fmt.Errorf("load_balancing: retries: method %s is not idempotent", method)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"lmsmodule/api-gateway/internal/circuitbreaker"
+)
+
+// CircuitBreakerPolicy - выключатель экземпляра размыкается, когда за window секунд набралось
+// не меньше min_requests запросов и доля сбоев среди них не меньше failure_rate. Через timeout
+// секунд пропускается half_open_requests пробных запросов; столько же успехов замыкают его.
+// Нулевые значения заменяются значениями по умолчанию.
+type CircuitBreakerPolicy struct {
+	Window           int     `yaml:"window"`
+	FailureRate      float64 `yaml:"failure_rate"`
+	MinRequests      int     `yaml:"min_requests"`
+	HalfOpenRequests int     `yaml:"half_open_requests"`
+	Timeout          int     `yaml:"timeout"`
+}
+
+// CircuitBreakers - политика по умолчанию и политики отдельных сервисов по имени в Eureka;
+// незаданные поля политики сервиса берутся из политики по умолчанию
+type CircuitBreakers struct {
+	CircuitBreakerPolicy `yaml:",inline"`
+	Services             map[string]CircuitBreakerPolicy `yaml:"services"`
+}
+
+// merge заполняет незаданные поля значениями defaults
+func (p CircuitBreakerPolicy) merge(defaults CircuitBreakerPolicy) CircuitBreakerPolicy {
+	if p.Window == 0 {
+		p.Window = defaults.Window
+	}
+	if p.FailureRate == 0 {
+		p.FailureRate = defaults.FailureRate
+	}
+	if p.MinRequests == 0 {
+		p.MinRequests = defaults.MinRequests
+	}
+	if p.HalfOpenRequests == 0 {
+		p.HalfOpenRequests = defaults.HalfOpenRequests
+	}
+	if p.Timeout == 0 {
+		p.Timeout = defaults.Timeout
+	}
+	return p
+}
+
+// Policy возвращает политику выключателей сервиса
+func (c CircuitBreakers) Policy(serviceName string) circuitbreaker.Policy {
+	policy := c.CircuitBreakerPolicy
+	for name, servicePolicy := range c.Services {
+		if strings.EqualFold(name, serviceName) {
+			policy = servicePolicy.merge(policy)
+			break
+		}
+	}
+
+	result := circuitbreaker.DefaultPolicy
+	if policy.Window > 0 {
+		result.Window = time.Duration(policy.Window) * time.Second
+	}
+	if policy.FailureRate > 0 {
+		result.FailureRate = policy.FailureRate
+	}
+	if policy.MinRequests > 0 {
+		result.MinRequests = policy.MinRequests
+	}
+	if policy.HalfOpenRequests > 0 {
+		result.HalfOpenRequests = policy.HalfOpenRequests
+	}
+	if policy.Timeout > 0 {
+		result.Timeout = time.Duration(policy.Timeout) * time.Second
+	}
+	return result
+}
+
+func (p CircuitBreakerPolicy) validate() error {
+	if p.FailureRate < 0 || p.FailureRate > 1 {
+		return fmt.Errorf("failure_rate must be between 0 and 1, got %v", p.FailureRate)
+	}
+	if p.Window < 0 || p.MinRequests < 0 || p.HalfOpenRequests < 0 || p.Timeout < 0 {
+		return fmt.Errorf("window, min_requests, half_open_requests and timeout must not be negative")
+	}
+	return nil
+}
+
+// ValidateCircuitBreakers проверяет политики выключателей
+func (c *Config) ValidateCircuitBreakers() error {
+	if err := c.CircuitBreaker.validate(); err != nil {
+		return fmt.Errorf("circuit_breaker: %w", err)
+	}
+	for service, policy := range c.CircuitBreaker.Services {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("circuit_breaker: service %s: %w", service, err)
+		}
+	}
+	return nil
+}
@@ -1,19 +1,36 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"lmsmodule/api-gateway/internal/auth"
+	"lmsmodule/api-gateway/pkg/tracing"
 
 	"gopkg.in/yaml.v2"
 )
 
 type Config struct {
-	Port                int     `yaml:"port"`
-	LogLevel            string  `yaml:"log_level"`
-	AuthService         Service `yaml:"auth_service"`
-	CourseService       Service `yaml:"course_service"`
-	CodeExecutorService Service `yaml:"code_executor_service"`
-	Eureka              Eureka  `yaml:"eureka"`
+	Port     int    `yaml:"port"`
+	LogLevel string `yaml:"log_level"`
+	// LogFormat - json или logfmt
+	LogFormat string  `yaml:"log_format"`
+	Eureka    Eureka  `yaml:"eureka"`
+	JWT       JWT     `yaml:"jwt"`
+	Tracing   Tracing `yaml:"tracing"`
+	// Discovery - провайдер реестра экземпляров сервисов
+	Discovery Discovery `yaml:"discovery"`
+	// LoadBalancing - распределение запросов между экземплярами сервисов
+	LoadBalancing LoadBalancing `yaml:"load_balancing"`
+	// CircuitBreaker - политики выключателей экземпляров сервисов
+	CircuitBreaker CircuitBreakers `yaml:"circuit_breaker"`
+	// RateLimits - именованные политики ограничения частоты запросов, на которые ссылаются маршруты
+	RateLimits    map[string]RateLimitPolicy `yaml:"rate_limits"`
+	RouteDefaults RouteDefaults              `yaml:"route_defaults"`
+	Routes        []RouteConfig              `yaml:"routes"`
 }
 
 type CORSConfig struct {
@@ -25,17 +42,69 @@ type CORSConfig struct {
 	MaxAge           int      `yaml:"max_age"`
 }
 
-type Service struct {
-	URL     string `yaml:"url"`
-	Timeout int    `yaml:"timeout"`
-}
-
 type Eureka struct {
 	URL        string `yaml:"url"`
 	AppName    string `yaml:"app_name"`
 	InstanceIP string `yaml:"instance_ip"`
 }
 
+// JWT - проверка токенов на шлюзе. Секрет обычно задается переменной окружения JWT_SECRET,
+// общей с backend-svc.
+type JWT struct {
+	Enabled     bool   `yaml:"enabled"`
+	Secret      string `yaml:"secret"`
+	JWKSFile    string `yaml:"jwks_file"`
+	Issuer      string `yaml:"issuer"`
+	Audience    string `yaml:"audience"`
+	UserIDClaim string `yaml:"user_id_claim"`
+	RolesClaim  string `yaml:"roles_claim"`
+	// Leeway - допустимое расхождение часов в секундах
+	Leeway int `yaml:"leeway"`
+	// AdminCheck - где шлюз проверяет права администратора для своих служебных маршрутов
+	AdminCheck AdminCheck `yaml:"admin_check"`
+}
+
+// AdminCheck - запрос к сервису, подтверждающий права администратора. Роли в токене могут
+// устареть за время его жизни, поэтому права проверяет сервис по своей базе: ответ 200
+// подтверждает их, 401 и 403 - нет. Пустой Service берется из route_defaults.
+type AdminCheck struct {
+	Service string `yaml:"service"`
+	Path    string `yaml:"path"`
+}
+
+// AdminCheckTarget возвращает сервис и путь проверки прав администратора
+func (c *Config) AdminCheckTarget() AdminCheck {
+	check := c.JWT.AdminCheck
+	if check.Service == "" {
+		check.Service = c.RouteDefaults.Service
+	}
+	if check.Path == "" {
+		check.Path = "/api/admin/access"
+	}
+	return check
+}
+
+// AuthConfig возвращает настройки для auth.NewVerifier
+func (j JWT) AuthConfig() auth.Config {
+	return auth.Config{
+		Secret:      j.Secret,
+		JWKSFile:    j.JWKSFile,
+		Issuer:      j.Issuer,
+		Audience:    j.Audience,
+		UserIDClaim: j.UserIDClaim,
+		RolesClaim:  j.RolesClaim,
+		Leeway:      time.Duration(j.Leeway) * time.Second,
+	}
+}
+
+// Tracing - экспорт спанов трассировки: none, otlp (коллектору otlp_endpoint) или file
+type Tracing struct {
+	Exporter     string `yaml:"exporter"`
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	File         string `yaml:"file"`
+	ServiceName  string `yaml:"service_name"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 	config := &Config{}
 
@@ -57,10 +126,22 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		config.LogFormat = format
+	}
+
 	if eurekaURL := os.Getenv("EUREKA_URL"); eurekaURL != "" {
 		config.Eureka.URL = eurekaURL
 	}
 
+	if provider := os.Getenv("DISCOVERY_PROVIDER"); provider != "" {
+		config.Discovery.Provider = provider
+	}
+
+	if file := os.Getenv("DISCOVERY_FILE"); file != "" {
+		config.Discovery.File = file
+	}
+
 	if appName := os.Getenv("APP_NAME"); appName != "" {
 		config.Eureka.AppName = appName
 	}
@@ -69,5 +150,58 @@ func LoadConfig(path string) (*Config, error) {
 		config.Eureka.InstanceIP = instanceIP
 	}
 
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		config.JWT.Secret = secret
+	}
+
+	if verify := os.Getenv("JWT_VERIFY"); verify != "" {
+		if enabled, err := strconv.ParseBool(verify); err == nil {
+			config.JWT.Enabled = enabled
+		}
+	}
+
+	if exporter := os.Getenv("TRACING_EXPORTER"); exporter != "" {
+		config.Tracing.Exporter = exporter
+	}
+
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		config.Tracing.OTLPEndpoint = endpoint
+	}
+
+	if file := os.Getenv("TRACING_FILE"); file != "" {
+		config.Tracing.File = file
+	}
+
+	switch strings.ToLower(config.Tracing.Exporter) {
+	case "", tracing.ExporterNone, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		if config.Tracing.File == "" {
+			return nil, fmt.Errorf("tracing: file is required for the file exporter")
+		}
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q, expected none, otlp or file", config.Tracing.Exporter)
+	}
+
+	if config.JWT.Enabled {
+		if _, err := auth.NewVerifier(config.JWT.AuthConfig()); err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+	}
+
+	if err := config.ValidateDiscovery(); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateLoadBalancing(); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateCircuitBreakers(); err != nil {
+		return nil, err
+	}
+
+	config.applyRouteDefaults()
+	if err := config.ValidateRoutes(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
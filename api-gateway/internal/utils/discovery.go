@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"lmsmodule/api-gateway/pkg/discovery"
+)
+
+// Discovery - откуда шлюз берет экземпляры сервисов: eureka (по умолчанию, адрес из eureka.url),
+// static (список static), file (файл file, перечитывается при изменении) или dns (записи SRV
+// из dns). Провайдеры static, file и dns позволяют запустить систему без сервера Eureka;
+// с остальными провайдерами список static служит запасным.
+type Discovery struct {
+	Provider string `yaml:"provider"`
+	// RefreshInterval - период опроса реестра в секундах, по умолчанию 30
+	RefreshInterval int                             `yaml:"refresh_interval"`
+	Static          map[string][]discovery.Instance `yaml:"static"`
+	File            string                          `yaml:"file"`
+	// DNS - имя записи SRV для каждого сервиса
+	DNS       map[string]string `yaml:"dns"`
+	DNSServer string            `yaml:"dns_server"`
+}
+
+// RefreshDuration возвращает период опроса реестра
+func (d Discovery) RefreshDuration() time.Duration {
+	if d.RefreshInterval <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(d.RefreshInterval) * time.Second
+}
+
+// DiscoveryConfig возвращает настройки для discovery.New. Без адреса Eureka шлюз работает
+// только по списку static.
+func (c *Config) DiscoveryConfig() discovery.Config {
+	provider := c.Discovery.Provider
+	if c.usesEureka() && c.Eureka.URL == "" {
+		provider = discovery.ProviderStatic
+	}
+	return discovery.Config{
+		Provider:  provider,
+		EurekaURL: c.Eureka.URL,
+		Static:    c.Discovery.Static,
+		File:      c.Discovery.File,
+		DNS:       c.Discovery.DNS,
+		DNSServer: c.Discovery.DNSServer,
+	}
+}
+
+// StaticInstances возвращает экземпляры сервиса из static. С провайдерами eureka, file и dns
+// они используются, пока реестр не знает экземпляров сервиса.
+func (d Discovery) StaticInstances(serviceName string) []discovery.Instance {
+	for name, instances := range d.Static {
+		if strings.EqualFold(name, serviceName) {
+			return instances
+		}
+	}
+	return nil
+}
+
+func (c *Config) usesEureka() bool {
+	provider := strings.ToLower(c.Discovery.Provider)
+	return provider == "" || provider == discovery.ProviderEureka
+}
+
+// ValidateDiscovery проверяет настройки провайдера
+func (c *Config) ValidateDiscovery() error {
+	switch strings.ToLower(c.Discovery.Provider) {
+	case "", discovery.ProviderEureka:
+	case discovery.ProviderStatic:
+		for name, instances := range c.Discovery.Static {
+			for _, instance := range instances {
+				if instance.URL == "" {
+					return fmt.Errorf("discovery: instance of %s without url", name)
+				}
+			}
+		}
+	case discovery.ProviderFile:
+		if c.Discovery.File == "" {
+			return fmt.Errorf("discovery: file is required for the file provider")
+		}
+	case discovery.ProviderDNS:
+		if len(c.Discovery.DNS) == 0 {
+			return fmt.Errorf("discovery: dns records are required for the dns provider")
+		}
+	default:
+		return fmt.Errorf("discovery: unknown provider %q, expected eureka, static, file or dns", c.Discovery.Provider)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"lmsmodule/api-gateway/internal/ratelimit"
+	"lmsmodule/api-gateway/internal/rewrite"
+)
+
+// Требования к авторизации маршрута
+const (
+	// AuthPublic - маршрут доступен без токена
+	AuthPublic = "public"
+	// AuthRequired - запрос без заголовка Authorization, а при включенной проверке jwt
+	// и с недействительным токеном отклоняется шлюзом
+	AuthRequired = "required"
+)
+
+// RateLimitNone отключает ограничение частоты запросов для маршрута
+const RateLimitNone = "none"
+
+// RouteConfig - маршрут шлюза. Пустые поля берутся из route_defaults.
+type RouteConfig struct {
+	// Path - шаблон пути в синтаксисе gin: /api/courses/:id
+	Path string `yaml:"path"`
+	// Methods - HTTP-методы маршрута; пустой список - любые методы
+	Methods []string `yaml:"methods"`
+	// Service - имя сервиса в Eureka, которому передается запрос
+	Service string `yaml:"service"`
+	// Rewrite - правила переписывания пути, заголовков и строки запроса.
+	// Без них запрос передается по исходному пути.
+	Rewrite   rewrite.Rules `yaml:"rewrite"`
+	Auth      string        `yaml:"auth"`
+	RateLimit string        `yaml:"rate_limit"`
+	// Timeout - сколько секунд ждать ответа сервиса; 0 - без ограничения, например для потоков событий
+	Timeout *int `yaml:"timeout"`
+}
+
+// RouteDefaults - значения полей маршрута по умолчанию
+type RouteDefaults struct {
+	Service   string `yaml:"service"`
+	Auth      string `yaml:"auth"`
+	RateLimit string `yaml:"rate_limit"`
+	Timeout   int    `yaml:"timeout"`
+}
+
+// RateLimitPolicy - сколько запросов разрешено одному клиенту за окно в секундах.
+// Algorithm - token_bucket или sliding_window (по умолчанию), Key - ip (по умолчанию),
+// user или api_key, Burst - емкость корзины token_bucket.
+type RateLimitPolicy struct {
+	Algorithm string `yaml:"algorithm"`
+	Requests  int    `yaml:"requests"`
+	Window    int    `yaml:"window"`
+	Burst     int    `yaml:"burst"`
+	Key       string `yaml:"key"`
+}
+
+// Policy возвращает политику для ratelimit
+func (p RateLimitPolicy) Policy() ratelimit.Policy {
+	policy := ratelimit.Policy{
+		Algorithm: p.Algorithm,
+		Requests:  p.Requests,
+		Window:    time.Duration(p.Window) * time.Second,
+		Burst:     p.Burst,
+		Key:       p.Key,
+	}
+	if policy.Algorithm == "" {
+		policy.Algorithm = ratelimit.SlidingWindow
+	}
+	if policy.Key == "" {
+		policy.Key = ratelimit.KeyIP
+	}
+	return policy
+}
+
+// TimeoutSeconds возвращает таймаут маршрута
+func (r RouteConfig) TimeoutSeconds() int {
+	if r.Timeout == nil {
+		return 0
+	}
+	return *r.Timeout
+}
+
+// String описывает маршрут для сообщений об ошибках и журналов
+func (r RouteConfig) String() string {
+	methods := "ANY"
+	if len(r.Methods) > 0 {
+		methods = strings.Join(r.Methods, ",")
+	}
+	return methods + " " + r.Path
+}
+
+var routeMethods = map[string]bool{
+	http.MethodGet: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true,
+	http.MethodDelete: true, http.MethodHead: true, http.MethodOptions: true,
+}
+
+// applyRouteDefaults заполняет поля маршрутов значениями по умолчанию и приводит методы к верхнему регистру
+func (c *Config) applyRouteDefaults() {
+	for i := range c.Routes {
+		route := &c.Routes[i]
+		if route.Service == "" {
+			route.Service = c.RouteDefaults.Service
+		}
+		if route.Auth == "" {
+			route.Auth = c.RouteDefaults.Auth
+		}
+		if route.RateLimit == "" {
+			route.RateLimit = c.RouteDefaults.RateLimit
+		}
+		if route.Timeout == nil {
+			timeout := c.RouteDefaults.Timeout
+			route.Timeout = &timeout
+		}
+		for j, method := range route.Methods {
+			route.Methods[j] = strings.ToUpper(method)
+		}
+	}
+}
+
+// ValidateRoutes проверяет таблицу маршрутов: поля каждого маршрута, ссылки на политики
+// ограничения частоты, повторы и пересечения шаблонов, на которых gin не смог бы собрать роутер.
+// Возвращает все найденные ошибки сразу.
+func (c *Config) ValidateRoutes() error {
+	var errs []string
+	fail := func(route RouteConfig, format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf("route %s: %s", route, fmt.Sprintf(format, args...)))
+	}
+
+	for name, policy := range c.RateLimits {
+		if name == RateLimitNone {
+			errs = append(errs, fmt.Sprintf("rate limit %q: name is reserved", name))
+		}
+		if err := policy.Policy().Validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("rate limit %q: %v", name, err))
+		}
+	}
+
+	if len(c.Routes) == 0 {
+		errs = append(errs, "no routes configured")
+	}
+
+	seen := make(map[string]bool)
+	for _, route := range c.Routes {
+		if !strings.HasPrefix(route.Path, "/") {
+			fail(route, "path must start with /")
+		}
+		if route.Service == "" {
+			fail(route, "service is required")
+		}
+		if route.Auth != AuthPublic && route.Auth != AuthRequired {
+			fail(route, "auth must be %q or %q", AuthPublic, AuthRequired)
+		}
+		if _, ok := c.RateLimits[route.RateLimit]; !ok && route.RateLimit != RateLimitNone {
+			fail(route, "unknown rate limit policy %q", route.RateLimit)
+		}
+		if route.TimeoutSeconds() < 0 {
+			fail(route, "timeout must not be negative")
+		}
+		if _, err := rewrite.New(route.Path, route.Rewrite); err != nil {
+			fail(route, "rewrite: %v", err)
+		}
+
+		methods := route.Methods
+		if len(methods) == 0 {
+			methods = []string{"ANY"}
+		}
+		for _, method := range methods {
+			if method != "ANY" && !routeMethods[method] {
+				fail(route, "unsupported method %q", method)
+			}
+			key := method + " " + route.Path
+			if seen[key] {
+				fail(route, "duplicate route")
+			}
+			seen[key] = true
+		}
+	}
+
+	if len(errs) == 0 {
+		if err := checkRouteConflicts(c.Routes); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid routes:\n  " + strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// checkRouteConflicts собирает маршруты в пустом роутере gin: он паникует на шаблонах,
+// которые нельзя различить, например /courses/:id и /courses/:course_id/tasks
+func checkRouteConflicts(routes []RouteConfig) (err error) {
+	mode := gin.Mode()
+	gin.SetMode(gin.ReleaseMode)
+	defer gin.SetMode(mode)
+	writer := gin.DefaultWriter
+	gin.DefaultWriter = io.Discard
+	defer func() { gin.DefaultWriter = writer }()
+
+	router := gin.New()
+	var current RouteConfig
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("route %s: %v", current, r)
+		}
+	}()
+
+	noop := func(*gin.Context) {}
+	for _, route := range routes {
+		current = route
+		if len(route.Methods) == 0 {
+			router.Any(route.Path, noop)
+			continue
+		}
+		for _, method := range route.Methods {
+			router.Handle(method, route.Path, noop)
+		}
+	}
+	return nil
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"lmsmodule/api-gateway/internal/api"
 	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/discovery"
 	"lmsmodule/api-gateway/pkg/logger"
 )
 
@@ -15,12 +20,39 @@ func main() {
 
 	config, err := utils.LoadConfig(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		logger.Default().Fatal("Failed to load configuration: %v", err)
 	}
 
-	logger := logger.NewLogger(config.LogLevel)
+	logger.SetDefault(logger.New(logger.Options{Level: config.LogLevel, Format: config.LogFormat}))
+	logger := logger.Default()
 
+	logger.Info("Loaded %d gateway routes", len(config.Routes))
 	server := api.NewServer(config, logger)
+
+	// Шлюз регистрирует себя в реестре; провайдеры без регистрации принимают ее без действий
+	deregister := func() {}
+	if config.Eureka.AppName != "" && config.Eureka.InstanceIP != "" {
+		deregister = discovery.Keep(server.Discovery.Provider(), discovery.Registration{
+			Service:         config.Eureka.AppName,
+			Host:            config.Eureka.InstanceIP,
+			Port:            config.Port,
+			HealthCheckPath: "/health",
+		}, 30*time.Second, logger)
+	}
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+		deregister()
+		// Спаны отправляются пачками, оставшиеся нужно выгрузить до выхода
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Tracer.Shutdown(ctx); err != nil {
+			logger.Error("Tracing shutdown failed: %v", err)
+		}
+		os.Exit(0)
+	}()
 	logger.Info("Starting API Gateway on port %d", config.Port)
 	if err := server.Run(); err != nil {
 		logger.Fatal("Failed to start server: %v", err)
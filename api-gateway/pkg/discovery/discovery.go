@@ -0,0 +1,165 @@
+// Package discovery - реестры экземпляров сервисов: Eureka, список из конфигурации, файл,
+// который перечитывается при изменении, и записи DNS SRV. Шлюз получает через Discovery
+// экземпляры сервисов, а сервисы регистрируют в нем себя.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"lmsmodule/api-gateway/pkg/logger"
+)
+
+// Названия провайдеров для настроек сервисов
+const (
+	ProviderEureka = "eureka"
+	ProviderStatic = "static"
+	ProviderFile   = "file"
+	ProviderDNS    = "dns"
+)
+
+// Instance - экземпляр сервиса
+type Instance struct {
+	URL string `yaml:"url" json:"url"`
+	// HealthCheckURL - адрес проверки здоровья; пустой - экземпляр не проверяется
+	HealthCheckURL string `yaml:"health_check_url" json:"health_check_url,omitempty"`
+	// Weight - доля запросов для стратегии weighted; 0 означает 1
+	Weight int `yaml:"weight" json:"weight,omitempty"`
+}
+
+// Registration - экземпляр, который сервис регистрирует о себе
+type Registration struct {
+	// Service - имя сервиса, по которому его находит шлюз
+	Service string
+	Host    string
+	Port    int
+	// HealthCheckPath - путь проверки здоровья, например /api/health
+	HealthCheckPath string
+	Weight          int
+}
+
+// URL возвращает адрес экземпляра
+func (r Registration) URL() string {
+	return fmt.Sprintf("http://%s:%d", r.Host, r.Port)
+}
+
+// Discovery - реестр экземпляров. Провайдеры, в которых экземпляры задаются снаружи
+// (список, файл, DNS), принимают регистрацию без действий.
+type Discovery interface {
+	// Instances возвращает доступные экземпляры по именам сервисов в верхнем регистре
+	Instances(ctx context.Context) (map[string][]Instance, error)
+	Register(ctx context.Context, registration Registration) error
+	// Heartbeat продлевает регистрацию; реестр снимает экземпляры без продления
+	Heartbeat(ctx context.Context, registration Registration) error
+	Deregister(ctx context.Context, registration Registration) error
+}
+
+// Watcher - реестр, который сам сообщает об изменениях экземпляров. Канал закрывается
+// после отмены ctx.
+type Watcher interface {
+	Watch(ctx context.Context) <-chan struct{}
+}
+
+// Config - настройки провайдера для New
+type Config struct {
+	// Provider - eureka (по умолчанию), static, file или dns
+	Provider  string
+	EurekaURL string
+	// Static - экземпляры сервисов провайдера static
+	Static map[string][]Instance
+	// File - файл YAML или JSON с экземплярами в формате Static
+	File string
+	// FileInterval - как часто проверять изменение файла, по умолчанию 2 секунды
+	FileInterval time.Duration
+	// DNS - имя записи SRV для каждого сервиса, например _http._tcp.backend-svc.service.consul
+	DNS map[string]string
+	// DNSServer - адрес DNS-сервера host:port; пустой - системный
+	DNSServer string
+}
+
+// New создает провайдер по настройкам
+func New(config Config) (Discovery, error) {
+	switch strings.ToLower(config.Provider) {
+	case "", ProviderEureka:
+		if config.EurekaURL == "" {
+			return nil, errors.New("eureka url is required")
+		}
+		return NewEureka(config.EurekaURL), nil
+	case ProviderStatic:
+		return NewStatic(config.Static), nil
+	case ProviderFile:
+		return NewFile(config.File, config.FileInterval)
+	case ProviderDNS:
+		if len(config.DNS) == 0 {
+			return nil, errors.New("dns provider needs SRV records of services")
+		}
+		return NewDNS(config.DNS, config.DNSServer), nil
+	}
+	return nil, fmt.Errorf("unknown discovery provider %q, expected eureka, static, file or dns", config.Provider)
+}
+
+// Keep регистрирует экземпляр и продлевает регистрацию каждые interval. Неудачная регистрация
+// повторяется при следующем продлении. Возвращенная функция снимает регистрацию.
+func Keep(d Discovery, registration Registration, interval time.Duration, log *logger.Logger) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	entry := log.WithFields(map[string]interface{}{"service": registration.Service, "instance": registration.URL()})
+
+	registered := false
+	register := func() {
+		if err := d.Register(ctx, registration); err != nil {
+			entry.Error("Service registration failed: %v", err)
+			return
+		}
+		registered = true
+		entry.Info("Service registered")
+	}
+
+	register()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !registered {
+					register()
+				} else if err := d.Heartbeat(ctx, registration); err != nil {
+					entry.Error("Heartbeat error: %v", err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			deregisterCtx, cancelDeregister := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancelDeregister()
+			if err := d.Deregister(deregisterCtx, registration); err != nil {
+				entry.Error("Service deregistration failed: %v", err)
+				return
+			}
+			entry.Info("Service deregistered")
+		})
+	}
+}
+
+// normalize приводит имена сервисов к верхнему регистру, как в Eureka
+func normalize(services map[string][]Instance) map[string][]Instance {
+	result := make(map[string][]Instance, len(services))
+	for name, instances := range services {
+		name = strings.ToUpper(name)
+		result[name] = append(result[name], instances...)
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DNS - экземпляры из записей SRV, например в Consul или Kubernetes. Используются записи
+// с наименьшим приоритетом, вес записи становится весом экземпляра.
+type DNS struct {
+	records  map[string]string
+	resolver *net.Resolver
+}
+
+// NewDNS создает провайдер; records - имя записи SRV для каждого сервиса, server - адрес
+// DNS-сервера host:port или пустая строка для системного
+func NewDNS(records map[string]string, server string) *DNS {
+	resolver := net.DefaultResolver
+	if server != "" {
+		dialer := &net.Dialer{}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	normalized := make(map[string]string, len(records))
+	for service, record := range records {
+		normalized[strings.ToUpper(service)] = record
+	}
+	return &DNS{records: normalized, resolver: resolver}
+}
+
+// Instances разрешает записи всех сервисов. Сервис, запись которого не разрешилась,
+// пропускается; ошибка возвращается, только если не разрешилась ни одна запись.
+func (d *DNS) Instances(ctx context.Context) (map[string][]Instance, error) {
+	services := make(map[string][]Instance, len(d.records))
+	var errs []error
+	for service, record := range d.records {
+		_, addrs, err := d.resolver.LookupSRV(ctx, "", "", record)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", service, err))
+			continue
+		}
+		services[service] = srvInstances(addrs)
+	}
+	if len(services) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return services, nil
+}
+
+func srvInstances(addrs []*net.SRV) []Instance {
+	if len(addrs) == 0 {
+		return nil
+	}
+	priority := addrs[0].Priority
+	for _, addr := range addrs {
+		if addr.Priority < priority {
+			priority = addr.Priority
+		}
+	}
+	var instances []Instance
+	for _, addr := range addrs {
+		if addr.Priority != priority {
+			continue
+		}
+		instances = append(instances, Instance{
+			URL:    fmt.Sprintf("http://%s:%d", strings.TrimSuffix(addr.Target, "."), addr.Port),
+			Weight: int(addr.Weight),
+		})
+	}
+	return instances
+}
+
+func (d *DNS) Register(context.Context, Registration) error   { return nil }
+func (d *DNS) Heartbeat(context.Context, Registration) error  { return nil }
+func (d *DNS) Deregister(context.Context, Registration) error { return nil }
@@ -0,0 +1,102 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hudl/fargo"
+)
+
+// Eureka - реестр Netflix Eureka
+type Eureka struct {
+	connection *fargo.EurekaConnection
+}
+
+// NewEureka создает провайдер для сервера Eureka по адресу url, например http://discovery-server:8761/eureka
+func NewEureka(url string) *Eureka {
+	conn := fargo.NewConn(url)
+	return &Eureka{connection: &conn}
+}
+
+// Instances возвращает экземпляры в состоянии UP. Вес берется из метаданных weight.
+func (e *Eureka) Instances(context.Context) (map[string][]Instance, error) {
+	apps, err := e.connection.GetApps()
+	if err != nil {
+		return nil, err
+	}
+	services := make(map[string][]Instance, len(apps))
+	for _, app := range apps {
+		serviceName := strings.ToUpper(app.Name)
+		for _, instance := range app.Instances {
+			if instance.Status != fargo.UP {
+				continue
+			}
+			services[serviceName] = append(services[serviceName], Instance{
+				URL:            fmt.Sprintf("http://%s:%d", instance.IPAddr, instance.Port),
+				HealthCheckURL: instance.HealthCheckUrl,
+				Weight:         metadataWeight(&instance.Metadata),
+			})
+		}
+	}
+	return services, nil
+}
+
+// metadataWeight читает вес из метаданных: Eureka отдает их строками в XML и числами в JSON
+func metadataWeight(metadata *fargo.InstanceMetadata) int {
+	if value, err := metadata.GetString("weight"); err == nil && value != "" {
+		if weight, err := strconv.Atoi(value); err == nil {
+			return weight
+		}
+	}
+	weight, _ := metadata.GetInt("weight")
+	return weight
+}
+
+func (e *Eureka) Register(_ context.Context, registration Registration) error {
+	return e.connection.RegisterInstance(eurekaInstance(registration))
+}
+
+func (e *Eureka) Heartbeat(_ context.Context, registration Registration) error {
+	return e.connection.HeartBeatInstance(eurekaInstance(registration))
+}
+
+func (e *Eureka) Deregister(_ context.Context, registration Registration) error {
+	return e.connection.DeregisterInstance(eurekaInstance(registration))
+}
+
+func eurekaInstance(registration Registration) *fargo.Instance {
+	app := strings.ToUpper(registration.Service)
+	url := registration.URL()
+	instance := &fargo.Instance{
+		InstanceId:       fmt.Sprintf("%s:%s:%d", registration.Host, registration.Service, registration.Port),
+		HostName:         registration.Host,
+		App:              app,
+		IPAddr:           registration.Host,
+		Port:             registration.Port,
+		PortEnabled:      true,
+		VipAddress:       registration.Service,
+		SecureVipAddress: registration.Service,
+		DataCenterInfo: fargo.DataCenterInfo{
+			Name:  fargo.MyOwn,
+			Class: "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo",
+		},
+		Status:           fargo.UP,
+		Overriddenstatus: fargo.UNKNOWN,
+		LeaseInfo: fargo.LeaseInfo{
+			RenewalIntervalInSecs: 30,
+			DurationInSecs:        90,
+		},
+		HomePageUrl:   url + "/",
+		StatusPageUrl: url + registration.HealthCheckPath,
+		CountryId:     1,
+	}
+	if registration.HealthCheckPath != "" {
+		instance.HealthCheckUrl = url + registration.HealthCheckPath
+	}
+	if registration.Weight > 0 {
+		instance.SetMetadataString("weight", strconv.Itoa(registration.Weight))
+	}
+	return instance
+}
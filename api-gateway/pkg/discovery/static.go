@@ -0,0 +1,101 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Static - экземпляры, заданные в конфигурации. Подходит для локальной разработки и тестов
+// без сервера Eureka.
+type Static struct {
+	services map[string][]Instance
+}
+
+func NewStatic(services map[string][]Instance) *Static {
+	return &Static{services: normalize(services)}
+}
+
+func (s *Static) Instances(context.Context) (map[string][]Instance, error) {
+	result := make(map[string][]Instance, len(s.services))
+	for name, instances := range s.services {
+		result[name] = append([]Instance(nil), instances...)
+	}
+	return result, nil
+}
+
+func (s *Static) Register(context.Context, Registration) error   { return nil }
+func (s *Static) Heartbeat(context.Context, Registration) error  { return nil }
+func (s *Static) Deregister(context.Context, Registration) error { return nil }
+
+// File - экземпляры из файла YAML или JSON вида {"BACKEND-SERVICE": [{"url": "http://..."}]}.
+// Файл перечитывается при каждом запросе экземпляров, Watch сообщает о его изменении.
+type File struct {
+	path     string
+	interval time.Duration
+}
+
+// NewFile создает провайдер для файла path; interval - как часто проверять изменение файла
+func NewFile(path string, interval time.Duration) (*File, error) {
+	if path == "" {
+		return nil, fmt.Errorf("discovery file path is required")
+	}
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	f := &File{path: path, interval: interval}
+	// Ошибка в файле видна при запуске, а не при первом обновлении
+	if _, err := f.Instances(context.Background()); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) Instances(context.Context) (map[string][]Instance, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	services := make(map[string][]Instance)
+	if err := yaml.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("discovery file %s: %w", f.path, err)
+	}
+	return normalize(services), nil
+}
+
+// Watch проверяет файл раз в interval и сообщает, когда изменилось его содержимое
+func (f *File) Watch(ctx context.Context) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	// Изменения считаются от содержимого на момент вызова Watch
+	last, _ := os.ReadFile(f.path)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				data, err := os.ReadFile(f.path)
+				if err != nil || bytes.Equal(data, last) {
+					continue
+				}
+				last = data
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changes
+}
+
+func (f *File) Register(context.Context, Registration) error   { return nil }
+func (f *File) Heartbeat(context.Context, Registration) error  { return nil }
+func (f *File) Deregister(context.Context, Registration) error { return nil }
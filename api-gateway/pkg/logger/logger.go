@@ -1,10 +1,16 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -12,15 +18,74 @@ type LogLevel int
 
 const (
 	LogLevelError LogLevel = iota
+	LogLevelWarn
 	LogLevelInfo
 	LogLevelDebug
 )
 
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelError:
+		return "error"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// Форматы записей журнала
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// Redacted заменяет значения полей, по имени похожих на секреты
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys - части имен полей, значения которых не выводятся: пароли, токены, коды OTP
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "otp", "authorization", "cookie", "api_key", "apikey"}
+
+// Options - настройки журнала
+type Options struct {
+	Level string
+	// Format - json (по умолчанию) или logfmt
+	Format string
+	// Output получает записи info и debug, ErrorOutput - error и warn; по умолчанию stdout и stderr
+	Output      io.Writer
+	ErrorOutput io.Writer
+}
+
+// Logger пишет по одной структурированной записи на строку. WithFields и WithTime возвращают
+// копию с дополнительными полями, исходный журнал не меняется.
 type Logger struct {
-	infoLogger  *log.Logger
-	errorLogger *log.Logger
-	debugLogger *log.Logger
-	level       LogLevel
+	out    *sink
+	errOut *sink
+	level  LogLevel
+	format string
+	fields []field
+	time   time.Time
+}
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+// sink не дает записям разных горутин перемешаться
+type sink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *sink) write(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(p); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to log message: %v\n", err)
+	}
 }
 
 func ParseLogLevel(level string) LogLevel {
@@ -29,59 +94,251 @@ func ParseLogLevel(level string) LogLevel {
 		return LogLevelDebug
 	case "INFO":
 		return LogLevelInfo
+	case "WARN", "WARNING":
+		return LogLevelWarn
 	default:
 		return LogLevelError
 	}
 }
 
+// ParseFormat возвращает формат журнала; неизвестные значения означают json
+func ParseFormat(format string) string {
+	if strings.EqualFold(format, FormatLogfmt) {
+		return FormatLogfmt
+	}
+	return FormatJSON
+}
+
+// NewLogger создает журнал с уровнем level; формат берется из переменной окружения LOG_FORMAT
 func NewLogger(level string) *Logger {
-	infoLogger := log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	errorLogger := log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-	debugLogger := log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
+	return New(Options{Level: level, Format: os.Getenv("LOG_FORMAT")})
+}
 
+func New(options Options) *Logger {
+	if options.Output == nil {
+		options.Output = os.Stdout
+	}
+	if options.ErrorOutput == nil {
+		options.ErrorOutput = os.Stderr
+	}
+	out := &sink{w: options.Output}
+	errOut := out
+	if options.ErrorOutput != options.Output {
+		errOut = &sink{w: options.ErrorOutput}
+	}
 	return &Logger{
-		infoLogger:  infoLogger,
-		errorLogger: errorLogger,
-		debugLogger: debugLogger,
-		level:       ParseLogLevel(level),
+		out:    out,
+		errOut: errOut,
+		level:  ParseLogLevel(options.Level),
+		format: ParseFormat(options.Format),
 	}
 }
 
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = NewLogger("INFO")
+)
+
+// Default возвращает общий журнал процесса для кода, которому журнал не передается явно
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// SetDefault заменяет общий журнал процесса
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
 func (l *Logger) Info(format string, args ...interface{}) {
-	if l.level >= LogLevelInfo {
-		l.log(l.infoLogger, format, args...)
-	}
+	l.log(LogLevelInfo, format, args...)
+}
+
+func (l *Logger) Warn(format string, args ...interface{}) {
+	l.log(LogLevelWarn, format, args...)
 }
 
 func (l *Logger) Error(format string, args ...interface{}) {
-	l.log(l.errorLogger, format, args...)
+	l.log(LogLevelError, format, args...)
 }
 
 func (l *Logger) Debug(format string, args ...interface{}) {
-	if l.level >= LogLevelDebug {
-		l.log(l.debugLogger, format, args...)
-	}
+	l.log(LogLevelDebug, format, args...)
 }
 
 func (l *Logger) Fatal(format string, args ...interface{}) {
-	l.log(l.errorLogger, format, args...)
+	l.log(LogLevelError, format, args...)
 	os.Exit(1)
 }
 
-func (l *Logger) log(logger *log.Logger, format string, args ...interface{}) {
+// Enabled сообщает, выводятся ли записи уровня level
+func (l *Logger) Enabled(level LogLevel) bool {
+	return level <= l.level
+}
+
+// WithFields возвращает журнал, добавляющий поля к каждой записи. Поля выводятся
+// в порядке добавления, внутри одного вызова - по имени.
+func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clone := *l
+	clone.fields = make([]field, 0, len(l.fields)+len(keys))
+	for _, f := range l.fields {
+		if _, replaced := fields[f.key]; !replaced {
+			clone.fields = append(clone.fields, f)
+		}
+	}
+	for _, key := range keys {
+		clone.fields = append(clone.fields, field{key: key, value: fields[key]})
+	}
+	return &clone
+}
+
+// WithField - WithFields для одного поля
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return l.WithFields(map[string]interface{}{key: value})
+}
+
+// WithTime возвращает журнал, записи которого помечены временем t вместо текущего
+func (l *Logger) WithTime(t time.Time) *Logger {
+	clone := *l
+	clone.time = t
+	return &clone
+}
+
+// Writer возвращает io.Writer, каждая запись в который выводится сообщением уровня level.
+// Подходит для log.SetOutput и журналов сторонних библиотек.
+func (l *Logger) Writer(level LogLevel) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		l.output(level, strings.TrimRight(string(p), "\n"), 5)
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
 	message := format
 	if len(args) > 0 {
 		message = fmt.Sprintf(format, args...)
 	}
-	if err := logger.Output(2, message); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to log message: %v\n", err)
+	l.output(level, message, 3)
+}
+
+func (l *Logger) output(level LogLevel, message string, depth int) {
+	if !l.Enabled(level) {
+		return
+	}
+	t := l.time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	fields := []field{
+		{key: "time", value: t.UTC().Format(time.RFC3339Nano)},
+		{key: "level", value: level.String()},
+		{key: "msg", value: message},
+	}
+	if _, file, line, ok := runtime.Caller(depth); ok {
+		fields = append(fields, field{key: "caller", value: filepath.Base(file) + ":" + strconv.Itoa(line)})
+	}
+	fields = append(fields, l.fields...)
+
+	var line []byte
+	if l.format == FormatLogfmt {
+		line = encodeLogfmt(fields)
+	} else {
+		line = encodeJSON(fields)
+	}
+
+	if level <= LogLevelWarn {
+		l.errOut.write(line)
+	} else {
+		l.out.write(line)
 	}
 }
 
-func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
-	return l
+// fieldValue приводит значение поля к выводимому виду и скрывает секреты
+func fieldValue(f field) interface{} {
+	if isSensitive(f.key) {
+		return Redacted
+	}
+	switch v := f.value.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return f.value
 }
 
-func (l *Logger) WithTime(t time.Time) *Logger {
-	return l
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeys {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeJSON(fields []field) []byte {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(fieldValue(f))
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(f.value))
+		}
+		b.Write(value)
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func encodeLogfmt(fields []field) []byte {
+	var b strings.Builder
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f.key)
+		b.WriteByte('=')
+		var value string
+		switch v := fieldValue(f).(type) {
+		case string:
+			value = v
+		case nil:
+			value = ""
+		default:
+			value = fmt.Sprint(v)
+		}
+		if value == "" || strings.ContainsAny(value, " =\"\n\t") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(value)
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
 }
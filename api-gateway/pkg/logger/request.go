@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader передает идентификатор запроса между шлюзом и сервисами
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+type fieldsKey struct{}
+
+// validRequestID ограничивает принимаемые от клиента идентификаторы: они попадают в журналы и заголовки
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// NewRequestID возвращает случайный идентификатор запроса
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// ContextWithRequestID сохраняет идентификатор запроса в контексте
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithFields сохраняет в контексте поля, которые WithContext добавит к записям,
+// например идентификаторы трассы
+func ContextWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	merged := make(map[string]interface{}, len(fields))
+	if existing, ok := ctx.Value(fieldsKey{}).(map[string]interface{}); ok {
+		for key, value := range existing {
+			merged[key] = value
+		}
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// WithContext возвращает журнал с полем request_id и полями ContextWithFields из контекста
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if id := RequestID(ctx); id != "" {
+		l = l.WithField("request_id", id)
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).(map[string]interface{}); ok {
+		l = l.WithFields(fields)
+	}
+	return l
+}
+
+// RequestIDMiddleware принимает идентификатор запроса из X-Request-ID или создает новый,
+// сохраняет его в контексте запроса и возвращает клиенту в том же заголовке.
+// Заголовок запроса перезаписывается, поэтому проксируемый запрос уносит проверенное значение.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = NewRequestID()
+		}
+		c.Request.Header.Set(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(ContextWithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware записывает каждый запрос после ответа. Строка запроса не выводится:
+// в ней могут быть токены, например у потока событий.
+func AccessLogMiddleware(l *Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		entry := l.WithContext(c.Request.Context()).WithFields(map[string]interface{}{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"route":      route,
+			"status":     c.Writer.Status(),
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip":  c.ClientIP(),
+		})
+		if c.Writer.Status() >= 500 {
+			entry.Error("request completed")
+		} else {
+			entry.Info("request completed")
+		}
+	}
+}
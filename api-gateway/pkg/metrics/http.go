@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute - метка запросов, не попавших ни в один маршрут: путь запроса в метку
+// не попадает, иначе число сочетаний меток росло бы с каждым сканером адресов
+const unmatchedRoute = "unmatched"
+
+// knownMethods - остальные методы учитываются как OTHER по той же причине
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// HTTPMetrics - число и длительность HTTP-запросов по шаблону маршрута, методу и статусу
+type HTTPMetrics struct {
+	requests *CounterVec
+	duration *HistogramVec
+}
+
+// NewHTTPMetrics регистрирует метрики <prefix>_http_requests_total и
+// <prefix>_http_request_duration_seconds
+func NewHTTPMetrics(registry *Registry, prefix string) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: registry.NewCounterVec(prefix+"_http_requests_total",
+			"HTTP requests by route template, method and status code.", "route", "method", "status"),
+		duration: registry.NewHistogramVec(prefix+"_http_request_duration_seconds",
+			"HTTP request duration by route template and method.", DefaultBuckets, "route", "method"),
+	}
+}
+
+// Middleware учитывает запрос после его обработки
+func (m *HTTPMetrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		if !knownMethods[method] {
+			method = "OTHER"
+		}
+		m.requests.Inc(route, method, strconv.Itoa(c.Writer.Status()))
+		m.duration.Observe(time.Since(start).Seconds(), route, method)
+	}
+}
+
+// Requests возвращает число учтенных запросов
+func (m *HTTPMetrics) Requests(route, method, status string) float64 {
+	return m.requests.Value(route, method, status)
+}
@@ -0,0 +1,319 @@
+// Package metrics - счетчики, датчики и гистограммы в текстовом формате Prometheus.
+// Память ограничена числом сочетаний меток: гистограммы хранят только счетчики корзин,
+// поэтому метки должны браться из конечных наборов (шаблон маршрута, а не путь запроса).
+// Пакет общий для шлюза и backend-svc.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets - границы корзин гистограмм длительности запросов в секундах
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Sample - значение метрики с метками
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry - набор метрик одного сервиса
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+	names      map[string]bool
+}
+
+// NewRegistry создает пустой набор
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[c.name()] {
+		panic("metrics: duplicate metric " + c.name())
+	}
+	r.names[c.name()] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// Write выводит все метрики в текстовом формате Prometheus
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.RUnlock()
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler отдает метрики по HTTP
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// series - значения метрики по сочетаниям меток
+type series struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+	mu         sync.Mutex
+	values     map[string]*value
+}
+
+type value struct {
+	labels  []string
+	current float64
+	// Только для гистограмм
+	buckets []uint64
+	count   uint64
+}
+
+func newSeries(name, help, kind string, labels []string) *series {
+	return &series{metricName: name, help: help, kind: kind, labels: labels, values: make(map[string]*value)}
+}
+
+func (s *series) name() string { return s.metricName }
+
+// get возвращает значение для меток; вызывается под s.mu
+func (s *series) get(labelValues []string, buckets int) *value {
+	if len(labelValues) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d labels, got %d", s.metricName, len(s.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	v, ok := s.values[key]
+	if !ok {
+		v = &value{labels: append([]string(nil), labelValues...)}
+		if buckets > 0 {
+			v.buckets = make([]uint64, buckets)
+		}
+		s.values[key] = v
+	}
+	return v
+}
+
+func (s *series) sorted() []*value {
+	values := make([]*value, 0, len(s.values))
+	for _, v := range s.values {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return strings.Join(values[i].labels, "\xff") < strings.Join(values[j].labels, "\xff")
+	})
+	return values
+}
+
+func (s *series) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.metricName, escapeHelp(s.help), s.metricName, s.kind)
+}
+
+func (s *series) write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header(w)
+	for _, v := range s.sorted() {
+		writeSample(w, s.metricName, s.labels, v.labels, "", "", v.current)
+	}
+}
+
+// CounterVec - возрастающий счетчик
+type CounterVec struct{ s *series }
+
+// NewCounterVec регистрирует счетчик с метками labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{s: newSeries(name, help, "counter", labels)}
+	r.register(c.s)
+	return c
+}
+
+// Add увеличивает счетчик меток labelValues на delta >= 0
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.s.mu.Lock()
+	c.s.get(labelValues, 0).current += delta
+	c.s.mu.Unlock()
+}
+
+// Inc увеличивает счетчик меток labelValues на 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Value возвращает текущее значение счетчика
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	return c.s.get(labelValues, 0).current
+}
+
+// GaugeVec - значение, которое может расти и убывать
+type GaugeVec struct{ s *series }
+
+// NewGaugeVec регистрирует датчик с метками labels
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{s: newSeries(name, help, "gauge", labels)}
+	r.register(g.s)
+	return g
+}
+
+// Set задает значение датчика
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.s.mu.Lock()
+	g.s.get(labelValues, 0).current = v
+	g.s.mu.Unlock()
+}
+
+// Add изменяет значение датчика на delta
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.s.mu.Lock()
+	g.s.get(labelValues, 0).current += delta
+	g.s.mu.Unlock()
+}
+
+// gaugeFunc - датчик, значения которого считываются при выводе метрик
+type gaugeFunc struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+	collect    func() []Sample
+}
+
+func (g *gaugeFunc) name() string { return g.metricName }
+
+func (g *gaugeFunc) write(w io.Writer) {
+	samples := g.collect()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", g.metricName, escapeHelp(g.help), g.metricName, g.kind)
+	for _, sample := range samples {
+		writeSample(w, g.metricName, g.labels, sample.Labels, "", "", sample.Value)
+	}
+}
+
+// NewGaugeFunc регистрирует датчик, значения которого возвращает collect при каждом выводе:
+// так состояние, которое уже хранится в другом месте (пул соединений, экземпляры сервисов),
+// не дублируется в метриках
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&gaugeFunc{metricName: name, help: help, kind: "gauge", labels: labels, collect: collect})
+}
+
+// NewCounterFunc - то же для счетчиков, которые ведет другой код, например sql.DBStats.WaitCount
+func (r *Registry) NewCounterFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&gaugeFunc{metricName: name, help: help, kind: "counter", labels: labels, collect: collect})
+}
+
+// HistogramVec - распределение значений по корзинам
+type HistogramVec struct {
+	s       *series
+	buckets []float64
+}
+
+// NewHistogramVec регистрирует гистограмму с границами корзин buckets по возрастанию
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: buckets of " + name + " must be sorted")
+	}
+	h := &HistogramVec{s: newSeries(name, help, "histogram", labels), buckets: buckets}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) name() string { return h.s.metricName }
+
+// Observe добавляет значение v
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	entry := h.s.get(labelValues, len(h.buckets))
+	for i, bound := range h.buckets {
+		if v <= bound {
+			entry.buckets[i]++
+		}
+	}
+	entry.count++
+	entry.current += v
+}
+
+// Snapshot возвращает число значений и их сумму
+func (h *HistogramVec) Snapshot(labelValues ...string) (count uint64, sum float64) {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	entry := h.s.get(labelValues, len(h.buckets))
+	return entry.count, entry.current
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	h.s.header(w)
+	for _, v := range h.s.sorted() {
+		for i, bound := range h.buckets {
+			writeSample(w, h.s.metricName+"_bucket", h.s.labels, v.labels, "le", formatFloat(bound), float64(v.buckets[i]))
+		}
+		writeSample(w, h.s.metricName+"_bucket", h.s.labels, v.labels, "le", "+Inf", float64(v.count))
+		writeSample(w, h.s.metricName+"_sum", h.s.labels, v.labels, "", "", v.current)
+		writeSample(w, h.s.metricName+"_count", h.s.labels, v.labels, "", "", float64(v.count))
+	}
+}
+
+func writeSample(w io.Writer, name string, labels, labelValues []string, extraLabel, extraValue string, v float64) {
+	var pairs []string
+	for i, label := range labels {
+		if i < len(labelValues) {
+			pairs = append(pairs, label+`="`+escapeLabel(labelValues[i])+`"`)
+		}
+	}
+	if extraLabel != "" {
+		pairs = append(pairs, extraLabel+`="`+extraValue+`"`)
+	}
+	if len(pairs) > 0 {
+		name += "{" + strings.Join(pairs, ",") + "}"
+	}
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(v string) string {
+	return helpEscaper.Replace(v)
+}
@@ -0,0 +1,240 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exporter отправляет завершенные спаны. ExportSpans вызывается из одной горутины трассировщика.
+type Exporter interface {
+	ExportSpans(spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Названия экспортеров для настроек сервисов
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+// NewExporter создает экспортер по названию: otlp отправляет спаны коллектору endpoint,
+// file дописывает их в файл path. Для none и пустого названия возвращает nil.
+func NewExporter(name, endpoint, path string) (Exporter, error) {
+	switch strings.ToLower(name) {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterOTLP:
+		return NewOTLPExporter(endpoint), nil
+	case ExporterFile:
+		return NewFileExporter(path)
+	}
+	return nil, fmt.Errorf("unknown tracing exporter %q, expected none, otlp or file", name)
+}
+
+// JSONExporter пишет по одному спану в строке JSON
+type JSONExporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewJSONExporter пишет спаны в w, например в буфер теста
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{w: w}
+}
+
+// NewFileExporter дописывает спаны в файл path
+func NewFileExporter(path string) (*JSONExporter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("tracing file path is required")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONExporter{w: f, closer: f}, nil
+}
+
+func (e *JSONExporter) ExportSpans(spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	encoder := json.NewEncoder(e.w)
+	for _, span := range spans {
+		if err := encoder.Encode(span); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *JSONExporter) Shutdown(context.Context) error {
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
+
+// OTLPExporter отправляет спаны коллектору по OTLP/HTTP в кодировке JSON
+type OTLPExporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewOTLPExporter создает экспортер для коллектора endpoint, например http://otel-collector:4318.
+// Путь /v1/traces добавляется, если не указан.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	if endpoint == "" {
+		endpoint = "http://localhost:4318"
+	}
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	return &OTLPExporter{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (e *OTLPExporter) ExportSpans(spans []SpanData) error {
+	body, err := json.Marshal(otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("otlp export: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp export: collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// Структуры OTLP/JSON: идентификаторы передаются в hex, время - строкой в наносекундах
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+var otlpKinds = map[string]int{"internal": 1, "server": 2, "client": 3}
+
+func otlpRequest(spans []SpanData) otlpExportRequest {
+	byService := make(map[string]*otlpResourceSpans)
+	var order []string
+	for _, span := range spans {
+		resource, ok := byService[span.Service]
+		if !ok {
+			resource = &otlpResourceSpans{}
+			resource.Resource.Attributes = []otlpKeyValue{otlpAttribute("service.name", span.Service)}
+			scope := otlpScopeSpans{}
+			scope.Scope.Name = "lmsmodule/tracing"
+			resource.ScopeSpans = []otlpScopeSpans{scope}
+			byService[span.Service] = resource
+			order = append(order, span.Service)
+		}
+
+		converted := otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              otlpKinds[span.Kind],
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: 1},
+		}
+		for key, value := range span.Attributes {
+			converted.Attributes = append(converted.Attributes, otlpAttribute(key, value))
+		}
+		if span.Error != "" {
+			converted.Status = otlpStatus{Code: 2, Message: span.Error}
+		}
+		resource.ScopeSpans[0].Spans = append(resource.ScopeSpans[0].Spans, converted)
+	}
+
+	request := otlpExportRequest{}
+	for _, service := range order {
+		request.ResourceSpans = append(request.ResourceSpans, *byService[service])
+	}
+	return request
+}
+
+func otlpAttribute(key string, value interface{}) otlpKeyValue {
+	kv := otlpKeyValue{Key: key}
+	switch v := value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case bool:
+		kv.Value.BoolValue = &v
+	case int:
+		s := strconv.Itoa(v)
+		kv.Value.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case float64:
+		kv.Value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+	return kv
+}
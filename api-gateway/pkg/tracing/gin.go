@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lmsmodule/api-gateway/pkg/logger"
+)
+
+// Middleware начинает серверный спан для каждого запроса, продолжая трассу из traceparent.
+// Идентификаторы трассы и спана добавляются к записям журнала запроса.
+// С nil-трассировщиком запросы проходят без спанов.
+func Middleware(t *Tracer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if t == nil {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := t.Start(ctx, c.Request.Method+" "+route, SpanKindServer)
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.target", c.Request.URL.Path)
+		if requestID := logger.RequestID(ctx); requestID != "" {
+			span.SetAttribute("request_id", requestID)
+		}
+		ctx = logger.ContextWithFields(ctx, map[string]interface{}{
+			"trace_id": span.Context().TraceID.String(),
+			"span_id":  span.Context().SpanID.String(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("HTTP %d", status))
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,415 @@
+// Package tracing - распределенная трассировка с передачей контекста в заголовке W3C traceparent.
+// Спаны запроса связываются через context.Context и по завершении отдаются экспортеру пачками.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader - заголовок W3C Trace Context
+const TraceparentHeader = "traceparent"
+
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext - то, что передается между сервисами: трасса, родительский спан и флаг записи
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent возвращает значение заголовка traceparent
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent разбирает заголовок traceparent. Заголовки будущих версий принимаются,
+// если их начало совпадает с форматом версии 00.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, errors.New("malformed traceparent")
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, errors.New("unsupported traceparent version")
+	}
+	for _, part := range parts[:4] {
+		if strings.ToLower(part) != part {
+			return sc, errors.New("traceparent must be lowercase hex")
+		}
+	}
+	var version, flags [1]byte
+	if _, err := hex.Decode(version[:], []byte(parts[0])); err != nil {
+		return sc, fmt.Errorf("invalid version: %w", err)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("invalid trace id: %w", err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("invalid parent id: %w", err)
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, fmt.Errorf("invalid flags: %w", err)
+	}
+	if !sc.IsValid() {
+		return sc, errors.New("trace id and parent id must not be zero")
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// SpanKind - роль спана в обмене между сервисами
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// SpanData - завершенный спан в виде, который получают экспортеры
+type SpanData struct {
+	Service      string                 `json:"service"`
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	DurationMS   float64                `json:"duration_ms"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Span - операция в трассе. Методы безопасно вызывать у nil: так выглядит спан,
+// когда трассировка выключена.
+type Span struct {
+	tracer *Tracer
+	ctx    SpanContext
+	parent SpanID
+	name   string
+	kind   SpanKind
+	start  time.Time
+
+	mu         sync.Mutex
+	attributes map[string]interface{}
+	err        string
+	ended      bool
+}
+
+// Context возвращает контекст спана для передачи в другие сервисы
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.ctx
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// RecordError помечает спан ошибочным; nil игнорируется
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End завершает спан и передает его экспортеру; повторные вызовы ничего не делают
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := SpanData{
+		Service:    s.tracer.service,
+		Name:       s.name,
+		Kind:       s.kind.String(),
+		TraceID:    s.ctx.TraceID.String(),
+		SpanID:     s.ctx.SpanID.String(),
+		Start:      s.start,
+		End:        end,
+		DurationMS: float64(end.Sub(s.start).Microseconds()) / 1000,
+		Attributes: s.attributes,
+		Error:      s.err,
+	}
+	if s.parent.IsValid() {
+		data.ParentSpanID = s.parent.String()
+	}
+	s.mu.Unlock()
+
+	if s.ctx.Sampled {
+		s.tracer.enqueue(data)
+	}
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// SpanFromContext возвращает текущий спан или nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithSpan делает span текущим в контексте
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// Extract сохраняет в контексте родительский спан из заголовка traceparent, если он корректен
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject записывает текущий спан контекста в заголовок traceparent
+func Inject(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set(TraceparentHeader, span.ctx.Traceparent())
+	}
+}
+
+// StartSpan начинает дочерний спан текущего спана контекста. Без текущего спана
+// трассировка для этого запроса выключена и возвращается nil.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, kind)
+}
+
+// Options - настройки трассировщика
+type Options struct {
+	// BatchSize - сколько спанов отправлять за раз; по умолчанию 256
+	BatchSize int
+	// FlushInterval - как часто отправлять неполную пачку; по умолчанию 5 секунд
+	FlushInterval time.Duration
+	// QueueSize - сколько спанов ждут отправки; при переполнении новые спаны отбрасываются
+	QueueSize int
+	// OnError получает ошибки экспорта
+	OnError func(error)
+}
+
+// Tracer создает спаны сервиса и отправляет завершенные экспортеру в фоне
+type Tracer struct {
+	service  string
+	exporter Exporter
+	options  Options
+
+	queue    chan SpanData
+	flushReq chan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// NewTracer создает трассировщик сервиса service. С nil-экспортером возвращает nil:
+// трассировка выключена, а все функции пакета работают как пустые.
+func NewTracer(service string, exporter Exporter, options Options) *Tracer {
+	if exporter == nil {
+		return nil
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 256
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = 5 * time.Second
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = 4096
+	}
+	t := &Tracer{
+		service:  service,
+		exporter: exporter,
+		options:  options,
+		queue:    make(chan SpanData, options.QueueSize),
+		flushReq: make(chan chan struct{}),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Start начинает спан. Родитель берется из текущего спана контекста, затем из traceparent,
+// сохраненного Extract; без них начинается новая трасса.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.ctx.TraceID = parent.ctx.TraceID
+		span.ctx.Sampled = parent.ctx.Sampled
+		span.parent = parent.ctx.SpanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		span.ctx.TraceID = remote.TraceID
+		span.ctx.Sampled = remote.Sampled
+		span.parent = remote.SpanID
+	} else {
+		_, _ = rand.Read(span.ctx.TraceID[:])
+		span.ctx.Sampled = true
+	}
+	_, _ = rand.Read(span.ctx.SpanID[:])
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case t.queue <- data:
+	default:
+		t.reportError(errors.New("tracing: span queue is full, span dropped"))
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.options.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, t.options.BatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.exporter.ExportSpans(batch); err != nil {
+			t.reportError(err)
+		}
+		batch = make([]SpanData, 0, t.options.BatchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case data := <-t.queue:
+				batch = append(batch, data)
+				if len(batch) >= t.options.BatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.options.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case reply := <-t.flushReq:
+			drain()
+			close(reply)
+		case <-t.done:
+			drain()
+			return
+		}
+	}
+}
+
+func (t *Tracer) reportError(err error) {
+	if t.options.OnError != nil {
+		t.options.OnError(err)
+	}
+}
+
+// Flush отправляет завершенные спаны, не дожидаясь интервала
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	reply := make(chan struct{})
+	select {
+	case t.flushReq <- reply:
+	case <-t.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-reply:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown отправляет оставшиеся спаны и закрывает экспортер
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.stopOnce.Do(func() { close(t.done) })
+	select {
+	case <-t.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.exporter.Shutdown(ctx)
+}
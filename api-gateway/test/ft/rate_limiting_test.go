@@ -9,13 +9,15 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"lmsmodule/api-gateway/internal/middleware"
+	"lmsmodule/api-gateway/internal/ratelimit"
 )
 
 func TestRateLimiterMiddleware(t *testing.T) {
 	router := gin.Default()
 
 	api := router.Group("/api")
-	api.Use(middleware.RateLimiterMiddleware())
+	policy := ratelimit.Policy{Algorithm: ratelimit.SlidingWindow, Requests: 100, Window: time.Minute, Key: ratelimit.KeyIP}
+	api.Use(middleware.RateLimit(ratelimit.NewLimiter("default", policy, ratelimit.NewMemoryStore())))
 	{
 		api.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
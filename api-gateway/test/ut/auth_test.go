@@ -0,0 +1,187 @@
+package ut
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/auth"
+	"lmsmodule/api-gateway/internal/middleware"
+	"lmsmodule/api-gateway/internal/ratelimit"
+)
+
+const testSecret = "gateway-test-secret"
+
+func signHS256(t *testing.T, secret string, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": 42, "roles": []string{"teacher"}, "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestVerifierSecret(t *testing.T) {
+	verifier, err := auth.NewVerifier(auth.Config{Secret: testSecret})
+	require.NoError(t, err)
+
+	identity, err := verifier.Verify(signHS256(t, testSecret, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "42", identity.UserID)
+	assert.Equal(t, []string{"teacher"}, identity.Roles)
+
+	claims := validClaims()
+	claims["roles"] = "teacher, admin,bad role\r\nX-Evil: 1"
+	identity, err = verifier.Verify(signHS256(t, testSecret, claims))
+	require.NoError(t, err)
+	assert.Equal(t, "teacher,admin", identity.RolesHeader())
+
+	invalid := map[string]string{
+		"wrong secret": signHS256(t, "other", validClaims()),
+		"expired":      signHS256(t, testSecret, jwt.MapClaims{"sub": 42, "exp": time.Now().Add(-time.Hour).Unix()}),
+		"no expiry":    signHS256(t, testSecret, jwt.MapClaims{"sub": 42}),
+		"no subject":   signHS256(t, testSecret, jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}),
+		"garbage":      "not-a-token",
+	}
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	invalid["alg none"] = none
+
+	for name, token := range invalid {
+		_, err := verifier.Verify(token)
+		assert.Error(t, err, name)
+	}
+
+	_, err = auth.NewVerifier(auth.Config{})
+	assert.ErrorIs(t, err, auth.ErrNoKeys)
+}
+
+func TestVerifierJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encode := func(value *big.Int) string { return base64.RawURLEncoding.EncodeToString(value.Bytes()) }
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "bad", "e": "bad"},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks, 0o600))
+
+	verifier, err := auth.NewVerifier(auth.Config{JWKSFile: path, Issuer: "lms"})
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+	claims := validClaims()
+	claims["iss"] = "lms"
+
+	identity, err := verifier.Verify(sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, claims))
+	require.NoError(t, err)
+	assert.Equal(t, "42", identity.UserID)
+
+	_, err = verifier.Verify(sign(jwt.SigningMethodES256, "ec-1", ecKey, claims))
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(sign(jwt.SigningMethodRS256, "unknown", rsaKey, claims))
+	assert.Error(t, err)
+
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "other"
+	_, err = verifier.Verify(sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongIssuer))
+	assert.Error(t, err)
+
+	// Без секрета в конфигурации токены HS256 не принимаются, даже подписанные открытым ключом
+	_, err = verifier.Verify(signHS256(t, string(rsaKey.N.Bytes()), claims))
+	assert.Error(t, err)
+}
+
+func TestAuthenticateMiddleware(t *testing.T) {
+	verifier, err := auth.NewVerifier(auth.Config{Secret: testSecret})
+	require.NoError(t, err)
+
+	policy := ratelimit.Policy{Algorithm: ratelimit.SlidingWindow, Requests: 1, Window: time.Minute, Key: ratelimit.KeyUser}
+	limit := middleware.RateLimit(ratelimit.NewLimiter("user", policy, ratelimit.NewMemoryStore()))
+	echo := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"user":  c.Request.Header.Get(auth.HeaderUserID),
+			"roles": c.Request.Header.Get(auth.HeaderUserRoles),
+		})
+	}
+
+	router := gin.New()
+	router.GET("/private", middleware.Authenticate(verifier, true), echo)
+	router.GET("/public", middleware.Authenticate(verifier, false), echo)
+	router.GET("/limited", middleware.Authenticate(verifier, true), limit, echo)
+	router.GET("/unverified", middleware.Authenticate(nil, true), echo)
+
+	do := func(path, token string) (int, map[string]string) {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set(auth.HeaderUserID, "1")
+		req.Header.Set(auth.HeaderUserRoles, "admin")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		body := map[string]string{}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	valid := signHS256(t, testSecret, validClaims())
+	forged := signHS256(t, "other", jwt.MapClaims{"sub": 1, "roles": []string{"admin"}, "exp": time.Now().Add(time.Hour).Unix()})
+
+	code, body := do("/private", valid)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"user": "42", "roles": "teacher"}, body)
+
+	code, _ = do("/private", forged)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = do("/private", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// На публичном маршруте недействительный токен пропускается, но без личности
+	code, body = do("/public", forged)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"user": "", "roles": ""}, body)
+
+	// Без проверки токен передается сервису, а поддельные заголовки все равно удаляются
+	code, body = do("/unverified", forged)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"user": "", "roles": ""}, body)
+
+	// Лимит считается на проверенного пользователя, а не на IP
+	code, _ = do("/limited", valid)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do("/limited", valid)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	other := validClaims()
+	other["sub"] = 7
+	code, _ = do("/limited", signHS256(t, testSecret, other))
+	assert.Equal(t, http.StatusOK, code)
+}
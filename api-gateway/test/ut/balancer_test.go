@@ -0,0 +1,281 @@
+package ut
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/api"
+	"lmsmodule/api-gateway/internal/balancer"
+	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/logger"
+)
+
+func newBalancer(t *testing.T, strategy string, policy balancer.EjectionPolicy, instances ...balancer.Instance) *balancer.Balancer {
+	t.Helper()
+	s, err := balancer.NewStrategy(strategy)
+	require.NoError(t, err)
+	b := balancer.New(s, policy)
+	b.Update(instances)
+	return b
+}
+
+func pickCounts(t *testing.T, b *balancer.Balancer, key string, n int) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		endpoint, err := b.Next(key, nil)
+		require.NoError(t, err)
+		counts[endpoint.URL]++
+	}
+	return counts
+}
+
+func TestBalancerStrategies(t *testing.T) {
+	_, err := balancer.NewStrategy("random")
+	assert.Error(t, err)
+
+	instances := []balancer.Instance{{URL: "http://a"}, {URL: "http://b"}, {URL: "http://c"}}
+
+	t.Run("round robin", func(t *testing.T) {
+		b := newBalancer(t, balancer.StrategyRoundRobin, balancer.DefaultEjectionPolicy, instances...)
+		assert.Equal(t, map[string]int{"http://a": 2, "http://b": 2, "http://c": 2}, pickCounts(t, b, "", 6))
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		b := newBalancer(t, balancer.StrategyWeighted, balancer.DefaultEjectionPolicy,
+			balancer.Instance{URL: "http://a", Weight: 3}, balancer.Instance{URL: "http://b"})
+		assert.Equal(t, map[string]int{"http://a": 6, "http://b": 2}, pickCounts(t, b, "", 8))
+
+		// Плавный выбор: тяжелый экземпляр не получает все свои запросы подряд
+		var sequence []string
+		for i := 0; i < 4; i++ {
+			endpoint, err := b.Next("", nil)
+			require.NoError(t, err)
+			sequence = append(sequence, endpoint.URL)
+		}
+		assert.Contains(t, sequence[:3], "http://b")
+	})
+
+	t.Run("least outstanding", func(t *testing.T) {
+		b := newBalancer(t, balancer.StrategyLeastOutstanding, balancer.DefaultEjectionPolicy, instances...)
+		endpoints := b.Endpoints()
+		releaseA := endpoints[0].Acquire()
+		releaseB := endpoints[1].Acquire()
+		assert.Equal(t, map[string]int{"http://c": 3}, pickCounts(t, b, "", 3))
+
+		releaseA()
+		releaseA()
+		assert.Equal(t, int64(0), endpoints[0].Outstanding(), "release is idempotent")
+		releaseB()
+		counts := pickCounts(t, b, "", 3)
+		assert.Len(t, counts, 3, "idle instances share requests")
+	})
+
+	t.Run("consistent hash", func(t *testing.T) {
+		b := newBalancer(t, balancer.StrategyConsistentHash, balancer.DefaultEjectionPolicy, instances...)
+		owners := make(map[string]string)
+		for i := 0; i < 300; i++ {
+			key := fmt.Sprintf("user:%d", i)
+			counts := pickCounts(t, b, key, 3)
+			require.Len(t, counts, 1, "requests of one user go to one instance")
+			for url := range counts {
+				owners[key] = url
+			}
+		}
+		perInstance := make(map[string]int)
+		for _, url := range owners {
+			perInstance[url]++
+		}
+		for _, instance := range instances {
+			assert.Greater(t, perInstance[instance.URL], 50, "keys are spread across instances")
+		}
+
+		// Без экземпляра c на другие экземпляры переезжают только его пользователи
+		b.Update(instances[:2])
+		for key, owner := range owners {
+			endpoint, err := b.Next(key, nil)
+			require.NoError(t, err)
+			if owner != "http://c" {
+				assert.Equal(t, owner, endpoint.URL)
+			}
+		}
+
+		// Без ключа запросы распределяются по очереди
+		assert.Len(t, pickCounts(t, b, "", 4), 2)
+	})
+}
+
+func TestBalancerEjection(t *testing.T) {
+	policy := balancer.EjectionPolicy{ConsecutiveFailures: 2, BaseTime: 50 * time.Millisecond, MaxTime: time.Second}
+	b := newBalancer(t, balancer.StrategyRoundRobin, policy, balancer.Instance{URL: "http://a"}, balancer.Instance{URL: "http://b"})
+	a := b.Endpoints()[0]
+
+	assert.False(t, b.ReportFailure(a))
+	b.ReportSuccess(a)
+	assert.False(t, b.ReportFailure(a), "success resets the failure count")
+	assert.True(t, b.ReportFailure(a))
+	assert.Equal(t, map[string]int{"http://b": 4}, pickCounts(t, b, "", 4))
+
+	// Обновление списка из реестра не возвращает исключенный экземпляр
+	b.Update([]balancer.Instance{{URL: "http://b"}, {URL: "http://a"}})
+	assert.Equal(t, map[string]int{"http://b": 2}, pickCounts(t, b, "", 2))
+	status := b.Status()
+	require.Len(t, status, 2)
+	assert.False(t, status[0].Available)
+	assert.False(t, status[0].EjectedUntil.IsZero())
+
+	// Уже опробованный экземпляр не выбирается повторно, а при исключении всех
+	// выбор идет среди исключенных
+	endpoint, err := b.Next("", map[string]bool{"http://b": true})
+	require.NoError(t, err)
+	assert.Equal(t, "http://a", endpoint.URL)
+	_, err = b.Next("", map[string]bool{"http://a": true, "http://b": true})
+	assert.ErrorIs(t, err, balancer.ErrNoInstances)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Len(t, pickCounts(t, b, "", 2), 2, "instance returns after the ejection time")
+}
+
+func TestBalancerHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	instance := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer instance.Close()
+
+	b := newBalancer(t, balancer.StrategyRoundRobin, balancer.DefaultEjectionPolicy,
+		balancer.Instance{URL: instance.URL, HealthCheckURL: instance.URL + "/health"},
+		balancer.Instance{URL: "http://unchecked"})
+	policy := balancer.HealthCheckPolicy{Timeout: time.Second, UnhealthyThreshold: 2, HealthyThreshold: 2}
+	check := func() []balancer.HealthChange {
+		return b.CheckHealth(context.Background(), http.DefaultClient, policy)
+	}
+
+	assert.Empty(t, check())
+	healthy.Store(false)
+	assert.Empty(t, check(), "one failed check is not enough")
+	changes := check()
+	require.Len(t, changes, 1)
+	assert.Equal(t, instance.URL, changes[0].URL)
+	assert.False(t, changes[0].Healthy)
+	assert.Error(t, changes[0].Err)
+	assert.Equal(t, map[string]int{"http://unchecked": 2}, pickCounts(t, b, "", 2))
+
+	healthy.Store(true)
+	assert.Empty(t, check())
+	changes = check()
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].Healthy)
+	assert.Len(t, pickCounts(t, b, "", 2), 2)
+}
+
+func TestGatewayRetriesIdempotentRequests(t *testing.T) {
+	var served atomic.Int64
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte("ok "), body...))
+	}))
+	defer good.Close()
+	// Экземпляр, который перестал принимать соединения
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	config := &utils.Config{
+		Eureka: utils.Eureka{URL: "http://127.0.0.1:1/eureka"},
+		LoadBalancing: utils.LoadBalancing{
+			Ejection: utils.Ejection{ConsecutiveFailures: 100},
+			Retries:  utils.Retries{Attempts: 2, Methods: []string{"GET", "PUT"}},
+		},
+		// Выключатель недоступного экземпляра не должен размыкаться во время теста
+		CircuitBreaker: utils.CircuitBreakers{CircuitBreakerPolicy: utils.CircuitBreakerPolicy{MinRequests: 100}},
+		Routes: []utils.RouteConfig{
+			{Path: "/api/courses/:id", Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone},
+		},
+	}
+	server := api.NewServer(config, logger.New(logger.Options{Level: "error", Output: io.Discard, ErrorOutput: io.Discard}))
+	server.Discovery.SetServiceInstances("BACKEND-SERVICE", []balancer.Instance{{URL: good.URL}, {URL: down.URL}})
+	gateway := httptest.NewServer(server.Router)
+	defer gateway.Close()
+
+	do := func(method, body string) (int, string) {
+		req, err := http.NewRequest(method, gateway.URL+"/api/courses/1", strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	for i := 0; i < 4; i++ {
+		status, _ := do(http.MethodGet, "")
+		assert.Equal(t, http.StatusOK, status)
+		status, body := do(http.MethodPut, "payload")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok payload", body, "the body is sent again on retry")
+	}
+	assert.Equal(t, int64(8), served.Load())
+
+	// POST не повторяется: один из двух запросов попадает на недоступный экземпляр
+	statuses := make(map[int]int)
+	for i := 0; i < 2; i++ {
+		status, _ := do(http.MethodPost, "")
+		statuses[status]++
+	}
+	assert.Equal(t, map[int]int{http.StatusOK: 1, http.StatusBadGateway: 1}, statuses)
+
+	metrics := httptest.NewRecorder()
+	server.Router.ServeHTTP(metrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, metrics.Body.String(), `gateway_upstream_retries_total{service="BACKEND-SERVICE"}`)
+}
+
+func TestGatewayEjectsFailingInstance(t *testing.T) {
+	var badHits, goodHits atomic.Int64
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		goodHits.Add(1)
+	}))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		badHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer bad.Close()
+
+	config := &utils.Config{
+		Eureka: utils.Eureka{URL: "http://127.0.0.1:1/eureka"},
+		LoadBalancing: utils.LoadBalancing{
+			Ejection: utils.Ejection{ConsecutiveFailures: 2, BaseTime: 60},
+			Retries:  utils.Retries{Attempts: 1},
+		},
+		Routes: []utils.RouteConfig{
+			{Path: "/api/courses", Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone},
+		},
+	}
+	server := api.NewServer(config, logger.New(logger.Options{Level: "error", Output: io.Discard, ErrorOutput: io.Discard}))
+	server.Discovery.SetServiceInstances("BACKEND-SERVICE", []balancer.Instance{{URL: good.URL}, {URL: bad.URL}})
+	gateway := httptest.NewServer(server.Router)
+	defer gateway.Close()
+
+	for i := 0; i < 10; i++ {
+		resp, err := http.Get(gateway.URL + "/api/courses")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, int64(2), badHits.Load(), "the instance is ejected after two 503 responses")
+	assert.Equal(t, int64(8), goodHits.Load())
+}
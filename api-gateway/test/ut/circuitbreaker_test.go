@@ -0,0 +1,301 @@
+package ut
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/api"
+	"lmsmodule/api-gateway/internal/balancer"
+	"lmsmodule/api-gateway/internal/circuitbreaker"
+	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/logger"
+)
+
+type transitionLog struct {
+	mu          sync.Mutex
+	transitions []circuitbreaker.Transition
+}
+
+func (l *transitionLog) record(transition circuitbreaker.Transition) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.transitions = append(l.transitions, transition)
+}
+
+func (l *transitionLog) reasons() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var reasons []string
+	for _, transition := range l.transitions {
+		reasons = append(reasons, transition.From.String()+">"+transition.To.String()+":"+transition.Reason)
+	}
+	return reasons
+}
+
+func allow(t *testing.T, cb *circuitbreaker.CircuitBreaker, result circuitbreaker.Result) {
+	t.Helper()
+	done, allowed := cb.Allow()
+	require.True(t, allowed)
+	done(result)
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	var log transitionLog
+	policy := circuitbreaker.Policy{Window: time.Minute, FailureRate: 0.5, MinRequests: 4, HalfOpenRequests: 2, Timeout: 50 * time.Millisecond}
+	cb := circuitbreaker.NewCircuitBreaker("BACKEND-SERVICE", "http://a", policy, log.record)
+
+	// Исход запроса, начатого до размыкания, не влияет на новое состояние
+	late, allowed := cb.Allow()
+	require.True(t, allowed)
+
+	for i := 0; i < 3; i++ {
+		allow(t, cb, circuitbreaker.Failure)
+	}
+	allow(t, cb, circuitbreaker.Ignored)
+	assert.Equal(t, circuitbreaker.Closed, cb.GetState(), "three requests are below min_requests")
+	allow(t, cb, circuitbreaker.Success)
+	assert.Equal(t, circuitbreaker.Open, cb.GetState())
+	status := cb.Status()
+	assert.Equal(t, "open", status.State)
+	late(circuitbreaker.Success)
+
+	_, allowed = cb.Allow()
+	assert.False(t, allowed)
+
+	// После таймаута пропускается не больше half_open_requests пробных запросов
+	time.Sleep(60 * time.Millisecond)
+	first, allowed := cb.Allow()
+	require.True(t, allowed)
+	second, allowed := cb.Allow()
+	require.True(t, allowed)
+	_, allowed = cb.Allow()
+	assert.False(t, allowed, "probe limit reached")
+	assert.Equal(t, circuitbreaker.HalfOpen, cb.GetState())
+
+	first(circuitbreaker.Success)
+	first(circuitbreaker.Failure)
+	assert.Equal(t, circuitbreaker.HalfOpen, cb.GetState(), "done is counted once")
+	second(circuitbreaker.Failure)
+	assert.Equal(t, circuitbreaker.Open, cb.GetState())
+
+	time.Sleep(60 * time.Millisecond)
+	allow(t, cb, circuitbreaker.Success)
+	allow(t, cb, circuitbreaker.Success)
+	assert.Equal(t, circuitbreaker.Closed, cb.GetState())
+	assert.Zero(t, cb.Status().Requests, "closing starts a new window")
+
+	assert.Equal(t, []string{
+		"closed>open:failure_rate",
+		"open>half_open:timeout",
+		"half_open>open:probe_failed",
+		"open>half_open:timeout",
+		"half_open>closed:probes_succeeded",
+	}, log.reasons())
+	assert.Equal(t, "http://a", log.transitions[0].Instance)
+}
+
+func TestCircuitBreakerWindowAndForce(t *testing.T) {
+	policy := circuitbreaker.Policy{Window: 100 * time.Millisecond, FailureRate: 0.5, MinRequests: 2, Timeout: time.Hour}
+	cb := circuitbreaker.NewCircuitBreaker("EXECUTOR-SVC", "http://b", policy, nil)
+
+	allow(t, cb, circuitbreaker.Failure)
+	time.Sleep(120 * time.Millisecond)
+	allow(t, cb, circuitbreaker.Failure)
+	assert.Equal(t, circuitbreaker.Closed, cb.GetState(), "the first failure left the window")
+
+	require.NoError(t, cb.Force(circuitbreaker.Closed))
+	for i := 0; i < 5; i++ {
+		allow(t, cb, circuitbreaker.Failure)
+	}
+	assert.Equal(t, circuitbreaker.Closed, cb.GetState(), "a forced breaker ignores failures")
+	assert.True(t, cb.Status().Forced)
+
+	require.NoError(t, cb.Force(circuitbreaker.Open))
+	_, allowed := cb.Allow()
+	assert.False(t, allowed)
+	assert.Error(t, cb.Force(circuitbreaker.HalfOpen))
+
+	cb.Reset()
+	assert.False(t, cb.Status().Forced)
+	allow(t, cb, circuitbreaker.Failure)
+	allow(t, cb, circuitbreaker.Failure)
+	assert.Equal(t, circuitbreaker.Open, cb.GetState())
+}
+
+func TestCircuitBreakerRegistry(t *testing.T) {
+	config := utils.CircuitBreakers{
+		CircuitBreakerPolicy: utils.CircuitBreakerPolicy{MinRequests: 10, Timeout: 20},
+		Services:             map[string]utils.CircuitBreakerPolicy{"executor-svc": {MinRequests: 3}},
+	}
+	policy := config.Policy("EXECUTOR-SVC")
+	assert.Equal(t, 3, policy.MinRequests)
+	assert.Equal(t, 20*time.Second, policy.Timeout, "unset fields come from the default policy")
+	assert.Equal(t, circuitbreaker.DefaultPolicy.FailureRate, policy.FailureRate)
+	assert.Equal(t, 10, config.Policy("BACKEND-SERVICE").MinRequests)
+
+	registry := circuitbreaker.NewRegistry(config.Policy, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allow(t, registry.Get("BACKEND-SERVICE", "http://b"), circuitbreaker.Success)
+			allow(t, registry.Get("BACKEND-SERVICE", "http://a"), circuitbreaker.Success)
+		}()
+	}
+	wg.Wait()
+	assert.Same(t, registry.Get("BACKEND-SERVICE", "http://a"), registry.Get("BACKEND-SERVICE", "http://a"))
+	list := registry.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, "http://a", list[0].Instance)
+	assert.Equal(t, 20, list[1].Requests)
+
+	registry.Get("EXECUTOR-SVC", "http://e")
+	assert.Equal(t, 1, registry.Prune("backend-service", []string{"http://b"}))
+	list = registry.List()
+	require.Len(t, list, 2, "breakers of other services are kept")
+	assert.Equal(t, "http://b", list[0].Instance)
+	assert.Equal(t, "http://e", list[1].Instance)
+}
+
+func TestGatewayPerInstanceCircuitBreakers(t *testing.T) {
+	var goodHits, badHits atomic.Int64
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		goodHits.Add(1)
+	}))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		badHits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer bad.Close()
+
+	admin := signHS256(t, testSecret, jwt.MapClaims{"sub": 1, "roles": []string{"admin"}, "exp": time.Now().Add(time.Hour).Unix()})
+	demoted := signHS256(t, testSecret, jwt.MapClaims{"sub": 3, "roles": []string{"admin"}, "exp": time.Now().Add(time.Hour).Unix()})
+	teacher := signHS256(t, testSecret, validClaims())
+	// Сервис прав знает только первого администратора: роль второго в токене устарела
+	var checkFails atomic.Bool
+	users := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path != "/api/admin/access" || checkFails.Load():
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Header.Get("Authorization") != "Bearer "+admin:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer users.Close()
+
+	config := &utils.Config{
+		Eureka: utils.Eureka{URL: "http://127.0.0.1:1/eureka"},
+		JWT:    utils.JWT{Enabled: true, Secret: testSecret, AdminCheck: utils.AdminCheck{Service: "USER-SERVICE"}},
+		CircuitBreaker: utils.CircuitBreakers{
+			CircuitBreakerPolicy: utils.CircuitBreakerPolicy{MinRequests: 2, FailureRate: 0.5, Timeout: 3600},
+		},
+		Routes: []utils.RouteConfig{
+			{Path: "/api/courses", Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone},
+		},
+	}
+	var logs syncBuffer
+	server := api.NewServer(config, logger.New(logger.Options{Level: "info", Output: &logs, ErrorOutput: &logs}))
+	server.Discovery.SetServiceInstances("BACKEND-SERVICE", []balancer.Instance{{URL: good.URL}, {URL: bad.URL}})
+	server.Discovery.SetServiceInstances("USER-SERVICE", []balancer.Instance{{URL: users.URL}})
+	gateway := httptest.NewServer(server.Router)
+	defer gateway.Close()
+
+	do := func(method, path, token string) (int, []byte) {
+		req, err := http.NewRequest(method, gateway.URL+path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+
+	// Ответы 500 не повторяются: выключатель размыкается только у сбоящего экземпляра
+	for i := 0; i < 10; i++ {
+		do(http.MethodPost, "/api/courses", "")
+	}
+	assert.Equal(t, int64(2), badHits.Load())
+	assert.Equal(t, int64(8), goodHits.Load())
+	assert.Contains(t, logs.String(), `"msg":"circuit breaker opened"`)
+
+	status, body := do(http.MethodGet, "/api/admin/circuit-breakers", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = do(http.MethodGet, "/api/admin/circuit-breakers", teacher)
+	require.Equal(t, http.StatusForbidden, status)
+	status, _ = do(http.MethodPost, "/api/admin/circuit-breakers/backend-service/close", demoted)
+	require.Equal(t, http.StatusForbidden, status, "the admin role in the token is not trusted without the service")
+	checkFails.Store(true)
+	status, _ = do(http.MethodGet, "/api/admin/circuit-breakers", admin)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	checkFails.Store(false)
+
+	status, body = do(http.MethodGet, "/api/admin/circuit-breakers", admin)
+	require.Equal(t, http.StatusOK, status)
+	var list struct {
+		CircuitBreakers []circuitbreaker.Status `json:"circuit_breakers"`
+	}
+	require.NoError(t, json.Unmarshal(body, &list))
+	states := make(map[string]string)
+	for _, breaker := range list.CircuitBreakers {
+		states[breaker.Instance] = breaker.State
+	}
+	assert.Equal(t, map[string]string{good.URL: "closed", bad.URL: "open"}, states)
+
+	// Выключатели всех экземпляров разомкнуты вручную: шлюз отвечает 503 без обращения к сервису
+	status, _ = do(http.MethodPost, "/api/admin/circuit-breakers/backend-service/open", admin)
+	require.Equal(t, http.StatusOK, status)
+	status, body = do(http.MethodGet, "/api/courses", "")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.JSONEq(t, `{"error":"Service temporarily unavailable"}`, string(body))
+	assert.Equal(t, int64(8), goodHits.Load())
+
+	status, _ = do(http.MethodPost, "/api/admin/circuit-breakers/backend-service/reset?instance="+good.URL, admin)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = do(http.MethodGet, "/api/courses", "")
+	assert.Equal(t, http.StatusOK, status)
+
+	status, _ = do(http.MethodPost, "/api/admin/circuit-breakers/backend-service/break", admin)
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = do(http.MethodPost, "/api/admin/circuit-breakers/backend-service/open?instance=http://unknown", admin)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	metrics := httptest.NewRecorder()
+	server.Router.ServeHTTP(metrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, metrics.Body.String(), `gateway_circuit_breaker_state{service="BACKEND-SERVICE",instance="`+bad.URL+`",state="open"} 1`)
+	assert.Contains(t, metrics.Body.String(), `gateway_circuit_breaker_transitions_total{service="BACKEND-SERVICE",instance="`+good.URL+`",state="closed"} 1`)
+
+	// Выключатель экземпляра, пропавшего из реестра, удаляется
+	server.Discovery.SetServiceInstances("BACKEND-SERVICE", []balancer.Instance{{URL: good.URL}})
+	breakers := server.CircuitBreakers.List()
+	require.Len(t, breakers, 1)
+	assert.Equal(t, good.URL, breakers[0].Instance)
+}
+
+func TestCircuitBreakerAdminWithoutVerification(t *testing.T) {
+	config := &utils.Config{Eureka: utils.Eureka{URL: "http://127.0.0.1:1/eureka"}}
+	var logs syncBuffer
+	server := api.NewServer(config, logger.New(logger.Options{Level: "warn", Output: &logs, ErrorOutput: &logs}))
+	assert.Contains(t, logs.String(), "/api/admin/circuit-breakers rejects all requests", "startup warns that the API needs jwt.enabled")
+
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/admin/circuit-breakers", nil))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/admin/circuit-breakers/BACKEND-SERVICE/open", nil))
+	assert.Equal(t, http.StatusForbidden, recorder.Code, "without token verification the gateway cannot check the admin role")
+}
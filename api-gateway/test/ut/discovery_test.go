@@ -0,0 +1,263 @@
+package ut
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+	"lmsmodule/api-gateway/internal/api"
+	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/discovery"
+	"lmsmodule/api-gateway/pkg/logger"
+)
+
+// staticDiscovery возвращает настройки со списком static из одного экземпляра на сервис
+func staticDiscovery(services map[string]string) utils.Discovery {
+	static := make(map[string][]discovery.Instance, len(services))
+	for name, url := range services {
+		static[name] = []discovery.Instance{{URL: url}}
+	}
+	return utils.Discovery{Static: static}
+}
+
+func TestStaticDiscovery(t *testing.T) {
+	_, err := discovery.New(discovery.Config{Provider: "zookeeper"})
+	assert.Error(t, err)
+
+	d, err := discovery.New(discovery.Config{
+		Provider: discovery.ProviderStatic,
+		Static: map[string][]discovery.Instance{
+			"backend-service": {{URL: "http://a:8081", Weight: 2}},
+		},
+	})
+	require.NoError(t, err)
+	instances, err := d.Instances(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]discovery.Instance{
+		"BACKEND-SERVICE": {{URL: "http://a:8081", Weight: 2}},
+	}, instances, "service names are upper-cased as in Eureka")
+	assert.NoError(t, d.Register(context.Background(), discovery.Registration{Service: "backend-service"}))
+}
+
+func TestFileDiscoveryReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	_, err := discovery.NewFile(path, time.Millisecond)
+	assert.Error(t, err, "a missing file fails at startup")
+
+	require.NoError(t, os.WriteFile(path, []byte("BACKEND-SERVICE:\n  - url: http://a:8081\n"), 0o644))
+	f, err := discovery.NewFile(path, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := f.Watch(ctx)
+
+	data := `{"BACKEND-SERVICE": [{"url": "http://a:8081"}, {"url": "http://b:8081", "health_check_url": "http://b:8081/api/health"}]}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("file change was not reported")
+	}
+	instances, err := f.Instances(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []discovery.Instance{
+		{URL: "http://a:8081"},
+		{URL: "http://b:8081", HealthCheckURL: "http://b:8081/api/health"},
+	}, instances["BACKEND-SERVICE"])
+
+	cancel()
+	for range changes {
+	}
+}
+
+// serveSRV отвечает на запросы SRV записями records
+func serveSRV(t *testing.T, records map[string][]dnsmessage.SRVResource) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var request dnsmessage.Message
+			if err := request.Unpack(buf[:n]); err != nil || len(request.Questions) == 0 {
+				continue
+			}
+			question := request.Questions[0]
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: request.ID, Response: true, Authoritative: true},
+				Questions: request.Questions,
+			}
+			srvs, ok := records[question.Name.String()]
+			if !ok {
+				response.RCode = dnsmessage.RCodeNameError
+			}
+			if question.Type == dnsmessage.TypeSRV {
+				for i := range srvs {
+					response.Answers = append(response.Answers, dnsmessage.Resource{
+						Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: 30},
+						Body:   &srvs[i],
+					})
+				}
+			}
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSDiscovery(t *testing.T) {
+	server := serveSRV(t, map[string][]dnsmessage.SRVResource{
+		"_http._tcp.backend.test.": {
+			{Priority: 10, Weight: 3, Port: 8081, Target: dnsmessage.MustNewName("a.backend.test.")},
+			{Priority: 10, Weight: 1, Port: 8082, Target: dnsmessage.MustNewName("b.backend.test.")},
+			{Priority: 20, Weight: 1, Port: 8083, Target: dnsmessage.MustNewName("backup.backend.test.")},
+		},
+	})
+
+	d := discovery.NewDNS(map[string]string{
+		"backend-service": "_http._tcp.backend.test",
+		"missing-service": "_http._tcp.missing.test",
+	}, server)
+	instances, err := d.Instances(context.Background())
+	require.NoError(t, err, "an unresolved record does not hide the others")
+
+	backend := instances["BACKEND-SERVICE"]
+	sort.Slice(backend, func(i, j int) bool { return backend[i].URL < backend[j].URL })
+	assert.Equal(t, []discovery.Instance{
+		{URL: "http://a.backend.test:8081", Weight: 3},
+		{URL: "http://b.backend.test:8082", Weight: 1},
+	}, backend, "only records of the lowest priority are used")
+	assert.NotContains(t, instances, "MISSING-SERVICE")
+
+	_, err = discovery.NewDNS(map[string]string{"missing-service": "_http._tcp.missing.test"}, server).Instances(context.Background())
+	assert.Error(t, err)
+}
+
+// recordingDiscovery запоминает вызовы регистрации
+type recordingDiscovery struct {
+	discovery.Static
+	mu          sync.Mutex
+	calls       []string
+	registerErr error
+}
+
+func (r *recordingDiscovery) record(call string, err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+	return err
+}
+
+func (r *recordingDiscovery) Register(context.Context, discovery.Registration) error {
+	r.mu.Lock()
+	err := r.registerErr
+	r.registerErr = nil
+	r.mu.Unlock()
+	return r.record("register", err)
+}
+
+func (r *recordingDiscovery) Heartbeat(context.Context, discovery.Registration) error {
+	return r.record("heartbeat", nil)
+}
+
+func (r *recordingDiscovery) Deregister(context.Context, discovery.Registration) error {
+	return r.record("deregister", nil)
+}
+
+func (r *recordingDiscovery) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func TestDiscoveryKeepRetriesRegistration(t *testing.T) {
+	d := &recordingDiscovery{registerErr: assert.AnError}
+	log := logger.New(logger.Options{Level: "error", Output: io.Discard, ErrorOutput: io.Discard})
+	stop := discovery.Keep(d, discovery.Registration{Service: "backend-service", Host: "127.0.0.1", Port: 8081}, 10*time.Millisecond, log)
+
+	assert.Eventually(t, func() bool {
+		calls := d.Calls()
+		return len(calls) >= 3 && calls[2] == "heartbeat"
+	}, 2*time.Second, 5*time.Millisecond)
+	stop()
+	stop()
+
+	calls := d.Calls()
+	assert.Equal(t, []string{"register", "register", "heartbeat"}, calls[:3], "failed registration is retried before heartbeats")
+	assert.Equal(t, "deregister", calls[len(calls)-1])
+	assert.Equal(t, 1, countCalls(calls, "deregister"))
+}
+
+func countCalls(calls []string, call string) int {
+	count := 0
+	for _, c := range calls {
+		if c == call {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGatewayWithFileDiscovery(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("from file instance"))
+	}))
+	defer backend.Close()
+
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0o644))
+
+	config := &utils.Config{
+		Discovery: utils.Discovery{Provider: discovery.ProviderFile, File: path, RefreshInterval: 3600},
+		Routes: []utils.RouteConfig{
+			{Path: "/api/courses", Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone},
+		},
+	}
+	require.NoError(t, config.ValidateDiscovery())
+	server := api.NewServer(config, logger.New(logger.Options{Level: "error", Output: io.Discard, ErrorOutput: io.Discard}))
+	gateway := httptest.NewServer(server.Router)
+	defer gateway.Close()
+
+	// Сервис без адреса в конфигурации и без экземпляров в файле неизвестен шлюзу
+	resp, err := http.Get(gateway.URL + "/api/courses")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	// Новый экземпляр подхватывается после изменения файла, без ожидания refresh_interval
+	require.NoError(t, os.WriteFile(path, []byte("backend-service:\n  - url: "+backend.URL+"\n"), 0o644))
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(gateway.URL + "/api/courses")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode == http.StatusOK && string(body) == "from file instance"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	health := httptest.NewRecorder()
+	server.Router.ServeHTTP(health, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, health.Code)
+}
@@ -0,0 +1,137 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/api"
+	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/logger"
+)
+
+// syncBuffer - буфер журнала, который читает тест, пока шлюз пишет в него из своих горутин
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLoggerJSON(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(logger.Options{Level: "info", Output: &out})
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	base := log.WithFields(map[string]interface{}{"service": "gateway", "attempt": 2})
+	base.WithTime(at).WithFields(map[string]interface{}{
+		"error":    errors.New("boom"),
+		"password": "hunter2",
+		"otp_code": "123456",
+	}).Info("login %s", "failed")
+	log.Debug("hidden")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 1)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "2024-05-01T12:00:00Z", entry["time"])
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "login failed", entry["msg"])
+	assert.Contains(t, entry["caller"], "logger_test.go:")
+	assert.Equal(t, "gateway", entry["service"])
+	assert.Equal(t, 2.0, entry["attempt"])
+	assert.Equal(t, "boom", entry["error"])
+	assert.Equal(t, logger.Redacted, entry["password"])
+	assert.Equal(t, logger.Redacted, entry["otp_code"])
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "123456")
+
+	// Исходный журнал не получает полей производного
+	out.Reset()
+	log.Info("plain")
+	assert.NotContains(t, out.String(), "service")
+}
+
+func TestLoggerLogfmtAndLevels(t *testing.T) {
+	var out, errOut bytes.Buffer
+	log := logger.New(logger.Options{Level: "warn", Format: "logfmt", Output: &out, ErrorOutput: &errOut})
+	log.WithField("path", "/api/courses").WithField("note", `say "hi"`).Warn("slow request")
+	log.Info("hidden")
+
+	assert.Empty(t, out.String())
+	line := errOut.String()
+	assert.Contains(t, line, "level=warn ")
+	assert.Contains(t, line, `msg="slow request"`)
+	assert.Contains(t, line, "path=/api/courses")
+	assert.Contains(t, line, `note="say \"hi\""`)
+	assert.True(t, strings.HasSuffix(line, "\n"))
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get(logger.RequestIDHeader))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	config := &utils.Config{
+		Eureka:    utils.Eureka{URL: "http://127.0.0.1:1/eureka"},
+		Discovery: staticDiscovery(map[string]string{"BACKEND-SERVICE": backend.URL}),
+		Routes: []utils.RouteConfig{
+			{Path: "/api/courses", Methods: []string{"GET"}, Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone},
+		},
+	}
+	var out syncBuffer
+	gateway := httptest.NewServer(api.NewServer(config, logger.New(logger.Options{Level: "info", Output: &out})).Router)
+	defer gateway.Close()
+
+	do := func(requestID string) string {
+		req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/api/courses?token=secret-value", nil)
+		if requestID != "" {
+			req.Header.Set(logger.RequestIDHeader, requestID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return resp.Header.Get(logger.RequestIDHeader)
+	}
+
+	assert.Equal(t, "client-req-1", do("client-req-1"))
+	generated := do("")
+	assert.Len(t, generated, 32)
+	replaced := do("bad id\twith spaces")
+	assert.NotEqual(t, "bad id\twith spaces", replaced)
+
+	mu.Lock()
+	assert.Equal(t, []string{"client-req-1", generated, replaced}, received)
+	mu.Unlock()
+
+	logs := out.String()
+	assert.Contains(t, logs, `"request_id":"client-req-1"`)
+	assert.Contains(t, logs, `"route":"/api/courses"`)
+	assert.NotContains(t, logs, "secret-value")
+}
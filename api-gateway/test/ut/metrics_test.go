@@ -0,0 +1,106 @@
+package ut
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/api"
+	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/logger"
+	prom "lmsmodule/api-gateway/pkg/metrics"
+)
+
+func TestRegistryTextFormat(t *testing.T) {
+	registry := prom.NewRegistry()
+	counter := registry.NewCounterVec("test_requests_total", "Requests.", "path")
+	counter.Inc(`/a"b`)
+	counter.Add(2, `/a"b`)
+	histogram := registry.NewHistogramVec("test_duration_seconds", "Duration.", []float64{0.1, 1}, "path")
+	histogram.Observe(0.05, "/")
+	histogram.Observe(0.5, "/")
+	histogram.Observe(5, "/")
+	registry.NewGaugeFunc("test_instances", "Instances.", []string{"service"}, func() []prom.Sample {
+		return []prom.Sample{{Labels: []string{"b"}, Value: 2}, {Labels: []string{"a"}, Value: 1}}
+	})
+
+	var out bytes.Buffer
+	registry.Write(&out)
+	text := out.String()
+
+	assert.Contains(t, text, "# TYPE test_requests_total counter\n")
+	assert.Contains(t, text, `test_requests_total{path="/a\"b"} 3`+"\n")
+	assert.Contains(t, text, "# TYPE test_duration_seconds histogram\n")
+	assert.Contains(t, text, `test_duration_seconds_bucket{path="/",le="0.1"} 1`+"\n")
+	assert.Contains(t, text, `test_duration_seconds_bucket{path="/",le="1"} 2`+"\n")
+	assert.Contains(t, text, `test_duration_seconds_bucket{path="/",le="+Inf"} 3`+"\n")
+	assert.Contains(t, text, `test_duration_seconds_count{path="/"} 3`+"\n")
+	assert.Contains(t, text, `test_instances{service="a"} 1`+"\n"+`test_instances{service="b"} 2`+"\n")
+
+	count, sum := histogram.Snapshot("/")
+	assert.Equal(t, uint64(3), count)
+	assert.InDelta(t, 5.55, sum, 1e-9)
+
+	assert.Panics(t, func() { registry.NewCounterVec("test_requests_total", "Again.") })
+}
+
+func TestHTTPMetricsMiddleware(t *testing.T) {
+	registry := prom.NewRegistry()
+	httpMetrics := prom.NewHTTPMetrics(registry, "test")
+	router := gin.New()
+	router.Use(httpMetrics.Middleware())
+	router.GET("/courses/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/courses/1", "/courses/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PROPFIND", "/courses/1", nil))
+
+	assert.Equal(t, 2.0, httpMetrics.Requests("/courses/:id", "GET", "204"))
+	assert.Equal(t, 1.0, httpMetrics.Requests("unmatched", "GET", "404"))
+	assert.Equal(t, 1.0, httpMetrics.Requests("unmatched", "OTHER", "404"))
+}
+
+func TestGatewayMetricsEndpoint(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	config := &utils.Config{
+		Discovery: staticDiscovery(map[string]string{"BACKEND-SERVICE": backend.URL}),
+		Eureka:    utils.Eureka{URL: "http://127.0.0.1:1/eureka"},
+		Routes: []utils.RouteConfig{
+			{Path: "/api/courses/:id", Methods: []string{"GET"}, Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone},
+		},
+	}
+	server := api.NewServer(config, logger.NewLogger("error"))
+	gateway := httptest.NewServer(server.Router)
+	defer gateway.Close()
+
+	resp, err := http.Get(gateway.URL + "/api/courses/7")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(gateway.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+	body, _ := io.ReadAll(resp.Body)
+	text := string(body)
+
+	assert.Contains(t, text, `gateway_http_requests_total{route="/api/courses/:id",method="GET",status="200"} 1`)
+	assert.Contains(t, text, `gateway_http_request_duration_seconds_count{route="/api/courses/:id",method="GET"} 1`)
+	assert.Contains(t, text, `gateway_upstream_requests_total{service="BACKEND-SERVICE"} 1`)
+	assert.Contains(t, text, `gateway_circuit_breaker_state{service="BACKEND-SERVICE",instance="`+backend.URL+`",state="closed"} 1`)
+	assert.Contains(t, text, `gateway_circuit_breaker_state{service="BACKEND-SERVICE",instance="`+backend.URL+`",state="open"} 0`)
+	assert.Contains(t, text, "# TYPE gateway_discovered_instances gauge")
+	assert.Equal(t, 1, server.Metrics.GetRequestCount("BACKEND-SERVICE"))
+}
@@ -4,10 +4,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"lmsmodule/api-gateway/internal/middleware"
+	"lmsmodule/api-gateway/internal/ratelimit"
 	"lmsmodule/api-gateway/pkg/logger"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestLoggerMiddleware(t *testing.T) {
@@ -24,7 +26,8 @@ func TestLoggerMiddleware(t *testing.T) {
 }
 
 func TestRateLimiterMiddleware(t *testing.T) {
-	mw := middleware.RateLimiterMiddleware()
+	policy := ratelimit.Policy{Algorithm: ratelimit.SlidingWindow, Requests: 100, Window: time.Minute, Key: ratelimit.KeyIP}
+	mw := middleware.RateLimit(ratelimit.NewLimiter("default", policy, ratelimit.NewMemoryStore()))
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
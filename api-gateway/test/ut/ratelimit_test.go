@@ -0,0 +1,186 @@
+package ut
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/auth"
+	"lmsmodule/api-gateway/internal/middleware"
+	"lmsmodule/api-gateway/internal/ratelimit"
+)
+
+func TestTokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	// 60 запросов в минуту - токен в секунду, всплеск до 3
+	policy := ratelimit.Policy{Algorithm: ratelimit.TokenBucket, Requests: 60, Window: time.Minute, Burst: 3, Key: ratelimit.KeyIP}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		decision, err := store.Allow("a", policy, now)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed, "request %d", i)
+		assert.Equal(t, 2-i, decision.Remaining)
+		assert.Equal(t, 3, decision.Limit)
+	}
+
+	decision, _ := store.Allow("a", policy, now)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+	assert.Equal(t, 3*time.Second, decision.Reset)
+
+	decision, _ = store.Allow("a", policy, now.Add(500*time.Millisecond))
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 500*time.Millisecond, decision.RetryAfter)
+
+	decision, _ = store.Allow("a", policy, now.Add(time.Second))
+	assert.True(t, decision.Allowed)
+
+	decision, _ = store.Allow("b", policy, now)
+	assert.True(t, decision.Allowed, "keys are limited independently")
+
+	// Корзина не переполняется сверх Burst
+	for i := 0; i < 3; i++ {
+		decision, _ = store.Allow("a", policy, now.Add(time.Hour))
+		assert.True(t, decision.Allowed)
+	}
+	decision, _ = store.Allow("a", policy, now.Add(time.Hour))
+	assert.False(t, decision.Allowed)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	policy := ratelimit.Policy{Algorithm: ratelimit.SlidingWindow, Requests: 10, Window: time.Minute, Key: ratelimit.KeyIP}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 10; i++ {
+		decision, err := store.Allow("a", policy, start.Add(time.Duration(i)*time.Second))
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed, "request %d", i)
+		assert.Equal(t, 9-i, decision.Remaining)
+	}
+
+	decision, _ := store.Allow("a", policy, start.Add(30*time.Second))
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 30*time.Second, decision.Reset)
+	// Окно станет предыдущим через 30 с, и еще 6 с, пока его вес не опустится до 9 запросов
+	assert.Equal(t, 36*time.Second, decision.RetryAfter)
+
+	// В начале следующего окна предыдущее учитывается почти целиком
+	decision, _ = store.Allow("a", policy, start.Add(61*time.Second))
+	assert.False(t, decision.Allowed)
+
+	decision, _ = store.Allow("a", policy, start.Add(66*time.Second))
+	assert.True(t, decision.Allowed)
+	decision, _ = store.Allow("a", policy, start.Add(66*time.Second))
+	assert.False(t, decision.Allowed)
+
+	// Через два окна клиент снова как новый
+	for i := 0; i < 10; i++ {
+		decision, _ = store.Allow("a", policy, start.Add(3*time.Minute))
+		assert.True(t, decision.Allowed)
+	}
+}
+
+func TestMemoryStoreEvictsIdleKeys(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	bucket := ratelimit.Policy{Algorithm: ratelimit.TokenBucket, Requests: 60, Window: time.Minute, Key: ratelimit.KeyIP}
+	window := ratelimit.Policy{Algorithm: ratelimit.SlidingWindow, Requests: 10, Window: time.Minute, Key: ratelimit.KeyIP}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, _ = store.Allow("bucket|"+key, bucket, now)
+		_, _ = store.Allow("window|"+key, window, now)
+	}
+	assert.Equal(t, 6, store.Len())
+
+	// Корзины полны через секунду, окна устаревают через две минуты
+	_, _ = store.Allow("bucket|d", bucket, now.Add(90*time.Second))
+	assert.Equal(t, 4, store.Len())
+
+	_, _ = store.Allow("bucket|d", bucket, now.Add(4*time.Minute))
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	policy := ratelimit.Policy{Algorithm: ratelimit.SlidingWindow, Requests: 2, Window: time.Minute, Key: ratelimit.KeyUser}
+	verifier, err := auth.NewVerifier(auth.Config{Secret: "secret"})
+	require.NoError(t, err)
+	newRouter := func(verifier *auth.Verifier) *gin.Engine {
+		router := gin.New()
+		router.GET("/courses",
+			middleware.Authenticate(verifier, false),
+			middleware.RateLimit(ratelimit.NewLimiter("relaxed", policy, ratelimit.NewMemoryStore())),
+			func(c *gin.Context) { c.Status(http.StatusOK) },
+		)
+		return router
+	}
+
+	token := func(sub interface{}, secret string) string {
+		claims := jwt.MapClaims{"sub": sub, "exp": time.Now().Add(time.Hour).Unix()}
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+	do := func(router *gin.Engine, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/courses", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Verified users", func(t *testing.T) {
+		router := newRouter(verifier)
+		w := do(router, token(1, "secret"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+		assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+
+		assert.Equal(t, http.StatusOK, do(router, token(1, "secret")).Code)
+		w = do(router, token(1, "secret"))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		// Другой пользователь с того же IP считается отдельно
+		assert.Equal(t, http.StatusOK, do(router, token("2", "secret")).Code)
+		// Запросы без токена и с поддельным токеном считаются по IP
+		assert.Equal(t, http.StatusOK, do(router, "").Code)
+		assert.Equal(t, http.StatusOK, do(router, token(1, "forged")).Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(router, "Bearer not-a-jwt").Code)
+	})
+
+	t.Run("Unverified tokens", func(t *testing.T) {
+		// Без проверки токенов субъект не различает клиентов: смена sub не обнуляет лимит
+		router := newRouter(nil)
+		assert.Equal(t, http.StatusOK, do(router, token(1, "secret")).Code)
+		assert.Equal(t, http.StatusOK, do(router, token(2, "secret")).Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(router, token(3, "secret")).Code)
+	})
+}
+
+func TestRateLimitPolicyValidate(t *testing.T) {
+	valid := ratelimit.Policy{Algorithm: ratelimit.TokenBucket, Requests: 1, Window: time.Second, Key: ratelimit.KeyAPIKey}
+	assert.NoError(t, valid.Validate())
+
+	invalid := []ratelimit.Policy{
+		{Algorithm: "leaky_bucket", Requests: 1, Window: time.Second, Key: ratelimit.KeyIP},
+		{Algorithm: ratelimit.SlidingWindow, Requests: 0, Window: time.Second, Key: ratelimit.KeyIP},
+		{Algorithm: ratelimit.SlidingWindow, Requests: 1, Window: 0, Key: ratelimit.KeyIP},
+		{Algorithm: ratelimit.TokenBucket, Requests: 1, Window: time.Second, Burst: -1, Key: ratelimit.KeyIP},
+		{Algorithm: ratelimit.SlidingWindow, Requests: 1, Window: time.Second, Key: "cookie"},
+	}
+	for _, policy := range invalid {
+		assert.Error(t, policy.Validate(), "%+v", policy)
+	}
+}
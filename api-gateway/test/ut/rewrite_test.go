@@ -0,0 +1,162 @@
+package ut
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/rewrite"
+)
+
+func TestRewriteApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		route     string
+		rules     rewrite.Rules
+		params    map[string]string
+		url       string
+		headers   map[string]string
+		wantPath  string
+		wantQuery string
+		wantHead  map[string]string
+	}{
+		{
+			name:     "no rules",
+			route:    "/api/courses/:id",
+			url:      "/api/courses/7?page=2",
+			params:   map[string]string{"id": "7"},
+			wantPath: "/api/courses/7", wantQuery: "page=2",
+		},
+		{
+			name:     "strip prefix",
+			route:    "/api/executor/execute",
+			rules:    rewrite.Rules{StripPrefix: "/api/executor"},
+			url:      "/api/executor/execute",
+			wantPath: "/execute",
+		},
+		{
+			name:     "strip prefix keeps params",
+			route:    "/api/executor/result/:session_id",
+			rules:    rewrite.Rules{StripPrefix: "/api/executor"},
+			params:   map[string]string{"session_id": "abc"},
+			url:      "/api/executor/result/abc",
+			wantPath: "/result/abc",
+		},
+		{
+			name:     "strip whole path",
+			route:    "/api/executor",
+			rules:    rewrite.Rules{StripPrefix: "/api/executor"},
+			url:      "/api/executor",
+			wantPath: "/",
+		},
+		{
+			name:     "path template",
+			route:    "/api/labs/:lab_id/runs/:run_id",
+			rules:    rewrite.Rules{Path: "/runs/:run_id/lab/:lab_id"},
+			params:   map[string]string{"lab_id": "3", "run_id": "r1"},
+			url:      "/api/labs/3/runs/r1",
+			wantPath: "/runs/r1/lab/3",
+		},
+		{
+			name:     "path template with wildcard",
+			route:    "/api/files/*path",
+			rules:    rewrite.Rules{Path: "/storage/*path"},
+			params:   map[string]string{"path": "/a/b.txt"},
+			url:      "/api/files/a/b.txt",
+			wantPath: "/storage/a/b.txt",
+		},
+		{
+			name:     "regex with numbered group",
+			route:    "/api/v1/reports/:id",
+			rules:    rewrite.Rules{Regex: `^/api/v1/(.*)$`, Replacement: "/api/$1"},
+			url:      "/api/v1/reports/5",
+			wantPath: "/api/reports/5",
+		},
+		{
+			name:     "regex with named group after strip",
+			route:    "/api/executor/sessions/:session_id/log",
+			rules:    rewrite.Rules{StripPrefix: "/api/executor", Regex: `^/sessions/(?P<id>[^/]+)/log$`, Replacement: "/logs/${id}"},
+			url:      "/api/executor/sessions/s9/log",
+			wantPath: "/logs/s9",
+		},
+		{
+			name:     "regex without match leaves path",
+			route:    "/api/courses",
+			rules:    rewrite.Rules{Regex: `^/v2/(.*)$`, Replacement: "/$1"},
+			url:      "/api/courses",
+			wantPath: "/api/courses",
+		},
+		{
+			name:     "replacement without leading slash",
+			route:    "/api/executor/execute",
+			rules:    rewrite.Rules{Regex: `^/api/executor/`, Replacement: ""},
+			url:      "/api/executor/execute",
+			wantPath: "/execute",
+		},
+		{
+			name:     "headers",
+			route:    "/api/courses",
+			rules:    rewrite.Rules{SetHeaders: map[string]string{"X-Source": "gateway", "Accept": "application/json"}, RemoveHeaders: []string{"Cookie"}},
+			url:      "/api/courses",
+			headers:  map[string]string{"Cookie": "session=1", "Accept": "text/html", "Authorization": "Bearer t"},
+			wantPath: "/api/courses",
+			wantHead: map[string]string{"X-Source": "gateway", "Accept": "application/json", "Cookie": "", "Authorization": "Bearer t"},
+		},
+		{
+			name:     "query",
+			route:    "/api/search",
+			rules:    rewrite.Rules{SetQuery: map[string]string{"limit": "20", "format": "json"}, RemoveQuery: []string{"debug"}},
+			url:      "/api/search?q=go&debug=1&limit=500",
+			wantPath: "/api/search", wantQuery: "format=json&limit=20&q=go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewriter, err := rewrite.New(tt.route, tt.rules)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rewriter.Apply(req, func(name string) string { return tt.params[name] })
+
+			assert.Equal(t, tt.wantPath, req.URL.Path)
+			assert.Equal(t, tt.wantQuery, req.URL.RawQuery)
+			for name, value := range tt.wantHead {
+				assert.Equal(t, value, req.Header.Get(name), name)
+			}
+		})
+	}
+}
+
+func TestRewriteNewRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		route string
+		rules rewrite.Rules
+		err   string
+	}{
+		{"path with strip prefix", "/api/a", rewrite.Rules{Path: "/a", StripPrefix: "/api"}, "cannot be combined"},
+		{"relative path", "/api/a", rewrite.Rules{Path: "a"}, "path must start with /"},
+		{"unknown parameter", "/api/courses/:id", rewrite.Rules{Path: "/courses/:course_id"}, `unknown path parameter "course_id"`},
+		{"prefix with trailing slash", "/api/a", rewrite.Rules{StripPrefix: "/api/"}, "must not end with /"},
+		{"prefix of another segment", "/api/executor/run", rewrite.Rules{StripPrefix: "/api/exec"}, "is not a prefix"},
+		{"bad regex", "/api/a", rewrite.Rules{Regex: "(", Replacement: "/"}, "regex"},
+		{"replacement without regex", "/api/a", rewrite.Rules{Replacement: "/b"}, "replacement requires regex"},
+		{"bad header name", "/api/a", rewrite.Rules{SetHeaders: map[string]string{"X Bad": "1"}}, "invalid header name"},
+		{"header injection", "/api/a", rewrite.Rules{SetHeaders: map[string]string{"X-Good": "1\r\nX-Evil: 1"}}, "line breaks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rewrite.New(tt.route, tt.rules)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+	assert.True(t, rewrite.Rules{}.IsZero())
+	assert.False(t, rewrite.Rules{RemoveQuery: []string{"debug"}}.IsZero())
+}
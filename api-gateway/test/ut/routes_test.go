@@ -2,11 +2,22 @@ package ut
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"lmsmodule/api-gateway/internal/api"
+	"lmsmodule/api-gateway/internal/ratelimit"
+	"lmsmodule/api-gateway/internal/rewrite"
+	"lmsmodule/api-gateway/internal/routecheck"
+	"lmsmodule/api-gateway/internal/utils"
 	"lmsmodule/api-gateway/pkg/logger"
 )
 
@@ -22,9 +33,246 @@ func TestSetupRoutes(t *testing.T) {
 
 	public := router.Group("/api")
 	{
-		public.Any("/register", server.ProxyRequest("http://auth.service"))
-		public.Any("/login", server.ProxyRequest("http://auth.service"))
+		public.Any("/register", server.ProxyRoute(utils.RouteConfig{Path: "/api/register", Service: "AUTH-SERVICE"}))
+		public.Any("/login", server.ProxyRoute(utils.RouteConfig{Path: "/api/login", Service: "AUTH-SERVICE"}))
 	}
 
 	assert.NotNil(t, router.Routes())
 }
+
+func TestGatewayConfigRoutes(t *testing.T) {
+	config, err := utils.LoadConfig("../../configs/config.yaml")
+	require.NoError(t, err)
+
+	routes := make(map[string]utils.RouteConfig)
+	for _, route := range config.Routes {
+		routes[route.Path] = route
+	}
+
+	for path, method := range map[string]string{
+		"/api/progress/:user_id/submissions":           http.MethodGet,
+		"/api/progress/:user_id/tasks/:task_id/submit": http.MethodPost,
+		"/api/progress/:user_id/learning-path":         http.MethodGet,
+	} {
+		route, ok := routes[path]
+		require.True(t, ok, path)
+		assert.Equal(t, []string{method}, route.Methods, path)
+		assert.Equal(t, "BACKEND-SERVICE", route.Service, path)
+		assert.Equal(t, utils.AuthRequired, route.Auth, path)
+		assert.Equal(t, "default", route.RateLimit, path)
+		assert.Equal(t, 30, route.TimeoutSeconds(), path)
+	}
+
+	assert.Equal(t, utils.AuthPublic, routes["/api/login"].Auth)
+	assert.Equal(t, "strict", routes["/api/login"].RateLimit)
+	assert.Equal(t, "relaxed", routes["/api/courses"].RateLimit)
+	assert.Equal(t, ratelimit.KeyUser, config.RateLimits["relaxed"].Policy().Key)
+	assert.Equal(t, 0, routes["/api/events/stream"].TimeoutSeconds())
+	assert.Equal(t, "EXECUTOR-SVC", routes["/api/executor/result/:session_id"].Service)
+	assert.Equal(t, "/api/executor", routes["/api/executor/result/:session_id"].Rewrite.StripPrefix)
+}
+
+func TestValidateRoutes(t *testing.T) {
+	timeout := func(seconds int) *int { return &seconds }
+	valid := func() utils.RouteConfig {
+		return utils.RouteConfig{
+			Path: "/api/courses/:id", Methods: []string{"GET"}, Service: "BACKEND-SERVICE",
+			Auth: utils.AuthRequired, RateLimit: "default", Timeout: timeout(30),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(routes []utils.RouteConfig) []utils.RouteConfig
+		err    string
+	}{
+		{"valid", func(routes []utils.RouteConfig) []utils.RouteConfig { return routes }, ""},
+		{"no routes", func([]utils.RouteConfig) []utils.RouteConfig { return nil }, "no routes configured"},
+		{"relative path", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			routes[0].Path = "api/courses"
+			return routes
+		}, "path must start with /"},
+		{"missing service", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			routes[0].Service = ""
+			return routes
+		}, "service is required"},
+		{"unknown method", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			routes[0].Methods = []string{"FETCH"}
+			return routes
+		}, `unsupported method "FETCH"`},
+		{"unknown auth", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			routes[0].Auth = "optional"
+			return routes
+		}, "auth must be"},
+		{"unknown rate limit", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			routes[0].RateLimit = "strict"
+			return routes
+		}, `unknown rate limit policy "strict"`},
+		{"negative timeout", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			routes[0].Timeout = timeout(-1)
+			return routes
+		}, "timeout must not be negative"},
+		{"rewrite with unknown parameter", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			routes[0].Rewrite = rewrite.Rules{Path: "/courses/:course_id"}
+			return routes
+		}, `unknown path parameter "course_id"`},
+		{"duplicate", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			return append(routes, valid())
+		}, "duplicate route"},
+		{"conflicting wildcards", func(routes []utils.RouteConfig) []utils.RouteConfig {
+			other := valid()
+			other.Path = "/api/courses/:course_id/tasks"
+			return append(routes, other)
+		}, "/api/courses/:course_id/tasks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &utils.Config{
+				RateLimits: map[string]utils.RateLimitPolicy{"default": {Requests: 100, Window: 60}},
+				Routes:     tt.modify([]utils.RouteConfig{valid()}),
+			}
+			err := config.ValidateRoutes()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestLoadConfigAppliesRouteDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rate_limits:
+  strict: {requests: 5, window: 60}
+route_defaults:
+  service: BACKEND-SERVICE
+  auth: required
+  rate_limit: strict
+  timeout: 15
+routes:
+  - path: /api/login
+    methods: [post]
+    auth: public
+  - path: /api/events/stream
+    timeout: 0
+`), 0o600))
+
+	config, err := utils.LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, config.Routes, 2)
+	assert.Equal(t, []string{"POST"}, config.Routes[0].Methods)
+	assert.Equal(t, utils.AuthPublic, config.Routes[0].Auth)
+	assert.Equal(t, "strict", config.Routes[0].RateLimit)
+	assert.Equal(t, 15, config.Routes[0].TimeoutSeconds())
+	assert.Equal(t, "BACKEND-SERVICE", config.Routes[1].Service)
+	assert.Equal(t, 0, config.Routes[1].TimeoutSeconds())
+
+	require.NoError(t, os.WriteFile(path, []byte("routes:\n  - path: /api/login\n"), 0o600))
+	_, err = utils.LoadConfig(path)
+	assert.ErrorContains(t, err, "service is required")
+
+	require.NoError(t, os.WriteFile(path, []byte("jwt:\n  enabled: true\nroutes:\n  - path: /api/login\n    service: BACKEND-SERVICE\n    auth: public\n    rate_limit: none\n"), 0o600))
+	_, err = utils.LoadConfig(path)
+	assert.ErrorContains(t, err, "jwt secret or jwks file is required")
+	t.Setenv("JWT_SECRET", "secret")
+	config, err = utils.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", config.JWT.Secret)
+}
+
+func TestConfigRoutesProxy(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/slow" {
+			time.Sleep(1500 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	timeout := 1
+	config := &utils.Config{
+		Discovery:  staticDiscovery(map[string]string{"BACKEND-SERVICE": backend.URL, "EXECUTOR-SVC": backend.URL}),
+		Eureka:     utils.Eureka{URL: "http://127.0.0.1:1/eureka"},
+		RateLimits: map[string]utils.RateLimitPolicy{"strict": {Requests: 1, Window: 60}},
+		Routes: []utils.RouteConfig{
+			{Path: "/api/courses", Methods: []string{"GET"}, Service: "BACKEND-SERVICE", Auth: utils.AuthRequired, RateLimit: utils.RateLimitNone},
+			{Path: "/api/login", Methods: []string{"POST"}, Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: "strict"},
+			{Path: "/api/executor/result/:session_id", Methods: []string{"GET"}, Service: "EXECUTOR-SVC", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone, Rewrite: rewrite.Rules{StripPrefix: "/api/executor"}},
+			{Path: "/api/slow", Methods: []string{"GET"}, Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone, Rewrite: rewrite.Rules{Path: "/slow"}, Timeout: &timeout},
+		},
+	}
+	require.NoError(t, config.ValidateRoutes())
+	gateway := httptest.NewServer(api.NewServer(config, logger.NewLogger("error")).Router)
+	defer gateway.Close()
+
+	do := func(method, path string, authorized bool) int {
+		req, _ := http.NewRequest(method, gateway.URL+path, nil)
+		if authorized {
+			req.Header.Set("Authorization", "Bearer token")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/courses", false))
+	assert.Equal(t, http.StatusOK, do("GET", "/api/courses", true))
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/courses", true))
+
+	assert.Equal(t, http.StatusOK, do("POST", "/api/login", false))
+	assert.Equal(t, http.StatusTooManyRequests, do("POST", "/api/login", false))
+
+	assert.Equal(t, http.StatusOK, do("GET", "/api/executor/result/abc", false))
+	assert.Equal(t, http.StatusGatewayTimeout, do("GET", "/api/slow", false))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"GET /api/courses", "POST /api/login", "GET /result/abc", "GET /slow"}, received)
+}
+
+func TestRoutecheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swagger.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"swagger": "2.0",
+		"basePath": "/api",
+		"paths": {
+			"/courses": {"get": {}, "parameters": []},
+			"/courses/{id}": {"get": {}, "delete": {}},
+			"/progress/{user_id}/submissions": {"get": {}},
+			"/internal/events": {"post": {}}
+		}
+	}`), 0o600))
+
+	spec, err := routecheck.LoadSpec(path)
+	require.NoError(t, err)
+	assert.Len(t, spec.Endpoints, 5)
+
+	routes := []utils.RouteConfig{
+		{Path: "/api/courses", Service: "BACKEND-SERVICE"},
+		{Path: "/api/courses/:course_id", Methods: []string{"GET"}, Service: "BACKEND-SERVICE"},
+		{Path: "/api/search", Methods: []string{"GET"}, Service: "BACKEND-SERVICE"},
+		{Path: "/api/executor/execute", Methods: []string{"POST"}, Service: "EXECUTOR-SVC", Rewrite: rewrite.Rules{StripPrefix: "/api/executor"}},
+	}
+	report := routecheck.Check(routes, "BACKEND-SERVICE", spec, []string{"/api/internal/"})
+
+	var unmapped []string
+	for _, endpoint := range report.Unmapped {
+		unmapped = append(unmapped, endpoint.String())
+	}
+	assert.Equal(t, []string{"DELETE /api/courses/{id}", "GET /api/progress/{user_id}/submissions"}, unmapped)
+	require.Len(t, report.Undocumented, 1)
+	assert.Equal(t, "/api/search", report.Undocumented[0].Path)
+
+	assert.Equal(t, "/api/courses/{}/tasks/{}", routecheck.Normalize("/api/courses/:id/tasks/{task_id}/"))
+	assert.True(t, strings.HasSuffix(routecheck.Normalize("/files/*path"), "{}"))
+}
@@ -0,0 +1,85 @@
+package ut
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/api"
+	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/logger"
+)
+
+func TestProxyStreamsEvents(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/events/stream" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: 1\nevent: grading\ndata: {}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer backend.Close()
+	defer close(release)
+
+	config, err := utils.LoadConfig("../../configs/config.yaml")
+	require.NoError(t, err)
+	config.Discovery.Static = staticDiscovery(map[string]string{"BACKEND-SERVICE": backend.URL}).Static
+	config.Eureka = utils.Eureka{URL: "http://127.0.0.1:1/eureka"}
+	gateway := httptest.NewServer(api.NewServer(config, logger.NewLogger("error")).Router)
+	defer gateway.Close()
+
+	req, _ := http.NewRequest("GET", gateway.URL+"/api/events/stream", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := make(chan string)
+	go func() {
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				close(lines)
+				return
+			}
+			lines <- strings.TrimSpace(line)
+		}
+	}()
+
+	var received []string
+	timeout := time.After(5 * time.Second)
+	for len(received) < 3 {
+		select {
+		case line := <-lines:
+			received = append(received, line)
+		case <-timeout:
+			t.Fatalf("event was buffered by the gateway, received %v", received)
+		}
+	}
+	assert.Equal(t, []string{"id: 1", "event: grading", "data: {}"}, received)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	req, _ = http.NewRequest("GET", gateway.URL+"/api/courses", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	other, err := client.Do(req)
+	require.NoError(t, err, "an open stream must not block other requests")
+	other.Body.Close()
+	assert.Equal(t, http.StatusOK, other.StatusCode)
+}
@@ -0,0 +1,210 @@
+package ut
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lmsmodule/api-gateway/internal/api"
+	"lmsmodule/api-gateway/internal/utils"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/api-gateway/pkg/tracing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	_, err = tracing.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	assert.NoError(t, err, "later versions may append fields")
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, err := tracing.ParseTraceparent(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func readSpans(t *testing.T, r io.Reader) []tracing.SpanData {
+	var spans []tracing.SpanData
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var span tracing.SpanData
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+func TestTracerSpans(t *testing.T) {
+	var out syncBuffer
+	tracer := tracing.NewTracer("test", tracing.NewJSONExporter(&out), tracing.Options{})
+
+	header := http.Header{}
+	header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, server := tracer.Start(tracing.Extract(context.Background(), header), "GET /courses/:id", tracing.SpanKindServer)
+	childCtx, child := tracing.StartSpan(ctx, "storage.GetCourseByID", tracing.SpanKindInternal)
+	child.SetAttribute("db.operation", "GetCourseByID")
+	child.RecordError(assert.AnError)
+	child.End()
+	child.End()
+
+	outgoing := http.Header{}
+	tracing.Inject(childCtx, outgoing)
+	assert.Equal(t, child.Context().Traceparent(), outgoing.Get(tracing.TraceparentHeader))
+	server.End()
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+	spans := readSpans(t, strings.NewReader(out.String()))
+	require.Len(t, spans, 2)
+	assert.Equal(t, "storage.GetCourseByID", spans[0].Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].TraceID)
+	assert.Equal(t, server.Context().SpanID.String(), spans[0].ParentSpanID)
+	assert.Equal(t, assert.AnError.Error(), spans[0].Error)
+	assert.Equal(t, "GetCourseByID", spans[0].Attributes["db.operation"])
+	assert.Equal(t, "server", spans[1].Kind)
+	assert.Equal(t, "00f067aa0ba902b7", spans[1].ParentSpanID)
+	assert.Equal(t, "test", spans[1].Service)
+
+	// Без трассировщика спаны не создаются, а методы nil-спана ничего не делают
+	var disabled *tracing.Tracer
+	ctx, span := disabled.Start(context.Background(), "noop", tracing.SpanKindServer)
+	assert.Nil(t, span)
+	span.SetAttribute("key", "value")
+	span.End()
+	_, span = tracing.StartSpan(ctx, "child", tracing.SpanKindInternal)
+	assert.Nil(t, span)
+}
+
+func TestTracerRespectsSampledFlag(t *testing.T) {
+	var out syncBuffer
+	tracer := tracing.NewTracer("test", tracing.NewJSONExporter(&out), tracing.Options{})
+	header := http.Header{}
+	header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, span := tracer.Start(tracing.Extract(context.Background(), header), "unsampled", tracing.SpanKindServer)
+	span.End()
+	require.NoError(t, tracer.Shutdown(context.Background()))
+	assert.Empty(t, out.String())
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		requests = append(requests, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	tracer := tracing.NewTracer("api-gateway", tracing.NewOTLPExporter(collector.URL), tracing.Options{})
+	ctx, parent := tracer.Start(context.Background(), "GET /api/courses", tracing.SpanKindServer)
+	_, child := tracing.StartSpan(ctx, "proxy BACKEND-SERVICE", tracing.SpanKindClient)
+	child.SetAttribute("http.status_code", 502)
+	child.RecordError(assert.AnError)
+	child.End()
+	parent.End()
+	require.NoError(t, tracer.Flush(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 1)
+	resource := requests[0]["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	serviceName := resource["resource"].(map[string]interface{})["attributes"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "service.name", serviceName["key"])
+	assert.Equal(t, "api-gateway", serviceName["value"].(map[string]interface{})["stringValue"])
+
+	spans := resource["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+	require.Len(t, spans, 2)
+	client := spans[0].(map[string]interface{})
+	assert.Equal(t, child.Context().TraceID.String(), client["traceId"])
+	assert.Equal(t, parent.Context().SpanID.String(), client["parentSpanId"])
+	assert.Equal(t, 3.0, client["kind"])
+	assert.Equal(t, 2.0, client["status"].(map[string]interface{})["code"])
+	attribute := client["attributes"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "http.status_code", attribute["key"])
+	assert.Equal(t, "502", attribute["value"].(map[string]interface{})["intValue"])
+}
+
+func TestGatewayTracing(t *testing.T) {
+	var mu sync.Mutex
+	var traceparents []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents = append(traceparents, r.Header.Get(tracing.TraceparentHeader))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	spanFile := filepath.Join(t.TempDir(), "spans.json")
+	config := &utils.Config{
+		Discovery: staticDiscovery(map[string]string{"BACKEND-SERVICE": backend.URL}),
+		Eureka:    utils.Eureka{URL: "http://127.0.0.1:1/eureka"},
+		Tracing:   utils.Tracing{Exporter: tracing.ExporterFile, File: spanFile},
+		Routes: []utils.RouteConfig{
+			{Path: "/api/courses/:id", Methods: []string{"GET"}, Service: "BACKEND-SERVICE", Auth: utils.AuthPublic, RateLimit: utils.RateLimitNone},
+		},
+	}
+	var logs syncBuffer
+	server := api.NewServer(config, logger.New(logger.Options{Level: "info", Output: &logs}))
+	gateway := httptest.NewServer(server.Router)
+	defer gateway.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/api/courses/7", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Tracer.Shutdown(ctx))
+
+	file, err := os.Open(spanFile)
+	require.NoError(t, err)
+	defer file.Close()
+	spans := readSpans(t, file)
+	require.Len(t, spans, 2)
+	proxySpan, serverSpan := spans[0], spans[1]
+	assert.Equal(t, "proxy BACKEND-SERVICE", proxySpan.Name)
+	assert.Equal(t, "GET /api/courses/:id", serverSpan.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.ParentSpanID)
+	assert.Equal(t, serverSpan.SpanID, proxySpan.ParentSpanID)
+	assert.Equal(t, 200.0, proxySpan.Attributes["http.status_code"])
+
+	mu.Lock()
+	assert.Equal(t, []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-" + proxySpan.SpanID + "-01"}, traceparents)
+	mu.Unlock()
+	assert.Contains(t, logs.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+}
@@ -0,0 +1,118 @@
+// Package achievements присуждает значки по декларативным правилам models.Badge.
+// Условия вычисляются по снимку данных пользователя Facts и не обращаются к хранилищу,
+// поэтому значки можно пересчитать по уже накопленному прогрессу. Новые типы условий
+// подключаются через Register.
+package achievements
+
+import (
+	"fmt"
+	"lmsmodule/backend-svc/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Facts - данные пользователя, по которым проверяются условия значков
+type Facts struct {
+	Snapshot    models.LearningSnapshot
+	Completions []models.CourseCompletion
+	// Ranks - место пользователя в рейтинге курса, ключ 0 - общий рейтинг.
+	// Отсутствие ключа означает, что пользователь не попал в рейтинг.
+	Ranks map[int]int
+	// Location - часовой пояс, по которому считаются дни серии; nil - UTC
+	Location *time.Location
+	Now      time.Time
+}
+
+// Criterion - тип условия значка
+type Criterion interface {
+	Name() string
+	// Validate проверяет параметры условия при настройке значка
+	Validate(criteria models.BadgeCriteria) error
+	// Evaluate сообщает, выполнено ли условие, и момент его выполнения
+	Evaluate(criteria models.BadgeCriteria, facts Facts) (bool, time.Time)
+}
+
+var (
+	mu       sync.RWMutex
+	criteria = map[string]Criterion{}
+)
+
+func init() {
+	Register(TasksCompleted{})
+	Register(CoursesCompleted{})
+	Register(StreakDays{})
+	Register(TasksWithoutHints{})
+	Register(LeaderboardRank{})
+}
+
+// Register добавляет тип условия или заменяет тип с тем же именем
+func Register(criterion Criterion) {
+	mu.Lock()
+	defer mu.Unlock()
+	criteria[criterion.Name()] = criterion
+}
+
+// Lookup возвращает тип условия по имени
+func Lookup(name string) (Criterion, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	criterion, ok := criteria[name]
+	return criterion, ok
+}
+
+// Names возвращает имена зарегистрированных типов условий в алфавитном порядке
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(criteria))
+	for name := range criteria {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate проверяет, что тип условия известен и его параметры допустимы
+func Validate(c models.BadgeCriteria) error {
+	criterion, ok := Lookup(c.Type)
+	if !ok {
+		return fmt.Errorf("unknown criteria type %q, expected one of %v", c.Type, Names())
+	}
+	return criterion.Validate(c)
+}
+
+// Evaluate возвращает значки, условия которых выполнены. Неактивные значки
+// и значки с неизвестным типом условия пропускаются.
+func Evaluate(badges []models.Badge, facts Facts) []models.UserBadge {
+	var awarded []models.UserBadge
+	for _, badge := range badges {
+		criterion, ok := Lookup(badge.Criteria.Type)
+		if !badge.IsActive || !ok {
+			continue
+		}
+		if achieved, at := criterion.Evaluate(badge.Criteria, facts); achieved {
+			awarded = append(awarded, models.UserBadge{
+				BadgeID:     badge.ID,
+				Code:        badge.Code,
+				Name:        badge.Name,
+				Description: badge.Description,
+				Icon:        badge.Icon,
+				AwardedAt:   at,
+			})
+		}
+	}
+	return awarded
+}
+
+// LeaderboardLimits возвращает рейтинги, которые нужны активным значкам:
+// курс (0 - общий рейтинг) и сколько первых мест загрузить
+func LeaderboardLimits(badges []models.Badge) map[int]int {
+	limits := make(map[int]int)
+	for _, badge := range badges {
+		if badge.IsActive && badge.Criteria.Type == CriterionLeaderboardRank && badge.Criteria.Threshold > limits[badge.Criteria.CourseID] {
+			limits[badge.Criteria.CourseID] = badge.Criteria.Threshold
+		}
+	}
+	return limits
+}
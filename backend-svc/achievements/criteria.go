@@ -0,0 +1,188 @@
+package achievements
+
+import (
+	"errors"
+	"lmsmodule/backend-svc/models"
+	"sort"
+	"time"
+)
+
+// Типы условий значков
+const (
+	CriterionTasksCompleted    = "tasks_completed"
+	CriterionCoursesCompleted  = "courses_completed"
+	CriterionStreakDays        = "streak_days"
+	CriterionTasksWithoutHints = "tasks_without_hints"
+	CriterionLeaderboardRank   = "leaderboard_rank"
+)
+
+var difficulties = map[string]bool{"easy": true, "medium": true, "hard": true}
+
+func validateThreshold(c models.BadgeCriteria) error {
+	if c.Threshold < 1 {
+		return errors.New("threshold must be at least 1")
+	}
+	return nil
+}
+
+func validateDifficulty(c models.BadgeCriteria) error {
+	if c.Difficulty != "" && !difficulties[c.Difficulty] {
+		return errors.New("difficulty must be easy, medium or hard")
+	}
+	return nil
+}
+
+// nth возвращает момент, когда набралось n событий
+func nth(times []time.Time, n int) (bool, time.Time) {
+	if n < 1 || len(times) < n {
+		return false, time.Time{}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return true, times[n-1]
+}
+
+func matchesTask(c models.BadgeCriteria, task models.LearningTask) bool {
+	return (c.CourseID == 0 || task.CourseID == c.CourseID) && (c.Difficulty == "" || task.Difficulty == c.Difficulty)
+}
+
+// TasksCompleted - выполнено не меньше Threshold задач, с фильтром по курсу и сложности
+type TasksCompleted struct{}
+
+func (TasksCompleted) Name() string { return CriterionTasksCompleted }
+
+func (TasksCompleted) Validate(c models.BadgeCriteria) error {
+	if err := validateThreshold(c); err != nil {
+		return err
+	}
+	return validateDifficulty(c)
+}
+
+func (TasksCompleted) Evaluate(c models.BadgeCriteria, facts Facts) (bool, time.Time) {
+	var times []time.Time
+	for _, task := range facts.Snapshot.Tasks {
+		if completedAt, done := facts.Snapshot.Completed[task.ID]; done && matchesTask(c, task) {
+			times = append(times, completedAt)
+		}
+	}
+	return nth(times, c.Threshold)
+}
+
+// CoursesCompleted - пройдено не меньше Threshold курсов; с CourseID - конкретный курс
+type CoursesCompleted struct{}
+
+func (CoursesCompleted) Name() string { return CriterionCoursesCompleted }
+
+func (CoursesCompleted) Validate(c models.BadgeCriteria) error {
+	return validateThreshold(c)
+}
+
+func (CoursesCompleted) Evaluate(c models.BadgeCriteria, facts Facts) (bool, time.Time) {
+	var times []time.Time
+	for _, completion := range facts.Completions {
+		if completion.IsCompleted && completion.CompletedAt != nil && (c.CourseID == 0 || completion.CourseID == c.CourseID) {
+			times = append(times, *completion.CompletedAt)
+		}
+	}
+	return nth(times, c.Threshold)
+}
+
+// StreakDays - учеба Threshold дней подряд. Днем учебы считается день, когда пользователь
+// выполнил задачу или отправил ответ; дни определяются в часовом поясе Facts.Location.
+type StreakDays struct{}
+
+func (StreakDays) Name() string { return CriterionStreakDays }
+
+func (StreakDays) Validate(c models.BadgeCriteria) error {
+	return validateThreshold(c)
+}
+
+func (StreakDays) Evaluate(c models.BadgeCriteria, facts Facts) (bool, time.Time) {
+	location := facts.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	var times []time.Time
+	for _, completedAt := range facts.Snapshot.Completed {
+		times = append(times, completedAt)
+	}
+	for _, attempt := range facts.Snapshot.Attempts {
+		times = append(times, attempt.SubmittedAt)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	streak := 0
+	var lastDay time.Time
+	for _, at := range times {
+		local := at.In(location)
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+		switch {
+		case streak > 0 && day.Equal(lastDay):
+			continue
+		case streak > 0 && day.Equal(lastDay.AddDate(0, 0, 1)):
+			streak++
+		default:
+			streak = 1
+		}
+		lastDay = day
+		if streak >= c.Threshold {
+			return true, at
+		}
+	}
+	return false, time.Time{}
+}
+
+// TasksWithoutHints - выполнены все задачи курса (или всех курсов) заданной сложности
+// без просмотра подсказок. По умолчанию проверяются сложные задачи.
+type TasksWithoutHints struct{}
+
+func (TasksWithoutHints) Name() string { return CriterionTasksWithoutHints }
+
+func (TasksWithoutHints) Validate(c models.BadgeCriteria) error {
+	return validateDifficulty(c)
+}
+
+func (TasksWithoutHints) Evaluate(c models.BadgeCriteria, facts Facts) (bool, time.Time) {
+	if c.Difficulty == "" {
+		c.Difficulty = "hard"
+	}
+
+	hints := make(map[int]int)
+	for _, summary := range facts.Snapshot.Activity {
+		hints[summary.TaskID] += summary.HintsViewed
+	}
+
+	var last time.Time
+	matched := 0
+	for _, task := range facts.Snapshot.Tasks {
+		if !matchesTask(c, task) {
+			continue
+		}
+		completedAt, done := facts.Snapshot.Completed[task.ID]
+		if !done || hints[task.ID] > 0 {
+			return false, time.Time{}
+		}
+		matched++
+		if completedAt.After(last) {
+			last = completedAt
+		}
+	}
+	return matched > 0, last
+}
+
+// LeaderboardRank - место в рейтинге курса (CourseID 0 - общий рейтинг) не ниже Threshold
+type LeaderboardRank struct{}
+
+func (LeaderboardRank) Name() string { return CriterionLeaderboardRank }
+
+func (LeaderboardRank) Validate(c models.BadgeCriteria) error {
+	return validateThreshold(c)
+}
+
+func (LeaderboardRank) Evaluate(c models.BadgeCriteria, facts Facts) (bool, time.Time) {
+	rank, ok := facts.Ranks[c.CourseID]
+	if !ok || rank < 1 || rank > c.Threshold {
+		return false, time.Time{}
+	}
+	return true, facts.Now
+}
@@ -0,0 +1,143 @@
+// Package certificate подписывает сертификаты о прохождении курсов и выводит их в SVG и PDF.
+//
+// Подпись Ed25519 ставится на каноническое представление сертификата (Payload), поэтому
+// любую выданную копию можно проверить открытым ключом без обращения к платформе.
+// QR-код сертификата ведет на страницу проверки и содержит код "<id>.<подпись>".
+package certificate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"lmsmodule/backend-svc/models"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Algorithm - алгоритм подписи сертификатов
+const Algorithm = "ed25519"
+
+// idAlphabet - алфавит Crockford base32 без похожих символов I, L, O, U
+const idAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	ErrInvalidKey  = errors.New("certificate signing key must be a 32-byte ed25519 seed")
+	ErrInvalidCode = errors.New("invalid certificate verification code")
+)
+
+// Signer подписывает и проверяет сертификаты
+type Signer struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// NewSigner создает подписчика из 32-байтного seed ключа Ed25519
+func NewSigner(seed []byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, ErrInvalidKey
+	}
+	private := ed25519.NewKeyFromSeed(seed)
+	return &Signer{private: private, public: private.Public().(ed25519.PublicKey)}, nil
+}
+
+// ParseSigner создает подписчика из seed в base64 (формат переменной окружения)
+func ParseSigner(encoded string) (*Signer, error) {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return NewSigner(seed)
+}
+
+// GenerateSigner создает подписчика со случайным ключом. Подписи такого ключа перестают
+// проверяться после перезапуска, поэтому он подходит только для разработки и тестов.
+func GenerateSigner() (*Signer, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return NewSigner(seed)
+}
+
+// PublicKey возвращает открытый ключ в base64
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.public)
+}
+
+// Sign возвращает подпись сертификата в base64url
+func (s *Signer) Sign(cert models.Certificate) string {
+	return base64.RawURLEncoding.EncodeToString(ed25519.Sign(s.private, Payload(cert)))
+}
+
+// Verify проверяет подпись сертификата
+func (s *Signer) Verify(cert models.Certificate) bool {
+	signature, err := base64.RawURLEncoding.DecodeString(cert.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(s.public, Payload(cert), signature)
+}
+
+// Payload - каноническое представление подписываемых полей сертификата.
+// Время берется в UTC с точностью до секунды, балл - с двумя знаками, как они хранятся в БД.
+func Payload(cert models.Certificate) []byte {
+	return []byte(strings.Join([]string{
+		"lms-certificate-v1",
+		cert.ID,
+		cert.FullName,
+		fmt.Sprint(cert.CourseID),
+		cert.CourseTitle,
+		fmt.Sprintf("%.2f", cert.Score),
+		cert.CompletedAt.UTC().Format(time.RFC3339),
+		cert.IssuedAt.UTC().Format(time.RFC3339),
+	}, "\n"))
+}
+
+// NewID генерирует уникальный идентификатор вида LMS-XXXX-XXXX-XXXX
+func NewID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := []byte("LMS-XXXX-XXXX-XXXX")
+	for i, n := 4, 0; i < len(id); i++ {
+		if id[i] == '-' {
+			continue
+		}
+		id[i] = idAlphabet[int(buf[n])%len(idAlphabet)]
+		n++
+	}
+	return string(id), nil
+}
+
+// Code - код проверки из QR: идентификатор и подпись сертификата
+func Code(cert models.Certificate) string {
+	return cert.ID + "." + cert.Signature
+}
+
+// VerificationURL возвращает адрес проверки сертификата, который кодируется в QR
+func VerificationURL(baseURL string, cert models.Certificate) string {
+	return baseURL + "?code=" + url.QueryEscape(Code(cert))
+}
+
+// ParseCode разбирает код проверки. Принимает код "<id>.<подпись>" или
+// отсканированный из QR адрес проверки целиком.
+func ParseCode(code string) (id, signature string, err error) {
+	code = strings.TrimSpace(code)
+	if strings.Contains(code, "://") {
+		parsed, err := url.Parse(code)
+		if err != nil {
+			return "", "", ErrInvalidCode
+		}
+		code = parsed.Query().Get("code")
+	}
+
+	id, signature, found := strings.Cut(code, ".")
+	if !found || id == "" || signature == "" {
+		return "", "", ErrInvalidCode
+	}
+	return id, signature, nil
+}
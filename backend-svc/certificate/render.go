@@ -0,0 +1,235 @@
+package certificate
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"lmsmodule/backend-svc/models"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+)
+
+const (
+	title     = "Certificate of Completion"
+	issuer    = "Cybersecurity Platform"
+	dateFmt   = "January 2, 2006"
+	quietZone = 4 // белое поле вокруг QR-кода в модулях
+)
+
+// Размеры страниц: SVG в пикселях (A4 альбомная, 96 dpi), PDF в пунктах
+const (
+	svgWidth, svgHeight = 1123, 794
+	pdfWidth, pdfHeight = 842, 595
+)
+
+// line - строка текста сертификата
+type line struct {
+	text string
+	size float64
+	bold bool
+}
+
+func certificateLines(cert models.Certificate) []line {
+	return []line{
+		{title, 40, true},
+		{"This certifies that", 18, false},
+		{cert.FullName, 32, true},
+		{"has successfully completed the course", 18, false},
+		{cert.CourseTitle, 26, true},
+		{"Score: " + strconv.FormatFloat(cert.Score, 'f', -1, 64) + "%    Completed: " + cert.CompletedAt.Format(dateFmt), 16, false},
+	}
+}
+
+func footerLines(cert models.Certificate) []string {
+	status := "Issued by " + issuer + " on " + cert.IssuedAt.Format(dateFmt)
+	if cert.IsRevoked() {
+		status = "REVOKED on " + cert.RevokedAt.Format(dateFmt)
+	}
+	return []string{status, "Certificate ID: " + cert.ID, "Verify by scanning the QR code"}
+}
+
+// qrModules кодирует адрес проверки в QR-код
+func qrModules(content string) (barcode.Barcode, error) {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return nil, fmt.Errorf("encode qr code: %w", err)
+	}
+	return code, nil
+}
+
+func isDark(code barcode.Barcode, x, y int) bool {
+	r, _, _, _ := code.At(x, y).RGBA()
+	return r == 0
+}
+
+// WriteSVG выводит сертификат в SVG. verifyURL кодируется в QR-код.
+func WriteSVG(w io.Writer, cert models.Certificate, verifyURL string) error {
+	code, err := qrModules(verifyURL)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`,
+		svgWidth, svgHeight, svgWidth, svgHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/>`, svgWidth, svgHeight)
+	fmt.Fprintf(&b, `<rect x="24" y="24" width="%d" height="%d" fill="none" stroke="#1f3b73" stroke-width="6"/>`, svgWidth-48, svgHeight-48)
+
+	y := 150.0
+	for _, l := range certificateLines(cert) {
+		weight := "normal"
+		if l.bold {
+			weight = "bold"
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%.0f" font-size="%.0f" font-weight="%s" text-anchor="middle" fill="#1f3b73">%s</text>`,
+			svgWidth/2, y, l.size, weight, escapeXML(l.text))
+		y += l.size*1.2 + 30
+	}
+
+	y = svgHeight - 130
+	for _, text := range footerLines(cert) {
+		fmt.Fprintf(&b, `<text x="70" y="%.0f" font-size="14" fill="#555555">%s</text>`, y, escapeXML(text))
+		y += 24
+	}
+
+	size := code.Bounds().Dx() + 2*quietZone
+	scale := 160.0 / float64(size)
+	fmt.Fprintf(&b, `<g transform="translate(%d %d) scale(%.4f)"><rect width="%d" height="%d" fill="#ffffff"/><path fill="#000000" d="`,
+		svgWidth-230, svgHeight-230, scale, size, size)
+	for y := 0; y < code.Bounds().Dy(); y++ {
+		for x := 0; x < code.Bounds().Dx(); x++ {
+			if isDark(code, x, y) {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+	b.WriteString(`"/></g></svg>`)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WritePDF выводит сертификат в одностраничный PDF. Используются стандартные шрифты
+// Helvetica без встраивания, поэтому кириллица транслитерируется, а остальные символы
+// вне WinAnsi заменяются на "?". Точное написание имени доступно в SVG и при проверке.
+func WritePDF(w io.Writer, cert models.Certificate, verifyURL string) error {
+	code, err := qrModules(verifyURL)
+	if err != nil {
+		return err
+	}
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "0.12 0.23 0.45 RG 4 w 20 20 %d %d re S\n", pdfWidth-40, pdfHeight-40)
+
+	y := 480.0
+	content.WriteString("0.12 0.23 0.45 rg\n")
+	for _, l := range certificateLines(cert) {
+		font := "F1"
+		if l.bold {
+			font = "F2"
+		}
+		size := l.size * 0.75
+		fmt.Fprintf(&content, "BT /%s %.1f Tf 70 %.1f Td (%s) Tj ET\n", font, size, y, pdfText(l.text))
+		y -= size*1.2 + 22
+	}
+
+	y = 110
+	content.WriteString("0.33 0.33 0.33 rg\n")
+	for _, text := range footerLines(cert) {
+		fmt.Fprintf(&content, "BT /F1 10 Tf 70 %.1f Td (%s) Tj ET\n", y, pdfText(text))
+		y -= 18
+	}
+
+	size := code.Bounds().Dx() + 2*quietZone
+	module := 120.0 / float64(size)
+	left, top := float64(pdfWidth-170), 170.0
+	content.WriteString("0 0 0 rg\n")
+	for y := 0; y < code.Bounds().Dy(); y++ {
+		for x := 0; x < code.Bounds().Dx(); x++ {
+			if isDark(code, x, y) {
+				fmt.Fprintf(&content, "%.3f %.3f %.3f %.3f re\n",
+					left+float64(x+quietZone)*module, top-float64(y+quietZone+1)*module, module, module)
+			}
+		}
+	}
+	content.WriteString("f\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>",
+			pdfWidth, pdfHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) /Producer (%s) >>", pdfText(title+" "+cert.ID), issuer),
+	}
+
+	var doc bytes.Buffer
+	doc.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = doc.Len()
+		fmt.Fprintf(&doc, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, len(objects), xref)
+
+	_, err = w.Write(doc.Bytes())
+	return err
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// pdfText переводит строку в WinAnsi и экранирует ее для строкового литерала PDF
+func pdfText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if latin, ok := cyrillic[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		if lower := unicode.ToLower(r); lower != r {
+			if latin, ok := cyrillic[lower]; ok {
+				if latin != "" {
+					b.WriteString(strings.ToUpper(latin[:1]) + latin[1:])
+				}
+				continue
+			}
+		}
+
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,202 @@
+// Package events доставляет события подключенным клиентам: уведомления, результаты проверки,
+// выполнения лабораторных и изменения рейтинга. Брокер передает события между экземплярами
+// сервиса, Hub раздает их подключениям своего экземпляра.
+package events
+
+import (
+	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/models"
+	"sync"
+	"time"
+)
+
+// Broker передает опубликованные события всем подписчикам, в том числе на других экземплярах сервиса
+type Broker interface {
+	Publish(event models.LiveEvent) error
+	// Subscribe регистрирует обработчик событий и возвращает функцию отмены подписки
+	Subscribe(handler func(models.LiveEvent)) func()
+}
+
+// subscribers - общий для брокеров список обработчиков
+type subscribers struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]func(models.LiveEvent)
+}
+
+func (s *subscribers) add(handler func(models.LiveEvent)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.handlers == nil {
+		s.handlers = make(map[int]func(models.LiveEvent))
+	}
+	s.nextID++
+	id := s.nextID
+	s.handlers[id] = handler
+
+	return func() {
+		s.mu.Lock()
+		delete(s.handlers, id)
+		s.mu.Unlock()
+	}
+}
+
+func (s *subscribers) dispatch(event models.LiveEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, handler := range s.handlers {
+		handler(event)
+	}
+}
+
+// MemoryBroker доставляет события в пределах одного процесса. Подходит для одного
+// экземпляра сервиса и для тестов.
+type MemoryBroker struct {
+	subscribers
+	idMu   sync.Mutex
+	lastID int
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+func (b *MemoryBroker) Publish(event models.LiveEvent) error {
+	b.idMu.Lock()
+	b.lastID++
+	event.ID = b.lastID
+	b.idMu.Unlock()
+
+	b.dispatch(event)
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(handler func(models.LiveEvent)) func() {
+	return b.add(handler)
+}
+
+// EventLog - общее для всех экземпляров хранилище событий
+type EventLog interface {
+	AppendLiveEvent(event models.LiveEvent) (models.LiveEvent, error)
+	GetLiveEventsAfter(afterID, limit int) ([]models.LiveEvent, error)
+	GetLastLiveEventID() (int, error)
+	DeleteLiveEventsBefore(before time.Time) (int, error)
+}
+
+// reorderWindow - сколько последних ID перечитывается при каждом чтении журнала. Автоинкремент
+// выдается при вставке, а видна запись после фиксации транзакции, поэтому событие с меньшим ID
+// может появиться в журнале позже события с большим.
+const reorderWindow = 100
+
+// StoreBroker передает события между экземплярами через общую базу данных: событие
+// записывается в журнал, а каждый экземпляр читает новые записи с интервалом Poll.
+// События, зафиксированные не по порядку ID, доставляются, если опоздали не больше чем
+// на reorderWindow записей.
+type StoreBroker struct {
+	subscribers
+	log       EventLog
+	lastID    int
+	batchSize int
+	retention time.Duration
+	// seen - доставленные ID из окна перечитывания
+	seen map[int]bool
+}
+
+// NewStoreBroker создает брокер, который доставляет события, записанные после его создания
+func NewStoreBroker(eventLog EventLog, retention time.Duration) (*StoreBroker, error) {
+	lastID, err := eventLog.GetLastLiveEventID()
+	if err != nil {
+		return nil, fmt.Errorf("get last event id: %w", err)
+	}
+	b := &StoreBroker{log: eventLog, lastID: lastID, batchSize: 500, retention: retention, seen: make(map[int]bool)}
+	// События, уже записанные в окне перечитывания, не доставляются
+	if _, err := b.scan(false); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *StoreBroker) Publish(event models.LiveEvent) error {
+	_, err := b.log.AppendLiveEvent(event)
+	return err
+}
+
+func (b *StoreBroker) Subscribe(handler func(models.LiveEvent)) func() {
+	return b.add(handler)
+}
+
+// Poll доставляет подписчикам события, записанные с прошлого чтения, и возвращает их число
+func (b *StoreBroker) Poll() (int, error) {
+	return b.scan(true)
+}
+
+// scan читает журнал начиная с окна перед последним прочитанным ID и отмечает новые события
+// прочитанными; с deliver они передаются подписчикам
+func (b *StoreBroker) scan(deliver bool) (int, error) {
+	delivered := 0
+	afterID := b.lastID - reorderWindow
+	if afterID < 0 {
+		afterID = 0
+	}
+	for {
+		batch, err := b.log.GetLiveEventsAfter(afterID, b.batchSize)
+		if err != nil {
+			return delivered, fmt.Errorf("get events: %w", err)
+		}
+		for _, event := range batch {
+			afterID = event.ID
+			if event.ID > b.lastID {
+				b.lastID = event.ID
+			}
+			if b.seen[event.ID] {
+				continue
+			}
+			b.seen[event.ID] = true
+			if deliver {
+				b.dispatch(event)
+				delivered++
+			}
+		}
+		if len(batch) < b.batchSize {
+			break
+		}
+	}
+
+	for id := range b.seen {
+		if id <= b.lastID-reorderWindow {
+			delete(b.seen, id)
+		}
+	}
+	return delivered, nil
+}
+
+// Start читает журнал событий каждые interval и удаляет записи старше retention.
+// Возвращает функцию остановки.
+func (b *StoreBroker) Start(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		lastCleanup := time.Now()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := b.Poll(); err != nil {
+					logger.Default().Error("Live events poll error: %v", err)
+				}
+				if b.retention > 0 && time.Since(lastCleanup) >= b.retention {
+					if _, err := b.log.DeleteLiveEventsBefore(time.Now().Add(-b.retention)); err != nil {
+						logger.Default().Error("Live events cleanup error: %v", err)
+					}
+					lastCleanup = time.Now()
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
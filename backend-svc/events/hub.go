@@ -0,0 +1,104 @@
+package events
+
+import (
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/models"
+	"sync"
+	"time"
+)
+
+// ClientBuffer - сколько событий ждут отправки медленному клиенту; при переполнении
+// новые события для него пропускаются
+const ClientBuffer = 32
+
+type client struct {
+	userID int
+	events chan models.LiveEvent
+}
+
+// Hub раздает события брокера подключениям текущего экземпляра сервиса
+type Hub struct {
+	broker      Broker
+	unsubscribe func()
+
+	mu      sync.RWMutex
+	clients map[int]map[*client]struct{}
+}
+
+func NewHub(broker Broker) *Hub {
+	hub := &Hub{broker: broker, clients: make(map[int]map[*client]struct{})}
+	hub.unsubscribe = broker.Subscribe(hub.deliver)
+	return hub
+}
+
+// Publish отправляет событие через брокер
+func (h *Hub) Publish(event models.LiveEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	return h.broker.Publish(event)
+}
+
+// Subscribe подключает клиента пользователя userID. Канал закрывается функцией отмены.
+func (h *Hub) Subscribe(userID int) (<-chan models.LiveEvent, func()) {
+	c := &client{userID: userID, events: make(chan models.LiveEvent, ClientBuffer)}
+
+	h.mu.Lock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*client]struct{})
+	}
+	h.clients[userID][c] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return c.events, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.clients[userID], c)
+			if len(h.clients[userID]) == 0 {
+				delete(h.clients, userID)
+			}
+			h.mu.Unlock()
+			close(c.events)
+		})
+	}
+}
+
+// Connections возвращает число подключенных клиентов
+func (h *Hub) Connections() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	count := 0
+	for _, clients := range h.clients {
+		count += len(clients)
+	}
+	return count
+}
+
+// Close отписывает Hub от брокера
+func (h *Hub) Close() {
+	h.unsubscribe()
+}
+
+func (h *Hub) deliver(event models.LiveEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if event.UserID != 0 {
+		h.send(h.clients[event.UserID], event)
+		return
+	}
+	for _, clients := range h.clients {
+		h.send(clients, event)
+	}
+}
+
+func (h *Hub) send(clients map[*client]struct{}, event models.LiveEvent) {
+	for c := range clients {
+		select {
+		case c.events <- event:
+		default:
+			logger.Default().Warn("Live event %d dropped for user %d: client is too slow", event.ID, c.userID)
+		}
+	}
+}
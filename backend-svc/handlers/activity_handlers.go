@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	maxActivityBatchSize = 500
+	// maxActivityDuration ограничивает одно событие time_on_task, чтобы забытая вкладка не искажала статистику
+	maxActivityDuration = 4 * 60 * 60
+	// maxActivityClockSkew - допустимое расхождение часов клиента и сервера
+	maxActivityClockSkew = 5 * time.Minute
+)
+
+// RecordLearningActivity принимает пакет событий учебной активности текущего пользователя
+// @Summary Record learning activity
+// @Description Accepts a batch of learning events from web and Android clients: task_opened, time_on_task (duration_seconds required), hint_viewed, submission.
+// @Description Events are always recorded for the authenticated user; user_id in the body is ignored. Missing timestamps default to the server time.
+// @Tags Progress
+// @Accept json
+// @Produce json
+// @Param request body models.ActivityBatchRequest true "Activity events"
+// @Success 200 {object} models.ActivityBatchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /activity [post]
+func RecordLearningActivity(c *gin.Context) {
+	userID := c.GetInt("userID")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var req models.ActivityBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request"})
+		return
+	}
+
+	if len(req.Events) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "At least one event is required"})
+		return
+	}
+	if len(req.Events) > maxActivityBatchSize {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Too many events in batch, maximum is " + strconv.Itoa(maxActivityBatchSize)})
+		return
+	}
+
+	now := time.Now()
+	for i := range req.Events {
+		if err := normalizeActivity(&req.Events[i], now); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Event " + strconv.Itoa(i) + ": " + err.Error()})
+			return
+		}
+	}
+
+	if err := store(c).RecordLearningActivities(userID, req.Events); err != nil {
+		if errors.Is(err, storage.ErrTaskNotFound) || errors.Is(err, storage.ErrActivityCourseMismatch) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to record activity: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ActivityBatchResponse{Accepted: len(req.Events)})
+}
+
+// normalizeActivity проверяет событие и приводит его к виду, который сохраняет хранилище
+func normalizeActivity(activity *models.LearningActivity, now time.Time) error {
+	if !models.IsValidActivityType(activity.ActivityType) {
+		return errors.New("invalid activity type " + strconv.Quote(activity.ActivityType))
+	}
+	if activity.TaskID <= 0 {
+		return errors.New("task_id is required")
+	}
+
+	if activity.ActivityType == models.ActivityTimeOnTask {
+		if activity.Duration <= 0 || activity.Duration > maxActivityDuration {
+			return errors.New("duration_seconds must be between 1 and " + strconv.Itoa(maxActivityDuration))
+		}
+	} else {
+		activity.Duration = 0
+	}
+
+	if activity.Timestamp.IsZero() {
+		activity.Timestamp = now
+	} else if activity.Timestamp.After(now.Add(maxActivityClockSkew)) {
+		return errors.New("timestamp is in the future")
+	}
+
+	if len(activity.Details) > 1024 {
+		activity.Details = activity.Details[:1024]
+	}
+
+	return nil
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"errors"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxAssignmentTitleLength = 255
+
+// CreateAssignment
+// @Summary Create an assignment with due dates
+// @Description Публикует набор задач курса со сроком сдачи для всего курса или одной группы.
+// @Description Без opens_at задание открывается сразу. late_policy задает штраф за просрочку.
+// @Tags Assignments
+// @Accept json
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param assignment body models.Assignment true "Assignment"
+// @Success 201 {object} models.Assignment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/assignments [post]
+func CreateAssignment(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return
+	}
+
+	var assignment models.Assignment
+	if err := c.ShouldBindJSON(&assignment); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	assignment.CourseID = courseID
+	assignment.CreatedBy = c.GetInt("userID")
+
+	if err := validateAssignment(&assignment); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	assignment, err = store(c).CreateAssignment(assignment)
+	if err != nil {
+		respondAssignmentError(c, err, "Failed to create assignment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, assignment)
+}
+
+// GetCourseAssignments
+// @Summary List assignments of a course
+// @Tags Assignments
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Success 200 {array} models.Assignment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/assignments [get]
+func GetCourseAssignments(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return
+	}
+
+	assignments, err := store(c).GetCourseAssignments(courseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve assignments: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// UpdateAssignment
+// @Summary Update an assignment
+// @Tags Assignments
+// @Accept json
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param assignment_id path int true "Assignment ID"
+// @Param assignment body models.Assignment true "Assignment"
+// @Success 200 {object} models.Assignment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/assignments/{assignment_id} [put]
+func UpdateAssignment(c *gin.Context) {
+	courseID, assignmentID, ok := assignmentPathIDs(c)
+	if !ok {
+		return
+	}
+
+	var assignment models.Assignment
+	if err := c.ShouldBindJSON(&assignment); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	assignment.ID = assignmentID
+	assignment.CourseID = courseID
+
+	if err := validateAssignment(&assignment); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	assignment, err := store(c).UpdateAssignment(assignment)
+	if err != nil {
+		respondAssignmentError(c, err, "Failed to update assignment")
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// DeleteAssignment
+// @Summary Delete an assignment
+// @Tags Assignments
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param assignment_id path int true "Assignment ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/assignments/{assignment_id} [delete]
+func DeleteAssignment(c *gin.Context) {
+	courseID, assignmentID, ok := assignmentPathIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := store(c).DeleteAssignment(courseID, assignmentID); err != nil {
+		respondAssignmentError(c, err, "Failed to delete assignment")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Assignment deleted successfully"})
+}
+
+// GrantExtension
+// @Summary Grant a student an extension
+// @Description Устанавливает индивидуальный срок студента по заданию. Срок должен быть позже общего срока задания.
+// @Tags Assignments
+// @Accept json
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param assignment_id path int true "Assignment ID"
+// @Param user_id path int true "Student ID"
+// @Param extension body models.AssignmentExtension true "Extended due date and reason"
+// @Success 200 {object} models.AssignmentExtension
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/assignments/{assignment_id}/extensions/{user_id} [put]
+func GrantExtension(c *gin.Context) {
+	courseID, assignmentID, ok := assignmentPathIDs(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var extension models.AssignmentExtension
+	if err := c.ShouldBindJSON(&extension); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	assignment, err := store(c).GetAssignment(courseID, assignmentID)
+	if err != nil {
+		respondAssignmentError(c, err, "Failed to grant extension")
+		return
+	}
+	if _, err := store(c).GetUserByID(userID); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found"})
+		return
+	}
+	if !extension.DueAt.After(assignment.DueAt) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Extended due date must be later than the assignment due date"})
+		return
+	}
+
+	extension.AssignmentID = assignmentID
+	extension.UserID = userID
+	extension.GrantedBy = c.GetInt("userID")
+	extension.Reason = strings.TrimSpace(extension.Reason)
+
+	if err := store(c).GrantExtension(extension); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to grant extension: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, extension)
+}
+
+// GetUserDeadlines
+// @Summary Get task deadlines of a user
+// @Description Действующие сроки задач пользователя с учетом индивидуальных продлений
+// @Tags Progress
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {array} models.TaskDeadline
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /progress/{user_id}/deadlines [get]
+func GetUserDeadlines(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	currentUserID := c.GetInt("userID")
+	if userID != currentUserID {
+		isAdmin, _ := CheckAdminRights(currentUserID)
+		isTeacher, _ := CheckTeacherRights(currentUserID)
+		if !isAdmin && !isTeacher {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Access denied"})
+			return
+		}
+	}
+
+	deadlines, err := store(c).GetUserDeadlines(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve deadlines: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadlines)
+}
+
+func assignmentPathIDs(c *gin.Context) (int, int, bool) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return 0, 0, false
+	}
+
+	assignmentID, err := strconv.Atoi(c.Param("assignment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid assignment ID"})
+		return 0, 0, false
+	}
+
+	return courseID, assignmentID, true
+}
+
+// validateAssignment проверяет задание и подставляет значения по умолчанию
+func validateAssignment(assignment *models.Assignment) error {
+	assignment.Title = strings.TrimSpace(assignment.Title)
+	if assignment.Title == "" || len(assignment.Title) > maxAssignmentTitleLength {
+		return errors.New("Title must be between 1 and 255 characters")
+	}
+
+	if len(assignment.TaskIDs) == 0 {
+		return errors.New("Assignment must contain at least one task")
+	}
+	seen := make(map[int]bool)
+	for _, taskID := range assignment.TaskIDs {
+		if taskID < 1 || seen[taskID] {
+			return errors.New("Task IDs must be positive and unique")
+		}
+		seen[taskID] = true
+	}
+
+	if assignment.CohortID != nil && *assignment.CohortID < 1 {
+		return errors.New("Invalid cohort_id")
+	}
+
+	if assignment.OpensAt.IsZero() {
+		assignment.OpensAt = time.Now().UTC()
+	}
+	if !assignment.OpensAt.Before(assignment.DueAt) {
+		return errors.New("Parameter opens_at must be earlier than due_at")
+	}
+
+	policy := assignment.LatePolicy
+	if policy.PenaltyPerDay < 0 || policy.PenaltyPerDay > 100 || policy.MaxPenalty < 0 || policy.MaxPenalty > 100 {
+		return errors.New("Late penalties must be between 0 and 100 percent")
+	}
+	if policy.GraceMinutes < 0 || policy.CloseAfterDays < 0 {
+		return errors.New("grace_minutes and close_after_days must not be negative")
+	}
+
+	return nil
+}
+
+func respondAssignmentError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrAssignmentNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Assignment not found"})
+	case errors.Is(err, storage.ErrCourseNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course not found"})
+	case errors.Is(err, storage.ErrTaskNotFound):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "All tasks must belong to the course"})
+	case errors.Is(err, storage.ErrCohortNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Cohort not found"})
+	case errors.Is(err, storage.ErrCourseNotInCohort):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Course is not assigned to the cohort"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message + ": " + err.Error()})
+	}
+}
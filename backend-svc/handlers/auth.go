@@ -7,9 +7,10 @@ import (
 	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/pquerna/otp/totp"
 	"golang.org/x/crypto/bcrypt"
+	"lmsmodule/api-gateway/pkg/logger"
 	"lmsmodule/backend-svc/mail"
+	"lmsmodule/backend-svc/metrics"
 	"lmsmodule/backend-svc/models"
-	"log"
 	"net/http"
 	"time"
 )
@@ -61,7 +62,7 @@ func RegisterHandler(c *gin.Context) {
 		IsTeacher:    req.IsTeacher,
 	}
 
-	err = Store.CreateUser(user)
+	err = store(c).CreateUser(user)
 	if err != nil {
 		if err.Error() == "username or email already exists" {
 			c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Username or email already exists"})
@@ -71,13 +72,13 @@ func RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	createdUser, err := Store.GetUserByUsername(user.Username)
+	createdUser, err := store(c).GetUserByUsername(user.Username)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "User created but failed to retrieve"})
 		return
 	}
 
-	token, err := createJWTToken(createdUser.ID)
+	token, err := createJWTToken(createdUser)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "System error"})
 		return
@@ -107,18 +108,20 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	user, err := Store.GetUserByUsername(req.Username)
+	user, err := store(c).GetUserByUsername(req.Username)
 	if err != nil {
+		metrics.Login(metrics.LoginFailed)
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid credentials"})
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		metrics.Login(metrics.LoginFailed)
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid credentials"})
 		return
 	}
 
-	err = Store.UpdateUserLastLogin(user.ID)
+	err = store(c).UpdateUserLastLogin(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "System error"})
 		return
@@ -131,15 +134,15 @@ func LoginHandler(c *gin.Context) {
 			return
 		}
 
-		err = Store.SaveOTPCode(user.ID, code)
+		err = store(c).SaveOTPCode(user.ID, code)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save OTP code"})
 			return
 		}
 
-		err = mail.SendOTPEmail(user.Email, code)
+		err = mail.SendOTPEmail(c.Request.Context(), user.Email, code)
 		if err != nil {
-			fmt.Printf("Error sending OTP email: %v\n", err)
+			requestLog(c).Error("Error sending OTP email: %v", err)
 		}
 
 		tempToken, err := CreateTempToken(user.ID)
@@ -148,17 +151,19 @@ func LoginHandler(c *gin.Context) {
 			return
 		}
 
+		metrics.Login(metrics.LoginOTPRequired)
 		c.JSON(http.StatusOK, models.TempTokenResponse{
 			TempToken: tempToken,
 			Message:   "OTP sent to registered email",
 		})
 	} else {
-		token, err := createJWTToken(user.ID)
+		token, err := createJWTToken(user)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "System error"})
 			return
 		}
 
+		metrics.Login(metrics.LoginSuccess)
 		c.JSON(http.StatusOK, models.LoginResponse{
 			Token:    token,
 			UserID:   user.ID,
@@ -191,34 +196,36 @@ func VerifyOTPHandler(c *gin.Context) {
 		return
 	}
 
-	valid, err := Store.VerifyOTPCode(userID, req.OTP)
+	valid, err := store(c).VerifyOTPCode(userID, req.OTP)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "System error"})
 		return
 	}
 
 	if !valid {
+		metrics.Login(metrics.LoginFailed)
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired OTP code"})
 		return
 	}
 
-	err = Store.ClearOTPCode(userID)
+	err = store(c).ClearOTPCode(userID)
 	if err != nil {
-		fmt.Printf("Error clearing OTP code: %v\n", err)
+		requestLog(c).Error("Error clearing OTP code: %v", err)
 	}
 
-	user, err := Store.GetUserByID(userID)
+	user, err := store(c).GetUserByID(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "User not found"})
 		return
 	}
 
-	token, err := createJWTToken(user.ID)
+	token, err := createJWTToken(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "System error"})
 		return
 	}
 
+	metrics.Login(metrics.LoginSuccess)
 	c.JSON(http.StatusOK, models.LoginResponse{
 		Token:    token,
 		UserID:   user.ID,
@@ -257,7 +264,7 @@ func Enable2FAHandler(c *gin.Context) {
 		return
 	}
 
-	user, err := Store.GetUserByID(userID)
+	user, err := store(c).GetUserByID(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "User not found"})
 		return
@@ -268,7 +275,7 @@ func Enable2FAHandler(c *gin.Context) {
 		return
 	}
 
-	err = Store.Enable2FA(userID)
+	err = store(c).Enable2FA(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to enable 2FA: " + err.Error()})
 		return
@@ -320,17 +327,29 @@ func validateTempToken(tokenString string) (int, error) {
 	return int(userIDFloat), nil
 }
 
-func createJWTToken(userID int) (string, error) {
+// createJWTToken выпускает токен доступа. Роли в токене нужны шлюзу, чтобы передавать их
+// сервисам в X-User-Roles; права в обработчиках по-прежнему проверяются по базе, потому что
+// роли в выпущенном токене не меняются до его истечения.
+func createJWTToken(user models.User) (string, error) {
 	now := time.Now()
 
+	roles := []string{}
+	if user.IsTeacher {
+		roles = append(roles, "teacher")
+	}
+	if user.IsAdmin {
+		roles = append(roles, "admin")
+	}
+
 	claims := jwt.MapClaims{
-		"sub": userID,
-		"iat": now.Unix(),
-		"exp": now.Add(7 * 24 * time.Hour).Unix(),
+		"sub":   user.ID,
+		"roles": roles,
+		"iat":   now.Unix(),
+		"exp":   now.Add(7 * 24 * time.Hour).Unix(),
 	}
 
-	log.Printf("Creating token for user %d, expires at: %v",
-		userID, time.Unix(claims["exp"].(int64), 0))
+	logger.Default().Debug("Creating token for user %d, expires at: %v",
+		user.ID, time.Unix(claims["exp"].(int64), 0))
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(JWTSecret))
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/achievements"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBadges
+// @Summary Get all badges
+// @Description Значки с условиями получения, включая неактивные
+// @Tags Badges
+// @Produce json
+// @Success 200 {array} models.Badge
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/badges [get]
+func GetBadges(c *gin.Context) {
+	badges, err := store(c).GetBadges()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve badges"})
+		return
+	}
+	c.JSON(http.StatusOK, badges)
+}
+
+// CreateBadge
+// @Summary Create a badge
+// @Description Условие criteria.type: tasks_completed, courses_completed, streak_days (threshold - задачи,
+// @Description курсы или дни; course_id и difficulty сужают задачи), tasks_without_hints (все задачи
+// @Description сложности difficulty, по умолчанию hard, решены без подсказок) и leaderboard_rank
+// @Description (место в рейтинге курса course_id, 0 - общий рейтинг, не ниже threshold).
+// @Description Значок выдается при следующей проверке; для уже накопленного прогресса - через /admin/badges/evaluate.
+// @Tags Badges
+// @Accept json
+// @Produce json
+// @Param badge body models.Badge true "Badge"
+// @Success 201 {object} models.Badge
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/badges [post]
+func CreateBadge(c *gin.Context) {
+	badge, ok := bindBadge(c)
+	if !ok {
+		return
+	}
+
+	badge, err := store(c).CreateBadge(badge)
+	if err != nil {
+		respondBadgeError(c, err, "Failed to create badge")
+		return
+	}
+	c.JSON(http.StatusCreated, badge)
+}
+
+// UpdateBadge
+// @Summary Update a badge
+// @Description Изменение условия не отзывает уже выданные значки
+// @Tags Badges
+// @Accept json
+// @Produce json
+// @Param badge_id path int true "Badge ID"
+// @Param badge body models.Badge true "Badge"
+// @Success 200 {object} models.Badge
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/badges/{badge_id} [put]
+func UpdateBadge(c *gin.Context) {
+	badgeID, err := strconv.Atoi(c.Param("badge_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid badge ID"})
+		return
+	}
+	badge, ok := bindBadge(c)
+	if !ok {
+		return
+	}
+	badge.ID = badgeID
+
+	badge, err = store(c).UpdateBadge(badge)
+	if err != nil {
+		respondBadgeError(c, err, "Failed to update badge")
+		return
+	}
+	c.JSON(http.StatusOK, badge)
+}
+
+// DeleteBadge
+// @Summary Delete a badge
+// @Description Удаляет значок вместе с выданными экземплярами. Чтобы сохранить выданные значки, значок можно выключить.
+// @Tags Badges
+// @Produce json
+// @Param badge_id path int true "Badge ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/badges/{badge_id} [delete]
+func DeleteBadge(c *gin.Context) {
+	badgeID, err := strconv.Atoi(c.Param("badge_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid badge ID"})
+		return
+	}
+
+	if err := store(c).DeleteBadge(badgeID); err != nil {
+		respondBadgeError(c, err, "Failed to delete badge")
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Badge deleted successfully"})
+}
+
+// EvaluateBadges
+// @Summary Evaluate badges for existing progress
+// @Description Проверяет условия значков по уже накопленному прогрессу и выдает недостающие значки
+// @Description всем пользователям или пользователю user_id.
+// @Tags Badges
+// @Produce json
+// @Param user_id query int false "User ID"
+// @Success 200 {object} models.BadgeEvaluationResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/badges/evaluate [post]
+func EvaluateBadges(c *gin.Context) {
+	var userIDs []int
+	if value := c.Query("user_id"); value != "" {
+		userID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid user ID"})
+			return
+		}
+		userIDs = append(userIDs, userID)
+	} else {
+		users, _, err := store(c).GetAllUsers(models.ListParams{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve users"})
+			return
+		}
+		for _, user := range users {
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+
+	evaluator, err := newBadgeEvaluator()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load badges: " + err.Error()})
+		return
+	}
+
+	result := models.BadgeEvaluationResult{}
+	for _, userID := range userIDs {
+		awarded, err := evaluator.award(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to evaluate badges: " + err.Error()})
+			return
+		}
+		result.EvaluatedUsers++
+		result.AwardedBadges += len(awarded)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUserBadges
+// @Summary Get user badges
+// @Description Полученные пользователем значки. Чужие значки доступны администратору.
+// @Tags Badges
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {array} models.UserBadge
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /progress/{user_id}/badges [get]
+func GetUserBadges(c *gin.Context) {
+	userID, ok := progressUser(c, true)
+	if !ok {
+		return
+	}
+
+	badges, err := store(c).GetUserBadges(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve badges"})
+		return
+	}
+	c.JSON(http.StatusOK, badges)
+}
+
+// badgeEvaluator проверяет условия значков. Значки и рейтинги загружаются один раз,
+// чтобы при пересчете по всем пользователям не читать их для каждого.
+type badgeEvaluator struct {
+	badges []models.Badge
+	ranks  map[int]map[int]int
+}
+
+func newBadgeEvaluator() (*badgeEvaluator, error) {
+	badges, err := Store.GetBadges()
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator := &badgeEvaluator{badges: badges, ranks: make(map[int]map[int]int)}
+	for courseID, limit := range achievements.LeaderboardLimits(badges) {
+		leaderboard, err := Store.GetLeaderboard(courseID, limit)
+		if err != nil {
+			return nil, err
+		}
+		evaluator.ranks[courseID] = make(map[int]int)
+		for _, entry := range leaderboard {
+			evaluator.ranks[courseID][entry.UserID] = entry.Position
+		}
+	}
+	return evaluator, nil
+}
+
+// award выдает пользователю значки, условия которых выполнены, и возвращает новые
+func (e *badgeEvaluator) award(userID int) ([]models.UserBadge, error) {
+	snapshot, err := Store.GetLearningSnapshot(userID)
+	if err != nil {
+		return nil, err
+	}
+	completions, err := Store.GetCourseCompletions(userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := Store.GetUserPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	facts := achievements.Facts{
+		Snapshot:    snapshot,
+		Completions: completions,
+		Ranks:       make(map[int]int),
+		Location:    prefs.Location(),
+		Now:         time.Now().UTC(),
+	}
+	for courseID, ranks := range e.ranks {
+		if rank, ok := ranks[userID]; ok {
+			facts.Ranks[courseID] = rank
+		}
+	}
+
+	return Store.AwardBadges(userID, achievements.Evaluate(e.badges, facts))
+}
+
+// awardBadges выдает значки после выполнения задачи. Ошибка не отменяет выполнение
+// задачи: значок будет выдан при следующей проверке.
+func awardBadges(userID int) {
+	evaluator, err := newBadgeEvaluator()
+	if err == nil {
+		_, err = evaluator.award(userID)
+	}
+	if err != nil {
+		logger.Default().Error("Error awarding badges for user %d: %v", userID, err)
+	}
+}
+
+func bindBadge(c *gin.Context) (models.Badge, bool) {
+	var badge models.Badge
+	if err := c.ShouldBindJSON(&badge); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return badge, false
+	}
+	badge.Code = strings.TrimSpace(badge.Code)
+	badge.Name = strings.TrimSpace(badge.Name)
+	if badge.Code == "" || badge.Name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Badge code and name are required"})
+		return badge, false
+	}
+
+	if err := achievements.Validate(badge.Criteria); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid badge criteria: " + err.Error()})
+		return badge, false
+	}
+	return badge, true
+}
+
+func respondBadgeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrBadgeNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Badge not found"})
+	case errors.Is(err, storage.ErrBadgeCodeTaken):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Badge code is already taken"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message})
+	}
+}
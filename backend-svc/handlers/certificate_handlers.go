@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/certificate"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	certificateFormatJSON = "json"
+	certificateFormatPDF  = "pdf"
+	certificateFormatSVG  = "svg"
+)
+
+// IssueCertificates
+// @Summary Issue certificates for completed courses
+// @Description Выдает подписанные сертификаты за все пройденные курсы, за которые их еще нет.
+// @Description Сертификаты выдаются и автоматически при выполнении последней задачи курса;
+// @Description запрос нужен для курсов, пройденных до появления сертификатов.
+// @Tags Certificates
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {array} models.Certificate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /progress/{user_id}/certificates [post]
+func IssueCertificates(c *gin.Context) {
+	userID, ok := progressUser(c, false)
+	if !ok {
+		return
+	}
+
+	if _, err := issueCourseCertificates(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to issue certificates: " + err.Error()})
+		return
+	}
+
+	certificates, err := store(c).GetUserCertificates(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve certificates"})
+		return
+	}
+	c.JSON(http.StatusOK, certificates)
+}
+
+// GetUserCertificates
+// @Summary Get user certificates
+// @Description Сертификаты пользователя, включая отозванные. Чужие сертификаты доступны администратору.
+// @Tags Certificates
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {array} models.Certificate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /progress/{user_id}/certificates [get]
+func GetUserCertificates(c *gin.Context) {
+	userID, ok := progressUser(c, true)
+	if !ok {
+		return
+	}
+
+	certificates, err := store(c).GetUserCertificates(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve certificates"})
+		return
+	}
+	c.JSON(http.StatusOK, certificates)
+}
+
+// DownloadCertificate
+// @Summary Download certificate
+// @Description Сертификат в JSON, PDF или SVG. QR-код документа ведет на публичную проверку.
+// @Tags Certificates
+// @Produce json
+// @Produce application/pdf
+// @Produce image/svg+xml
+// @Param certificate_id path string true "Certificate ID"
+// @Param format query string false "Response format: json, pdf, svg (default json)"
+// @Success 200 {object} models.Certificate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /certificates/{certificate_id} [get]
+func DownloadCertificate(c *gin.Context) {
+	format := c.DefaultQuery("format", certificateFormatJSON)
+	if format != certificateFormatJSON && format != certificateFormatPDF && format != certificateFormatSVG {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid format parameter, expected json, pdf or svg"})
+		return
+	}
+
+	cert, err := store(c).GetCertificate(normalizeCertificateID(c.Param("certificate_id")))
+	if err != nil {
+		respondCertificateError(c, err)
+		return
+	}
+
+	currentUserID := c.GetInt("userID")
+	if cert.UserID != currentUserID {
+		if isAdmin, _ := CheckAdminRights(currentUserID); !isAdmin {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Access denied"})
+			return
+		}
+	}
+
+	if format == certificateFormatJSON {
+		c.JSON(http.StatusOK, cert)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "image/svg+xml"
+	verifyURL := certificate.VerificationURL(CertificateVerifyURL, cert)
+	if format == certificateFormatPDF {
+		contentType = "application/pdf"
+		err = certificate.WritePDF(&buf, cert, verifyURL)
+	} else {
+		err = certificate.WriteSVG(&buf, cert, verifyURL)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to render certificate: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="certificate_`+cert.ID+"."+format+`"`)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// VerifyCertificate
+// @Summary Verify certificate
+// @Description Публичная проверка сертификата по ID или по коду из QR (можно передать
+// @Description отсканированный адрес целиком). Сертификат действителен, если подпись верна
+// @Description и он не отозван. Код из QR дополнительно сверяется с подписью сертификата.
+// @Tags Certificates
+// @Produce json
+// @Param id query string false "Certificate ID"
+// @Param code query string false "Verification code or URL from the QR code"
+// @Success 200 {object} models.CertificateVerification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /certificates/verify [get]
+func VerifyCertificate(c *gin.Context) {
+	id := c.Query("id")
+	var signature string
+	if code := c.Query("code"); code != "" {
+		var err error
+		if id, signature, err = certificate.ParseCode(code); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid verification code"})
+			return
+		}
+	}
+	if strings.TrimSpace(id) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Certificate ID or verification code is required"})
+		return
+	}
+
+	cert, err := store(c).GetCertificate(normalizeCertificateID(id))
+	if err != nil {
+		respondCertificateError(c, err)
+		return
+	}
+
+	status := models.CertificateValid
+	switch {
+	case !certificateSigner().Verify(cert), signature != "" && signature != cert.Signature:
+		status = models.CertificateInvalidSignature
+	case cert.IsRevoked():
+		status = models.CertificateRevoked
+	}
+
+	cert.UserID = 0
+	c.JSON(http.StatusOK, models.CertificateVerification{
+		Valid:       status == models.CertificateValid,
+		Status:      status,
+		Certificate: cert,
+	})
+}
+
+// GetCertificatePublicKey
+// @Summary Get certificate signing public key
+// @Description Открытый ключ Ed25519 для самостоятельной проверки подписи сертификатов.
+// @Description Подписывается каноническое представление полей (см. certificate.Payload).
+// @Tags Certificates
+// @Produce json
+// @Success 200 {object} models.CertificatePublicKey
+// @Router /certificates/public-key [get]
+func GetCertificatePublicKey(c *gin.Context) {
+	c.JSON(http.StatusOK, models.CertificatePublicKey{
+		Algorithm: certificate.Algorithm,
+		PublicKey: certificateSigner().PublicKey(),
+	})
+}
+
+// RevokeCertificate
+// @Summary Revoke certificate
+// @Description Отозванный сертификат остается доступен для проверки со статусом revoked
+// @Description и не выдается за курс повторно.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param certificate_id path string true "Certificate ID"
+// @Param request body models.RevokeCertificateRequest true "Revocation reason"
+// @Success 200 {object} models.Certificate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/certificates/{certificate_id}/revoke [post]
+func RevokeCertificate(c *gin.Context) {
+	var req models.RevokeCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Revocation reason is required"})
+		return
+	}
+
+	cert, err := store(c).RevokeCertificate(normalizeCertificateID(c.Param("certificate_id")), strings.TrimSpace(req.Reason), time.Now().UTC())
+	if err != nil {
+		respondCertificateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cert)
+}
+
+// issueCourseCertificates выдает сертификаты за пройденные курсы, за которые их еще нет,
+// и возвращает новые сертификаты
+func issueCourseCertificates(userID int) ([]models.Certificate, error) {
+	completions, err := Store.GetCourseCompletions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := Store.GetUserCertificates(userID)
+	if err != nil {
+		return nil, err
+	}
+	issued := make(map[int]bool)
+	for _, cert := range existing {
+		issued[cert.CourseID] = true
+	}
+
+	var user models.User
+	var certificates []models.Certificate
+	for _, completion := range completions {
+		if !completion.IsCompleted || issued[completion.CourseID] {
+			continue
+		}
+		if user.ID == 0 {
+			if user, err = Store.GetUserByID(userID); err != nil {
+				return certificates, err
+			}
+		}
+
+		cert, err := newCertificate(user, completion)
+		if err != nil {
+			return certificates, err
+		}
+		cert, err = Store.CreateCertificate(cert)
+		if errors.Is(err, storage.ErrCertificateExists) {
+			continue
+		}
+		if err != nil {
+			return certificates, err
+		}
+		certificates = append(certificates, cert)
+	}
+	return certificates, nil
+}
+
+// awardCertificates выдает сертификаты после выполнения задачи. Ошибка выдачи не отменяет
+// выполнение задачи: сертификат можно получить позже через IssueCertificates.
+func awardCertificates(userID int) {
+	if _, err := issueCourseCertificates(userID); err != nil {
+		logger.Default().Error("Error issuing certificates for user %d: %v", userID, err)
+	}
+}
+
+func newCertificate(user models.User, completion models.CourseCompletion) (models.Certificate, error) {
+	id, err := certificate.NewID()
+	if err != nil {
+		return models.Certificate{}, err
+	}
+
+	name := strings.TrimSpace(user.FullName)
+	if name == "" {
+		name = user.Username
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	completedAt := now
+	if completion.CompletedAt != nil {
+		completedAt = completion.CompletedAt.UTC().Truncate(time.Second)
+	}
+
+	cert := models.Certificate{
+		ID:          id,
+		UserID:      user.ID,
+		FullName:    name,
+		CourseID:    completion.CourseID,
+		CourseTitle: completion.CourseTitle,
+		Score:       completion.Score,
+		CompletedAt: completedAt,
+		IssuedAt:    now,
+	}
+	cert.Signature = certificateSigner().Sign(cert)
+	return cert, nil
+}
+
+var certificateSignerMu sync.Mutex
+
+// certificateSigner возвращает ключ подписи. Если CertificateSigner не задан (тесты, моковое
+// хранилище), создается случайный ключ на время работы процесса: секрет JWT для подписи не
+// используется, иначе его владелец мог бы подделывать сертификаты.
+func certificateSigner() *certificate.Signer {
+	certificateSignerMu.Lock()
+	defer certificateSignerMu.Unlock()
+	if CertificateSigner == nil {
+		signer, err := certificate.GenerateSigner()
+		if err != nil {
+			panic(fmt.Sprintf("generate certificate signing key: %v", err))
+		}
+		CertificateSigner = signer
+	}
+	return CertificateSigner
+}
+
+func normalizeCertificateID(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+func respondCertificateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.ErrCertificateNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Certificate not found"})
+	case errors.Is(err, storage.ErrCertificateRevoked):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Certificate is already revoked"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	}
+}
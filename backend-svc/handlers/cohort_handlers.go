@@ -0,0 +1,754 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxCohortNameLength   = 100
+	maxCohortImportRows   = 1000
+	defaultLeaderboardTop = 10
+	inviteCodeLength      = 8
+	// В коде приглашения нет похожих символов (0/O, 1/I)
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// CreateCohort
+// @Summary Create a student group
+// @Description Создает учебную группу с курсами и преподавателями. Создатель становится преподавателем группы.
+// @Description Код приглашения для вступления студентов генерируется автоматически.
+// @Tags Cohorts
+// @Accept json
+// @Produce json
+// @Param cohort body models.Cohort true "Cohort"
+// @Success 201 {object} models.Cohort
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts [post]
+func CreateCohort(c *gin.Context) {
+	var cohort models.Cohort
+	if err := c.ShouldBindJSON(&cohort); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	currentUserID := c.GetInt("userID")
+	if isAdmin, _ := CheckAdminRights(currentUserID); !isAdmin && !containsInt(cohort.TeacherIDs, currentUserID) {
+		cohort.TeacherIDs = append(cohort.TeacherIDs, currentUserID)
+	}
+	if err := validateCohort(&cohort); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate invite code"})
+		return
+	}
+	cohort.InviteCode = code
+	cohort.CreatedBy = currentUserID
+
+	cohort, err = store(c).CreateCohort(cohort)
+	if err != nil {
+		respondCohortError(c, err, "Failed to create cohort")
+		return
+	}
+
+	c.JSON(http.StatusCreated, cohort)
+}
+
+// GetCohorts
+// @Summary List student groups
+// @Description Преподаватель видит свои группы, администратор - все
+// @Tags Cohorts
+// @Produce json
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return cohorts with ID greater than cursor"
+// @Param sort query string false "Sort field: id, name, created_at (prefix - for descending)"
+// @Param course_id query int false "Only cohorts studying the course"
+// @Success 200 {array} models.Cohort
+// @Header 200 {integer} X-Total-Count "Total number of cohorts"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts [get]
+func GetCohorts(c *gin.Context) {
+	params, err := parseListParams(c, "id", "name", "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	teacherID := c.GetInt("userID")
+	if isAdmin, _ := CheckAdminRights(teacherID); isAdmin {
+		teacherID = 0
+	}
+
+	cohorts, total, err := store(c).GetCohorts(teacherID, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve cohorts: " + err.Error()})
+		return
+	}
+
+	var lastID int
+	if len(cohorts) > 0 {
+		lastID = cohorts[len(cohorts)-1].ID
+	}
+	setListHeaders(c, params, total, lastID, len(cohorts))
+
+	c.JSON(http.StatusOK, cohorts)
+}
+
+// GetCohort
+// @Summary Get a student group
+// @Tags Cohorts
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Success 200 {object} models.Cohort
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id} [get]
+func GetCohort(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, cohort)
+}
+
+// UpdateCohort
+// @Summary Update a student group
+// @Description Заменяет название, описание, курсы и преподавателей группы
+// @Tags Cohorts
+// @Accept json
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Param cohort body models.Cohort true "Cohort"
+// @Success 200 {object} models.Cohort
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id} [put]
+func UpdateCohort(c *gin.Context) {
+	current, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	var cohort models.Cohort
+	if err := c.ShouldBindJSON(&cohort); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	cohort.ID = current.ID
+
+	if err := validateCohort(&cohort); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	cohort, err := store(c).UpdateCohort(cohort)
+	if err != nil {
+		respondCohortError(c, err, "Failed to update cohort")
+		return
+	}
+
+	c.JSON(http.StatusOK, cohort)
+}
+
+// DeleteCohort
+// @Summary Delete a student group
+// @Description Удаляет группу, ее состав и задания группы
+// @Tags Cohorts
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id} [delete]
+func DeleteCohort(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := store(c).DeleteCohort(cohort.ID); err != nil {
+		respondCohortError(c, err, "Failed to delete cohort")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Cohort deleted successfully"})
+}
+
+// RegenerateCohortInviteCode
+// @Summary Regenerate the invite code of a group
+// @Description Выдает новый код приглашения, старый код перестает действовать
+// @Tags Cohorts
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Success 200 {object} models.Cohort
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id}/invite-code [post]
+func RegenerateCohortInviteCode(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to generate invite code"})
+		return
+	}
+	if err := store(c).SetCohortInviteCode(cohort.ID, code); err != nil {
+		respondCohortError(c, err, "Failed to update invite code")
+		return
+	}
+
+	cohort.InviteCode = code
+	c.JSON(http.StatusOK, cohort)
+}
+
+// JoinCohort
+// @Summary Join a student group by invite code
+// @Tags Cohorts
+// @Accept json
+// @Produce json
+// @Param request body models.JoinCohortRequest true "Invite code"
+// @Success 200 {object} models.Cohort
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /cohorts/join [post]
+func JoinCohort(c *gin.Context) {
+	var request models.JoinCohortRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	cohort, err := store(c).GetCohortByInviteCode(strings.ToUpper(strings.TrimSpace(request.InviteCode)))
+	if err != nil {
+		if errors.Is(err, storage.ErrCohortNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Invalid invite code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to join cohort: " + err.Error()})
+		return
+	}
+
+	result, err := store(c).AddCohortMembers(cohort.ID, []int{c.GetInt("userID")})
+	if err != nil {
+		respondCohortError(c, err, "Failed to join cohort")
+		return
+	}
+	if len(result.Added) == 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "You are already a member of this cohort"})
+		return
+	}
+
+	cohort.InviteCode = ""
+	cohort.MembersCount++
+	c.JSON(http.StatusOK, cohort)
+}
+
+// GetCohortMembers
+// @Summary List students of a group
+// @Tags Cohorts
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return students with user ID greater than cursor"
+// @Param sort query string false "Sort field: user_id, username, joined_at (prefix - for descending)"
+// @Param is_active query bool false "Filter students by active status"
+// @Success 200 {array} models.CohortMember
+// @Header 200 {integer} X-Total-Count "Total number of students"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id}/members [get]
+func GetCohortMembers(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	params, err := parseListParams(c, "user_id", "username", "joined_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	members, total, err := store(c).GetCohortMembers(cohort.ID, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve cohort members: " + err.Error()})
+		return
+	}
+
+	var lastID int
+	if len(members) > 0 {
+		lastID = members[len(members)-1].UserID
+	}
+	setListHeaders(c, params, total, lastID, len(members))
+
+	c.JSON(http.StatusOK, members)
+}
+
+// AddCohortMembers
+// @Summary Add students to a group
+// @Tags Cohorts
+// @Accept json
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Param request body models.CohortMembersRequest true "Student IDs"
+// @Success 200 {object} models.CohortImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id}/members [post]
+func AddCohortMembers(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	var request models.CohortMembersRequest
+	if err := c.ShouldBindJSON(&request); err != nil || len(request.UserIDs) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if len(request.UserIDs) > maxCohortImportRows {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("At most %d students can be added at once", maxCohortImportRows)})
+		return
+	}
+
+	result, err := store(c).AddCohortMembers(cohort.ID, uniqueInts(request.UserIDs))
+	if err != nil {
+		respondCohortError(c, err, "Failed to add cohort members")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ImportCohortMembers
+// @Summary Import students to a group from CSV
+// @Description Принимает CSV файлом (поле file) или телом запроса text/csv. В первой колонке -
+// @Description имя пользователя или email, строка заголовка (username/email/login) пропускается.
+// @Tags Cohorts
+// @Accept mpfd
+// @Accept text/csv
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Param file formData file false "CSV file"
+// @Success 200 {object} models.CohortImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id}/members/import [post]
+func ImportCohortMembers(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "CSV file is required"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	logins, err := parseMembersCSV(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ids, err := store(c).ResolveUserIDs(logins)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to resolve users: " + err.Error()})
+		return
+	}
+
+	var userIDs []int
+	var notFound []string
+	for _, login := range logins {
+		if id, found := ids[login]; found {
+			userIDs = append(userIDs, id)
+		} else {
+			notFound = append(notFound, login)
+		}
+	}
+
+	result, err := store(c).AddCohortMembers(cohort.ID, uniqueInts(userIDs))
+	if err != nil {
+		respondCohortError(c, err, "Failed to import cohort members")
+		return
+	}
+	result.NotFound = append(result.NotFound, notFound...)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RemoveCohortMember
+// @Summary Remove a student from a group
+// @Tags Cohorts
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Param user_id path int true "Student ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id}/members/{user_id} [delete]
+func RemoveCohortMember(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	if err := store(c).RemoveCohortMember(cohort.ID, userID); err != nil {
+		respondCohortError(c, err, "Failed to remove cohort member")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Student removed from cohort"})
+}
+
+// GetCohortCourseStatistics
+// @Summary Get course statistics of a group
+// @Description Статистика курса только по студентам группы. Параметры списка студентов - как у статистики курса.
+// @Tags Cohorts
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Param course_id path int true "Course ID"
+// @Param page query int false "Students progress page number (starting from 1)"
+// @Param limit query int false "Students progress page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return students with user ID greater than cursor"
+// @Param sort query string false "Sort field: user_id, username, completion, last_activity (prefix - for descending)"
+// @Param is_active query bool false "Filter students by active status"
+// @Param from query string false "Last activity at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Last activity before (RFC3339 or YYYY-MM-DD, date is inclusive)"
+// @Success 200 {object} models.CourseStatistics
+// @Header 200 {integer} X-Total-Count "Total number of students in students_progress"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id}/courses/{course_id}/statistics [get]
+func GetCohortCourseStatistics(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return
+	}
+	if !containsInt(cohort.CourseIDs, courseID) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course is not assigned to the cohort"})
+		return
+	}
+
+	params, err := parseListParams(c, "user_id", "username", "completion", "last_activity")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	params.CohortID = cohort.ID
+
+	respondCourseStatistics(c, courseID, params)
+}
+
+// GetCohortLeaderboard
+// @Summary Get leaderboard of a group
+// @Tags Cohorts
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Param course_id query int false "Only tasks of the course"
+// @Param limit query int false "Number of entries (default 10, max 100)"
+// @Success 200 {array} models.LeaderboardEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id}/leaderboard [get]
+func GetCohortLeaderboard(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	var courseID int
+	if courseIDStr := c.Query("course_id"); courseIDStr != "" {
+		var err error
+		courseID, err = strconv.Atoi(courseIDStr)
+		if err != nil || !containsInt(cohort.CourseIDs, courseID) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course_id parameter"})
+			return
+		}
+	}
+
+	limit := defaultLeaderboardTop
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit parameter"})
+			return
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+	}
+
+	leaderboard, err := store(c).GetCohortLeaderboard(cohort.ID, courseID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve leaderboard: " + err.Error()})
+		return
+	}
+	if leaderboard == nil {
+		leaderboard = []models.LeaderboardEntry{}
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+// GetCohortSubmissions
+// @Summary Get submission queue of a group
+// @Description Попытки сдачи студентов группы по курсам группы, по умолчанию - сначала новые
+// @Tags Cohorts
+// @Produce json
+// @Param cohort_id path int true "Cohort ID"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return submissions with ID greater than cursor"
+// @Param sort query string false "Sort field: submitted_at, username, task_id, score (prefix - for descending)"
+// @Param course_id query int false "Filter by course ID"
+// @Param from query string false "Submitted at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Submitted before (RFC3339 or YYYY-MM-DD, date is inclusive)"
+// @Success 200 {array} models.CohortSubmission
+// @Header 200 {integer} X-Total-Count "Total number of submissions"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/cohorts/{cohort_id}/submissions [get]
+func GetCohortSubmissions(c *gin.Context) {
+	cohort, ok := cohortAccess(c)
+	if !ok {
+		return
+	}
+
+	params, err := parseListParams(c, "submitted_at", "username", "task_id", "score")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	submissions, total, err := store(c).GetCohortSubmissions(cohort.ID, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve submissions: " + err.Error()})
+		return
+	}
+
+	var lastID int
+	if len(submissions) > 0 {
+		lastID = submissions[len(submissions)-1].SubmissionID
+	}
+	setListHeaders(c, params, total, lastID, len(submissions))
+
+	c.JSON(http.StatusOK, submissions)
+}
+
+// cohortAccess загружает группу из пути запроса и проверяет, что пользователь -
+// преподаватель группы или администратор. При ошибке ответ уже отправлен.
+func cohortAccess(c *gin.Context) (models.Cohort, bool) {
+	cohortID, err := strconv.Atoi(c.Param("cohort_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid cohort ID"})
+		return models.Cohort{}, false
+	}
+
+	cohort, err := store(c).GetCohort(cohortID)
+	if err != nil {
+		respondCohortError(c, err, "Failed to retrieve cohort")
+		return models.Cohort{}, false
+	}
+
+	userID := c.GetInt("userID")
+	if isAdmin, _ := CheckAdminRights(userID); isAdmin {
+		return cohort, true
+	}
+	if isTeacher, err := store(c).IsCohortTeacher(cohortID, userID); err != nil || !isTeacher {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Only teachers of the cohort can manage it"})
+		return models.Cohort{}, false
+	}
+	return cohort, true
+}
+
+// validateCohort проверяет название, курсы и преподавателей группы
+func validateCohort(cohort *models.Cohort) error {
+	cohort.Name = strings.TrimSpace(cohort.Name)
+	if cohort.Name == "" || len(cohort.Name) > maxCohortNameLength {
+		return errors.New("Name must be between 1 and 100 characters")
+	}
+
+	for _, ids := range [][]int{cohort.CourseIDs, cohort.TeacherIDs} {
+		if len(uniqueInts(ids)) != len(ids) {
+			return errors.New("Course and teacher IDs must be unique")
+		}
+	}
+	if cohort.CourseIDs == nil {
+		cohort.CourseIDs = []int{}
+	}
+
+	for _, teacherID := range cohort.TeacherIDs {
+		isTeacher, _ := CheckTeacherRights(teacherID)
+		isAdmin, _ := CheckAdminRights(teacherID)
+		if !isTeacher && !isAdmin {
+			return fmt.Errorf("User %d is not a teacher", teacherID)
+		}
+	}
+	return nil
+}
+
+// parseMembersCSV читает имена пользователей или email из первой колонки CSV
+func parseMembersCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var logins []string
+	seen := make(map[string]bool)
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("Invalid CSV: " + err.Error())
+		}
+
+		login := strings.TrimSpace(record[0])
+		if login == "" {
+			continue
+		}
+		if row == 0 {
+			switch strings.ToLower(login) {
+			case "username", "email", "login":
+				continue
+			}
+		}
+		if seen[strings.ToLower(login)] {
+			continue
+		}
+		seen[strings.ToLower(login)] = true
+
+		logins = append(logins, login)
+		if len(logins) > maxCohortImportRows {
+			return nil, fmt.Errorf("CSV must contain at most %d students", maxCohortImportRows)
+		}
+	}
+
+	if len(logins) == 0 {
+		return nil, errors.New("CSV contains no students")
+	}
+	return logins, nil
+}
+
+func generateInviteCode() (string, error) {
+	code := make([]byte, inviteCodeLength)
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func respondCohortError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrCohortNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Cohort not found"})
+	case errors.Is(err, storage.ErrCohortMemberNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User is not a member of the cohort"})
+	case errors.Is(err, storage.ErrCohortNameTaken):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Cohort name already taken"})
+	case errors.Is(err, storage.ErrCourseNotFound):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown course in course_ids"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message + ": " + err.Error()})
+	}
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	unique := make([]int, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
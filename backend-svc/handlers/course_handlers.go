@@ -1,27 +1,54 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"lmsmodule/backend-svc/learningpath"
+	"lmsmodule/backend-svc/metrics"
 	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// cwePattern - формат идентификатора CWE, например CWE-89
+var cwePattern = regexp.MustCompile(`^CWE-[0-9]{1,5}$`)
+
 // GetCourses
 // @Summary Get all courses
 // @Tags Courses
 // @Produce json
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return courses with ID greater than cursor"
+// @Param sort query string false "Sort field: id, vulnerability_type, tasks_count (prefix - for descending)"
 // @Success 200 {array} models.Course
+// @Header 200 {integer} X-Total-Count "Total number of courses"
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /courses [get]
 func GetCourses(c *gin.Context) {
-	courses, err := Store.GetCourses()
+	params, err := parseListParams(c, "id", "vulnerability_type", "tasks_count")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	courses, total, err := store(c).GetCourses(params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	var lastID int
+	if len(courses) > 0 {
+		lastID = courses[len(courses)-1].ID
+	}
+	setListHeaders(c, params, total, lastID, len(courses))
+
 	c.JSON(http.StatusOK, courses)
 }
 
@@ -41,7 +68,7 @@ func GetCourseByID(c *gin.Context) {
 		return
 	}
 
-	course, err := Store.GetCourseByID(id)
+	course, err := store(c).GetCourseByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course not found"})
 		return
@@ -65,7 +92,7 @@ func GetUserProgress(c *gin.Context) {
 		return
 	}
 
-	progress, err := Store.GetUserProgress(userID)
+	progress, err := store(c).GetUserProgress(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
@@ -76,6 +103,7 @@ func GetUserProgress(c *gin.Context) {
 
 // CompleteTask
 // @Summary Complete task
+// @Description Квиз и задачу задания засчитывает только оцененный ответ: submit или попытка квиза.
 // @Tags Progress
 // @Produce json
 // @Param user_id path int true "User ID"
@@ -104,8 +132,12 @@ func CompleteTask(c *gin.Context) {
 		return
 	}
 
-	err = Store.CompleteTask(userID, taskID)
+	err = store(c).CompleteTaskWithoutAnswer(userID, taskID)
 	if err != nil {
+		if errors.Is(err, storage.ErrGradedAnswerRequired) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Quizzes and assignment tasks are completed by a graded answer"})
+			return
+		}
 		if err.Error() == "task not found" {
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Task not found"})
 			return
@@ -114,11 +146,14 @@ func CompleteTask(c *gin.Context) {
 		return
 	}
 
+	taskCompleted(userID, taskID)
 	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Task completed successfully"})
 }
 
 // SubmitTaskWithAnswer
 // @Summary Submit task with answer for grading
+// @Description Если задача входит в задание, ответ принимается только после открытия задания и до его закрытия.
+// @Description Верный ответ после срока получает балл со штрафом по правилам задания.
 // @Tags Progress
 // @Produce json
 // @Param user_id path int true "User ID"
@@ -158,12 +193,26 @@ func SubmitTaskWithAnswer(c *gin.Context) {
 	submission.TaskID = taskID
 	submission.SubmittedAt = time.Now()
 
-	result, err := Store.SubmitTaskAnswer(submission)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to submit task: " + err.Error()})
+	result, err := store(c).SubmitTaskAnswer(submission)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrAssignmentNotOpen):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Assignment is not open yet"})
+		case errors.Is(err, storage.ErrAssignmentClosed):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Assignment is closed, submissions are no longer accepted"})
+		case errors.Is(err, storage.ErrQuizTask):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Quiz tasks are answered through quiz attempts"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to submit task: " + err.Error()})
+		}
 		return
 	}
 
+	metrics.Submission(metrics.SubmissionTask, result.IsCorrect)
+	notifyGradingResult(userID, taskID, result.IsCorrect, result.Score, scorePoints, result.IsLate)
+	if result.IsCorrect {
+		taskCompleted(userID, taskID)
+	}
 	c.JSON(http.StatusOK, result)
 }
 
@@ -172,7 +221,15 @@ func SubmitTaskWithAnswer(c *gin.Context) {
 // @Tags Progress
 // @Produce json
 // @Param user_id path int true "User ID"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return submissions with task ID greater than cursor"
+// @Param sort query string false "Sort field: submitted_at, task_id, task_title, course_id (prefix - for descending)"
+// @Param course_id query int false "Filter by course ID"
+// @Param from query string false "Submitted at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Submitted before (RFC3339 or YYYY-MM-DD, date is inclusive)"
 // @Success 200 {array} models.TaskSubmissionDetails
+// @Header 200 {integer} X-Total-Count "Total number of submissions"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
@@ -191,26 +248,48 @@ func GetUserSubmissions(c *gin.Context) {
 		return
 	}
 
-	submissions, err := Store.GetUserSubmissions(userID)
+	params, err := parseListParams(c, "submitted_at", "task_id", "task_title", "course_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	submissions, total, err := store(c).GetUserSubmissions(userID, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve submissions: " + err.Error()})
 		return
 	}
 
+	var lastID int
+	if len(submissions) > 0 {
+		lastID = submissions[len(submissions)-1].TaskID
+	}
+	setListHeaders(c, params, total, lastID, len(submissions))
+
 	c.JSON(http.StatusOK, submissions)
 }
 
 // GetCourseStatistics
 // @Summary Get statistics for a course
+// @Description Администратор видит всех студентов курса, преподаватель - только студентов своих групп, которым назначен курс.
 // @Tags Analytics
 // @Produce json
 // @Param course_id path int true "Course ID"
+// @Param page query int false "Students progress page number (starting from 1)"
+// @Param limit query int false "Students progress page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return students with user ID greater than cursor"
+// @Param sort query string false "Sort field: user_id, username, completion, last_activity (prefix - for descending)"
+// @Param is_active query bool false "Filter students by active status"
+// @Param from query string false "Last activity at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Last activity before (RFC3339 or YYYY-MM-DD, date is inclusive)"
 // @Success 200 {object} models.CourseStatistics
+// @Header 200 {integer} X-Total-Count "Total number of students in students_progress"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /analytics/courses/{course_id}/statistics [get]
+// @Router /teacher/courses/{course_id}/statistics [get]
+// @Router /admin/analytics/courses/{course_id}/statistics [get]
 func GetCourseStatistics(c *gin.Context) {
 	courseID, err := strconv.Atoi(c.Param("course_id"))
 	if err != nil {
@@ -218,13 +297,30 @@ func GetCourseStatistics(c *gin.Context) {
 		return
 	}
 
-	isAdmin, err := CheckAdminRights(c.GetInt("userID"))
-	if err != nil || !isAdmin {
-		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Only administrators can view course statistics"})
+	userID := c.GetInt("userID")
+	isAdmin, _ := CheckAdminRights(userID)
+	isTeacher, _ := CheckTeacherRights(userID)
+	if !isAdmin && !isTeacher {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Only administrators and teachers can view course statistics"})
 		return
 	}
 
-	stats, err := Store.GetCourseStatistics(courseID)
+	params, err := parseListParams(c, "user_id", "username", "completion", "last_activity")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	// Преподаватель видит только студентов своих групп, которым назначен курс
+	if !isAdmin {
+		params.TeacherID = userID
+	}
+
+	respondCourseStatistics(c, courseID, params)
+}
+
+// respondCourseStatistics отдает статистику курса со списком студентов по параметрам списка
+func respondCourseStatistics(c *gin.Context, courseID int, params models.ListParams) {
+	stats, err := store(c).GetCourseStatistics(courseID, params)
 	if err != nil {
 		if err.Error() == "course not found" {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course not found"})
@@ -234,6 +330,12 @@ func GetCourseStatistics(c *gin.Context) {
 		return
 	}
 
+	var lastID int
+	if len(stats.StudentsProgress) > 0 {
+		lastID = stats.StudentsProgress[len(stats.StudentsProgress)-1].UserID
+	}
+	setListHeaders(c, params, stats.StudentsTotal, lastID, len(stats.StudentsProgress))
+
 	c.JSON(http.StatusOK, stats)
 }
 
@@ -261,20 +363,36 @@ func GetUserStatistics(c *gin.Context) {
 		return
 	}
 
-	stats, err := Store.GetUserStatistics(userID)
+	stats, err := store(c).GetUserStatistics(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user statistics: " + err.Error()})
 		return
 	}
 
+	stats.Badges, err = store(c).GetUserBadges(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve badges"})
+		return
+	}
+
+	stats.Streak, err = userStreak(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve activity streak"})
+		return
+	}
+
 	c.JSON(http.StatusOK, stats)
 }
 
 // GetUserLearningPath
 // @Summary Get personalized learning path for a user
+// @Description Навыки задач (категории CWE) оцениваются по выполненным задачам и неверным попыткам сдачи.
+// @Description Следующие задачи выбираются среди задач с решенными предварительными задачами
+// @Description по стратегии strategy; время оценивается по данным учебной активности.
 // @Tags Progress
 // @Produce json
 // @Param user_id path int true "User ID"
+// @Param strategy query string false "Recommendation strategy: weakness (default), sequential"
 // @Success 200 {object} models.LearningPath
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
@@ -293,12 +411,22 @@ func GetUserLearningPath(c *gin.Context) {
 		return
 	}
 
-	learningPath, err := Store.GetUserLearningPath(userID)
+	strategy, ok := learningpath.Lookup(c.DefaultQuery("strategy", LearningPathStrategy))
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid strategy parameter, expected one of: " + strings.Join(learningpath.Names(), ", "),
+		})
+		return
+	}
+
+	snapshot, err := store(c).GetLearningSnapshot(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve learning path: " + err.Error()})
 		return
 	}
 
+	learningPath := strategy.Build(snapshot)
+
 	c.JSON(http.StatusOK, learningPath)
 }
 
@@ -314,7 +442,7 @@ func GetUserLearningPath(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
-// @Router /api/courses [post]
+// @Router /teacher/courses [post]
 func CreateCourse(c *gin.Context) {
 	var course models.Course
 	if err := c.BindJSON(&course); err != nil {
@@ -322,12 +450,14 @@ func CreateCourse(c *gin.Context) {
 		return
 	}
 
-	course, err := Store.CreateCourse(course)
+	course, err := store(c).CreateCourse(course)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	go notifyCoursePublished(course)
+
 	c.JSON(http.StatusCreated, course)
 }
 
@@ -335,7 +465,7 @@ func CreateCourse(c *gin.Context) {
 // @Summary Update course information
 // @Tags Courses
 // @Produce json
-// @Param id path int true "Course ID"
+// @Param course_id path int true "Course ID"
 // @Param title body string true "Title of the course"
 // @Param description body string true "Description of the course"
 // @Param difficulty_level body string true "Difficulty level of the course"
@@ -345,9 +475,9 @@ func CreateCourse(c *gin.Context) {
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /api/courses/{id} [put]
+// @Router /teacher/courses/{course_id} [put]
 func UpdateCourse(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := strconv.Atoi(c.Param("course_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
 		return
@@ -359,7 +489,7 @@ func UpdateCourse(c *gin.Context) {
 		return
 	}
 
-	course, err = Store.UpdateCourse(id, course)
+	course, err = store(c).UpdateCourse(id, course)
 	if err != nil {
 		if err.Error() == "course not found" {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course not found"})
@@ -376,20 +506,20 @@ func UpdateCourse(c *gin.Context) {
 // @Summary Delete course
 // @Tags Courses
 // @Produce json
-// @Param id path int true "Course ID"
+// @Param course_id path int true "Course ID"
 // @Success 204 {object} models.SuccessResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /api/courses/{id} [delete]
+// @Router /teacher/courses/{course_id} [delete]
 func DeleteCourse(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := strconv.Atoi(c.Param("course_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
 		return
 	}
 
-	err = Store.DeleteCourse(id)
+	err = store(c).DeleteCourse(id)
 	if err != nil {
 		if err.Error() == "course not found" {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course not found"})
@@ -412,11 +542,12 @@ func DeleteCourse(c *gin.Context) {
 // @Param type body string true "Type of the task"
 // @Param points body int true "Points for the task"
 // @Param content body string true "Content of the task"
+// @Param quiz body models.QuizSettings false "Quiz settings for tasks of type quiz"
 // @Success 201 {object} models.Task
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
-// @Router /api/courses/{course_id}/tasks [post]
+// @Router /teacher/courses/{course_id}/tasks [post]
 func CreateTask(c *gin.Context) {
 	var task models.Task
 	if err := c.BindJSON(&task); err != nil {
@@ -430,9 +561,17 @@ func CreateTask(c *gin.Context) {
 		return
 	}
 	task.CourseID = courseID
+	if err := validateTaskType(&task); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
 
-	task, err = Store.CreateTask(courseID, task)
+	task, err = store(c).CreateTask(courseID, task)
 	if err != nil {
+		if errors.Is(err, storage.ErrQuestionBankNotFound) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Quiz question banks must belong to the course"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -451,12 +590,13 @@ func CreateTask(c *gin.Context) {
 // @Param type body string true "Type of the task"
 // @Param points body int true "Points for the task"
 // @Param content body string true "Content of the task"
+// @Param quiz body models.QuizSettings false "Quiz settings for tasks of type quiz"
 // @Success 200 {object} models.Task
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /api/courses/{course_id}/tasks/{task_id} [put]
+// @Router /teacher/courses/{course_id}/tasks/{task_id} [put]
 func UpdateTask(c *gin.Context) {
 	courseID, err := strconv.Atoi(c.Param("course_id"))
 	if err != nil {
@@ -477,13 +617,21 @@ func UpdateTask(c *gin.Context) {
 	}
 	task.CourseID = courseID
 	task.ID = taskID
+	if err := validateTaskType(&task); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
 
-	task, err = Store.UpdateTask(courseID, taskID, task)
+	task, err = store(c).UpdateTask(courseID, taskID, task)
 	if err != nil {
 		if err.Error() == "task not found" {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Task not found"})
 			return
 		}
+		if errors.Is(err, storage.ErrQuestionBankNotFound) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Quiz question banks must belong to the course"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -491,6 +639,89 @@ func UpdateTask(c *gin.Context) {
 	c.JSON(http.StatusOK, task)
 }
 
+// SetTaskSkills
+// @Summary Set skills and prerequisites of a task
+// @Description Заменяет навыки (категории CWE) задачи и список задач, которые нужно решить до нее.
+// @Description Без явных навыков задача относится к навыку по типу уязвимости курса.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param task_id path int true "Task ID"
+// @Param request body models.TaskSkillsRequest true "Task skills and prerequisites"
+// @Success 200 {object} models.TaskSkillsRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/tasks/{task_id}/skills [put]
+func SetTaskSkills(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return
+	}
+
+	taskID, err := strconv.Atoi(c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid task ID"})
+		return
+	}
+
+	var request models.TaskSkillsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	seen := make(map[string]bool)
+	for i, skill := range request.Skills {
+		skill.Skill = strings.TrimSpace(skill.Skill)
+		skill.CWE = strings.ToUpper(strings.TrimSpace(skill.CWE))
+		if skill.Skill == "" || seen[strings.ToLower(skill.Skill)] {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Skill names must be non-empty and unique"})
+			return
+		}
+		if skill.CWE != "" && !cwePattern.MatchString(skill.CWE) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid CWE identifier: " + skill.CWE})
+			return
+		}
+		if skill.Weight < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Skill weight must be positive"})
+			return
+		}
+		if skill.Weight == 0 {
+			skill.Weight = 1
+		}
+		seen[strings.ToLower(skill.Skill)] = true
+		request.Skills[i] = skill
+	}
+
+	for _, prerequisiteID := range request.Prerequisites {
+		if prerequisiteID == taskID {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Task cannot be its own prerequisite"})
+			return
+		}
+	}
+
+	if err := store(c).SetTaskSkills(courseID, taskID, request); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrPrerequisiteCycle):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Prerequisites form a cycle"})
+		case err == storage.ErrTaskNotFound:
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Task not found"})
+		case errors.Is(err, storage.ErrTaskNotFound):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown prerequisite task"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to set task skills: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
 // DeleteTask
 // @Summary Delete task
 // @Tags Tasks
@@ -501,7 +732,7 @@ func UpdateTask(c *gin.Context) {
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /api/courses/{course_id}/tasks/{task_id} [delete]
+// @Router /teacher/courses/{course_id}/tasks/{task_id} [delete]
 func DeleteTask(c *gin.Context) {
 	courseID, err := strconv.Atoi(c.Param("course_id"))
 	if err != nil {
@@ -515,7 +746,7 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	err = Store.DeleteTask(courseID, taskID)
+	err = store(c).DeleteTask(courseID, taskID)
 	if err != nil {
 		if err.Error() == "task not found" {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Task not found"})
@@ -552,7 +783,7 @@ func GetTaskByID(c *gin.Context) {
 		return
 	}
 
-	task, err := Store.GetTaskByID(courseID, taskID)
+	task, err := store(c).GetTaskByID(courseID, taskID)
 	if err != nil {
 		if err.Error() == "task not found" {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Task not found"})
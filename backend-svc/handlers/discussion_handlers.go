@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDiscussions
+// @Summary List course discussions
+// @Description Темы курса: сначала закрепленные, затем по последней активности. task_id оставляет
+// @Description темы одной задачи. Скрытые темы видят только преподаватели; текст тем со спойлером
+// @Description скрыт, пока пользователь не выполнил задачу.
+// @Tags Discussions
+// @Produce json
+// @Param id path int true "Course ID"
+// @Param task_id query int false "Task ID"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Success 200 {array} models.DiscussionThread
+// @Header 200 {integer} X-Total-Count "Total number of matching threads"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /courses/{id}/discussions [get]
+func GetDiscussions(c *gin.Context) {
+	courseID, ok := discussionCourseID(c)
+	if !ok {
+		return
+	}
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if params.IsCursor() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Cursor pagination is not supported for discussions"})
+		return
+	}
+	taskID := 0
+	if value := c.Query("task_id"); value != "" {
+		if taskID, err = strconv.Atoi(value); err != nil || taskID < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid task ID"})
+			return
+		}
+	}
+
+	userID := c.GetInt("userID")
+	moderator := isModerator(userID)
+	threads, total, err := store(c).GetThreads(courseID, taskID, moderator, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve discussions"})
+		return
+	}
+
+	viewer := newSpoilerViewer(userID, moderator)
+	for i := range threads {
+		viewer.apply(&threads[i])
+	}
+
+	var lastID int
+	if len(threads) > 0 {
+		lastID = threads[len(threads)-1].ID
+	}
+	setListHeaders(c, params, total, lastID, len(threads))
+	c.JSON(http.StatusOK, threads)
+}
+
+// CreateDiscussion
+// @Summary Start a discussion
+// @Description Создает тему курса или, с task_id, тему задачи. Если текст содержит решение задачи,
+// @Description тема сразу помечается спойлером.
+// @Tags Discussions
+// @Accept json
+// @Produce json
+// @Param id path int true "Course ID"
+// @Param thread body models.CreateThreadRequest true "Thread"
+// @Success 201 {object} models.DiscussionThread
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /courses/{id}/discussions [post]
+func CreateDiscussion(c *gin.Context) {
+	courseID, ok := discussionCourseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateThreadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	thread := models.DiscussionThread{
+		CourseID: courseID,
+		TaskID:   req.TaskID,
+		AuthorID: c.GetInt("userID"),
+		Title:    strings.TrimSpace(req.Title),
+		Body:     strings.TrimSpace(req.Body),
+	}
+	if thread.Title == "" || utf8.RuneCountInString(thread.Title) > models.MaxThreadTitleLength {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("Title must be 1-%d characters long", models.MaxThreadTitleLength)})
+		return
+	}
+	if !validDiscussionBody(c, thread.Body) {
+		return
+	}
+
+	if thread.TaskID != nil {
+		task, err := store(c).GetTaskByID(courseID, *thread.TaskID)
+		if err != nil {
+			if errors.Is(err, storage.ErrTaskNotFound) {
+				c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Task not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve task"})
+			return
+		}
+		thread.HasSpoiler = models.ContainsSolution(thread.Title+"\n"+thread.Body, task.Solution)
+	}
+
+	thread, err := store(c).CreateThread(thread)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create discussion"})
+		return
+	}
+	c.JSON(http.StatusCreated, thread)
+}
+
+// GetDiscussion
+// @Summary Get a discussion with replies
+// @Tags Discussions
+// @Produce json
+// @Param thread_id path int true "Thread ID"
+// @Success 200 {object} models.DiscussionThread
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /discussions/{thread_id} [get]
+func GetDiscussion(c *gin.Context) {
+	userID := c.GetInt("userID")
+	moderator := isModerator(userID)
+	thread, ok := visibleThread(c, moderator)
+	if !ok {
+		return
+	}
+
+	replies, err := store(c).GetReplies(thread.ID, moderator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve replies"})
+		return
+	}
+	thread.Replies = replies
+	newSpoilerViewer(userID, moderator).apply(&thread)
+	c.JSON(http.StatusOK, thread)
+}
+
+// CreateDiscussionReply
+// @Summary Reply to a discussion
+// @Description В закрытую тему отвечать могут только преподаватели. Ответ с решением задачи
+// @Description помечает тему спойлером. Автор темы получает уведомление об ответе.
+// @Tags Discussions
+// @Accept json
+// @Produce json
+// @Param thread_id path int true "Thread ID"
+// @Param reply body models.CreateReplyRequest true "Reply"
+// @Success 201 {object} models.DiscussionReply
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /discussions/{thread_id}/replies [post]
+func CreateDiscussionReply(c *gin.Context) {
+	userID := c.GetInt("userID")
+	moderator := isModerator(userID)
+	thread, ok := visibleThread(c, moderator)
+	if !ok {
+		return
+	}
+
+	var req models.CreateReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if !validDiscussionBody(c, body) {
+		return
+	}
+
+	if thread.IsLocked && !moderator {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Discussion is locked"})
+		return
+	}
+	viewer := newSpoilerViewer(userID, moderator)
+	if viewer.hides(thread) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Complete the task to join this discussion"})
+		return
+	}
+
+	reply, err := store(c).CreateReply(models.DiscussionReply{ThreadID: thread.ID, AuthorID: userID, Body: body})
+	if err != nil {
+		respondDiscussionError(c, err, "Failed to create reply")
+		return
+	}
+
+	if thread.TaskID != nil && !thread.HasSpoiler {
+		if task, err := store(c).GetTaskByID(thread.CourseID, *thread.TaskID); err == nil && models.ContainsSolution(body, task.Solution) {
+			spoiler := true
+			if _, err := store(c).ModerateThread(thread.ID, models.ThreadModeration{HasSpoiler: &spoiler}); err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to mark discussion as spoiler"})
+				return
+			}
+		}
+	}
+
+	if thread.AuthorID != userID {
+		notify(models.Notification{
+			UserID:  thread.AuthorID,
+			Type:    models.NotificationDiscussion,
+			Title:   "New reply: " + thread.Title,
+			Message: fmt.Sprintf("%s replied to your discussion \"%s\".", reply.AuthorName, thread.Title),
+			Payload: map[string]interface{}{"thread_id": thread.ID, "reply_id": reply.ID, "course_id": thread.CourseID},
+		})
+	}
+	c.JSON(http.StatusCreated, reply)
+}
+
+// ModerateDiscussion
+// @Summary Moderate a discussion
+// @Description Закрепляет, закрывает, скрывает тему или помечает ее спойлером. Меняются только
+// @Description переданные поля. Спойлером можно пометить только тему задачи.
+// @Tags Discussions
+// @Accept json
+// @Produce json
+// @Param thread_id path int true "Thread ID"
+// @Param moderation body models.ThreadModeration true "Moderation flags"
+// @Success 200 {object} models.DiscussionThread
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /teacher/discussions/{thread_id} [put]
+func ModerateDiscussion(c *gin.Context) {
+	threadID, ok := discussionThreadID(c)
+	if !ok {
+		return
+	}
+	var moderation models.ThreadModeration
+	if err := c.ShouldBindJSON(&moderation); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	if moderation.HasSpoiler != nil && *moderation.HasSpoiler {
+		thread, err := store(c).GetThread(threadID)
+		if err != nil {
+			respondDiscussionError(c, err, "Failed to retrieve discussion")
+			return
+		}
+		if thread.TaskID == nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Only task discussions can be marked as spoilers"})
+			return
+		}
+	}
+
+	thread, err := store(c).ModerateThread(threadID, moderation)
+	if err != nil {
+		respondDiscussionError(c, err, "Failed to moderate discussion")
+		return
+	}
+	c.JSON(http.StatusOK, thread)
+}
+
+// ModerateDiscussionReply
+// @Summary Hide or restore a reply
+// @Tags Discussions
+// @Accept json
+// @Produce json
+// @Param thread_id path int true "Thread ID"
+// @Param reply_id path int true "Reply ID"
+// @Param moderation body models.ReplyModeration true "Moderation flags"
+// @Success 200 {object} models.DiscussionReply
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /teacher/discussions/{thread_id}/replies/{reply_id} [put]
+func ModerateDiscussionReply(c *gin.Context) {
+	threadID, ok := discussionThreadID(c)
+	if !ok {
+		return
+	}
+	replyID, err := strconv.Atoi(c.Param("reply_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid reply ID"})
+		return
+	}
+	var moderation models.ReplyModeration
+	if err := c.ShouldBindJSON(&moderation); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	reply, err := store(c).ModerateReply(threadID, replyID, moderation.IsHidden)
+	if err != nil {
+		respondDiscussionError(c, err, "Failed to moderate reply")
+		return
+	}
+	c.JSON(http.StatusOK, reply)
+}
+
+// AcceptDiscussionReply
+// @Summary Mark the accepted answer
+// @Description Отмечает ответ как принятый; reply_id 0 снимает отметку. Автор ответа получает уведомление.
+// @Tags Discussions
+// @Accept json
+// @Produce json
+// @Param thread_id path int true "Thread ID"
+// @Param reply body models.AcceptReplyRequest true "Accepted reply"
+// @Success 200 {object} models.DiscussionThread
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /teacher/discussions/{thread_id}/accepted-reply [put]
+func AcceptDiscussionReply(c *gin.Context) {
+	threadID, ok := discussionThreadID(c)
+	if !ok {
+		return
+	}
+	var req models.AcceptReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ReplyID < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	thread, err := store(c).SetAcceptedReply(threadID, req.ReplyID)
+	if err != nil {
+		respondDiscussionError(c, err, "Failed to accept reply")
+		return
+	}
+
+	if req.ReplyID > 0 {
+		replies, err := store(c).GetReplies(threadID, true)
+		if err == nil {
+			for _, reply := range replies {
+				if reply.ID == req.ReplyID && reply.AuthorID != c.GetInt("userID") {
+					notify(models.Notification{
+						UserID:  reply.AuthorID,
+						Type:    models.NotificationDiscussion,
+						Title:   "Your answer was accepted",
+						Message: fmt.Sprintf("Your reply in \"%s\" was marked as the accepted answer.", thread.Title),
+						Payload: map[string]interface{}{"thread_id": thread.ID, "reply_id": reply.ID, "course_id": thread.CourseID},
+					})
+				}
+			}
+		}
+	}
+	c.JSON(http.StatusOK, thread)
+}
+
+// isModerator сообщает, может ли пользователь модерировать обсуждения: преподаватель или администратор
+func isModerator(userID int) bool {
+	if isTeacher, _ := CheckTeacherRights(userID); isTeacher {
+		return true
+	}
+	isAdmin, _ := CheckAdminRights(userID)
+	return isAdmin
+}
+
+// spoilerViewer скрывает текст тем со спойлером от пользователя, который еще не выполнил задачу.
+// Прогресс загружается один раз при первой теме со спойлером.
+type spoilerViewer struct {
+	userID    int
+	moderator bool
+	completed map[int]bool
+	loaded    bool
+}
+
+func newSpoilerViewer(userID int, moderator bool) *spoilerViewer {
+	return &spoilerViewer{userID: userID, moderator: moderator}
+}
+
+func (v *spoilerViewer) hides(thread models.DiscussionThread) bool {
+	if !thread.HasSpoiler || thread.TaskID == nil || v.moderator || thread.AuthorID == v.userID {
+		return false
+	}
+	if !v.loaded {
+		v.loaded = true
+		// Без прогресса тема остается скрытой
+		if progress, err := Store.GetUserProgress(v.userID); err == nil {
+			v.completed = progress.Completed
+		}
+	}
+	return !v.completed[*thread.TaskID]
+}
+
+func (v *spoilerViewer) apply(thread *models.DiscussionThread) {
+	if v.hides(*thread) {
+		thread.Body = ""
+		thread.Replies = nil
+		thread.SpoilerHidden = true
+	}
+}
+
+// visibleThread загружает тему из пути запроса; скрытая тема для обычного пользователя не существует
+func visibleThread(c *gin.Context, moderator bool) (models.DiscussionThread, bool) {
+	threadID, ok := discussionThreadID(c)
+	if !ok {
+		return models.DiscussionThread{}, false
+	}
+	thread, err := store(c).GetThread(threadID)
+	if err != nil {
+		respondDiscussionError(c, err, "Failed to retrieve discussion")
+		return thread, false
+	}
+	if thread.IsHidden && !moderator {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Discussion not found"})
+		return thread, false
+	}
+	return thread, true
+}
+
+func discussionCourseID(c *gin.Context) (int, bool) {
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return 0, false
+	}
+	if _, err := store(c).GetCourseByID(courseID); err != nil {
+		if errors.Is(err, storage.ErrCourseNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course not found"})
+			return 0, false
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve course"})
+		return 0, false
+	}
+	return courseID, true
+}
+
+func discussionThreadID(c *gin.Context) (int, bool) {
+	threadID, err := strconv.Atoi(c.Param("thread_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid thread ID"})
+		return 0, false
+	}
+	return threadID, true
+}
+
+func validDiscussionBody(c *gin.Context, body string) bool {
+	if body == "" || utf8.RuneCountInString(body) > models.MaxDiscussionBodyLength {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("Body must be 1-%d characters long", models.MaxDiscussionBodyLength)})
+		return false
+	}
+	return true
+}
+
+func respondDiscussionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrThreadNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Discussion not found"})
+	case errors.Is(err, storage.ErrReplyNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Reply not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message})
+	}
+}
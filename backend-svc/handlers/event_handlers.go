@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/metrics"
+	"lmsmodule/backend-svc/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventHeartbeatInterval - как часто в поток событий пишется комментарий, чтобы прокси
+// и балансировщики не закрывали простаивающее соединение
+const EventHeartbeatInterval = 25 * time.Second
+
+// StreamEvents
+// @Summary Subscribe to live updates
+// @Description Поток Server-Sent Events с событиями текущего пользователя: notification, grading,
+// @Description progress, lab_result и leaderboard. Данные события - JSON models.LiveEvent.
+// @Description Токен передается только в заголовке Authorization, чтобы не попасть в журналы запросов;
+// @Description в браузере нужен EventSource на основе fetch.
+// @Tags Events
+// @Produce text/event-stream
+// @Success 200 {object} models.LiveEvent
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /events/stream [get]
+func StreamEvents(c *gin.Context) {
+	if Events == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Live updates are disabled"})
+		return
+	}
+
+	stream, cancel := Events.Subscribe(c.GetInt("userID"))
+	defer cancel()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Отключает буферизацию ответа в nginx
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(EventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				logger.Default().Error("Error encoding live event %d: %v", event.ID, err)
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// PublishLabResult
+// @Summary Publish a lab execution result
+// @Description Вызывается сервисом исполнения кода, когда результат лабораторной готов; клиент
+// @Description получает событие lab_result вместо опроса /executor/result. Запрос подписывается
+// @Description общим секретом в заголовке X-Internal-Token и не проксируется шлюзом.
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param X-Internal-Token header string true "Shared service token"
+// @Param result body models.LabResultEvent true "Lab result"
+// @Success 202 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /internal/events/lab-results [post]
+func PublishLabResult(c *gin.Context) {
+	if InternalEventsToken == "" || Events == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Live updates are disabled"})
+		return
+	}
+	token := c.GetHeader("X-Internal-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(InternalEventsToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid internal token"})
+		return
+	}
+
+	var result models.LabResultEvent
+	if err := c.ShouldBindJSON(&result); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	err := Events.Publish(models.LiveEvent{
+		Type:   models.EventLabResult,
+		UserID: result.UserID,
+		Data: map[string]interface{}{
+			"session_id": result.SessionID,
+			"status":     result.Status,
+			"result":     result.Result,
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to publish lab result"})
+		return
+	}
+	c.JSON(http.StatusAccepted, models.SuccessResponse{Message: "Lab result published"})
+}
+
+// publishEvent отправляет событие подключенным клиентам. Канал обновлений только подсказывает
+// клиенту, что данные изменились, поэтому ошибка публикации не отменяет действие.
+func publishEvent(eventType string, userID int, data map[string]interface{}) {
+	if Events == nil {
+		return
+	}
+	if err := Events.Publish(models.LiveEvent{Type: eventType, UserID: userID, Data: data}); err != nil {
+		logger.Default().Error("Error publishing %s event for user %d: %v", eventType, userID, err)
+	}
+}
+
+// taskCompleted выдает сертификаты и значки за выполненную задачу и сообщает об изменении
+// прогресса пользователю, а рейтинга - всем подключенным клиентам
+func taskCompleted(userID, taskID int) {
+	metrics.TaskCompleted()
+	awardCertificates(userID)
+	awardBadges(userID)
+	publishEvent(models.EventProgress, userID, map[string]interface{}{"task_id": taskID})
+	publishEvent(models.EventLeaderboard, 0, map[string]interface{}{"user_id": userID})
+}
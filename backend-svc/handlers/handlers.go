@@ -2,7 +2,14 @@ package handlers
 
 import (
 	"database/sql"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/certificate"
+	"lmsmodule/backend-svc/events"
+	"lmsmodule/backend-svc/learningpath"
+	"lmsmodule/backend-svc/notifications"
 	"lmsmodule/backend-svc/storage"
+
+	"github.com/gin-gonic/gin"
 )
 
 // Глобальные переменные
@@ -11,8 +18,30 @@ var (
 	Store         storage.Storage
 	JWTSecret     = "your_strong_secret_here"
 	TempJWTSecret = "temp_2fa_secret_here"
+	// LearningPathStrategy - стратегия траектории обучения, если клиент не выбрал другую
+	LearningPathStrategy = learningpath.DefaultStrategy
+	// CertificateSigner подписывает сертификаты; если не задан, создается случайный ключ
+	CertificateSigner *certificate.Signer
+	// CertificateVerifyURL - публичный адрес проверки сертификатов, который кодируется в QR
+	CertificateVerifyURL = "https://localhost:8080/api/certificates/verify"
+	// NotificationMailer дублирует уведомления письмом; если не задан, письма не отправляются
+	NotificationMailer notifications.Mailer
+	// Events раздает события подключенным клиентам; если не задан, канал обновлений выключен
+	Events *events.Hub
+	// InternalEventsToken - общий секрет сервисов, публикующих события; пустой выключает прием
+	InternalEventsToken string
 )
 
+// requestLog возвращает журнал с идентификатором текущего запроса
+func requestLog(c *gin.Context) *logger.Logger {
+	return logger.Default().WithContext(c.Request.Context())
+}
+
+// store возвращает хранилище, вызовы которого записываются спанами трассировки запроса
+func store(c *gin.Context) storage.Storage {
+	return storage.WithTracing(Store, c.Request.Context())
+}
+
 // UseStorage устанавливает хранилище для обработчиков
 func UseStorage(s storage.Storage) {
 	Store = s
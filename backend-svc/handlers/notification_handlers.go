@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/notifications"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications
+// @Summary Get user notifications
+// @Description Уведомления текущего пользователя от новых к старым. Курсор - id последнего
+// @Description полученного уведомления, выборка продолжается с более старых.
+// @Tags Notifications
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return notifications older than cursor"
+// @Success 200 {array} models.Notification
+// @Header 200 {integer} X-Total-Count "Total number of matching notifications"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /notifications [get]
+func GetNotifications(c *gin.Context) {
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	unreadOnly := false
+	if value := c.Query("unread"); value != "" {
+		if unreadOnly, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid unread parameter"})
+			return
+		}
+	}
+
+	list, total, err := store(c).GetNotifications(c.GetInt("userID"), unreadOnly, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve notifications"})
+		return
+	}
+
+	var lastID int
+	if len(list) > 0 {
+		lastID = list[len(list)-1].ID
+	}
+	setListHeaders(c, params, total, lastID, len(list))
+	c.JSON(http.StatusOK, list)
+}
+
+// GetUnreadNotificationCount
+// @Summary Get unread notification count
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} models.UnreadNotificationsResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /notifications/unread-count [get]
+func GetUnreadNotificationCount(c *gin.Context) {
+	count, err := store(c).CountUnreadNotifications(c.GetInt("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to count notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, models.UnreadNotificationsResponse{UnreadCount: count})
+}
+
+// MarkNotificationRead
+// @Summary Mark a notification as read
+// @Tags Notifications
+// @Produce json
+// @Param notification_id path int true "Notification ID"
+// @Success 200 {object} models.Notification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /notifications/{notification_id}/read [post]
+func MarkNotificationRead(c *gin.Context) {
+	notificationID, err := strconv.Atoi(c.Param("notification_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid notification ID"})
+		return
+	}
+
+	notification, err := store(c).MarkNotificationRead(c.GetInt("userID"), notificationID, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Notification not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to mark notification as read"})
+		return
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead
+// @Summary Mark all notifications as read
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} models.MarkNotificationsReadResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /notifications/read-all [post]
+func MarkAllNotificationsRead(c *gin.Context) {
+	marked, err := store(c).MarkAllNotificationsRead(c.GetInt("userID"), time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to mark notifications as read"})
+		return
+	}
+	c.JSON(http.StatusOK, models.MarkNotificationsReadResponse{Marked: marked})
+}
+
+// GetNotificationPreferences
+// @Summary Get notification email preferences
+// @Description Для каждого типа уведомления - дублируется ли оно письмом
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} models.NotificationPreferences
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /notifications/preferences [get]
+func GetNotificationPreferences(c *gin.Context) {
+	prefs, err := store(c).GetNotificationPreferences(c.GetInt("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs.WithDefaults())
+}
+
+// UpdateNotificationPreferences
+// @Summary Update notification email preferences
+// @Description Меняет настройки только для переданных типов: grading_result, course_published,
+// @Description deadline_reminder, role_changed, account_status, discussion
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param preferences body models.NotificationPreferences true "Email preferences by notification type"
+// @Success 200 {object} models.NotificationPreferences
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /notifications/preferences [put]
+func UpdateNotificationPreferences(c *gin.Context) {
+	var prefs models.NotificationPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	for notificationType := range prefs.Email {
+		if !models.IsValidNotificationType(notificationType) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown notification type: " + notificationType})
+			return
+		}
+	}
+
+	userID := c.GetInt("userID")
+	if err := store(c).UpdateNotificationPreferences(userID, prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update notification preferences"})
+		return
+	}
+
+	prefs, err := store(c).GetNotificationPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs.WithDefaults())
+}
+
+// SendNotification сохраняет уведомление, дублирует его письмом по настройкам пользователя
+// и сообщает о нем подключенным клиентам
+func SendNotification(notification models.Notification) (models.Notification, error) {
+	notification, err := notifications.Send(Store, NotificationMailer, notification)
+	if err != nil {
+		return notification, err
+	}
+	publishEvent(models.EventNotification, notification.UserID, map[string]interface{}{"notification": notification})
+	return notification, nil
+}
+
+// notify отправляет уведомление. Ошибка уведомления не отменяет действие, которое его вызвало.
+func notify(notification models.Notification) {
+	if _, err := SendNotification(notification); err != nil {
+		logger.Default().Error("Error sending %s notification to user %d: %v", notification.Type, notification.UserID, err)
+	}
+}
+
+// scoreUnit - единица балла в уведомлении об оценке: задача оценивается в баллах
+// с учетом штрафа, попытка квиза - в процентах верных ответов
+type scoreUnit string
+
+const (
+	scorePoints  scoreUnit = "points"
+	scorePercent scoreUnit = "percent"
+)
+
+func notifyGradingResult(userID, taskID int, isCorrect bool, score float64, unit scoreUnit, isLate bool) {
+	verdict := "incorrect"
+	if isCorrect {
+		verdict = "correct"
+	}
+	scoreText := fmt.Sprintf("%.2f points", score)
+	if unit == scorePercent {
+		scoreText = fmt.Sprintf("%.0f%%", score)
+	}
+	message := fmt.Sprintf("Your answer to task #%d was graded as %s with a score of %s.", taskID, verdict, scoreText)
+	if isLate {
+		message += " A late penalty was applied."
+	}
+	payload := map[string]interface{}{"task_id": taskID, "is_correct": isCorrect, "score": score, "score_unit": unit, "is_late": isLate}
+	publishEvent(models.EventGrading, userID, payload)
+	notify(models.Notification{
+		UserID:  userID,
+		Type:    models.NotificationGradingResult,
+		Title:   "Your answer has been graded",
+		Message: message,
+		Payload: payload,
+	})
+}
+
+// notifyCoursePublished сообщает о новом курсе всем активным пользователям. CreateCourse
+// запускает рассылку в фоне, чтобы она не задерживала ответ.
+func notifyCoursePublished(course models.Course) {
+	active := true
+	users, _, err := Store.GetAllUsers(models.ListParams{IsActive: &active})
+	if err != nil {
+		logger.Default().Error("Error loading users for course %d notification: %v", course.ID, err)
+		return
+	}
+	userIDs := make([]int, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+
+	notification := models.Notification{
+		Type:    models.NotificationCoursePublished,
+		Title:   "New course: " + course.VulnerabilityType,
+		Message: fmt.Sprintf("A new course \"%s\" has been published.", course.VulnerabilityType),
+		Payload: map[string]interface{}{"course_id": course.ID},
+	}
+	for _, sent := range notifications.Broadcast(Store, NotificationMailer, userIDs, notification) {
+		publishEvent(models.EventNotification, sent.UserID, map[string]interface{}{"notification": sent})
+	}
+}
+
+func notifyRoleChanged(userID int, role string, granted bool) {
+	title, message := "Role granted: "+role, "You have been granted the "+role+" role."
+	if !granted {
+		title, message = "Role revoked: "+role, "Your "+role+" role has been revoked."
+	}
+	notify(models.Notification{
+		UserID:  userID,
+		Type:    models.NotificationRoleChanged,
+		Title:   title,
+		Message: message,
+		Payload: map[string]interface{}{"role": role, "granted": granted},
+	})
+}
+
+func notifyAccountStatus(userID int, isActive bool) {
+	title, message := "Your account has been activated", "Your account has been activated by an administrator."
+	if !isActive {
+		title, message = "Your account has been deactivated", "Your account has been deactivated by an administrator."
+	}
+	notify(models.Notification{
+		UserID:  userID,
+		Type:    models.NotificationAccountStatus,
+		Title:   title,
+		Message: message,
+		Payload: map[string]interface{}{"is_active": isActive},
+	})
+}
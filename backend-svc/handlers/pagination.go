@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"lmsmodule/backend-svc/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parseListParams разбирает общие параметры списочных запросов:
+// page и limit (или cursor), sort (поле, с префиксом "-" для убывания),
+// фильтры is_active, is_teacher, is_admin, course_id и диапазон дат from/to.
+// Поле сортировки проверяется по списку допустимых полей конкретного эндпоинта.
+// Без page, limit и cursor список возвращается целиком, как до появления пагинации.
+func parseListParams(c *gin.Context, sortFields ...string) (models.ListParams, error) {
+	params := models.ListParams{Page: 1}
+	if c.Query("page") != "" || c.Query("cursor") != "" {
+		params.Limit = defaultPageLimit
+	}
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			return params, errors.New("Invalid page parameter")
+		}
+		params.Page = page
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return params, errors.New("Invalid limit parameter")
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+		params.Limit = limit
+	}
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := strconv.Atoi(cursorStr)
+		if err != nil || cursor < 1 {
+			return params, errors.New("Invalid cursor parameter")
+		}
+		if c.Query("page") != "" || c.Query("sort") != "" {
+			return params, errors.New("Cursor cannot be combined with page or sort parameters")
+		}
+		params.Cursor = cursor
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		field := strings.TrimPrefix(sort, "-")
+		if !containsString(sortFields, field) {
+			return params, errors.New("Invalid sort field: " + field)
+		}
+		params.SortBy = field
+		params.SortDesc = strings.HasPrefix(sort, "-")
+	}
+
+	var err error
+	if params.IsActive, err = parseBoolFilter(c, "is_active"); err != nil {
+		return params, err
+	}
+	if params.IsTeacher, err = parseBoolFilter(c, "is_teacher"); err != nil {
+		return params, err
+	}
+	if params.IsAdmin, err = parseBoolFilter(c, "is_admin"); err != nil {
+		return params, err
+	}
+
+	if courseIDStr := c.Query("course_id"); courseIDStr != "" {
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID < 1 {
+			return params, errors.New("Invalid course_id parameter")
+		}
+		params.CourseID = courseID
+	}
+
+	if params.From, err = parseDateFilter(c, "from", false); err != nil {
+		return params, err
+	}
+	if params.To, err = parseDateFilter(c, "to", true); err != nil {
+		return params, err
+	}
+	if !params.From.IsZero() && !params.To.IsZero() && !params.From.Before(params.To) {
+		return params, errors.New("Parameter from must be earlier than to")
+	}
+
+	return params, nil
+}
+
+func parseBoolFilter(c *gin.Context, name string) (*bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.New("Invalid " + name + " parameter")
+	}
+	return &parsed, nil
+}
+
+// parseDateFilter принимает дату в формате RFC3339 или YYYY-MM-DD.
+// Для верхней границы дата без времени включает весь указанный день.
+func parseDateFilter(c *gin.Context, name string, upperBound bool) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid " + name + " parameter, expected RFC3339 or YYYY-MM-DD")
+	}
+	if upperBound {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// setListHeaders добавляет в ответ общее количество записей и параметры страницы
+func setListHeaders(c *gin.Context, params models.ListParams, total int, lastID int, count int) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	if params.Limit > 0 {
+		c.Header("X-Limit", strconv.Itoa(params.Limit))
+	}
+
+	if params.IsCursor() {
+		if count == params.Limit && lastID > 0 {
+			c.Header("X-Next-Cursor", strconv.Itoa(lastID))
+		}
+		return
+	}
+
+	c.Header("X-Page", strconv.Itoa(params.Page))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
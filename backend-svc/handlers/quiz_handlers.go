@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"errors"
+	"lmsmodule/backend-svc/metrics"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetQuestionBanks
+// @Summary List question banks of a course
+// @Tags Quizzes
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Success 200 {array} models.QuestionBank
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/question-banks [get]
+func GetQuestionBanks(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return
+	}
+
+	banks, err := store(c).GetQuestionBanks(courseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve question banks: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, banks)
+}
+
+// CreateQuestionBank
+// @Summary Create a question bank
+// @Description Вопросы бывают single_choice, multiple_choice, short_answer (варианты - допустимые ответы)
+// @Description и ordering (порядок вариантов - верный). Вес вопроса по умолчанию - 1.
+// @Tags Quizzes
+// @Accept json
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param bank body models.QuestionBank true "Question bank"
+// @Success 201 {object} models.QuestionBank
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/question-banks [post]
+func CreateQuestionBank(c *gin.Context) {
+	bank, ok := bindQuestionBank(c)
+	if !ok {
+		return
+	}
+
+	bank, err := store(c).CreateQuestionBank(bank)
+	if err != nil {
+		respondQuizError(c, err, "Failed to create question bank")
+		return
+	}
+
+	c.JSON(http.StatusCreated, bank)
+}
+
+// GetQuestionBank
+// @Summary Get a question bank with answers
+// @Tags Quizzes
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param bank_id path int true "Question bank ID"
+// @Success 200 {object} models.QuestionBank
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/question-banks/{bank_id} [get]
+func GetQuestionBank(c *gin.Context) {
+	courseID, bankID, ok := questionBankPath(c)
+	if !ok {
+		return
+	}
+
+	bank, err := store(c).GetQuestionBank(courseID, bankID)
+	if err != nil {
+		respondQuizError(c, err, "Failed to retrieve question bank")
+		return
+	}
+
+	c.JSON(http.StatusOK, bank)
+}
+
+// UpdateQuestionBank
+// @Summary Replace a question bank
+// @Description Заменяет название и вопросы банка. Начатые попытки используют прежние вопросы.
+// @Tags Quizzes
+// @Accept json
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param bank_id path int true "Question bank ID"
+// @Param bank body models.QuestionBank true "Question bank"
+// @Success 200 {object} models.QuestionBank
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/question-banks/{bank_id} [put]
+func UpdateQuestionBank(c *gin.Context) {
+	_, bankID, ok := questionBankPath(c)
+	if !ok {
+		return
+	}
+	bank, ok := bindQuestionBank(c)
+	if !ok {
+		return
+	}
+	bank.ID = bankID
+
+	bank, err := store(c).UpdateQuestionBank(bank)
+	if err != nil {
+		respondQuizError(c, err, "Failed to update question bank")
+		return
+	}
+
+	c.JSON(http.StatusOK, bank)
+}
+
+// DeleteQuestionBank
+// @Summary Delete a question bank
+// @Description Банк, из которого собирается хотя бы один тест, удалить нельзя
+// @Tags Quizzes
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param bank_id path int true "Question bank ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/question-banks/{bank_id} [delete]
+func DeleteQuestionBank(c *gin.Context) {
+	courseID, bankID, ok := questionBankPath(c)
+	if !ok {
+		return
+	}
+
+	if err := store(c).DeleteQuestionBank(courseID, bankID); err != nil {
+		respondQuizError(c, err, "Failed to delete question bank")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Question bank deleted successfully"})
+}
+
+// StartQuizAttempt
+// @Summary Start a quiz attempt
+// @Description Выдает случайные вопросы из банков теста без верных ответов. Если есть незавершенная
+// @Description попытка, возвращает ее. Число попыток и время на попытку ограничиваются настройками теста.
+// @Tags Quizzes
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param task_id path int true "Task ID"
+// @Success 200 {object} models.QuizAttempt
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /progress/{user_id}/tasks/{task_id}/quiz-attempts [post]
+func StartQuizAttempt(c *gin.Context) {
+	userID, ok := progressUser(c, false)
+	if !ok {
+		return
+	}
+
+	taskID, err := strconv.Atoi(c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid task ID"})
+		return
+	}
+
+	attempt, err := store(c).StartQuizAttempt(userID, taskID, time.Now())
+	if err != nil {
+		respondQuizError(c, err, "Failed to start quiz")
+		return
+	}
+
+	c.JSON(http.StatusOK, attempt.ForStudent())
+}
+
+// GetQuizAttempts
+// @Summary List quiz attempts of a user
+// @Tags Quizzes
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param task_id path int true "Task ID"
+// @Success 200 {array} models.QuizAttempt
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /progress/{user_id}/tasks/{task_id}/quiz-attempts [get]
+func GetQuizAttempts(c *gin.Context) {
+	userID, ok := progressUser(c, true)
+	if !ok {
+		return
+	}
+
+	taskID, err := strconv.Atoi(c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid task ID"})
+		return
+	}
+
+	attempts, err := store(c).GetQuizAttempts(userID, taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve quiz attempts: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// GetQuizAttempt
+// @Summary Get a quiz attempt
+// @Description Студент видит вопросы, свои ответы и оценки без верных ответов, администратор - полностью
+// @Tags Quizzes
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param attempt_id path int true "Attempt ID"
+// @Success 200 {object} models.QuizAttempt
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /progress/{user_id}/quiz-attempts/{attempt_id} [get]
+func GetQuizAttempt(c *gin.Context) {
+	userID, ok := progressUser(c, true)
+	if !ok {
+		return
+	}
+	attempt, ok := userQuizAttempt(c, userID)
+	if !ok {
+		return
+	}
+
+	if isAdmin, _ := CheckAdminRights(c.GetInt("userID")); !isAdmin {
+		attempt = attempt.ForStudent()
+	}
+	c.JSON(http.StatusOK, attempt)
+}
+
+// SubmitQuizAttempt
+// @Summary Submit answers of a quiz attempt
+// @Description Ответы оцениваются автоматически с частичным зачетом. Ответы после истечения времени
+// @Description не засчитываются. Балл задачи начисляется пропорционально проценту верных ответов,
+// @Description при достижении проходного процента задача отмечается выполненной.
+// @Tags Quizzes
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param attempt_id path int true "Attempt ID"
+// @Param submission body models.QuizSubmission true "Answers"
+// @Success 200 {object} models.QuizAttempt
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /progress/{user_id}/quiz-attempts/{attempt_id}/submit [post]
+func SubmitQuizAttempt(c *gin.Context) {
+	userID, ok := progressUser(c, false)
+	if !ok {
+		return
+	}
+
+	var submission models.QuizSubmission
+	if err := c.ShouldBindJSON(&submission); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid submission data"})
+		return
+	}
+
+	attempt, ok := userQuizAttempt(c, userID)
+	if !ok {
+		return
+	}
+
+	attempt, err := store(c).SubmitQuizAttempt(attempt.ID, submission.Answers, time.Now())
+	if err != nil {
+		respondQuizError(c, err, "Failed to submit quiz")
+		return
+	}
+
+	metrics.Submission(metrics.SubmissionQuiz, attempt.IsPassed)
+	notifyGradingResult(userID, attempt.TaskID, attempt.IsPassed, attempt.Score, scorePercent, attempt.IsLate)
+	if attempt.IsPassed {
+		taskCompleted(userID, attempt.TaskID)
+	}
+	c.JSON(http.StatusOK, attempt.ForStudent())
+}
+
+// validateTaskType проверяет тип задачи и параметры теста
+func validateTaskType(task *models.Task) error {
+	switch task.Type {
+	case "", models.TaskTypeCode:
+		task.Type = models.TaskTypeCode
+		task.Quiz = nil
+	case models.TaskTypeQuiz:
+		if task.Quiz == nil {
+			return errors.New("Quiz settings are required for quiz tasks")
+		}
+		return task.Quiz.Validate()
+	default:
+		return errors.New("Unknown task type")
+	}
+	return nil
+}
+
+// bindQuestionBank читает и проверяет банк вопросов из запроса
+func bindQuestionBank(c *gin.Context) (models.QuestionBank, bool) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return models.QuestionBank{}, false
+	}
+
+	var bank models.QuestionBank
+	if err := c.ShouldBindJSON(&bank); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return bank, false
+	}
+	bank.CourseID = courseID
+	bank.Title = strings.TrimSpace(bank.Title)
+	for i := range bank.Questions {
+		if bank.Questions[i].Points == 0 {
+			bank.Questions[i].Points = 1
+		}
+	}
+
+	if err := bank.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return bank, false
+	}
+	return bank, true
+}
+
+func questionBankPath(c *gin.Context) (int, int, bool) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return 0, 0, false
+	}
+	bankID, err := strconv.Atoi(c.Param("bank_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid question bank ID"})
+		return 0, 0, false
+	}
+	return courseID, bankID, true
+}
+
+// progressUser возвращает пользователя из пути запроса и проверяет, что это текущий пользователь.
+// Работать с данными другого пользователя может администратор, если allowAdmin.
+func progressUser(c *gin.Context, allowAdmin bool) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid user ID"})
+		return 0, false
+	}
+
+	currentUserID := c.GetInt("userID")
+	if userID != currentUserID {
+		isAdmin, _ := CheckAdminRights(currentUserID)
+		if !allowAdmin || !isAdmin {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Access denied"})
+			return 0, false
+		}
+	}
+	return userID, true
+}
+
+// userQuizAttempt загружает попытку из пути запроса и проверяет, что она принадлежит пользователю
+func userQuizAttempt(c *gin.Context, userID int) (models.QuizAttempt, bool) {
+	attemptID, err := strconv.Atoi(c.Param("attempt_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid attempt ID"})
+		return models.QuizAttempt{}, false
+	}
+
+	attempt, err := store(c).GetQuizAttempt(attemptID)
+	if err == nil && attempt.UserID != userID {
+		err = storage.ErrQuizAttemptNotFound
+	}
+	if err != nil {
+		respondQuizError(c, err, "Failed to retrieve quiz attempt")
+		return models.QuizAttempt{}, false
+	}
+	return attempt, true
+}
+
+func respondQuizError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrQuestionBankNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Question bank not found"})
+	case errors.Is(err, storage.ErrQuestionBankInUse):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Question bank is used by a quiz"})
+	case errors.Is(err, storage.ErrCourseNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course not found"})
+	case errors.Is(err, storage.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Task not found"})
+	case errors.Is(err, storage.ErrNotQuizTask):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Task is not a quiz"})
+	case errors.Is(err, storage.ErrQuizHasNoQuestions):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Quiz has no questions yet"})
+	case errors.Is(err, storage.ErrQuizAttemptNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Quiz attempt not found"})
+	case errors.Is(err, storage.ErrQuizAttemptsExhausted):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "No quiz attempts left"})
+	case errors.Is(err, storage.ErrQuizAttemptFinished):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Quiz attempt is already finished"})
+	case errors.Is(err, storage.ErrAssignmentNotOpen):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Assignment is not open yet"})
+	case errors.Is(err, storage.ErrAssignmentClosed):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Assignment is closed, submissions are no longer accepted"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message + ": " + err.Error()})
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/report"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	reportFormatJSON = "json"
+	reportFormatCSV  = "csv"
+	reportFormatXLSX = "xlsx"
+
+	maxInactivityDays = 365
+	xlsxContentType   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// GetLearningEffectiveness
+// @Summary Get learning effectiveness report for a course
+// @Description Совокупные показатели курса за период: средний процент выполнения и баллов,
+// @Description доля завершивших и выбывших студентов, время работы и самые сложные задачи.
+// @Description Определения показателей и формула effectiveness_score описаны в models/effectiveness.go.
+// @Tags Analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param course_id path int true "Course ID"
+// @Param period query string false "Report period: week, month, term (default month)"
+// @Param to query string false "Period end (RFC3339 or YYYY-MM-DD, date is inclusive; default now)"
+// @Param from query string false "Period start (RFC3339 or YYYY-MM-DD); overrides period length"
+// @Param inactive_days query int false "Days without activity after which a student counts as dropped out (default 14)"
+// @Param format query string false "Response format: json, csv, xlsx (default json)"
+// @Success 200 {object} models.LearningEffectiveness
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /teacher/courses/{course_id}/effectiveness [get]
+// @Router /admin/analytics/courses/{course_id}/effectiveness [get]
+func GetLearningEffectiveness(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return
+	}
+
+	userID := c.GetInt("userID")
+	isAdmin, _ := CheckAdminRights(userID)
+	isTeacher, _ := CheckTeacherRights(userID)
+	if !isAdmin && !isTeacher {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Only teachers and administrators can view course effectiveness"})
+		return
+	}
+
+	params, err := parseEffectivenessParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	params.CourseID = courseID
+
+	format := c.DefaultQuery("format", reportFormatJSON)
+	if format != reportFormatJSON && format != reportFormatCSV && format != reportFormatXLSX {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid format parameter, expected json, csv or xlsx"})
+		return
+	}
+
+	effectiveness, err := store(c).GetLearningEffectiveness(params)
+	if err != nil {
+		if errors.Is(err, storage.ErrCourseNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Course not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to build effectiveness report: " + err.Error()})
+		return
+	}
+
+	if format == reportFormatJSON {
+		c.JSON(http.StatusOK, effectiveness)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "text/csv; charset=utf-8"
+	tables := effectivenessTables(effectiveness)
+	if format == reportFormatXLSX {
+		contentType = xlsxContentType
+		err = report.WriteXLSX(&buf, tables...)
+	} else {
+		err = report.WriteCSV(&buf, tables...)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to export effectiveness report: " + err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("effectiveness_course_%d_%s.%s", courseID, params.Period, format)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// parseEffectivenessParams разбирает период отчета. Без from начало периода
+// отсчитывается от to (по умолчанию - текущий момент) на длину period.
+func parseEffectivenessParams(c *gin.Context) (models.EffectivenessParams, error) {
+	params := models.EffectivenessParams{
+		Period:         c.DefaultQuery("period", models.PeriodMonth),
+		InactivityDays: models.DefaultInactivityDays,
+	}
+	if !models.IsValidPeriod(params.Period) {
+		return params, errors.New("Invalid period parameter, expected week, month or term")
+	}
+
+	var err error
+	if params.To, err = parseDateFilter(c, "to", true); err != nil {
+		return params, err
+	}
+	if params.To.IsZero() {
+		params.To = time.Now()
+	}
+	if params.From, err = parseDateFilter(c, "from", false); err != nil {
+		return params, err
+	}
+	if params.From.IsZero() {
+		params.From = models.PeriodStart(params.Period, params.To)
+	}
+	if !params.From.Before(params.To) {
+		return params, errors.New("Parameter from must be earlier than to")
+	}
+
+	if daysStr := c.Query("inactive_days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 1 || days > maxInactivityDays {
+			return params, fmt.Errorf("Invalid inactive_days parameter, expected 1 to %d", maxInactivityDays)
+		}
+		params.InactivityDays = days
+	}
+
+	return params, nil
+}
+
+// effectivenessTables раскладывает отчет на таблицу сводных показателей и таблицу сложных задач
+func effectivenessTables(e models.LearningEffectiveness) []report.Table {
+	summary := report.Table{
+		Name: "Summary",
+		Rows: [][]string{
+			{"metric", "value"},
+			{"course_id", strconv.Itoa(e.CourseID)},
+			{"course_name", e.CourseName},
+			{"period", e.Period},
+			{"period_start", e.PeriodStart.UTC().Format(time.RFC3339)},
+			{"period_end", e.PeriodEnd.UTC().Format(time.RFC3339)},
+			{"inactivity_threshold_days", strconv.Itoa(e.InactivityDays)},
+			{"total_students", strconv.Itoa(e.TotalStudents)},
+			{"average_completion_percentage", formatFloat(e.AverageCompletion)},
+			{"average_grade", formatFloat(e.AverageGrade)},
+			{"completion_rate", formatFloat(e.CompletionRate)},
+			{"dropout_rate", formatFloat(e.DropoutRate)},
+			{"average_time_spent", e.AverageTimeSpent},
+			{"effectiveness_score", formatFloat(e.EffectivenessScore)},
+		},
+	}
+
+	difficult := report.Table{
+		Name: "Difficult tasks",
+		Rows: [][]string{{"task_id", "task_title", "fail_rate", "average_score", "average_time"}},
+	}
+	for _, task := range e.DifficultTasks {
+		difficult.Rows = append(difficult.Rows, []string{
+			strconv.Itoa(task.TaskID),
+			task.TaskTitle,
+			formatFloat(task.FailRate),
+			formatFloat(task.AverageScore),
+			task.AverageTime,
+		})
+	}
+
+	return []report.Table{summary, difficult}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"lmsmodule/backend-svc/models"
+	"net/http"
+	"strings"
+)
+
+// Search выполняет единый полнотекстовый поиск
+// @Summary Search courses, tasks and users
+// @Description Ranked full-text search over course titles and descriptions, task titles, descriptions and content.
+// @Description Teachers and administrators also match task solutions; administrators can search users.
+// @Description Snippets are HTML-escaped, matches are wrapped in <mark> tags.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param type query string false "Comma-separated result types: course, task, user"
+// @Param course_id query int false "Restrict courses and tasks to a course"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Success 200 {array} models.SearchResult
+// @Header 200 {integer} X-Total-Count "Total number of results"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /search [get]
+func Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Search query is required"})
+		return
+	}
+
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if params.IsCursor() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Cursor pagination is not supported for search"})
+		return
+	}
+
+	query := models.SearchQuery{Text: text}
+	if typesStr := c.Query("type"); typesStr != "" {
+		for _, t := range strings.Split(typesStr, ",") {
+			t = strings.TrimSpace(t)
+			if t != models.SearchTypeCourse && t != models.SearchTypeTask && t != models.SearchTypeUser {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid search type: " + t})
+				return
+			}
+			query.Types = append(query.Types, t)
+		}
+	}
+
+	userID := c.GetInt("userID")
+	isAdmin, err := CheckAdminRights(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Error checking admin rights: " + err.Error()})
+		return
+	}
+	isTeacher, err := CheckTeacherRights(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Error checking teacher rights: " + err.Error()})
+		return
+	}
+
+	// Решения задач видят только преподаватели и администраторы, пользователей ищут только администраторы
+	query.IncludeSolutions = isAdmin || isTeacher
+	query.IncludeUsers = isAdmin
+	if !isAdmin && containsString(query.Types, models.SearchTypeUser) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Only administrators can search users"})
+		return
+	}
+
+	results, total, err := store(c).Search(query, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to search: " + err.Error()})
+		return
+	}
+	if results == nil {
+		results = []models.SearchResult{}
+	}
+
+	setListHeaders(c, params, total, 0, len(results))
+	c.JSON(http.StatusOK, results)
+}
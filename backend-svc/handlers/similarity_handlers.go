@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSimilarityFlags
+// @Summary List suspicious submission pairs
+// @Description Пары ответов разных студентов на одну задачу курса, отмеченные фоновой проверкой:
+// @Description similar_answer - тексты похожи после нормализации кода, rapid_submission - похожий
+// @Description верный ответ сдан вскоре после ответа другого студента. Сначала самые похожие пары.
+// @Tags Similarity
+// @Produce json
+// @Param course_id path int true "Course ID"
+// @Param assignment_id query int false "Only tasks of the assignment"
+// @Param status query string false "pending, confirmed or dismissed"
+// @Param kind query string false "similar_answer or rapid_submission"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Success 200 {array} models.SimilarityFlag
+// @Header 200 {integer} X-Total-Count "Total number of matching flags"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /teacher/courses/{course_id}/similarity-flags [get]
+func GetSimilarityFlags(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("course_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid course ID"})
+		return
+	}
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if params.IsCursor() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Cursor pagination is not supported for similarity flags"})
+		return
+	}
+
+	filter := models.SimilarityFlagFilter{Status: c.Query("status"), Kind: c.Query("kind")}
+	if value := c.Query("assignment_id"); value != "" {
+		if filter.AssignmentID, err = strconv.Atoi(value); err != nil || filter.AssignmentID < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid assignment ID"})
+			return
+		}
+	}
+	if filter.Status != "" && !models.IsValidSimilarityStatus(filter.Status) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid status"})
+		return
+	}
+	if filter.Kind != "" && filter.Kind != models.SimilarityKindAnswer && filter.Kind != models.SimilarityKindTiming {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid kind"})
+		return
+	}
+
+	flags, total, err := store(c).GetSimilarityFlags(courseID, filter, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve similarity flags"})
+		return
+	}
+
+	var lastID int
+	if len(flags) > 0 {
+		lastID = flags[len(flags)-1].ID
+	}
+	setListHeaders(c, params, total, lastID, len(flags))
+	c.JSON(http.StatusOK, flags)
+}
+
+// ReviewSimilarityFlag
+// @Summary Review a suspicious submission pair
+// @Description Преподаватель подтверждает списывание (confirmed), снимает отметку (dismissed)
+// @Description или возвращает ее на проверку (pending)
+// @Tags Similarity
+// @Accept json
+// @Produce json
+// @Param flag_id path int true "Flag ID"
+// @Param review body models.SimilarityReview true "Review decision"
+// @Success 200 {object} models.SimilarityFlag
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /teacher/similarity-flags/{flag_id} [put]
+func ReviewSimilarityFlag(c *gin.Context) {
+	flagID, err := strconv.Atoi(c.Param("flag_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid flag ID"})
+		return
+	}
+	var review models.SimilarityReview
+	if err := c.ShouldBindJSON(&review); err != nil || !models.IsValidSimilarityStatus(review.Status) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Status must be pending, confirmed or dismissed"})
+		return
+	}
+	review.Note = strings.TrimSpace(review.Note)
+
+	flag, err := store(c).ReviewSimilarityFlag(flagID, c.GetInt("userID"), review, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, storage.ErrSimilarityFlagNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Similarity flag not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to review similarity flag"})
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
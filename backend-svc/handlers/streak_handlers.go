@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"lmsmodule/backend-svc/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxHeatmapDays ограничивает период тепловой карты активности
+const MaxHeatmapDays = 731
+
+// GetUserActivityHeatmap
+// @Summary Get daily activity heatmap and streak
+// @Description Число выполненных задач и отправленных ответов по дням периода [from, to] в часовом поясе
+// @Description пользователя, а также текущая и самая длинная серия дней подряд с активностью.
+// @Description По умолчанию - последние 365 дней. Чужая активность доступна администратору.
+// @Tags Progress
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD"
+// @Success 200 {object} models.ActivityHeatmap
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /progress/{user_id}/activity [get]
+func GetUserActivityHeatmap(c *gin.Context) {
+	userID, ok := progressUser(c, true)
+	if !ok {
+		return
+	}
+
+	prefs, err := store(c).GetUserPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve preferences"})
+		return
+	}
+	location := prefs.Location()
+	now := time.Now()
+
+	to := models.LocalDate(now, location)
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation(models.DateLayout, value, location); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -364)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation(models.DateLayout, value, location); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+	if from.After(to) || from.AddDate(0, 0, MaxHeatmapDays).Before(to) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid period, from must not be after to and the period is limited to 731 days"})
+		return
+	}
+
+	// Для серии нужна вся история, тепловая карта строится по ее части
+	times, err := store(c).GetActivityTimes(userID, time.Time{}, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve activity: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ActivityHeatmap{
+		UserID:   userID,
+		Timezone: location.String(),
+		From:     from.Format(models.DateLayout),
+		To:       to.Format(models.DateLayout),
+		Days:     models.BuildActivityDays(times, from, to, location),
+		Streak:   models.ComputeStreak(times, now, location),
+	})
+}
+
+// GetUserPreferences
+// @Summary Get user preferences
+// @Tags Account
+// @Produce json
+// @Success 200 {object} models.UserPreferences
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /account/preferences [get]
+func GetUserPreferences(c *gin.Context) {
+	prefs, err := store(c).GetUserPreferences(c.GetInt("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateUserPreferences
+// @Summary Update user preferences
+// @Description Часовой пояс задается именем IANA, например Europe/Moscow; пустой - UTC.
+// @Description По нему считаются дни тепловой карты и серии, а также время напоминаний о серии.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param preferences body models.UserPreferences true "Preferences"
+// @Success 200 {object} models.UserPreferences
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /account/preferences [put]
+func UpdateUserPreferences(c *gin.Context) {
+	var prefs models.UserPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	prefs.Timezone = strings.TrimSpace(prefs.Timezone)
+	if prefs.Timezone == "" {
+		prefs.Timezone = models.DefaultTimezone
+	}
+	if _, err := time.LoadLocation(prefs.Timezone); err != nil || strings.EqualFold(prefs.Timezone, "Local") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown timezone " + prefs.Timezone})
+		return
+	}
+
+	if err := store(c).UpdateUserPreferences(c.GetInt("userID"), prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// userStreak считает серию дней пользователя в его часовом поясе
+func userStreak(userID int) (models.StreakStats, error) {
+	prefs, err := Store.GetUserPreferences(userID)
+	if err != nil {
+		return models.StreakStats{}, err
+	}
+	now := time.Now()
+	times, err := Store.GetActivityTimes(userID, time.Time{}, now)
+	if err != nil {
+		return models.StreakStats{}, err
+	}
+	return models.ComputeStreak(times, now, prefs.Location()), nil
+}
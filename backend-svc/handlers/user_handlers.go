@@ -33,7 +33,7 @@ func GetUserProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := Store.GetUserByID(userID.(int))
+	user, err := store(c).GetUserByID(userID.(int))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user profile"})
 		return
@@ -42,6 +42,18 @@ func GetUserProfile(c *gin.Context) {
 	user.PasswordHash = ""
 	user.TOTPSecret = ""
 
+	user.Certificates, err = store(c).GetUserCertificates(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve certificates"})
+		return
+	}
+
+	user.Badges, err = store(c).GetUserBadges(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve badges"})
+		return
+	}
+
 	c.JSON(http.StatusOK, user)
 }
 
@@ -73,7 +85,7 @@ func UpdateUserProfile(c *gin.Context) {
 
 	req.Password = ""
 
-	err := Store.UpdateUserProfile(userID.(int), req)
+	err := store(c).UpdateUserProfile(userID.(int), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update profile"})
 		return
@@ -108,7 +120,7 @@ func InitDeleteAccount(c *gin.Context) {
 		return
 	}
 
-	user, err := Store.GetUserByID(userID.(int))
+	user, err := store(c).GetUserByID(userID.(int))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get user data"})
 		return
@@ -124,13 +136,13 @@ func InitDeleteAccount(c *gin.Context) {
 		code += strconv.Itoa(rand.Intn(10))
 	}
 
-	if err := Store.SaveOTPCode(user.ID, code); err != nil {
+	if err := store(c).SaveOTPCode(user.ID, code); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save verification code"})
 		return
 	}
 
-	if err := mail.SendDeleteAccountEmail(user.Email, code); err != nil {
-		fmt.Printf("Error sending delete account email: %v\n", err)
+	if err := mail.SendDeleteAccountEmail(c.Request.Context(), user.Email, code); err != nil {
+		requestLog(c).Error("Error sending delete account email: %v", err)
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
@@ -164,7 +176,7 @@ func ConfirmDeleteAccount(c *gin.Context) {
 		return
 	}
 
-	valid, err := Store.VerifyOTPCode(userID.(int), req.Code)
+	valid, err := store(c).VerifyOTPCode(userID.(int), req.Code)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to verify code"})
 		return
@@ -175,11 +187,11 @@ func ConfirmDeleteAccount(c *gin.Context) {
 		return
 	}
 
-	if err := Store.ClearOTPCode(userID.(int)); err != nil {
-		fmt.Printf("Error clearing OTP code: %v\n", err)
+	if err := store(c).ClearOTPCode(userID.(int)); err != nil {
+		requestLog(c).Error("Error clearing OTP code: %v", err)
 	}
 
-	if err := Store.DeleteUser(userID.(int)); err != nil {
+	if err := store(c).DeleteUser(userID.(int)); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete account"})
 		return
 	}
@@ -252,7 +264,7 @@ func UpdateProfileImageHandler(c *gin.Context) {
 
 	imageURL := fmt.Sprintf("/uploads/profiles/%s", fileName)
 
-	err = Store.UpdateUserProfileImage(userID.(int), imageURL)
+	err = store(c).UpdateUserProfileImage(userID.(int), imageURL)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update profile image"})
 		return
@@ -290,7 +302,7 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	user, err := Store.GetUserByID(userID.(int))
+	user, err := store(c).GetUserByID(userID.(int))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve user data"})
 		return
@@ -311,7 +323,7 @@ func ChangePassword(c *gin.Context) {
 		Password: string(hashedPassword),
 	}
 
-	err = Store.UpdatePassword(userID.(int), updateReq)
+	err = store(c).UpdatePassword(userID.(int), updateReq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update password"})
 		return
@@ -343,7 +355,7 @@ func ForgotPassword(c *gin.Context) {
 	var err error
 
 	if req.Email != "" {
-		users, err := Store.SearchUsers(req.Email)
+		users, _, err := store(c).SearchUsers(req.Email, models.ListParams{})
 		if err != nil || len(users) == 0 {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found"})
 			return
@@ -363,7 +375,7 @@ func ForgotPassword(c *gin.Context) {
 			return
 		}
 	} else if req.Username != "" {
-		user, err = Store.GetUserByUsername(req.Username)
+		user, err = store(c).GetUserByUsername(req.Username)
 		if err != nil {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found"})
 			return
@@ -375,15 +387,15 @@ func ForgotPassword(c *gin.Context) {
 
 	code := generateResetCode(6)
 
-	err = Store.SaveOTPCode(user.ID, code)
+	err = store(c).SaveOTPCode(user.ID, code)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save reset code"})
 		return
 	}
 
-	err = mail.SendOTPEmail(user.Email, code)
+	err = mail.SendOTPEmail(c.Request.Context(), user.Email, code)
 	if err != nil {
-		fmt.Printf("Error sending reset code email: %v\n", err)
+		requestLog(c).Error("Error sending reset code email: %v", err)
 	}
 
 	tempToken, err := CreateTempToken(user.ID)
@@ -423,7 +435,7 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	valid, err := Store.VerifyOTPCode(userID, req.Code)
+	valid, err := store(c).VerifyOTPCode(userID, req.Code)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to verify code"})
 		return
@@ -434,9 +446,9 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	err = Store.ClearOTPCode(userID)
+	err = store(c).ClearOTPCode(userID)
 	if err != nil {
-		fmt.Printf("Error clearing OTP code: %v\n", err)
+		requestLog(c).Error("Error clearing OTP code: %v", err)
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
@@ -449,7 +461,7 @@ func ResetPassword(c *gin.Context) {
 		Password: string(hashedPassword),
 	}
 
-	err = Store.UpdateUserProfile(userID, updateReq)
+	err = store(c).UpdateUserProfile(userID, updateReq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update password"})
 		return
@@ -489,7 +501,7 @@ func GetUserByID(c *gin.Context) {
 		return
 	}
 
-	user, err := Store.GetUserByID(userID)
+	user, err := store(c).GetUserByID(userID)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found"})
@@ -505,20 +517,57 @@ func GetUserByID(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// CheckAdminAccess подтверждает права администратора текущего пользователя. Роли в токене
+// могут устареть, поэтому шлюз проверяет права здесь: до обработчика запрос доходит, только
+// если AdminAuthMiddleware нашел права в базе.
+// @Summary Check admin access
+// @Description Confirm that the current user is an administrator according to the database (admin only)
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/access [get]
+func CheckAdminAccess(c *gin.Context) {
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Admin access granted"})
+}
+
+// userSortFields - поля, по которым можно сортировать списки пользователей
+var userSortFields = []string{"id", "username", "email", "full_name", "created_at", "last_login"}
+
 // GetAllUsers возвращает список всех пользователей системы
 // @Summary Get all users
 // @Description Get a list of all users (admin only)
 // @Tags Admin
 // @Accept json
 // @Produce json
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return users with ID greater than cursor"
+// @Param sort query string false "Sort field: id, username, email, full_name, created_at, last_login (prefix - for descending)"
+// @Param is_active query bool false "Filter by active status"
+// @Param is_teacher query bool false "Filter by teacher role"
+// @Param is_admin query bool false "Filter by admin role"
+// @Param from query string false "Registered at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Registered before (RFC3339 or YYYY-MM-DD, date is inclusive)"
 // @Success 200 {array} models.User
+// @Header 200 {integer} X-Total-Count "Total number of matching users"
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Security BearerAuth
 // @Router /admin/users [get]
 func GetAllUsers(c *gin.Context) {
-	users, err := Store.GetAllUsers()
+	params, err := parseListParams(c, userSortFields...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	users, total, err := store(c).GetAllUsers(params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get users: " + err.Error()})
 		return
@@ -530,6 +579,12 @@ func GetAllUsers(c *gin.Context) {
 		users[i].TOTPSecret = ""
 	}
 
+	var lastID int
+	if len(users) > 0 {
+		lastID = users[len(users)-1].ID
+	}
+	setListHeaders(c, params, total, lastID, len(users))
+
 	c.JSON(http.StatusOK, users)
 }
 
@@ -540,7 +595,17 @@ func GetAllUsers(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param is_admin query bool true "Admin role flag"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return users with ID greater than cursor"
+// @Param sort query string false "Sort field: id, username, email, full_name, created_at, last_login (prefix - for descending)"
+// @Param is_active query bool false "Filter by active status"
+// @Param is_teacher query bool false "Filter by teacher role"
+// @Param from query string false "Registered at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Registered before (RFC3339 or YYYY-MM-DD, date is inclusive)"
 // @Success 200 {array} models.User
+// @Header 200 {integer} X-Total-Count "Total number of matching users"
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
@@ -550,7 +615,15 @@ func GetUsersByRole(c *gin.Context) {
 	isAdminStr := c.Query("is_admin")
 	isAdmin := isAdminStr == "true"
 
-	users, err := Store.GetUsersByRole(isAdmin)
+	params, err := parseListParams(c, userSortFields...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	// is_admin здесь задает саму роль, а не дополнительный фильтр
+	params.IsAdmin = nil
+
+	users, total, err := store(c).GetUsersByRole(isAdmin, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get users: " + err.Error()})
 		return
@@ -562,6 +635,12 @@ func GetUsersByRole(c *gin.Context) {
 		users[i].TOTPSecret = ""
 	}
 
+	var lastID int
+	if len(users) > 0 {
+		lastID = users[len(users)-1].ID
+	}
+	setListHeaders(c, params, total, lastID, len(users))
+
 	c.JSON(http.StatusOK, users)
 }
 
@@ -572,7 +651,17 @@ func GetUsersByRole(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param query query string true "Search query"
+// @Param page query int false "Page number (starting from 1)"
+// @Param limit query int false "Page size (max 100, default 20 with page or cursor; all rows without paging)"
+// @Param cursor query int false "Return users with ID greater than cursor"
+// @Param sort query string false "Sort field: id, username, email, full_name, created_at, last_login (prefix - for descending)"
+// @Param is_active query bool false "Filter by active status"
+// @Param is_teacher query bool false "Filter by teacher role"
+// @Param is_admin query bool false "Filter by admin role"
+// @Param from query string false "Registered at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Registered before (RFC3339 or YYYY-MM-DD, date is inclusive)"
 // @Success 200 {array} models.User
+// @Header 200 {integer} X-Total-Count "Total number of matching users"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
@@ -586,7 +675,13 @@ func SearchUsers(c *gin.Context) {
 		return
 	}
 
-	users, err := Store.SearchUsers(query)
+	params, err := parseListParams(c, userSortFields...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	users, total, err := store(c).SearchUsers(query, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to search users: " + err.Error()})
 		return
@@ -598,6 +693,12 @@ func SearchUsers(c *gin.Context) {
 		users[i].TOTPSecret = ""
 	}
 
+	var lastID int
+	if len(users) > 0 {
+		lastID = users[len(users)-1].ID
+	}
+	setListHeaders(c, params, total, lastID, len(users))
+
 	c.JSON(http.StatusOK, users)
 }
 
@@ -630,12 +731,14 @@ func UpdateUserStatus(c *gin.Context) {
 		return
 	}
 
-	err = Store.UpdateUserStatus(targetUserID, req.IsActive)
+	err = store(c).UpdateUserStatus(targetUserID, req.IsActive)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update user status: " + err.Error()})
 		return
 	}
 
+	notifyAccountStatus(targetUserID, req.IsActive)
+
 	c.JSON(http.StatusOK, models.SuccessResponse{Message: "User status updated successfully"})
 }
 
@@ -661,12 +764,14 @@ func PromoteToAdmin(c *gin.Context) {
 		return
 	}
 
-	err = Store.PromoteToAdmin(targetUserID)
+	err = store(c).PromoteToAdmin(targetUserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to promote user: " + err.Error()})
 		return
 	}
 
+	notifyRoleChanged(targetUserID, "admin", true)
+
 	c.JSON(http.StatusOK, models.SuccessResponse{Message: "User promoted to admin successfully"})
 }
 
@@ -692,11 +797,13 @@ func DemoteFromAdmin(c *gin.Context) {
 		return
 	}
 
-	err = Store.DemoteFromAdmin(targetUserID)
+	err = store(c).DemoteFromAdmin(targetUserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to demote user: " + err.Error()})
 		return
 	}
 
+	notifyRoleChanged(targetUserID, "admin", false)
+
 	c.JSON(http.StatusOK, models.SuccessResponse{Message: "User demoted from admin successfully"})
 }
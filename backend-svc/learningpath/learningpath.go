@@ -0,0 +1,61 @@
+// Package learningpath строит персональную траекторию обучения по снимку
+// models.LearningSnapshot. Стратегии подключаются через Register и не зависят от хранилища,
+// поэтому их можно сравнивать на данных MockStorage.
+package learningpath
+
+import (
+	"lmsmodule/backend-svc/models"
+	"sort"
+	"sync"
+)
+
+const (
+	// DefaultStrategy - стратегия, которая используется, если клиент не указал другую
+	DefaultStrategy = "weakness"
+
+	maxNextTasks       = 5
+	maxRecommendations = 3
+)
+
+// Strategy строит траекторию обучения по снимку данных пользователя
+type Strategy interface {
+	Name() string
+	Build(snapshot models.LearningSnapshot) models.LearningPath
+}
+
+var (
+	mu         sync.RWMutex
+	strategies = map[string]Strategy{}
+)
+
+func init() {
+	Register(WeaknessStrategy{UseDueDates: true})
+	Register(SequentialStrategy{})
+}
+
+// Register добавляет стратегию или заменяет стратегию с тем же именем
+func Register(strategy Strategy) {
+	mu.Lock()
+	defer mu.Unlock()
+	strategies[strategy.Name()] = strategy
+}
+
+// Lookup возвращает стратегию по имени
+func Lookup(name string) (Strategy, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	strategy, ok := strategies[name]
+	return strategy, ok
+}
+
+// Names возвращает имена зарегистрированных стратегий в алфавитном порядке
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,303 @@
+package learningpath
+
+import (
+	"lmsmodule/backend-svc/models"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// MaxSkillLevel - верхний уровень навыка; уровень равен доле освоения, умноженной на MaxSkillLevel
+	MaxSkillLevel = 5
+
+	// failedAttemptPenalty снижает освоение решенной задачи за каждую неверную попытку до решения
+	failedAttemptPenalty = 0.25
+	minSolvedMastery     = 0.4
+
+	// Темп пользователя ограничен, чтобы одна аномальная задача не искажала все оценки
+	minPace = 0.5
+	maxPace = 2.0
+	// minRemainingShare - оценка не опускается ниже этой доли полной оценки, пока задача не решена
+	minRemainingShare = 0.25
+)
+
+// defaultTaskTime - оценка времени задачи в секундах, когда по ней еще нет данных активности
+var defaultTaskTime = map[string]int{
+	"easy":   15 * 60,
+	"medium": 30 * 60,
+	"hard":   45 * 60,
+}
+
+const fallbackTaskTime = 30 * 60
+
+// Типы элементов models.LearningPath, чтобы заполнять анонимные структуры модели
+type (
+	courseRecommendation = struct {
+		CourseID      int    `json:"course_id"`
+		CourseName    string `json:"course_name"`
+		Priority      int    `json:"priority"`
+		Reason        string `json:"reason"`
+		EstimatedTime string `json:"estimated_time"`
+	}
+	nextTask = struct {
+		TaskID     int    `json:"task_id"`
+		TaskTitle  string `json:"task_title"`
+		CourseID   int    `json:"course_id"`
+		CourseName string `json:"course_name"`
+		Priority   int    `json:"priority"`
+		DueDate    string `json:"due_date,omitempty"`
+	}
+	skillLevel = struct {
+		SkillName       string  `json:"skill_name"`
+		CurrentLevel    int     `json:"current_level"`
+		Progress        float64 `json:"progress_to_next_level"`
+		RecommendedTask int     `json:"recommended_task_id,omitempty"`
+	}
+)
+
+// SkillState - освоение навыка пользователем от 0 до 1
+type SkillState struct {
+	Name    string
+	CWE     string
+	Mastery float64
+}
+
+// Level возвращает текущий уровень навыка и прогресс до следующего в процентах
+func (s SkillState) Level() (int, float64) {
+	scaled := s.Mastery * MaxSkillLevel
+	level := int(math.Floor(scaled + 1e-9))
+	if level >= MaxSkillLevel {
+		return MaxSkillLevel, 100
+	}
+	return level, math.Round((scaled-float64(level))*1000) / 10
+}
+
+// Model - общие для стратегий вычисления по снимку: освоение задач и навыков,
+// готовность задач и оценки времени
+type Model struct {
+	Snapshot models.LearningSnapshot
+	Tasks    map[int]models.LearningTask
+	// TaskMastery - освоение решенных задач с учетом неверных попыток
+	TaskMastery map[int]float64
+	Skills      map[string]*SkillState
+	// Started - начатые, но не решенные задачи: есть активность или попытки сдачи
+	Started map[int]bool
+	// FailedAttempts - неверные попытки до решения задачи (или все, если задача не решена)
+	FailedAttempts map[int]int
+	spent          map[int]int
+	pace           float64
+}
+
+// NewModel рассчитывает освоение навыков и темп пользователя.
+// Задачи без явной привязки относятся к навыку по типу уязвимости курса.
+func NewModel(snapshot models.LearningSnapshot) *Model {
+	m := &Model{
+		Snapshot:       snapshot,
+		Tasks:          make(map[int]models.LearningTask, len(snapshot.Tasks)),
+		TaskMastery:    make(map[int]float64),
+		Skills:         make(map[string]*SkillState),
+		Started:        make(map[int]bool),
+		FailedAttempts: make(map[int]int),
+		spent:          make(map[int]int),
+		pace:           1,
+	}
+
+	for _, task := range snapshot.Tasks {
+		if len(task.Skills) == 0 {
+			task.Skills = []models.TaskSkill{models.DefaultTaskSkill(task.CourseName)}
+		}
+		m.Tasks[task.ID] = task
+	}
+
+	for _, attempt := range snapshot.Attempts {
+		completedAt, completed := snapshot.Completed[attempt.TaskID]
+		if !attempt.IsCorrect && (!completed || attempt.SubmittedAt.Before(completedAt)) {
+			m.FailedAttempts[attempt.TaskID]++
+		}
+		if !completed {
+			m.Started[attempt.TaskID] = true
+		}
+	}
+	for _, activity := range snapshot.Activity {
+		m.spent[activity.TaskID] += activity.TimeSpentSeconds
+		if _, completed := snapshot.Completed[activity.TaskID]; !completed {
+			m.Started[activity.TaskID] = true
+		}
+	}
+
+	for taskID := range snapshot.Completed {
+		mastery := 1 / (1 + failedAttemptPenalty*float64(m.FailedAttempts[taskID]))
+		m.TaskMastery[taskID] = math.Max(mastery, minSolvedMastery)
+	}
+
+	m.computeSkills()
+	m.computePace()
+	return m
+}
+
+func (m *Model) computeSkills() {
+	weights := make(map[string]float64)
+	for _, task := range m.Snapshot.Tasks {
+		for _, skill := range m.Tasks[task.ID].Skills {
+			weight := skill.Weight
+			if weight <= 0 {
+				weight = 1
+			}
+			state, ok := m.Skills[skill.Skill]
+			if !ok {
+				state = &SkillState{Name: skill.Skill, CWE: skill.CWE}
+				m.Skills[skill.Skill] = state
+			}
+			if state.CWE == "" {
+				state.CWE = skill.CWE
+			}
+			state.Mastery += weight * m.TaskMastery[task.ID]
+			weights[skill.Skill] += weight
+		}
+	}
+	for name, state := range m.Skills {
+		state.Mastery /= weights[name]
+	}
+}
+
+// computePace сравнивает время пользователя на решенных задачах со средним по всем пользователям
+func (m *Model) computePace() {
+	var own, average int
+	for taskID := range m.Snapshot.Completed {
+		task, ok := m.Tasks[taskID]
+		if !ok || task.AverageTimeSeconds == 0 || m.spent[taskID] == 0 {
+			continue
+		}
+		own += m.spent[taskID]
+		average += task.AverageTimeSeconds
+	}
+	if average > 0 {
+		m.pace = math.Min(math.Max(float64(own)/float64(average), minPace), maxPace)
+	}
+}
+
+// Pace возвращает отношение времени пользователя к среднему времени других пользователей
+func (m *Model) Pace() float64 {
+	return m.pace
+}
+
+// Completed сообщает, решена ли задача
+func (m *Model) Completed(taskID int) bool {
+	_, ok := m.Snapshot.Completed[taskID]
+	return ok
+}
+
+// Ready сообщает, что задача не решена и все ее предварительные задачи решены
+func (m *Model) Ready(taskID int) bool {
+	if m.Completed(taskID) {
+		return false
+	}
+	for _, prerequisite := range m.Tasks[taskID].Prerequisites {
+		if !m.Completed(prerequisite) {
+			return false
+		}
+	}
+	return true
+}
+
+// Weakness - средняя доля неосвоенности навыков задачи с учетом весов
+func (m *Model) Weakness(taskID int) float64 {
+	var sum, weights float64
+	for _, skill := range m.Tasks[taskID].Skills {
+		weight := skill.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		sum += weight * (1 - m.Skills[skill.Skill].Mastery)
+		weights += weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sum / weights
+}
+
+// EstimateSeconds оценивает оставшееся время на задачу: среднее время решивших ее
+// пользователей (или оценка по сложности), умноженное на темп пользователя,
+// за вычетом уже потраченного времени
+func (m *Model) EstimateSeconds(taskID int) int {
+	if m.Completed(taskID) {
+		return 0
+	}
+
+	task := m.Tasks[taskID]
+	base := task.AverageTimeSeconds
+	if base == 0 {
+		base = defaultTaskTime[task.Difficulty]
+	}
+	if base == 0 {
+		base = fallbackTaskTime
+	}
+
+	full := float64(base) * m.pace
+	remaining := math.Max(full-float64(m.spent[taskID]), full*minRemainingShare)
+	return int(math.Round(remaining))
+}
+
+// SkillLevels возвращает навыки от самых слабых к самым сильным.
+// recommended задает рекомендуемую задачу для навыка, если она есть.
+func (m *Model) SkillLevels(recommended map[string]int) []skillLevel {
+	states := make([]*SkillState, 0, len(m.Skills))
+	for _, state := range m.Skills {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Mastery != states[j].Mastery {
+			return states[i].Mastery < states[j].Mastery
+		}
+		return states[i].Name < states[j].Name
+	})
+
+	levels := make([]skillLevel, 0, len(states))
+	for _, state := range states {
+		level, progress := state.Level()
+		name := state.Name
+		if state.CWE != "" {
+			name += " (" + state.CWE + ")"
+		}
+		levels = append(levels, skillLevel{
+			SkillName:       name,
+			CurrentLevel:    level,
+			Progress:        progress,
+			RecommendedTask: recommended[state.Name],
+		})
+	}
+	return levels
+}
+
+// WeakestSkill возвращает самый слабый навык задачи
+func (m *Model) WeakestSkill(taskID int) *SkillState {
+	var weakest *SkillState
+	for _, skill := range m.Tasks[taskID].Skills {
+		state := m.Skills[skill.Skill]
+		if weakest == nil || state.Mastery < weakest.Mastery {
+			weakest = state
+		}
+	}
+	return weakest
+}
+
+// NewPath создает пустую траекторию с непустыми списками, как ожидают клиенты
+func NewPath(snapshot models.LearningSnapshot) models.LearningPath {
+	path := models.LearningPath{
+		UserID:      snapshot.UserID,
+		GeneratedAt: snapshot.GeneratedAt,
+	}
+	path.Recommendations = []courseRecommendation{}
+	path.NextTasks = []nextTask{}
+	path.Skills = []skillLevel{}
+	return path
+}
+
+func formatDueDate(dueAt *time.Time) string {
+	if dueAt == nil {
+		return ""
+	}
+	return dueAt.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,196 @@
+package learningpath
+
+import (
+	"lmsmodule/backend-svc/models"
+	"sort"
+	"time"
+)
+
+// difficultyRank переводит сложность задачи в шкалу 0..2 для сравнения с освоением навыка
+var difficultyRank = map[string]float64{
+	"easy":   0,
+	"medium": 1,
+	"hard":   2,
+}
+
+// candidate - готовая к решению задача с оценкой стратегии
+type candidate struct {
+	taskID int
+	score  float64
+	reason string
+}
+
+// WeaknessStrategy рекомендует готовые к решению задачи по слабости их навыков:
+//
+//	score = 3*weakness + fit + started + urgency
+//
+// где weakness - доля неосвоенности навыков задачи, fit - соответствие сложности задачи
+// уровню навыка (0..1), started - 1 для начатых задач, urgency - близость срока сдачи
+// (учитывается при UseDueDates).
+type WeaknessStrategy struct {
+	UseDueDates bool
+}
+
+func (WeaknessStrategy) Name() string {
+	return "weakness"
+}
+
+func (s WeaknessStrategy) Build(snapshot models.LearningSnapshot) models.LearningPath {
+	m := NewModel(snapshot)
+
+	var candidates []candidate
+	for _, task := range snapshot.Tasks {
+		if !m.Ready(task.ID) {
+			continue
+		}
+
+		weakest := m.WeakestSkill(task.ID)
+		rank, ok := difficultyRank[task.Difficulty]
+		if !ok {
+			rank = 1
+		}
+		fit := 1 - abs(rank-weakest.Mastery*2)/2
+
+		c := candidate{
+			taskID: task.ID,
+			score:  3*m.Weakness(task.ID) + fit,
+			reason: "Strengthen weak skill: " + weakest.Name,
+		}
+		if m.Started[task.ID] {
+			c.score++
+			c.reason = "Continue where you left off"
+		}
+		if s.UseDueDates {
+			if urgency, reason := dueUrgency(task.DueAt, snapshot.GeneratedAt); urgency > 0 {
+				c.score += urgency
+				c.reason = reason
+			}
+		}
+		candidates = append(candidates, c)
+	}
+
+	return buildPath(m, candidates)
+}
+
+// SequentialStrategy проходит курсы по порядку: сначала начатые курсы, затем новые,
+// внутри курса - по порядку задач. Используется как базовая стратегия для сравнения.
+type SequentialStrategy struct{}
+
+func (SequentialStrategy) Name() string {
+	return "sequential"
+}
+
+func (SequentialStrategy) Build(snapshot models.LearningSnapshot) models.LearningPath {
+	m := NewModel(snapshot)
+
+	startedCourses := make(map[int]bool)
+	for taskID := range snapshot.Completed {
+		startedCourses[m.Tasks[taskID].CourseID] = true
+	}
+
+	var candidates []candidate
+	for i, task := range snapshot.Tasks {
+		if !m.Ready(task.ID) {
+			continue
+		}
+		// Задачи в снимке упорядочены по курсу и порядку задачи
+		score := -float64(i)
+		reason := "Next task in a new course"
+		if startedCourses[task.CourseID] {
+			score += float64(len(snapshot.Tasks))
+			reason = "Next task in your current course"
+		}
+		candidates = append(candidates, candidate{taskID: task.ID, score: score, reason: reason})
+	}
+
+	return buildPath(m, candidates)
+}
+
+// buildPath упорядочивает кандидатов и собирает следующие задачи, рекомендации курсов
+// и уровни навыков. Приоритет 1 - самый высокий.
+func buildPath(m *Model, candidates []candidate) models.LearningPath {
+	path := NewPath(m.Snapshot)
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	recommendedForSkill := make(map[string]int)
+	type courseCandidate struct {
+		courseID int
+		score    float64
+		reason   string
+	}
+	var courses []courseCandidate
+	seenCourses := make(map[int]bool)
+
+	for _, c := range candidates {
+		task := m.Tasks[c.taskID]
+		if len(path.NextTasks) < maxNextTasks {
+			path.NextTasks = append(path.NextTasks, nextTask{
+				TaskID:     task.ID,
+				TaskTitle:  task.Title,
+				CourseID:   task.CourseID,
+				CourseName: task.CourseName,
+				Priority:   len(path.NextTasks) + 1,
+				DueDate:    formatDueDate(task.DueAt),
+			})
+		}
+		for _, skill := range task.Skills {
+			if _, ok := recommendedForSkill[skill.Skill]; !ok {
+				recommendedForSkill[skill.Skill] = task.ID
+			}
+		}
+		if !seenCourses[task.CourseID] {
+			seenCourses[task.CourseID] = true
+			courses = append(courses, courseCandidate{courseID: task.CourseID, score: c.score, reason: c.reason})
+		}
+	}
+
+	remaining := make(map[int]int)
+	courseNames := make(map[int]string)
+	for _, task := range m.Snapshot.Tasks {
+		remaining[task.CourseID] += m.EstimateSeconds(task.ID)
+		courseNames[task.CourseID] = task.CourseName
+	}
+
+	for _, course := range courses {
+		if len(path.Recommendations) == maxRecommendations {
+			break
+		}
+		path.Recommendations = append(path.Recommendations, courseRecommendation{
+			CourseID:      course.courseID,
+			CourseName:    courseNames[course.courseID],
+			Priority:      len(path.Recommendations) + 1,
+			Reason:        course.reason,
+			EstimatedTime: models.FormatDuration(remaining[course.courseID]),
+		})
+	}
+
+	path.Skills = m.SkillLevels(recommendedForSkill)
+	return path
+}
+
+// dueUrgency повышает приоритет задач с близким или прошедшим сроком сдачи
+func dueUrgency(dueAt *time.Time, now time.Time) (float64, string) {
+	if dueAt == nil {
+		return 0, ""
+	}
+	left := dueAt.Sub(now)
+	switch {
+	case left < 0:
+		return 2.5, "Overdue"
+	case left <= 3*24*time.Hour:
+		return 2, "Due in less than 3 days"
+	case left <= 7*24*time.Hour:
+		return 1, "Due this week"
+	}
+	return 0, ""
+}
+
+func abs(value float64) float64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -3,6 +3,7 @@ package mail
 import (
 	"fmt"
 	"html/template"
+	"lmsmodule/api-gateway/pkg/logger"
 	"os"
 	"path/filepath"
 )
@@ -45,14 +46,14 @@ func loadEmailTemplates() {
 
 	rootDir, err := os.Getwd()
 	if err != nil {
-		fmt.Printf("Error getting working directory: %v\n", err)
+		logger.Default().Error("Error getting working directory: %v", err)
 		return
 	}
 
 	templatePath := filepath.Join(rootDir, "backend", "templates", "emails", "otp_email.html")
 	tmpl, err := template.ParseFiles(templatePath)
 	if err != nil {
-		fmt.Printf("Error loading email template: %v\n", err)
+		logger.Default().Error("Error loading email template: %v", err)
 		return
 	}
 
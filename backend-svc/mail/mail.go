@@ -2,8 +2,11 @@ package mail
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/api-gateway/pkg/tracing"
 	"net/smtp"
 	"strings"
 	"time"
@@ -13,10 +16,26 @@ type OTPEmailData struct {
 	Code string
 }
 
-func SendOTPEmail(email, code string) error {
+// startSend начинает спан отправки письма вида kind; адрес получателя в спан не попадает
+func startSend(ctx context.Context, kind string) *tracing.Span {
+	_, span := tracing.StartSpan(ctx, "smtp.send", tracing.SpanKindClient)
+	span.SetAttribute("email.kind", kind)
+	span.SetAttribute("net.peer.name", smtpHost)
+	return span
+}
+
+func endSend(span *tracing.Span, err error) {
+	span.RecordError(err)
+	span.End()
+}
+
+func SendOTPEmail(ctx context.Context, email, code string) (err error) {
+	span := startSend(ctx, "otp")
+	defer func() { endSend(span, err) }()
+
 	template, ok := emailTemplates["otp_email"]
 	if !ok {
-		fmt.Printf("Email template not found, using fallback template\n")
+		logger.Default().Info("Email template not found, using fallback template")
 		return sendOTPEmailFallback(email, code)
 	}
 
@@ -26,7 +45,7 @@ func SendOTPEmail(email, code string) error {
 
 	var bodyBuffer bytes.Buffer
 	if err := template.Execute(&bodyBuffer, data); err != nil {
-		fmt.Printf("Error executing email template: %v\n", err)
+		logger.Default().Error("Error executing email template: %v", err)
 		return sendOTPEmailFallback(email, code)
 	}
 
@@ -54,14 +73,13 @@ func SendOTPEmail(email, code string) error {
 		"--%s--",
 		smtpFrom, email, subject, boundary, boundary, code, boundary, bodyBuffer.String(), boundary))
 
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, smtpUsername, []string{email}, message)
+	err = smtp.SendMail(smtpHost+":"+smtpPort, auth, smtpUsername, []string{email}, message)
 	if err != nil {
-		fmt.Printf("Error sending email: %v\n", err)
-		fmt.Printf("OTP for %s: %s\n", email, code)
+		logger.Default().Error("Error sending email: %v", err)
 		return err
 	}
 
-	fmt.Printf("Email sent successfully to %s\n", email)
+	logger.Default().Info("Email sent successfully to %s", email)
 	return nil
 }
 
@@ -72,22 +90,20 @@ func sendOTPEmailFallback(email, code string) error {
 
 	conn, err := tls.Dial("tcp", smtpHost+":"+smtpPort, tlsConfig)
 	if err != nil {
-		fmt.Printf("SSL connection error: %v\n", err)
-		fmt.Printf("OTP for %s: %s\n", email, code)
+		logger.Default().Error("SSL connection error: %v", err)
 		return err
 	}
 
 	client, err := smtp.NewClient(conn, smtpHost)
 	if err != nil {
-		fmt.Printf("Error creating SMTP client: %v\n", err)
+		logger.Default().Error("Error creating SMTP client: %v", err)
 		return err
 	}
 	defer client.Close()
 
 	auth := smtp.PlainAuth("", smtpUsername, smtpPassword, smtpHost)
 	if err = client.Auth(auth); err != nil {
-		fmt.Printf("Authentication error: %v\n", err)
-		fmt.Printf("OTP for %s: %s\n", email, code)
+		logger.Default().Error("Authentication error: %v", err)
 		return err
 	}
 
@@ -124,11 +140,14 @@ func sendOTPEmailFallback(email, code string) error {
 		return err
 	}
 
-	fmt.Printf("Fallback email sent successfully to %s\n", email)
+	logger.Default().Info("Fallback email sent successfully to %s", email)
 	return nil
 }
 
-func SendDeleteAccountEmail(email, code string) error {
+func SendDeleteAccountEmail(ctx context.Context, email, code string) (err error) {
+	span := startSend(ctx, "delete_account")
+	defer func() { endSend(span, err) }()
+
 	subject := "Account Deletion Confirmation"
 	plainText := fmt.Sprintf("You have requested to delete your account. To confirm, please use this verification code: %s\n\n"+
 		"This code is valid for 5 minutes.\n\n"+
@@ -143,14 +162,14 @@ func SendDeleteAccountEmail(email, code string) error {
 		smtpFrom, email, subject, plainText))
 
 	auth := smtp.PlainAuth("", smtpUsername, smtpPassword, smtpHost)
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, smtpUsername, []string{email}, message)
+	err = smtp.SendMail(smtpHost+":"+smtpPort, auth, smtpUsername, []string{email}, message)
 	if err != nil {
-		fmt.Printf("Error sending delete account email: %v\n", err)
-		fmt.Printf("Trying fallback method for deletion code email...\n")
+		logger.Default().Error("Error sending delete account email: %v", err)
+		logger.Default().Info("Trying fallback method for deletion code email...")
 		return sendDeleteAccountEmailFallback(email, code)
 	}
 
-	fmt.Printf("Delete account email sent successfully to %s\n", email)
+	logger.Default().Info("Delete account email sent successfully to %s", email)
 	return nil
 }
 
@@ -161,22 +180,20 @@ func sendDeleteAccountEmailFallback(email, code string) error {
 
 	conn, err := tls.Dial("tcp", smtpHost+":"+smtpPort, tlsConfig)
 	if err != nil {
-		fmt.Printf("SSL connection error: %v\n", err)
-		fmt.Printf("Deletion code for %s: %s\n", email, code)
+		logger.Default().Error("SSL connection error: %v", err)
 		return err
 	}
 
 	client, err := smtp.NewClient(conn, smtpHost)
 	if err != nil {
-		fmt.Printf("Error creating SMTP client: %v\n", err)
+		logger.Default().Error("Error creating SMTP client: %v", err)
 		return err
 	}
 	defer client.Close()
 
 	auth := smtp.PlainAuth("", smtpUsername, smtpPassword, smtpHost)
 	if err = client.Auth(auth); err != nil {
-		fmt.Printf("Authentication error: %v\n", err)
-		fmt.Printf("Deletion code for %s: %s\n", email, code)
+		logger.Default().Error("Authentication error: %v", err)
 		return err
 	}
 
@@ -215,6 +232,119 @@ func sendDeleteAccountEmailFallback(email, code string) error {
 		return err
 	}
 
-	fmt.Printf("Fallback deletion email sent successfully to %s\n", email)
+	logger.Default().Info("Fallback deletion email sent successfully to %s", email)
+	return nil
+}
+
+type DeadlineReminderData struct {
+	Username        string
+	AssignmentTitle string
+	CourseName      string
+	DueAt           time.Time
+	RemainingTasks  int
+}
+
+func SendDeadlineReminderEmail(email string, data DeadlineReminderData) error {
+	subject := "Assignment deadline reminder: " + data.AssignmentTitle
+	plainText := fmt.Sprintf("Hello, %s!\n\n"+
+		"The assignment \"%s\" in the course \"%s\" is due on %s.\n"+
+		"You still have %d unfinished task(s).\n\n"+
+		"Submissions after the deadline may receive a late penalty.",
+		data.Username, data.AssignmentTitle, data.CourseName,
+		data.DueAt.UTC().Format("2006-01-02 15:04 MST"), data.RemainingTasks)
+
+	if err := sendPlainTextEmail(email, subject, plainText); err != nil {
+		logger.Default().Error("Error sending deadline reminder email: %v", err)
+		return err
+	}
+
+	logger.Default().Info("Deadline reminder email sent successfully to %s", email)
+	return nil
+}
+
+type StreakReminderData struct {
+	Username string
+	Streak   int
+}
+
+func SendStreakReminderEmail(email string, data StreakReminderData) error {
+	subject := fmt.Sprintf("Keep your %d-day learning streak", data.Streak)
+	plainText := fmt.Sprintf("Hello, %s!\n\n"+
+		"You have studied %d day(s) in a row, but there is no activity today yet.\n"+
+		"Complete a task or submit an answer before midnight to keep your streak going.\n\n"+
+		"You can turn these reminders off in your account preferences.",
+		data.Username, data.Streak)
+
+	if err := sendPlainTextEmail(email, subject, plainText); err != nil {
+		logger.Default().Error("Error sending streak reminder email: %v", err)
+		return err
+	}
+
+	logger.Default().Info("Streak reminder email sent successfully to %s", email)
 	return nil
 }
+
+type NotificationData struct {
+	Username string
+	Title    string
+	Message  string
+}
+
+// SendNotificationEmail дублирует письмом уведомление из приложения
+func SendNotificationEmail(email string, data NotificationData) error {
+	plainText := fmt.Sprintf("Hello, %s!\n\n%s\n\n"+
+		"You can choose which notifications are sent by email in your notification preferences.",
+		data.Username, data.Message)
+
+	if err := sendPlainTextEmail(email, data.Title, plainText); err != nil {
+		logger.Default().Error("Error sending notification email: %v", err)
+		return err
+	}
+	return nil
+}
+
+// sendPlainTextEmail отправляет текстовое письмо, при ошибке повторяя отправку через TLS-соединение
+func sendPlainTextEmail(email, subject, plainText string) error {
+	message := []byte(fmt.Sprintf("From: %s\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"%s",
+		smtpFrom, email, subject, plainText))
+
+	auth := smtp.PlainAuth("", smtpUsername, smtpPassword, smtpHost)
+	if err := smtp.SendMail(smtpHost+":"+smtpPort, auth, smtpUsername, []string{email}, message); err == nil {
+		return nil
+	}
+
+	conn, err := tls.Dial("tcp", smtpHost+":"+smtpPort, &tls.Config{ServerName: smtpHost})
+	if err != nil {
+		return fmt.Errorf("ssl connection: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, smtpHost)
+	if err != nil {
+		return fmt.Errorf("create smtp client: %w", err)
+	}
+	defer client.Close()
+
+	if err = client.Auth(auth); err != nil {
+		return fmt.Errorf("authenticate: %w", err)
+	}
+	if err = client.Mail(smtpUsername); err != nil {
+		return err
+	}
+	if err = client.Rcpt(email); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(message); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -1,17 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/hudl/fargo"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"lmsmodule/api-gateway/pkg/discovery"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/api-gateway/pkg/tracing"
+	"lmsmodule/backend-svc/certificate"
 	_ "lmsmodule/backend-svc/docs"
+	"lmsmodule/backend-svc/events"
 	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/metrics"
 	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/notifications"
+	"lmsmodule/backend-svc/reminders"
+	"lmsmodule/backend-svc/similarity"
 	"lmsmodule/backend-svc/storage"
 	"log"
 	"net/http"
@@ -21,6 +30,7 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 )
 
 // @title LMS API
@@ -33,8 +43,19 @@ import (
 // @in header
 // @name Authorization
 func main() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("Starting LMS API server...")
+	logLevel := os.Getenv("LOG_LEVEL")
+	if logLevel == "" {
+		logLevel = "INFO"
+	}
+	appLog := logger.New(logger.Options{Level: logLevel, Format: os.Getenv("LOG_FORMAT")})
+	logger.SetDefault(appLog)
+	// Записи стандартного log из библиотек выводятся в том же формате
+	log.SetFlags(0)
+	log.SetOutput(appLog.Writer(logger.LogLevelInfo))
+	appLog.Info("Starting LMS API server...")
+
+	tracer := setupTracing(appLog)
+	defer shutdownTracing(tracer)
 
 	dsn := os.Getenv("DATABASE_DSN")
 	if dsn == "" {
@@ -45,7 +66,7 @@ func main() {
 		port := "3306"
 
 		if user == "" || password == "" || database == "" {
-			log.Fatal("Database credentials not provided. Set MYSQL_USER, MYSQL_PASSWORD, MYSQL_DATABASE environment variables")
+			appLog.Fatal("Database credentials not provided. Set MYSQL_USER, MYSQL_PASSWORD, MYSQL_DATABASE environment variables")
 		}
 
 		dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, password, host, port, database)
@@ -54,44 +75,82 @@ func main() {
 	handlers.JWTSecret = os.Getenv("JWT_SECRET")
 	if handlers.JWTSecret == "" {
 		handlers.JWTSecret = "mock_JWT"
-		log.Fatal("JWT secret not provided")
+		appLog.Fatal("JWT secret not provided")
 	}
 
 	handlers.TempJWTSecret = os.Getenv("TEMP_JWT_SECRET")
 	if handlers.TempJWTSecret == "" {
 		handlers.TempJWTSecret = "mock_JWT"
-		log.Fatal("JWT secret not provided")
+		appLog.Fatal("JWT secret not provided")
 	}
 
 	var useMockData = false
 
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		log.Printf("Database connection error: %v. Using mock data instead.", err)
+		appLog.Error("Database connection error: %v. Using mock data instead.", err)
 		useMockData = true
 	} else {
 		err = db.Ping()
 		if err != nil {
-			log.Printf("Database ping failed: %v. Using mock data instead.", err)
+			appLog.Error("Database ping failed: %v. Using mock data instead.", err)
 			useMockData = true
 		} else {
 			db.SetMaxOpenConns(25)
 			db.SetMaxIdleConns(25)
 			db.SetConnMaxLifetime(5 * time.Minute)
 			handlers.Db = db
+			metrics.WatchDB(db.Stats)
 			defer db.Close()
-			log.Println("Successfully connected to database")
+			appLog.Info("Successfully connected to database")
 		}
 	}
 
 	if useMockData {
-		log.Println("Using mock data storage")
+		appLog.Info("Using mock data storage")
 		handlers.UseStorage(&storage.MockStorage{})
 	} else {
-		log.Println("Using database storage")
+		appLog.Info("Using database storage")
 		handlers.UseStorage(&storage.DBStorage{DB: db})
 	}
 
+	if key := os.Getenv("CERTIFICATE_SIGNING_KEY"); key != "" {
+		signer, err := certificate.ParseSigner(key)
+		if err != nil {
+			appLog.Fatal("Invalid CERTIFICATE_SIGNING_KEY: %v", err)
+		}
+		handlers.CertificateSigner = signer
+	} else if !useMockData {
+		// Сертификаты из БД должны проверяться и после перезапуска, а ключ, выведенный из
+		// другого секрета, позволил бы его владельцам подделывать их
+		appLog.Fatal("CERTIFICATE_SIGNING_KEY is required: a base64 32-byte ed25519 seed, e.g. openssl rand -base64 32")
+	} else {
+		appLog.Warn("CERTIFICATE_SIGNING_KEY not set, certificates are signed with a random key until restart")
+	}
+	if verifyURL := os.Getenv("CERTIFICATE_VERIFY_URL"); verifyURL != "" {
+		handlers.CertificateVerifyURL = verifyURL
+	}
+
+	reminderWindow := 24 * time.Hour
+	if hours, err := strconv.Atoi(os.Getenv("DEADLINE_REMINDER_HOURS")); err == nil && hours > 0 {
+		reminderWindow = time.Duration(hours) * time.Hour
+	}
+	handlers.NotificationMailer = notifications.EmailMailer
+	stopReminders := reminders.Start(handlers.Store, time.Hour, reminderWindow, reminders.NotificationSender(handlers.SendNotification))
+	defer stopReminders()
+	stopStreakReminders := reminders.StartStreakReminders(handlers.Store, time.Hour, reminders.StreakEmailSender)
+	defer stopStreakReminders()
+
+	similarityInterval := 10 * time.Minute
+	if minutes, err := strconv.Atoi(os.Getenv("SIMILARITY_CHECK_MINUTES")); err == nil && minutes > 0 {
+		similarityInterval = time.Duration(minutes) * time.Minute
+	}
+	stopSimilarity := similarity.Start(handlers.Store, similarityInterval, similarity.DefaultConfig)
+	defer stopSimilarity()
+
+	stopEvents := setupLiveEvents(useMockData)
+	defer stopEvents()
+
 	eurekaURL := os.Getenv("EUREKA_URL")
 	if eurekaURL == "" {
 		eurekaURL = "http://discovery-server:8761/eureka/v2"
@@ -107,7 +166,7 @@ func main() {
 		var err error
 		instanceHost, err = os.Hostname()
 		if err != nil {
-			log.Printf("Error getting hostname: %v", err)
+			appLog.Error("Error getting hostname: %v", err)
 			instanceHost = "localhost"
 		}
 	}
@@ -119,74 +178,49 @@ func main() {
 
 	instancePort, err := strconv.Atoi(port)
 	if err != nil {
-		log.Printf("Error converting PORT: %v, using port 8081", err)
+		appLog.Error("Error converting PORT: %v, using port 8081", err)
 		instancePort = 8081
 	}
 
-	conn := fargo.NewConn(eurekaURL)
-	conn.PollInterval = time.Second * 30
-
-	metadata := fargo.InstanceMetadata{}
-
-	instance := fargo.Instance{
-		HostName:         instanceHost,
-		Port:             instancePort,
-		App:              appName,
-		IPAddr:           instanceHost,
-		VipAddress:       appName,
-		SecureVipAddress: appName,
-		DataCenterInfo: fargo.DataCenterInfo{
-			Name:  "MyOwn",
-			Class: "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo",
-		},
-		Status:           fargo.UP,
-		Overriddenstatus: "UNKNOWN",
-		LeaseInfo: fargo.LeaseInfo{
-			RenewalIntervalInSecs: 30,
-			DurationInSecs:        90,
-		},
-		Metadata:       metadata,
-		HomePageUrl:    "http://" + instanceHost + ":" + port + "/",
-		StatusPageUrl:  "http://" + instanceHost + ":" + port + "/info",
-		HealthCheckUrl: "http://" + instanceHost + ":" + port + "/health",
-	}
-
-	err = conn.RegisterInstance(&instance)
+	// Регистрация в реестре экземпляров; с провайдерами static, file и dns шлюз узнает
+	// адрес сервиса из своей конфигурации, и сервис работает без сервера Eureka
+	deregister := func() {}
+	registry, err := discovery.New(discovery.Config{
+		Provider:  os.Getenv("DISCOVERY_PROVIDER"),
+		EurekaURL: eurekaURL,
+		File:      os.Getenv("DISCOVERY_FILE"),
+	})
 	if err != nil {
-		log.Printf("Error registering with Eureka: %v", err)
+		appLog.Error("Error initializing service discovery: %v", err)
 	} else {
-		log.Println("Successfully registered with Eureka")
-
-		ticker := time.NewTicker(time.Second * 30)
-		go func() {
-			for range ticker.C {
-				err := conn.HeartBeatInstance(&instance)
-				if err != nil {
-					log.Printf("Heartbeat error: %v", err)
-				}
-			}
-		}()
-
-		go func() {
-			sigChan := make(chan os.Signal, 1)
-			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-			<-sigChan
-
-			log.Println("Deregistering from Eureka...")
-			err = conn.DeregisterInstance(&instance)
-			if err != nil {
-				log.Printf("Error deregistering from Eureka: %v", err)
-			} else {
-				log.Println("Successfully deregistered from Eureka")
-			}
-			os.Exit(0)
-		}()
+		deregister = discovery.Keep(registry, discovery.Registration{
+			Service:         appName,
+			Host:            instanceHost,
+			Port:            instancePort,
+			HealthCheckPath: "/api/health",
+		}, 30*time.Second, appLog)
 	}
 
-	r := gin.Default()
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+
+		shutdownTracing(tracer)
+		deregister()
+		os.Exit(0)
+	}()
+
+	r := gin.New()
+	r.Use(logger.RequestIDMiddleware())
+	r.Use(tracing.Middleware(tracer))
+	r.Use(logger.AccessLogMiddleware(appLog))
+	r.Use(metrics.HTTP.Middleware())
+	r.Use(gin.Recovery())
 	r.Use(CORSMiddleware())
+	r.GET("/metrics", gin.WrapH(metrics.Registry.Handler()))
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	log.Println("Swagger documentation available at /swagger/index.html")
+	appLog.Info("Swagger documentation available at /swagger/index.html")
 
 	public := r.Group("/api")
 	{
@@ -196,6 +230,8 @@ func main() {
 		public.POST("/forgot-password", handlers.ForgotPassword)
 		public.POST("/reset-password", handlers.ResetPassword)
 		public.GET("/health", HealthCheckHandler)
+		public.GET("/certificates/verify", handlers.VerifyCertificate)
+		public.GET("/certificates/public-key", handlers.GetCertificatePublicKey)
 	}
 
 	api := r.Group("/api")
@@ -204,15 +240,44 @@ func main() {
 		api.GET("/courses", handlers.GetCourses)
 		api.GET("/courses/:id", handlers.GetCourseByID)
 		api.GET("/courses/:id/tasks/:task_id", handlers.GetTaskByID)
+		api.GET("/courses/:id/discussions", handlers.GetDiscussions)
+		api.POST("/courses/:id/discussions", handlers.CreateDiscussion)
+		api.GET("/discussions/:thread_id", handlers.GetDiscussion)
+		api.POST("/discussions/:thread_id/replies", handlers.CreateDiscussionReply)
 		api.GET("/progress/:user_id", handlers.GetUserProgress)
 		api.POST("/progress/:user_id/tasks/:task_id/complete", handlers.CompleteTask)
 		api.GET("/progress/:user_id/submissions", handlers.GetUserSubmissions)
 		api.POST("/progress/:user_id/tasks/:task_id/submit", handlers.SubmitTaskWithAnswer)
 		api.GET("/progress/:user_id/learning-path", handlers.GetUserLearningPath)
+		api.GET("/progress/:user_id/deadlines", handlers.GetUserDeadlines)
+		api.GET("/progress/:user_id/certificates", handlers.GetUserCertificates)
+		api.POST("/progress/:user_id/certificates", handlers.IssueCertificates)
+		api.GET("/progress/:user_id/badges", handlers.GetUserBadges)
+		api.GET("/progress/:user_id/activity", handlers.GetUserActivityHeatmap)
+		api.POST("/progress/:user_id/tasks/:task_id/quiz-attempts", handlers.StartQuizAttempt)
+		api.GET("/progress/:user_id/tasks/:task_id/quiz-attempts", handlers.GetQuizAttempts)
+		api.GET("/progress/:user_id/quiz-attempts/:attempt_id", handlers.GetQuizAttempt)
+		api.POST("/progress/:user_id/quiz-attempts/:attempt_id/submit", handlers.SubmitQuizAttempt)
+		api.POST("/activity", handlers.RecordLearningActivity)
+		api.POST("/cohorts/join", handlers.JoinCohort)
+		api.GET("/certificates/:certificate_id", handlers.DownloadCertificate)
 
 		api.GET("/profile", handlers.GetUserProfile)
 		api.PUT("/profile", handlers.UpdateUserProfile)
 
+		api.GET("/search", handlers.Search)
+		api.GET("/events/stream", handlers.StreamEvents)
+
+		notificationsGroup := api.Group("/notifications")
+		{
+			notificationsGroup.GET("", handlers.GetNotifications)
+			notificationsGroup.GET("/unread-count", handlers.GetUnreadNotificationCount)
+			notificationsGroup.POST("/read-all", handlers.MarkAllNotificationsRead)
+			notificationsGroup.POST("/:notification_id/read", handlers.MarkNotificationRead)
+			notificationsGroup.GET("/preferences", handlers.GetNotificationPreferences)
+			notificationsGroup.PUT("/preferences", handlers.UpdateNotificationPreferences)
+		}
+
 		account := api.Group("/account")
 		{
 			account.POST("/2fa/enable", handlers.Enable2FAHandler)
@@ -220,6 +285,8 @@ func main() {
 			account.POST("/change-password", handlers.ChangePassword)
 			account.POST("/delete", handlers.InitDeleteAccount)
 			account.POST("/delete/confirm", handlers.ConfirmDeleteAccount)
+			account.GET("/preferences", handlers.GetUserPreferences)
+			account.PUT("/preferences", handlers.UpdateUserPreferences)
 		}
 
 		analytics := api.Group("/analytics")
@@ -231,12 +298,45 @@ func main() {
 		teacher.Use(TeacherAuthMiddleware())
 		{
 			teacher.POST("/courses", handlers.CreateCourse)
-			teacher.PUT("/courses", handlers.UpdateCourse)
-			teacher.DELETE("/courses", handlers.DeleteCourse)
+			teacher.PUT("/courses/:course_id", handlers.UpdateCourse)
+			teacher.DELETE("/courses/:course_id", handlers.DeleteCourse)
 			teacher.POST("/courses/:course_id/tasks", handlers.CreateTask)
 			teacher.PUT("/courses/:course_id/tasks/:task_id", handlers.UpdateTask)
 			teacher.DELETE("/courses/:course_id/tasks/:task_id", handlers.DeleteTask)
-			teacher.GET("/courses/:id/statistics", handlers.GetCourseStatistics)
+			teacher.PUT("/courses/:course_id/tasks/:task_id/skills", handlers.SetTaskSkills)
+			teacher.GET("/courses/:course_id/question-banks", handlers.GetQuestionBanks)
+			teacher.POST("/courses/:course_id/question-banks", handlers.CreateQuestionBank)
+			teacher.GET("/courses/:course_id/question-banks/:bank_id", handlers.GetQuestionBank)
+			teacher.PUT("/courses/:course_id/question-banks/:bank_id", handlers.UpdateQuestionBank)
+			teacher.DELETE("/courses/:course_id/question-banks/:bank_id", handlers.DeleteQuestionBank)
+			teacher.GET("/courses/:course_id/assignments", handlers.GetCourseAssignments)
+			teacher.POST("/courses/:course_id/assignments", handlers.CreateAssignment)
+			teacher.PUT("/courses/:course_id/assignments/:assignment_id", handlers.UpdateAssignment)
+			teacher.DELETE("/courses/:course_id/assignments/:assignment_id", handlers.DeleteAssignment)
+			teacher.PUT("/courses/:course_id/assignments/:assignment_id/extensions/:user_id", handlers.GrantExtension)
+			teacher.GET("/courses/:course_id/statistics", handlers.GetCourseStatistics)
+			teacher.GET("/courses/:course_id/effectiveness", handlers.GetLearningEffectiveness)
+
+			teacher.GET("/cohorts", handlers.GetCohorts)
+			teacher.POST("/cohorts", handlers.CreateCohort)
+			teacher.GET("/cohorts/:cohort_id", handlers.GetCohort)
+			teacher.PUT("/cohorts/:cohort_id", handlers.UpdateCohort)
+			teacher.DELETE("/cohorts/:cohort_id", handlers.DeleteCohort)
+			teacher.POST("/cohorts/:cohort_id/invite-code", handlers.RegenerateCohortInviteCode)
+			teacher.GET("/cohorts/:cohort_id/members", handlers.GetCohortMembers)
+			teacher.POST("/cohorts/:cohort_id/members", handlers.AddCohortMembers)
+			teacher.POST("/cohorts/:cohort_id/members/import", handlers.ImportCohortMembers)
+			teacher.DELETE("/cohorts/:cohort_id/members/:user_id", handlers.RemoveCohortMember)
+			teacher.GET("/cohorts/:cohort_id/courses/:course_id/statistics", handlers.GetCohortCourseStatistics)
+			teacher.GET("/cohorts/:cohort_id/leaderboard", handlers.GetCohortLeaderboard)
+			teacher.GET("/cohorts/:cohort_id/submissions", handlers.GetCohortSubmissions)
+
+			teacher.PUT("/discussions/:thread_id", handlers.ModerateDiscussion)
+			teacher.PUT("/discussions/:thread_id/replies/:reply_id", handlers.ModerateDiscussionReply)
+			teacher.PUT("/discussions/:thread_id/accepted-reply", handlers.AcceptDiscussionReply)
+
+			teacher.GET("/courses/:course_id/similarity-flags", handlers.GetSimilarityFlags)
+			teacher.PUT("/similarity-flags/:flag_id", handlers.ReviewSimilarityFlag)
 		}
 
 		admin := api.Group("/admin")
@@ -244,6 +344,7 @@ func main() {
 		{
 			admin.POST("/reload-templates", handlers.ReloadTemplatesHandler)
 
+			admin.GET("/access", handlers.CheckAdminAccess)
 			admin.GET("/users", handlers.GetAllUsers)
 			admin.GET("/users/:id", handlers.GetUserByID)
 			admin.GET("/users/by-role", handlers.GetUsersByRole)
@@ -252,14 +353,91 @@ func main() {
 			admin.POST("/users/:id/promote", handlers.PromoteToAdmin)
 			admin.POST("/users/:id/demote", handlers.DemoteFromAdmin)
 			admin.GET("/analytics/courses/:course_id/statistics", handlers.GetCourseStatistics)
+			admin.GET("/analytics/courses/:course_id/effectiveness", handlers.GetLearningEffectiveness)
+			admin.POST("/certificates/:certificate_id/revoke", handlers.RevokeCertificate)
+			admin.GET("/badges", handlers.GetBadges)
+			admin.POST("/badges", handlers.CreateBadge)
+			admin.POST("/badges/evaluate", handlers.EvaluateBadges)
+			admin.PUT("/badges/:badge_id", handlers.UpdateBadge)
+			admin.DELETE("/badges/:badge_id", handlers.DeleteBadge)
 		}
 	}
 
+	// Служебные маршруты для других сервисов, шлюз их не проксирует
+	internal := r.Group("/internal")
+	{
+		internal.POST("/events/lab-results", handlers.PublishLabResult)
+	}
+
 	r.Static("/uploads", "/uploads")
 
-	log.Printf("Server starting on port %s", port)
+	appLog.Info("Server starting on port %s", port)
 	if err := r.Run("0.0.0.0:" + port); err != nil {
-		log.Fatal("Server failed:", err)
+		appLog.Fatal("Server failed: %v", err)
+	}
+}
+
+// setupTracing включает трассировку: TRACING_EXPORTER=otlp отправляет спаны коллектору
+// OTEL_EXPORTER_OTLP_ENDPOINT, file - в файл TRACING_FILE, none (по умолчанию) выключает
+func setupTracing(appLog *logger.Logger) *tracing.Tracer {
+	exporter, err := tracing.NewExporter(os.Getenv("TRACING_EXPORTER"), os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), os.Getenv("TRACING_FILE"))
+	if err != nil {
+		appLog.Error("Tracing disabled: %v", err)
+		return nil
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "backend-svc"
+	}
+	return tracing.NewTracer(service, exporter, tracing.Options{
+		OnError: func(err error) { appLog.Error("Tracing export failed: %v", err) },
+	})
+}
+
+// shutdownTracing отправляет оставшиеся спаны перед завершением
+func shutdownTracing(tracer *tracing.Tracer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		logger.Default().Error("Tracing shutdown failed: %v", err)
+	}
+}
+
+// setupLiveEvents выбирает брокер событий: LIVE_EVENTS_BROKER=memory доставляет события
+// в пределах экземпляра, database (по умолчанию с базой данных) - всем экземплярам через
+// общий журнал. Возвращает функцию остановки.
+func setupLiveEvents(useMockData bool) func() {
+	handlers.InternalEventsToken = os.Getenv("INTERNAL_EVENTS_TOKEN")
+
+	brokerName := os.Getenv("LIVE_EVENTS_BROKER")
+	if brokerName == "" {
+		brokerName = "database"
+		if useMockData {
+			brokerName = "memory"
+		}
+	}
+
+	switch brokerName {
+	case "memory":
+		handlers.Events = events.NewHub(events.NewMemoryBroker())
+		logger.Default().Info("Live events use in-memory broker")
+		return func() { handlers.Events.Close() }
+	case "database":
+		broker, err := events.NewStoreBroker(handlers.Store, time.Hour)
+		if err != nil {
+			logger.Default().Warn("Live events disabled: %v", err)
+			return func() {}
+		}
+		handlers.Events = events.NewHub(broker)
+		stop := broker.Start(time.Second)
+		logger.Default().Info("Live events use database broker")
+		return func() {
+			stop()
+			handlers.Events.Close()
+		}
+	default:
+		logger.Default().Fatal("Unknown LIVE_EVENTS_BROKER %q, expected memory or database", brokerName)
+		return nil
 	}
 }
 
@@ -362,8 +540,9 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Page, X-Limit, X-Next-Cursor, X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -374,6 +553,12 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// HealthCheckHandler сообщает, что сервис работает, и состояние подключения к базе
+// @Summary Health check
+// @Tags System
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /health [get]
 func HealthCheckHandler(c *gin.Context) {
 	dbStatus := "ok"
 	if handlers.Db != nil {
@@ -0,0 +1,109 @@
+// Package metrics собирает метрики сервиса для /metrics в текстовом формате Prometheus
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"sync"
+
+	prom "lmsmodule/api-gateway/pkg/metrics"
+)
+
+// Результаты входа для backend_logins_total
+const (
+	LoginSuccess     = "success"
+	LoginOTPRequired = "otp_required"
+	LoginFailed      = "failed"
+)
+
+// Виды ответов для backend_submissions_total
+const (
+	SubmissionTask = "task"
+	SubmissionQuiz = "quiz"
+)
+
+var (
+	Registry = prom.NewRegistry()
+	HTTP     = prom.NewHTTPMetrics(Registry, "backend")
+
+	logins = Registry.NewCounterVec("backend_logins_total",
+		"Login attempts by result.", "result")
+	submissions = Registry.NewCounterVec("backend_submissions_total",
+		"Graded task answers and quiz attempts.", "kind", "correct")
+	completions = Registry.NewCounterVec("backend_task_completions_total",
+		"Tasks completed by users.")
+)
+
+// Login учитывает попытку входа
+func Login(result string) {
+	logins.Inc(result)
+}
+
+// Submission учитывает проверенный ответ
+func Submission(kind string, correct bool) {
+	submissions.Inc(kind, strconv.FormatBool(correct))
+}
+
+// TaskCompleted учитывает выполненную задачу
+func TaskCompleted() {
+	completions.Inc()
+}
+
+// Logins, Submissions и TaskCompletions возвращают накопленные значения счетчиков
+func Logins(result string) float64 {
+	return logins.Value(result)
+}
+
+func Submissions(kind string, correct bool) float64 {
+	return submissions.Value(kind, strconv.FormatBool(correct))
+}
+
+func TaskCompletions() float64 {
+	return completions.Value()
+}
+
+// WatchDB экспортирует состояние пула соединений базы данных; без вызова ряды пула не выводятся
+func WatchDB(stats func() sql.DBStats) {
+	dbStatsMu.Lock()
+	dbStats = stats
+	dbStatsMu.Unlock()
+}
+
+var (
+	dbStatsMu sync.RWMutex
+	dbStats   func() sql.DBStats
+)
+
+func init() {
+	gauge := func(name, help string, value func(sql.DBStats) float64) {
+		Registry.NewGaugeFunc(name, help, nil, dbSample(value))
+	}
+	counter := func(name, help string, value func(sql.DBStats) float64) {
+		Registry.NewCounterFunc(name, help, nil, dbSample(value))
+	}
+
+	gauge("backend_db_open_connections", "Open database connections.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("backend_db_in_use_connections", "Database connections in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("backend_db_idle_connections", "Idle database connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	gauge("backend_db_max_open_connections", "Maximum open database connections.",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	counter("backend_db_wait_total", "Waits for a free database connection.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("backend_db_wait_duration_seconds_total", "Time spent waiting for a free database connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+}
+
+func dbSample(value func(sql.DBStats) float64) func() []prom.Sample {
+	return func() []prom.Sample {
+		dbStatsMu.RLock()
+		stats := dbStats
+		dbStatsMu.RUnlock()
+		if stats == nil {
+			return nil
+		}
+		return []prom.Sample{{Value: value(stats())}}
+	}
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Типы событий учебной активности, которые присылают веб- и Android-клиенты
+const (
+	ActivityTaskOpened = "task_opened"
+	ActivityTimeOnTask = "time_on_task"
+	ActivityHintViewed = "hint_viewed"
+	ActivitySubmission = "submission"
+)
+
+// IsValidActivityType проверяет, что тип события поддерживается
+func IsValidActivityType(activityType string) bool {
+	switch activityType {
+	case ActivityTaskOpened, ActivityTimeOnTask, ActivityHintViewed, ActivitySubmission:
+		return true
+	}
+	return false
+}
+
+type ActivityBatchRequest struct {
+	Events []LearningActivity `json:"events" binding:"required"`
+}
+
+type ActivityBatchResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// TaskActivitySummary - агрегированная активность пользователя по одной задаче
+type TaskActivitySummary struct {
+	UserID           int       `json:"user_id"`
+	TaskID           int       `json:"task_id"`
+	CourseID         int       `json:"course_id"`
+	TimeSpentSeconds int       `json:"time_spent_seconds"`
+	OpenedCount      int       `json:"opened_count"`
+	HintsViewed      int       `json:"hints_viewed"`
+	Submissions      int       `json:"submissions"`
+	FirstActivityAt  time.Time `json:"first_activity_at"`
+	LastActivityAt   time.Time `json:"last_activity_at"`
+}
+
+// Add учитывает событие в агрегате
+func (s *TaskActivitySummary) Add(activity LearningActivity) {
+	switch activity.ActivityType {
+	case ActivityTaskOpened:
+		s.OpenedCount++
+	case ActivityTimeOnTask:
+		s.TimeSpentSeconds += activity.Duration
+	case ActivityHintViewed:
+		s.HintsViewed++
+	case ActivitySubmission:
+		s.Submissions++
+	}
+
+	if s.FirstActivityAt.IsZero() || activity.Timestamp.Before(s.FirstActivityAt) {
+		s.FirstActivityAt = activity.Timestamp
+	}
+	if activity.Timestamp.After(s.LastActivityAt) {
+		s.LastActivityAt = activity.Timestamp
+	}
+}
+
+// FormatDuration форматирует длительность в секундах в виде "1h 05m" или "12m 30s"
+func FormatDuration(seconds int) string {
+	d := time.Duration(seconds) * time.Second
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm %02ds", int(d.Minutes()), seconds%60)
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Состояния сдачи задачи относительно срока задания
+const (
+	DeadlineNotOpen = "not_open"
+	DeadlineOpen    = "open"
+	DeadlineLate    = "late"
+	DeadlineClosed  = "closed"
+)
+
+// LatePolicy - штраф за сдачу после срока. За каждые начатые сутки просрочки (после GraceMinutes)
+// балл снижается на PenaltyPerDay процентов, но не более чем на MaxPenalty (0 - до 100%).
+// При CloseAfterDays > 0 задание перестает принимать ответы через указанное число дней после срока.
+type LatePolicy struct {
+	PenaltyPerDay  float64 `json:"penalty_per_day"`
+	MaxPenalty     float64 `json:"max_penalty"`
+	GraceMinutes   int     `json:"grace_minutes"`
+	CloseAfterDays int     `json:"close_after_days"`
+}
+
+// Assignment - набор задач курса со сроками. CohortID ограничивает задание одной группой,
+// без него задание действует для всех студентов курса.
+type Assignment struct {
+	ID          int        `json:"id"`
+	CourseID    int        `json:"course_id"`
+	CohortID    *int       `json:"cohort_id,omitempty"`
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	TaskIDs     []int      `json:"task_ids" binding:"required"`
+	OpensAt     time.Time  `json:"opens_at"`
+	DueAt       time.Time  `json:"due_at" binding:"required"`
+	LatePolicy  LatePolicy `json:"late_policy"`
+	CreatedBy   int        `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// AssignmentExtension - индивидуальный срок студента по заданию
+type AssignmentExtension struct {
+	AssignmentID int       `json:"assignment_id"`
+	UserID       int       `json:"user_id"`
+	DueAt        time.Time `json:"due_at" binding:"required"`
+	Reason       string    `json:"reason,omitempty"`
+	GrantedBy    int       `json:"granted_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TaskDeadline - действующий для пользователя срок задачи с учетом продления
+type TaskDeadline struct {
+	TaskID          int        `json:"task_id"`
+	CourseID        int        `json:"course_id"`
+	AssignmentID    int        `json:"assignment_id"`
+	AssignmentTitle string     `json:"assignment_title"`
+	OpensAt         time.Time  `json:"opens_at"`
+	DueAt           time.Time  `json:"due_at"`
+	Extended        bool       `json:"extended"`
+	LatePolicy      LatePolicy `json:"late_policy"`
+}
+
+// Status возвращает состояние сдачи в момент at
+func (d TaskDeadline) Status(at time.Time) string {
+	switch {
+	case at.Before(d.OpensAt):
+		return DeadlineNotOpen
+	case !at.After(d.DueAt.Add(time.Duration(d.LatePolicy.GraceMinutes) * time.Minute)):
+		return DeadlineOpen
+	case d.LatePolicy.CloseAfterDays > 0 && at.After(d.DueAt.AddDate(0, 0, d.LatePolicy.CloseAfterDays)):
+		return DeadlineClosed
+	}
+	return DeadlineLate
+}
+
+// Penalty возвращает штраф в процентах за сдачу в момент at
+func (d TaskDeadline) Penalty(at time.Time) float64 {
+	if d.Status(at) != DeadlineLate {
+		return 0
+	}
+
+	late := at.Sub(d.DueAt.Add(time.Duration(d.LatePolicy.GraceMinutes) * time.Minute))
+	days := math.Ceil(late.Hours() / 24)
+	limit := d.LatePolicy.MaxPenalty
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return math.Min(days*d.LatePolicy.PenaltyPerDay, limit)
+}
+
+// ResolveDeadlines сопоставляет задачам сроки из действующих для пользователя заданий.
+// extensions - продленные сроки пользователя по ID задания. Если задача входит в несколько
+// заданий, выбирается задание с самым поздним сроком.
+func ResolveDeadlines(assignments []Assignment, extensions map[int]time.Time) []TaskDeadline {
+	byTask := make(map[int]TaskDeadline)
+	var order []int
+
+	for _, assignment := range assignments {
+		dueAt, extended := extensions[assignment.ID]
+		if !extended {
+			dueAt = assignment.DueAt
+		}
+
+		for _, taskID := range assignment.TaskIDs {
+			current, exists := byTask[taskID]
+			if exists && !dueAt.After(current.DueAt) {
+				continue
+			}
+			if !exists {
+				order = append(order, taskID)
+			}
+			byTask[taskID] = TaskDeadline{
+				TaskID:          taskID,
+				CourseID:        assignment.CourseID,
+				AssignmentID:    assignment.ID,
+				AssignmentTitle: assignment.Title,
+				OpensAt:         assignment.OpensAt,
+				DueAt:           dueAt,
+				Extended:        extended,
+				LatePolicy:      assignment.LatePolicy,
+			}
+		}
+	}
+
+	deadlines := make([]TaskDeadline, 0, len(order))
+	for _, taskID := range order {
+		deadlines = append(deadlines, byTask[taskID])
+	}
+	return deadlines
+}
+
+// DeadlineReminder - напоминание студенту о приближающемся сроке задания
+type DeadlineReminder struct {
+	AssignmentID    int       `json:"assignment_id"`
+	AssignmentTitle string    `json:"assignment_title"`
+	CourseID        int       `json:"course_id"`
+	CourseName      string    `json:"course_name"`
+	UserID          int       `json:"user_id"`
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	DueAt           time.Time `json:"due_at"`
+	RemainingTasks  int       `json:"remaining_tasks"`
+}
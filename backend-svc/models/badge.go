@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// BadgeCriteria - декларативное условие получения значка. Type выбирает правило движка
+// достижений (см. package achievements), остальные поля - его параметры.
+type BadgeCriteria struct {
+	Type       string `json:"type" binding:"required"`
+	Threshold  int    `json:"threshold,omitempty"`
+	CourseID   int    `json:"course_id,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+}
+
+// Badge - значок, который администратор настраивает без изменения кода
+type Badge struct {
+	ID          int           `json:"id"`
+	Code        string        `json:"code" binding:"required"`
+	Name        string        `json:"name" binding:"required"`
+	Description string        `json:"description"`
+	Icon        string        `json:"icon,omitempty"`
+	Criteria    BadgeCriteria `json:"criteria" binding:"required"`
+	IsActive    bool          `json:"is_active"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// UserBadge - значок, полученный пользователем
+type UserBadge struct {
+	BadgeID     int       `json:"badge_id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Icon        string    `json:"icon,omitempty"`
+	AwardedAt   time.Time `json:"awarded_at"`
+}
+
+// BadgeEvaluationResult - итог пересчета значков по накопленным данным
+type BadgeEvaluationResult struct {
+	EvaluatedUsers int `json:"evaluated_users"`
+	AwardedBadges  int `json:"awarded_badges"`
+}
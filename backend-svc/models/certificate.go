@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Статусы проверки сертификата
+const (
+	CertificateValid            = "valid"
+	CertificateRevoked          = "revoked"
+	CertificateInvalidSignature = "invalid_signature"
+)
+
+// Certificate - подписанный сертификат о прохождении курса. Имя студента и название
+// курса сохраняются на момент выдачи, поэтому сертификат остается проверяемым после
+// переименования или удаления курса.
+type Certificate struct {
+	ID           string     `json:"id"`
+	UserID       int        `json:"user_id,omitempty"`
+	FullName     string     `json:"full_name"`
+	CourseID     int        `json:"course_id"`
+	CourseTitle  string     `json:"course_title"`
+	Score        float64    `json:"score"`
+	CompletedAt  time.Time  `json:"completed_at"`
+	IssuedAt     time.Time  `json:"issued_at"`
+	Signature    string     `json:"signature"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason string     `json:"revoke_reason,omitempty"`
+}
+
+// IsRevoked сообщает, отозван ли сертификат
+func (c Certificate) IsRevoked() bool {
+	return c.RevokedAt != nil
+}
+
+// CourseCompletion - прохождение курса студентом: выполненные задачи и итоговый балл.
+// Score - процент набранных баллов курса с учетом штрафов за просрочку.
+type CourseCompletion struct {
+	CourseID       int        `json:"course_id"`
+	CourseTitle    string     `json:"course_title"`
+	TasksCount     int        `json:"tasks_count"`
+	CompletedTasks int        `json:"completed_tasks"`
+	Score          float64    `json:"score"`
+	IsCompleted    bool       `json:"is_completed"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// CertificateVerification - результат публичной проверки сертификата
+type CertificateVerification struct {
+	Valid       bool        `json:"valid"`
+	Status      string      `json:"status"`
+	Certificate Certificate `json:"certificate"`
+}
+
+// CertificatePublicKey - открытый ключ для самостоятельной проверки подписи сертификатов
+type CertificatePublicKey struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
+
+type RevokeCertificateRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
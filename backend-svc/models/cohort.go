@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Cohort - учебная группа (например, "CS-21-1") со студентами, преподавателями и курсами.
+// Студенты вступают в группу по коду приглашения или добавляются преподавателем.
+type Cohort struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name" binding:"required"`
+	Description  string    `json:"description"`
+	InviteCode   string    `json:"invite_code,omitempty"`
+	CourseIDs    []int     `json:"course_ids"`
+	TeacherIDs   []int     `json:"teacher_ids"`
+	MembersCount int       `json:"members_count"`
+	CreatedBy    int       `json:"created_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CohortMember - студент группы
+type CohortMember struct {
+	UserID   int       `json:"user_id"`
+	Username string    `json:"username"`
+	FullName string    `json:"full_name"`
+	Email    string    `json:"email"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// CohortMembersRequest - добавление студентов в группу по ID
+type CohortMembersRequest struct {
+	UserIDs []int `json:"user_ids" binding:"required"`
+}
+
+// CohortImportResult - итог массового добавления студентов. NotFound содержит
+// строки CSV, для которых не найден пользователь.
+type CohortImportResult struct {
+	Added          []int    `json:"added"`
+	AlreadyMembers []int    `json:"already_members"`
+	NotFound       []string `json:"not_found"`
+}
+
+// JoinCohortRequest - вступление в группу по коду приглашения
+type JoinCohortRequest struct {
+	InviteCode string `json:"invite_code" binding:"required"`
+}
+
+// CohortSubmission - попытка сдачи студента группы в очереди преподавателя
+type CohortSubmission struct {
+	SubmissionID int       `json:"submission_id"`
+	UserID       int       `json:"user_id"`
+	Username     string    `json:"username"`
+	TaskID       int       `json:"task_id"`
+	TaskTitle    string    `json:"task_title"`
+	CourseID     int       `json:"course_id"`
+	IsCorrect    bool      `json:"is_correct"`
+	Score        float64   `json:"score"`
+	IsLate       bool      `json:"is_late"`
+	Penalty      float64   `json:"penalty_percent"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Ограничения обсуждений
+const (
+	MaxThreadTitleLength    = 200
+	MaxDiscussionBodyLength = 20000
+	// MinSpoilerSolutionLength - решения короче не ищутся в тексте, чтобы короткие
+	// ответы вроде "42" не скрывали обычные сообщения
+	MinSpoilerSolutionLength = 8
+)
+
+// DiscussionThread - тема обсуждения курса или задачи курса. Body и ответы пишутся в markdown;
+// сервер хранит текст как есть, клиент экранирует HTML при отображении.
+// Тема со спойлером скрывает текст и ответы от тех, кто еще не выполнил задачу: тогда
+// SpoilerHidden равен true, а Body и Replies пусты.
+type DiscussionThread struct {
+	ID              int               `json:"id"`
+	CourseID        int               `json:"course_id"`
+	TaskID          *int              `json:"task_id,omitempty"`
+	AuthorID        int               `json:"author_id"`
+	AuthorName      string            `json:"author_name"`
+	Title           string            `json:"title"`
+	Body            string            `json:"body"`
+	IsPinned        bool              `json:"is_pinned"`
+	IsLocked        bool              `json:"is_locked"`
+	IsHidden        bool              `json:"is_hidden"`
+	HasSpoiler      bool              `json:"has_spoiler"`
+	SpoilerHidden   bool              `json:"spoiler_hidden"`
+	AcceptedReplyID *int              `json:"accepted_reply_id,omitempty"`
+	RepliesCount    int               `json:"replies_count"`
+	CreatedAt       time.Time         `json:"created_at"`
+	LastActivityAt  time.Time         `json:"last_activity_at"`
+	Replies         []DiscussionReply `json:"replies,omitempty"`
+}
+
+// DiscussionReply - ответ в теме
+type DiscussionReply struct {
+	ID         int       `json:"id"`
+	ThreadID   int       `json:"thread_id"`
+	AuthorID   int       `json:"author_id"`
+	AuthorName string    `json:"author_name"`
+	Body       string    `json:"body"`
+	IsHidden   bool      `json:"is_hidden"`
+	IsAccepted bool      `json:"is_accepted"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateThreadRequest - новая тема; без task_id тема относится ко всему курсу
+type CreateThreadRequest struct {
+	Title  string `json:"title" binding:"required"`
+	Body   string `json:"body" binding:"required"`
+	TaskID *int   `json:"task_id"`
+}
+
+// CreateReplyRequest - новый ответ в теме
+type CreateReplyRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// ThreadModeration - изменение темы модератором; поля без значения не меняются
+type ThreadModeration struct {
+	IsPinned   *bool `json:"is_pinned"`
+	IsLocked   *bool `json:"is_locked"`
+	IsHidden   *bool `json:"is_hidden"`
+	HasSpoiler *bool `json:"has_spoiler"`
+}
+
+// ReplyModeration - скрытие или возврат ответа модератором
+type ReplyModeration struct {
+	IsHidden bool `json:"is_hidden"`
+}
+
+// AcceptReplyRequest - отметка принятого ответа; reply_id 0 снимает отметку
+type AcceptReplyRequest struct {
+	ReplyID int `json:"reply_id"`
+}
+
+// ContainsSolution сообщает, содержит ли текст решение задачи. Регистр и пробелы не учитываются.
+func ContainsSolution(text, solution string) bool {
+	solution = normalizeSpoilerText(solution)
+	if len([]rune(solution)) < MinSpoilerSolutionLength {
+		return false
+	}
+	return strings.Contains(normalizeSpoilerText(text), solution)
+}
+
+func normalizeSpoilerText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
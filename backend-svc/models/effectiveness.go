@@ -0,0 +1,70 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Периоды отчета об эффективности обучения. Семестр (term) считается равным четырем месяцам.
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodTerm  = "term"
+
+	DefaultInactivityDays = 14
+)
+
+// EffectivenessParams задает курс и период отчета [From, To).
+//
+// Показатели отчета:
+//   - TotalStudents - пользователи, у которых до конца периода была любая активность по курсу:
+//     выполненная задача, попытка сдачи или событие учебной активности;
+//   - AverageCompletion - средний процент выполненных задач курса на конец периода;
+//   - AverageGrade - средний процент набранных баллов от суммы баллов задач курса;
+//   - CompletionRate - доля студентов, выполнивших все задачи курса;
+//   - DropoutRate - доля не завершивших курс студентов без активности дольше InactivityDays
+//     дней к концу периода;
+//   - AverageTimeSpent - среднее время работы над задачами за период (по событиям time_on_task)
+//     среди студентов, у которых оно есть;
+//   - DifficultTasks - задачи с попытками сдачи за период, по убыванию доли неверных попыток.
+type EffectivenessParams struct {
+	CourseID       int
+	Period         string
+	From           time.Time
+	To             time.Time
+	InactivityDays int
+}
+
+// IsValidPeriod проверяет, что период отчета поддерживается
+func IsValidPeriod(period string) bool {
+	return period == PeriodWeek || period == PeriodMonth || period == PeriodTerm
+}
+
+// PeriodStart возвращает начало периода, заканчивающегося в момент end
+func PeriodStart(period string, end time.Time) time.Time {
+	switch period {
+	case PeriodWeek:
+		return end.AddDate(0, 0, -7)
+	case PeriodTerm:
+		return end.AddDate(0, -4, 0)
+	default:
+		return end.AddDate(0, -1, 0)
+	}
+}
+
+// EffectivenessScore считает итоговую оценку эффективности от 0 до 100:
+//
+//	0.35*AverageCompletion + 0.25*AverageGrade + 0.25*(100-DropoutRate) + 0.15*AttemptSuccessRate
+//
+// где AttemptSuccessRate - доля верных попыток сдачи за период. Если попыток за период не было,
+// последнее слагаемое не учитывается, а остальные веса нормируются на 0.85.
+// Результат округляется до одного знака после запятой.
+func EffectivenessScore(averageCompletion, averageGrade, dropoutRate, attemptSuccessRate float64, hasAttempts bool) float64 {
+	score := 0.35*averageCompletion + 0.25*averageGrade + 0.25*(100-dropoutRate)
+	if hasAttempts {
+		score += 0.15 * attemptSuccessRate
+	} else {
+		score /= 0.85
+	}
+	return math.Round(score*10) / 10
+}
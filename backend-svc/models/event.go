@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Типы событий канала обновлений в реальном времени
+const (
+	EventNotification = "notification"
+	EventGrading      = "grading"
+	EventProgress     = "progress"
+	EventLabResult    = "lab_result"
+	EventLeaderboard  = "leaderboard"
+)
+
+// LiveEvent - событие, которое отправляется подключенным клиентам. Событие с UserID 0
+// получают все подключенные пользователи, например изменение рейтинга.
+type LiveEvent struct {
+	ID        int                    `json:"id"`
+	Type      string                 `json:"type"`
+	UserID    int                    `json:"user_id,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// LabResultEvent - результат выполнения лабораторной работы от сервиса исполнения кода
+type LabResultEvent struct {
+	UserID    int                    `json:"user_id" binding:"required"`
+	SessionID string                 `json:"session_id" binding:"required"`
+	Status    string                 `json:"status" binding:"required"`
+	Result    map[string]interface{} `json:"result,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// ListParams описывает общий контракт постраничной выборки, сортировки и фильтрации
+// для списочных эндпоинтов. Нулевое значение означает выборку без ограничений.
+type ListParams struct {
+	Page      int
+	Limit     int
+	Cursor    int
+	SortBy    string
+	SortDesc  bool
+	IsActive  *bool
+	IsTeacher *bool
+	IsAdmin   *bool
+	CourseID  int
+	CohortID  int
+	// TeacherID ограничивает статистику курса студентами групп преподавателя с этим курсом
+	TeacherID int
+	From      time.Time
+	To        time.Time
+}
+
+// Offset возвращает смещение первой записи страницы
+func (p ListParams) Offset() int {
+	if p.Limit <= 0 || p.Page <= 1 || p.Cursor > 0 {
+		return 0
+	}
+	return (p.Page - 1) * p.Limit
+}
+
+// IsCursor сообщает, запрошена ли выборка по курсору вместо номера страницы
+func (p ListParams) IsCursor() bool {
+	return p.Cursor > 0
+}
@@ -18,10 +18,13 @@ type User struct {
 	IsTeacher      bool             `json:"isTeacher,omitempty"`
 	IsDeleted      bool             `json:"-"` // Скрыто в JSON
 	LastLogin      time.Time        `json:"lastLogin,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt,omitempty"`
 	Courses        []CourseProgress `json:"courses,omitempty"`
 	CompletedTasks int              `json:"completedTasks,omitempty"`
 	TotalTasks     int              `json:"totalTasks,omitempty"`
 	Progress       float64          `json:"progress,omitempty"`
+	Certificates   []Certificate    `json:"certificates,omitempty"`
+	Badges         []UserBadge      `json:"badges,omitempty"`
 }
 
 type CourseProgress struct {
@@ -41,17 +44,21 @@ type Course struct {
 	Tasks             []Task `json:"tasks"`
 }
 
+// Task - задача курса. Type по умолчанию code (ответ сверяется с Solution),
+// для тестов (quiz) параметры задаются в Quiz.
 type Task struct {
-	ID          int    `json:"id"`
-	CourseID    int    `json:"courseId"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Difficulty  string `json:"difficulty"`
-	Order       int    `json:"order"`
-	Points      int    `json:"points"`
-	Content     string `json:"content"`
-	Solution    string `json:"solution"`
-	IsCompleted bool   `json:"isCompleted"`
+	ID          int           `json:"id"`
+	CourseID    int           `json:"courseId"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Difficulty  string        `json:"difficulty"`
+	Order       int           `json:"order"`
+	Points      int           `json:"points"`
+	Type        string        `json:"type"`
+	Content     string        `json:"content"`
+	Solution    string        `json:"solution"`
+	Quiz        *QuizSettings `json:"quiz,omitempty"`
+	IsCompleted bool          `json:"isCompleted"`
 }
 
 type UserProgress struct {
@@ -77,6 +84,11 @@ type TaskSubmissionResponse struct {
 	SubmittedAt  time.Time `json:"submitted_at"`
 	Message      string    `json:"message,omitempty"`
 	IsCorrect    bool      `json:"is_correct"`
+	Score        float64   `json:"score"`
+	// IsLate, Penalty и DueDate заполняются, если задача входит в задание со сроком
+	IsLate  bool       `json:"is_late,omitempty"`
+	Penalty float64    `json:"penalty_percent,omitempty"`
+	DueDate *time.Time `json:"due_date,omitempty"`
 }
 
 type TaskSubmissionDetails struct {
@@ -93,6 +105,19 @@ type TaskSubmissionDetails struct {
 	Feedback     string    `json:"feedback,omitempty"`
 }
 
+// SubmissionAttempt - одна попытка сдачи ответа на задачу
+type SubmissionAttempt struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	TaskID      int       `json:"task_id"`
+	CourseID    int       `json:"course_id"`
+	IsCorrect   bool      `json:"is_correct"`
+	Score       float64   `json:"score"`
+	IsLate      bool      `json:"is_late"`
+	Penalty     float64   `json:"penalty_percent"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
 type GradeSubmission struct {
 	Score    float64   `json:"score" binding:"required"`
 	Feedback string    `json:"feedback"`
@@ -107,6 +132,7 @@ type CourseStatistics struct {
 	CompletedStudents   int     `json:"completed_students"`
 	AverageCompletion   float64 `json:"average_completion_percentage"`
 	AverageScore        float64 `json:"average_score"`
+	AverageTimeSpent    string  `json:"average_time_spent"`
 	TaskCompletionRates []struct {
 		TaskID       int     `json:"task_id"`
 		TaskTitle    string  `json:"task_title"`
@@ -121,6 +147,7 @@ type CourseStatistics struct {
 		AverageScore      float64 `json:"average_score"`
 		LastActivity      string  `json:"last_activity"`
 	} `json:"students_progress"`
+	StudentsTotal int `json:"students_total"`
 }
 
 type UserStatistics struct {
@@ -133,6 +160,7 @@ type UserStatistics struct {
 	TotalPoints      int       `json:"total_points"`
 	JoinedDate       time.Time `json:"joined_date"`
 	LastActive       time.Time `json:"last_active"`
+	TotalTimeSpent   string    `json:"total_time_spent"`
 	CoursesProgress  []struct {
 		CourseID          int     `json:"course_id"`
 		CourseName        string  `json:"course_name"`
@@ -140,18 +168,25 @@ type UserStatistics struct {
 		AverageScore      float64 `json:"average_score"`
 		LastActivity      string  `json:"last_activity"`
 	} `json:"courses_progress"`
+	Badges []UserBadge `json:"badges"`
+	Streak StreakStats `json:"streak"`
 }
 
+// LearningEffectiveness - отчет об эффективности обучения по курсу за период.
+// Определения показателей приведены в effectiveness.go.
 type LearningEffectiveness struct {
-	Period            string  `json:"period"`
-	CourseID          int     `json:"course_id,omitempty"`
-	CourseName        string  `json:"course_name,omitempty"`
-	TotalStudents     int     `json:"total_students"`
-	AverageCompletion float64 `json:"average_completion_percentage"`
-	AverageGrade      float64 `json:"average_grade"`
-	CompletionRate    float64 `json:"completion_rate"`
-	DropoutRate       float64 `json:"dropout_rate"`
-	AverageTimeSpent  string  `json:"average_time_spent"`
+	Period            string    `json:"period"`
+	PeriodStart       time.Time `json:"period_start"`
+	PeriodEnd         time.Time `json:"period_end"`
+	InactivityDays    int       `json:"inactivity_threshold_days"`
+	CourseID          int       `json:"course_id,omitempty"`
+	CourseName        string    `json:"course_name,omitempty"`
+	TotalStudents     int       `json:"total_students"`
+	AverageCompletion float64   `json:"average_completion_percentage"`
+	AverageGrade      float64   `json:"average_grade"`
+	CompletionRate    float64   `json:"completion_rate"`
+	DropoutRate       float64   `json:"dropout_rate"`
+	AverageTimeSpent  string    `json:"average_time_spent"`
 	DifficultTasks    []struct {
 		TaskID       int     `json:"task_id"`
 		TaskTitle    string  `json:"task_title"`
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Типы уведомлений
+const (
+	NotificationGradingResult   = "grading_result"
+	NotificationCoursePublished = "course_published"
+	NotificationDeadline        = "deadline_reminder"
+	NotificationRoleChanged     = "role_changed"
+	NotificationAccountStatus   = "account_status"
+	NotificationDiscussion      = "discussion"
+)
+
+// DefaultEmailNotifications - типы уведомлений, которые дублируются письмом, пока пользователь
+// не изменил настройки. Напоминания о сроках и изменения учетной записи раньше приходили
+// только письмом, поэтому для них письма включены.
+var DefaultEmailNotifications = map[string]bool{
+	NotificationGradingResult:   false,
+	NotificationCoursePublished: false,
+	NotificationDeadline:        true,
+	NotificationRoleChanged:     true,
+	NotificationAccountStatus:   true,
+	NotificationDiscussion:      false,
+}
+
+// IsValidNotificationType проверяет, что тип уведомления поддерживается
+func IsValidNotificationType(notificationType string) bool {
+	_, ok := DefaultEmailNotifications[notificationType]
+	return ok
+}
+
+// Notification - уведомление пользователя в приложении. Payload содержит данные
+// для клиента, например идентификаторы задачи или курса.
+type Notification struct {
+	ID        int                    `json:"id"`
+	UserID    int                    `json:"user_id"`
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Payload   map[string]interface{} `json:"payload,omitempty"`
+	IsRead    bool                   `json:"is_read"`
+	CreatedAt time.Time              `json:"created_at"`
+	ReadAt    *time.Time             `json:"read_at,omitempty"`
+}
+
+type UnreadNotificationsResponse struct {
+	UnreadCount int `json:"unread_count"`
+}
+
+type MarkNotificationsReadResponse struct {
+	Marked int `json:"marked"`
+}
+
+// NotificationPreferences - какие типы уведомлений пользователь получает и письмом
+type NotificationPreferences struct {
+	Email map[string]bool `json:"email" binding:"required"`
+}
+
+// EmailEnabled сообщает, нужно ли отправить уведомление этого типа письмом
+func (p NotificationPreferences) EmailEnabled(notificationType string) bool {
+	if enabled, ok := p.Email[notificationType]; ok {
+		return enabled
+	}
+	return DefaultEmailNotifications[notificationType]
+}
+
+// WithDefaults возвращает настройки по всем типам уведомлений
+func (p NotificationPreferences) WithDefaults() NotificationPreferences {
+	email := make(map[string]bool, len(DefaultEmailNotifications))
+	for notificationType := range DefaultEmailNotifications {
+		email[notificationType] = p.EmailEnabled(notificationType)
+	}
+	return NotificationPreferences{Email: email}
+}
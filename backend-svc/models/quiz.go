@@ -0,0 +1,382 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Типы задач
+const (
+	TaskTypeCode = "code"
+	TaskTypeQuiz = "quiz"
+)
+
+// Типы вопросов теста
+const (
+	QuestionSingleChoice   = "single_choice"
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionShortAnswer    = "short_answer"
+	QuestionOrdering       = "ordering"
+)
+
+// Состояния попытки прохождения теста
+const (
+	QuizAttemptInProgress = "in_progress"
+	QuizAttemptSubmitted  = "submitted"
+	QuizAttemptExpired    = "expired"
+)
+
+const (
+	// DefaultPassingScore - процент верных ответов для зачета, если он не задан в тесте
+	DefaultPassingScore = 60.0
+	// QuizGracePeriod - запас времени на сетевые задержки при проверке лимита времени
+	QuizGracePeriod = 30 * time.Second
+)
+
+// QuizSettings - параметры задачи-теста. Вопросы попытки выбираются из банков вопросов курса
+// по списку Sources. Нулевые TimeLimitMinutes и MaxAttempts означают отсутствие ограничения.
+type QuizSettings struct {
+	TimeLimitMinutes int          `json:"time_limit_minutes"`
+	MaxAttempts      int          `json:"max_attempts"`
+	PassingScore     float64      `json:"passing_score"`
+	ShuffleQuestions bool         `json:"shuffle_questions"`
+	ShuffleOptions   bool         `json:"shuffle_options"`
+	Sources          []QuizSource `json:"sources"`
+}
+
+// QuizSource - сколько случайных вопросов взять из банка (0 - все вопросы банка)
+type QuizSource struct {
+	BankID int `json:"bank_id"`
+	Count  int `json:"count"`
+}
+
+// QuestionBank - банк вопросов курса, из которого собираются тесты
+type QuestionBank struct {
+	ID        int            `json:"id"`
+	CourseID  int            `json:"course_id"`
+	Title     string         `json:"title" binding:"required"`
+	Questions []QuizQuestion `json:"questions" binding:"required"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// QuizQuestion - вопрос теста. Для short_answer варианты - допустимые ответы,
+// для ordering порядок вариантов при создании вопроса считается верным.
+type QuizQuestion struct {
+	ID      int          `json:"id"`
+	Type    string       `json:"type"`
+	Text    string       `json:"text"`
+	Points  float64      `json:"points"`
+	Options []QuizOption `json:"options"`
+}
+
+// QuizOption - вариант ответа. Position - место варианта в исходном вопросе.
+type QuizOption struct {
+	ID        int    `json:"id"`
+	Text      string `json:"text"`
+	IsCorrect bool   `json:"is_correct,omitempty"`
+	Position  int    `json:"position,omitempty"`
+}
+
+// QuizAnswer - ответ на вопрос: выбранные варианты (для ordering - в выбранном порядке) или текст
+type QuizAnswer struct {
+	QuestionID int    `json:"question_id"`
+	OptionIDs  []int  `json:"option_ids,omitempty"`
+	Text       string `json:"text,omitempty"`
+}
+
+// QuizSubmission - ответы на вопросы попытки
+type QuizSubmission struct {
+	Answers []QuizAnswer `json:"answers" binding:"required"`
+}
+
+// QuizAttempt - попытка прохождения теста. Вопросы сохраняются в попытке в том виде,
+// в котором они были выданы, поэтому изменение банка не влияет на начатые попытки.
+type QuizAttempt struct {
+	ID           int                   `json:"id"`
+	UserID       int                   `json:"user_id"`
+	TaskID       int                   `json:"task_id"`
+	CourseID     int                   `json:"course_id"`
+	Status       string                `json:"status"`
+	StartedAt    time.Time             `json:"started_at"`
+	ExpiresAt    *time.Time            `json:"expires_at,omitempty"`
+	SubmittedAt  *time.Time            `json:"submitted_at,omitempty"`
+	Score        float64               `json:"score"`
+	Points       float64               `json:"points"`
+	IsPassed     bool                  `json:"is_passed"`
+	IsLate       bool                  `json:"is_late,omitempty"`
+	Penalty      float64               `json:"penalty_percent,omitempty"`
+	SubmissionID int                   `json:"submission_id,omitempty"`
+	Questions    []QuizAttemptQuestion `json:"questions,omitempty"`
+}
+
+// QuizAttemptQuestion - вопрос попытки с ответом студента и полученной долей балла
+type QuizAttemptQuestion struct {
+	QuizQuestion
+	Answer *QuizAnswer `json:"answer,omitempty"`
+	Earned *float64    `json:"earned,omitempty"`
+}
+
+// Validate проверяет параметры теста
+func (s QuizSettings) Validate() error {
+	if s.TimeLimitMinutes < 0 || s.MaxAttempts < 0 {
+		return errors.New("time_limit_minutes and max_attempts must not be negative")
+	}
+	if s.PassingScore < 0 || s.PassingScore > 100 {
+		return errors.New("passing_score must be between 0 and 100")
+	}
+	if len(s.Sources) == 0 {
+		return errors.New("quiz must take questions from at least one question bank")
+	}
+	seen := make(map[int]bool)
+	for _, source := range s.Sources {
+		if source.Count < 0 {
+			return errors.New("question count must not be negative")
+		}
+		if seen[source.BankID] {
+			return fmt.Errorf("question bank %d is listed twice", source.BankID)
+		}
+		seen[source.BankID] = true
+	}
+	return nil
+}
+
+// PassingPercent возвращает процент для зачета теста
+func (s QuizSettings) PassingPercent() float64 {
+	if s.PassingScore == 0 {
+		return DefaultPassingScore
+	}
+	return s.PassingScore
+}
+
+// Validate проверяет банк вопросов
+func (b QuestionBank) Validate() error {
+	if strings.TrimSpace(b.Title) == "" {
+		return errors.New("title is required")
+	}
+	if len(b.Questions) == 0 {
+		return errors.New("question bank must contain at least one question")
+	}
+	for i, question := range b.Questions {
+		if err := question.Validate(); err != nil {
+			return fmt.Errorf("question %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Validate проверяет текст, вес и варианты ответа вопроса
+func (q QuizQuestion) Validate() error {
+	if strings.TrimSpace(q.Text) == "" {
+		return errors.New("text is required")
+	}
+	if q.Points < 0 {
+		return errors.New("points must not be negative")
+	}
+
+	var correct int
+	for _, option := range q.Options {
+		if strings.TrimSpace(option.Text) == "" {
+			return errors.New("option text is required")
+		}
+		if option.IsCorrect {
+			correct++
+		}
+	}
+
+	switch q.Type {
+	case QuestionSingleChoice:
+		if len(q.Options) < 2 || correct != 1 {
+			return errors.New("single choice question needs at least two options and exactly one correct")
+		}
+	case QuestionMultipleChoice:
+		if len(q.Options) < 2 || correct == 0 {
+			return errors.New("multiple choice question needs at least two options and a correct one")
+		}
+	case QuestionShortAnswer:
+		if len(q.Options) == 0 {
+			return errors.New("short answer question needs at least one accepted answer")
+		}
+	case QuestionOrdering:
+		if len(q.Options) < 2 {
+			return errors.New("ordering question needs at least two items")
+		}
+	default:
+		return fmt.Errorf("unknown question type %q", q.Type)
+	}
+	return nil
+}
+
+// Grade возвращает долю балла за ответ от 0 до 1. В вопросах с несколькими
+// верными вариантами каждый неверно выбранный вариант отменяет один верный,
+// в вопросах на упорядочивание засчитывается каждый элемент на своем месте.
+func (q QuizQuestion) Grade(answer QuizAnswer) float64 {
+	switch q.Type {
+	case QuestionSingleChoice:
+		if len(answer.OptionIDs) == 1 {
+			if option, ok := q.option(answer.OptionIDs[0]); ok && option.IsCorrect {
+				return 1
+			}
+		}
+	case QuestionMultipleChoice:
+		var correct, hits, misses int
+		for _, option := range q.Options {
+			if option.IsCorrect {
+				correct++
+			}
+		}
+		selected := make(map[int]bool)
+		for _, id := range answer.OptionIDs {
+			option, ok := q.option(id)
+			if !ok || selected[id] {
+				continue
+			}
+			selected[id] = true
+			if option.IsCorrect {
+				hits++
+			} else {
+				misses++
+			}
+		}
+		if correct > 0 && hits > misses {
+			return float64(hits-misses) / float64(correct)
+		}
+	case QuestionShortAnswer:
+		text := normalizeAnswer(answer.Text)
+		for _, option := range q.Options {
+			if text != "" && text == normalizeAnswer(option.Text) {
+				return 1
+			}
+		}
+	case QuestionOrdering:
+		var placed int
+		for i, id := range answer.OptionIDs {
+			if option, ok := q.option(id); ok && option.Position == i+1 {
+				placed++
+			}
+		}
+		if len(q.Options) > 0 {
+			return float64(placed) / float64(len(q.Options))
+		}
+	}
+	return 0
+}
+
+// ForStudent возвращает вопрос без признаков верных ответов
+func (q QuizQuestion) ForStudent() QuizQuestion {
+	options := make([]QuizOption, 0, len(q.Options))
+	if q.Type != QuestionShortAnswer {
+		for _, option := range q.Options {
+			options = append(options, QuizOption{ID: option.ID, Text: option.Text})
+		}
+	}
+	q.Options = options
+	return q
+}
+
+func (q QuizQuestion) option(id int) (QuizOption, bool) {
+	for _, option := range q.Options {
+		if option.ID == id {
+			return option, true
+		}
+	}
+	return QuizOption{}, false
+}
+
+// SelectQuestions собирает вопросы попытки: из каждого банка берется Count случайных
+// вопросов в исходном порядке, затем при необходимости вопросы и варианты перемешиваются.
+// Элементы вопросов на упорядочивание перемешиваются всегда.
+func (s QuizSettings) SelectQuestions(banks map[int][]QuizQuestion, rnd *rand.Rand) []QuizQuestion {
+	var questions []QuizQuestion
+	seen := make(map[int]bool)
+	for _, source := range s.Sources {
+		bank := banks[source.BankID]
+		picked := make([]bool, len(bank))
+		if source.Count > 0 && source.Count < len(bank) {
+			for _, i := range rnd.Perm(len(bank))[:source.Count] {
+				picked[i] = true
+			}
+		} else {
+			for i := range picked {
+				picked[i] = true
+			}
+		}
+		for i, question := range bank {
+			if picked[i] && !seen[question.ID] {
+				seen[question.ID] = true
+				questions = append(questions, question)
+			}
+		}
+	}
+
+	if s.ShuffleQuestions {
+		rnd.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+	}
+	for i, question := range questions {
+		options := append([]QuizOption{}, question.Options...)
+		if s.ShuffleOptions || question.Type == QuestionOrdering {
+			rnd.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+		}
+		questions[i].Options = options
+	}
+	return questions
+}
+
+// Expired сообщает, истекло ли время попытки с учетом QuizGracePeriod
+func (a QuizAttempt) Expired(at time.Time) bool {
+	return a.ExpiresAt != nil && at.After(a.ExpiresAt.Add(QuizGracePeriod))
+}
+
+// Grade оценивает ответы попытки и переводит ее в итоговое состояние. Ответы, отправленные
+// после истечения времени, не засчитываются. Score - взвешенный процент верных ответов.
+func (a *QuizAttempt) Grade(answers []QuizAnswer, passingPercent float64, at time.Time) {
+	a.Status = QuizAttemptSubmitted
+	if a.Expired(at) {
+		a.Status = QuizAttemptExpired
+		answers = nil
+	}
+	submittedAt := at
+	a.SubmittedAt = &submittedAt
+
+	byQuestion := make(map[int]QuizAnswer, len(answers))
+	for _, answer := range answers {
+		byQuestion[answer.QuestionID] = answer
+	}
+
+	var earned, total float64
+	for i := range a.Questions {
+		question := &a.Questions[i]
+		grade := 0.0
+		if answer, ok := byQuestion[question.ID]; ok {
+			question.Answer = &answer
+			grade = question.Grade(answer)
+		}
+		question.Earned = &grade
+		earned += grade * question.Points
+		total += question.Points
+	}
+
+	a.Score = 0
+	if total > 0 {
+		a.Score = math.Round(earned/total*10000) / 100
+	}
+	a.IsPassed = a.Score >= passingPercent
+}
+
+// ForStudent возвращает попытку без верных ответов
+func (a QuizAttempt) ForStudent() QuizAttempt {
+	questions := make([]QuizAttemptQuestion, len(a.Questions))
+	for i, question := range a.Questions {
+		questions[i] = question
+		questions[i].QuizQuestion = question.QuizQuestion.ForStudent()
+	}
+	a.Questions = questions
+	return a
+}
+
+func normalizeAnswer(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
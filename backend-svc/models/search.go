@@ -0,0 +1,44 @@
+package models
+
+const (
+	SearchTypeCourse = "course"
+	SearchTypeTask   = "task"
+	SearchTypeUser   = "user"
+)
+
+// SearchQuery описывает запрос к единому поиску.
+// Права пользователя определяет обработчик: хранилище только выполняет ограничения.
+type SearchQuery struct {
+	Text             string
+	Types            []string
+	IncludeUsers     bool
+	IncludeSolutions bool
+}
+
+// WantsType сообщает, нужно ли искать среди сущностей указанного типа
+func (q SearchQuery) WantsType(resultType string) bool {
+	if resultType == SearchTypeUser && !q.IncludeUsers {
+		return false
+	}
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, t := range q.Types {
+		if t == resultType {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchResult - одна найденная сущность. Snippet содержит HTML-экранированный фрагмент
+// текста, в котором совпадения обернуты в <mark>...</mark>
+type SearchResult struct {
+	Type         string  `json:"type"`
+	ID           int     `json:"id"`
+	CourseID     int     `json:"course_id,omitempty"`
+	Title        string  `json:"title"`
+	Snippet      string  `json:"snippet"`
+	MatchedField string  `json:"matched_field"`
+	Score        float64 `json:"score"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Причины отметки пары ответов
+const (
+	// SimilarityKindAnswer - тексты ответов похожи после нормализации кода
+	SimilarityKindAnswer = "similar_answer"
+	// SimilarityKindTiming - похожий верный ответ сдан вскоре после ответа другого студента
+	SimilarityKindTiming = "rapid_submission"
+)
+
+// Статусы проверки отметки преподавателем
+const (
+	SimilarityStatusPending   = "pending"
+	SimilarityStatusConfirmed = "confirmed"
+	SimilarityStatusDismissed = "dismissed"
+)
+
+// SubmittedAnswer - сохраненный текст ответа, по которому ищутся совпадения
+type SubmittedAnswer struct {
+	SubmissionID int       `json:"submission_id"`
+	UserID       int       `json:"user_id"`
+	TaskID       int       `json:"task_id"`
+	CourseID     int       `json:"course_id"`
+	Answer       string    `json:"answer"`
+	IsCorrect    bool      `json:"is_correct"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+}
+
+// SimilarityFlag - пара ответов разных студентов на одну задачу, которую стоит проверить.
+// Submission - более поздний ответ пары, OtherSubmission - более ранний.
+type SimilarityFlag struct {
+	ID                int        `json:"id"`
+	CourseID          int        `json:"course_id"`
+	TaskID            int        `json:"task_id"`
+	TaskTitle         string     `json:"task_title"`
+	Kind              string     `json:"kind"`
+	Score             float64    `json:"score"`
+	TimeGapSeconds    int        `json:"time_gap_seconds"`
+	SubmissionID      int        `json:"submission_id"`
+	UserID            int        `json:"user_id"`
+	Username          string     `json:"username"`
+	Answer            string     `json:"answer"`
+	OtherSubmissionID int        `json:"other_submission_id"`
+	OtherUserID       int        `json:"other_user_id"`
+	OtherUsername     string     `json:"other_username"`
+	OtherAnswer       string     `json:"other_answer"`
+	Status            string     `json:"status"`
+	Note              string     `json:"note,omitempty"`
+	ReviewedBy        *int       `json:"reviewed_by,omitempty"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// SimilarityFlagFilter - отбор отметок для проверки
+type SimilarityFlagFilter struct {
+	// AssignmentID больше 0 оставляет задачи одного задания
+	AssignmentID int
+	Status       string
+	Kind         string
+}
+
+// SimilarityReview - решение преподавателя по отметке
+type SimilarityReview struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+}
+
+// IsValidSimilarityStatus сообщает, известен ли статус отметки
+func IsValidSimilarityStatus(status string) bool {
+	switch status {
+	case SimilarityStatusPending, SimilarityStatusConfirmed, SimilarityStatusDismissed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// TaskSkill - навык (категория CWE), который тренирует задача. Weight задает вклад
+// задачи в уровень навыка относительно других задач того же навыка.
+type TaskSkill struct {
+	Skill  string  `json:"skill" binding:"required"`
+	CWE    string  `json:"cwe,omitempty"`
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// TaskSkillsRequest - привязка задачи к навыкам и список задач, которые нужно решить до нее
+type TaskSkillsRequest struct {
+	Skills        []TaskSkill `json:"skills" binding:"required"`
+	Prerequisites []int       `json:"prerequisites"`
+}
+
+// courseCWE сопоставляет типы уязвимостей курсов с категориями CWE
+var courseCWE = map[string]string{
+	"sql injection":                     "CWE-89",
+	"xss":                               "CWE-79",
+	"cross-site scripting":              "CWE-79",
+	"csrf":                              "CWE-352",
+	"cross-site request forgery":        "CWE-352",
+	"command injection":                 "CWE-78",
+	"os command injection":              "CWE-78",
+	"path traversal":                    "CWE-22",
+	"ssrf":                              "CWE-918",
+	"server-side request forgery":       "CWE-918",
+	"xxe":                               "CWE-611",
+	"idor":                              "CWE-639",
+	"insecure deserialization":          "CWE-502",
+	"open redirect":                     "CWE-601",
+	"broken authentication":             "CWE-287",
+	"unrestricted file upload":          "CWE-434",
+	"ldap injection":                    "CWE-90",
+	"server-side template injection":    "CWE-1336",
+	"hardcoded credentials":             "CWE-798",
+	"missing authorization":             "CWE-862",
+	"sensitive data exposure":           "CWE-200",
+	"use of a broken crypto algorithm":  "CWE-327",
+	"improper certificate validation":   "CWE-295",
+	"race condition":                    "CWE-362",
+	"buffer overflow":                   "CWE-120",
+	"integer overflow":                  "CWE-190",
+	"cleartext transmission":            "CWE-319",
+	"improper input validation":         "CWE-20",
+	"incorrect permission assignment":   "CWE-732",
+	"uncontrolled resource consumption": "CWE-400",
+}
+
+// DefaultTaskSkill возвращает навык задачи без явной привязки: навыком считается
+// тип уязвимости курса, CWE определяется по известным названиям
+func DefaultTaskSkill(courseName string) TaskSkill {
+	return TaskSkill{
+		Skill:  courseName,
+		CWE:    courseCWE[strings.ToLower(strings.TrimSpace(courseName))],
+		Weight: 1,
+	}
+}
+
+// LearningTask - задача в снимке обучения пользователя
+type LearningTask struct {
+	Task
+	CourseName    string
+	Skills        []TaskSkill
+	Prerequisites []int
+	// DueAt - срок сдачи, если он назначен пользователю
+	DueAt *time.Time
+	// AverageTimeSeconds - среднее время работы над задачей среди решивших ее пользователей, 0 - нет данных
+	AverageTimeSeconds int
+}
+
+// LearningSnapshot - данные, по которым стратегии строят персональную траекторию обучения.
+// Хранилище собирает снимок целиком, стратегии не обращаются к хранилищу.
+type LearningSnapshot struct {
+	UserID      int
+	GeneratedAt time.Time
+	Tasks       []LearningTask
+	// Completed - время выполнения задач пользователем
+	Completed map[int]time.Time
+	Attempts  []SubmissionAttempt
+	Activity  []TaskActivitySummary
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// DateLayout - формат календарной даты в тепловой карте и серии дней
+const DateLayout = "2006-01-02"
+
+// DefaultTimezone используется, если пользователь не выбрал часовой пояс
+const DefaultTimezone = "UTC"
+
+// UserPreferences - личные настройки пользователя
+type UserPreferences struct {
+	// Timezone - часовой пояс IANA, по которому считаются дни активности
+	Timezone string `json:"timezone"`
+	// StreakReminders - присылать письмо, если серия дней вот-вот прервется
+	StreakReminders bool `json:"streak_reminders"`
+}
+
+// Location возвращает часовой пояс настроек; неизвестный пояс считается UTC
+func (p UserPreferences) Location() *time.Location {
+	if location, err := time.LoadLocation(p.Timezone); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
+// StreakReminderCandidate - пользователь, включивший напоминания о серии дней
+type StreakReminderCandidate struct {
+	UserID   int
+	Username string
+	Email    string
+	Timezone string
+	// RemindedOn - дата последнего напоминания в часовом поясе пользователя
+	RemindedOn string
+}
+
+// ActivityTimes - моменты выполнения задач и отправки ответов пользователя
+type ActivityTimes struct {
+	Completed []time.Time
+	Submitted []time.Time
+}
+
+// ActivityDay - активность за один день в часовом поясе пользователя
+type ActivityDay struct {
+	Date        string `json:"date"`
+	Completed   int    `json:"completed"`
+	Submissions int    `json:"submissions"`
+	Total       int    `json:"total"`
+}
+
+// StreakStats - серия дней подряд с выполненной задачей или отправленным ответом.
+// Текущая серия не прерывается, пока не закончился день после последнего дня активности.
+type StreakStats struct {
+	Current        int    `json:"current"`
+	Longest        int    `json:"longest"`
+	LastActiveDate string `json:"last_active_date,omitempty"`
+	ActiveToday    bool   `json:"active_today"`
+}
+
+// AtRisk сообщает, что серия прервется, если сегодня не будет активности
+func (s StreakStats) AtRisk() bool {
+	return s.Current > 0 && !s.ActiveToday
+}
+
+// ActivityHeatmap - активность по дням за период [From, To] включительно
+type ActivityHeatmap struct {
+	UserID   int           `json:"user_id"`
+	Timezone string        `json:"timezone"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Days     []ActivityDay `json:"days"`
+	Streak   StreakStats   `json:"streak"`
+}
+
+// LocalDate возвращает начало календарного дня момента t в часовом поясе location
+func LocalDate(t time.Time, location *time.Location) time.Time {
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+// BuildActivityDays раскладывает активность по дням периода [from, to]; дни без активности
+// тоже входят в результат, чтобы клиент мог нарисовать сетку без пропусков
+func BuildActivityDays(times ActivityTimes, from, to time.Time, location *time.Location) []ActivityDay {
+	from, to = LocalDate(from, location), LocalDate(to, location)
+	index := make(map[string]int)
+	days := []ActivityDay{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		index[day.Format(DateLayout)] = len(days)
+		days = append(days, ActivityDay{Date: day.Format(DateLayout)})
+	}
+
+	for _, at := range times.Completed {
+		if i, ok := index[LocalDate(at, location).Format(DateLayout)]; ok {
+			days[i].Completed++
+			days[i].Total++
+		}
+	}
+	for _, at := range times.Submitted {
+		if i, ok := index[LocalDate(at, location).Format(DateLayout)]; ok {
+			days[i].Submissions++
+			days[i].Total++
+		}
+	}
+	return days
+}
+
+// ComputeStreak считает текущую и самую длинную серию на момент now
+func ComputeStreak(times ActivityTimes, now time.Time, location *time.Location) StreakStats {
+	active := make(map[time.Time]bool)
+	for _, at := range append(append([]time.Time{}, times.Completed...), times.Submitted...) {
+		if !at.After(now) {
+			active[LocalDate(at, location)] = true
+		}
+	}
+	days := make([]time.Time, 0, len(active))
+	for day := range active {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var stats StreakStats
+	run := 0
+	for i, day := range days {
+		if i > 0 && day.Equal(days[i-1].AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > stats.Longest {
+			stats.Longest = run
+		}
+	}
+	if len(days) == 0 {
+		return stats
+	}
+
+	last := days[len(days)-1]
+	today := LocalDate(now, location)
+	stats.LastActiveDate = last.Format(DateLayout)
+	stats.ActiveToday = last.Equal(today)
+	if stats.ActiveToday || last.Equal(today.AddDate(0, 0, -1)) {
+		stats.Current = run
+	}
+	return stats
+}
@@ -0,0 +1,89 @@
+// Package notifications сохраняет уведомления пользователей в приложении и дублирует их
+// письмом, если пользователь включил письма для этого типа уведомлений.
+package notifications
+
+import (
+	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/mail"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+)
+
+// Mailer доставляет уведомление письмом
+type Mailer func(user models.User, notification models.Notification) error
+
+// EmailMailer отправляет уведомление письмом через пакет mail
+func EmailMailer(user models.User, notification models.Notification) error {
+	return mail.SendNotificationEmail(user.Email, mail.NotificationData{
+		Username: user.Username,
+		Title:    notification.Title,
+		Message:  notification.Message,
+	})
+}
+
+// BroadcastBatchSize - число уведомлений, которые Broadcast сохраняет одним запросом
+const BroadcastBatchSize = 500
+
+// Send сохраняет уведомление и, если пользователь этого хочет, отправляет его письмом.
+// Без mailer письма не отправляются. Ошибка письма не отменяет уведомление в приложении.
+func Send(store storage.Storage, mailer Mailer, notification models.Notification) (models.Notification, error) {
+	notification, err := store.CreateNotification(notification)
+	if err != nil {
+		return notification, fmt.Errorf("create notification: %w", err)
+	}
+	return notification, email(store, mailer, notification)
+}
+
+// Broadcast отправляет одинаковое уведомление каждому пользователю из userIDs и возвращает
+// созданные уведомления. Уведомления сохраняются пачками по BroadcastBatchSize; ошибка
+// пачки или письма записывается в лог и не прерывает рассылку остальным пользователям.
+func Broadcast(store storage.Storage, mailer Mailer, userIDs []int, notification models.Notification) []models.Notification {
+	var sent []models.Notification
+	for start := 0; start < len(userIDs); start += BroadcastBatchSize {
+		end := min(start+BroadcastBatchSize, len(userIDs))
+		batch := make([]models.Notification, 0, end-start)
+		for _, userID := range userIDs[start:end] {
+			notification.UserID = userID
+			batch = append(batch, notification)
+		}
+
+		created, err := store.CreateNotifications(batch)
+		if err != nil {
+			logger.Default().Error("%s notifications for users %v not created: %v", notification.Type, userIDs[start:end], err)
+			continue
+		}
+		for _, n := range created {
+			if err := email(store, mailer, n); err != nil {
+				logger.Default().Error("Notification %d email for user %d skipped: %v", n.ID, n.UserID, err)
+			}
+		}
+		sent = append(sent, created...)
+	}
+	return sent
+}
+
+// email отправляет сохраненное уведомление письмом, если пользователь включил письма
+// для его типа. Ошибка самого письма только записывается в лог.
+func email(store storage.Storage, mailer Mailer, notification models.Notification) error {
+	if mailer == nil {
+		return nil
+	}
+
+	prefs, err := store.GetNotificationPreferences(notification.UserID)
+	if err != nil {
+		return fmt.Errorf("get notification preferences: %w", err)
+	}
+	if !prefs.EmailEnabled(notification.Type) {
+		return nil
+	}
+
+	user, err := store.GetUserByID(notification.UserID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if err := mailer(user, notification); err != nil {
+		logger.Default().Error("Notification %d email for user %d not sent: %v", notification.ID, notification.UserID, err)
+	}
+	return nil
+}
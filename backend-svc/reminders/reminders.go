@@ -0,0 +1,112 @@
+// Package reminders периодически рассылает напоминания о сроках заданий и о сериях дней учебы
+package reminders
+
+import (
+	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/mail"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"time"
+)
+
+// Sender доставляет одно напоминание студенту
+type Sender func(reminder models.DeadlineReminder) error
+
+// EmailSender отправляет напоминание письмом через пакет mail
+func EmailSender(reminder models.DeadlineReminder) error {
+	return mail.SendDeadlineReminderEmail(reminder.Email, mail.DeadlineReminderData{
+		Username:        reminder.Username,
+		AssignmentTitle: reminder.AssignmentTitle,
+		CourseName:      reminder.CourseName,
+		DueAt:           reminder.DueAt,
+		RemainingTasks:  reminder.RemainingTasks,
+	})
+}
+
+// NotificationSender создает уведомление о сроке в приложении через deliver,
+// например handlers.SendNotification
+func NotificationSender(deliver func(models.Notification) (models.Notification, error)) Sender {
+	return func(reminder models.DeadlineReminder) error {
+		_, err := deliver(models.Notification{
+			UserID: reminder.UserID,
+			Type:   models.NotificationDeadline,
+			Title:  "Assignment deadline reminder: " + reminder.AssignmentTitle,
+			Message: fmt.Sprintf("The assignment \"%s\" in the course \"%s\" is due on %s. "+
+				"You still have %d unfinished task(s).",
+				reminder.AssignmentTitle, reminder.CourseName,
+				reminder.DueAt.UTC().Format("2006-01-02 15:04 MST"), reminder.RemainingTasks),
+			Payload: map[string]interface{}{
+				"assignment_id":   reminder.AssignmentID,
+				"course_id":       reminder.CourseID,
+				"due_at":          reminder.DueAt.UTC(),
+				"remaining_tasks": reminder.RemainingTasks,
+			},
+		})
+		return err
+	}
+}
+
+// SendDue отправляет напоминания о сроках, наступающих в течение window, и отмечает отправленные.
+// Неотправленные напоминания остаются в очереди до следующего запуска. Возвращает число отправленных.
+func SendDue(store storage.Storage, now time.Time, window time.Duration, send Sender) (int, error) {
+	reminders, err := store.GetPendingReminders(now, window)
+	if err != nil {
+		return 0, fmt.Errorf("get pending reminders: %w", err)
+	}
+
+	sent := 0
+	for _, reminder := range reminders {
+		if err := send(reminder); err != nil {
+			logger.Default().Error("Deadline reminder for user %d, assignment %d not sent: %v", reminder.UserID, reminder.AssignmentID, err)
+			continue
+		}
+		if err := store.MarkReminderSent(reminder, now); err != nil {
+			return sent, fmt.Errorf("mark reminder sent: %w", err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// Start запускает рассылку каждые interval. Возвращаемая функция останавливает рассылку.
+func Start(store storage.Storage, interval, window time.Duration, send Sender) (stop func()) {
+	return every(interval, "deadline", func(now time.Time) (int, error) {
+		return SendDue(store, now, window, send)
+	})
+}
+
+// StartStreakReminders запускает напоминания о сериях дней каждые interval.
+// Возвращаемая функция останавливает рассылку.
+func StartStreakReminders(store storage.Storage, interval time.Duration, send StreakSender) (stop func()) {
+	return every(interval, "streak", func(now time.Time) (int, error) {
+		return SendStreakReminders(store, now, send)
+	})
+}
+
+// every запускает рассылку kind сразу и затем каждые interval
+func every(interval time.Duration, kind string, run func(now time.Time) (int, error)) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			if sent, err := run(time.Now()); err != nil {
+				logger.Default().Error("%s reminders error: %v", kind, err)
+			} else if sent > 0 {
+				logger.Default().Info("Sent %d %s reminders", sent, kind)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -0,0 +1,64 @@
+package reminders
+
+import (
+	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/mail"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"time"
+)
+
+// StreakReminderHour - час по местному времени пользователя, начиная с которого
+// напоминают о серии без активности за сегодня
+const StreakReminderHour = 19
+
+// StreakSender доставляет напоминание о серии дней
+type StreakSender func(candidate models.StreakReminderCandidate, streak models.StreakStats) error
+
+// StreakEmailSender отправляет напоминание о серии письмом через пакет mail
+func StreakEmailSender(candidate models.StreakReminderCandidate, streak models.StreakStats) error {
+	return mail.SendStreakReminderEmail(candidate.Email, mail.StreakReminderData{
+		Username: candidate.Username,
+		Streak:   streak.Current,
+	})
+}
+
+// SendStreakReminders напоминает пользователям, включившим напоминания, что серия прервется,
+// если сегодня не будет активности. Напоминание отправляется не раньше StreakReminderHour
+// по местному времени и не чаще раза в день. Возвращает число отправленных.
+func SendStreakReminders(store storage.Storage, now time.Time, send StreakSender) (int, error) {
+	candidates, err := store.GetStreakReminderCandidates()
+	if err != nil {
+		return 0, fmt.Errorf("get streak reminder candidates: %w", err)
+	}
+
+	sent := 0
+	for _, candidate := range candidates {
+		location := models.UserPreferences{Timezone: candidate.Timezone}.Location()
+		local := now.In(location)
+		today := local.Format(models.DateLayout)
+		if local.Hour() < StreakReminderHour || candidate.RemindedOn == today {
+			continue
+		}
+
+		times, err := store.GetActivityTimes(candidate.UserID, time.Time{}, now)
+		if err != nil {
+			return sent, fmt.Errorf("get activity times: %w", err)
+		}
+		streak := models.ComputeStreak(times, now, location)
+		if !streak.AtRisk() {
+			continue
+		}
+
+		if err := send(candidate, streak); err != nil {
+			logger.Default().Error("Streak reminder for user %d not sent: %v", candidate.UserID, err)
+			continue
+		}
+		if err := store.MarkStreakReminderSent(candidate.UserID, today); err != nil {
+			return sent, fmt.Errorf("mark streak reminder sent: %w", err)
+		}
+		sent++
+	}
+	return sent, nil
+}
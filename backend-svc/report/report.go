@@ -0,0 +1,30 @@
+// Package report выгружает табличные отчеты в CSV и XLSX
+package report
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// Table - именованная таблица отчета. Первая строка Rows считается заголовком.
+type Table struct {
+	Name string
+	Rows [][]string
+}
+
+// WriteCSV записывает таблицы в CSV, разделяя их пустой строкой
+func WriteCSV(w io.Writer, tables ...Table) error {
+	writer := csv.NewWriter(w)
+	for i, table := range tables {
+		if i > 0 {
+			if err := writer.Write(nil); err != nil {
+				return err
+			}
+		}
+		if err := writer.WriteAll(table.Rows); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxSheetNameLength - ограничение Excel на длину имени листа
+const maxSheetNameLength = 31
+
+const contentTypesHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`
+
+const rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// WriteXLSX записывает таблицы в книгу XLSX, каждую на отдельный лист.
+// Ячейки сохраняются как строки (inlineStr), поэтому книге не нужны sharedStrings и стили.
+func WriteXLSX(w io.Writer, tables ...Table) error {
+	zw := zip.NewWriter(w)
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(contentTypesHeader)
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, table := range tables {
+		n := i + 1
+		fmt.Fprintf(&contentTypes,
+			`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(sheetName(table.Name, n)), n, n)
+		fmt.Fprintf(&workbookRels,
+			`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+
+		if err := writeZipFile(zw, fmt.Sprintf("xl/worksheets/sheet%d.xml", n), sheetXML(table.Rows)); err != nil {
+			return err
+		}
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+	}
+	for _, file := range files {
+		if err := writeZipFile(zw, file.name, file.content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name, content string) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if _, err := io.WriteString(f, content); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func sheetXML(rows [][]string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, value := range row {
+			fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`,
+				columnName(c), r+1, escapeXML(value))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// columnName переводит номер колонки с нуля в буквенное обозначение: 0 - A, 26 - AA
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// sheetName убирает из имени листа запрещенные Excel символы и обрезает его до допустимой длины
+func sheetName(name string, n int) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return fmt.Sprintf("Sheet%d", n)
+	}
+	if runes := []rune(name); len(runes) > maxSheetNameLength {
+		name = string(runes[:maxSheetNameLength])
+	}
+	return name
+}
+
+func escapeXML(value string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
@@ -0,0 +1,163 @@
+// Package similarity ищет ответы, которые студенты могли списать друг у друга. Ответы на
+// одну задачу сравниваются попарно после нормализации кода (Tokenize), а верные ответы,
+// сданные вскоре после похожего ответа другого студента, отмечаются отдельно. Проверка
+// идет фоновой задачей по сохраненным ответам и не замедляет сдачу.
+package similarity
+
+import (
+	"fmt"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"sort"
+	"time"
+)
+
+// Config - пороги проверки
+type Config struct {
+	// Threshold - сходство, с которого пара ответов отмечается как похожая
+	Threshold float64
+	// MinTokens - ответы короче не сравниваются по тексту: короткие верные ответы
+	// совпадают у всех, кто решил задачу
+	MinTokens int
+	// TimingWindow - верный ответ, сданный в пределах окна после ответа другого студента,
+	// отмечается, если ответы похожи не меньше чем на TimingThreshold
+	TimingWindow    time.Duration
+	TimingThreshold float64
+	// BatchSize - сколько новых ответов проверяется за один запуск
+	BatchSize int
+	// PeerLimit - со сколькими последними ответами на ту же задачу сравнивается новый
+	PeerLimit int
+}
+
+// DefaultConfig - пороги по умолчанию
+var DefaultConfig = Config{
+	Threshold:       0.85,
+	MinTokens:       12,
+	TimingWindow:    2 * time.Minute,
+	TimingThreshold: 0.6,
+	BatchSize:       200,
+	PeerLimit:       1000,
+}
+
+// Match сравнивает ответ с более ранними ответами других студентов на ту же задачу и
+// возвращает отметки, по одной на студента и причину. Похожими по тексту не считаются
+// пары, где оба ответа совпадают с эталонным решением: такие ответы ожидаемы, их
+// списывание выдает только время сдачи.
+func Match(answer models.SubmittedAnswer, peers []models.SubmittedAnswer, solution string, config Config) []models.SimilarityFlag {
+	tokens := Tokenize(answer.Answer)
+	reference := Tokenize(solution)
+	answerIsReference := len(reference) > 0 && Similarity(tokens, reference) >= config.Threshold
+
+	best := make(map[string]models.SimilarityFlag)
+	keep := func(flag models.SimilarityFlag) {
+		key := fmt.Sprintf("%s:%d", flag.Kind, flag.OtherUserID)
+		if current, ok := best[key]; !ok || flag.Score > current.Score {
+			best[key] = flag
+		}
+	}
+
+	for _, peer := range peers {
+		if peer.UserID == answer.UserID || peer.TaskID != answer.TaskID || peer.SubmissionID == answer.SubmissionID {
+			continue
+		}
+		peerTokens := Tokenize(peer.Answer)
+		score := Similarity(tokens, peerTokens)
+		gap := answer.SubmittedAt.Sub(peer.SubmittedAt)
+		flag := models.SimilarityFlag{
+			CourseID:          answer.CourseID,
+			TaskID:            answer.TaskID,
+			Score:             score,
+			TimeGapSeconds:    int(gap / time.Second),
+			SubmissionID:      answer.SubmissionID,
+			UserID:            answer.UserID,
+			OtherSubmissionID: peer.SubmissionID,
+			OtherUserID:       peer.UserID,
+			Status:            models.SimilarityStatusPending,
+		}
+
+		if len(tokens) >= config.MinTokens && len(peerTokens) >= config.MinTokens && score >= config.Threshold {
+			peerIsReference := answerIsReference && Similarity(peerTokens, reference) >= config.Threshold
+			if !peerIsReference {
+				flag.Kind = models.SimilarityKindAnswer
+				keep(flag)
+			}
+		}
+		if answer.IsCorrect && peer.IsCorrect && gap >= 0 && gap <= config.TimingWindow && score >= config.TimingThreshold {
+			flag.Kind = models.SimilarityKindTiming
+			keep(flag)
+		}
+	}
+
+	flags := make([]models.SimilarityFlag, 0, len(best))
+	for _, flag := range best {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		if flags[i].OtherSubmissionID != flags[j].OtherSubmissionID {
+			return flags[i].OtherSubmissionID < flags[j].OtherSubmissionID
+		}
+		return flags[i].Kind < flags[j].Kind
+	})
+	return flags
+}
+
+// Check проверяет ответы, сохраненные с прошлого запуска, и записывает отметки.
+// Возвращает число новых отметок.
+func Check(store storage.Storage, config Config) (int, error) {
+	answers, err := store.GetUncheckedAnswers(config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("get unchecked answers: %w", err)
+	}
+
+	solutions := make(map[int]string)
+	flagged := 0
+	for _, answer := range answers {
+		solution, ok := solutions[answer.TaskID]
+		if !ok {
+			// Без эталона сравниваются только ответы между собой
+			if task, err := store.GetTaskByID(answer.CourseID, answer.TaskID); err == nil {
+				solution = task.Solution
+			}
+			solutions[answer.TaskID] = solution
+		}
+
+		peers, err := store.GetTaskAnswers(answer.TaskID, answer.SubmissionID, config.PeerLimit)
+		if err != nil {
+			return flagged, fmt.Errorf("get answers to task %d: %w", answer.TaskID, err)
+		}
+		flags := Match(answer, peers, solution, config)
+		if err := store.RecordSimilarityCheck(answer.SubmissionID, flags); err != nil {
+			return flagged, fmt.Errorf("record check of submission %d: %w", answer.SubmissionID, err)
+		}
+		flagged += len(flags)
+	}
+	return flagged, nil
+}
+
+// Start запускает проверку сразу и затем каждые interval. Возвращаемая функция останавливает ее.
+func Start(store storage.Storage, interval time.Duration, config Config) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			if flagged, err := Check(store, config); err != nil {
+				logger.Default().Error("Similarity check error: %v", err)
+			} else if flagged > 0 {
+				logger.Default().Info("Similarity check flagged %d submission pairs", flagged)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
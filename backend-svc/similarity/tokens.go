@@ -0,0 +1,136 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Токены, которыми заменяются идентификаторы, числа и границы строк
+const (
+	TokenIdentifier = "ID"
+	TokenNumber     = "NUM"
+	TokenString     = "STR"
+)
+
+// ShingleSize - длина последовательностей токенов, из которых собирается отпечаток ответа
+const ShingleSize = 4
+
+// keywords сохраняются при нормализации: без них у всех ответов одинаковая структура
+// из идентификаторов. Набор общий для языков, на которых обычно отвечают на задачи.
+var keywords = map[string]bool{
+	"if": true, "else": true, "elif": true, "for": true, "while": true, "do": true, "switch": true,
+	"case": true, "break": true, "continue": true, "return": true, "func": true, "function": true,
+	"def": true, "class": true, "var": true, "let": true, "const": true, "new": true, "try": true,
+	"catch": true, "except": true, "finally": true, "throw": true, "raise": true, "import": true,
+	"from": true, "as": true, "with": true, "lambda": true, "async": true, "await": true,
+	"public": true, "private": true, "static": true, "void": true, "this": true, "self": true,
+	"null": true, "nil": true, "none": true, "true": true, "false": true, "and": true, "or": true,
+	"not": true, "in": true, "is": true, "echo": true, "print": true,
+	"select": true, "insert": true, "update": true, "delete": true, "where": true, "into": true,
+	"values": true, "set": true, "order": true, "by": true, "group": true, "join": true, "on": true,
+	"union": true, "like": true, "limit": true,
+}
+
+// Tokenize нормализует ответ: удаляет комментарии и пробелы, заменяет идентификаторы и числа
+// общими токенами и приводит ключевые слова к нижнему регистру. Текст строковых литералов
+// разбирается так же, как код, потому что в ответах на задачи в строках часто лежат запросы.
+// Переименование переменных и переформатирование кода не меняют результат.
+func Tokenize(answer string) []string {
+	runes := []rune(answer)
+	tokens := []string{}
+	var quote rune
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case quote == 0 && r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			i = skipLine(runes, i)
+		case quote == 0 && r == '-' && i+2 < len(runes) && runes[i+1] == '-' && unicode.IsSpace(runes[i+2]):
+			i = skipLine(runes, i)
+		case quote == 0 && r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '"' || r == '\'' || r == '`':
+			if quote == 0 {
+				quote = r
+			} else if quote == r {
+				quote = 0
+			} else {
+				i++
+				continue
+			}
+			tokens = append(tokens, TokenString)
+			i++
+		case r == '\\' && quote != 0:
+			i += 2
+		case isIdentifierStart(r):
+			start := i
+			for i < len(runes) && isIdentifierPart(runes[i]) {
+				i++
+			}
+			word := strings.ToLower(string(runes[start:i]))
+			if keywords[word] {
+				tokens = append(tokens, word)
+			} else {
+				tokens = append(tokens, TokenIdentifier)
+			}
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, TokenNumber)
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+// Similarity возвращает долю общих последовательностей токенов двух ответов (коэффициент
+// Жаккара по отпечаткам) от 0 до 1. Ответы короче ShingleSize сравниваются целиком.
+func Similarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	left, right := shingles(a), shingles(b)
+	common := 0
+	for shingle := range left {
+		if right[shingle] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(left)+len(right)-common)
+}
+
+func shingles(tokens []string) map[string]bool {
+	set := make(map[string]bool)
+	if len(tokens) < ShingleSize {
+		set[strings.Join(tokens, " ")] = true
+		return set
+	}
+	for i := 0; i+ShingleSize <= len(tokens); i++ {
+		set[strings.Join(tokens[i:i+ShingleSize], " ")] = true
+	}
+	return set
+}
+
+func skipLine(runes []rune, i int) int {
+	for i < len(runes) && runes[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+func isIdentifierStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '$'
+}
+
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || unicode.IsDigit(r)
+}
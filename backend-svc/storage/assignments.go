@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"lmsmodule/backend-svc/models"
+	"math"
+	"time"
+)
+
+// gradeSubmission оценивает попытку сдачи с учетом срока задания, если он есть:
+// до открытия и после закрытия задания ответ не принимается, просроченный
+// верный ответ получает балл со штрафом
+func gradeSubmission(task models.Task, submission models.TaskSubmission, deadlines []models.TaskDeadline) (models.TaskSubmissionResponse, error) {
+	response := models.TaskSubmissionResponse{
+		TaskID:      submission.TaskID,
+		Status:      "completed",
+		SubmittedAt: submission.SubmittedAt,
+		IsCorrect:   submission.Answer == task.Solution,
+	}
+	if task.Type == models.TaskTypeQuiz {
+		return response, ErrQuizTask
+	}
+
+	if err := applyDeadline(&response, deadlines, submission.SubmittedAt); err != nil {
+		return response, err
+	}
+
+	if response.IsCorrect {
+		response.Score = penalizedScore(float64(task.Points), response.Penalty)
+	}
+	return response, nil
+}
+
+// requiresGradedAnswer сообщает, что задачу засчитывает только оцененный ответ: квиз
+// или задача задания, где действуют сроки, штрафы и лимиты попыток
+func requiresGradedAnswer(taskType string, deadlines []models.TaskDeadline) bool {
+	return taskType == models.TaskTypeQuiz || len(deadlines) > 0
+}
+
+// applyDeadline проверяет, что задание открыто в момент at, и заполняет штраф за просрочку
+func applyDeadline(response *models.TaskSubmissionResponse, deadlines []models.TaskDeadline, at time.Time) error {
+	if len(deadlines) == 0 {
+		return nil
+	}
+
+	deadline := deadlines[0]
+	switch deadline.Status(at) {
+	case models.DeadlineNotOpen:
+		return ErrAssignmentNotOpen
+	case models.DeadlineClosed:
+		return ErrAssignmentClosed
+	case models.DeadlineLate:
+		response.IsLate = true
+		response.Penalty = deadline.Penalty(at)
+	}
+	dueAt := deadline.DueAt
+	response.DueDate = &dueAt
+	return nil
+}
+
+// penalizedScore снижает балл на штраф в процентах и округляет до сотых
+func penalizedScore(points, penalty float64) float64 {
+	score := points * (100 - penalty) / 100
+	return math.Round(score*100) / 100
+}
+
+// submissionTime возвращает время попытки, по умолчанию - текущее
+func submissionTime(submission models.TaskSubmission) time.Time {
+	if submission.SubmittedAt.IsZero() {
+		return time.Now()
+	}
+	return submission.SubmittedAt
+}
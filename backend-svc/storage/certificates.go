@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"lmsmodule/backend-svc/models"
+	"math"
+	"time"
+)
+
+// taskResult - результат студента по одной задаче курса
+type taskResult struct {
+	CourseID    int
+	CourseTitle string
+	Points      int
+	CompletedAt *time.Time
+	BestScore   *float64
+}
+
+// courseCompletions сводит результаты по задачам в прохождение курсов. В сводку попадают
+// только курсы, в которых студент выполнил задачу или отправил ответ. Балл задачи - лучший
+// балл попыток (со штрафами за просрочку), а для задачи, выполненной без попыток, - полный.
+func courseCompletions(results []taskResult) []models.CourseCompletion {
+	var completions []models.CourseCompletion
+	index := make(map[int]int)
+	earned := make(map[int]float64)
+	total := make(map[int]int)
+	started := make(map[int]bool)
+
+	for _, result := range results {
+		i, exists := index[result.CourseID]
+		if !exists {
+			i = len(completions)
+			index[result.CourseID] = i
+			completions = append(completions, models.CourseCompletion{CourseID: result.CourseID, CourseTitle: result.CourseTitle})
+		}
+		completion := &completions[i]
+		completion.TasksCount++
+		total[result.CourseID] += result.Points
+
+		switch {
+		case result.BestScore != nil:
+			earned[result.CourseID] += math.Min(*result.BestScore, float64(result.Points))
+			started[result.CourseID] = true
+		case result.CompletedAt != nil:
+			earned[result.CourseID] += float64(result.Points)
+		}
+
+		if result.CompletedAt != nil {
+			completion.CompletedTasks++
+			started[result.CourseID] = true
+			if completion.CompletedAt == nil || result.CompletedAt.After(*completion.CompletedAt) {
+				completedAt := *result.CompletedAt
+				completion.CompletedAt = &completedAt
+			}
+		}
+	}
+
+	filtered := completions[:0]
+	for _, completion := range completions {
+		if !started[completion.CourseID] {
+			continue
+		}
+		completion.IsCompleted = completion.CompletedTasks == completion.TasksCount
+		if !completion.IsCompleted {
+			completion.CompletedAt = nil
+		}
+		if points := total[completion.CourseID]; points > 0 {
+			completion.Score = math.Round(earned[completion.CourseID]/float64(points)*10000) / 100
+		} else if completion.IsCompleted {
+			completion.Score = 100
+		}
+		filtered = append(filtered, completion)
+	}
+	return filtered
+}
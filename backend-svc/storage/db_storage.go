@@ -3,9 +3,13 @@ package storage
 import (
 	"crypto/subtle"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/go-sql-driver/mysql"
 	"lmsmodule/backend-svc/models"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -168,7 +172,7 @@ func (s *DBStorage) GetUserByID(userID int) (models.User, error) {
 		user.LastLogin = lastLogin.Time
 	}
 
-	courses, err := s.GetCourses()
+	courses, _, err := s.GetCourses(models.ListParams{})
 	if err != nil {
 		return models.User{}, fmt.Errorf("get courses: %w", err)
 	}
@@ -528,81 +532,88 @@ func (s *DBStorage) IsAdmin(userID int) (bool, error) {
 	return isAdmin, nil
 }
 
-func (s *DBStorage) GetAllUsers() ([]models.User, error) {
-	stmt, err := s.DB.Prepare(`
-        SELECT id, username, password_hash, email, full_name, profile_image, totp_secret, 
-               is_2fa_enabled, is_admin, is_active, is_teacher, last_login 
-        FROM users
-        WHERE is_deleted = FALSE
-    `)
-	if err != nil {
-		return nil, err
-	}
-	defer stmt.Close()
+var userSortColumns = map[string]string{
+	"id":         "id",
+	"username":   "username",
+	"email":      "email",
+	"full_name":  "full_name",
+	"created_at": "created_at",
+	"last_login": "last_login",
+}
 
-	rows, err := stmt.Query()
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (s *DBStorage) GetAllUsers(params models.ListParams) ([]models.User, int, error) {
+	return s.listUsers(nil, nil, params)
+}
 
-	var users []models.User
-	for rows.Next() {
-		var user models.User
-		var profileImage sql.NullString
-		var lastLogin sql.NullTime
+func (s *DBStorage) GetUsersByRole(isAdmin bool, params models.ListParams) ([]models.User, int, error) {
+	return s.listUsers([]string{"is_admin = ?"}, []interface{}{isAdmin}, params)
+}
 
-		err := rows.Scan(
-			&user.ID,
-			&user.Username,
-			&user.PasswordHash,
-			&user.Email,
-			&user.FullName,
-			&profileImage,
-			&user.TOTPSecret,
-			&user.Is2FAEnabled,
-			&user.IsAdmin,
-			&user.IsActive,
-			&user.IsTeacher,
-			&lastLogin,
-		)
-		if err != nil {
-			return nil, err
-		}
+func (s *DBStorage) SearchUsers(query string, params models.ListParams) ([]models.User, int, error) {
+	searchQuery := "%" + query + "%"
 
-		if profileImage.Valid {
-			user.ProfileImage = profileImage.String
-		}
+	return s.listUsers(
+		[]string{"(username LIKE ? OR email LIKE ? OR full_name LIKE ?)"},
+		[]interface{}{searchQuery, searchQuery, searchQuery},
+		params,
+	)
+}
 
-		if lastLogin.Valid {
-			user.LastLogin = lastLogin.Time
-		}
+// listUsers выбирает неудалённых пользователей с дополнительными условиями,
+// применяя к выборке фильтры, сортировку и пагинацию из ListParams
+func (s *DBStorage) listUsers(conditions []string, args []interface{}, params models.ListParams) ([]models.User, int, error) {
+	conditions = append([]string{"is_deleted = FALSE"}, conditions...)
 
-		users = append(users, user)
+	if params.IsActive != nil {
+		conditions = append(conditions, "is_active = ?")
+		args = append(args, *params.IsActive)
+	}
+	if params.IsTeacher != nil {
+		conditions = append(conditions, "is_teacher = ?")
+		args = append(args, *params.IsTeacher)
+	}
+	if params.IsAdmin != nil {
+		conditions = append(conditions, "is_admin = ?")
+		args = append(args, *params.IsAdmin)
+	}
+	if !params.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, params.From)
+	}
+	if !params.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, params.To)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	var total int
+	countQuery := "SELECT COUNT(*) FROM users WHERE " + strings.Join(conditions, " AND ")
+	if err := s.DB.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
 	}
 
-	return users, nil
-}
+	if params.IsCursor() {
+		conditions = append(conditions, "id > ?")
+		args = append(args, params.Cursor)
+	}
 
-func (s *DBStorage) GetUsersByRole(isAdmin bool) ([]models.User, error) {
-	stmt, err := s.DB.Prepare(`
+	query := `
         SELECT id, username, password_hash, email, full_name, profile_image, totp_secret, 
-               is_2fa_enabled, is_admin, is_active, is_teacher, last_login 
+               is_2fa_enabled, is_admin, is_active, is_teacher, last_login, created_at 
         FROM users
-        WHERE is_admin = ? AND is_deleted = FALSE
-    `)
+        WHERE ` + strings.Join(conditions, " AND ") +
+		orderByClause(params, userSortColumns, "id ASC", "id") +
+		limitClause(params)
+	args = append(args, limitArgs(params)...)
+
+	stmt, err := s.DB.Prepare(query)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(isAdmin)
+	rows, err := stmt.Query(args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -610,7 +621,7 @@ func (s *DBStorage) GetUsersByRole(isAdmin bool) ([]models.User, error) {
 	for rows.Next() {
 		var user models.User
 		var profileImage sql.NullString
-		var lastLogin sql.NullTime
+		var lastLogin, createdAt sql.NullTime
 
 		err := rows.Scan(
 			&user.ID,
@@ -625,9 +636,10 @@ func (s *DBStorage) GetUsersByRole(isAdmin bool) ([]models.User, error) {
 			&user.IsActive,
 			&user.IsTeacher,
 			&lastLogin,
+			&createdAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		if profileImage.Valid {
@@ -638,76 +650,71 @@ func (s *DBStorage) GetUsersByRole(isAdmin bool) ([]models.User, error) {
 			user.LastLogin = lastLogin.Time
 		}
 
+		if createdAt.Valid {
+			user.CreatedAt = createdAt.Time
+		}
+
 		users = append(users, user)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return users, nil
+	return users, total, nil
 }
 
-func (s *DBStorage) SearchUsers(query string) ([]models.User, error) {
-	searchQuery := "%" + query + "%"
+// orderByClause строит ORDER BY по разрешённым колонкам. В режиме курсора
+// сортировка всегда идёт по ключевой колонке, иначе она используется для стабильного порядка.
+func orderByClause(params models.ListParams, columns map[string]string, defaultOrder, keyColumn string) string {
+	if params.IsCursor() {
+		return " ORDER BY " + keyColumn + " ASC"
+	}
 
-	stmt, err := s.DB.Prepare(`
-        SELECT id, username, password_hash, email, full_name, profile_image, totp_secret, 
-               is_2fa_enabled, is_admin, is_active, is_teacher, last_login 
-        FROM users
-        WHERE (username LIKE ? OR email LIKE ? OR full_name LIKE ?) AND is_deleted = FALSE
-    `)
-	if err != nil {
-		return nil, err
+	column, ok := columns[params.SortBy]
+	if !ok {
+		return " ORDER BY " + defaultOrder
 	}
-	defer stmt.Close()
 
-	rows, err := stmt.Query(searchQuery, searchQuery, searchQuery)
-	if err != nil {
-		return nil, err
+	direction := "ASC"
+	if params.SortDesc {
+		direction = "DESC"
 	}
-	defer rows.Close()
 
-	var users []models.User
-	for rows.Next() {
-		var user models.User
-		var profileImage sql.NullString
-		var lastLogin sql.NullTime
+	return fmt.Sprintf(" ORDER BY %s %s, %s ASC", column, direction, keyColumn)
+}
 
-		err := rows.Scan(
-			&user.ID,
-			&user.Username,
-			&user.PasswordHash,
-			&user.Email,
-			&user.FullName,
-			&profileImage,
-			&user.TOTPSecret,
-			&user.Is2FAEnabled,
-			&user.IsAdmin,
-			&user.IsActive,
-			&user.IsTeacher,
-			&lastLogin,
-		)
-		if err != nil {
-			return nil, err
-		}
+func limitClause(params models.ListParams) string {
+	if params.Limit <= 0 {
+		return ""
+	}
+	return " LIMIT ? OFFSET ?"
+}
 
-		if profileImage.Valid {
-			user.ProfileImage = profileImage.String
-		}
+func limitArgs(params models.ListParams) []interface{} {
+	if params.Limit <= 0 {
+		return nil
+	}
+	return []interface{}{params.Limit, params.Offset()}
+}
 
-		if lastLogin.Valid {
-			user.LastLogin = lastLogin.Time
-		}
+// pageBounds возвращает границы среза для страницы уже отфильтрованной выборки
+func pageBounds(count int, params models.ListParams) (int, int) {
+	if params.Limit <= 0 {
+		return 0, count
+	}
 
-		users = append(users, user)
+	start := params.Offset()
+	if start > count {
+		start = count
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	end := start + params.Limit
+	if end > count {
+		end = count
 	}
 
-	return users, nil
+	return start, end
 }
 
 func (s *DBStorage) UpdateUserStatus(userID int, isActive bool) error {
@@ -788,22 +795,45 @@ var (
 	ErrCourseNotFound = errors.New("course not found")
 )
 
-func (s *DBStorage) GetCourses() ([]models.Course, error) {
-	stmt, err := s.DB.Prepare(`
+var courseSortColumns = map[string]string{
+	"id":                 "c.id",
+	"vulnerability_type": "c.vulnerability_type",
+	"tasks_count":        "tasks_count",
+}
+
+func (s *DBStorage) GetCourses(params models.ListParams) ([]models.Course, int, error) {
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM courses").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count courses: %w", err)
+	}
+
+	where := ""
+	var args []interface{}
+	if params.IsCursor() {
+		where = "WHERE c.id > ?"
+		args = append(args, params.Cursor)
+	}
+
+	query := `
 		SELECT c.id, c.vulnerability_type, 
 			   COUNT(t.id) as tasks_count, c.description
 		FROM courses c
 		LEFT JOIN tasks t ON c.id = t.course_id
-		GROUP BY c.id
-	`)
+		` + where + `
+		GROUP BY c.id` +
+		orderByClause(params, courseSortColumns, "c.id ASC", "c.id") +
+		limitClause(params)
+	args = append(args, limitArgs(params)...)
+
+	stmt, err := s.DB.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("prepare statement: %w", err)
+		return nil, 0, fmt.Errorf("prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query()
+	rows, err := stmt.Query(args...)
 	if err != nil {
-		return nil, fmt.Errorf("execute query: %w", err)
+		return nil, 0, fmt.Errorf("execute query: %w", err)
 	}
 	defer rows.Close()
 
@@ -816,16 +846,16 @@ func (s *DBStorage) GetCourses() ([]models.Course, error) {
 			&course.TasksCount,
 			&course.Description,
 		); err != nil {
-			return nil, fmt.Errorf("scan row: %w", err)
+			return nil, 0, fmt.Errorf("scan row: %w", err)
 		}
 		courses = append(courses, course)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate rows: %w", err)
+		return nil, 0, fmt.Errorf("iterate rows: %w", err)
 	}
 
-	return courses, nil
+	return courses, total, nil
 }
 
 func (s *DBStorage) GetCourseByID(id int) (models.Course, error) {
@@ -921,7 +951,9 @@ func (s *DBStorage) GetCourseByID(id int) (models.Course, error) {
 // ****** МЕТОДЫ ДЛЯ РАБОТЫ С ЗАДАНИЯМИ И ПРОГРЕССОМ ******
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskNotFound         = errors.New("task not found")
+	ErrPrerequisiteCycle    = errors.New("task prerequisites form a cycle")
+	ErrGradedAnswerRequired = errors.New("task is completed only by a graded answer")
 )
 
 func (s *DBStorage) GetUserProgress(userID int) (models.UserProgress, error) {
@@ -969,7 +1001,7 @@ func (s *DBStorage) GetUserProgress(userID int) (models.UserProgress, error) {
 func (s *DBStorage) GetTaskByID(courseID, taskID int) (models.Task, error) {
 	stmt, err := s.DB.Prepare(`
 		SELECT 
-			id, course_id, title, description, difficulty, task_order, points, content, solution
+			id, course_id, title, description, difficulty, task_order, points, type, content, solution
 		FROM tasks
 		WHERE id = ? AND course_id = ?
 	`)
@@ -987,17 +1019,24 @@ func (s *DBStorage) GetTaskByID(courseID, taskID int) (models.Task, error) {
 		&task.Difficulty,
 		&task.Order,
 		&task.Points,
+		&task.Type,
 		&task.Content,
 		&task.Solution,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return models.Task{}, errors.New("task not found")
+			return models.Task{}, ErrTaskNotFound
 		}
 		return models.Task{}, fmt.Errorf("query task: %w", err)
 	}
 
+	if task.Type == models.TaskTypeQuiz {
+		if task.Quiz, err = s.quizSettings(task.ID); err != nil {
+			return models.Task{}, err
+		}
+	}
+
 	return task, nil
 }
 
@@ -1037,23 +1076,60 @@ func (s *DBStorage) CompleteTask(userID, taskID int) error {
 	return nil
 }
 
+// CompleteTaskWithoutAnswer засчитывает задачу по запросу студента. Квиз и задачу задания
+// засчитывает только оцененный ответ, поэтому без него возвращается ErrGradedAnswerRequired.
+func (s *DBStorage) CompleteTaskWithoutAnswer(userID, taskID int) error {
+	var taskType string
+	err := s.DB.QueryRow("SELECT type FROM tasks WHERE id = ?", taskID).Scan(&taskType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTaskNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("query task: %w", err)
+	}
+
+	deadlines, err := s.userDeadlines(userID, taskID)
+	if err != nil {
+		return fmt.Errorf("get deadline: %w", err)
+	}
+	if requiresGradedAnswer(taskType, deadlines) {
+		// Верный ответ и пройденный квиз уже засчитали задачу
+		var completed bool
+		err := s.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM user_progress WHERE user_id = ? AND task_id = ?)", userID, taskID).Scan(&completed)
+		if err != nil {
+			return fmt.Errorf("check progress: %w", err)
+		}
+		if !completed {
+			return ErrGradedAnswerRequired
+		}
+	}
+
+	return s.CompleteTask(userID, taskID)
+}
+
 func (s *DBStorage) SubmitTaskAnswer(submission models.TaskSubmission) (models.TaskSubmissionResponse, error) {
 	task, err := s.GetTaskByID(submission.CourseID, submission.TaskID)
 	if err != nil {
 		return models.TaskSubmissionResponse{}, fmt.Errorf("get task: %w", err)
 	}
 
-	isCorrect := submission.Answer == task.Solution
-	response := models.TaskSubmissionResponse{
-		SubmissionID: 0,
-		TaskID:       submission.TaskID,
-		Status:       "completed",
-		SubmittedAt:  submission.SubmittedAt,
-		Message:      "Solution submitted",
-		IsCorrect:    isCorrect,
+	deadlines, err := s.userDeadlines(submission.UserID, task.ID)
+	if err != nil {
+		return models.TaskSubmissionResponse{}, fmt.Errorf("get deadline: %w", err)
+	}
+
+	submission.SubmittedAt = submissionTime(submission)
+	response, err := gradeSubmission(task, submission, deadlines)
+	if err != nil {
+		return response, err
 	}
 
-	if isCorrect {
+	response.SubmissionID, err = insertSubmission(s.DB, submission.UserID, task, response, submission.Answer)
+	if err != nil {
+		return models.TaskSubmissionResponse{}, err
+	}
+
+	if response.IsCorrect {
 		err = s.CompleteTask(submission.UserID, submission.TaskID)
 		if err != nil {
 			return response, fmt.Errorf("complete task: %w", err)
@@ -1066,24 +1142,92 @@ func (s *DBStorage) SubmitTaskAnswer(submission models.TaskSubmission) (models.T
 	return response, nil
 }
 
-func (s *DBStorage) GetUserSubmissions(userID int) ([]models.TaskSubmissionDetails, error) {
-	stmt, err := s.DB.Prepare(`
-		SELECT 
-			t.id, t.title, t.course_id, c.vulnerability_type, up.created_at
+// execer - общее для *sql.DB и *sql.Tx выполнение запросов
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertSubmission сохраняет оцененную попытку сдачи и возвращает ее ID. Текст ответа
+// хранится для проверки на списывание; у квизов текста нет.
+func insertSubmission(db execer, userID int, task models.Task, response models.TaskSubmissionResponse, answer string) (int, error) {
+	var answerText interface{}
+	if answer != "" {
+		answerText = answer
+	}
+	result, err := db.Exec(`
+		INSERT INTO task_submissions (user_id, task_id, course_id, is_correct, score, is_late, penalty_percent, submitted_at, answer)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		userID, task.ID, task.CourseID, response.IsCorrect, response.Score,
+		response.IsLate, response.Penalty, response.SubmittedAt.UTC(), answerText,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("save submission attempt: %w", err)
+	}
+	submissionID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("get submission id: %w", err)
+	}
+	return int(submissionID), nil
+}
+
+var submissionSortColumns = map[string]string{
+	"submitted_at": "up.completed_at",
+	"task_id":      "t.id",
+	"task_title":   "t.title",
+	"course_id":    "t.course_id",
+}
+
+func (s *DBStorage) GetUserSubmissions(userID int, params models.ListParams) ([]models.TaskSubmissionDetails, int, error) {
+	conditions := []string{"up.user_id = ?"}
+	args := []interface{}{userID}
+
+	if params.CourseID > 0 {
+		conditions = append(conditions, "t.course_id = ?")
+		args = append(args, params.CourseID)
+	}
+	if !params.From.IsZero() {
+		conditions = append(conditions, "up.completed_at >= ?")
+		args = append(args, params.From)
+	}
+	if !params.To.IsZero() {
+		conditions = append(conditions, "up.completed_at < ?")
+		args = append(args, params.To)
+	}
+
+	from := `
 		FROM user_progress up
 		JOIN tasks t ON up.task_id = t.id
 		JOIN courses c ON t.course_id = c.id
-		WHERE up.user_id = ?
-		ORDER BY up.created_at DESC
-	`)
+		WHERE `
+
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*)"+from+strings.Join(conditions, " AND "), args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count submissions: %w", err)
+	}
+
+	if params.IsCursor() {
+		conditions = append(conditions, "t.id > ?")
+		args = append(args, params.Cursor)
+	}
+
+	query := `
+		SELECT 
+			t.id, t.title, t.course_id, c.vulnerability_type, up.completed_at` +
+		from + strings.Join(conditions, " AND ") +
+		orderByClause(params, submissionSortColumns, "up.completed_at DESC, t.id ASC", "t.id") +
+		limitClause(params)
+	args = append(args, limitArgs(params)...)
+
+	stmt, err := s.DB.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("prepare statement: %w", err)
+		return nil, 0, fmt.Errorf("prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(userID)
+	rows, err := stmt.Query(args...)
 	if err != nil {
-		return nil, fmt.Errorf("execute query: %w", err)
+		return nil, 0, fmt.Errorf("execute query: %w", err)
 	}
 	defer rows.Close()
 
@@ -1099,7 +1243,7 @@ func (s *DBStorage) GetUserSubmissions(userID int) ([]models.TaskSubmissionDetai
 			&submission.CourseName,
 			&submittedAt,
 		); err != nil {
-			return nil, fmt.Errorf("scan row: %w", err)
+			return nil, 0, fmt.Errorf("scan row: %w", err)
 		}
 
 		submission.SubmissionID = submission.TaskID
@@ -1110,13 +1254,20 @@ func (s *DBStorage) GetUserSubmissions(userID int) ([]models.TaskSubmissionDetai
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate rows: %w", err)
+		return nil, 0, fmt.Errorf("iterate rows: %w", err)
 	}
 
-	return submissions, nil
+	return submissions, total, nil
 }
 
-func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics, error) {
+var studentProgressSortColumns = map[string]string{
+	"user_id":       "sp.user_id",
+	"username":      "sp.username",
+	"completion":    "sp.completed_tasks",
+	"last_activity": "sp.last_activity",
+}
+
+func (s *DBStorage) GetCourseStatistics(courseID int, params models.ListParams) (models.CourseStatistics, error) {
 	var stats models.CourseStatistics
 	stats.CourseID = courseID
 
@@ -1128,6 +1279,8 @@ func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics,
 		return stats, fmt.Errorf("get course name: %w", err)
 	}
 
+	memberFilter, memberArgs := courseStudentFilter("up.user_id", courseID, params)
+
 	err = s.DB.QueryRow(`
 		SELECT 
 			COUNT(DISTINCT up.user_id) as enrolled_students,
@@ -1140,8 +1293,9 @@ func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics,
 			) THEN up.user_id ELSE NULL END) as completed_students
 		FROM user_progress up
 		JOIN tasks t ON up.task_id = t.id
-		WHERE t.course_id = ?
-	`, courseID, courseID, courseID).Scan(
+		WHERE t.course_id = ?`+memberFilter,
+		append([]interface{}{courseID, courseID, courseID}, memberArgs...)...,
+	).Scan(
 		&stats.EnrolledStudents,
 		&stats.CompletedStudents,
 	)
@@ -1158,7 +1312,7 @@ func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics,
 			t.id, t.title,
 			COUNT(DISTINCT up.user_id) as completed_by
 		FROM tasks t
-		LEFT JOIN user_progress up ON t.id = up.task_id
+		LEFT JOIN user_progress up ON t.id = up.task_id` + memberFilter + `
 		WHERE t.course_id = ?
 		GROUP BY t.id
 	`)
@@ -1167,7 +1321,7 @@ func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics,
 	}
 	defer taskStmt.Close()
 
-	taskRows, err := taskStmt.Query(courseID)
+	taskRows, err := taskStmt.Query(append(memberArgs, courseID)...)
 	if err != nil {
 		return stats, fmt.Errorf("execute task stats query: %w", err)
 	}
@@ -1207,26 +1361,92 @@ func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics,
 		return stats, fmt.Errorf("iterate task stats rows: %w", err)
 	}
 
-	studentStmt, err := s.DB.Prepare(`
+	var activeStudents, totalTimeSpent int
+	summaryFilter, summaryArgs := courseStudentFilter("user_id", courseID, params)
+	err = s.DB.QueryRow(`
+		SELECT COUNT(DISTINCT user_id), COALESCE(SUM(time_spent_seconds), 0)
+		FROM learning_activity_summary
+		WHERE course_id = ?`+summaryFilter,
+		append([]interface{}{courseID}, summaryArgs...)...,
+	).Scan(&activeStudents, &totalTimeSpent)
+	if err != nil {
+		return stats, fmt.Errorf("get course time spent: %w", err)
+	}
+	if activeStudents > 0 {
+		totalTimeSpent /= activeStudents
+	}
+	stats.AverageTimeSpent = models.FormatDuration(totalTimeSpent)
+
+	// Последняя активность студента в курсе - самое позднее из выполнения задачи курса
+	// и события учебной активности по задачам курса
+	innerConditions := []string{"u.is_deleted = 0"}
+	innerArgs := []interface{}{courseID, courseID, courseID, courseID, courseID}
+	if params.IsActive != nil {
+		innerConditions = append(innerConditions, "u.is_active = ?")
+		innerArgs = append(innerArgs, *params.IsActive)
+	}
+	if studentFilter, studentArgs := courseStudentFilter("u.id", courseID, params); studentFilter != "" {
+		innerConditions = append(innerConditions, strings.TrimPrefix(studentFilter, " AND "))
+		innerArgs = append(innerArgs, studentArgs...)
+	}
+
+	studentsQuery := `
 		SELECT 
-			u.id, u.username,
+			u.id as user_id, u.username as username,
 			COUNT(DISTINCT CASE WHEN t.course_id = ? THEN up.task_id ELSE NULL END) as completed_tasks,
 			(SELECT COUNT(*) FROM tasks WHERE course_id = ?) as total_tasks,
-			MAX(up.created_at) as last_activity
+			CASE WHEN MAX(las.last_activity_at) > MAX(CASE WHEN t.course_id = ? THEN up.completed_at END)
+				THEN MAX(las.last_activity_at)
+				ELSE MAX(CASE WHEN t.course_id = ? THEN up.completed_at END)
+			END as last_activity
 		FROM users u
 		JOIN user_progress up ON u.id = up.user_id
 		JOIN tasks t ON up.task_id = t.id
-		WHERE u.is_deleted = 0
-		GROUP BY u.id
+		LEFT JOIN learning_activity_summary las ON las.user_id = u.id AND las.course_id = ?
+		WHERE ` + strings.Join(innerConditions, " AND ") + `
+		GROUP BY u.id, u.username
 		HAVING completed_tasks > 0
-		ORDER BY completed_tasks DESC
-	`)
+	`
+
+	var outerConditions []string
+	outerArgs := append([]interface{}{}, innerArgs...)
+	if !params.From.IsZero() {
+		outerConditions = append(outerConditions, "sp.last_activity >= ?")
+		outerArgs = append(outerArgs, params.From)
+	}
+	if !params.To.IsZero() {
+		outerConditions = append(outerConditions, "sp.last_activity < ?")
+		outerArgs = append(outerArgs, params.To)
+	}
+
+	countQuery := "SELECT COUNT(*) FROM (" + studentsQuery + ") sp"
+	if len(outerConditions) > 0 {
+		countQuery += " WHERE " + strings.Join(outerConditions, " AND ")
+	}
+	if err := s.DB.QueryRow(countQuery, outerArgs...).Scan(&stats.StudentsTotal); err != nil {
+		return stats, fmt.Errorf("count students: %w", err)
+	}
+
+	if params.IsCursor() {
+		outerConditions = append(outerConditions, "sp.user_id > ?")
+		outerArgs = append(outerArgs, params.Cursor)
+	}
+
+	pageQuery := "SELECT sp.user_id, sp.username, sp.completed_tasks, sp.total_tasks, sp.last_activity FROM (" + studentsQuery + ") sp"
+	if len(outerConditions) > 0 {
+		pageQuery += " WHERE " + strings.Join(outerConditions, " AND ")
+	}
+	pageQuery += orderByClause(params, studentProgressSortColumns, "sp.completed_tasks DESC, sp.user_id ASC", "sp.user_id") +
+		limitClause(params)
+	outerArgs = append(outerArgs, limitArgs(params)...)
+
+	studentStmt, err := s.DB.Prepare(pageQuery)
 	if err != nil {
 		return stats, fmt.Errorf("prepare student progress statement: %w", err)
 	}
 	defer studentStmt.Close()
 
-	studentRows, err := studentStmt.Query(courseID, courseID)
+	studentRows, err := studentStmt.Query(outerArgs...)
 	if err != nil {
 		return stats, fmt.Errorf("execute student progress query: %w", err)
 	}
@@ -1249,7 +1469,7 @@ func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics,
 			LastActivity      string  `json:"last_activity"`
 		}
 		var completedTasks, totalTasks int
-		var lastActivity time.Time
+		var lastActivity nullTime
 
 		if err := studentRows.Scan(
 			&studentProgress.UserID,
@@ -1264,7 +1484,7 @@ func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics,
 		if totalTasks > 0 {
 			studentProgress.CompletionPercent = float64(completedTasks) / float64(totalTasks) * 100
 		}
-		studentProgress.LastActivity = lastActivity.Format("2006-01-02 15:04:05")
+		studentProgress.LastActivity = lastActivity.Time.Format("2006-01-02 15:04:05")
 
 		stats.StudentsProgress = append(stats.StudentsProgress, studentProgress)
 	}
@@ -1276,15 +1496,131 @@ func (s *DBStorage) GetCourseStatistics(courseID int) (models.CourseStatistics,
 	return stats, nil
 }
 
+// GetLearningEffectiveness выбирает исходные данные отчета по курсу до конца периода,
+// показатели считает buildEffectivenessReport
+func (s *DBStorage) GetLearningEffectiveness(params models.EffectivenessParams) (models.LearningEffectiveness, error) {
+	var facts effectivenessFacts
+	from, to := params.From.UTC(), params.To.UTC()
+
+	err := s.DB.QueryRow("SELECT vulnerability_type FROM courses WHERE id = ?", params.CourseID).Scan(&facts.courseName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LearningEffectiveness{}, ErrCourseNotFound
+		}
+		return models.LearningEffectiveness{}, fmt.Errorf("get course name: %w", err)
+	}
+
+	taskRows, err := s.DB.Query("SELECT id, title, points FROM tasks WHERE course_id = ? ORDER BY task_order, id", params.CourseID)
+	if err != nil {
+		return models.LearningEffectiveness{}, fmt.Errorf("get course tasks: %w", err)
+	}
+	defer taskRows.Close()
+	for taskRows.Next() {
+		var task models.Task
+		if err := taskRows.Scan(&task.ID, &task.Title, &task.Points); err != nil {
+			return models.LearningEffectiveness{}, fmt.Errorf("scan task: %w", err)
+		}
+		facts.tasks = append(facts.tasks, task)
+	}
+	if err := taskRows.Err(); err != nil {
+		return models.LearningEffectiveness{}, fmt.Errorf("iterate tasks: %w", err)
+	}
+
+	completionRows, err := s.DB.Query(`
+		SELECT up.user_id, up.task_id, up.completed_at
+		FROM user_progress up
+		JOIN tasks t ON up.task_id = t.id
+		JOIN users u ON up.user_id = u.id
+		WHERE t.course_id = ? AND up.completed_at < ? AND u.is_deleted = FALSE
+	`, params.CourseID, to)
+	if err != nil {
+		return models.LearningEffectiveness{}, fmt.Errorf("get completions: %w", err)
+	}
+	defer completionRows.Close()
+	for completionRows.Next() {
+		var completion completionFact
+		var completedAt nullTime
+		if err := completionRows.Scan(&completion.userID, &completion.taskID, &completedAt); err != nil {
+			return models.LearningEffectiveness{}, fmt.Errorf("scan completion: %w", err)
+		}
+		completion.completedAt = completedAt.Time
+		facts.completions = append(facts.completions, completion)
+	}
+	if err := completionRows.Err(); err != nil {
+		return models.LearningEffectiveness{}, fmt.Errorf("iterate completions: %w", err)
+	}
+
+	attemptRows, err := s.DB.Query(`
+		SELECT ts.id, ts.user_id, ts.task_id, ts.course_id, ts.is_correct, ts.score, ts.submitted_at
+		FROM task_submissions ts
+		JOIN users u ON ts.user_id = u.id
+		WHERE ts.course_id = ? AND ts.submitted_at < ? AND u.is_deleted = FALSE
+	`, params.CourseID, to)
+	if err != nil {
+		return models.LearningEffectiveness{}, fmt.Errorf("get submission attempts: %w", err)
+	}
+	defer attemptRows.Close()
+	for attemptRows.Next() {
+		var attempt models.SubmissionAttempt
+		var submittedAt nullTime
+		if err := attemptRows.Scan(
+			&attempt.ID,
+			&attempt.UserID,
+			&attempt.TaskID,
+			&attempt.CourseID,
+			&attempt.IsCorrect,
+			&attempt.Score,
+			&submittedAt,
+		); err != nil {
+			return models.LearningEffectiveness{}, fmt.Errorf("scan submission attempt: %w", err)
+		}
+		attempt.SubmittedAt = submittedAt.Time
+		facts.attempts = append(facts.attempts, attempt)
+	}
+	if err := attemptRows.Err(); err != nil {
+		return models.LearningEffectiveness{}, fmt.Errorf("iterate submission attempts: %w", err)
+	}
+
+	activityRows, err := s.DB.Query(`
+		SELECT 
+			la.user_id, la.task_id,
+			COALESCE(SUM(CASE WHEN la.activity_type = 'time_on_task' AND la.occurred_at >= ? THEN la.duration_seconds ELSE 0 END), 0) as time_spent,
+			MAX(la.occurred_at) as last_activity
+		FROM learning_activities la
+		JOIN users u ON la.user_id = u.id
+		WHERE la.course_id = ? AND la.occurred_at < ? AND u.is_deleted = FALSE
+		GROUP BY la.user_id, la.task_id
+	`, from, params.CourseID, to)
+	if err != nil {
+		return models.LearningEffectiveness{}, fmt.Errorf("get learning activity: %w", err)
+	}
+	defer activityRows.Close()
+	for activityRows.Next() {
+		var activity activityFact
+		var lastAt nullTime
+		if err := activityRows.Scan(&activity.userID, &activity.taskID, &activity.timeSpent, &lastAt); err != nil {
+			return models.LearningEffectiveness{}, fmt.Errorf("scan learning activity: %w", err)
+		}
+		activity.lastAt = lastAt.Time
+		facts.activity = append(facts.activity, activity)
+	}
+	if err := activityRows.Err(); err != nil {
+		return models.LearningEffectiveness{}, fmt.Errorf("iterate learning activity: %w", err)
+	}
+
+	return buildEffectivenessReport(params, facts), nil
+}
+
 func (s *DBStorage) GetUserStatistics(userID int) (models.UserStatistics, error) {
 	var stats models.UserStatistics
 	stats.UserID = userID
 
-	var createdAt, lastActive time.Time
+	var createdAt time.Time
+	var lastActive nullTime
 	err := s.DB.QueryRow(`
 		SELECT 
 			created_at,
-			IFNULL((SELECT MAX(created_at) FROM user_progress WHERE user_id = ?), created_at) as last_active
+			(SELECT MAX(completed_at) FROM user_progress WHERE user_id = ?) as last_active
 		FROM users
 		WHERE id = ? AND is_deleted = 0
 	`, userID, userID).Scan(&createdAt, &lastActive)
@@ -1293,11 +1629,34 @@ func (s *DBStorage) GetUserStatistics(userID int) (models.UserStatistics, error)
 		return stats, fmt.Errorf("get user base info: %w", err)
 	}
 
+	var lastActivityEvent nullTime
+	var timeSpent int
+	err = s.DB.QueryRow(`
+		SELECT MAX(last_activity_at), COALESCE(SUM(time_spent_seconds), 0)
+		FROM learning_activity_summary
+		WHERE user_id = ?
+	`, userID).Scan(&lastActivityEvent, &timeSpent)
+	if err != nil {
+		return stats, fmt.Errorf("get user activity: %w", err)
+	}
+
 	stats.JoinedDate = createdAt
-	stats.LastActive = lastActive
+	stats.LastActive = createdAt
+	if lastActive.Valid {
+		stats.LastActive = lastActive.Time
+	}
+	if lastActivityEvent.Valid && lastActivityEvent.Time.After(stats.LastActive) {
+		stats.LastActive = lastActivityEvent.Time
+	}
+	stats.TotalTimeSpent = models.FormatDuration(timeSpent)
 
-	err = s.DB.QueryRow(`
-		SELECT
+	courseActivity, err := s.lastActivityByCourse(userID)
+	if err != nil {
+		return stats, err
+	}
+
+	err = s.DB.QueryRow(`
+		SELECT
 			(SELECT COUNT(DISTINCT course_id) FROM tasks t JOIN user_progress up ON t.id = up.task_id WHERE up.user_id = ?) as total_courses,
 			(SELECT COUNT(DISTINCT course_id) FROM (
 				SELECT t.course_id, COUNT(t.id) as total_tasks, COUNT(up.task_id) as completed_tasks
@@ -1323,7 +1682,7 @@ func (s *DBStorage) GetUserStatistics(userID int) (models.UserStatistics, error)
 			c.id, c.vulnerability_type,
 			COUNT(DISTINCT up.task_id) as completed_tasks,
 			(SELECT COUNT(*) FROM tasks WHERE course_id = c.id) as total_tasks,
-			MAX(up.created_at) as last_activity
+			MAX(up.completed_at) as last_activity
 		FROM courses c
 		JOIN tasks t ON c.id = t.course_id
 		JOIN user_progress up ON t.id = up.task_id
@@ -1359,7 +1718,7 @@ func (s *DBStorage) GetUserStatistics(userID int) (models.UserStatistics, error)
 			LastActivity      string  `json:"last_activity"`
 		}
 		var completedTasks, totalTasks int
-		var lastActivity time.Time
+		var lastActivity nullTime
 
 		if err := courseRows.Scan(
 			&courseProgress.CourseID,
@@ -1374,7 +1733,10 @@ func (s *DBStorage) GetUserStatistics(userID int) (models.UserStatistics, error)
 		if totalTasks > 0 {
 			courseProgress.CompletionPercent = float64(completedTasks) / float64(totalTasks) * 100
 		}
-		courseProgress.LastActivity = lastActivity.Format("2006-01-02 15:04:05")
+		if eventTime, ok := courseActivity[courseProgress.CourseID]; ok && eventTime.After(lastActivity.Time) {
+			lastActivity.Time = eventTime
+		}
+		courseProgress.LastActivity = lastActivity.Time.Format("2006-01-02 15:04:05")
 
 		stats.CoursesProgress = append(stats.CoursesProgress, courseProgress)
 	}
@@ -1387,6 +1749,12 @@ func (s *DBStorage) GetUserStatistics(userID int) (models.UserStatistics, error)
 }
 
 func (s *DBStorage) GetLeaderboard(courseID int, limit int) ([]models.LeaderboardEntry, error) {
+	return s.leaderboard(0, courseID, limit)
+}
+
+// leaderboard строит рейтинг по курсу (или по всем курсам), при cohortID > 0 - только
+// по студентам группы и курсам группы
+func (s *DBStorage) leaderboard(cohortID, courseID, limit int) ([]models.LeaderboardEntry, error) {
 	courseFilter := ""
 	params := []interface{}{}
 
@@ -1394,6 +1762,11 @@ func (s *DBStorage) GetLeaderboard(courseID int, limit int) ([]models.Leaderboar
 		courseFilter = "AND t.course_id = ?"
 		params = append(params, courseID)
 	}
+	if cohortID > 0 {
+		memberFilter, memberArgs := cohortMemberFilter("u.id", cohortID)
+		courseFilter += memberFilter + " AND t.course_id IN (SELECT course_id FROM cohort_courses WHERE cohort_id = ?)"
+		params = append(append(params, memberArgs...), cohortID)
+	}
 
 	query := fmt.Sprintf(`
 		SELECT 
@@ -1446,137 +1819,260 @@ func (s *DBStorage) GetLeaderboard(courseID int, limit int) ([]models.Leaderboar
 	return leaderboard, nil
 }
 
-func (s *DBStorage) GetUserLearningPath(userID int) (models.LearningPath, error) {
-	var path models.LearningPath
-	path.UserID = userID
-	path.GeneratedAt = time.Now()
+// GetLearningSnapshot собирает данные для построения траектории обучения: все задачи
+// с навыками и предварительными задачами, среднее время решения задач и историю пользователя
+func (s *DBStorage) GetLearningSnapshot(userID int) (models.LearningSnapshot, error) {
+	snapshot := models.LearningSnapshot{
+		UserID:      userID,
+		GeneratedAt: time.Now(),
+		Completed:   make(map[int]time.Time),
+	}
 
-	recStmt, err := s.DB.Prepare(`
-		SELECT 
-			c.id, c.vulnerability_type,
-			CASE 
-				WHEN NOT EXISTS (
-					SELECT 1 FROM user_progress up 
-					JOIN tasks t ON up.task_id = t.id 
-					WHERE up.user_id = ? AND t.course_id = c.id
-				) THEN 3
-				WHEN (
-					SELECT COUNT(*) FROM tasks WHERE course_id = c.id
-				) > (
-					SELECT COUNT(*) FROM user_progress up 
-					JOIN tasks t ON up.task_id = t.id 
-					WHERE up.user_id = ? AND t.course_id = c.id
-				) THEN 2
-				ELSE 1
-			END as priority,
-			CASE 
-				WHEN NOT EXISTS (
-					SELECT 1 FROM user_progress up 
-					JOIN tasks t ON up.task_id = t.id 
-					WHERE up.user_id = ? AND t.course_id = c.id
-				) THEN 'New recommended course'
-				ELSE 'Continue your progress'
-			END as reason
-		FROM courses c
-		ORDER BY priority DESC, c.id
-		LIMIT 3
+	taskRows, err := s.DB.Query(`
+		SELECT t.id, t.course_id, c.vulnerability_type, t.title, t.description, t.difficulty, t.task_order, t.points
+		FROM tasks t
+		JOIN courses c ON t.course_id = c.id
+		ORDER BY t.course_id, t.task_order, t.id
 	`)
 	if err != nil {
-		return path, fmt.Errorf("prepare recommendations statement: %w", err)
+		return snapshot, fmt.Errorf("get tasks: %w", err)
+	}
+	defer taskRows.Close()
+
+	taskIndex := make(map[int]int)
+	for taskRows.Next() {
+		var task models.LearningTask
+		if err := taskRows.Scan(
+			&task.ID,
+			&task.CourseID,
+			&task.CourseName,
+			&task.Title,
+			&task.Description,
+			&task.Difficulty,
+			&task.Order,
+			&task.Points,
+		); err != nil {
+			return snapshot, fmt.Errorf("scan task: %w", err)
+		}
+		taskIndex[task.ID] = len(snapshot.Tasks)
+		snapshot.Tasks = append(snapshot.Tasks, task)
+	}
+	if err := taskRows.Err(); err != nil {
+		return snapshot, fmt.Errorf("iterate tasks: %w", err)
+	}
+
+	skillRows, err := s.DB.Query("SELECT task_id, skill, COALESCE(cwe, ''), weight FROM task_skills ORDER BY task_id, skill")
+	if err != nil {
+		return snapshot, fmt.Errorf("get task skills: %w", err)
+	}
+	defer skillRows.Close()
+	for skillRows.Next() {
+		var taskID int
+		var skill models.TaskSkill
+		if err := skillRows.Scan(&taskID, &skill.Skill, &skill.CWE, &skill.Weight); err != nil {
+			return snapshot, fmt.Errorf("scan task skill: %w", err)
+		}
+		if i, ok := taskIndex[taskID]; ok {
+			snapshot.Tasks[i].Skills = append(snapshot.Tasks[i].Skills, skill)
+		}
+	}
+	if err := skillRows.Err(); err != nil {
+		return snapshot, fmt.Errorf("iterate task skills: %w", err)
 	}
-	defer recStmt.Close()
 
-	recRows, err := recStmt.Query(userID, userID, userID)
+	prerequisites, err := s.loadPrerequisites(s.DB)
 	if err != nil {
-		return path, fmt.Errorf("execute recommendations query: %w", err)
+		return snapshot, err
+	}
+	for taskID, ids := range prerequisites {
+		if i, ok := taskIndex[taskID]; ok {
+			snapshot.Tasks[i].Prerequisites = ids
+		}
 	}
-	defer recRows.Close()
 
-	path.Recommendations = []struct {
-		CourseID      int    `json:"course_id"`
-		CourseName    string `json:"course_name"`
-		Priority      int    `json:"priority"`
-		Reason        string `json:"reason"`
-		EstimatedTime string `json:"estimated_time"`
-	}{}
+	averageRows, err := s.DB.Query(`
+		SELECT las.task_id, AVG(las.time_spent_seconds)
+		FROM learning_activity_summary las
+		JOIN user_progress up ON up.user_id = las.user_id AND up.task_id = las.task_id
+		WHERE las.time_spent_seconds > 0
+		GROUP BY las.task_id
+	`)
+	if err != nil {
+		return snapshot, fmt.Errorf("get average task time: %w", err)
+	}
+	defer averageRows.Close()
+	for averageRows.Next() {
+		var taskID int
+		var average float64
+		if err := averageRows.Scan(&taskID, &average); err != nil {
+			return snapshot, fmt.Errorf("scan average task time: %w", err)
+		}
+		if i, ok := taskIndex[taskID]; ok {
+			snapshot.Tasks[i].AverageTimeSeconds = int(average)
+		}
+	}
+	if err := averageRows.Err(); err != nil {
+		return snapshot, fmt.Errorf("iterate average task time: %w", err)
+	}
+
+	completionRows, err := s.DB.Query("SELECT task_id, completed_at FROM user_progress WHERE user_id = ?", userID)
+	if err != nil {
+		return snapshot, fmt.Errorf("get completed tasks: %w", err)
+	}
+	defer completionRows.Close()
+	for completionRows.Next() {
+		var taskID int
+		var completedAt nullTime
+		if err := completionRows.Scan(&taskID, &completedAt); err != nil {
+			return snapshot, fmt.Errorf("scan completed task: %w", err)
+		}
+		snapshot.Completed[taskID] = completedAt.Time
+	}
+	if err := completionRows.Err(); err != nil {
+		return snapshot, fmt.Errorf("iterate completed tasks: %w", err)
+	}
 
-	for recRows.Next() {
-		var rec struct {
-			CourseID      int    `json:"course_id"`
-			CourseName    string `json:"course_name"`
-			Priority      int    `json:"priority"`
-			Reason        string `json:"reason"`
-			EstimatedTime string `json:"estimated_time"`
-		}
-		if err := recRows.Scan(
-			&rec.CourseID,
-			&rec.CourseName,
-			&rec.Priority,
-			&rec.Reason,
+	attemptRows, err := s.DB.Query(`
+		SELECT id, user_id, task_id, course_id, is_correct, score, submitted_at
+		FROM task_submissions
+		WHERE user_id = ?
+		ORDER BY submitted_at
+	`, userID)
+	if err != nil {
+		return snapshot, fmt.Errorf("get submission attempts: %w", err)
+	}
+	defer attemptRows.Close()
+	for attemptRows.Next() {
+		var attempt models.SubmissionAttempt
+		var submittedAt nullTime
+		if err := attemptRows.Scan(
+			&attempt.ID,
+			&attempt.UserID,
+			&attempt.TaskID,
+			&attempt.CourseID,
+			&attempt.IsCorrect,
+			&attempt.Score,
+			&submittedAt,
 		); err != nil {
-			return path, fmt.Errorf("scan recommendation row: %w", err)
+			return snapshot, fmt.Errorf("scan submission attempt: %w", err)
 		}
+		attempt.SubmittedAt = submittedAt.Time
+		snapshot.Attempts = append(snapshot.Attempts, attempt)
+	}
+	if err := attemptRows.Err(); err != nil {
+		return snapshot, fmt.Errorf("iterate submission attempts: %w", err)
+	}
 
-		rec.EstimatedTime = "Based on course size"
-		path.Recommendations = append(path.Recommendations, rec)
+	snapshot.Activity, err = s.GetUserActivitySummary(userID)
+	if err != nil {
+		return snapshot, fmt.Errorf("get activity summary: %w", err)
 	}
 
-	taskStmt, err := s.DB.Prepare(`
-		SELECT 
-			t.id, t.title, t.course_id, c.vulnerability_type,
-			ROW_NUMBER() OVER (ORDER BY t.task_order) as priority
-		FROM tasks t
-		JOIN courses c ON t.course_id = c.id
-		WHERE NOT EXISTS (
-			SELECT 1 FROM user_progress up
-			WHERE up.user_id = ? AND up.task_id = t.id
-		)
-		ORDER BY c.id, t.task_order
-		LIMIT 5
-	`)
+	deadlines, err := s.GetUserDeadlines(userID)
 	if err != nil {
-		return path, fmt.Errorf("prepare next tasks statement: %w", err)
+		return snapshot, fmt.Errorf("get deadlines: %w", err)
 	}
-	defer taskStmt.Close()
+	for _, deadline := range deadlines {
+		if i, ok := taskIndex[deadline.TaskID]; ok {
+			dueAt := deadline.DueAt
+			snapshot.Tasks[i].DueAt = &dueAt
+		}
+	}
+
+	return snapshot, nil
+}
+
+// queryer - общий интерфейс *sql.DB и *sql.Tx для запросов, которые выполняются в обоих контекстах
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
 
-	taskRows, err := taskStmt.Query(userID)
+func (s *DBStorage) loadPrerequisites(q queryer) (map[int][]int, error) {
+	rows, err := q.Query("SELECT task_id, prerequisite_task_id FROM task_prerequisites ORDER BY task_id, prerequisite_task_id")
 	if err != nil {
-		return path, fmt.Errorf("execute next tasks query: %w", err)
+		return nil, fmt.Errorf("get task prerequisites: %w", err)
 	}
-	defer taskRows.Close()
+	defer rows.Close()
 
-	path.NextTasks = []struct {
-		TaskID     int    `json:"task_id"`
-		TaskTitle  string `json:"task_title"`
-		CourseID   int    `json:"course_id"`
-		CourseName string `json:"course_name"`
-		Priority   int    `json:"priority"`
-		DueDate    string `json:"due_date,omitempty"`
-	}{}
+	prerequisites := make(map[int][]int)
+	for rows.Next() {
+		var taskID, prerequisiteID int
+		if err := rows.Scan(&taskID, &prerequisiteID); err != nil {
+			return nil, fmt.Errorf("scan task prerequisite: %w", err)
+		}
+		prerequisites[taskID] = append(prerequisites[taskID], prerequisiteID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate task prerequisites: %w", err)
+	}
+	return prerequisites, nil
+}
 
-	for taskRows.Next() {
-		var task struct {
-			TaskID     int    `json:"task_id"`
-			TaskTitle  string `json:"task_title"`
-			CourseID   int    `json:"course_id"`
-			CourseName string `json:"course_name"`
-			Priority   int    `json:"priority"`
-			DueDate    string `json:"due_date,omitempty"`
+// SetTaskSkills заменяет навыки и предварительные задачи задачи курса.
+// Предварительные задачи должны существовать и не образовывать цикл.
+func (s *DBStorage) SetTaskSkills(courseID, taskID int, request models.TaskSkillsRequest) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow("SELECT COUNT(*) FROM tasks WHERE id = ? AND course_id = ?", taskID, courseID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check task: %w", err)
+	}
+	if exists == 0 {
+		return ErrTaskNotFound
+	}
+
+	for _, prerequisiteID := range request.Prerequisites {
+		err = tx.QueryRow("SELECT COUNT(*) FROM tasks WHERE id = ?", prerequisiteID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("check prerequisite: %w", err)
 		}
-		if err := taskRows.Scan(
-			&task.TaskID,
-			&task.TaskTitle,
-			&task.CourseID,
-			&task.CourseName,
-			&task.Priority,
-		); err != nil {
-			return path, fmt.Errorf("scan task row: %w", err)
+		if exists == 0 {
+			return fmt.Errorf("prerequisite %d: %w", prerequisiteID, ErrTaskNotFound)
 		}
+	}
+
+	prerequisites, err := s.loadPrerequisites(tx)
+	if err != nil {
+		return err
+	}
+	prerequisites[taskID] = request.Prerequisites
+	if hasPrerequisiteCycle(prerequisites, taskID) {
+		return ErrPrerequisiteCycle
+	}
 
-		path.NextTasks = append(path.NextTasks, task)
+	if _, err := tx.Exec("DELETE FROM task_skills WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("delete task skills: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM task_prerequisites WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("delete task prerequisites: %w", err)
+	}
+
+	for _, skill := range request.Skills {
+		if _, err := tx.Exec(
+			"INSERT INTO task_skills (task_id, skill, cwe, weight) VALUES (?, ?, ?, ?)",
+			taskID, skill.Skill, skill.CWE, skill.Weight,
+		); err != nil {
+			return fmt.Errorf("insert task skill: %w", err)
+		}
+	}
+	for _, prerequisiteID := range request.Prerequisites {
+		if _, err := tx.Exec(
+			"INSERT INTO task_prerequisites (task_id, prerequisite_task_id) VALUES (?, ?)",
+			taskID, prerequisiteID,
+		); err != nil {
+			return fmt.Errorf("insert task prerequisite: %w", err)
+		}
 	}
 
-	return path, nil
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
 }
 
 // ****** МЕТОДЫ ДЛЯ ПРЕПОДОВАТЕЛЯ ******
@@ -1709,21 +2205,22 @@ func (s *DBStorage) DeleteCourse(id int) error {
 }
 
 func (s *DBStorage) CreateTask(courseID int, task models.Task) (models.Task, error) {
-	insertStmt, err := s.DB.Prepare(
-		"INSERT INTO tasks (course_id, title, description, difficulty, task_order, points, content, solution) " +
-			"VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return models.Task{}, err
 	}
-	defer insertStmt.Close()
+	defer tx.Rollback()
 
-	result, err := insertStmt.Exec(
+	result, err := tx.Exec(
+		"INSERT INTO tasks (course_id, title, description, difficulty, task_order, points, type, content, solution) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		courseID,
 		task.Title,
 		task.Description,
 		task.Difficulty,
 		task.Order,
 		task.Points,
+		taskType(task),
 		task.Content,
 		task.Solution,
 	)
@@ -1735,26 +2232,34 @@ func (s *DBStorage) CreateTask(courseID int, task models.Task) (models.Task, err
 	if err != nil {
 		return models.Task{}, err
 	}
-
 	task.ID = int(id)
+
+	if err := saveQuizSettings(tx, courseID, task); err != nil {
+		return models.Task{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, err
+	}
+
 	return task, nil
 }
 
 func (s *DBStorage) UpdateTask(courseID, taskID int, task models.Task) (models.Task, error) {
-	updateStmt, err := s.DB.Prepare(
-		"UPDATE tasks SET title = ?, description = ?, difficulty = ?, task_order = ?, points = ?, content = ?, solution = ? " +
-			"WHERE course_id = ? AND id = ?")
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return models.Task{}, err
 	}
-	defer updateStmt.Close()
+	defer tx.Rollback()
 
-	_, err = updateStmt.Exec(
+	_, err = tx.Exec(
+		"UPDATE tasks SET title = ?, description = ?, difficulty = ?, task_order = ?, points = ?, type = ?, content = ?, solution = ? "+
+			"WHERE course_id = ? AND id = ?",
 		task.Title,
 		task.Description,
 		task.Difficulty,
 		task.Order,
 		task.Points,
+		taskType(task),
 		task.Content,
 		task.Solution,
 		courseID,
@@ -1769,6 +2274,13 @@ func (s *DBStorage) UpdateTask(courseID, taskID int, task models.Task) (models.T
 
 	task.CourseID = courseID
 	task.ID = taskID
+	if err := saveQuizSettings(tx, courseID, task); err != nil {
+		return models.Task{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, err
+	}
+
 	return task, nil
 }
 
@@ -1789,3 +2301,3373 @@ func (s *DBStorage) DeleteTask(courseID, taskID int) error {
 
 	return nil
 }
+
+// ****** МЕТОДЫ ПОИСКА ******
+
+// Search выполняет единый поиск по курсам, задачам и (для администраторов) пользователям.
+// В MySQL используются FULLTEXT-индексы из миграции 012, в остальных БД - переносимый поиск
+// подстроки через LIKE. Итоговое ранжирование и подсветка выполняются одинаково для обоих
+// вариантов, к релевантности MySQL добавляется собственная оценка по весам полей.
+func (s *DBStorage) Search(query models.SearchQuery, params models.ListParams) ([]models.SearchResult, int, error) {
+	terms := searchTerms(query.Text)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	fullText := s.supportsFullText() && fullTextIndexable(terms)
+	var results []models.SearchResult
+
+	if query.WantsType(models.SearchTypeCourse) {
+		courses, err := s.searchCourses(terms, query.Text, params, fullText)
+		if err != nil {
+			return nil, 0, fmt.Errorf("search courses: %w", err)
+		}
+		results = append(results, courses...)
+	}
+
+	if query.WantsType(models.SearchTypeTask) {
+		tasks, err := s.searchTasks(terms, query.Text, query.IncludeSolutions, params, fullText)
+		if err != nil {
+			return nil, 0, fmt.Errorf("search tasks: %w", err)
+		}
+		results = append(results, tasks...)
+	}
+
+	if query.WantsType(models.SearchTypeUser) {
+		users, err := s.searchUsers(terms, query.Text, fullText)
+		if err != nil {
+			return nil, 0, fmt.Errorf("search users: %w", err)
+		}
+		results = append(results, users...)
+	}
+
+	rankSearchResults(results)
+
+	start, end := pageBounds(len(results), params)
+	return results[start:end], len(results), nil
+}
+
+// supportsFullText сообщает, поддерживает ли подключенная БД MATCH ... AGAINST
+func (s *DBStorage) supportsFullText() bool {
+	_, ok := s.DB.Driver().(*mysql.MySQLDriver)
+	return ok
+}
+
+// matchCondition возвращает условие отбора и выражение релевантности для набора колонок
+// вместе с их аргументами. Без FULLTEXT релевантность считается только на стороне Go.
+func matchCondition(terms []string, text string, fullText bool, columns ...string) (string, []interface{}, string, []interface{}) {
+	if fullText {
+		match := "MATCH(" + strings.Join(columns, ", ") + ") AGAINST (? IN NATURAL LANGUAGE MODE)"
+		return match, []interface{}{text}, match, []interface{}{text}
+	}
+
+	condition, args := likeConditions(terms, columns...)
+	return condition, args, "0", nil
+}
+
+func (s *DBStorage) searchCourses(terms []string, text string, params models.ListParams, fullText bool) ([]models.SearchResult, error) {
+	condition, conditionArgs, relevance, relevanceArgs := matchCondition(terms, text, fullText, "vulnerability_type", "description")
+
+	query := "SELECT id, vulnerability_type, description, " + relevance + " FROM courses WHERE " + condition
+	args := append(relevanceArgs, conditionArgs...)
+	if params.CourseID > 0 {
+		query += " AND id = ?"
+		args = append(args, params.CourseID)
+	}
+	query += " LIMIT ?"
+	args = append(args, searchCandidatesLimit)
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var course models.Course
+		var rank float64
+		if err := rows.Scan(&course.ID, &course.VulnerabilityType, &course.Description, &rank); err != nil {
+			return nil, err
+		}
+
+		result, ok := newSearchResult(models.SearchTypeCourse, course.ID, course.ID, course.VulnerabilityType, terms, courseSearchFields(course)...)
+		if !ok {
+			continue
+		}
+		result.Score += rank
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// searchTasks никогда не читает колонку solution, если решения не разрешено показывать:
+// так совпадение по тексту решения не может попасть ни в отбор, ни в ранжирование, ни во фрагмент.
+func (s *DBStorage) searchTasks(terms []string, text string, includeSolutions bool, params models.ListParams, fullText bool) ([]models.SearchResult, error) {
+	condition, conditionArgs, relevance, relevanceArgs := matchCondition(terms, text, fullText, "title", "description", "content")
+	solutionColumn := "''"
+
+	if includeSolutions {
+		solutionCondition, solutionConditionArgs, solutionRelevance, solutionRelevanceArgs := matchCondition(terms, text, fullText, "solution")
+		condition = "(" + condition + " OR " + solutionCondition + ")"
+		conditionArgs = append(conditionArgs, solutionConditionArgs...)
+		relevance = "(" + relevance + " + " + solutionRelevance + ")"
+		relevanceArgs = append(relevanceArgs, solutionRelevanceArgs...)
+		solutionColumn = "solution"
+	}
+
+	query := "SELECT id, course_id, title, description, content, " + solutionColumn + ", " + relevance +
+		" FROM tasks WHERE " + condition
+	args := append(relevanceArgs, conditionArgs...)
+	if params.CourseID > 0 {
+		query += " AND course_id = ?"
+		args = append(args, params.CourseID)
+	}
+	query += " LIMIT ?"
+	args = append(args, searchCandidatesLimit)
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var task models.Task
+		var rank float64
+		if err := rows.Scan(&task.ID, &task.CourseID, &task.Title, &task.Description, &task.Content, &task.Solution, &rank); err != nil {
+			return nil, err
+		}
+
+		result, ok := newSearchResult(models.SearchTypeTask, task.ID, task.CourseID, task.Title, terms, taskSearchFields(task, includeSolutions)...)
+		if !ok {
+			continue
+		}
+		result.Score += rank
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+func (s *DBStorage) searchUsers(terms []string, text string, fullText bool) ([]models.SearchResult, error) {
+	condition, conditionArgs, relevance, relevanceArgs := matchCondition(terms, text, fullText, "username", "email", "full_name")
+
+	query := "SELECT id, username, email, full_name, " + relevance +
+		" FROM users WHERE is_deleted = FALSE AND " + condition + " LIMIT ?"
+	args := append(append(relevanceArgs, conditionArgs...), searchCandidatesLimit)
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var user models.User
+		var rank float64
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.FullName, &rank); err != nil {
+			return nil, err
+		}
+
+		result, ok := newSearchResult(models.SearchTypeUser, user.ID, 0, user.Username, terms, userSearchFields(user)...)
+		if !ok {
+			continue
+		}
+		result.Score += rank
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// ****** МЕТОДЫ УЧЕБНОЙ АКТИВНОСТИ ******
+
+var (
+	ErrActivityCourseMismatch = errors.New("task does not belong to course")
+)
+
+// RecordLearningActivities сохраняет пакет событий и в той же транзакции обновляет агрегаты
+// learning_activity_summary по парам (пользователь, задача). Курс события определяется по задаче.
+func (s *DBStorage) RecordLearningActivities(userID int, activities []models.LearningActivity) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insertStmt, err := tx.Prepare(`
+		INSERT INTO learning_activities (user_id, course_id, task_id, activity_type, duration_seconds, details, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare insert statement: %w", err)
+	}
+	defer insertStmt.Close()
+
+	taskCourses := make(map[int]int)
+	summaries := make(map[int]*models.TaskActivitySummary)
+	var taskIDs []int
+
+	for i, activity := range activities {
+		courseID, ok := taskCourses[activity.TaskID]
+		if !ok {
+			err := tx.QueryRow("SELECT course_id FROM tasks WHERE id = ?", activity.TaskID).Scan(&courseID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("event %d: %w", i, ErrTaskNotFound)
+				}
+				return fmt.Errorf("get task course: %w", err)
+			}
+			taskCourses[activity.TaskID] = courseID
+		}
+
+		if activity.CourseID != 0 && activity.CourseID != courseID {
+			return fmt.Errorf("event %d: %w", i, ErrActivityCourseMismatch)
+		}
+
+		activity.Timestamp = activity.Timestamp.UTC()
+		if _, err := insertStmt.Exec(
+			userID,
+			courseID,
+			activity.TaskID,
+			activity.ActivityType,
+			activity.Duration,
+			activity.Details,
+			activity.Timestamp,
+		); err != nil {
+			return fmt.Errorf("insert activity: %w", err)
+		}
+
+		summary, ok := summaries[activity.TaskID]
+		if !ok {
+			summary = &models.TaskActivitySummary{UserID: userID, TaskID: activity.TaskID, CourseID: courseID}
+			summaries[activity.TaskID] = summary
+			taskIDs = append(taskIDs, activity.TaskID)
+		}
+		summary.Add(activity)
+	}
+
+	for _, taskID := range taskIDs {
+		if err := upsertActivitySummary(tx, *summaries[taskID]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// upsertActivitySummary прибавляет агрегат пакета к сохраненному агрегату задачи.
+// Вместо ON DUPLICATE KEY UPDATE используется проверка существования, чтобы запрос
+// работал одинаково в MySQL и SQLite.
+func upsertActivitySummary(tx *sql.Tx, summary models.TaskActivitySummary) error {
+	var exists bool
+	err := tx.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM learning_activity_summary WHERE user_id = ? AND task_id = ?)",
+		summary.UserID, summary.TaskID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check activity summary: %w", err)
+	}
+
+	if !exists {
+		_, err = tx.Exec(`
+			INSERT INTO learning_activity_summary (
+				user_id, task_id, course_id, time_spent_seconds, opened_count, hints_viewed,
+				submissions_count, first_activity_at, last_activity_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			summary.UserID, summary.TaskID, summary.CourseID, summary.TimeSpentSeconds, summary.OpenedCount,
+			summary.HintsViewed, summary.Submissions, summary.FirstActivityAt, summary.LastActivityAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert activity summary: %w", err)
+		}
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE learning_activity_summary SET
+			time_spent_seconds = time_spent_seconds + ?,
+			opened_count = opened_count + ?,
+			hints_viewed = hints_viewed + ?,
+			submissions_count = submissions_count + ?,
+			first_activity_at = CASE WHEN first_activity_at > ? THEN ? ELSE first_activity_at END,
+			last_activity_at = CASE WHEN last_activity_at < ? THEN ? ELSE last_activity_at END
+		WHERE user_id = ? AND task_id = ?
+	`,
+		summary.TimeSpentSeconds, summary.OpenedCount, summary.HintsViewed, summary.Submissions,
+		summary.FirstActivityAt, summary.FirstActivityAt,
+		summary.LastActivityAt, summary.LastActivityAt,
+		summary.UserID, summary.TaskID,
+	)
+	if err != nil {
+		return fmt.Errorf("update activity summary: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DBStorage) GetUserActivitySummary(userID int) ([]models.TaskActivitySummary, error) {
+	rows, err := s.DB.Query(`
+		SELECT 
+			user_id, task_id, course_id, time_spent_seconds, opened_count, hints_viewed,
+			submissions_count, first_activity_at, last_activity_at
+		FROM learning_activity_summary
+		WHERE user_id = ?
+		ORDER BY task_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []models.TaskActivitySummary
+	for rows.Next() {
+		var summary models.TaskActivitySummary
+		var firstActivity, lastActivity nullTime
+		if err := rows.Scan(
+			&summary.UserID,
+			&summary.TaskID,
+			&summary.CourseID,
+			&summary.TimeSpentSeconds,
+			&summary.OpenedCount,
+			&summary.HintsViewed,
+			&summary.Submissions,
+			&firstActivity,
+			&lastActivity,
+		); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		summary.FirstActivityAt = firstActivity.Time
+		summary.LastActivityAt = lastActivity.Time
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// lastActivityByCourse возвращает время последнего события активности пользователя по каждому курсу
+func (s *DBStorage) lastActivityByCourse(userID int) (map[int]time.Time, error) {
+	rows, err := s.DB.Query(`
+		SELECT course_id, MAX(last_activity_at)
+		FROM learning_activity_summary
+		WHERE user_id = ?
+		GROUP BY course_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get course activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := make(map[int]time.Time)
+	for rows.Next() {
+		var courseID int
+		var lastActivity nullTime
+		if err := rows.Scan(&courseID, &lastActivity); err != nil {
+			return nil, fmt.Errorf("scan course activity: %w", err)
+		}
+		activity[courseID] = lastActivity.Time
+	}
+
+	return activity, rows.Err()
+}
+
+// ****** МЕТОДЫ ДЛЯ ЗАДАНИЙ СО СРОКАМИ ******
+
+var (
+	ErrAssignmentNotFound = errors.New("assignment not found")
+	ErrAssignmentNotOpen  = errors.New("assignment is not open yet")
+	ErrAssignmentClosed   = errors.New("assignment is closed")
+)
+
+const assignmentColumns = `
+	a.id, a.course_id, a.cohort_id, a.title, COALESCE(a.description, ''), a.opens_at, a.due_at,
+	a.penalty_per_day, a.max_penalty, a.grace_minutes, a.close_after_days, COALESCE(a.created_by, 0), a.created_at
+`
+
+func scanAssignment(scanner interface{ Scan(...interface{}) error }) (models.Assignment, error) {
+	var assignment models.Assignment
+	var cohortID sql.NullInt64
+	var opensAt, dueAt, createdAt nullTime
+	err := scanner.Scan(
+		&assignment.ID,
+		&assignment.CourseID,
+		&cohortID,
+		&assignment.Title,
+		&assignment.Description,
+		&opensAt,
+		&dueAt,
+		&assignment.LatePolicy.PenaltyPerDay,
+		&assignment.LatePolicy.MaxPenalty,
+		&assignment.LatePolicy.GraceMinutes,
+		&assignment.LatePolicy.CloseAfterDays,
+		&assignment.CreatedBy,
+		&createdAt,
+	)
+	if err != nil {
+		return assignment, err
+	}
+	if cohortID.Valid {
+		id := int(cohortID.Int64)
+		assignment.CohortID = &id
+	}
+	assignment.OpensAt = opensAt.Time
+	assignment.DueAt = dueAt.Time
+	assignment.CreatedAt = createdAt.Time
+	return assignment, nil
+}
+
+// queryAssignments выбирает задания вместе с их задачами
+func (s *DBStorage) queryAssignments(query string, args ...interface{}) ([]models.Assignment, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get assignments: %w", err)
+	}
+	defer rows.Close()
+
+	assignments := []models.Assignment{}
+	index := make(map[int]int)
+	for rows.Next() {
+		assignment, err := scanAssignment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan assignment: %w", err)
+		}
+		assignment.TaskIDs = []int{}
+		index[assignment.ID] = len(assignments)
+		assignments = append(assignments, assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate assignments: %w", err)
+	}
+	if len(assignments) == 0 {
+		return assignments, nil
+	}
+
+	placeholders := make([]string, 0, len(assignments))
+	ids := make([]interface{}, 0, len(assignments))
+	for _, assignment := range assignments {
+		placeholders = append(placeholders, "?")
+		ids = append(ids, assignment.ID)
+	}
+
+	taskRows, err := s.DB.Query(`
+		SELECT at.assignment_id, at.task_id
+		FROM assignment_tasks at
+		JOIN tasks t ON at.task_id = t.id
+		WHERE at.assignment_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY at.assignment_id, t.task_order, t.id
+	`, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("get assignment tasks: %w", err)
+	}
+	defer taskRows.Close()
+	for taskRows.Next() {
+		var assignmentID, taskID int
+		if err := taskRows.Scan(&assignmentID, &taskID); err != nil {
+			return nil, fmt.Errorf("scan assignment task: %w", err)
+		}
+		i := index[assignmentID]
+		assignments[i].TaskIDs = append(assignments[i].TaskIDs, taskID)
+	}
+	if err := taskRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate assignment tasks: %w", err)
+	}
+
+	return assignments, nil
+}
+
+// saveAssignmentTasks проверяет, что задачи принадлежат курсу задания, и заменяет состав задания
+func saveAssignmentTasks(tx *sql.Tx, assignment models.Assignment) error {
+	for _, taskID := range assignment.TaskIDs {
+		var exists int
+		err := tx.QueryRow("SELECT COUNT(*) FROM tasks WHERE id = ? AND course_id = ?", taskID, assignment.CourseID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("check task: %w", err)
+		}
+		if exists == 0 {
+			return fmt.Errorf("task %d: %w", taskID, ErrTaskNotFound)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM assignment_tasks WHERE assignment_id = ?", assignment.ID); err != nil {
+		return fmt.Errorf("delete assignment tasks: %w", err)
+	}
+	for _, taskID := range assignment.TaskIDs {
+		if _, err := tx.Exec("INSERT INTO assignment_tasks (assignment_id, task_id) VALUES (?, ?)", assignment.ID, taskID); err != nil {
+			return fmt.Errorf("insert assignment task: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkAssignmentCohort проверяет, что группа задания существует и изучает курс задания
+func checkAssignmentCohort(tx *sql.Tx, assignment models.Assignment) error {
+	if assignment.CohortID == nil {
+		return nil
+	}
+
+	var cohort, course int
+	err := tx.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM cohorts WHERE id = ?),
+			(SELECT COUNT(*) FROM cohort_courses WHERE cohort_id = ? AND course_id = ?)
+	`, *assignment.CohortID, *assignment.CohortID, assignment.CourseID).Scan(&cohort, &course)
+	if err != nil {
+		return fmt.Errorf("check cohort: %w", err)
+	}
+	if cohort == 0 {
+		return ErrCohortNotFound
+	}
+	if course == 0 {
+		return ErrCourseNotInCohort
+	}
+	return nil
+}
+
+func nullableInt(value *int) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func (s *DBStorage) CreateAssignment(assignment models.Assignment) (models.Assignment, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return assignment, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM courses WHERE id = ?", assignment.CourseID).Scan(&exists); err != nil {
+		return assignment, fmt.Errorf("check course: %w", err)
+	}
+	if exists == 0 {
+		return assignment, ErrCourseNotFound
+	}
+	if err := checkAssignmentCohort(tx, assignment); err != nil {
+		return assignment, err
+	}
+
+	assignment.CreatedAt = time.Now().UTC()
+	var createdBy interface{}
+	if assignment.CreatedBy > 0 {
+		createdBy = assignment.CreatedBy
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO assignments (
+			course_id, cohort_id, title, description, opens_at, due_at,
+			penalty_per_day, max_penalty, grace_minutes, close_after_days, created_by, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		assignment.CourseID, nullableInt(assignment.CohortID), assignment.Title, assignment.Description,
+		assignment.OpensAt.UTC(), assignment.DueAt.UTC(),
+		assignment.LatePolicy.PenaltyPerDay, assignment.LatePolicy.MaxPenalty,
+		assignment.LatePolicy.GraceMinutes, assignment.LatePolicy.CloseAfterDays,
+		createdBy, assignment.CreatedAt,
+	)
+	if err != nil {
+		return assignment, fmt.Errorf("insert assignment: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return assignment, fmt.Errorf("get assignment id: %w", err)
+	}
+	assignment.ID = int(id)
+
+	if err := saveAssignmentTasks(tx, assignment); err != nil {
+		return assignment, err
+	}
+	if err := tx.Commit(); err != nil {
+		return assignment, fmt.Errorf("commit transaction: %w", err)
+	}
+	return assignment, nil
+}
+
+func (s *DBStorage) UpdateAssignment(assignment models.Assignment) (models.Assignment, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return assignment, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkAssignmentCohort(tx, assignment); err != nil {
+		return assignment, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE assignments
+		SET cohort_id = ?, title = ?, description = ?, opens_at = ?, due_at = ?,
+			penalty_per_day = ?, max_penalty = ?, grace_minutes = ?, close_after_days = ?
+		WHERE id = ? AND course_id = ?
+	`,
+		nullableInt(assignment.CohortID), assignment.Title, assignment.Description,
+		assignment.OpensAt.UTC(), assignment.DueAt.UTC(),
+		assignment.LatePolicy.PenaltyPerDay, assignment.LatePolicy.MaxPenalty,
+		assignment.LatePolicy.GraceMinutes, assignment.LatePolicy.CloseAfterDays,
+		assignment.ID, assignment.CourseID,
+	)
+	if err != nil {
+		return assignment, fmt.Errorf("update assignment: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return assignment, fmt.Errorf("get affected rows: %w", err)
+	} else if affected == 0 {
+		return assignment, ErrAssignmentNotFound
+	}
+
+	if err := saveAssignmentTasks(tx, assignment); err != nil {
+		return assignment, err
+	}
+	if err := tx.Commit(); err != nil {
+		return assignment, fmt.Errorf("commit transaction: %w", err)
+	}
+	return s.GetAssignment(assignment.CourseID, assignment.ID)
+}
+
+func (s *DBStorage) DeleteAssignment(courseID, assignmentID int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM assignments WHERE id = ? AND course_id = ?", assignmentID, courseID)
+	if err != nil {
+		return fmt.Errorf("delete assignment: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if affected == 0 {
+		return ErrAssignmentNotFound
+	}
+
+	for _, table := range []string{"assignment_tasks", "assignment_extensions", "assignment_reminders"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE assignment_id = ?", assignmentID); err != nil {
+			return fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStorage) GetAssignment(courseID, assignmentID int) (models.Assignment, error) {
+	assignments, err := s.queryAssignments(
+		"SELECT "+assignmentColumns+" FROM assignments a WHERE a.id = ? AND a.course_id = ?",
+		assignmentID, courseID,
+	)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if len(assignments) == 0 {
+		return models.Assignment{}, ErrAssignmentNotFound
+	}
+	return assignments[0], nil
+}
+
+func (s *DBStorage) GetCourseAssignments(courseID int) ([]models.Assignment, error) {
+	return s.queryAssignments(
+		"SELECT "+assignmentColumns+" FROM assignments a WHERE a.course_id = ? ORDER BY a.due_at, a.id",
+		courseID,
+	)
+}
+
+// GrantExtension устанавливает индивидуальный срок студента, заменяя прежнее продление
+func (s *DBStorage) GrantExtension(extension models.AssignmentExtension) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var grantedBy interface{}
+	if extension.GrantedBy > 0 {
+		grantedBy = extension.GrantedBy
+	}
+
+	if _, err := tx.Exec(
+		"DELETE FROM assignment_extensions WHERE assignment_id = ? AND user_id = ?",
+		extension.AssignmentID, extension.UserID,
+	); err != nil {
+		return fmt.Errorf("delete extension: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO assignment_extensions (assignment_id, user_id, due_at, reason, granted_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, extension.AssignmentID, extension.UserID, extension.DueAt.UTC(), extension.Reason, grantedBy, time.Now().UTC()); err != nil {
+		return fmt.Errorf("insert extension: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// userAssignments выбирает задания, действующие для пользователя: задания всего курса.
+// taskID > 0 ограничивает выборку заданиями с этой задачей.
+func (s *DBStorage) userAssignments(userID, taskID int) ([]models.Assignment, error) {
+	query := "SELECT " + assignmentColumns + ` FROM assignments a
+		WHERE (a.cohort_id IS NULL OR a.cohort_id IN (SELECT cohort_id FROM cohort_members WHERE user_id = ?))`
+	args := []interface{}{userID}
+	if taskID > 0 {
+		query += " AND EXISTS (SELECT 1 FROM assignment_tasks at WHERE at.assignment_id = a.id AND at.task_id = ?)"
+		args = append(args, taskID)
+	}
+	return s.queryAssignments(query+" ORDER BY a.due_at, a.id", args...)
+}
+
+func (s *DBStorage) userExtensions(userID int) (map[int]time.Time, error) {
+	rows, err := s.DB.Query("SELECT assignment_id, due_at FROM assignment_extensions WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, fmt.Errorf("get extensions: %w", err)
+	}
+	defer rows.Close()
+
+	extensions := make(map[int]time.Time)
+	for rows.Next() {
+		var assignmentID int
+		var dueAt nullTime
+		if err := rows.Scan(&assignmentID, &dueAt); err != nil {
+			return nil, fmt.Errorf("scan extension: %w", err)
+		}
+		extensions[assignmentID] = dueAt.Time
+	}
+	return extensions, rows.Err()
+}
+
+// GetUserDeadlines возвращает действующие сроки задач пользователя с учетом продлений
+func (s *DBStorage) GetUserDeadlines(userID int) ([]models.TaskDeadline, error) {
+	return s.userDeadlines(userID, 0)
+}
+
+func (s *DBStorage) userDeadlines(userID, taskID int) ([]models.TaskDeadline, error) {
+	assignments, err := s.userAssignments(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(assignments) == 0 {
+		return []models.TaskDeadline{}, nil
+	}
+
+	extensions, err := s.userExtensions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deadlines := models.ResolveDeadlines(assignments, extensions)
+	if taskID > 0 {
+		for _, deadline := range deadlines {
+			if deadline.TaskID == taskID {
+				return []models.TaskDeadline{deadline}, nil
+			}
+		}
+		return []models.TaskDeadline{}, nil
+	}
+	return deadlines, nil
+}
+
+// GetPendingReminders возвращает напоминания о сроках, наступающих в интервале (now, now+window],
+// для студентов курса, которые еще не решили все задачи задания и не получали напоминание об этом сроке.
+// Студентами курса считаются пользователи с любой активностью по его задачам.
+func (s *DBStorage) GetPendingReminders(now time.Time, window time.Duration) ([]models.DeadlineReminder, error) {
+	from, to := now.UTC(), now.Add(window).UTC()
+
+	assignments, err := s.queryAssignments(`
+		SELECT `+assignmentColumns+`
+		FROM assignments a
+		WHERE (
+			(a.due_at > ? AND a.due_at <= ?)
+			OR EXISTS (
+				SELECT 1 FROM assignment_extensions ae
+				WHERE ae.assignment_id = a.id AND ae.due_at > ? AND ae.due_at <= ?
+			)
+		)
+		ORDER BY a.due_at, a.id
+	`, from, to, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []models.DeadlineReminder
+	for _, assignment := range assignments {
+		courseReminders, err := s.assignmentReminders(assignment, now, window)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, courseReminders...)
+	}
+	return reminders, nil
+}
+
+// assignmentReminders выбирает студентов задания: участников группы для задания группы,
+// иначе всех, кто занимался курсом
+func (s *DBStorage) assignmentReminders(assignment models.Assignment, now time.Time, window time.Duration) ([]models.DeadlineReminder, error) {
+	students := `
+			EXISTS (
+				SELECT 1 FROM user_progress up JOIN tasks t ON up.task_id = t.id
+				WHERE up.user_id = u.id AND t.course_id = c.id
+			)
+			OR EXISTS (SELECT 1 FROM task_submissions ts WHERE ts.user_id = u.id AND ts.course_id = c.id)
+			OR EXISTS (SELECT 1 FROM learning_activities la WHERE la.user_id = u.id AND la.course_id = c.id)`
+	args := []interface{}{assignment.CourseID, assignment.ID}
+	if assignment.CohortID != nil {
+		students = "EXISTS (SELECT 1 FROM cohort_members cm WHERE cm.user_id = u.id AND cm.cohort_id = ?)"
+		args = append(args, *assignment.CohortID)
+	}
+
+	rows, err := s.DB.Query(`
+		SELECT u.id, u.username, u.email, c.vulnerability_type, ae.due_at
+		FROM users u
+		JOIN courses c ON c.id = ?
+		LEFT JOIN assignment_extensions ae ON ae.assignment_id = ? AND ae.user_id = u.id
+		WHERE u.is_deleted = FALSE AND u.is_active = TRUE AND (`+students+`
+		)
+		ORDER BY u.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get course students: %w", err)
+	}
+
+	var reminders []models.DeadlineReminder
+	for rows.Next() {
+		reminder := models.DeadlineReminder{
+			AssignmentID:    assignment.ID,
+			AssignmentTitle: assignment.Title,
+			CourseID:        assignment.CourseID,
+			DueAt:           assignment.DueAt,
+		}
+		var extendedDue nullTime
+		if err := rows.Scan(&reminder.UserID, &reminder.Username, &reminder.Email, &reminder.CourseName, &extendedDue); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan course student: %w", err)
+		}
+		if extendedDue.Valid {
+			reminder.DueAt = extendedDue.Time
+		}
+		if reminder.DueAt.After(now) && !reminder.DueAt.After(now.Add(window)) {
+			reminders = append(reminders, reminder)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("iterate course students: %w", err)
+	}
+	rows.Close()
+
+	pending := reminders[:0]
+	for _, reminder := range reminders {
+		var sent int
+		err := s.DB.QueryRow(
+			"SELECT COUNT(*) FROM assignment_reminders WHERE assignment_id = ? AND user_id = ? AND due_at = ?",
+			reminder.AssignmentID, reminder.UserID, reminder.DueAt.UTC(),
+		).Scan(&sent)
+		if err != nil {
+			return nil, fmt.Errorf("check reminder: %w", err)
+		}
+		if sent > 0 {
+			continue
+		}
+
+		var completed int
+		for _, taskID := range assignment.TaskIDs {
+			var exists int
+			err := s.DB.QueryRow("SELECT COUNT(*) FROM user_progress WHERE user_id = ? AND task_id = ?", reminder.UserID, taskID).Scan(&exists)
+			if err != nil {
+				return nil, fmt.Errorf("check progress: %w", err)
+			}
+			completed += exists
+		}
+		reminder.RemainingTasks = len(assignment.TaskIDs) - completed
+		if reminder.RemainingTasks > 0 {
+			pending = append(pending, reminder)
+		}
+	}
+	return pending, nil
+}
+
+// MarkReminderSent отмечает, что напоминание об этом сроке отправлено
+func (s *DBStorage) MarkReminderSent(reminder models.DeadlineReminder, sentAt time.Time) error {
+	_, err := s.DB.Exec(
+		"INSERT INTO assignment_reminders (assignment_id, user_id, due_at, sent_at) VALUES (?, ?, ?, ?)",
+		reminder.AssignmentID, reminder.UserID, reminder.DueAt.UTC(), sentAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("save reminder: %w", err)
+	}
+	return nil
+}
+
+// ****** МЕТОДЫ ДЛЯ УЧЕБНЫХ ГРУПП ******
+
+var (
+	ErrCohortNotFound       = errors.New("cohort not found")
+	ErrCohortNameTaken      = errors.New("cohort name already taken")
+	ErrCohortMemberNotFound = errors.New("user is not a member of the cohort")
+	ErrCourseNotInCohort    = errors.New("course is not assigned to the cohort")
+)
+
+const cohortColumns = `
+	c.id, c.name, c.description, c.invite_code, c.created_by, c.created_at,
+	(SELECT COUNT(*) FROM cohort_members cm WHERE cm.cohort_id = c.id) AS members_count`
+
+var cohortSortColumns = map[string]string{
+	"id":         "c.id",
+	"name":       "c.name",
+	"created_at": "c.created_at",
+}
+
+var cohortMemberSortColumns = map[string]string{
+	"user_id":   "u.id",
+	"username":  "u.username",
+	"joined_at": "cm.joined_at",
+}
+
+var cohortSubmissionSortColumns = map[string]string{
+	"submitted_at": "ts.submitted_at",
+	"username":     "u.username",
+	"task_id":      "ts.task_id",
+	"score":        "ts.score",
+}
+
+// cohortMemberFilter возвращает условие отбора студентов группы по колонке с ID пользователя
+func cohortMemberFilter(column string, cohortID int) (string, []interface{}) {
+	if cohortID <= 0 {
+		return "", nil
+	}
+	return " AND " + column + " IN (SELECT user_id FROM cohort_members WHERE cohort_id = ?)", []interface{}{cohortID}
+}
+
+// courseStudentFilter возвращает условие отбора студентов статистики курса: участников группы
+// params.CohortID и групп преподавателя params.TeacherID, которым назначен курс
+func courseStudentFilter(column string, courseID int, params models.ListParams) (string, []interface{}) {
+	filter, args := cohortMemberFilter(column, params.CohortID)
+	if params.TeacherID > 0 {
+		filter += " AND " + column + ` IN (
+			SELECT cm.user_id FROM cohort_members cm
+			JOIN cohort_teachers ct ON ct.cohort_id = cm.cohort_id
+			JOIN cohort_courses cc ON cc.cohort_id = cm.cohort_id
+			WHERE ct.user_id = ? AND cc.course_id = ?)`
+		args = append(args, params.TeacherID, courseID)
+	}
+	return filter, args
+}
+
+// queryCohorts выбирает группы вместе с курсами и преподавателями
+func (s *DBStorage) queryCohorts(query string, args ...interface{}) ([]models.Cohort, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get cohorts: %w", err)
+	}
+	defer rows.Close()
+
+	cohorts := []models.Cohort{}
+	index := make(map[int]int)
+	for rows.Next() {
+		var cohort models.Cohort
+		var description, inviteCode sql.NullString
+		var createdBy sql.NullInt64
+		var createdAt nullTime
+		if err := rows.Scan(
+			&cohort.ID, &cohort.Name, &description, &inviteCode, &createdBy, &createdAt, &cohort.MembersCount,
+		); err != nil {
+			return nil, fmt.Errorf("scan cohort: %w", err)
+		}
+		cohort.Description = description.String
+		cohort.InviteCode = inviteCode.String
+		cohort.CreatedBy = int(createdBy.Int64)
+		cohort.CreatedAt = createdAt.Time
+		cohort.CourseIDs = []int{}
+		cohort.TeacherIDs = []int{}
+
+		index[cohort.ID] = len(cohorts)
+		cohorts = append(cohorts, cohort)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate cohorts: %w", err)
+	}
+	if len(cohorts) == 0 {
+		return cohorts, nil
+	}
+
+	placeholders := make([]string, 0, len(cohorts))
+	ids := make([]interface{}, 0, len(cohorts))
+	for _, cohort := range cohorts {
+		placeholders = append(placeholders, "?")
+		ids = append(ids, cohort.ID)
+	}
+
+	links := []struct {
+		table, column string
+		add           func(cohort *models.Cohort, id int)
+	}{
+		{"cohort_courses", "course_id", func(cohort *models.Cohort, id int) { cohort.CourseIDs = append(cohort.CourseIDs, id) }},
+		{"cohort_teachers", "user_id", func(cohort *models.Cohort, id int) { cohort.TeacherIDs = append(cohort.TeacherIDs, id) }},
+	}
+	for _, link := range links {
+		linkRows, err := s.DB.Query(
+			"SELECT cohort_id, "+link.column+" FROM "+link.table+
+				" WHERE cohort_id IN ("+strings.Join(placeholders, ", ")+") ORDER BY cohort_id, "+link.column,
+			ids...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %w", link.table, err)
+		}
+		for linkRows.Next() {
+			var cohortID, id int
+			if err := linkRows.Scan(&cohortID, &id); err != nil {
+				linkRows.Close()
+				return nil, fmt.Errorf("scan %s: %w", link.table, err)
+			}
+			link.add(&cohorts[index[cohortID]], id)
+		}
+		err = linkRows.Err()
+		linkRows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("iterate %s: %w", link.table, err)
+		}
+	}
+
+	return cohorts, nil
+}
+
+// checkCohortName возвращает ErrCohortNameTaken, если название занято другой группой
+func checkCohortName(tx *sql.Tx, name string, cohortID int) error {
+	var taken int
+	err := tx.QueryRow("SELECT COUNT(*) FROM cohorts WHERE name = ? AND id <> ?", name, cohortID).Scan(&taken)
+	if err != nil {
+		return fmt.Errorf("check cohort name: %w", err)
+	}
+	if taken > 0 {
+		return ErrCohortNameTaken
+	}
+	return nil
+}
+
+// saveCohort проверяет уникальность названия и курсы группы и заменяет курсы и преподавателей
+func saveCohort(tx *sql.Tx, cohort models.Cohort) error {
+	if err := checkCohortName(tx, cohort.Name, cohort.ID); err != nil {
+		return err
+	}
+
+	for _, courseID := range cohort.CourseIDs {
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM courses WHERE id = ?", courseID).Scan(&exists); err != nil {
+			return fmt.Errorf("check course: %w", err)
+		}
+		if exists == 0 {
+			return fmt.Errorf("course %d: %w", courseID, ErrCourseNotFound)
+		}
+	}
+
+	for _, table := range []string{"cohort_courses", "cohort_teachers"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE cohort_id = ?", cohort.ID); err != nil {
+			return fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+	for _, courseID := range cohort.CourseIDs {
+		if _, err := tx.Exec("INSERT INTO cohort_courses (cohort_id, course_id) VALUES (?, ?)", cohort.ID, courseID); err != nil {
+			return fmt.Errorf("insert cohort course: %w", err)
+		}
+	}
+	for _, teacherID := range cohort.TeacherIDs {
+		if _, err := tx.Exec("INSERT INTO cohort_teachers (cohort_id, user_id) VALUES (?, ?)", cohort.ID, teacherID); err != nil {
+			return fmt.Errorf("insert cohort teacher: %w", err)
+		}
+	}
+	return nil
+}
+
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func (s *DBStorage) CreateCohort(cohort models.Cohort) (models.Cohort, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return cohort, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkCohortName(tx, cohort.Name, 0); err != nil {
+		return cohort, err
+	}
+
+	var createdBy interface{}
+	if cohort.CreatedBy > 0 {
+		createdBy = cohort.CreatedBy
+	}
+	result, err := tx.Exec(
+		"INSERT INTO cohorts (name, description, invite_code, created_by, created_at) VALUES (?, ?, ?, ?, ?)",
+		cohort.Name, cohort.Description, nullableString(cohort.InviteCode), createdBy, time.Now().UTC(),
+	)
+	if err != nil {
+		return cohort, fmt.Errorf("insert cohort: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return cohort, fmt.Errorf("get cohort id: %w", err)
+	}
+	cohort.ID = int(id)
+
+	if err := saveCohort(tx, cohort); err != nil {
+		return cohort, err
+	}
+	if err := tx.Commit(); err != nil {
+		return cohort, fmt.Errorf("commit transaction: %w", err)
+	}
+	return s.GetCohort(cohort.ID)
+}
+
+// UpdateCohort изменяет название, описание, курсы и преподавателей группы. Код приглашения не меняется.
+func (s *DBStorage) UpdateCohort(cohort models.Cohort) (models.Cohort, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return cohort, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM cohorts WHERE id = ?", cohort.ID).Scan(&exists); err != nil {
+		return cohort, fmt.Errorf("check cohort: %w", err)
+	}
+	if exists == 0 {
+		return cohort, ErrCohortNotFound
+	}
+
+	if err := saveCohort(tx, cohort); err != nil {
+		return cohort, err
+	}
+	if _, err := tx.Exec("UPDATE cohorts SET name = ?, description = ? WHERE id = ?", cohort.Name, cohort.Description, cohort.ID); err != nil {
+		return cohort, fmt.Errorf("update cohort: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return cohort, fmt.Errorf("commit transaction: %w", err)
+	}
+	return s.GetCohort(cohort.ID)
+}
+
+// DeleteCohort удаляет группу вместе с составом и заданиями группы
+func (s *DBStorage) DeleteCohort(cohortID int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM cohorts WHERE id = ?", cohortID)
+	if err != nil {
+		return fmt.Errorf("delete cohort: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if affected == 0 {
+		return ErrCohortNotFound
+	}
+
+	for _, table := range []string{"assignment_tasks", "assignment_extensions", "assignment_reminders"} {
+		_, err := tx.Exec("DELETE FROM "+table+" WHERE assignment_id IN (SELECT id FROM assignments WHERE cohort_id = ?)", cohortID)
+		if err != nil {
+			return fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+	for _, table := range []string{"assignments", "cohort_members", "cohort_teachers", "cohort_courses"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE cohort_id = ?", cohortID); err != nil {
+			return fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStorage) GetCohort(cohortID int) (models.Cohort, error) {
+	cohorts, err := s.queryCohorts("SELECT "+cohortColumns+" FROM cohorts c WHERE c.id = ?", cohortID)
+	if err != nil {
+		return models.Cohort{}, err
+	}
+	if len(cohorts) == 0 {
+		return models.Cohort{}, ErrCohortNotFound
+	}
+	return cohorts[0], nil
+}
+
+func (s *DBStorage) GetCohortByInviteCode(code string) (models.Cohort, error) {
+	cohorts, err := s.queryCohorts("SELECT "+cohortColumns+" FROM cohorts c WHERE c.invite_code = ?", code)
+	if err != nil {
+		return models.Cohort{}, err
+	}
+	if len(cohorts) == 0 {
+		return models.Cohort{}, ErrCohortNotFound
+	}
+	return cohorts[0], nil
+}
+
+// GetCohorts возвращает группы преподавателя, при teacherID = 0 - все группы
+func (s *DBStorage) GetCohorts(teacherID int, params models.ListParams) ([]models.Cohort, int, error) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+
+	if teacherID > 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM cohort_teachers ct WHERE ct.cohort_id = c.id AND ct.user_id = ?)")
+		args = append(args, teacherID)
+	}
+	if params.CourseID > 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM cohort_courses cc WHERE cc.cohort_id = c.id AND cc.course_id = ?)")
+		args = append(args, params.CourseID)
+	}
+
+	where := " WHERE " + strings.Join(conditions, " AND ")
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM cohorts c"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count cohorts: %w", err)
+	}
+
+	if params.IsCursor() {
+		where += " AND c.id > ?"
+		args = append(args, params.Cursor)
+	}
+	query := "SELECT " + cohortColumns + " FROM cohorts c" + where +
+		orderByClause(params, cohortSortColumns, "c.name ASC, c.id ASC", "c.id") +
+		limitClause(params)
+	args = append(args, limitArgs(params)...)
+
+	cohorts, err := s.queryCohorts(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cohorts, total, nil
+}
+
+func (s *DBStorage) SetCohortInviteCode(cohortID int, code string) error {
+	result, err := s.DB.Exec("UPDATE cohorts SET invite_code = ? WHERE id = ?", nullableString(code), cohortID)
+	if err != nil {
+		return fmt.Errorf("update invite code: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if affected == 0 {
+		return ErrCohortNotFound
+	}
+	return nil
+}
+
+func (s *DBStorage) IsCohortTeacher(cohortID, userID int) (bool, error) {
+	var count int
+	err := s.DB.QueryRow(
+		"SELECT COUNT(*) FROM cohort_teachers WHERE cohort_id = ? AND user_id = ?", cohortID, userID,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("check cohort teacher: %w", err)
+	}
+	return count > 0, nil
+}
+
+// AddCohortMembers добавляет студентов в группу. Уже состоящие в группе пропускаются,
+// ID несуществующих пользователей возвращаются в NotFound.
+func (s *DBStorage) AddCohortMembers(cohortID int, userIDs []int) (models.CohortImportResult, error) {
+	result := models.CohortImportResult{Added: []int{}, AlreadyMembers: []int{}, NotFound: []string{}}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return result, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM cohorts WHERE id = ?", cohortID).Scan(&exists); err != nil {
+		return result, fmt.Errorf("check cohort: %w", err)
+	}
+	if exists == 0 {
+		return result, ErrCohortNotFound
+	}
+
+	joinedAt := time.Now().UTC()
+	for _, userID := range userIDs {
+		var user, member int
+		err := tx.QueryRow(`
+			SELECT
+				(SELECT COUNT(*) FROM users WHERE id = ? AND is_deleted = FALSE),
+				(SELECT COUNT(*) FROM cohort_members WHERE cohort_id = ? AND user_id = ?)
+		`, userID, cohortID, userID).Scan(&user, &member)
+		if err != nil {
+			return result, fmt.Errorf("check member: %w", err)
+		}
+
+		switch {
+		case user == 0:
+			result.NotFound = append(result.NotFound, strconv.Itoa(userID))
+		case member > 0:
+			result.AlreadyMembers = append(result.AlreadyMembers, userID)
+		default:
+			_, err := tx.Exec("INSERT INTO cohort_members (cohort_id, user_id, joined_at) VALUES (?, ?, ?)", cohortID, userID, joinedAt)
+			if err != nil {
+				return result, fmt.Errorf("insert member: %w", err)
+			}
+			result.Added = append(result.Added, userID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+func (s *DBStorage) RemoveCohortMember(cohortID, userID int) error {
+	result, err := s.DB.Exec("DELETE FROM cohort_members WHERE cohort_id = ? AND user_id = ?", cohortID, userID)
+	if err != nil {
+		return fmt.Errorf("delete member: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if affected == 0 {
+		return ErrCohortMemberNotFound
+	}
+	return nil
+}
+
+func (s *DBStorage) GetCohortMembers(cohortID int, params models.ListParams) ([]models.CohortMember, int, error) {
+	conditions := []string{"cm.cohort_id = ?", "u.is_deleted = FALSE"}
+	args := []interface{}{cohortID}
+	if params.IsActive != nil {
+		conditions = append(conditions, "u.is_active = ?")
+		args = append(args, *params.IsActive)
+	}
+
+	from := " FROM cohort_members cm JOIN users u ON u.id = cm.user_id WHERE "
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*)"+from+strings.Join(conditions, " AND "), args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count members: %w", err)
+	}
+
+	if params.IsCursor() {
+		conditions = append(conditions, "u.id > ?")
+		args = append(args, params.Cursor)
+	}
+	query := "SELECT u.id, u.username, u.full_name, u.email, cm.joined_at" + from + strings.Join(conditions, " AND ") +
+		orderByClause(params, cohortMemberSortColumns, "u.username ASC, u.id ASC", "u.id") +
+		limitClause(params)
+	args = append(args, limitArgs(params)...)
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.CohortMember{}
+	for rows.Next() {
+		var member models.CohortMember
+		var fullName sql.NullString
+		var joinedAt nullTime
+		if err := rows.Scan(&member.UserID, &member.Username, &fullName, &member.Email, &joinedAt); err != nil {
+			return nil, 0, fmt.Errorf("scan member: %w", err)
+		}
+		member.FullName = fullName.String
+		member.JoinedAt = joinedAt.Time
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate members: %w", err)
+	}
+	return members, total, nil
+}
+
+// ResolveUserIDs находит пользователей по имени пользователя или email без учета регистра
+func (s *DBStorage) ResolveUserIDs(logins []string) (map[string]int, error) {
+	ids := make(map[string]int)
+	for _, login := range logins {
+		var id int
+		err := s.DB.QueryRow(
+			"SELECT id FROM users WHERE (LOWER(username) = LOWER(?) OR LOWER(email) = LOWER(?)) AND is_deleted = FALSE",
+			login, login,
+		).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("find user %q: %w", login, err)
+		}
+		ids[login] = id
+	}
+	return ids, nil
+}
+
+// GetCohortLeaderboard возвращает рейтинг студентов группы по курсу или по всем курсам группы
+func (s *DBStorage) GetCohortLeaderboard(cohortID, courseID, limit int) ([]models.LeaderboardEntry, error) {
+	return s.leaderboard(cohortID, courseID, limit)
+}
+
+// GetCohortSubmissions возвращает очередь попыток сдачи студентов группы по курсам группы
+func (s *DBStorage) GetCohortSubmissions(cohortID int, params models.ListParams) ([]models.CohortSubmission, int, error) {
+	conditions := []string{
+		"ts.user_id IN (SELECT user_id FROM cohort_members WHERE cohort_id = ?)",
+		"ts.course_id IN (SELECT course_id FROM cohort_courses WHERE cohort_id = ?)",
+	}
+	args := []interface{}{cohortID, cohortID}
+
+	if params.CourseID > 0 {
+		conditions = append(conditions, "ts.course_id = ?")
+		args = append(args, params.CourseID)
+	}
+	if !params.From.IsZero() {
+		conditions = append(conditions, "ts.submitted_at >= ?")
+		args = append(args, params.From.UTC())
+	}
+	if !params.To.IsZero() {
+		conditions = append(conditions, "ts.submitted_at < ?")
+		args = append(args, params.To.UTC())
+	}
+
+	from := `
+		FROM task_submissions ts
+		JOIN users u ON u.id = ts.user_id
+		JOIN tasks t ON t.id = ts.task_id
+		WHERE `
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*)"+from+strings.Join(conditions, " AND "), args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count submissions: %w", err)
+	}
+
+	if params.IsCursor() {
+		conditions = append(conditions, "ts.id > ?")
+		args = append(args, params.Cursor)
+	}
+	query := `
+		SELECT ts.id, ts.user_id, u.username, ts.task_id, t.title, ts.course_id,
+			ts.is_correct, ts.score, ts.is_late, ts.penalty_percent, ts.submitted_at` +
+		from + strings.Join(conditions, " AND ") +
+		orderByClause(params, cohortSubmissionSortColumns, "ts.submitted_at DESC, ts.id DESC", "ts.id") +
+		limitClause(params)
+	args = append(args, limitArgs(params)...)
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get submissions: %w", err)
+	}
+	defer rows.Close()
+
+	submissions := []models.CohortSubmission{}
+	for rows.Next() {
+		var submission models.CohortSubmission
+		var submittedAt nullTime
+		if err := rows.Scan(
+			&submission.SubmissionID, &submission.UserID, &submission.Username,
+			&submission.TaskID, &submission.TaskTitle, &submission.CourseID,
+			&submission.IsCorrect, &submission.Score, &submission.IsLate, &submission.Penalty, &submittedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("scan submission: %w", err)
+		}
+		submission.SubmittedAt = submittedAt.Time
+		submissions = append(submissions, submission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate submissions: %w", err)
+	}
+	return submissions, total, nil
+}
+
+// ****** МЕТОДЫ ДЛЯ ТЕСТОВ ******
+
+var (
+	ErrQuestionBankNotFound  = errors.New("question bank not found")
+	ErrQuestionBankInUse     = errors.New("question bank is used by a quiz")
+	ErrNotQuizTask           = errors.New("task is not a quiz")
+	ErrQuizTask              = errors.New("quiz tasks are answered through quiz attempts")
+	ErrQuizHasNoQuestions    = errors.New("quiz has no questions")
+	ErrQuizAttemptNotFound   = errors.New("quiz attempt not found")
+	ErrQuizAttemptsExhausted = errors.New("no quiz attempts left")
+	ErrQuizAttemptFinished   = errors.New("quiz attempt is already finished")
+)
+
+// taskType возвращает тип задачи, по умолчанию - задача с кодом
+func taskType(task models.Task) string {
+	if task.Type == "" {
+		return models.TaskTypeCode
+	}
+	return task.Type
+}
+
+// saveQuizSettings заменяет параметры теста задачи. Банки вопросов должны принадлежать курсу задачи.
+func saveQuizSettings(tx *sql.Tx, courseID int, task models.Task) error {
+	for _, table := range []string{"quiz_sources", "quiz_settings"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE task_id = ?", task.ID); err != nil {
+			return fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+	if task.Type != models.TaskTypeQuiz || task.Quiz == nil {
+		return nil
+	}
+
+	quiz := task.Quiz
+	_, err := tx.Exec(`
+		INSERT INTO quiz_settings (task_id, time_limit_minutes, max_attempts, passing_score, shuffle_questions, shuffle_options)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, task.ID, quiz.TimeLimitMinutes, quiz.MaxAttempts, quiz.PassingScore, quiz.ShuffleQuestions, quiz.ShuffleOptions)
+	if err != nil {
+		return fmt.Errorf("insert quiz settings: %w", err)
+	}
+
+	for i, source := range quiz.Sources {
+		var exists int
+		err := tx.QueryRow("SELECT COUNT(*) FROM question_banks WHERE id = ? AND course_id = ?", source.BankID, courseID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("check question bank: %w", err)
+		}
+		if exists == 0 {
+			return fmt.Errorf("bank %d: %w", source.BankID, ErrQuestionBankNotFound)
+		}
+		_, err = tx.Exec(
+			"INSERT INTO quiz_sources (task_id, bank_id, question_count, position) VALUES (?, ?, ?, ?)",
+			task.ID, source.BankID, source.Count, i+1,
+		)
+		if err != nil {
+			return fmt.Errorf("insert quiz source: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *DBStorage) quizSettings(taskID int) (*models.QuizSettings, error) {
+	quiz := &models.QuizSettings{Sources: []models.QuizSource{}}
+	err := s.DB.QueryRow(`
+		SELECT time_limit_minutes, max_attempts, passing_score, shuffle_questions, shuffle_options
+		FROM quiz_settings
+		WHERE task_id = ?
+	`, taskID).Scan(&quiz.TimeLimitMinutes, &quiz.MaxAttempts, &quiz.PassingScore, &quiz.ShuffleQuestions, &quiz.ShuffleOptions)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get quiz settings: %w", err)
+	}
+
+	rows, err := s.DB.Query("SELECT bank_id, question_count FROM quiz_sources WHERE task_id = ? ORDER BY position", taskID)
+	if err != nil {
+		return nil, fmt.Errorf("get quiz sources: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var source models.QuizSource
+		if err := rows.Scan(&source.BankID, &source.Count); err != nil {
+			return nil, fmt.Errorf("scan quiz source: %w", err)
+		}
+		quiz.Sources = append(quiz.Sources, source)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate quiz sources: %w", err)
+	}
+	return quiz, nil
+}
+
+// taskCourseID возвращает курс задачи
+func (s *DBStorage) taskCourseID(taskID int) (int, error) {
+	var courseID int
+	err := s.DB.QueryRow("SELECT course_id FROM tasks WHERE id = ?", taskID).Scan(&courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrTaskNotFound
+		}
+		return 0, fmt.Errorf("get task course: %w", err)
+	}
+	return courseID, nil
+}
+
+// insertBankQuestions сохраняет вопросы банка в заданном порядке
+func insertBankQuestions(tx *sql.Tx, bankID int, questions []models.QuizQuestion) error {
+	for i, question := range questions {
+		result, err := tx.Exec(
+			"INSERT INTO quiz_questions (bank_id, type, text, points, position) VALUES (?, ?, ?, ?, ?)",
+			bankID, question.Type, question.Text, question.Points, i+1,
+		)
+		if err != nil {
+			return fmt.Errorf("insert question: %w", err)
+		}
+		questionID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("get question id: %w", err)
+		}
+
+		for _, j := range optionInsertOrder(question) {
+			option := question.Options[j]
+			_, err := tx.Exec(
+				"INSERT INTO quiz_question_options (question_id, text, is_correct, position) VALUES (?, ?, ?, ?)",
+				questionID, option.Text, option.IsCorrect || question.Type == models.QuestionShortAnswer, j+1,
+			)
+			if err != nil {
+				return fmt.Errorf("insert question option: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteBankQuestions удаляет вопросы банка вместе с вариантами ответа
+func deleteBankQuestions(tx *sql.Tx, bankID int) error {
+	_, err := tx.Exec(
+		"DELETE FROM quiz_question_options WHERE question_id IN (SELECT id FROM quiz_questions WHERE bank_id = ?)",
+		bankID,
+	)
+	if err != nil {
+		return fmt.Errorf("delete question options: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM quiz_questions WHERE bank_id = ?", bankID); err != nil {
+		return fmt.Errorf("delete questions: %w", err)
+	}
+	return nil
+}
+
+// bankQuestions возвращает вопросы банка с вариантами ответа в исходном порядке
+func (s *DBStorage) bankQuestions(bankID int) ([]models.QuizQuestion, error) {
+	rows, err := s.DB.Query(`
+		SELECT q.id, q.type, q.text, q.points, o.id, o.text, o.is_correct, o.position
+		FROM quiz_questions q
+		JOIN quiz_question_options o ON o.question_id = q.id
+		WHERE q.bank_id = ?
+		ORDER BY q.position, o.position
+	`, bankID)
+	if err != nil {
+		return nil, fmt.Errorf("get questions: %w", err)
+	}
+	defer rows.Close()
+
+	questions := []models.QuizQuestion{}
+	for rows.Next() {
+		var question models.QuizQuestion
+		var option models.QuizOption
+		if err := rows.Scan(
+			&question.ID, &question.Type, &question.Text, &question.Points,
+			&option.ID, &option.Text, &option.IsCorrect, &option.Position,
+		); err != nil {
+			return nil, fmt.Errorf("scan question: %w", err)
+		}
+
+		if n := len(questions); n == 0 || questions[n-1].ID != question.ID {
+			questions = append(questions, question)
+		}
+		last := &questions[len(questions)-1]
+		last.Options = append(last.Options, option)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate questions: %w", err)
+	}
+	return questions, nil
+}
+
+func (s *DBStorage) CreateQuestionBank(bank models.QuestionBank) (models.QuestionBank, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return bank, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM courses WHERE id = ?", bank.CourseID).Scan(&exists); err != nil {
+		return bank, fmt.Errorf("check course: %w", err)
+	}
+	if exists == 0 {
+		return bank, ErrCourseNotFound
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO question_banks (course_id, title, created_at) VALUES (?, ?, ?)",
+		bank.CourseID, bank.Title, time.Now().UTC(),
+	)
+	if err != nil {
+		return bank, fmt.Errorf("insert question bank: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return bank, fmt.Errorf("get question bank id: %w", err)
+	}
+	bank.ID = int(id)
+
+	if err := insertBankQuestions(tx, bank.ID, bank.Questions); err != nil {
+		return bank, err
+	}
+	if err := tx.Commit(); err != nil {
+		return bank, fmt.Errorf("commit transaction: %w", err)
+	}
+	return s.GetQuestionBank(bank.CourseID, bank.ID)
+}
+
+// UpdateQuestionBank заменяет название и вопросы банка. Начатые попытки хранят
+// свою копию вопросов и не меняются.
+func (s *DBStorage) UpdateQuestionBank(bank models.QuestionBank) (models.QuestionBank, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return bank, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow("SELECT COUNT(*) FROM question_banks WHERE id = ? AND course_id = ?", bank.ID, bank.CourseID).Scan(&exists)
+	if err != nil {
+		return bank, fmt.Errorf("check question bank: %w", err)
+	}
+	if exists == 0 {
+		return bank, ErrQuestionBankNotFound
+	}
+
+	if _, err := tx.Exec("UPDATE question_banks SET title = ? WHERE id = ?", bank.Title, bank.ID); err != nil {
+		return bank, fmt.Errorf("update question bank: %w", err)
+	}
+	if err := deleteBankQuestions(tx, bank.ID); err != nil {
+		return bank, err
+	}
+	if err := insertBankQuestions(tx, bank.ID, bank.Questions); err != nil {
+		return bank, err
+	}
+	if err := tx.Commit(); err != nil {
+		return bank, fmt.Errorf("commit transaction: %w", err)
+	}
+	return s.GetQuestionBank(bank.CourseID, bank.ID)
+}
+
+// DeleteQuestionBank удаляет банк, если он не используется ни одним тестом
+func (s *DBStorage) DeleteQuestionBank(courseID, bankID int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists, used int
+	err = tx.QueryRow("SELECT COUNT(*) FROM question_banks WHERE id = ? AND course_id = ?", bankID, courseID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check question bank: %w", err)
+	}
+	if exists == 0 {
+		return ErrQuestionBankNotFound
+	}
+	if err := tx.QueryRow("SELECT COUNT(*) FROM quiz_sources WHERE bank_id = ?", bankID).Scan(&used); err != nil {
+		return fmt.Errorf("check quiz sources: %w", err)
+	}
+	if used > 0 {
+		return ErrQuestionBankInUse
+	}
+
+	if err := deleteBankQuestions(tx, bankID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM question_banks WHERE id = ?", bankID); err != nil {
+		return fmt.Errorf("delete question bank: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStorage) GetQuestionBank(courseID, bankID int) (models.QuestionBank, error) {
+	banks, err := s.queryQuestionBanks("WHERE id = ? AND course_id = ?", bankID, courseID)
+	if err != nil {
+		return models.QuestionBank{}, err
+	}
+	if len(banks) == 0 {
+		return models.QuestionBank{}, ErrQuestionBankNotFound
+	}
+	return banks[0], nil
+}
+
+func (s *DBStorage) GetQuestionBanks(courseID int) ([]models.QuestionBank, error) {
+	return s.queryQuestionBanks("WHERE course_id = ?", courseID)
+}
+
+func (s *DBStorage) queryQuestionBanks(where string, args ...interface{}) ([]models.QuestionBank, error) {
+	rows, err := s.DB.Query("SELECT id, course_id, title, created_at FROM question_banks "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("get question banks: %w", err)
+	}
+	defer rows.Close()
+
+	banks := []models.QuestionBank{}
+	for rows.Next() {
+		var bank models.QuestionBank
+		var createdAt nullTime
+		if err := rows.Scan(&bank.ID, &bank.CourseID, &bank.Title, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan question bank: %w", err)
+		}
+		bank.CreatedAt = createdAt.Time
+		banks = append(banks, bank)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate question banks: %w", err)
+	}
+	rows.Close()
+
+	for i := range banks {
+		if banks[i].Questions, err = s.bankQuestions(banks[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return banks, nil
+}
+
+// StartQuizAttempt начинает попытку теста или возвращает незавершенную. Незавершенная попытка
+// с истекшим временем сначала закрывается без ответов.
+func (s *DBStorage) StartQuizAttempt(userID, taskID int, startedAt time.Time) (models.QuizAttempt, error) {
+	courseID, err := s.taskCourseID(taskID)
+	if err != nil {
+		return models.QuizAttempt{}, err
+	}
+	task, err := s.GetTaskByID(courseID, taskID)
+	if err != nil {
+		return models.QuizAttempt{}, fmt.Errorf("get task: %w", err)
+	}
+
+	attempts, err := s.GetQuizAttempts(userID, taskID)
+	if err != nil {
+		return models.QuizAttempt{}, err
+	}
+	for _, attempt := range attempts {
+		if attempt.Status != models.QuizAttemptInProgress {
+			continue
+		}
+		if !attempt.Expired(startedAt) {
+			return s.GetQuizAttempt(attempt.ID)
+		}
+		if _, err := s.SubmitQuizAttempt(attempt.ID, nil, startedAt); err != nil {
+			return models.QuizAttempt{}, fmt.Errorf("close expired attempt: %w", err)
+		}
+	}
+
+	deadlines, err := s.userDeadlines(userID, taskID)
+	if err != nil {
+		return models.QuizAttempt{}, fmt.Errorf("get deadline: %w", err)
+	}
+	if err := checkQuizStart(task, deadlines, len(attempts), startedAt); err != nil {
+		return models.QuizAttempt{}, err
+	}
+
+	banks := make(map[int][]models.QuizQuestion)
+	for _, bankID := range quizBankIDs(task.Quiz) {
+		if banks[bankID], err = s.bankQuestions(bankID); err != nil {
+			return models.QuizAttempt{}, err
+		}
+	}
+	attempt, err := newQuizAttempt(userID, task, banks, startedAt)
+	if err != nil {
+		return attempt, err
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return attempt, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var expiresAt interface{}
+	if attempt.ExpiresAt != nil {
+		expiresAt = attempt.ExpiresAt.UTC()
+	}
+	result, err := tx.Exec(
+		"INSERT INTO quiz_attempts (user_id, task_id, course_id, status, started_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		userID, task.ID, task.CourseID, attempt.Status, startedAt.UTC(), expiresAt,
+	)
+	if err != nil {
+		return attempt, fmt.Errorf("insert quiz attempt: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return attempt, fmt.Errorf("get quiz attempt id: %w", err)
+	}
+	attempt.ID = int(id)
+
+	for i, question := range attempt.Questions {
+		snapshot, err := json.Marshal(question.QuizQuestion)
+		if err != nil {
+			return attempt, fmt.Errorf("encode question: %w", err)
+		}
+		_, err = tx.Exec(
+			"INSERT INTO quiz_attempt_questions (attempt_id, position, question_id, question) VALUES (?, ?, ?, ?)",
+			attempt.ID, i+1, question.ID, string(snapshot),
+		)
+		if err != nil {
+			return attempt, fmt.Errorf("insert attempt question: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return attempt, fmt.Errorf("commit transaction: %w", err)
+	}
+	return attempt, nil
+}
+
+const quizAttemptColumns = `
+	SELECT id, user_id, task_id, course_id, status, started_at, expires_at, submitted_at,
+		score, points, is_passed, is_late, penalty_percent, submission_id
+	FROM quiz_attempts `
+
+func scanQuizAttempt(row interface{ Scan(...interface{}) error }) (models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	var startedAt, expiresAt, submittedAt nullTime
+	var submissionID sql.NullInt64
+	err := row.Scan(
+		&attempt.ID, &attempt.UserID, &attempt.TaskID, &attempt.CourseID, &attempt.Status,
+		&startedAt, &expiresAt, &submittedAt,
+		&attempt.Score, &attempt.Points, &attempt.IsPassed, &attempt.IsLate, &attempt.Penalty, &submissionID,
+	)
+	if err != nil {
+		return attempt, err
+	}
+	attempt.StartedAt = startedAt.Time
+	if expiresAt.Valid {
+		attempt.ExpiresAt = &expiresAt.Time
+	}
+	if submittedAt.Valid {
+		attempt.SubmittedAt = &submittedAt.Time
+	}
+	attempt.SubmissionID = int(submissionID.Int64)
+	return attempt, nil
+}
+
+// GetQuizAttempt возвращает попытку с вопросами, ответами и оценками
+func (s *DBStorage) GetQuizAttempt(attemptID int) (models.QuizAttempt, error) {
+	attempt, err := scanQuizAttempt(s.DB.QueryRow(quizAttemptColumns+"WHERE id = ?", attemptID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return attempt, ErrQuizAttemptNotFound
+		}
+		return attempt, fmt.Errorf("get quiz attempt: %w", err)
+	}
+
+	rows, err := s.DB.Query(
+		"SELECT question, answer, earned FROM quiz_attempt_questions WHERE attempt_id = ? ORDER BY position",
+		attemptID,
+	)
+	if err != nil {
+		return attempt, fmt.Errorf("get attempt questions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var snapshot string
+		var answer sql.NullString
+		var earned sql.NullFloat64
+		if err := rows.Scan(&snapshot, &answer, &earned); err != nil {
+			return attempt, fmt.Errorf("scan attempt question: %w", err)
+		}
+
+		var question models.QuizAttemptQuestion
+		if err := json.Unmarshal([]byte(snapshot), &question.QuizQuestion); err != nil {
+			return attempt, fmt.Errorf("decode question: %w", err)
+		}
+		if answer.Valid {
+			question.Answer = &models.QuizAnswer{}
+			if err := json.Unmarshal([]byte(answer.String), question.Answer); err != nil {
+				return attempt, fmt.Errorf("decode answer: %w", err)
+			}
+		}
+		if earned.Valid {
+			question.Earned = &earned.Float64
+		}
+		attempt.Questions = append(attempt.Questions, question)
+	}
+	if err := rows.Err(); err != nil {
+		return attempt, fmt.Errorf("iterate attempt questions: %w", err)
+	}
+	return attempt, nil
+}
+
+// GetQuizAttempts возвращает попытки пользователя по тесту без вопросов, от ранних к поздним
+func (s *DBStorage) GetQuizAttempts(userID, taskID int) ([]models.QuizAttempt, error) {
+	rows, err := s.DB.Query(quizAttemptColumns+"WHERE user_id = ? AND task_id = ? ORDER BY started_at, id", userID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("get quiz attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []models.QuizAttempt{}
+	for rows.Next() {
+		attempt, err := scanQuizAttempt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan quiz attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate quiz attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// SubmitQuizAttempt оценивает ответы попытки, сохраняет ее как попытку сдачи задачи
+// и отмечает задачу выполненной, если тест зачтен
+func (s *DBStorage) SubmitQuizAttempt(attemptID int, answers []models.QuizAnswer, submittedAt time.Time) (models.QuizAttempt, error) {
+	attempt, err := s.GetQuizAttempt(attemptID)
+	if err != nil {
+		return attempt, err
+	}
+	if attempt.Status != models.QuizAttemptInProgress {
+		return attempt, ErrQuizAttemptFinished
+	}
+
+	task, err := s.GetTaskByID(attempt.CourseID, attempt.TaskID)
+	if err != nil {
+		return attempt, fmt.Errorf("get task: %w", err)
+	}
+	if task.Quiz == nil {
+		return attempt, ErrNotQuizTask
+	}
+	deadlines, err := s.userDeadlines(attempt.UserID, attempt.TaskID)
+	if err != nil {
+		return attempt, fmt.Errorf("get deadline: %w", err)
+	}
+	response := gradeQuizAttempt(&attempt, task, answers, deadlines, submittedAt)
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return attempt, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	attempt.SubmissionID, err = insertSubmission(tx, attempt.UserID, task, response, "")
+	if err != nil {
+		return attempt, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE quiz_attempts
+		SET status = ?, submitted_at = ?, score = ?, points = ?, is_passed = ?, is_late = ?, penalty_percent = ?, submission_id = ?
+		WHERE id = ? AND status = ?
+	`,
+		attempt.Status, submittedAt.UTC(), attempt.Score, attempt.Points, attempt.IsPassed,
+		attempt.IsLate, attempt.Penalty, attempt.SubmissionID, attempt.ID, models.QuizAttemptInProgress,
+	)
+	if err != nil {
+		return attempt, fmt.Errorf("update quiz attempt: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return attempt, fmt.Errorf("get affected rows: %w", err)
+	} else if affected == 0 {
+		return attempt, ErrQuizAttemptFinished
+	}
+
+	for _, question := range attempt.Questions {
+		var answer interface{}
+		if question.Answer != nil {
+			encoded, err := json.Marshal(question.Answer)
+			if err != nil {
+				return attempt, fmt.Errorf("encode answer: %w", err)
+			}
+			answer = string(encoded)
+		}
+		_, err := tx.Exec(
+			"UPDATE quiz_attempt_questions SET answer = ?, earned = ? WHERE attempt_id = ? AND question_id = ?",
+			answer, *question.Earned, attempt.ID, question.ID,
+		)
+		if err != nil {
+			return attempt, fmt.Errorf("update attempt question: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return attempt, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	if attempt.IsPassed {
+		if err := s.CompleteTask(attempt.UserID, attempt.TaskID); err != nil {
+			return attempt, fmt.Errorf("complete task: %w", err)
+		}
+	}
+	return attempt, nil
+}
+
+// ****** МЕТОДЫ ДЛЯ СЕРТИФИКАТОВ ******
+
+var (
+	ErrCertificateNotFound = errors.New("certificate not found")
+	ErrCertificateExists   = errors.New("certificate for the course is already issued")
+	ErrCertificateRevoked  = errors.New("certificate is revoked")
+)
+
+// GetCourseCompletions возвращает прохождение курсов, которые студент начал
+func (s *DBStorage) GetCourseCompletions(userID int) ([]models.CourseCompletion, error) {
+	rows, err := s.DB.Query(`
+		SELECT c.id, c.vulnerability_type, t.points, up.completed_at, MAX(ts.score)
+		FROM courses c
+		JOIN tasks t ON t.course_id = c.id
+		LEFT JOIN user_progress up ON up.task_id = t.id AND up.user_id = ?
+		LEFT JOIN task_submissions ts ON ts.task_id = t.id AND ts.user_id = ?
+		GROUP BY c.id, c.vulnerability_type, t.id, t.points, up.completed_at
+		ORDER BY c.id, t.id
+	`, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get course completions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []taskResult
+	for rows.Next() {
+		var result taskResult
+		var completedAt nullTime
+		var bestScore sql.NullFloat64
+		if err := rows.Scan(&result.CourseID, &result.CourseTitle, &result.Points, &completedAt, &bestScore); err != nil {
+			return nil, fmt.Errorf("scan course completion: %w", err)
+		}
+		if completedAt.Valid {
+			result.CompletedAt = &completedAt.Time
+		}
+		if bestScore.Valid {
+			result.BestScore = &bestScore.Float64
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate course completions: %w", err)
+	}
+
+	return courseCompletions(results), nil
+}
+
+const certificateColumns = `
+	SELECT id, user_id, full_name, course_id, course_title, score, completed_at, issued_at,
+		signature, revoked_at, revoke_reason
+	FROM certificates `
+
+func scanCertificate(row interface{ Scan(...interface{}) error }) (models.Certificate, error) {
+	var cert models.Certificate
+	var completedAt, issuedAt, revokedAt nullTime
+	var reason sql.NullString
+	err := row.Scan(
+		&cert.ID, &cert.UserID, &cert.FullName, &cert.CourseID, &cert.CourseTitle, &cert.Score,
+		&completedAt, &issuedAt, &cert.Signature, &revokedAt, &reason,
+	)
+	if err != nil {
+		return cert, err
+	}
+	cert.CompletedAt = completedAt.Time
+	cert.IssuedAt = issuedAt.Time
+	if revokedAt.Valid {
+		cert.RevokedAt = &revokedAt.Time
+	}
+	cert.RevokeReason = reason.String
+	return cert, nil
+}
+
+// CreateCertificate сохраняет подписанный сертификат. На курс выдается один сертификат:
+// если он уже есть (в том числе отозванный), возвращается он вместе с ErrCertificateExists.
+func (s *DBStorage) CreateCertificate(cert models.Certificate) (models.Certificate, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return cert, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := scanCertificate(tx.QueryRow(certificateColumns+"WHERE user_id = ? AND course_id = ?", cert.UserID, cert.CourseID))
+	if err == nil {
+		return existing, ErrCertificateExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return cert, fmt.Errorf("check certificate: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO certificates (id, user_id, full_name, course_id, course_title, score, completed_at, issued_at, signature)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		cert.ID, cert.UserID, cert.FullName, cert.CourseID, cert.CourseTitle, cert.Score,
+		cert.CompletedAt.UTC(), cert.IssuedAt.UTC(), cert.Signature,
+	)
+	if err != nil {
+		return cert, fmt.Errorf("insert certificate: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return cert, fmt.Errorf("commit transaction: %w", err)
+	}
+	return cert, nil
+}
+
+func (s *DBStorage) GetCertificate(id string) (models.Certificate, error) {
+	cert, err := scanCertificate(s.DB.QueryRow(certificateColumns+"WHERE id = ?", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cert, ErrCertificateNotFound
+		}
+		return cert, fmt.Errorf("get certificate: %w", err)
+	}
+	return cert, nil
+}
+
+func (s *DBStorage) GetUserCertificates(userID int) ([]models.Certificate, error) {
+	rows, err := s.DB.Query(certificateColumns+"WHERE user_id = ? ORDER BY issued_at, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("get certificates: %w", err)
+	}
+	defer rows.Close()
+
+	certificates := []models.Certificate{}
+	for rows.Next() {
+		cert, err := scanCertificate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan certificate: %w", err)
+		}
+		certificates = append(certificates, cert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate certificates: %w", err)
+	}
+	return certificates, nil
+}
+
+// RevokeCertificate отзывает сертификат. Отозванный сертификат остается в базе,
+// чтобы проверка по ID сообщала об отзыве, а не об отсутствии сертификата.
+func (s *DBStorage) RevokeCertificate(id, reason string, revokedAt time.Time) (models.Certificate, error) {
+	result, err := s.DB.Exec(
+		"UPDATE certificates SET revoked_at = ?, revoke_reason = ? WHERE id = ? AND revoked_at IS NULL",
+		revokedAt.UTC(), reason, id,
+	)
+	if err != nil {
+		return models.Certificate{}, fmt.Errorf("revoke certificate: %w", err)
+	}
+
+	cert, err := s.GetCertificate(id)
+	if err != nil {
+		return cert, err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return cert, fmt.Errorf("get affected rows: %w", err)
+	} else if affected == 0 {
+		return cert, ErrCertificateRevoked
+	}
+	return cert, nil
+}
+
+// ****** МЕТОДЫ ДЛЯ ЗНАЧКОВ ******
+
+var (
+	ErrBadgeNotFound  = errors.New("badge not found")
+	ErrBadgeCodeTaken = errors.New("badge code is already taken")
+)
+
+const badgeColumns = `
+	SELECT id, code, name, description, icon, criteria, is_active, created_at
+	FROM badges `
+
+func scanBadge(row interface{ Scan(...interface{}) error }) (models.Badge, error) {
+	var badge models.Badge
+	var description, icon sql.NullString
+	var criteria string
+	var createdAt nullTime
+	if err := row.Scan(&badge.ID, &badge.Code, &badge.Name, &description, &icon, &criteria, &badge.IsActive, &createdAt); err != nil {
+		return badge, err
+	}
+	if err := json.Unmarshal([]byte(criteria), &badge.Criteria); err != nil {
+		return badge, fmt.Errorf("decode badge criteria: %w", err)
+	}
+	badge.Description = description.String
+	badge.Icon = icon.String
+	badge.CreatedAt = createdAt.Time
+	return badge, nil
+}
+
+func (s *DBStorage) GetBadges() ([]models.Badge, error) {
+	rows, err := s.DB.Query(badgeColumns + "ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("get badges: %w", err)
+	}
+	defer rows.Close()
+
+	badges := []models.Badge{}
+	for rows.Next() {
+		badge, err := scanBadge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan badge: %w", err)
+		}
+		badges = append(badges, badge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate badges: %w", err)
+	}
+	return badges, nil
+}
+
+func (s *DBStorage) GetBadge(badgeID int) (models.Badge, error) {
+	badge, err := scanBadge(s.DB.QueryRow(badgeColumns+"WHERE id = ?", badgeID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return badge, ErrBadgeNotFound
+		}
+		return badge, fmt.Errorf("get badge: %w", err)
+	}
+	return badge, nil
+}
+
+// checkBadgeCode проверяет, что код значка не занят другим значком
+func checkBadgeCode(tx *sql.Tx, code string, badgeID int) error {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM badges WHERE code = ? AND id <> ?)", code, badgeID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check badge code: %w", err)
+	}
+	if exists {
+		return ErrBadgeCodeTaken
+	}
+	return nil
+}
+
+func (s *DBStorage) CreateBadge(badge models.Badge) (models.Badge, error) {
+	criteria, err := json.Marshal(badge.Criteria)
+	if err != nil {
+		return badge, fmt.Errorf("encode badge criteria: %w", err)
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return badge, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkBadgeCode(tx, badge.Code, 0); err != nil {
+		return badge, err
+	}
+
+	badge.CreatedAt = time.Now().UTC()
+	result, err := tx.Exec(`
+		INSERT INTO badges (code, name, description, icon, criteria, is_active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, badge.Code, badge.Name, nullableString(badge.Description), nullableString(badge.Icon), string(criteria), badge.IsActive, badge.CreatedAt)
+	if err != nil {
+		return badge, fmt.Errorf("insert badge: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return badge, fmt.Errorf("get badge id: %w", err)
+	}
+	badge.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return badge, fmt.Errorf("commit transaction: %w", err)
+	}
+	return badge, nil
+}
+
+// UpdateBadge меняет значок. Уже выданные значки остаются у пользователей.
+func (s *DBStorage) UpdateBadge(badge models.Badge) (models.Badge, error) {
+	criteria, err := json.Marshal(badge.Criteria)
+	if err != nil {
+		return badge, fmt.Errorf("encode badge criteria: %w", err)
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return badge, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := scanBadge(tx.QueryRow(badgeColumns+"WHERE id = ?", badge.ID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return badge, ErrBadgeNotFound
+		}
+		return badge, fmt.Errorf("get badge: %w", err)
+	}
+	if err := checkBadgeCode(tx, badge.Code, badge.ID); err != nil {
+		return badge, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE badges SET code = ?, name = ?, description = ?, icon = ?, criteria = ?, is_active = ?
+		WHERE id = ?
+	`, badge.Code, badge.Name, nullableString(badge.Description), nullableString(badge.Icon), string(criteria), badge.IsActive, badge.ID)
+	if err != nil {
+		return badge, fmt.Errorf("update badge: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return badge, fmt.Errorf("commit transaction: %w", err)
+	}
+	badge.CreatedAt = existing.CreatedAt
+	return badge, nil
+}
+
+func (s *DBStorage) DeleteBadge(badgeID int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM user_badges WHERE badge_id = ?", badgeID); err != nil {
+		return fmt.Errorf("delete awarded badges: %w", err)
+	}
+	result, err := tx.Exec("DELETE FROM badges WHERE id = ?", badgeID)
+	if err != nil {
+		return fmt.Errorf("delete badge: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	} else if affected == 0 {
+		return ErrBadgeNotFound
+	}
+
+	return tx.Commit()
+}
+
+// AwardBadges сохраняет полученные значки и возвращает те, которых у пользователя еще не было
+func (s *DBStorage) AwardBadges(userID int, badges []models.UserBadge) ([]models.UserBadge, error) {
+	if len(badges) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var awarded []models.UserBadge
+	for _, badge := range badges {
+		var exists bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM user_badges WHERE user_id = ? AND badge_id = ?)", userID, badge.BadgeID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("check user badge: %w", err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO user_badges (user_id, badge_id, awarded_at) VALUES (?, ?, ?)",
+			userID, badge.BadgeID, badge.AwardedAt.UTC()); err != nil {
+			return nil, fmt.Errorf("award badge: %w", err)
+		}
+		awarded = append(awarded, badge)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return awarded, nil
+}
+
+func (s *DBStorage) GetUserBadges(userID int) ([]models.UserBadge, error) {
+	rows, err := s.DB.Query(`
+		SELECT b.id, b.code, b.name, b.description, b.icon, ub.awarded_at
+		FROM user_badges ub
+		JOIN badges b ON b.id = ub.badge_id
+		WHERE ub.user_id = ?
+		ORDER BY ub.awarded_at, b.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user badges: %w", err)
+	}
+	defer rows.Close()
+
+	badges := []models.UserBadge{}
+	for rows.Next() {
+		var badge models.UserBadge
+		var description, icon sql.NullString
+		var awardedAt nullTime
+		if err := rows.Scan(&badge.BadgeID, &badge.Code, &badge.Name, &description, &icon, &awardedAt); err != nil {
+			return nil, fmt.Errorf("scan user badge: %w", err)
+		}
+		badge.Description = description.String
+		badge.Icon = icon.String
+		badge.AwardedAt = awardedAt.Time
+		badges = append(badges, badge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user badges: %w", err)
+	}
+	return badges, nil
+}
+
+// ****** МЕТОДЫ ДЛЯ НАСТРОЕК И СЕРИЙ ДНЕЙ ******
+
+// GetUserPreferences возвращает настройки пользователя; без сохраненных настроек - значения по умолчанию
+func (s *DBStorage) GetUserPreferences(userID int) (models.UserPreferences, error) {
+	prefs := models.UserPreferences{Timezone: models.DefaultTimezone}
+	err := s.DB.QueryRow("SELECT timezone, streak_reminders FROM user_preferences WHERE user_id = ?", userID).
+		Scan(&prefs.Timezone, &prefs.StreakReminders)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return prefs, fmt.Errorf("get user preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+func (s *DBStorage) UpdateUserPreferences(userID int, prefs models.UserPreferences) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM user_preferences WHERE user_id = ?)", userID).Scan(&exists); err != nil {
+		return fmt.Errorf("check user preferences: %w", err)
+	}
+	if exists {
+		_, err = tx.Exec("UPDATE user_preferences SET timezone = ?, streak_reminders = ? WHERE user_id = ?",
+			prefs.Timezone, prefs.StreakReminders, userID)
+	} else {
+		_, err = tx.Exec("INSERT INTO user_preferences (user_id, timezone, streak_reminders) VALUES (?, ?, ?)",
+			userID, prefs.Timezone, prefs.StreakReminders)
+	}
+	if err != nil {
+		return fmt.Errorf("save user preferences: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetActivityTimes возвращает моменты выполнения задач и отправки ответов в интервале [from, to)
+func (s *DBStorage) GetActivityTimes(userID int, from, to time.Time) (models.ActivityTimes, error) {
+	var times models.ActivityTimes
+	queries := []struct {
+		query  string
+		target *[]time.Time
+	}{
+		{"SELECT completed_at FROM user_progress WHERE user_id = ? AND completed_at >= ? AND completed_at < ?", &times.Completed},
+		{"SELECT submitted_at FROM task_submissions WHERE user_id = ? AND submitted_at >= ? AND submitted_at < ?", &times.Submitted},
+	}
+
+	for _, q := range queries {
+		rows, err := s.DB.Query(q.query, userID, from.UTC(), to.UTC())
+		if err != nil {
+			return times, fmt.Errorf("get activity times: %w", err)
+		}
+		for rows.Next() {
+			var at nullTime
+			if err := rows.Scan(&at); err != nil {
+				rows.Close()
+				return times, fmt.Errorf("scan activity time: %w", err)
+			}
+			if at.Valid {
+				*q.target = append(*q.target, at.Time)
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return times, fmt.Errorf("iterate activity times: %w", err)
+		}
+	}
+	return times, nil
+}
+
+// GetStreakReminderCandidates возвращает активных пользователей, включивших напоминания о серии дней
+func (s *DBStorage) GetStreakReminderCandidates() ([]models.StreakReminderCandidate, error) {
+	rows, err := s.DB.Query(`
+		SELECT u.id, u.username, u.email, p.timezone, p.streak_reminded_on
+		FROM user_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.streak_reminders = TRUE AND u.is_active = TRUE AND u.is_deleted = FALSE
+		ORDER BY u.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("get streak reminder candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []models.StreakReminderCandidate
+	for rows.Next() {
+		var candidate models.StreakReminderCandidate
+		var remindedOn sql.NullString
+		if err := rows.Scan(&candidate.UserID, &candidate.Username, &candidate.Email, &candidate.Timezone, &remindedOn); err != nil {
+			return nil, fmt.Errorf("scan streak reminder candidate: %w", err)
+		}
+		candidate.RemindedOn = remindedOn.String
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// MarkStreakReminderSent запоминает дату напоминания, чтобы не напоминать дважды за день
+func (s *DBStorage) MarkStreakReminderSent(userID int, date string) error {
+	if _, err := s.DB.Exec("UPDATE user_preferences SET streak_reminded_on = ? WHERE user_id = ?", date, userID); err != nil {
+		return fmt.Errorf("mark streak reminder sent: %w", err)
+	}
+	return nil
+}
+
+// ****** МЕТОДЫ ДЛЯ УВЕДОМЛЕНИЙ ******
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+const notificationColumns = `
+	SELECT id, user_id, type, title, message, payload, is_read, created_at, read_at
+	FROM notifications `
+
+func scanNotification(row interface{ Scan(...interface{}) error }) (models.Notification, error) {
+	var notification models.Notification
+	var payload sql.NullString
+	var createdAt, readAt nullTime
+	err := row.Scan(&notification.ID, &notification.UserID, &notification.Type, &notification.Title,
+		&notification.Message, &payload, &notification.IsRead, &createdAt, &readAt)
+	if err != nil {
+		return notification, err
+	}
+	if payload.String != "" {
+		if err := json.Unmarshal([]byte(payload.String), &notification.Payload); err != nil {
+			return notification, fmt.Errorf("decode notification payload: %w", err)
+		}
+	}
+	notification.CreatedAt = createdAt.Time
+	if readAt.Valid {
+		notification.ReadAt = &readAt.Time
+	}
+	return notification, nil
+}
+
+func (s *DBStorage) CreateNotification(notification models.Notification) (models.Notification, error) {
+	payload, err := notificationPayload(notification)
+	if err != nil {
+		return notification, err
+	}
+
+	notification.IsRead = false
+	notification.ReadAt = nil
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now().UTC()
+	}
+	result, err := s.DB.Exec(`
+		INSERT INTO notifications (user_id, type, title, message, payload, is_read, created_at)
+		VALUES (?, ?, ?, ?, ?, FALSE, ?)
+	`, notification.UserID, notification.Type, notification.Title, notification.Message, payload, notification.CreatedAt)
+	if err != nil {
+		return notification, fmt.Errorf("insert notification: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return notification, fmt.Errorf("get notification id: %w", err)
+	}
+	notification.ID = int(id)
+	return notification, nil
+}
+
+// CreateNotifications сохраняет пачку уведомлений одним INSERT. InnoDB выделяет строкам
+// одной вставки идущие подряд ID, поэтому ID считаются от первого вставленного.
+func (s *DBStorage) CreateNotifications(notifications []models.Notification) ([]models.Notification, error) {
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+
+	created := make([]models.Notification, 0, len(notifications))
+	rows := make([]string, 0, len(notifications))
+	args := make([]interface{}, 0, len(notifications)*6)
+	now := time.Now().UTC()
+	for _, notification := range notifications {
+		payload, err := notificationPayload(notification)
+		if err != nil {
+			return nil, err
+		}
+		notification.IsRead = false
+		notification.ReadAt = nil
+		if notification.CreatedAt.IsZero() {
+			notification.CreatedAt = now
+		}
+		rows = append(rows, "(?, ?, ?, ?, ?, FALSE, ?)")
+		args = append(args, notification.UserID, notification.Type, notification.Title, notification.Message, payload, notification.CreatedAt)
+		created = append(created, notification)
+	}
+
+	result, err := s.DB.Exec(`
+		INSERT INTO notifications (user_id, type, title, message, payload, is_read, created_at)
+		VALUES `+strings.Join(rows, ", "), args...)
+	if err != nil {
+		return nil, fmt.Errorf("insert notifications: %w", err)
+	}
+	firstID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("get notification id: %w", err)
+	}
+	for i := range created {
+		created[i].ID = int(firstID) + i
+	}
+	return created, nil
+}
+
+// notificationPayload кодирует данные уведомления в JSON, пустые данные сохраняются как NULL
+func notificationPayload(notification models.Notification) (interface{}, error) {
+	if len(notification.Payload) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(notification.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode notification payload: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// GetNotifications возвращает уведомления пользователя от новых к старым и их общее число.
+// Курсор - идентификатор последнего полученного уведомления, выборка продолжается с более старых.
+func (s *DBStorage) GetNotifications(userID int, unreadOnly bool, params models.ListParams) ([]models.Notification, int, error) {
+	where := "WHERE user_id = ?"
+	args := []interface{}{userID}
+	if unreadOnly {
+		where += " AND is_read = FALSE"
+	}
+
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM notifications "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count notifications: %w", err)
+	}
+
+	if params.IsCursor() {
+		where += " AND id < ?"
+		args = append(args, params.Cursor)
+	}
+	query := notificationColumns + where + " ORDER BY id DESC"
+	if params.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, params.Limit, params.Offset())
+	}
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate notifications: %w", err)
+	}
+	return notifications, total, nil
+}
+
+func (s *DBStorage) CountUnreadNotifications(userID int) (int, error) {
+	var count int
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = FALSE", userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkNotificationRead отмечает уведомление прочитанным; повторная отметка не меняет время прочтения
+func (s *DBStorage) MarkNotificationRead(userID, notificationID int, readAt time.Time) (models.Notification, error) {
+	_, err := s.DB.Exec("UPDATE notifications SET is_read = TRUE, read_at = ? WHERE id = ? AND user_id = ? AND is_read = FALSE",
+		readAt.UTC(), notificationID, userID)
+	if err != nil {
+		return models.Notification{}, fmt.Errorf("mark notification read: %w", err)
+	}
+
+	notification, err := scanNotification(s.DB.QueryRow(notificationColumns+"WHERE id = ? AND user_id = ?", notificationID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notification, ErrNotificationNotFound
+		}
+		return notification, fmt.Errorf("get notification: %w", err)
+	}
+	return notification, nil
+}
+
+// MarkAllNotificationsRead отмечает прочитанными все уведомления пользователя и возвращает их число
+func (s *DBStorage) MarkAllNotificationsRead(userID int, readAt time.Time) (int, error) {
+	result, err := s.DB.Exec("UPDATE notifications SET is_read = TRUE, read_at = ? WHERE user_id = ? AND is_read = FALSE",
+		readAt.UTC(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("mark notifications read: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get affected rows: %w", err)
+	}
+	return int(affected), nil
+}
+
+// GetNotificationPreferences возвращает только измененные пользователем настройки писем
+func (s *DBStorage) GetNotificationPreferences(userID int) (models.NotificationPreferences, error) {
+	prefs := models.NotificationPreferences{Email: map[string]bool{}}
+	rows, err := s.DB.Query("SELECT type, email FROM notification_preferences WHERE user_id = ?", userID)
+	if err != nil {
+		return prefs, fmt.Errorf("get notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationType string
+		var email bool
+		if err := rows.Scan(&notificationType, &email); err != nil {
+			return prefs, fmt.Errorf("scan notification preference: %w", err)
+		}
+		prefs.Email[notificationType] = email
+	}
+	return prefs, rows.Err()
+}
+
+func (s *DBStorage) UpdateNotificationPreferences(userID int, prefs models.NotificationPreferences) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for notificationType, email := range prefs.Email {
+		if _, err := tx.Exec("DELETE FROM notification_preferences WHERE user_id = ? AND type = ?", userID, notificationType); err != nil {
+			return fmt.Errorf("delete notification preference: %w", err)
+		}
+		if _, err := tx.Exec("INSERT INTO notification_preferences (user_id, type, email) VALUES (?, ?, ?)",
+			userID, notificationType, email); err != nil {
+			return fmt.Errorf("insert notification preference: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ****** МЕТОДЫ ДЛЯ ОБСУЖДЕНИЙ ******
+
+var (
+	ErrThreadNotFound = errors.New("discussion thread not found")
+	ErrReplyNotFound  = errors.New("discussion reply not found")
+)
+
+// Число ответов и время последней активности учитывают только видимые ответы
+const threadColumns = `
+	SELECT t.id, t.course_id, t.task_id, t.author_id, u.username, t.title, t.body,
+		t.is_pinned, t.is_locked, t.is_hidden, t.has_spoiler, t.accepted_reply_id,
+		(SELECT COUNT(*) FROM discussion_replies r WHERE r.thread_id = t.id AND r.is_hidden = FALSE),
+		t.created_at, t.last_activity_at
+	FROM discussion_threads t
+	JOIN users u ON u.id = t.author_id `
+
+func scanThread(row interface{ Scan(...interface{}) error }) (models.DiscussionThread, error) {
+	var thread models.DiscussionThread
+	var taskID, acceptedReplyID sql.NullInt64
+	var createdAt, lastActivityAt nullTime
+	err := row.Scan(&thread.ID, &thread.CourseID, &taskID, &thread.AuthorID, &thread.AuthorName, &thread.Title, &thread.Body,
+		&thread.IsPinned, &thread.IsLocked, &thread.IsHidden, &thread.HasSpoiler, &acceptedReplyID,
+		&thread.RepliesCount, &createdAt, &lastActivityAt)
+	if err != nil {
+		return thread, err
+	}
+	if taskID.Valid {
+		id := int(taskID.Int64)
+		thread.TaskID = &id
+	}
+	if acceptedReplyID.Valid {
+		id := int(acceptedReplyID.Int64)
+		thread.AcceptedReplyID = &id
+	}
+	thread.CreatedAt = createdAt.Time
+	thread.LastActivityAt = lastActivityAt.Time
+	return thread, nil
+}
+
+func (s *DBStorage) CreateThread(thread models.DiscussionThread) (models.DiscussionThread, error) {
+	var taskID interface{}
+	if thread.TaskID != nil {
+		taskID = *thread.TaskID
+	}
+	now := time.Now().UTC()
+	result, err := s.DB.Exec(`
+		INSERT INTO discussion_threads (course_id, task_id, author_id, title, body, has_spoiler, created_at, last_activity_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, thread.CourseID, taskID, thread.AuthorID, thread.Title, thread.Body, thread.HasSpoiler, now, now)
+	if err != nil {
+		return thread, fmt.Errorf("insert thread: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return thread, fmt.Errorf("get thread id: %w", err)
+	}
+	return s.GetThread(int(id))
+}
+
+// GetThreads возвращает темы курса: закрепленные, затем по последней активности.
+// taskID больше 0 оставляет темы одной задачи.
+func (s *DBStorage) GetThreads(courseID, taskID int, includeHidden bool, params models.ListParams) ([]models.DiscussionThread, int, error) {
+	where := "WHERE t.course_id = ?"
+	args := []interface{}{courseID}
+	if taskID > 0 {
+		where += " AND t.task_id = ?"
+		args = append(args, taskID)
+	}
+	if !includeHidden {
+		where += " AND t.is_hidden = FALSE"
+	}
+
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM discussion_threads t "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count threads: %w", err)
+	}
+
+	query := threadColumns + where + " ORDER BY t.is_pinned DESC, t.last_activity_at DESC, t.id DESC"
+	if params.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, params.Limit, params.Offset())
+	}
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get threads: %w", err)
+	}
+	defer rows.Close()
+
+	threads := []models.DiscussionThread{}
+	for rows.Next() {
+		thread, err := scanThread(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan thread: %w", err)
+		}
+		threads = append(threads, thread)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate threads: %w", err)
+	}
+	return threads, total, nil
+}
+
+func (s *DBStorage) GetThread(threadID int) (models.DiscussionThread, error) {
+	thread, err := scanThread(s.DB.QueryRow(threadColumns+"WHERE t.id = ?", threadID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return thread, ErrThreadNotFound
+		}
+		return thread, fmt.Errorf("get thread: %w", err)
+	}
+	return thread, nil
+}
+
+func (s *DBStorage) ModerateThread(threadID int, moderation models.ThreadModeration) (models.DiscussionThread, error) {
+	var sets []string
+	var args []interface{}
+	for _, field := range []struct {
+		column string
+		value  *bool
+	}{
+		{"is_pinned", moderation.IsPinned},
+		{"is_locked", moderation.IsLocked},
+		{"is_hidden", moderation.IsHidden},
+		{"has_spoiler", moderation.HasSpoiler},
+	} {
+		if field.value != nil {
+			sets = append(sets, field.column+" = ?")
+			args = append(args, *field.value)
+		}
+	}
+	if len(sets) > 0 {
+		args = append(args, threadID)
+		if _, err := s.DB.Exec("UPDATE discussion_threads SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...); err != nil {
+			return models.DiscussionThread{}, fmt.Errorf("update thread: %w", err)
+		}
+	}
+	// Наличие темы проверяет GetThread: MySQL не считает строку измененной, если значения совпали
+	return s.GetThread(threadID)
+}
+
+// CreateReply добавляет ответ и продлевает активность темы
+func (s *DBStorage) CreateReply(reply models.DiscussionReply) (models.DiscussionReply, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return reply, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.Exec("INSERT INTO discussion_replies (thread_id, author_id, body, created_at) VALUES (?, ?, ?, ?)",
+		reply.ThreadID, reply.AuthorID, reply.Body, now)
+	if err != nil {
+		return reply, fmt.Errorf("insert reply: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return reply, fmt.Errorf("get reply id: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE discussion_threads SET last_activity_at = ? WHERE id = ?", now, reply.ThreadID); err != nil {
+		return reply, fmt.Errorf("update thread activity: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return reply, fmt.Errorf("commit reply: %w", err)
+	}
+	return s.getReply(reply.ThreadID, int(id))
+}
+
+const replyColumns = `
+	SELECT r.id, r.thread_id, r.author_id, u.username, r.body, r.is_hidden,
+		COALESCE(t.accepted_reply_id = r.id, FALSE), r.created_at
+	FROM discussion_replies r
+	JOIN discussion_threads t ON t.id = r.thread_id
+	JOIN users u ON u.id = r.author_id `
+
+func scanReply(row interface{ Scan(...interface{}) error }) (models.DiscussionReply, error) {
+	var reply models.DiscussionReply
+	var createdAt nullTime
+	err := row.Scan(&reply.ID, &reply.ThreadID, &reply.AuthorID, &reply.AuthorName, &reply.Body, &reply.IsHidden,
+		&reply.IsAccepted, &createdAt)
+	reply.CreatedAt = createdAt.Time
+	return reply, err
+}
+
+func (s *DBStorage) getReply(threadID, replyID int) (models.DiscussionReply, error) {
+	reply, err := scanReply(s.DB.QueryRow(replyColumns+"WHERE r.id = ? AND r.thread_id = ?", replyID, threadID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reply, ErrReplyNotFound
+		}
+		return reply, fmt.Errorf("get reply: %w", err)
+	}
+	return reply, nil
+}
+
+// GetReplies возвращает ответы темы в порядке написания
+func (s *DBStorage) GetReplies(threadID int, includeHidden bool) ([]models.DiscussionReply, error) {
+	query := replyColumns + "WHERE r.thread_id = ?"
+	if !includeHidden {
+		query += " AND r.is_hidden = FALSE"
+	}
+	rows, err := s.DB.Query(query+" ORDER BY r.id", threadID)
+	if err != nil {
+		return nil, fmt.Errorf("get replies: %w", err)
+	}
+	defer rows.Close()
+
+	replies := []models.DiscussionReply{}
+	for rows.Next() {
+		reply, err := scanReply(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan reply: %w", err)
+		}
+		replies = append(replies, reply)
+	}
+	return replies, rows.Err()
+}
+
+func (s *DBStorage) ModerateReply(threadID, replyID int, isHidden bool) (models.DiscussionReply, error) {
+	if _, err := s.DB.Exec("UPDATE discussion_replies SET is_hidden = ? WHERE id = ? AND thread_id = ?",
+		isHidden, replyID, threadID); err != nil {
+		return models.DiscussionReply{}, fmt.Errorf("update reply: %w", err)
+	}
+	return s.getReply(threadID, replyID)
+}
+
+// SetAcceptedReply отмечает принятый ответ темы; replyID 0 снимает отметку
+func (s *DBStorage) SetAcceptedReply(threadID, replyID int) (models.DiscussionThread, error) {
+	var accepted interface{}
+	if replyID > 0 {
+		if _, err := s.getReply(threadID, replyID); err != nil {
+			return models.DiscussionThread{}, err
+		}
+		accepted = replyID
+	}
+	if _, err := s.DB.Exec("UPDATE discussion_threads SET accepted_reply_id = ? WHERE id = ?", accepted, threadID); err != nil {
+		return models.DiscussionThread{}, fmt.Errorf("update accepted reply: %w", err)
+	}
+	return s.GetThread(threadID)
+}
+
+// ****** МЕТОДЫ ДЛЯ ПРОВЕРКИ СХОДСТВА ОТВЕТОВ ******
+
+var ErrSimilarityFlagNotFound = errors.New("similarity flag not found")
+
+const submittedAnswerColumns = `
+	SELECT id, user_id, task_id, course_id, answer, is_correct, submitted_at
+	FROM task_submissions `
+
+func (s *DBStorage) querySubmittedAnswers(query string, args ...interface{}) ([]models.SubmittedAnswer, error) {
+	rows, err := s.DB.Query(submittedAnswerColumns+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := []models.SubmittedAnswer{}
+	for rows.Next() {
+		var answer models.SubmittedAnswer
+		var submittedAt nullTime
+		if err := rows.Scan(&answer.SubmissionID, &answer.UserID, &answer.TaskID, &answer.CourseID,
+			&answer.Answer, &answer.IsCorrect, &submittedAt); err != nil {
+			return nil, err
+		}
+		answer.SubmittedAt = submittedAt.Time
+		answers = append(answers, answer)
+	}
+	return answers, rows.Err()
+}
+
+// GetUncheckedAnswers возвращает сохраненные ответы, которые еще не сравнивались с другими
+func (s *DBStorage) GetUncheckedAnswers(limit int) ([]models.SubmittedAnswer, error) {
+	answers, err := s.querySubmittedAnswers("WHERE answer IS NOT NULL AND similarity_checked = FALSE ORDER BY id LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("get unchecked answers: %w", err)
+	}
+	return answers, nil
+}
+
+// GetTaskAnswers возвращает до limit последних ответов на задачу, сданных раньше beforeSubmissionID
+func (s *DBStorage) GetTaskAnswers(taskID, beforeSubmissionID, limit int) ([]models.SubmittedAnswer, error) {
+	answers, err := s.querySubmittedAnswers("WHERE task_id = ? AND id < ? AND answer IS NOT NULL ORDER BY id DESC LIMIT ?",
+		taskID, beforeSubmissionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get task answers: %w", err)
+	}
+	return answers, nil
+}
+
+// RecordSimilarityCheck сохраняет отметки по ответу и помечает его проверенным в одной транзакции,
+// чтобы повторный запуск не создал те же отметки
+func (s *DBStorage) RecordSimilarityCheck(submissionID int, flags []models.SimilarityFlag) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, flag := range flags {
+		_, err := tx.Exec(`
+			INSERT INTO similarity_flags (course_id, task_id, kind, score, time_gap_seconds, submission_id, user_id,
+				other_submission_id, other_user_id, status, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, flag.CourseID, flag.TaskID, flag.Kind, flag.Score, flag.TimeGapSeconds, flag.SubmissionID, flag.UserID,
+			flag.OtherSubmissionID, flag.OtherUserID, models.SimilarityStatusPending, now)
+		if err != nil {
+			return fmt.Errorf("insert similarity flag: %w", err)
+		}
+	}
+	if _, err := tx.Exec("UPDATE task_submissions SET similarity_checked = TRUE WHERE id = ?", submissionID); err != nil {
+		return fmt.Errorf("mark submission checked: %w", err)
+	}
+	return tx.Commit()
+}
+
+const similarityFlagColumns = `
+	SELECT f.id, f.course_id, f.task_id, t.title, f.kind, f.score, f.time_gap_seconds,
+		f.submission_id, f.user_id, u.username, COALESCE(s.answer, ''),
+		f.other_submission_id, f.other_user_id, ou.username, COALESCE(os.answer, ''),
+		f.status, COALESCE(f.note, ''), f.reviewed_by, f.reviewed_at, f.created_at
+	FROM similarity_flags f
+	JOIN tasks t ON t.id = f.task_id
+	JOIN users u ON u.id = f.user_id
+	JOIN users ou ON ou.id = f.other_user_id
+	LEFT JOIN task_submissions s ON s.id = f.submission_id
+	LEFT JOIN task_submissions os ON os.id = f.other_submission_id `
+
+func scanSimilarityFlag(row interface{ Scan(...interface{}) error }) (models.SimilarityFlag, error) {
+	var flag models.SimilarityFlag
+	var reviewedBy sql.NullInt64
+	var reviewedAt, createdAt nullTime
+	err := row.Scan(&flag.ID, &flag.CourseID, &flag.TaskID, &flag.TaskTitle, &flag.Kind, &flag.Score, &flag.TimeGapSeconds,
+		&flag.SubmissionID, &flag.UserID, &flag.Username, &flag.Answer,
+		&flag.OtherSubmissionID, &flag.OtherUserID, &flag.OtherUsername, &flag.OtherAnswer,
+		&flag.Status, &flag.Note, &reviewedBy, &reviewedAt, &createdAt)
+	if err != nil {
+		return flag, err
+	}
+	if reviewedBy.Valid {
+		id := int(reviewedBy.Int64)
+		flag.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		t := reviewedAt.Time
+		flag.ReviewedAt = &t
+	}
+	flag.CreatedAt = createdAt.Time
+	return flag, nil
+}
+
+// GetSimilarityFlags возвращает отметки курса, сначала более похожие пары
+func (s *DBStorage) GetSimilarityFlags(courseID int, filter models.SimilarityFlagFilter, params models.ListParams) ([]models.SimilarityFlag, int, error) {
+	where := "WHERE f.course_id = ?"
+	args := []interface{}{courseID}
+	if filter.AssignmentID > 0 {
+		where += " AND f.task_id IN (SELECT task_id FROM assignment_tasks WHERE assignment_id = ?)"
+		args = append(args, filter.AssignmentID)
+	}
+	if filter.Status != "" {
+		where += " AND f.status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Kind != "" {
+		where += " AND f.kind = ?"
+		args = append(args, filter.Kind)
+	}
+
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM similarity_flags f "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count similarity flags: %w", err)
+	}
+
+	query := similarityFlagColumns + where + " ORDER BY f.score DESC, f.id DESC"
+	if params.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, params.Limit, params.Offset())
+	}
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get similarity flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []models.SimilarityFlag{}
+	for rows.Next() {
+		flag, err := scanSimilarityFlag(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan similarity flag: %w", err)
+		}
+		flags = append(flags, flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate similarity flags: %w", err)
+	}
+	return flags, total, nil
+}
+
+// ReviewSimilarityFlag сохраняет решение преподавателя по отметке
+func (s *DBStorage) ReviewSimilarityFlag(flagID, reviewerID int, review models.SimilarityReview, reviewedAt time.Time) (models.SimilarityFlag, error) {
+	result, err := s.DB.Exec("UPDATE similarity_flags SET status = ?, note = ?, reviewed_by = ?, reviewed_at = ? WHERE id = ?",
+		review.Status, review.Note, reviewerID, reviewedAt.UTC(), flagID)
+	if err != nil {
+		return models.SimilarityFlag{}, fmt.Errorf("update similarity flag: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return models.SimilarityFlag{}, ErrSimilarityFlagNotFound
+	}
+
+	flag, err := scanSimilarityFlag(s.DB.QueryRow(similarityFlagColumns+"WHERE f.id = ?", flagID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return flag, ErrSimilarityFlagNotFound
+		}
+		return flag, fmt.Errorf("get similarity flag: %w", err)
+	}
+	return flag, nil
+}
+
+// ****** МЕТОДЫ ДЛЯ ЖУРНАЛА СОБЫТИЙ ******
+
+// AppendLiveEvent записывает событие в журнал, который читают все экземпляры сервиса
+func (s *DBStorage) AppendLiveEvent(event models.LiveEvent) (models.LiveEvent, error) {
+	var data interface{}
+	if len(event.Data) > 0 {
+		encoded, err := json.Marshal(event.Data)
+		if err != nil {
+			return event, fmt.Errorf("encode event data: %w", err)
+		}
+		data = string(encoded)
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	result, err := s.DB.Exec("INSERT INTO live_events (type, user_id, data, created_at) VALUES (?, ?, ?, ?)",
+		event.Type, event.UserID, data, event.CreatedAt)
+	if err != nil {
+		return event, fmt.Errorf("insert event: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return event, fmt.Errorf("get event id: %w", err)
+	}
+	event.ID = int(id)
+	return event, nil
+}
+
+func (s *DBStorage) GetLiveEventsAfter(afterID, limit int) ([]models.LiveEvent, error) {
+	rows, err := s.DB.Query("SELECT id, type, user_id, data, created_at FROM live_events WHERE id > ? ORDER BY id LIMIT ?",
+		afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.LiveEvent{}
+	for rows.Next() {
+		var event models.LiveEvent
+		var data sql.NullString
+		var createdAt nullTime
+		if err := rows.Scan(&event.ID, &event.Type, &event.UserID, &data, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		if data.String != "" {
+			if err := json.Unmarshal([]byte(data.String), &event.Data); err != nil {
+				return nil, fmt.Errorf("decode event data: %w", err)
+			}
+		}
+		event.CreatedAt = createdAt.Time
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (s *DBStorage) GetLastLiveEventID() (int, error) {
+	var id sql.NullInt64
+	if err := s.DB.QueryRow("SELECT MAX(id) FROM live_events").Scan(&id); err != nil {
+		return 0, fmt.Errorf("get last event id: %w", err)
+	}
+	return int(id.Int64), nil
+}
+
+func (s *DBStorage) DeleteLiveEventsBefore(before time.Time) (int, error) {
+	result, err := s.DB.Exec("DELETE FROM live_events WHERE created_at < ?", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("delete events: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get affected rows: %w", err)
+	}
+	return int(affected), nil
+}
+
+// nullTime сканирует необязательную дату. В отличие от sql.NullTime понимает строки,
+// которые SQLite возвращает для агрегатов и выражений над датами (MAX, CASE).
+type nullTime struct {
+	Time  time.Time
+	Valid bool
+}
+
+var nullTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02",
+}
+
+func (t *nullTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time, t.Valid = time.Time{}, false
+		return nil
+	case time.Time:
+		t.Time, t.Valid = v, true
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	}
+	return fmt.Errorf("unsupported time value of type %T", value)
+}
+
+func (t *nullTime) parse(value string) error {
+	for _, layout := range nullTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			t.Time, t.Valid = parsed, true
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported time format %q", value)
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"lmsmodule/backend-svc/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxDifficultTasks ограничивает список сложных задач в отчете
+const maxDifficultTasks = 10
+
+// effectivenessFacts - исходные данные отчета, которые DBStorage и MockStorage выбирают
+// одинаково, а показатели считает общий buildEffectivenessReport
+type effectivenessFacts struct {
+	courseName  string
+	tasks       []models.Task
+	completions []completionFact
+	// attempts - попытки сдачи до конца периода, activity - агрегаты событий по (пользователь, задача)
+	attempts []models.SubmissionAttempt
+	activity []activityFact
+}
+
+type completionFact struct {
+	userID      int
+	taskID      int
+	completedAt time.Time
+}
+
+type activityFact struct {
+	userID int
+	taskID int
+	// timeSpent - время работы над задачей внутри периода, lastAt - последнее событие до конца периода
+	timeSpent int
+	lastAt    time.Time
+}
+
+type studentEffectiveness struct {
+	completed    map[int]bool
+	points       int
+	timeSpent    int
+	lastActivity time.Time
+}
+
+type taskAttempts struct {
+	attempts  int
+	failed    int
+	scoreSum  float64
+	timeSpent int
+	users     int
+}
+
+// buildEffectivenessReport считает показатели отчета по определениям из models.EffectivenessParams
+func buildEffectivenessReport(params models.EffectivenessParams, facts effectivenessFacts) models.LearningEffectiveness {
+	report := models.LearningEffectiveness{
+		Period:         params.Period,
+		PeriodStart:    params.From,
+		PeriodEnd:      params.To,
+		InactivityDays: params.InactivityDays,
+		CourseID:       params.CourseID,
+		CourseName:     facts.courseName,
+	}
+
+	tasks := make(map[int]models.Task, len(facts.tasks))
+	totalPoints := 0
+	for _, task := range facts.tasks {
+		tasks[task.ID] = task
+		totalPoints += task.Points
+	}
+
+	students := make(map[int]*studentEffectiveness)
+	student := func(userID int) *studentEffectiveness {
+		s, ok := students[userID]
+		if !ok {
+			s = &studentEffectiveness{completed: make(map[int]bool)}
+			students[userID] = s
+		}
+		return s
+	}
+	inPeriod := func(t time.Time) bool {
+		return !t.Before(params.From) && t.Before(params.To)
+	}
+
+	for _, completion := range facts.completions {
+		s := student(completion.userID)
+		if !s.completed[completion.taskID] {
+			s.completed[completion.taskID] = true
+			s.points += tasks[completion.taskID].Points
+		}
+		if completion.completedAt.After(s.lastActivity) {
+			s.lastActivity = completion.completedAt
+		}
+	}
+
+	perTask := make(map[int]*taskAttempts)
+	taskStats := func(taskID int) *taskAttempts {
+		t, ok := perTask[taskID]
+		if !ok {
+			t = &taskAttempts{}
+			perTask[taskID] = t
+		}
+		return t
+	}
+
+	var attempts, correct int
+	for _, attempt := range facts.attempts {
+		s := student(attempt.UserID)
+		if attempt.SubmittedAt.After(s.lastActivity) {
+			s.lastActivity = attempt.SubmittedAt
+		}
+		if !inPeriod(attempt.SubmittedAt) {
+			continue
+		}
+
+		t := taskStats(attempt.TaskID)
+		t.attempts++
+		t.scoreSum += attempt.Score
+		attempts++
+		if attempt.IsCorrect {
+			correct++
+		} else {
+			t.failed++
+		}
+	}
+
+	for _, activity := range facts.activity {
+		s := student(activity.userID)
+		if activity.lastAt.After(s.lastActivity) {
+			s.lastActivity = activity.lastAt
+		}
+		if activity.timeSpent > 0 {
+			s.timeSpent += activity.timeSpent
+			t := taskStats(activity.taskID)
+			t.timeSpent += activity.timeSpent
+			t.users++
+		}
+	}
+
+	report.TotalStudents = len(students)
+	inactiveSince := params.To.AddDate(0, 0, -params.InactivityDays)
+
+	var completionSum, gradeSum float64
+	var completedStudents, dropouts, timedStudents, timeSum int
+	for _, s := range students {
+		if len(facts.tasks) > 0 {
+			completionSum += float64(len(s.completed)) / float64(len(facts.tasks)) * 100
+		}
+		if totalPoints > 0 {
+			gradeSum += float64(s.points) / float64(totalPoints) * 100
+		}
+
+		if len(facts.tasks) > 0 && len(s.completed) == len(facts.tasks) {
+			completedStudents++
+		} else if s.lastActivity.Before(inactiveSince) {
+			dropouts++
+		}
+
+		if s.timeSpent > 0 {
+			timedStudents++
+			timeSum += s.timeSpent
+		}
+	}
+
+	if report.TotalStudents > 0 {
+		n := float64(report.TotalStudents)
+		report.AverageCompletion = roundPercent(completionSum / n)
+		report.AverageGrade = roundPercent(gradeSum / n)
+		report.CompletionRate = roundPercent(float64(completedStudents) / n * 100)
+		report.DropoutRate = roundPercent(float64(dropouts) / n * 100)
+	}
+	if timedStudents > 0 {
+		timeSum /= timedStudents
+	}
+	report.AverageTimeSpent = models.FormatDuration(timeSum)
+
+	report.DifficultTasks = []struct {
+		TaskID       int     `json:"task_id"`
+		TaskTitle    string  `json:"task_title"`
+		FailRate     float64 `json:"fail_rate"`
+		AverageScore float64 `json:"average_score"`
+		AverageTime  string  `json:"average_time"`
+	}{}
+
+	for _, task := range facts.tasks {
+		t, ok := perTask[task.ID]
+		if !ok || t.attempts == 0 {
+			continue
+		}
+
+		var difficult struct {
+			TaskID       int     `json:"task_id"`
+			TaskTitle    string  `json:"task_title"`
+			FailRate     float64 `json:"fail_rate"`
+			AverageScore float64 `json:"average_score"`
+			AverageTime  string  `json:"average_time"`
+		}
+		difficult.TaskID = task.ID
+		difficult.TaskTitle = task.Title
+		difficult.FailRate = roundPercent(float64(t.failed) / float64(t.attempts) * 100)
+		difficult.AverageScore = roundPercent(t.scoreSum / float64(t.attempts))
+		averageTime := 0
+		if t.users > 0 {
+			averageTime = t.timeSpent / t.users
+		}
+		difficult.AverageTime = models.FormatDuration(averageTime)
+
+		report.DifficultTasks = append(report.DifficultTasks, difficult)
+	}
+
+	sort.SliceStable(report.DifficultTasks, func(i, j int) bool {
+		return report.DifficultTasks[i].FailRate > report.DifficultTasks[j].FailRate
+	})
+	if len(report.DifficultTasks) > maxDifficultTasks {
+		report.DifficultTasks = report.DifficultTasks[:maxDifficultTasks]
+	}
+
+	var successRate float64
+	if attempts > 0 {
+		successRate = float64(correct) / float64(attempts) * 100
+	}
+	if report.TotalStudents > 0 {
+		report.EffectivenessScore = models.EffectivenessScore(
+			report.AverageCompletion, report.AverageGrade, report.DropoutRate, successRate, attempts > 0,
+		)
+	}
+
+	return report
+}
+
+func roundPercent(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,21 @@
+package storage
+
+// hasPrerequisiteCycle проверяет, достижима ли задача из своих предварительных задач.
+// prerequisites сопоставляет задаче список задач, которые нужно решить до нее.
+func hasPrerequisiteCycle(prerequisites map[int][]int, taskID int) bool {
+	visited := make(map[int]bool)
+	stack := append([]int(nil), prerequisites[taskID]...)
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == taskID {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		stack = append(stack, prerequisites[current]...)
+	}
+	return false
+}
@@ -2,9 +2,13 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"golang.org/x/crypto/bcrypt"
 	"lmsmodule/backend-svc/models"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -41,9 +45,13 @@ var (
 	}
 
 	mockTasks = []models.Task{
-		{ID: 1, CourseID: 1, Title: "Basics of SQL Injection", Description: "Understanding the fundamentals", Difficulty: "easy", Order: 1},
+		{ID: 1, CourseID: 1, Title: "Basics of SQL Injection", Description: "Understanding the fundamentals", Difficulty: "easy", Order: 1,
+			Content:  `query := "SELECT * FROM users WHERE name = '" + name + "'"`,
+			Solution: `db.Query("SELECT * FROM users WHERE name = ?", name) // prepared statement`},
 		{ID: 2, CourseID: 1, Title: "Advanced SQL Injection", Description: "More complex techniques", Difficulty: "medium", Order: 2},
-		{ID: 3, CourseID: 2, Title: "XSS in Web Applications", Description: "Exploiting front-end vulnerabilities", Difficulty: "medium", Order: 1},
+		{ID: 3, CourseID: 2, Title: "XSS in Web Applications", Description: "Exploiting front-end vulnerabilities", Difficulty: "medium", Order: 1,
+			Content:  `element.innerHTML = comment`,
+			Solution: `element.textContent = comment`},
 		{ID: 4, CourseID: 3, Title: "Understanding CSRF", Description: "Forging requests across sites", Difficulty: "hard", Order: 1},
 	}
 
@@ -76,6 +84,7 @@ var (
 			IsAdmin:        true,
 			IsActive:       true,
 			LastLogin:      time.Now().Add(-24 * time.Hour),
+			CreatedAt:      time.Now().Add(-90 * 24 * time.Hour),
 			CompletedTasks: 1,
 			TotalTasks:     4,
 			Progress:       25.0,
@@ -92,6 +101,7 @@ var (
 			IsAdmin:        false,
 			IsActive:       true,
 			LastLogin:      time.Now().Add(-2 * time.Hour),
+			CreatedAt:      time.Now().Add(-30 * 24 * time.Hour),
 			CompletedTasks: 2,
 			TotalTasks:     4,
 			Progress:       50.0,
@@ -102,21 +112,90 @@ var (
 		"admin":   1,
 		"user123": 2,
 	}
+
+	mockActivitySummaries  = map[int]map[int]models.TaskActivitySummary{}
+	mockLearningActivities []models.LearningActivity
+	mockSubmissions        []models.SubmissionAttempt
+	mockTaskSkills         = map[int][]models.TaskSkill{}
+	mockTaskPrerequisites  = map[int][]int{}
+	mockAssignments        []models.Assignment
+	mockExtensions         = map[int]map[int]models.AssignmentExtension{}
+	mockSentReminders      = map[string]bool{}
+	mockCohorts            []models.Cohort
+	mockCohortMembers      = map[int]map[int]time.Time{}
+	mockQuestionBanks      []models.QuestionBank
+	mockQuizAttempts       []models.QuizAttempt
+	mockQuizSequence       int
+	mockCertificates       []models.Certificate
+	mockUserBadges         = map[int][]models.UserBadge{}
+	mockUserPreferences    = map[int]models.UserPreferences{}
+	mockStreakRemindedOn   = map[int]string{}
+	mockNotifications      []models.Notification
+	mockNotificationPrefs  = map[int]map[string]bool{}
+	mockThreads            []models.DiscussionThread
+	mockReplies            []models.DiscussionReply
+	mockSubmissionAnswers  = map[int]string{}
+	mockCheckedAnswers     = map[int]bool{}
+	mockSimilarityFlags    []models.SimilarityFlag
+	mockLiveEvents         []models.LiveEvent
+	mockLiveEventSequence  int
+	// журнал событий читается фоновым брокером, поэтому защищен отдельно
+	mockLiveEventsMu sync.Mutex
+
+	mockBadges = []models.Badge{
+		{ID: 1, Code: "first_task", Name: "Первый шаг", Description: "Выполнена первая задача", Criteria: models.BadgeCriteria{Type: "tasks_completed", Threshold: 1}, IsActive: true},
+		{ID: 2, Code: "course_finished", Name: "Выпускник", Description: "Пройден курс целиком", Criteria: models.BadgeCriteria{Type: "courses_completed", Threshold: 1}, IsActive: true},
+		{ID: 3, Code: "streak_7", Name: "Неделя без перерыва", Description: "Учеба 7 дней подряд", Criteria: models.BadgeCriteria{Type: "streak_days", Threshold: 7}, IsActive: true},
+		{ID: 4, Code: "hard_no_hints", Name: "Без подсказок", Description: "Все сложные задачи решены без подсказок", Criteria: models.BadgeCriteria{Type: "tasks_without_hints", Difficulty: "hard"}, IsActive: true},
+		{ID: 5, Code: "top_10", Name: "Десятка лучших", Description: "Место в первой десятке общего рейтинга", Criteria: models.BadgeCriteria{Type: "leaderboard_rank", Threshold: 10}, IsActive: true},
+	}
+
+	mockCompletionTimes = map[int]map[int]time.Time{
+		1: {
+			1: time.Now().Add(-72 * time.Hour),
+		},
+		2: {
+			1: time.Now().Add(-48 * time.Hour),
+			2: time.Now().Add(-24 * time.Hour),
+		},
+	}
 )
 
-func (s *MockStorage) GetCourses() ([]models.Course, error) {
-	coursesWithoutTasks := make([]models.Course, len(mockCourses))
+var mockCourseLess = map[string]func(a, b models.Course) bool{
+	"id":                 func(a, b models.Course) bool { return a.ID < b.ID },
+	"vulnerability_type": func(a, b models.Course) bool { return a.VulnerabilityType < b.VulnerabilityType },
+	"tasks_count":        func(a, b models.Course) bool { return a.TasksCount < b.TasksCount },
+}
+
+func (s *MockStorage) GetCourses(params models.ListParams) ([]models.Course, int, error) {
+	coursesWithoutTasks := make([]models.Course, 0, len(mockCourses))
 
-	for i, course := range mockCourses {
-		coursesWithoutTasks[i] = models.Course{
+	for _, course := range mockCourses {
+		if params.IsCursor() && course.ID <= params.Cursor {
+			continue
+		}
+		coursesWithoutTasks = append(coursesWithoutTasks, models.Course{
 			ID:                course.ID,
 			VulnerabilityType: course.VulnerabilityType,
 			TasksCount:        course.TasksCount,
 			Description:       course.Description,
-		}
+		})
+	}
+
+	sort.SliceStable(coursesWithoutTasks, func(i, j int) bool {
+		return coursesWithoutTasks[i].ID < coursesWithoutTasks[j].ID
+	})
+	if less, ok := mockCourseLess[params.SortBy]; ok && !params.IsCursor() {
+		sort.SliceStable(coursesWithoutTasks, func(i, j int) bool {
+			if params.SortDesc {
+				return less(coursesWithoutTasks[j], coursesWithoutTasks[i])
+			}
+			return less(coursesWithoutTasks[i], coursesWithoutTasks[j])
+		})
 	}
 
-	return coursesWithoutTasks, nil
+	start, end := pageBounds(len(coursesWithoutTasks), params)
+	return coursesWithoutTasks[start:end], len(mockCourses), nil
 }
 
 func (s *MockStorage) GetCourseByID(id int) (models.Course, error) {
@@ -163,6 +242,13 @@ func (s *MockStorage) CompleteTask(userID, taskID int) error {
 	progress.Completed[taskID] = true
 	mockUserProgress[userID] = progress
 
+	if _, exists := mockCompletionTimes[userID]; !exists {
+		mockCompletionTimes[userID] = make(map[int]time.Time)
+	}
+	if _, exists := mockCompletionTimes[userID][taskID]; !exists {
+		mockCompletionTimes[userID][taskID] = time.Now()
+	}
+
 	user, userExists := mockUsers[userID]
 	if userExists && !progress.Completed[taskID] {
 		user.CompletedTasks++
@@ -183,6 +269,7 @@ func (s *MockStorage) CreateUser(user models.User) error {
 	user.TotalTasks = len(mockTasks)
 	user.Progress = 0
 	user.IsActive = true
+	user.CreatedAt = time.Now()
 
 	mockUsers[newID] = user
 	mockUsersByUsername[user.Username] = newID
@@ -201,7 +288,7 @@ func (s *MockStorage) GetUserByUsername(username string) (models.User, error) {
 		return models.User{}, errors.New("user not found")
 	}
 
-	courses, _ := s.GetCourses()
+	courses, _, _ := s.GetCourses(models.ListParams{})
 	coursesWithProgress := make([]models.CourseProgress, 0, len(courses))
 
 	progress, _ := s.GetUserProgress(user.ID)
@@ -240,7 +327,7 @@ func (s *MockStorage) GetUserByID(id int) (models.User, error) {
 		return models.User{}, errors.New("user not found")
 	}
 
-	courses, _ := s.GetCourses()
+	courses, _, _ := s.GetCourses(models.ListParams{})
 	coursesWithProgress := make([]models.CourseProgress, 0, len(courses))
 
 	progress, _ := s.GetUserProgress(user.ID)
@@ -313,12 +400,8 @@ func (s *MockStorage) IsTeacher(userID int) (bool, error) {
 	return user.IsTeacher, nil
 }
 
-func (s *MockStorage) GetAllUsers() ([]models.User, error) {
-	var users []models.User
-	for _, user := range mockUsers {
-		users = append(users, user)
-	}
-	return users, nil
+func (s *MockStorage) GetAllUsers(params models.ListParams) ([]models.User, int, error) {
+	return s.listUsers(func(models.User) bool { return true }, params)
 }
 
 func (s *MockStorage) UpdateUserProfile(userID int, data models.UpdateProfileRequest) error {
@@ -347,27 +430,76 @@ func (s *MockStorage) UpdateUserProfile(userID int, data models.UpdateProfileReq
 	return nil
 }
 
-func (s *MockStorage) GetUsersByRole(isAdmin bool) ([]models.User, error) {
-	var users []models.User
-	for _, user := range mockUsers {
-		if user.IsAdmin == isAdmin {
-			users = append(users, user)
-		}
-	}
-	return users, nil
+func (s *MockStorage) GetUsersByRole(isAdmin bool, params models.ListParams) ([]models.User, int, error) {
+	return s.listUsers(func(user models.User) bool { return user.IsAdmin == isAdmin }, params)
 }
 
-func (s *MockStorage) SearchUsers(query string) ([]models.User, error) {
+func (s *MockStorage) SearchUsers(query string, params models.ListParams) ([]models.User, int, error) {
 	query = strings.ToLower(query)
+	return s.listUsers(func(user models.User) bool {
+		return strings.Contains(strings.ToLower(user.Username), query) ||
+			strings.Contains(strings.ToLower(user.Email), query) ||
+			strings.Contains(strings.ToLower(user.FullName), query)
+	}, params)
+}
+
+var mockUserLess = map[string]func(a, b models.User) bool{
+	"id":         func(a, b models.User) bool { return a.ID < b.ID },
+	"username":   func(a, b models.User) bool { return a.Username < b.Username },
+	"email":      func(a, b models.User) bool { return a.Email < b.Email },
+	"full_name":  func(a, b models.User) bool { return a.FullName < b.FullName },
+	"created_at": func(a, b models.User) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"last_login": func(a, b models.User) bool { return a.LastLogin.Before(b.LastLogin) },
+}
+
+// listUsers повторяет семантику DBStorage.listUsers: фильтры, сортировка, страница или курсор
+func (s *MockStorage) listUsers(match func(models.User) bool, params models.ListParams) ([]models.User, int, error) {
 	var users []models.User
 	for _, user := range mockUsers {
-		if strings.Contains(strings.ToLower(user.Username), query) ||
-			strings.Contains(strings.ToLower(user.Email), query) ||
-			strings.Contains(strings.ToLower(user.FullName), query) {
-			users = append(users, user)
+		if !match(user) {
+			continue
+		}
+		if params.IsActive != nil && user.IsActive != *params.IsActive {
+			continue
+		}
+		if params.IsTeacher != nil && user.IsTeacher != *params.IsTeacher {
+			continue
+		}
+		if params.IsAdmin != nil && user.IsAdmin != *params.IsAdmin {
+			continue
+		}
+		if !params.From.IsZero() && user.CreatedAt.Before(params.From) {
+			continue
+		}
+		if !params.To.IsZero() && !user.CreatedAt.Before(params.To) {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	if less, ok := mockUserLess[params.SortBy]; ok && !params.IsCursor() {
+		sort.SliceStable(users, func(i, j int) bool {
+			if params.SortDesc {
+				return less(users[j], users[i])
+			}
+			return less(users[i], users[j])
+		})
+	}
+
+	total := len(users)
+	if params.IsCursor() {
+		var afterCursor []models.User
+		for _, user := range users {
+			if user.ID > params.Cursor {
+				afterCursor = append(afterCursor, user)
+			}
 		}
+		users = afterCursor
 	}
-	return users, nil
+
+	start, end := pageBounds(len(users), params)
+	return users[start:end], total, nil
 }
 
 func (s *MockStorage) UpdateUserStatus(userID int, isActive bool) error {
@@ -467,6 +599,8 @@ func (s *MockStorage) DeleteUser(userID int) error {
 	}
 
 	delete(mockUserProgress, userID)
+	delete(mockCompletionTimes, userID)
+	delete(mockActivitySummaries, userID)
 
 	return nil
 }
@@ -499,8 +633,12 @@ func (s *MockStorage) DeleteCourse(id int) error {
 }
 
 func (s *MockStorage) CreateTask(courseID int, task models.Task) (models.Task, error) {
+	if err := s.checkQuizBanks(courseID, task); err != nil {
+		return models.Task{}, err
+	}
 	newID := len(mockTasks) + 1
 	task.ID = newID
+	task.Type = taskType(task)
 	mockTasks = append(mockTasks, task)
 	return task, nil
 }
@@ -508,6 +646,10 @@ func (s *MockStorage) CreateTask(courseID int, task models.Task) (models.Task, e
 func (s *MockStorage) UpdateTask(courseID, taskID int, task models.Task) (models.Task, error) {
 	for i, t := range mockTasks {
 		if t.ID == taskID {
+			if err := s.checkQuizBanks(courseID, task); err != nil {
+				return models.Task{}, err
+			}
+			task.Type = taskType(task)
 			mockTasks[i] = task
 			return task, nil
 		}
@@ -525,42 +667,2216 @@ func (s *MockStorage) DeleteTask(courseID, taskID int) error {
 	return errors.New("task not found")
 }
 
+func (s *MockStorage) CompleteTaskWithoutAnswer(userID, taskID int) error {
+	var task models.Task
+	for _, t := range mockTasks {
+		if t.ID == taskID {
+			task = t
+			break
+		}
+	}
+	if task.ID == 0 {
+		return ErrTaskNotFound
+	}
+
+	var deadlines []models.TaskDeadline
+	for _, deadline := range s.userDeadlines(userID) {
+		if deadline.TaskID == taskID {
+			deadlines = append(deadlines, deadline)
+		}
+	}
+	if requiresGradedAnswer(task.Type, deadlines) && !mockUserProgress[userID].Completed[taskID] {
+		return ErrGradedAnswerRequired
+	}
+	return s.CompleteTask(userID, taskID)
+}
+
 func (s *MockStorage) SubmitTaskAnswer(submission models.TaskSubmission) (models.TaskSubmissionResponse, error) {
-	//TODO implement me
-	panic("implement me")
+	task, err := s.GetTaskByID(submission.CourseID, submission.TaskID)
+	if err != nil {
+		return models.TaskSubmissionResponse{}, fmt.Errorf("get task: %w", err)
+	}
+
+	var deadlines []models.TaskDeadline
+	for _, deadline := range s.userDeadlines(submission.UserID) {
+		if deadline.TaskID == task.ID {
+			deadlines = append(deadlines, deadline)
+		}
+	}
+
+	submission.SubmittedAt = submissionTime(submission)
+	response, err := gradeSubmission(task, submission, deadlines)
+	if err != nil {
+		return response, err
+	}
+
+	attempt := models.SubmissionAttempt{
+		ID:          len(mockSubmissions) + 1,
+		UserID:      submission.UserID,
+		TaskID:      task.ID,
+		CourseID:    task.CourseID,
+		IsCorrect:   response.IsCorrect,
+		Score:       response.Score,
+		IsLate:      response.IsLate,
+		Penalty:     response.Penalty,
+		SubmittedAt: submission.SubmittedAt,
+	}
+	mockSubmissions = append(mockSubmissions, attempt)
+	response.SubmissionID = attempt.ID
+	if submission.Answer != "" {
+		mockSubmissionAnswers[attempt.ID] = submission.Answer
+	}
+
+	if response.IsCorrect {
+		if err := s.CompleteTask(submission.UserID, submission.TaskID); err != nil {
+			return response, fmt.Errorf("complete task: %w", err)
+		}
+		response.Message = "Correct solution! Task marked as completed"
+	} else {
+		response.Message = "Incorrect solution, please try again"
+	}
+
+	return response, nil
 }
 
-func (s *MockStorage) GetUserSubmissions(userID int) ([]models.TaskSubmissionDetails, error) {
-	//TODO implement me
-	panic("implement me")
+var mockSubmissionLess = map[string]func(a, b models.TaskSubmissionDetails) bool{
+	"submitted_at": func(a, b models.TaskSubmissionDetails) bool { return a.SubmittedAt.Before(b.SubmittedAt) },
+	"task_id":      func(a, b models.TaskSubmissionDetails) bool { return a.TaskID < b.TaskID },
+	"task_title":   func(a, b models.TaskSubmissionDetails) bool { return a.TaskTitle < b.TaskTitle },
+	"course_id":    func(a, b models.TaskSubmissionDetails) bool { return a.CourseID < b.CourseID },
 }
 
-func (s *MockStorage) GetCourseStatistics(courseID int) (models.CourseStatistics, error) {
-	//TODO implement me
-	panic("implement me")
+func (s *MockStorage) GetUserSubmissions(userID int, params models.ListParams) ([]models.TaskSubmissionDetails, int, error) {
+	var submissions []models.TaskSubmissionDetails
+
+	progress := mockUserProgress[userID]
+	for _, task := range mockTasks {
+		if !progress.Completed[task.ID] {
+			continue
+		}
+		if params.CourseID > 0 && task.CourseID != params.CourseID {
+			continue
+		}
+
+		submittedAt := mockCompletionTimes[userID][task.ID]
+		if !params.From.IsZero() && submittedAt.Before(params.From) {
+			continue
+		}
+		if !params.To.IsZero() && !submittedAt.Before(params.To) {
+			continue
+		}
+
+		var courseName string
+		for _, course := range mockCourses {
+			if course.ID == task.CourseID {
+				courseName = course.VulnerabilityType
+				break
+			}
+		}
+
+		submissions = append(submissions, models.TaskSubmissionDetails{
+			SubmissionID: task.ID,
+			TaskID:       task.ID,
+			TaskTitle:    task.Title,
+			CourseID:     task.CourseID,
+			CourseName:   courseName,
+			SubmittedAt:  submittedAt,
+			Status:       "completed",
+		})
+	}
+
+	sort.Slice(submissions, func(i, j int) bool { return submissions[i].TaskID < submissions[j].TaskID })
+	if !params.IsCursor() {
+		less, ok := mockSubmissionLess[params.SortBy]
+		desc := params.SortDesc
+		if !ok {
+			less, desc = mockSubmissionLess["submitted_at"], true
+		}
+		sort.SliceStable(submissions, func(i, j int) bool {
+			if desc {
+				return less(submissions[j], submissions[i])
+			}
+			return less(submissions[i], submissions[j])
+		})
+	}
+
+	total := len(submissions)
+	if params.IsCursor() {
+		var afterCursor []models.TaskSubmissionDetails
+		for _, submission := range submissions {
+			if submission.TaskID > params.Cursor {
+				afterCursor = append(afterCursor, submission)
+			}
+		}
+		submissions = afterCursor
+	}
+
+	start, end := pageBounds(len(submissions), params)
+	return submissions[start:end], total, nil
+}
+
+// mockStudentProgress - строка прогресса студента до форматирования в models.CourseStatistics
+type mockStudentProgress struct {
+	userID         int
+	username       string
+	completedTasks int
+	lastActivity   time.Time
+}
+
+var mockStudentProgressLess = map[string]func(a, b mockStudentProgress) bool{
+	"user_id":       func(a, b mockStudentProgress) bool { return a.userID < b.userID },
+	"username":      func(a, b mockStudentProgress) bool { return a.username < b.username },
+	"completion":    func(a, b mockStudentProgress) bool { return a.completedTasks < b.completedTasks },
+	"last_activity": func(a, b mockStudentProgress) bool { return a.lastActivity.Before(b.lastActivity) },
+}
+
+func (s *MockStorage) GetCourseStatistics(courseID int, params models.ListParams) (models.CourseStatistics, error) {
+	var stats models.CourseStatistics
+	stats.CourseID = courseID
+
+	course, err := s.GetCourseByID(courseID)
+	if err != nil {
+		return stats, errors.New("course not found")
+	}
+	stats.CourseName = course.VulnerabilityType
+
+	var courseTasks []models.Task
+	for _, task := range mockTasks {
+		if task.CourseID == courseID {
+			courseTasks = append(courseTasks, task)
+		}
+	}
+
+	inCohort := func(userID int) bool {
+		if params.CohortID > 0 && !mockIsCohortMember(params.CohortID, userID) {
+			return false
+		}
+		return params.TeacherID <= 0 || mockTeachesStudent(params.TeacherID, userID, courseID)
+	}
+
+	var students []mockStudentProgress
+	for userID, progress := range mockUserProgress {
+		if !inCohort(userID) {
+			continue
+		}
+		completed := 0
+		for _, task := range courseTasks {
+			if progress.Completed[task.ID] {
+				completed++
+			}
+		}
+		if completed == 0 {
+			continue
+		}
+
+		stats.EnrolledStudents++
+		if completed == len(courseTasks) {
+			stats.CompletedStudents++
+		}
+
+		user, exists := mockUsers[userID]
+		if !exists {
+			continue
+		}
+		if params.IsActive != nil && user.IsActive != *params.IsActive {
+			continue
+		}
+
+		var lastActivity time.Time
+		for _, task := range courseTasks {
+			if completedAt, ok := mockCompletionTimes[userID][task.ID]; ok && completedAt.After(lastActivity) {
+				lastActivity = completedAt
+			}
+			if summary, ok := mockActivitySummaries[userID][task.ID]; ok && summary.LastActivityAt.After(lastActivity) {
+				lastActivity = summary.LastActivityAt
+			}
+		}
+		if !params.From.IsZero() && lastActivity.Before(params.From) {
+			continue
+		}
+		if !params.To.IsZero() && !lastActivity.Before(params.To) {
+			continue
+		}
+
+		students = append(students, mockStudentProgress{
+			userID:         userID,
+			username:       user.Username,
+			completedTasks: completed,
+			lastActivity:   lastActivity,
+		})
+	}
+
+	if stats.EnrolledStudents > 0 {
+		stats.AverageCompletion = float64(stats.CompletedStudents) / float64(stats.EnrolledStudents) * 100
+	}
+
+	var activeStudents, totalTimeSpent int
+	for userID, summaries := range mockActivitySummaries {
+		if !inCohort(userID) {
+			continue
+		}
+		active := false
+		for _, summary := range summaries {
+			if summary.CourseID == courseID {
+				totalTimeSpent += summary.TimeSpentSeconds
+				active = true
+			}
+		}
+		if active {
+			activeStudents++
+		}
+	}
+	if activeStudents > 0 {
+		totalTimeSpent /= activeStudents
+	}
+	stats.AverageTimeSpent = models.FormatDuration(totalTimeSpent)
+
+	stats.TaskCompletionRates = []struct {
+		TaskID       int     `json:"task_id"`
+		TaskTitle    string  `json:"task_title"`
+		CompletedBy  int     `json:"completed_by"`
+		SuccessRate  float64 `json:"success_rate"`
+		AverageScore float64 `json:"average_score"`
+	}{}
+
+	for _, task := range courseTasks {
+		var taskStat struct {
+			TaskID       int     `json:"task_id"`
+			TaskTitle    string  `json:"task_title"`
+			CompletedBy  int     `json:"completed_by"`
+			SuccessRate  float64 `json:"success_rate"`
+			AverageScore float64 `json:"average_score"`
+		}
+		taskStat.TaskID = task.ID
+		taskStat.TaskTitle = task.Title
+		for userID, progress := range mockUserProgress {
+			if progress.Completed[task.ID] && inCohort(userID) {
+				taskStat.CompletedBy++
+			}
+		}
+		if stats.EnrolledStudents > 0 {
+			taskStat.SuccessRate = float64(taskStat.CompletedBy) / float64(stats.EnrolledStudents) * 100
+		}
+		stats.TaskCompletionRates = append(stats.TaskCompletionRates, taskStat)
+	}
+
+	sort.Slice(students, func(i, j int) bool { return students[i].userID < students[j].userID })
+	if !params.IsCursor() {
+		less, ok := mockStudentProgressLess[params.SortBy]
+		desc := params.SortDesc
+		if !ok {
+			less, desc = mockStudentProgressLess["completion"], true
+		}
+		sort.SliceStable(students, func(i, j int) bool {
+			if desc {
+				return less(students[j], students[i])
+			}
+			return less(students[i], students[j])
+		})
+	}
+
+	stats.StudentsTotal = len(students)
+	if params.IsCursor() {
+		var afterCursor []mockStudentProgress
+		for _, student := range students {
+			if student.userID > params.Cursor {
+				afterCursor = append(afterCursor, student)
+			}
+		}
+		students = afterCursor
+	}
+
+	stats.StudentsProgress = []struct {
+		UserID            int     `json:"user_id"`
+		Username          string  `json:"username"`
+		CompletionPercent float64 `json:"completion_percentage"`
+		AverageScore      float64 `json:"average_score"`
+		LastActivity      string  `json:"last_activity"`
+	}{}
+
+	start, end := pageBounds(len(students), params)
+	for _, student := range students[start:end] {
+		var studentProgress struct {
+			UserID            int     `json:"user_id"`
+			Username          string  `json:"username"`
+			CompletionPercent float64 `json:"completion_percentage"`
+			AverageScore      float64 `json:"average_score"`
+			LastActivity      string  `json:"last_activity"`
+		}
+		studentProgress.UserID = student.userID
+		studentProgress.Username = student.username
+		if len(courseTasks) > 0 {
+			studentProgress.CompletionPercent = float64(student.completedTasks) / float64(len(courseTasks)) * 100
+		}
+		studentProgress.LastActivity = student.lastActivity.Format("2006-01-02 15:04:05")
+
+		stats.StudentsProgress = append(stats.StudentsProgress, studentProgress)
+	}
+
+	return stats, nil
 }
 
 func (s *MockStorage) GetUserStatistics(userID int) (models.UserStatistics, error) {
-	//TODO implement me
-	panic("implement me")
+	var stats models.UserStatistics
+	stats.UserID = userID
+
+	user, exists := mockUsers[userID]
+	if !exists {
+		return stats, errors.New("user not found")
+	}
+
+	stats.JoinedDate = user.CreatedAt
+	stats.LastActive = user.CreatedAt
+	stats.TotalTasks = len(mockTasks)
+
+	progress := mockUserProgress[userID]
+	courseActivity := make(map[int]time.Time)
+	for _, task := range mockTasks {
+		if completedAt, ok := mockCompletionTimes[userID][task.ID]; ok && completedAt.After(courseActivity[task.CourseID]) {
+			courseActivity[task.CourseID] = completedAt
+		}
+	}
+
+	var timeSpent int
+	for _, summary := range mockActivitySummaries[userID] {
+		timeSpent += summary.TimeSpentSeconds
+		if summary.LastActivityAt.After(courseActivity[summary.CourseID]) {
+			courseActivity[summary.CourseID] = summary.LastActivityAt
+		}
+	}
+	stats.TotalTimeSpent = models.FormatDuration(timeSpent)
+
+	for _, lastActivity := range courseActivity {
+		if lastActivity.After(stats.LastActive) {
+			stats.LastActive = lastActivity
+		}
+	}
+
+	stats.CoursesProgress = []struct {
+		CourseID          int     `json:"course_id"`
+		CourseName        string  `json:"course_name"`
+		CompletionPercent float64 `json:"completion_percentage"`
+		AverageScore      float64 `json:"average_score"`
+		LastActivity      string  `json:"last_activity"`
+	}{}
+
+	for _, course := range mockCourses {
+		var completed, total int
+		for _, task := range mockTasks {
+			if task.CourseID != course.ID {
+				continue
+			}
+			total++
+			if progress.Completed[task.ID] {
+				completed++
+			}
+		}
+		stats.CompletedTasks += completed
+		if completed == 0 {
+			continue
+		}
+
+		stats.TotalCourses++
+		if completed == total {
+			stats.CompletedCourses++
+		}
+
+		var courseProgress struct {
+			CourseID          int     `json:"course_id"`
+			CourseName        string  `json:"course_name"`
+			CompletionPercent float64 `json:"completion_percentage"`
+			AverageScore      float64 `json:"average_score"`
+			LastActivity      string  `json:"last_activity"`
+		}
+		courseProgress.CourseID = course.ID
+		courseProgress.CourseName = course.VulnerabilityType
+		courseProgress.CompletionPercent = float64(completed) / float64(total) * 100
+		courseProgress.LastActivity = courseActivity[course.ID].Format("2006-01-02 15:04:05")
+
+		stats.CoursesProgress = append(stats.CoursesProgress, courseProgress)
+	}
+
+	sort.SliceStable(stats.CoursesProgress, func(i, j int) bool {
+		return stats.CoursesProgress[i].LastActivity > stats.CoursesProgress[j].LastActivity
+	})
+
+	return stats, nil
 }
 
 func (s *MockStorage) GetLeaderboard(courseID int, limit int) ([]models.LeaderboardEntry, error) {
-	//TODO implement me
-	panic("implement me")
+	return s.leaderboard(0, courseID, limit)
 }
 
-func (s *MockStorage) GetUserLearningPath(userID int) (models.LearningPath, error) {
-	//TODO implement me
-	panic("implement me")
+func (s *MockStorage) leaderboard(cohortID, courseID, limit int) ([]models.LeaderboardEntry, error) {
+	var cohort models.Cohort
+	if cohortID > 0 {
+		var err error
+		if cohort, err = s.GetCohort(cohortID); err != nil {
+			return nil, err
+		}
+	}
+
+	leaderboard := []models.LeaderboardEntry{}
+	for userID, progress := range mockUserProgress {
+		user, exists := mockUsers[userID]
+		if !exists || user.IsDeleted || (cohortID > 0 && !mockIsCohortMember(cohortID, userID)) {
+			continue
+		}
+
+		completed := 0
+		for _, task := range mockTasks {
+			if !progress.Completed[task.ID] || (courseID > 0 && task.CourseID != courseID) {
+				continue
+			}
+			if cohortID > 0 && !mockCohortHasCourse(cohort, task.CourseID) {
+				continue
+			}
+			completed++
+		}
+		if completed > 0 {
+			leaderboard = append(leaderboard, models.LeaderboardEntry{
+				UserID:    userID,
+				Username:  user.Username,
+				Points:    completed * 10,
+				Completed: completed,
+			})
+		}
+	}
+
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].Points != leaderboard[j].Points {
+			return leaderboard[i].Points > leaderboard[j].Points
+		}
+		return leaderboard[i].UserID < leaderboard[j].UserID
+	})
+	if limit > 0 && len(leaderboard) > limit {
+		leaderboard = leaderboard[:limit]
+	}
+	for i := range leaderboard {
+		leaderboard[i].Position = i + 1
+	}
+	return leaderboard, nil
+}
+
+func (s *MockStorage) GetLearningSnapshot(userID int) (models.LearningSnapshot, error) {
+	snapshot := models.LearningSnapshot{
+		UserID:      userID,
+		GeneratedAt: time.Now(),
+		Completed:   make(map[int]time.Time),
+	}
+
+	courseNames := make(map[int]string)
+	for _, course := range mockCourses {
+		courseNames[course.ID] = course.VulnerabilityType
+	}
+
+	tasks := append([]models.Task(nil), mockTasks...)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].CourseID != tasks[j].CourseID {
+			return tasks[i].CourseID < tasks[j].CourseID
+		}
+		return tasks[i].Order < tasks[j].Order
+	})
+
+	for _, task := range tasks {
+		learningTask := models.LearningTask{
+			Task:          task,
+			CourseName:    courseNames[task.CourseID],
+			Skills:        mockTaskSkills[task.ID],
+			Prerequisites: mockTaskPrerequisites[task.ID],
+		}
+		learningTask.Solution = ""
+
+		var total, solvers int
+		for otherID, summaries := range mockActivitySummaries {
+			summary, ok := summaries[task.ID]
+			if ok && summary.TimeSpentSeconds > 0 && mockUserProgress[otherID].Completed[task.ID] {
+				total += summary.TimeSpentSeconds
+				solvers++
+			}
+		}
+		if solvers > 0 {
+			learningTask.AverageTimeSeconds = total / solvers
+		}
+
+		snapshot.Tasks = append(snapshot.Tasks, learningTask)
+	}
+
+	for taskID, completed := range mockUserProgress[userID].Completed {
+		if completed {
+			snapshot.Completed[taskID] = mockCompletionTimes[userID][taskID]
+		}
+	}
+
+	for _, attempt := range mockSubmissions {
+		if attempt.UserID == userID {
+			snapshot.Attempts = append(snapshot.Attempts, attempt)
+		}
+	}
+
+	activity, err := s.GetUserActivitySummary(userID)
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.Activity = activity
+
+	for _, deadline := range s.userDeadlines(userID) {
+		for i := range snapshot.Tasks {
+			if snapshot.Tasks[i].ID == deadline.TaskID {
+				dueAt := deadline.DueAt
+				snapshot.Tasks[i].DueAt = &dueAt
+			}
+		}
+	}
+
+	return snapshot, nil
+}
+
+func (s *MockStorage) SetTaskSkills(courseID, taskID int, request models.TaskSkillsRequest) error {
+	if _, err := s.GetTaskByID(courseID, taskID); err != nil {
+		return err
+	}
+
+	for _, prerequisiteID := range request.Prerequisites {
+		found := false
+		for _, task := range mockTasks {
+			if task.ID == prerequisiteID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("prerequisite %d: %w", prerequisiteID, ErrTaskNotFound)
+		}
+	}
+
+	prerequisites := make(map[int][]int, len(mockTaskPrerequisites)+1)
+	for id, ids := range mockTaskPrerequisites {
+		prerequisites[id] = ids
+	}
+	prerequisites[taskID] = request.Prerequisites
+	if hasPrerequisiteCycle(prerequisites, taskID) {
+		return ErrPrerequisiteCycle
+	}
+
+	mockTaskSkills[taskID] = append([]models.TaskSkill(nil), request.Skills...)
+	mockTaskPrerequisites[taskID] = append([]int(nil), request.Prerequisites...)
+	return nil
 }
 
 func (s *MockStorage) GetTaskByID(courseID, taskID int) (models.Task, error) {
-	//TODO implement me
-	panic("implement me")
+	for _, task := range mockTasks {
+		if task.ID == taskID && task.CourseID == courseID {
+			return task, nil
+		}
+	}
+	return models.Task{}, ErrTaskNotFound
 }
 
-func (s *MockStorage) UpdatePassword(userID int, data models.UpdateProfileRequest) error {
+func (s *MockStorage) GetLearningEffectiveness(params models.EffectivenessParams) (models.LearningEffectiveness, error) {
+	course, err := s.GetCourseByID(params.CourseID)
+	if err != nil {
+		return models.LearningEffectiveness{}, err
+	}
+
+	facts := effectivenessFacts{courseName: course.VulnerabilityType}
+	courseTasks := make(map[int]bool)
+	for _, task := range mockTasks {
+		if task.CourseID == params.CourseID {
+			facts.tasks = append(facts.tasks, task)
+			courseTasks[task.ID] = true
+		}
+	}
+
+	for userID, completions := range mockCompletionTimes {
+		if _, exists := mockUsers[userID]; !exists {
+			continue
+		}
+		for taskID, completedAt := range completions {
+			if courseTasks[taskID] && completedAt.Before(params.To) {
+				facts.completions = append(facts.completions, completionFact{userID: userID, taskID: taskID, completedAt: completedAt})
+			}
+		}
+	}
+
+	for _, attempt := range mockSubmissions {
+		if _, exists := mockUsers[attempt.UserID]; !exists {
+			continue
+		}
+		if attempt.CourseID == params.CourseID && attempt.SubmittedAt.Before(params.To) {
+			facts.attempts = append(facts.attempts, attempt)
+		}
+	}
+
+	type activityKey struct{ userID, taskID int }
+	activity := make(map[activityKey]*activityFact)
+	var keys []activityKey
+	for _, event := range mockLearningActivities {
+		if _, exists := mockUsers[event.UserID]; !exists {
+			continue
+		}
+		if event.CourseID != params.CourseID || !event.Timestamp.Before(params.To) {
+			continue
+		}
+
+		key := activityKey{event.UserID, event.TaskID}
+		fact, ok := activity[key]
+		if !ok {
+			fact = &activityFact{userID: event.UserID, taskID: event.TaskID}
+			activity[key] = fact
+			keys = append(keys, key)
+		}
+		if event.ActivityType == models.ActivityTimeOnTask && !event.Timestamp.Before(params.From) {
+			fact.timeSpent += event.Duration
+		}
+		if event.Timestamp.After(fact.lastAt) {
+			fact.lastAt = event.Timestamp
+		}
+	}
+	for _, key := range keys {
+		facts.activity = append(facts.activity, *activity[key])
+	}
+
+	return buildEffectivenessReport(params, facts), nil
+}
+
+func (s *MockStorage) UpdatePassword(userID int, data models.UpdateProfileRequest) error {
 	//TODO implement me
 	panic("implement me")
 }
+
+func (s *MockStorage) Search(query models.SearchQuery, params models.ListParams) ([]models.SearchResult, int, error) {
+	terms := searchTerms(query.Text)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	var results []models.SearchResult
+
+	if query.WantsType(models.SearchTypeCourse) {
+		for _, course := range mockCourses {
+			if params.CourseID > 0 && course.ID != params.CourseID {
+				continue
+			}
+			if result, ok := newSearchResult(models.SearchTypeCourse, course.ID, course.ID, course.VulnerabilityType, terms, courseSearchFields(course)...); ok {
+				results = append(results, result)
+			}
+		}
+	}
+
+	if query.WantsType(models.SearchTypeTask) {
+		for _, task := range mockTasks {
+			if params.CourseID > 0 && task.CourseID != params.CourseID {
+				continue
+			}
+			if result, ok := newSearchResult(models.SearchTypeTask, task.ID, task.CourseID, task.Title, terms, taskSearchFields(task, query.IncludeSolutions)...); ok {
+				results = append(results, result)
+			}
+		}
+	}
+
+	if query.WantsType(models.SearchTypeUser) {
+		for _, user := range mockUsers {
+			if result, ok := newSearchResult(models.SearchTypeUser, user.ID, 0, user.Username, terms, userSearchFields(user)...); ok {
+				results = append(results, result)
+			}
+		}
+	}
+
+	rankSearchResults(results)
+
+	start, end := pageBounds(len(results), params)
+	return results[start:end], len(results), nil
+}
+
+func (s *MockStorage) RecordLearningActivities(userID int, activities []models.LearningActivity) error {
+	taskCourses := make(map[int]int, len(activities))
+	for i, activity := range activities {
+		courseID := 0
+		for _, task := range mockTasks {
+			if task.ID == activity.TaskID {
+				courseID = task.CourseID
+				break
+			}
+		}
+		if courseID == 0 {
+			return fmt.Errorf("event %d: %w", i, ErrTaskNotFound)
+		}
+		if activity.CourseID != 0 && activity.CourseID != courseID {
+			return fmt.Errorf("event %d: %w", i, ErrActivityCourseMismatch)
+		}
+		taskCourses[activity.TaskID] = courseID
+	}
+
+	if _, exists := mockActivitySummaries[userID]; !exists {
+		mockActivitySummaries[userID] = make(map[int]models.TaskActivitySummary)
+	}
+
+	for _, activity := range activities {
+		activity.UserID = userID
+		activity.CourseID = taskCourses[activity.TaskID]
+		activity.Timestamp = activity.Timestamp.UTC()
+		activity.ID = len(mockLearningActivities) + 1
+		mockLearningActivities = append(mockLearningActivities, activity)
+
+		summary, exists := mockActivitySummaries[userID][activity.TaskID]
+		if !exists {
+			summary = models.TaskActivitySummary{UserID: userID, TaskID: activity.TaskID, CourseID: activity.CourseID}
+		}
+		summary.Add(activity)
+		mockActivitySummaries[userID][activity.TaskID] = summary
+	}
+
+	return nil
+}
+
+func (s *MockStorage) GetUserActivitySummary(userID int) ([]models.TaskActivitySummary, error) {
+	var summaries []models.TaskActivitySummary
+	for _, summary := range mockActivitySummaries[userID] {
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].TaskID < summaries[j].TaskID })
+	return summaries, nil
+}
+
+// validateAssignmentTasks проверяет группу задания и принадлежность задач курсу задания
+func (s *MockStorage) validateAssignmentTasks(assignment models.Assignment) error {
+	if assignment.CohortID != nil {
+		cohort, err := s.GetCohort(*assignment.CohortID)
+		if err != nil {
+			return err
+		}
+		if !mockCohortHasCourse(cohort, assignment.CourseID) {
+			return ErrCourseNotInCohort
+		}
+	}
+	for _, taskID := range assignment.TaskIDs {
+		if _, err := s.GetTaskByID(assignment.CourseID, taskID); err != nil {
+			return fmt.Errorf("task %d: %w", taskID, ErrTaskNotFound)
+		}
+	}
+	return nil
+}
+
+func (s *MockStorage) CreateAssignment(assignment models.Assignment) (models.Assignment, error) {
+	if _, err := s.GetCourseByID(assignment.CourseID); err != nil {
+		return assignment, err
+	}
+	if err := s.validateAssignmentTasks(assignment); err != nil {
+		return assignment, err
+	}
+
+	assignment.ID = 1
+	for _, a := range mockAssignments {
+		if a.ID >= assignment.ID {
+			assignment.ID = a.ID + 1
+		}
+	}
+	assignment.CreatedAt = time.Now().UTC()
+	assignment.TaskIDs = append([]int(nil), assignment.TaskIDs...)
+	mockAssignments = append(mockAssignments, assignment)
+	return assignment, nil
+}
+
+func (s *MockStorage) UpdateAssignment(assignment models.Assignment) (models.Assignment, error) {
+	for i, a := range mockAssignments {
+		if a.ID != assignment.ID || a.CourseID != assignment.CourseID {
+			continue
+		}
+		if err := s.validateAssignmentTasks(assignment); err != nil {
+			return assignment, err
+		}
+		assignment.CreatedBy = a.CreatedBy
+		assignment.CreatedAt = a.CreatedAt
+		assignment.TaskIDs = append([]int(nil), assignment.TaskIDs...)
+		mockAssignments[i] = assignment
+		return assignment, nil
+	}
+	return assignment, ErrAssignmentNotFound
+}
+
+func (s *MockStorage) DeleteAssignment(courseID, assignmentID int) error {
+	for i, a := range mockAssignments {
+		if a.ID == assignmentID && a.CourseID == courseID {
+			mockAssignments = append(mockAssignments[:i], mockAssignments[i+1:]...)
+			delete(mockExtensions, assignmentID)
+			return nil
+		}
+	}
+	return ErrAssignmentNotFound
+}
+
+func (s *MockStorage) GetAssignment(courseID, assignmentID int) (models.Assignment, error) {
+	for _, a := range mockAssignments {
+		if a.ID == assignmentID && a.CourseID == courseID {
+			return a, nil
+		}
+	}
+	return models.Assignment{}, ErrAssignmentNotFound
+}
+
+func (s *MockStorage) GetCourseAssignments(courseID int) ([]models.Assignment, error) {
+	assignments := []models.Assignment{}
+	for _, a := range mockAssignments {
+		if a.CourseID == courseID {
+			assignments = append(assignments, a)
+		}
+	}
+	sort.SliceStable(assignments, func(i, j int) bool { return assignments[i].DueAt.Before(assignments[j].DueAt) })
+	return assignments, nil
+}
+
+func (s *MockStorage) GrantExtension(extension models.AssignmentExtension) error {
+	if _, exists := mockExtensions[extension.AssignmentID]; !exists {
+		mockExtensions[extension.AssignmentID] = make(map[int]models.AssignmentExtension)
+	}
+	extension.CreatedAt = time.Now().UTC()
+	mockExtensions[extension.AssignmentID][extension.UserID] = extension
+	return nil
+}
+
+// userAssignments возвращает задания всего курса (без ограничения группой)
+func (s *MockStorage) userAssignments(userID int) []models.Assignment {
+	var assignments []models.Assignment
+	for _, a := range mockAssignments {
+		if a.CohortID == nil || mockIsCohortMember(*a.CohortID, userID) {
+			assignments = append(assignments, a)
+		}
+	}
+	return assignments
+}
+
+func (s *MockStorage) userDeadlines(userID int) []models.TaskDeadline {
+	extensions := make(map[int]time.Time)
+	for assignmentID, byUser := range mockExtensions {
+		if extension, ok := byUser[userID]; ok {
+			extensions[assignmentID] = extension.DueAt
+		}
+	}
+	return models.ResolveDeadlines(s.userAssignments(userID), extensions)
+}
+
+func (s *MockStorage) GetUserDeadlines(userID int) ([]models.TaskDeadline, error) {
+	return s.userDeadlines(userID), nil
+}
+
+// mockCourseStudents возвращает пользователей с любой активностью по задачам курса
+func mockCourseStudents(courseID int) []int {
+	courseTasks := make(map[int]bool)
+	for _, task := range mockTasks {
+		if task.CourseID == courseID {
+			courseTasks[task.ID] = true
+		}
+	}
+
+	students := make(map[int]bool)
+	for userID, progress := range mockUserProgress {
+		for taskID := range progress.Completed {
+			if courseTasks[taskID] {
+				students[userID] = true
+			}
+		}
+	}
+	for _, attempt := range mockSubmissions {
+		if attempt.CourseID == courseID {
+			students[attempt.UserID] = true
+		}
+	}
+	for _, activity := range mockLearningActivities {
+		if activity.CourseID == courseID {
+			students[activity.UserID] = true
+		}
+	}
+
+	var ids []int
+	for userID := range students {
+		if user, exists := mockUsers[userID]; exists && user.IsActive {
+			ids = append(ids, userID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func mockReminderKey(reminder models.DeadlineReminder) string {
+	return fmt.Sprintf("%d:%d:%d", reminder.AssignmentID, reminder.UserID, reminder.DueAt.Unix())
+}
+
+func (s *MockStorage) GetPendingReminders(now time.Time, window time.Duration) ([]models.DeadlineReminder, error) {
+	var reminders []models.DeadlineReminder
+	for _, assignment := range mockAssignments {
+		course, err := s.GetCourseByID(assignment.CourseID)
+		if err != nil {
+			continue
+		}
+
+		students := mockCourseStudents(assignment.CourseID)
+		if assignment.CohortID != nil {
+			students = nil
+			for userID := range mockCohortMembers[*assignment.CohortID] {
+				if user, exists := mockUsers[userID]; exists && user.IsActive {
+					students = append(students, userID)
+				}
+			}
+			sort.Ints(students)
+		}
+
+		for _, userID := range students {
+			reminder := models.DeadlineReminder{
+				AssignmentID:    assignment.ID,
+				AssignmentTitle: assignment.Title,
+				CourseID:        assignment.CourseID,
+				CourseName:      course.VulnerabilityType,
+				UserID:          userID,
+				Username:        mockUsers[userID].Username,
+				Email:           mockUsers[userID].Email,
+				DueAt:           assignment.DueAt,
+			}
+			if extension, ok := mockExtensions[assignment.ID][userID]; ok {
+				reminder.DueAt = extension.DueAt
+			}
+			if !reminder.DueAt.After(now) || reminder.DueAt.After(now.Add(window)) || mockSentReminders[mockReminderKey(reminder)] {
+				continue
+			}
+
+			for _, taskID := range assignment.TaskIDs {
+				if !mockUserProgress[userID].Completed[taskID] {
+					reminder.RemainingTasks++
+				}
+			}
+			if reminder.RemainingTasks > 0 {
+				reminders = append(reminders, reminder)
+			}
+		}
+	}
+	return reminders, nil
+}
+
+func (s *MockStorage) MarkReminderSent(reminder models.DeadlineReminder, sentAt time.Time) error {
+	mockSentReminders[mockReminderKey(reminder)] = true
+	return nil
+}
+
+// ****** УЧЕБНЫЕ ГРУППЫ ******
+
+func mockIsCohortMember(cohortID, userID int) bool {
+	_, ok := mockCohortMembers[cohortID][userID]
+	return ok
+}
+
+// mockTeachesStudent сообщает, что студент состоит в группе преподавателя, которой назначен курс
+func mockTeachesStudent(teacherID, userID, courseID int) bool {
+	for _, cohort := range mockCohorts {
+		if slices.Contains(cohort.TeacherIDs, teacherID) && mockCohortHasCourse(cohort, courseID) && mockIsCohortMember(cohort.ID, userID) {
+			return true
+		}
+	}
+	return false
+}
+
+func mockCohortHasCourse(cohort models.Cohort, courseID int) bool {
+	for _, id := range cohort.CourseIDs {
+		if id == courseID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MockStorage) withMembersCount(cohort models.Cohort) models.Cohort {
+	cohort.MembersCount = len(mockCohortMembers[cohort.ID])
+	cohort.CourseIDs = append([]int{}, cohort.CourseIDs...)
+	cohort.TeacherIDs = append([]int{}, cohort.TeacherIDs...)
+	return cohort
+}
+
+func (s *MockStorage) validateCohort(cohort models.Cohort) error {
+	for _, c := range mockCohorts {
+		if c.ID != cohort.ID && c.Name == cohort.Name {
+			return ErrCohortNameTaken
+		}
+	}
+	for _, courseID := range cohort.CourseIDs {
+		if _, err := s.GetCourseByID(courseID); err != nil {
+			return fmt.Errorf("course %d: %w", courseID, ErrCourseNotFound)
+		}
+	}
+	return nil
+}
+
+func (s *MockStorage) CreateCohort(cohort models.Cohort) (models.Cohort, error) {
+	if err := s.validateCohort(cohort); err != nil {
+		return cohort, err
+	}
+
+	cohort.ID = 1
+	for _, c := range mockCohorts {
+		if c.ID >= cohort.ID {
+			cohort.ID = c.ID + 1
+		}
+	}
+	cohort.CreatedAt = time.Now().UTC()
+	mockCohorts = append(mockCohorts, s.withMembersCount(cohort))
+	return s.GetCohort(cohort.ID)
+}
+
+func (s *MockStorage) UpdateCohort(cohort models.Cohort) (models.Cohort, error) {
+	for i, c := range mockCohorts {
+		if c.ID != cohort.ID {
+			continue
+		}
+		if err := s.validateCohort(cohort); err != nil {
+			return cohort, err
+		}
+		cohort.InviteCode = c.InviteCode
+		cohort.CreatedBy = c.CreatedBy
+		cohort.CreatedAt = c.CreatedAt
+		mockCohorts[i] = s.withMembersCount(cohort)
+		return s.GetCohort(cohort.ID)
+	}
+	return cohort, ErrCohortNotFound
+}
+
+func (s *MockStorage) DeleteCohort(cohortID int) error {
+	for i, c := range mockCohorts {
+		if c.ID != cohortID {
+			continue
+		}
+		mockCohorts = append(mockCohorts[:i], mockCohorts[i+1:]...)
+		delete(mockCohortMembers, cohortID)
+
+		var assignments []models.Assignment
+		for _, a := range mockAssignments {
+			if a.CohortID != nil && *a.CohortID == cohortID {
+				delete(mockExtensions, a.ID)
+				continue
+			}
+			assignments = append(assignments, a)
+		}
+		mockAssignments = assignments
+		return nil
+	}
+	return ErrCohortNotFound
+}
+
+func (s *MockStorage) GetCohort(cohortID int) (models.Cohort, error) {
+	for _, c := range mockCohorts {
+		if c.ID == cohortID {
+			return s.withMembersCount(c), nil
+		}
+	}
+	return models.Cohort{}, ErrCohortNotFound
+}
+
+func (s *MockStorage) GetCohortByInviteCode(code string) (models.Cohort, error) {
+	for _, c := range mockCohorts {
+		if code != "" && c.InviteCode == code {
+			return s.withMembersCount(c), nil
+		}
+	}
+	return models.Cohort{}, ErrCohortNotFound
+}
+
+func (s *MockStorage) GetCohorts(teacherID int, params models.ListParams) ([]models.Cohort, int, error) {
+	cohorts := []models.Cohort{}
+	for _, c := range mockCohorts {
+		if teacherID > 0 {
+			if isTeacher, _ := s.IsCohortTeacher(c.ID, teacherID); !isTeacher {
+				continue
+			}
+		}
+		if params.CourseID > 0 && !mockCohortHasCourse(c, params.CourseID) {
+			continue
+		}
+		if params.IsCursor() && c.ID <= params.Cursor {
+			continue
+		}
+		cohorts = append(cohorts, s.withMembersCount(c))
+	}
+
+	sort.SliceStable(cohorts, func(i, j int) bool {
+		if params.IsCursor() {
+			return cohorts[i].ID < cohorts[j].ID
+		}
+		switch params.SortBy {
+		case "id":
+			return cohorts[i].ID < cohorts[j].ID != params.SortDesc
+		case "created_at":
+			return cohorts[i].CreatedAt.Before(cohorts[j].CreatedAt) != params.SortDesc
+		case "name":
+			return cohorts[i].Name < cohorts[j].Name != params.SortDesc
+		}
+		return cohorts[i].Name < cohorts[j].Name
+	})
+
+	start, end := pageBounds(len(cohorts), params)
+	return cohorts[start:end], len(cohorts), nil
+}
+
+func (s *MockStorage) SetCohortInviteCode(cohortID int, code string) error {
+	for i, c := range mockCohorts {
+		if c.ID == cohortID {
+			mockCohorts[i].InviteCode = code
+			return nil
+		}
+	}
+	return ErrCohortNotFound
+}
+
+func (s *MockStorage) IsCohortTeacher(cohortID, userID int) (bool, error) {
+	for _, c := range mockCohorts {
+		if c.ID != cohortID {
+			continue
+		}
+		for _, teacherID := range c.TeacherIDs {
+			if teacherID == userID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (s *MockStorage) AddCohortMembers(cohortID int, userIDs []int) (models.CohortImportResult, error) {
+	result := models.CohortImportResult{Added: []int{}, AlreadyMembers: []int{}, NotFound: []string{}}
+	if _, err := s.GetCohort(cohortID); err != nil {
+		return result, err
+	}
+
+	if mockCohortMembers[cohortID] == nil {
+		mockCohortMembers[cohortID] = make(map[int]time.Time)
+	}
+	for _, userID := range userIDs {
+		user, exists := mockUsers[userID]
+		switch {
+		case !exists || user.IsDeleted:
+			result.NotFound = append(result.NotFound, fmt.Sprint(userID))
+		case mockIsCohortMember(cohortID, userID):
+			result.AlreadyMembers = append(result.AlreadyMembers, userID)
+		default:
+			mockCohortMembers[cohortID][userID] = time.Now().UTC()
+			result.Added = append(result.Added, userID)
+		}
+	}
+	return result, nil
+}
+
+func (s *MockStorage) RemoveCohortMember(cohortID, userID int) error {
+	if !mockIsCohortMember(cohortID, userID) {
+		return ErrCohortMemberNotFound
+	}
+	delete(mockCohortMembers[cohortID], userID)
+	return nil
+}
+
+func (s *MockStorage) GetCohortMembers(cohortID int, params models.ListParams) ([]models.CohortMember, int, error) {
+	members := []models.CohortMember{}
+	for userID, joinedAt := range mockCohortMembers[cohortID] {
+		user, exists := mockUsers[userID]
+		if !exists || user.IsDeleted {
+			continue
+		}
+		if params.IsActive != nil && user.IsActive != *params.IsActive {
+			continue
+		}
+		members = append(members, models.CohortMember{
+			UserID:   userID,
+			Username: user.Username,
+			FullName: user.FullName,
+			Email:    user.Email,
+			JoinedAt: joinedAt,
+		})
+	}
+	total := len(members)
+
+	sort.Slice(members, func(i, j int) bool {
+		if params.IsCursor() || params.SortBy == "user_id" {
+			return members[i].UserID < members[j].UserID != (params.SortDesc && !params.IsCursor())
+		}
+		if params.SortBy == "joined_at" {
+			return members[i].JoinedAt.Before(members[j].JoinedAt) != params.SortDesc
+		}
+		return members[i].Username < members[j].Username != params.SortDesc
+	})
+	if params.IsCursor() {
+		var afterCursor []models.CohortMember
+		for _, member := range members {
+			if member.UserID > params.Cursor {
+				afterCursor = append(afterCursor, member)
+			}
+		}
+		members = afterCursor
+	}
+
+	start, end := pageBounds(len(members), params)
+	return members[start:end], total, nil
+}
+
+func (s *MockStorage) ResolveUserIDs(logins []string) (map[string]int, error) {
+	ids := make(map[string]int)
+	for _, login := range logins {
+		for _, user := range mockUsers {
+			if user.IsDeleted {
+				continue
+			}
+			if strings.EqualFold(user.Username, login) || strings.EqualFold(user.Email, login) {
+				ids[login] = user.ID
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (s *MockStorage) GetCohortLeaderboard(cohortID, courseID, limit int) ([]models.LeaderboardEntry, error) {
+	return s.leaderboard(cohortID, courseID, limit)
+}
+
+func (s *MockStorage) GetCohortSubmissions(cohortID int, params models.ListParams) ([]models.CohortSubmission, int, error) {
+	cohort, err := s.GetCohort(cohortID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	submissions := []models.CohortSubmission{}
+	for _, attempt := range mockSubmissions {
+		if !mockIsCohortMember(cohortID, attempt.UserID) || !mockCohortHasCourse(cohort, attempt.CourseID) {
+			continue
+		}
+		if params.CourseID > 0 && attempt.CourseID != params.CourseID {
+			continue
+		}
+		if !params.From.IsZero() && attempt.SubmittedAt.Before(params.From) {
+			continue
+		}
+		if !params.To.IsZero() && !attempt.SubmittedAt.Before(params.To) {
+			continue
+		}
+
+		task, _ := s.GetTaskByID(attempt.CourseID, attempt.TaskID)
+		submissions = append(submissions, models.CohortSubmission{
+			SubmissionID: attempt.ID,
+			UserID:       attempt.UserID,
+			Username:     mockUsers[attempt.UserID].Username,
+			TaskID:       attempt.TaskID,
+			TaskTitle:    task.Title,
+			CourseID:     attempt.CourseID,
+			IsCorrect:    attempt.IsCorrect,
+			Score:        attempt.Score,
+			IsLate:       attempt.IsLate,
+			Penalty:      attempt.Penalty,
+			SubmittedAt:  attempt.SubmittedAt,
+		})
+	}
+	total := len(submissions)
+
+	if params.IsCursor() {
+		var afterCursor []models.CohortSubmission
+		for _, submission := range submissions {
+			if submission.SubmissionID > params.Cursor {
+				afterCursor = append(afterCursor, submission)
+			}
+		}
+		submissions = afterCursor
+	} else {
+		sort.SliceStable(submissions, func(i, j int) bool {
+			return submissions[i].SubmittedAt.After(submissions[j].SubmittedAt)
+		})
+	}
+
+	start, end := pageBounds(len(submissions), params)
+	return submissions[start:end], total, nil
+}
+
+// ****** ТЕСТЫ ******
+
+// checkQuizBanks проверяет, что банки вопросов теста принадлежат курсу задачи
+func (s *MockStorage) checkQuizBanks(courseID int, task models.Task) error {
+	if task.Type != models.TaskTypeQuiz {
+		return nil
+	}
+	for _, bankID := range quizBankIDs(task.Quiz) {
+		if _, err := s.GetQuestionBank(courseID, bankID); err != nil {
+			return fmt.Errorf("bank %d: %w", bankID, err)
+		}
+	}
+	return nil
+}
+
+// withQuestionIDs присваивает вопросам и вариантам банка новые ID и позиции
+func withQuestionIDs(bank models.QuestionBank) models.QuestionBank {
+	questions := make([]models.QuizQuestion, len(bank.Questions))
+	for i, question := range bank.Questions {
+		mockQuizSequence++
+		question.ID = mockQuizSequence
+		options := make([]models.QuizOption, len(question.Options))
+		for _, j := range optionInsertOrder(question) {
+			option := question.Options[j]
+			mockQuizSequence++
+			option.ID = mockQuizSequence
+			option.Position = j + 1
+			option.IsCorrect = option.IsCorrect || question.Type == models.QuestionShortAnswer
+			options[j] = option
+		}
+		question.Options = options
+		questions[i] = question
+	}
+	bank.Questions = questions
+	return bank
+}
+
+func cloneQuizAttempt(attempt models.QuizAttempt) models.QuizAttempt {
+	attempt.Questions = append([]models.QuizAttemptQuestion{}, attempt.Questions...)
+	return attempt
+}
+
+func (s *MockStorage) CreateQuestionBank(bank models.QuestionBank) (models.QuestionBank, error) {
+	if _, err := s.GetCourseByID(bank.CourseID); err != nil {
+		return bank, ErrCourseNotFound
+	}
+
+	bank.ID = 1
+	for _, b := range mockQuestionBanks {
+		if b.ID >= bank.ID {
+			bank.ID = b.ID + 1
+		}
+	}
+	bank.CreatedAt = time.Now().UTC()
+	bank = withQuestionIDs(bank)
+	mockQuestionBanks = append(mockQuestionBanks, bank)
+	return bank, nil
+}
+
+func (s *MockStorage) UpdateQuestionBank(bank models.QuestionBank) (models.QuestionBank, error) {
+	for i, b := range mockQuestionBanks {
+		if b.ID == bank.ID && b.CourseID == bank.CourseID {
+			bank.CreatedAt = b.CreatedAt
+			bank = withQuestionIDs(bank)
+			mockQuestionBanks[i] = bank
+			return bank, nil
+		}
+	}
+	return bank, ErrQuestionBankNotFound
+}
+
+func (s *MockStorage) DeleteQuestionBank(courseID, bankID int) error {
+	if _, err := s.GetQuestionBank(courseID, bankID); err != nil {
+		return err
+	}
+	for _, task := range mockTasks {
+		for _, id := range quizBankIDs(task.Quiz) {
+			if id == bankID {
+				return ErrQuestionBankInUse
+			}
+		}
+	}
+	for i, b := range mockQuestionBanks {
+		if b.ID == bankID {
+			mockQuestionBanks = append(mockQuestionBanks[:i], mockQuestionBanks[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *MockStorage) GetQuestionBank(courseID, bankID int) (models.QuestionBank, error) {
+	for _, b := range mockQuestionBanks {
+		if b.ID == bankID && b.CourseID == courseID {
+			return b, nil
+		}
+	}
+	return models.QuestionBank{}, ErrQuestionBankNotFound
+}
+
+func (s *MockStorage) GetQuestionBanks(courseID int) ([]models.QuestionBank, error) {
+	banks := []models.QuestionBank{}
+	for _, b := range mockQuestionBanks {
+		if b.CourseID == courseID {
+			banks = append(banks, b)
+		}
+	}
+	return banks, nil
+}
+
+func (s *MockStorage) StartQuizAttempt(userID, taskID int, startedAt time.Time) (models.QuizAttempt, error) {
+	var task models.Task
+	var found bool
+	for _, t := range mockTasks {
+		if t.ID == taskID {
+			task, found = t, true
+			break
+		}
+	}
+	if !found {
+		return models.QuizAttempt{}, ErrTaskNotFound
+	}
+
+	attempts, _ := s.GetQuizAttempts(userID, taskID)
+	for _, attempt := range attempts {
+		if attempt.Status != models.QuizAttemptInProgress {
+			continue
+		}
+		if !attempt.Expired(startedAt) {
+			return s.GetQuizAttempt(attempt.ID)
+		}
+		if _, err := s.SubmitQuizAttempt(attempt.ID, nil, startedAt); err != nil {
+			return models.QuizAttempt{}, fmt.Errorf("close expired attempt: %w", err)
+		}
+	}
+
+	var deadlines []models.TaskDeadline
+	for _, deadline := range s.userDeadlines(userID) {
+		if deadline.TaskID == taskID {
+			deadlines = append(deadlines, deadline)
+		}
+	}
+	if err := checkQuizStart(task, deadlines, len(attempts), startedAt); err != nil {
+		return models.QuizAttempt{}, err
+	}
+
+	banks := make(map[int][]models.QuizQuestion)
+	for _, bankID := range quizBankIDs(task.Quiz) {
+		bank, _ := s.GetQuestionBank(task.CourseID, bankID)
+		banks[bankID] = bank.Questions
+	}
+	attempt, err := newQuizAttempt(userID, task, banks, startedAt)
+	if err != nil {
+		return attempt, err
+	}
+
+	attempt.ID = len(mockQuizAttempts) + 1
+	mockQuizAttempts = append(mockQuizAttempts, cloneQuizAttempt(attempt))
+	return attempt, nil
+}
+
+func (s *MockStorage) GetQuizAttempt(attemptID int) (models.QuizAttempt, error) {
+	for _, attempt := range mockQuizAttempts {
+		if attempt.ID == attemptID {
+			return cloneQuizAttempt(attempt), nil
+		}
+	}
+	return models.QuizAttempt{}, ErrQuizAttemptNotFound
+}
+
+func (s *MockStorage) GetQuizAttempts(userID, taskID int) ([]models.QuizAttempt, error) {
+	attempts := []models.QuizAttempt{}
+	for _, attempt := range mockQuizAttempts {
+		if attempt.UserID == userID && attempt.TaskID == taskID {
+			attempt.Questions = nil
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func (s *MockStorage) SubmitQuizAttempt(attemptID int, answers []models.QuizAnswer, submittedAt time.Time) (models.QuizAttempt, error) {
+	attempt, err := s.GetQuizAttempt(attemptID)
+	if err != nil {
+		return attempt, err
+	}
+	if attempt.Status != models.QuizAttemptInProgress {
+		return attempt, ErrQuizAttemptFinished
+	}
+
+	task, err := s.GetTaskByID(attempt.CourseID, attempt.TaskID)
+	if err != nil {
+		return attempt, fmt.Errorf("get task: %w", err)
+	}
+	if task.Quiz == nil {
+		return attempt, ErrNotQuizTask
+	}
+	var deadlines []models.TaskDeadline
+	for _, deadline := range s.userDeadlines(attempt.UserID) {
+		if deadline.TaskID == task.ID {
+			deadlines = append(deadlines, deadline)
+		}
+	}
+	response := gradeQuizAttempt(&attempt, task, answers, deadlines, submittedAt)
+
+	submission := models.SubmissionAttempt{
+		ID:          len(mockSubmissions) + 1,
+		UserID:      attempt.UserID,
+		TaskID:      task.ID,
+		CourseID:    task.CourseID,
+		IsCorrect:   response.IsCorrect,
+		Score:       response.Score,
+		IsLate:      response.IsLate,
+		Penalty:     response.Penalty,
+		SubmittedAt: submittedAt,
+	}
+	mockSubmissions = append(mockSubmissions, submission)
+	attempt.SubmissionID = submission.ID
+	mockQuizAttempts[attempt.ID-1] = cloneQuizAttempt(attempt)
+
+	if attempt.IsPassed {
+		if err := s.CompleteTask(attempt.UserID, task.ID); err != nil {
+			return attempt, fmt.Errorf("complete task: %w", err)
+		}
+	}
+	return attempt, nil
+}
+
+// ****** СЕРТИФИКАТЫ ******
+
+func (s *MockStorage) GetCourseCompletions(userID int) ([]models.CourseCompletion, error) {
+	best := make(map[int]float64)
+	for _, submission := range mockSubmissions {
+		if score, exists := best[submission.TaskID]; submission.UserID == userID && (!exists || submission.Score > score) {
+			best[submission.TaskID] = submission.Score
+		}
+	}
+
+	var results []taskResult
+	for _, course := range mockCourses {
+		for _, task := range mockTasks {
+			if task.CourseID != course.ID {
+				continue
+			}
+			result := taskResult{CourseID: course.ID, CourseTitle: course.VulnerabilityType, Points: task.Points}
+			if mockUserProgress[userID].Completed[task.ID] {
+				completedAt := mockCompletionTimes[userID][task.ID]
+				result.CompletedAt = &completedAt
+			}
+			if score, exists := best[task.ID]; exists {
+				result.BestScore = &score
+			}
+			results = append(results, result)
+		}
+	}
+	return courseCompletions(results), nil
+}
+
+func (s *MockStorage) CreateCertificate(cert models.Certificate) (models.Certificate, error) {
+	for _, existing := range mockCertificates {
+		if existing.UserID == cert.UserID && existing.CourseID == cert.CourseID {
+			return existing, ErrCertificateExists
+		}
+	}
+	mockCertificates = append(mockCertificates, cert)
+	return cert, nil
+}
+
+func (s *MockStorage) GetCertificate(id string) (models.Certificate, error) {
+	for _, cert := range mockCertificates {
+		if cert.ID == id {
+			return cert, nil
+		}
+	}
+	return models.Certificate{}, ErrCertificateNotFound
+}
+
+func (s *MockStorage) GetUserCertificates(userID int) ([]models.Certificate, error) {
+	certificates := []models.Certificate{}
+	for _, cert := range mockCertificates {
+		if cert.UserID == userID {
+			certificates = append(certificates, cert)
+		}
+	}
+	return certificates, nil
+}
+
+func (s *MockStorage) RevokeCertificate(id, reason string, revokedAt time.Time) (models.Certificate, error) {
+	for i, cert := range mockCertificates {
+		if cert.ID != id {
+			continue
+		}
+		if cert.IsRevoked() {
+			return cert, ErrCertificateRevoked
+		}
+		cert.RevokedAt = &revokedAt
+		cert.RevokeReason = reason
+		mockCertificates[i] = cert
+		return cert, nil
+	}
+	return models.Certificate{}, ErrCertificateNotFound
+}
+
+// ****** ЗНАЧКИ ******
+
+func (s *MockStorage) GetBadges() ([]models.Badge, error) {
+	return append([]models.Badge{}, mockBadges...), nil
+}
+
+func (s *MockStorage) GetBadge(badgeID int) (models.Badge, error) {
+	for _, badge := range mockBadges {
+		if badge.ID == badgeID {
+			return badge, nil
+		}
+	}
+	return models.Badge{}, ErrBadgeNotFound
+}
+
+func mockBadgeCodeTaken(code string, badgeID int) bool {
+	for _, badge := range mockBadges {
+		if badge.Code == code && badge.ID != badgeID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MockStorage) CreateBadge(badge models.Badge) (models.Badge, error) {
+	if mockBadgeCodeTaken(badge.Code, 0) {
+		return badge, ErrBadgeCodeTaken
+	}
+	for _, existing := range mockBadges {
+		if existing.ID > badge.ID {
+			badge.ID = existing.ID
+		}
+	}
+	badge.ID++
+	badge.CreatedAt = time.Now().UTC()
+	mockBadges = append(mockBadges, badge)
+	return badge, nil
+}
+
+func (s *MockStorage) UpdateBadge(badge models.Badge) (models.Badge, error) {
+	for i, existing := range mockBadges {
+		if existing.ID != badge.ID {
+			continue
+		}
+		if mockBadgeCodeTaken(badge.Code, badge.ID) {
+			return badge, ErrBadgeCodeTaken
+		}
+		badge.CreatedAt = existing.CreatedAt
+		mockBadges[i] = badge
+		return badge, nil
+	}
+	return badge, ErrBadgeNotFound
+}
+
+func (s *MockStorage) DeleteBadge(badgeID int) error {
+	for i, badge := range mockBadges {
+		if badge.ID != badgeID {
+			continue
+		}
+		mockBadges = append(mockBadges[:i], mockBadges[i+1:]...)
+		for userID, badges := range mockUserBadges {
+			kept := badges[:0]
+			for _, awarded := range badges {
+				if awarded.BadgeID != badgeID {
+					kept = append(kept, awarded)
+				}
+			}
+			mockUserBadges[userID] = kept
+		}
+		return nil
+	}
+	return ErrBadgeNotFound
+}
+
+func (s *MockStorage) AwardBadges(userID int, badges []models.UserBadge) ([]models.UserBadge, error) {
+	var awarded []models.UserBadge
+	for _, badge := range badges {
+		exists := false
+		for _, existing := range mockUserBadges[userID] {
+			if existing.BadgeID == badge.BadgeID {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			mockUserBadges[userID] = append(mockUserBadges[userID], badge)
+			awarded = append(awarded, badge)
+		}
+	}
+	return awarded, nil
+}
+
+func (s *MockStorage) GetUserBadges(userID int) ([]models.UserBadge, error) {
+	badges := []models.UserBadge{}
+	for _, awarded := range mockUserBadges[userID] {
+		if badge, err := s.GetBadge(awarded.BadgeID); err == nil {
+			awarded.Code, awarded.Name, awarded.Description, awarded.Icon = badge.Code, badge.Name, badge.Description, badge.Icon
+		}
+		badges = append(badges, awarded)
+	}
+	return badges, nil
+}
+
+// ****** НАСТРОЙКИ И СЕРИИ ДНЕЙ ******
+
+func (s *MockStorage) GetUserPreferences(userID int) (models.UserPreferences, error) {
+	if prefs, exists := mockUserPreferences[userID]; exists {
+		return prefs, nil
+	}
+	return models.UserPreferences{Timezone: models.DefaultTimezone}, nil
+}
+
+func (s *MockStorage) UpdateUserPreferences(userID int, prefs models.UserPreferences) error {
+	mockUserPreferences[userID] = prefs
+	return nil
+}
+
+func (s *MockStorage) GetActivityTimes(userID int, from, to time.Time) (models.ActivityTimes, error) {
+	var times models.ActivityTimes
+	inRange := func(at time.Time) bool { return !at.Before(from) && at.Before(to) }
+	for _, completedAt := range mockCompletionTimes[userID] {
+		if inRange(completedAt) {
+			times.Completed = append(times.Completed, completedAt)
+		}
+	}
+	for _, submission := range mockSubmissions {
+		if submission.UserID == userID && inRange(submission.SubmittedAt) {
+			times.Submitted = append(times.Submitted, submission.SubmittedAt)
+		}
+	}
+	return times, nil
+}
+
+func (s *MockStorage) GetStreakReminderCandidates() ([]models.StreakReminderCandidate, error) {
+	var candidates []models.StreakReminderCandidate
+	for userID, prefs := range mockUserPreferences {
+		user, exists := mockUsers[userID]
+		if !exists || !prefs.StreakReminders || !user.IsActive || user.IsDeleted {
+			continue
+		}
+		candidates = append(candidates, models.StreakReminderCandidate{
+			UserID:     userID,
+			Username:   user.Username,
+			Email:      user.Email,
+			Timezone:   prefs.Timezone,
+			RemindedOn: mockStreakRemindedOn[userID],
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].UserID < candidates[j].UserID })
+	return candidates, nil
+}
+
+func (s *MockStorage) MarkStreakReminderSent(userID int, date string) error {
+	mockStreakRemindedOn[userID] = date
+	return nil
+}
+
+// ****** УВЕДОМЛЕНИЯ ******
+
+func (s *MockStorage) CreateNotification(notification models.Notification) (models.Notification, error) {
+	notification.ID = len(mockNotifications) + 1
+	notification.IsRead = false
+	notification.ReadAt = nil
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now().UTC()
+	}
+	mockNotifications = append(mockNotifications, notification)
+	return notification, nil
+}
+
+func (s *MockStorage) CreateNotifications(notifications []models.Notification) ([]models.Notification, error) {
+	created := make([]models.Notification, 0, len(notifications))
+	for _, notification := range notifications {
+		notification, _ = s.CreateNotification(notification)
+		created = append(created, notification)
+	}
+	return created, nil
+}
+
+func (s *MockStorage) GetNotifications(userID int, unreadOnly bool, params models.ListParams) ([]models.Notification, int, error) {
+	var matched []models.Notification
+	for i := len(mockNotifications) - 1; i >= 0; i-- {
+		notification := mockNotifications[i]
+		if notification.UserID == userID && (!unreadOnly || !notification.IsRead) {
+			matched = append(matched, notification)
+		}
+	}
+	total := len(matched)
+
+	if params.IsCursor() {
+		for len(matched) > 0 && matched[0].ID >= params.Cursor {
+			matched = matched[1:]
+		}
+	}
+	if offset := params.Offset(); offset < len(matched) {
+		matched = matched[offset:]
+	} else {
+		matched = nil
+	}
+	if params.Limit > 0 && len(matched) > params.Limit {
+		matched = matched[:params.Limit]
+	}
+	return append([]models.Notification{}, matched...), total, nil
+}
+
+func (s *MockStorage) CountUnreadNotifications(userID int) (int, error) {
+	count := 0
+	for _, notification := range mockNotifications {
+		if notification.UserID == userID && !notification.IsRead {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MockStorage) MarkNotificationRead(userID, notificationID int, readAt time.Time) (models.Notification, error) {
+	for i, notification := range mockNotifications {
+		if notification.ID != notificationID || notification.UserID != userID {
+			continue
+		}
+		if !notification.IsRead {
+			notification.IsRead = true
+			notification.ReadAt = &readAt
+			mockNotifications[i] = notification
+		}
+		return notification, nil
+	}
+	return models.Notification{}, ErrNotificationNotFound
+}
+
+func (s *MockStorage) MarkAllNotificationsRead(userID int, readAt time.Time) (int, error) {
+	marked := 0
+	for i, notification := range mockNotifications {
+		if notification.UserID == userID && !notification.IsRead {
+			mockNotifications[i].IsRead = true
+			mockNotifications[i].ReadAt = &readAt
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func (s *MockStorage) GetNotificationPreferences(userID int) (models.NotificationPreferences, error) {
+	prefs := models.NotificationPreferences{Email: map[string]bool{}}
+	for notificationType, email := range mockNotificationPrefs[userID] {
+		prefs.Email[notificationType] = email
+	}
+	return prefs, nil
+}
+
+func (s *MockStorage) UpdateNotificationPreferences(userID int, prefs models.NotificationPreferences) error {
+	if mockNotificationPrefs[userID] == nil {
+		mockNotificationPrefs[userID] = map[string]bool{}
+	}
+	for notificationType, email := range prefs.Email {
+		mockNotificationPrefs[userID][notificationType] = email
+	}
+	return nil
+}
+
+// ****** ОБСУЖДЕНИЯ ******
+
+// mockThreadView дополняет тему именем автора, числом видимых ответов и принятым ответом
+func mockThreadView(thread models.DiscussionThread) models.DiscussionThread {
+	thread.AuthorName = mockUsers[thread.AuthorID].Username
+	thread.RepliesCount = 0
+	for _, reply := range mockReplies {
+		if reply.ThreadID == thread.ID && !reply.IsHidden {
+			thread.RepliesCount++
+		}
+	}
+	return thread
+}
+
+func mockReplyView(reply models.DiscussionReply) models.DiscussionReply {
+	reply.AuthorName = mockUsers[reply.AuthorID].Username
+	for _, thread := range mockThreads {
+		if thread.ID == reply.ThreadID {
+			reply.IsAccepted = thread.AcceptedReplyID != nil && *thread.AcceptedReplyID == reply.ID
+		}
+	}
+	return reply
+}
+
+func (s *MockStorage) CreateThread(thread models.DiscussionThread) (models.DiscussionThread, error) {
+	thread.ID = len(mockThreads) + 1
+	thread.CreatedAt = time.Now().UTC()
+	thread.LastActivityAt = thread.CreatedAt
+	thread.IsPinned, thread.IsLocked, thread.IsHidden = false, false, false
+	thread.AcceptedReplyID = nil
+	mockThreads = append(mockThreads, thread)
+	return mockThreadView(thread), nil
+}
+
+func (s *MockStorage) GetThreads(courseID, taskID int, includeHidden bool, params models.ListParams) ([]models.DiscussionThread, int, error) {
+	threads := []models.DiscussionThread{}
+	for _, thread := range mockThreads {
+		if thread.CourseID != courseID || (taskID > 0 && (thread.TaskID == nil || *thread.TaskID != taskID)) {
+			continue
+		}
+		if thread.IsHidden && !includeHidden {
+			continue
+		}
+		threads = append(threads, mockThreadView(thread))
+	}
+	sort.SliceStable(threads, func(i, j int) bool {
+		if threads[i].IsPinned != threads[j].IsPinned {
+			return threads[i].IsPinned
+		}
+		if !threads[i].LastActivityAt.Equal(threads[j].LastActivityAt) {
+			return threads[i].LastActivityAt.After(threads[j].LastActivityAt)
+		}
+		return threads[i].ID > threads[j].ID
+	})
+
+	total := len(threads)
+	if params.Limit > 0 {
+		start := params.Offset()
+		if start > total {
+			start = total
+		}
+		end := start + params.Limit
+		if end > total {
+			end = total
+		}
+		threads = threads[start:end]
+	}
+	return threads, total, nil
+}
+
+func (s *MockStorage) GetThread(threadID int) (models.DiscussionThread, error) {
+	for _, thread := range mockThreads {
+		if thread.ID == threadID {
+			return mockThreadView(thread), nil
+		}
+	}
+	return models.DiscussionThread{}, ErrThreadNotFound
+}
+
+func (s *MockStorage) ModerateThread(threadID int, moderation models.ThreadModeration) (models.DiscussionThread, error) {
+	for i, thread := range mockThreads {
+		if thread.ID != threadID {
+			continue
+		}
+		if moderation.IsPinned != nil {
+			mockThreads[i].IsPinned = *moderation.IsPinned
+		}
+		if moderation.IsLocked != nil {
+			mockThreads[i].IsLocked = *moderation.IsLocked
+		}
+		if moderation.IsHidden != nil {
+			mockThreads[i].IsHidden = *moderation.IsHidden
+		}
+		if moderation.HasSpoiler != nil {
+			mockThreads[i].HasSpoiler = *moderation.HasSpoiler
+		}
+		return mockThreadView(mockThreads[i]), nil
+	}
+	return models.DiscussionThread{}, ErrThreadNotFound
+}
+
+func (s *MockStorage) CreateReply(reply models.DiscussionReply) (models.DiscussionReply, error) {
+	for i, thread := range mockThreads {
+		if thread.ID != reply.ThreadID {
+			continue
+		}
+		reply.ID = len(mockReplies) + 1
+		reply.CreatedAt = time.Now().UTC()
+		reply.IsHidden = false
+		mockReplies = append(mockReplies, reply)
+		mockThreads[i].LastActivityAt = reply.CreatedAt
+		return mockReplyView(reply), nil
+	}
+	return reply, ErrThreadNotFound
+}
+
+func (s *MockStorage) GetReplies(threadID int, includeHidden bool) ([]models.DiscussionReply, error) {
+	replies := []models.DiscussionReply{}
+	for _, reply := range mockReplies {
+		if reply.ThreadID == threadID && (includeHidden || !reply.IsHidden) {
+			replies = append(replies, mockReplyView(reply))
+		}
+	}
+	return replies, nil
+}
+
+func (s *MockStorage) ModerateReply(threadID, replyID int, isHidden bool) (models.DiscussionReply, error) {
+	for i, reply := range mockReplies {
+		if reply.ID == replyID && reply.ThreadID == threadID {
+			mockReplies[i].IsHidden = isHidden
+			return mockReplyView(mockReplies[i]), nil
+		}
+	}
+	return models.DiscussionReply{}, ErrReplyNotFound
+}
+
+func (s *MockStorage) SetAcceptedReply(threadID, replyID int) (models.DiscussionThread, error) {
+	if _, err := s.GetThread(threadID); err != nil {
+		return models.DiscussionThread{}, err
+	}
+	var accepted *int
+	if replyID > 0 {
+		found := false
+		for _, reply := range mockReplies {
+			found = found || (reply.ID == replyID && reply.ThreadID == threadID)
+		}
+		if !found {
+			return models.DiscussionThread{}, ErrReplyNotFound
+		}
+		accepted = &replyID
+	}
+	for i, thread := range mockThreads {
+		if thread.ID == threadID {
+			mockThreads[i].AcceptedReplyID = accepted
+		}
+	}
+	return s.GetThread(threadID)
+}
+
+// ****** ПРОВЕРКА СХОДСТВА ОТВЕТОВ ******
+
+func mockSubmittedAnswer(attempt models.SubmissionAttempt) models.SubmittedAnswer {
+	return models.SubmittedAnswer{
+		SubmissionID: attempt.ID,
+		UserID:       attempt.UserID,
+		TaskID:       attempt.TaskID,
+		CourseID:     attempt.CourseID,
+		Answer:       mockSubmissionAnswers[attempt.ID],
+		IsCorrect:    attempt.IsCorrect,
+		SubmittedAt:  attempt.SubmittedAt,
+	}
+}
+
+func (s *MockStorage) GetUncheckedAnswers(limit int) ([]models.SubmittedAnswer, error) {
+	answers := []models.SubmittedAnswer{}
+	for _, attempt := range mockSubmissions {
+		if _, ok := mockSubmissionAnswers[attempt.ID]; !ok || mockCheckedAnswers[attempt.ID] {
+			continue
+		}
+		if len(answers) == limit {
+			break
+		}
+		answers = append(answers, mockSubmittedAnswer(attempt))
+	}
+	return answers, nil
+}
+
+func (s *MockStorage) GetTaskAnswers(taskID, beforeSubmissionID, limit int) ([]models.SubmittedAnswer, error) {
+	answers := []models.SubmittedAnswer{}
+	for i := len(mockSubmissions) - 1; i >= 0 && len(answers) < limit; i-- {
+		attempt := mockSubmissions[i]
+		if _, ok := mockSubmissionAnswers[attempt.ID]; !ok || attempt.TaskID != taskID || attempt.ID >= beforeSubmissionID {
+			continue
+		}
+		answers = append(answers, mockSubmittedAnswer(attempt))
+	}
+	return answers, nil
+}
+
+func (s *MockStorage) RecordSimilarityCheck(submissionID int, flags []models.SimilarityFlag) error {
+	for _, flag := range flags {
+		flag.ID = len(mockSimilarityFlags) + 1
+		flag.Status = models.SimilarityStatusPending
+		flag.CreatedAt = time.Now().UTC()
+		mockSimilarityFlags = append(mockSimilarityFlags, flag)
+	}
+	mockCheckedAnswers[submissionID] = true
+	return nil
+}
+
+func mockSimilarityFlagView(flag models.SimilarityFlag) models.SimilarityFlag {
+	for _, task := range mockTasks {
+		if task.ID == flag.TaskID {
+			flag.TaskTitle = task.Title
+		}
+	}
+	flag.Username = mockUsers[flag.UserID].Username
+	flag.OtherUsername = mockUsers[flag.OtherUserID].Username
+	flag.Answer = mockSubmissionAnswers[flag.SubmissionID]
+	flag.OtherAnswer = mockSubmissionAnswers[flag.OtherSubmissionID]
+	return flag
+}
+
+func (s *MockStorage) GetSimilarityFlags(courseID int, filter models.SimilarityFlagFilter, params models.ListParams) ([]models.SimilarityFlag, int, error) {
+	var assignmentTasks map[int]bool
+	if filter.AssignmentID > 0 {
+		assignmentTasks = map[int]bool{}
+		for _, assignment := range mockAssignments {
+			if assignment.ID == filter.AssignmentID {
+				for _, taskID := range assignment.TaskIDs {
+					assignmentTasks[taskID] = true
+				}
+			}
+		}
+	}
+
+	flags := []models.SimilarityFlag{}
+	for _, flag := range mockSimilarityFlags {
+		if flag.CourseID != courseID || (assignmentTasks != nil && !assignmentTasks[flag.TaskID]) ||
+			(filter.Status != "" && flag.Status != filter.Status) || (filter.Kind != "" && flag.Kind != filter.Kind) {
+			continue
+		}
+		flags = append(flags, mockSimilarityFlagView(flag))
+	}
+	sort.SliceStable(flags, func(i, j int) bool {
+		if flags[i].Score != flags[j].Score {
+			return flags[i].Score > flags[j].Score
+		}
+		return flags[i].ID > flags[j].ID
+	})
+	total := len(flags)
+	if params.Limit > 0 {
+		start := params.Offset()
+		if start > total {
+			start = total
+		}
+		end := start + params.Limit
+		if end > total {
+			end = total
+		}
+		flags = flags[start:end]
+	}
+	return flags, total, nil
+}
+
+func (s *MockStorage) ReviewSimilarityFlag(flagID, reviewerID int, review models.SimilarityReview, reviewedAt time.Time) (models.SimilarityFlag, error) {
+	for i, flag := range mockSimilarityFlags {
+		if flag.ID != flagID {
+			continue
+		}
+		reviewedAt = reviewedAt.UTC()
+		flag.Status, flag.Note = review.Status, review.Note
+		flag.ReviewedBy, flag.ReviewedAt = &reviewerID, &reviewedAt
+		mockSimilarityFlags[i] = flag
+		return mockSimilarityFlagView(flag), nil
+	}
+	return models.SimilarityFlag{}, ErrSimilarityFlagNotFound
+}
+
+// ****** ЖУРНАЛ СОБЫТИЙ ******
+
+func (s *MockStorage) AppendLiveEvent(event models.LiveEvent) (models.LiveEvent, error) {
+	mockLiveEventsMu.Lock()
+	defer mockLiveEventsMu.Unlock()
+
+	mockLiveEventSequence++
+	event.ID = mockLiveEventSequence
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	mockLiveEvents = append(mockLiveEvents, event)
+	return event, nil
+}
+
+func (s *MockStorage) GetLiveEventsAfter(afterID, limit int) ([]models.LiveEvent, error) {
+	mockLiveEventsMu.Lock()
+	defer mockLiveEventsMu.Unlock()
+
+	events := []models.LiveEvent{}
+	for _, event := range mockLiveEvents {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *MockStorage) GetLastLiveEventID() (int, error) {
+	mockLiveEventsMu.Lock()
+	defer mockLiveEventsMu.Unlock()
+
+	return mockLiveEventSequence, nil
+}
+
+func (s *MockStorage) DeleteLiveEventsBefore(before time.Time) (int, error) {
+	mockLiveEventsMu.Lock()
+	defer mockLiveEventsMu.Unlock()
+
+	kept := mockLiveEvents[:0]
+	for _, event := range mockLiveEvents {
+		if !event.CreatedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := len(mockLiveEvents) - len(kept)
+	mockLiveEvents = kept
+	return deleted, nil
+}
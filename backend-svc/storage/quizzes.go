@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"lmsmodule/backend-svc/models"
+	"math/rand"
+	"time"
+)
+
+// checkQuizStart проверяет, что задача - тест, задание открыто и попытки не исчерпаны
+func checkQuizStart(task models.Task, deadlines []models.TaskDeadline, attempts int, at time.Time) error {
+	if task.Type != models.TaskTypeQuiz || task.Quiz == nil {
+		return ErrNotQuizTask
+	}
+	if err := applyDeadline(&models.TaskSubmissionResponse{}, deadlines, at); err != nil {
+		return err
+	}
+	if task.Quiz.MaxAttempts > 0 && attempts >= task.Quiz.MaxAttempts {
+		return ErrQuizAttemptsExhausted
+	}
+	return nil
+}
+
+// optionInsertOrder возвращает индексы вариантов вопроса в порядке сохранения. Элементы вопроса
+// на упорядочивание сохраняются вперемешку: их ID, растущие в верном порядке, выдавали бы ответ.
+// Верный порядок хранится в Position.
+func optionInsertOrder(question models.QuizQuestion) []int {
+	if question.Type == models.QuestionOrdering {
+		return rand.Perm(len(question.Options))
+	}
+	order := make([]int, len(question.Options))
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// newQuizAttempt собирает новую попытку из случайных вопросов банков теста
+func newQuizAttempt(userID int, task models.Task, banks map[int][]models.QuizQuestion, startedAt time.Time) (models.QuizAttempt, error) {
+	attempt := models.QuizAttempt{
+		UserID:    userID,
+		TaskID:    task.ID,
+		CourseID:  task.CourseID,
+		Status:    models.QuizAttemptInProgress,
+		StartedAt: startedAt,
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	questions := task.Quiz.SelectQuestions(banks, rnd)
+	if len(questions) == 0 {
+		return attempt, ErrQuizHasNoQuestions
+	}
+	for _, question := range questions {
+		attempt.Questions = append(attempt.Questions, models.QuizAttemptQuestion{QuizQuestion: question})
+	}
+
+	if task.Quiz.TimeLimitMinutes > 0 {
+		expiresAt := startedAt.Add(time.Duration(task.Quiz.TimeLimitMinutes) * time.Minute)
+		attempt.ExpiresAt = &expiresAt
+	}
+	return attempt, nil
+}
+
+// gradeQuizAttempt оценивает ответы попытки. Балл задачи начисляется пропорционально
+// проценту верных ответов, штраф за просрочку определяется сроком задания на момент
+// начала попытки. Зачтенный тест отмечает задачу выполненной.
+func gradeQuizAttempt(attempt *models.QuizAttempt, task models.Task, answers []models.QuizAnswer, deadlines []models.TaskDeadline, at time.Time) models.TaskSubmissionResponse {
+	attempt.Grade(answers, task.Quiz.PassingPercent(), at)
+
+	response := models.TaskSubmissionResponse{
+		TaskID:      task.ID,
+		Status:      "completed",
+		SubmittedAt: at,
+		IsCorrect:   attempt.IsPassed,
+	}
+	// Начать попытку можно было только в открытом задании, поэтому ошибку срока не учитываем
+	_ = applyDeadline(&response, deadlines, attempt.StartedAt)
+	response.Score = penalizedScore(float64(task.Points)*attempt.Score/100, response.Penalty)
+
+	if attempt.IsPassed {
+		response.Message = "Quiz passed! Task marked as completed"
+	} else {
+		response.Message = "Quiz is not passed, please try again"
+	}
+
+	attempt.Points = response.Score
+	attempt.IsLate = response.IsLate
+	attempt.Penalty = response.Penalty
+	return response
+}
+
+// quizBankIDs возвращает банки вопросов, из которых собирается тест
+func quizBankIDs(settings *models.QuizSettings) []int {
+	var ids []int
+	if settings != nil {
+		for _, source := range settings.Sources {
+			ids = append(ids, source.BankID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"html"
+	"lmsmodule/backend-svc/models"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxSearchTerms      = 10
+	minSearchTermLength = 2
+	// fullTextMinTermLength - innodb_ft_min_token_size по умолчанию: более короткие слова
+	// не попадают в FULLTEXT-индекс MySQL
+	fullTextMinTermLength = 3
+	// searchCandidatesLimit ограничивает число кандидатов каждого типа, выбираемых из БД до ранжирования
+	searchCandidatesLimit = 200
+	snippetContextBefore  = 60
+	snippetLength         = 200
+)
+
+// searchField - текстовое поле документа с весом для ранжирования.
+// Заголовки весят больше описаний, описания - больше содержимого задач.
+type searchField struct {
+	name   string
+	text   string
+	weight float64
+}
+
+// searchTerms разбивает строку запроса на уникальные термы в нижнем регистре.
+// Символ "_" считается частью слова, чтобы искать идентификаторы вроде user_id.
+func searchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range words {
+		if len([]rune(word)) < minSearchTermLength || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// fullTextIndexable сообщает, найдет ли FULLTEXT-индекс все термы. Запросы с короткими
+// термами выполняются через LIKE, чтобы MySQL и другие хранилища находили одно и то же.
+func fullTextIndexable(terms []string) bool {
+	for _, term := range terms {
+		if len([]rune(term)) < fullTextMinTermLength {
+			return false
+		}
+	}
+	return true
+}
+
+// scoreDocument считает релевантность документа: сумма весов полей, умноженных на число
+// вхождений термов (не более трех на терм), с бонусом за наличие всех термов запроса.
+// Возвращает также поле с наибольшим вкладом - по нему строится фрагмент.
+func scoreDocument(terms []string, fields ...searchField) (float64, searchField) {
+	var score, bestScore float64
+	var best searchField
+	found := make(map[string]bool)
+
+	for _, field := range fields {
+		text := strings.ToLower(field.text)
+		var fieldScore float64
+		for _, term := range terms {
+			count := strings.Count(text, term)
+			if count == 0 {
+				continue
+			}
+			if count > 3 {
+				count = 3
+			}
+			found[term] = true
+			fieldScore += field.weight * float64(count)
+		}
+		if fieldScore > bestScore {
+			bestScore = fieldScore
+			best = field
+		}
+		score += fieldScore
+	}
+
+	if score > 0 && len(found) == len(terms) {
+		score *= 1.5
+	}
+	return score, best
+}
+
+// highlightSnippet вырезает фрагмент текста вокруг первого совпадения, экранирует HTML
+// и оборачивает найденные термы в <mark>. Пробельные символы схлопываются.
+func highlightSnippet(text string, terms []string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	termRunes := make([][]rune, 0, len(terms))
+	for _, term := range terms {
+		termRunes = append(termRunes, []rune(term))
+	}
+
+	// matchEnd[i] - конец совпадения, начинающегося в позиции i, или 0
+	matchEnd := make([]int, len(runes))
+	first := -1
+	for i := range lower {
+		for _, term := range termRunes {
+			if hasRunePrefix(lower[i:], term) && i+len(term) > matchEnd[i] {
+				matchEnd[i] = i + len(term)
+				if first < 0 {
+					first = i
+				}
+			}
+		}
+	}
+
+	start := 0
+	if first > snippetContextBefore {
+		start = first - snippetContextBefore
+	}
+	end := start + snippetLength
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		if matchEnd[i] > 0 {
+			stop := matchEnd[i]
+			if stop > end {
+				stop = end
+			}
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(string(runes[i:stop])))
+			b.WriteString("</mark>")
+			i = stop
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+		i++
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func hasRunePrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// newSearchResult ранжирует документ и собирает результат; ok=false, если совпадений нет
+func newSearchResult(resultType string, id, courseID int, title string, terms []string, fields ...searchField) (models.SearchResult, bool) {
+	score, best := scoreDocument(terms, fields...)
+	if score == 0 {
+		return models.SearchResult{}, false
+	}
+
+	return models.SearchResult{
+		Type:         resultType,
+		ID:           id,
+		CourseID:     courseID,
+		Title:        title,
+		Snippet:      highlightSnippet(best.text, terms),
+		MatchedField: best.name,
+		Score:        score,
+	}, true
+}
+
+// courseSearchFields, taskSearchFields и userSearchFields задают поля и веса для каждого типа.
+// Решение задачи добавляется только при явном разрешении.
+func courseSearchFields(course models.Course) []searchField {
+	return []searchField{
+		{name: "title", text: course.VulnerabilityType, weight: 3},
+		{name: "description", text: course.Description, weight: 2},
+	}
+}
+
+func taskSearchFields(task models.Task, includeSolution bool) []searchField {
+	fields := []searchField{
+		{name: "title", text: task.Title, weight: 3},
+		{name: "description", text: task.Description, weight: 2},
+		{name: "content", text: task.Content, weight: 1},
+	}
+	if includeSolution {
+		fields = append(fields, searchField{name: "solution", text: task.Solution, weight: 1})
+	}
+	return fields
+}
+
+func userSearchFields(user models.User) []searchField {
+	return []searchField{
+		{name: "username", text: user.Username, weight: 3},
+		{name: "full_name", text: user.FullName, weight: 2},
+		{name: "email", text: user.Email, weight: 2},
+	}
+}
+
+var searchTypeOrder = map[string]int{
+	models.SearchTypeCourse: 0,
+	models.SearchTypeTask:   1,
+	models.SearchTypeUser:   2,
+}
+
+// rankSearchResults упорядочивает результаты по убыванию релевантности, затем по типу и ID
+func rankSearchResults(results []models.SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Type != results[j].Type {
+			return searchTypeOrder[results[i].Type] < searchTypeOrder[results[j].Type]
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+// likeConditions строит переносимое условие поиска подстроки для БД без FULLTEXT:
+// каждый терм ищется в каждой колонке, условия объединяются через OR.
+func likeConditions(terms []string, columns ...string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, term := range terms {
+		pattern := "%" + escapeLike(term) + "%"
+		for _, column := range columns {
+			conditions = append(conditions, "LOWER("+column+") LIKE ? ESCAPE '!'")
+			args = append(args, pattern)
+		}
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+func escapeLike(term string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(term)
+}
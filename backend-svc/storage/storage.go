@@ -3,14 +3,16 @@ package storage
 import (
 	"database/sql"
 	"lmsmodule/backend-svc/models"
+	"time"
 )
 
 // Storage определяет интерфейс для работы с данными
 type Storage interface {
-	GetCourses() ([]models.Course, error)
+	GetCourses(params models.ListParams) ([]models.Course, int, error)
 	GetCourseByID(id int) (models.Course, error)
 	GetUserProgress(userID int) (models.UserProgress, error)
 	CompleteTask(userID, taskID int) error
+	CompleteTaskWithoutAnswer(userID, taskID int) error
 	GetTaskByID(courseID, taskID int) (models.Task, error)
 
 	CreateUser(user models.User) error
@@ -25,9 +27,9 @@ type Storage interface {
 
 	IsTeacher(userID int) (bool, error)
 	IsAdmin(userID int) (bool, error)
-	GetAllUsers() ([]models.User, error)
-	GetUsersByRole(isAdmin bool) ([]models.User, error)
-	SearchUsers(query string) ([]models.User, error)
+	GetAllUsers(params models.ListParams) ([]models.User, int, error)
+	GetUsersByRole(isAdmin bool, params models.ListParams) ([]models.User, int, error)
+	SearchUsers(query string, params models.ListParams) ([]models.User, int, error)
 	UpdateUserStatus(userID int, isActive bool) error
 	PromoteToAdmin(userID int) error
 	DemoteFromAdmin(userID int) error
@@ -44,11 +46,102 @@ type Storage interface {
 	DeleteTask(courseID, taskID int) error
 
 	SubmitTaskAnswer(submission models.TaskSubmission) (models.TaskSubmissionResponse, error)
-	GetUserSubmissions(userID int) ([]models.TaskSubmissionDetails, error)
-	GetCourseStatistics(courseID int) (models.CourseStatistics, error)
+	GetUserSubmissions(userID int, params models.ListParams) ([]models.TaskSubmissionDetails, int, error)
+	GetCourseStatistics(courseID int, params models.ListParams) (models.CourseStatistics, error)
 	GetUserStatistics(userID int) (models.UserStatistics, error)
 	GetLeaderboard(courseID int, limit int) ([]models.LeaderboardEntry, error)
-	GetUserLearningPath(userID int) (models.LearningPath, error)
+	GetLearningSnapshot(userID int) (models.LearningSnapshot, error)
+	SetTaskSkills(courseID, taskID int, request models.TaskSkillsRequest) error
+
+	CreateAssignment(assignment models.Assignment) (models.Assignment, error)
+	UpdateAssignment(assignment models.Assignment) (models.Assignment, error)
+	DeleteAssignment(courseID, assignmentID int) error
+	GetAssignment(courseID, assignmentID int) (models.Assignment, error)
+	GetCourseAssignments(courseID int) ([]models.Assignment, error)
+	GrantExtension(extension models.AssignmentExtension) error
+	GetUserDeadlines(userID int) ([]models.TaskDeadline, error)
+	GetPendingReminders(now time.Time, window time.Duration) ([]models.DeadlineReminder, error)
+	MarkReminderSent(reminder models.DeadlineReminder, sentAt time.Time) error
+	GetLearningEffectiveness(params models.EffectivenessParams) (models.LearningEffectiveness, error)
+
+	CreateCohort(cohort models.Cohort) (models.Cohort, error)
+	UpdateCohort(cohort models.Cohort) (models.Cohort, error)
+	DeleteCohort(cohortID int) error
+	GetCohort(cohortID int) (models.Cohort, error)
+	GetCohortByInviteCode(code string) (models.Cohort, error)
+	GetCohorts(teacherID int, params models.ListParams) ([]models.Cohort, int, error)
+	SetCohortInviteCode(cohortID int, code string) error
+	IsCohortTeacher(cohortID, userID int) (bool, error)
+	AddCohortMembers(cohortID int, userIDs []int) (models.CohortImportResult, error)
+	RemoveCohortMember(cohortID, userID int) error
+	GetCohortMembers(cohortID int, params models.ListParams) ([]models.CohortMember, int, error)
+	ResolveUserIDs(logins []string) (map[string]int, error)
+	GetCohortLeaderboard(cohortID, courseID, limit int) ([]models.LeaderboardEntry, error)
+	GetCohortSubmissions(cohortID int, params models.ListParams) ([]models.CohortSubmission, int, error)
+
+	CreateQuestionBank(bank models.QuestionBank) (models.QuestionBank, error)
+	UpdateQuestionBank(bank models.QuestionBank) (models.QuestionBank, error)
+	DeleteQuestionBank(courseID, bankID int) error
+	GetQuestionBank(courseID, bankID int) (models.QuestionBank, error)
+	GetQuestionBanks(courseID int) ([]models.QuestionBank, error)
+	StartQuizAttempt(userID, taskID int, startedAt time.Time) (models.QuizAttempt, error)
+	GetQuizAttempt(attemptID int) (models.QuizAttempt, error)
+	GetQuizAttempts(userID, taskID int) ([]models.QuizAttempt, error)
+	SubmitQuizAttempt(attemptID int, answers []models.QuizAnswer, submittedAt time.Time) (models.QuizAttempt, error)
+
+	GetCourseCompletions(userID int) ([]models.CourseCompletion, error)
+	CreateCertificate(cert models.Certificate) (models.Certificate, error)
+	GetCertificate(id string) (models.Certificate, error)
+	GetUserCertificates(userID int) ([]models.Certificate, error)
+	RevokeCertificate(id, reason string, revokedAt time.Time) (models.Certificate, error)
+
+	GetBadges() ([]models.Badge, error)
+	GetBadge(badgeID int) (models.Badge, error)
+	CreateBadge(badge models.Badge) (models.Badge, error)
+	UpdateBadge(badge models.Badge) (models.Badge, error)
+	DeleteBadge(badgeID int) error
+	AwardBadges(userID int, badges []models.UserBadge) ([]models.UserBadge, error)
+	GetUserBadges(userID int) ([]models.UserBadge, error)
+
+	GetUserPreferences(userID int) (models.UserPreferences, error)
+	UpdateUserPreferences(userID int, prefs models.UserPreferences) error
+	GetActivityTimes(userID int, from, to time.Time) (models.ActivityTimes, error)
+	GetStreakReminderCandidates() ([]models.StreakReminderCandidate, error)
+	MarkStreakReminderSent(userID int, date string) error
+
+	CreateNotification(notification models.Notification) (models.Notification, error)
+	CreateNotifications(notifications []models.Notification) ([]models.Notification, error)
+	GetNotifications(userID int, unreadOnly bool, params models.ListParams) ([]models.Notification, int, error)
+	CountUnreadNotifications(userID int) (int, error)
+	MarkNotificationRead(userID, notificationID int, readAt time.Time) (models.Notification, error)
+	MarkAllNotificationsRead(userID int, readAt time.Time) (int, error)
+	GetNotificationPreferences(userID int) (models.NotificationPreferences, error)
+	UpdateNotificationPreferences(userID int, prefs models.NotificationPreferences) error
+
+	CreateThread(thread models.DiscussionThread) (models.DiscussionThread, error)
+	GetThreads(courseID, taskID int, includeHidden bool, params models.ListParams) ([]models.DiscussionThread, int, error)
+	GetThread(threadID int) (models.DiscussionThread, error)
+	ModerateThread(threadID int, moderation models.ThreadModeration) (models.DiscussionThread, error)
+	CreateReply(reply models.DiscussionReply) (models.DiscussionReply, error)
+	GetReplies(threadID int, includeHidden bool) ([]models.DiscussionReply, error)
+	ModerateReply(threadID, replyID int, isHidden bool) (models.DiscussionReply, error)
+	SetAcceptedReply(threadID, replyID int) (models.DiscussionThread, error)
+
+	GetUncheckedAnswers(limit int) ([]models.SubmittedAnswer, error)
+	GetTaskAnswers(taskID, beforeSubmissionID, limit int) ([]models.SubmittedAnswer, error)
+	RecordSimilarityCheck(submissionID int, flags []models.SimilarityFlag) error
+	GetSimilarityFlags(courseID int, filter models.SimilarityFlagFilter, params models.ListParams) ([]models.SimilarityFlag, int, error)
+	ReviewSimilarityFlag(flagID, reviewerID int, review models.SimilarityReview, reviewedAt time.Time) (models.SimilarityFlag, error)
+
+	AppendLiveEvent(event models.LiveEvent) (models.LiveEvent, error)
+	GetLiveEventsAfter(afterID, limit int) ([]models.LiveEvent, error)
+	GetLastLiveEventID() (int, error)
+	DeleteLiveEventsBefore(before time.Time) (int, error)
+
+	RecordLearningActivities(userID int, activities []models.LearningActivity) error
+	GetUserActivitySummary(userID int) ([]models.TaskActivitySummary, error)
+
+	Search(query models.SearchQuery, params models.ListParams) ([]models.SearchResult, int, error)
 }
 
 // DBStorage имплементирует Storage используя реальную базу данных
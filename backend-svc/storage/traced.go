@@ -0,0 +1,742 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"lmsmodule/api-gateway/pkg/tracing"
+	"lmsmodule/backend-svc/models"
+	"time"
+)
+
+// WithTracing возвращает хранилище, каждый вызов которого записывается дочерним спаном
+// текущего спана ctx. Без спана в контексте возвращает s без изменений.
+func WithTracing(s Storage, ctx context.Context) Storage {
+	if tracing.SpanFromContext(ctx) == nil {
+		return s
+	}
+	return tracedStorage{Storage: s, ctx: ctx}
+}
+
+type tracedStorage struct {
+	Storage
+	ctx context.Context
+}
+
+func (s tracedStorage) start(method string) *tracing.Span {
+	_, span := tracing.StartSpan(s.ctx, "storage."+method, tracing.SpanKindInternal)
+	span.SetAttribute("db.operation", method)
+	return span
+}
+
+// endSpan завершает спан вызова; отсутствие строки - обычный ответ, а не сбой хранилища
+func endSpan(span *tracing.Span, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		span.SetAttribute("db.no_rows", true)
+	} else {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+func (s tracedStorage) GetCourses(params models.ListParams) (r0 []models.Course, r1 int, err error) {
+	span := s.start("GetCourses")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCourses(params)
+}
+
+func (s tracedStorage) GetCourseByID(id int) (r0 models.Course, err error) {
+	span := s.start("GetCourseByID")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCourseByID(id)
+}
+
+func (s tracedStorage) GetUserProgress(userID int) (r0 models.UserProgress, err error) {
+	span := s.start("GetUserProgress")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserProgress(userID)
+}
+
+func (s tracedStorage) CompleteTask(userID int, taskID int) (err error) {
+	span := s.start("CompleteTask")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CompleteTask(userID, taskID)
+}
+
+func (s tracedStorage) CompleteTaskWithoutAnswer(userID int, taskID int) (err error) {
+	span := s.start("CompleteTaskWithoutAnswer")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CompleteTaskWithoutAnswer(userID, taskID)
+}
+
+func (s tracedStorage) GetTaskByID(courseID int, taskID int) (r0 models.Task, err error) {
+	span := s.start("GetTaskByID")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetTaskByID(courseID, taskID)
+}
+
+func (s tracedStorage) CreateUser(user models.User) (err error) {
+	span := s.start("CreateUser")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateUser(user)
+}
+
+func (s tracedStorage) GetUserByUsername(username string) (r0 models.User, err error) {
+	span := s.start("GetUserByUsername")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserByUsername(username)
+}
+
+func (s tracedStorage) GetUserByID(id int) (r0 models.User, err error) {
+	span := s.start("GetUserByID")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserByID(id)
+}
+
+func (s tracedStorage) UpdatePassword(userID int, data models.UpdateProfileRequest) (err error) {
+	span := s.start("UpdatePassword")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdatePassword(userID, data)
+}
+
+func (s tracedStorage) UpdateUserLastLogin(userID int) (err error) {
+	span := s.start("UpdateUserLastLogin")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateUserLastLogin(userID)
+}
+
+func (s tracedStorage) UpdateUserProfile(userID int, data models.UpdateProfileRequest) (err error) {
+	span := s.start("UpdateUserProfile")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateUserProfile(userID, data)
+}
+
+func (s tracedStorage) Enable2FA(userID int) (err error) {
+	span := s.start("Enable2FA")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.Enable2FA(userID)
+}
+
+func (s tracedStorage) UpdateUserProfileImage(userID int, imageURL string) (err error) {
+	span := s.start("UpdateUserProfileImage")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateUserProfileImage(userID, imageURL)
+}
+
+func (s tracedStorage) DeleteUser(userID int) (err error) {
+	span := s.start("DeleteUser")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DeleteUser(userID)
+}
+
+func (s tracedStorage) IsTeacher(userID int) (r0 bool, err error) {
+	span := s.start("IsTeacher")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.IsTeacher(userID)
+}
+
+func (s tracedStorage) IsAdmin(userID int) (r0 bool, err error) {
+	span := s.start("IsAdmin")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.IsAdmin(userID)
+}
+
+func (s tracedStorage) GetAllUsers(params models.ListParams) (r0 []models.User, r1 int, err error) {
+	span := s.start("GetAllUsers")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetAllUsers(params)
+}
+
+func (s tracedStorage) GetUsersByRole(isAdmin bool, params models.ListParams) (r0 []models.User, r1 int, err error) {
+	span := s.start("GetUsersByRole")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUsersByRole(isAdmin, params)
+}
+
+func (s tracedStorage) SearchUsers(query string, params models.ListParams) (r0 []models.User, r1 int, err error) {
+	span := s.start("SearchUsers")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.SearchUsers(query, params)
+}
+
+func (s tracedStorage) UpdateUserStatus(userID int, isActive bool) (err error) {
+	span := s.start("UpdateUserStatus")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateUserStatus(userID, isActive)
+}
+
+func (s tracedStorage) PromoteToAdmin(userID int) (err error) {
+	span := s.start("PromoteToAdmin")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.PromoteToAdmin(userID)
+}
+
+func (s tracedStorage) DemoteFromAdmin(userID int) (err error) {
+	span := s.start("DemoteFromAdmin")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DemoteFromAdmin(userID)
+}
+
+func (s tracedStorage) SaveOTPCode(userID int, code string) (err error) {
+	span := s.start("SaveOTPCode")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.SaveOTPCode(userID, code)
+}
+
+func (s tracedStorage) VerifyOTPCode(userID int, code string) (r0 bool, err error) {
+	span := s.start("VerifyOTPCode")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.VerifyOTPCode(userID, code)
+}
+
+func (s tracedStorage) ClearOTPCode(userID int) (err error) {
+	span := s.start("ClearOTPCode")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.ClearOTPCode(userID)
+}
+
+func (s tracedStorage) CreateCourse(course models.Course) (r0 models.Course, err error) {
+	span := s.start("CreateCourse")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateCourse(course)
+}
+
+func (s tracedStorage) UpdateCourse(id int, course models.Course) (r0 models.Course, err error) {
+	span := s.start("UpdateCourse")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateCourse(id, course)
+}
+
+func (s tracedStorage) DeleteCourse(id int) (err error) {
+	span := s.start("DeleteCourse")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DeleteCourse(id)
+}
+
+func (s tracedStorage) CreateTask(courseID int, task models.Task) (r0 models.Task, err error) {
+	span := s.start("CreateTask")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateTask(courseID, task)
+}
+
+func (s tracedStorage) UpdateTask(courseID int, taskID int, task models.Task) (r0 models.Task, err error) {
+	span := s.start("UpdateTask")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateTask(courseID, taskID, task)
+}
+
+func (s tracedStorage) DeleteTask(courseID int, taskID int) (err error) {
+	span := s.start("DeleteTask")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DeleteTask(courseID, taskID)
+}
+
+func (s tracedStorage) SubmitTaskAnswer(submission models.TaskSubmission) (r0 models.TaskSubmissionResponse, err error) {
+	span := s.start("SubmitTaskAnswer")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.SubmitTaskAnswer(submission)
+}
+
+func (s tracedStorage) GetUserSubmissions(userID int, params models.ListParams) (r0 []models.TaskSubmissionDetails, r1 int, err error) {
+	span := s.start("GetUserSubmissions")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserSubmissions(userID, params)
+}
+
+func (s tracedStorage) GetCourseStatistics(courseID int, params models.ListParams) (r0 models.CourseStatistics, err error) {
+	span := s.start("GetCourseStatistics")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCourseStatistics(courseID, params)
+}
+
+func (s tracedStorage) GetUserStatistics(userID int) (r0 models.UserStatistics, err error) {
+	span := s.start("GetUserStatistics")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserStatistics(userID)
+}
+
+func (s tracedStorage) GetLeaderboard(courseID int, limit int) (r0 []models.LeaderboardEntry, err error) {
+	span := s.start("GetLeaderboard")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetLeaderboard(courseID, limit)
+}
+
+func (s tracedStorage) GetLearningSnapshot(userID int) (r0 models.LearningSnapshot, err error) {
+	span := s.start("GetLearningSnapshot")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetLearningSnapshot(userID)
+}
+
+func (s tracedStorage) SetTaskSkills(courseID int, taskID int, request models.TaskSkillsRequest) (err error) {
+	span := s.start("SetTaskSkills")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.SetTaskSkills(courseID, taskID, request)
+}
+
+func (s tracedStorage) CreateAssignment(assignment models.Assignment) (r0 models.Assignment, err error) {
+	span := s.start("CreateAssignment")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateAssignment(assignment)
+}
+
+func (s tracedStorage) UpdateAssignment(assignment models.Assignment) (r0 models.Assignment, err error) {
+	span := s.start("UpdateAssignment")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateAssignment(assignment)
+}
+
+func (s tracedStorage) DeleteAssignment(courseID int, assignmentID int) (err error) {
+	span := s.start("DeleteAssignment")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DeleteAssignment(courseID, assignmentID)
+}
+
+func (s tracedStorage) GetAssignment(courseID int, assignmentID int) (r0 models.Assignment, err error) {
+	span := s.start("GetAssignment")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetAssignment(courseID, assignmentID)
+}
+
+func (s tracedStorage) GetCourseAssignments(courseID int) (r0 []models.Assignment, err error) {
+	span := s.start("GetCourseAssignments")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCourseAssignments(courseID)
+}
+
+func (s tracedStorage) GrantExtension(extension models.AssignmentExtension) (err error) {
+	span := s.start("GrantExtension")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GrantExtension(extension)
+}
+
+func (s tracedStorage) GetUserDeadlines(userID int) (r0 []models.TaskDeadline, err error) {
+	span := s.start("GetUserDeadlines")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserDeadlines(userID)
+}
+
+func (s tracedStorage) GetPendingReminders(now time.Time, window time.Duration) (r0 []models.DeadlineReminder, err error) {
+	span := s.start("GetPendingReminders")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetPendingReminders(now, window)
+}
+
+func (s tracedStorage) MarkReminderSent(reminder models.DeadlineReminder, sentAt time.Time) (err error) {
+	span := s.start("MarkReminderSent")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.MarkReminderSent(reminder, sentAt)
+}
+
+func (s tracedStorage) GetLearningEffectiveness(params models.EffectivenessParams) (r0 models.LearningEffectiveness, err error) {
+	span := s.start("GetLearningEffectiveness")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetLearningEffectiveness(params)
+}
+
+func (s tracedStorage) CreateCohort(cohort models.Cohort) (r0 models.Cohort, err error) {
+	span := s.start("CreateCohort")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateCohort(cohort)
+}
+
+func (s tracedStorage) UpdateCohort(cohort models.Cohort) (r0 models.Cohort, err error) {
+	span := s.start("UpdateCohort")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateCohort(cohort)
+}
+
+func (s tracedStorage) DeleteCohort(cohortID int) (err error) {
+	span := s.start("DeleteCohort")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DeleteCohort(cohortID)
+}
+
+func (s tracedStorage) GetCohort(cohortID int) (r0 models.Cohort, err error) {
+	span := s.start("GetCohort")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCohort(cohortID)
+}
+
+func (s tracedStorage) GetCohortByInviteCode(code string) (r0 models.Cohort, err error) {
+	span := s.start("GetCohortByInviteCode")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCohortByInviteCode(code)
+}
+
+func (s tracedStorage) GetCohorts(teacherID int, params models.ListParams) (r0 []models.Cohort, r1 int, err error) {
+	span := s.start("GetCohorts")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCohorts(teacherID, params)
+}
+
+func (s tracedStorage) SetCohortInviteCode(cohortID int, code string) (err error) {
+	span := s.start("SetCohortInviteCode")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.SetCohortInviteCode(cohortID, code)
+}
+
+func (s tracedStorage) IsCohortTeacher(cohortID int, userID int) (r0 bool, err error) {
+	span := s.start("IsCohortTeacher")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.IsCohortTeacher(cohortID, userID)
+}
+
+func (s tracedStorage) AddCohortMembers(cohortID int, userIDs []int) (r0 models.CohortImportResult, err error) {
+	span := s.start("AddCohortMembers")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.AddCohortMembers(cohortID, userIDs)
+}
+
+func (s tracedStorage) RemoveCohortMember(cohortID int, userID int) (err error) {
+	span := s.start("RemoveCohortMember")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.RemoveCohortMember(cohortID, userID)
+}
+
+func (s tracedStorage) GetCohortMembers(cohortID int, params models.ListParams) (r0 []models.CohortMember, r1 int, err error) {
+	span := s.start("GetCohortMembers")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCohortMembers(cohortID, params)
+}
+
+func (s tracedStorage) ResolveUserIDs(logins []string) (r0 map[string]int, err error) {
+	span := s.start("ResolveUserIDs")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.ResolveUserIDs(logins)
+}
+
+func (s tracedStorage) GetCohortLeaderboard(cohortID int, courseID int, limit int) (r0 []models.LeaderboardEntry, err error) {
+	span := s.start("GetCohortLeaderboard")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCohortLeaderboard(cohortID, courseID, limit)
+}
+
+func (s tracedStorage) GetCohortSubmissions(cohortID int, params models.ListParams) (r0 []models.CohortSubmission, r1 int, err error) {
+	span := s.start("GetCohortSubmissions")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCohortSubmissions(cohortID, params)
+}
+
+func (s tracedStorage) CreateQuestionBank(bank models.QuestionBank) (r0 models.QuestionBank, err error) {
+	span := s.start("CreateQuestionBank")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateQuestionBank(bank)
+}
+
+func (s tracedStorage) UpdateQuestionBank(bank models.QuestionBank) (r0 models.QuestionBank, err error) {
+	span := s.start("UpdateQuestionBank")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateQuestionBank(bank)
+}
+
+func (s tracedStorage) DeleteQuestionBank(courseID int, bankID int) (err error) {
+	span := s.start("DeleteQuestionBank")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DeleteQuestionBank(courseID, bankID)
+}
+
+func (s tracedStorage) GetQuestionBank(courseID int, bankID int) (r0 models.QuestionBank, err error) {
+	span := s.start("GetQuestionBank")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetQuestionBank(courseID, bankID)
+}
+
+func (s tracedStorage) GetQuestionBanks(courseID int) (r0 []models.QuestionBank, err error) {
+	span := s.start("GetQuestionBanks")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetQuestionBanks(courseID)
+}
+
+func (s tracedStorage) StartQuizAttempt(userID int, taskID int, startedAt time.Time) (r0 models.QuizAttempt, err error) {
+	span := s.start("StartQuizAttempt")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.StartQuizAttempt(userID, taskID, startedAt)
+}
+
+func (s tracedStorage) GetQuizAttempt(attemptID int) (r0 models.QuizAttempt, err error) {
+	span := s.start("GetQuizAttempt")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetQuizAttempt(attemptID)
+}
+
+func (s tracedStorage) GetQuizAttempts(userID int, taskID int) (r0 []models.QuizAttempt, err error) {
+	span := s.start("GetQuizAttempts")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetQuizAttempts(userID, taskID)
+}
+
+func (s tracedStorage) SubmitQuizAttempt(attemptID int, answers []models.QuizAnswer, submittedAt time.Time) (r0 models.QuizAttempt, err error) {
+	span := s.start("SubmitQuizAttempt")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.SubmitQuizAttempt(attemptID, answers, submittedAt)
+}
+
+func (s tracedStorage) GetCourseCompletions(userID int) (r0 []models.CourseCompletion, err error) {
+	span := s.start("GetCourseCompletions")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCourseCompletions(userID)
+}
+
+func (s tracedStorage) CreateCertificate(cert models.Certificate) (r0 models.Certificate, err error) {
+	span := s.start("CreateCertificate")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateCertificate(cert)
+}
+
+func (s tracedStorage) GetCertificate(id string) (r0 models.Certificate, err error) {
+	span := s.start("GetCertificate")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetCertificate(id)
+}
+
+func (s tracedStorage) GetUserCertificates(userID int) (r0 []models.Certificate, err error) {
+	span := s.start("GetUserCertificates")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserCertificates(userID)
+}
+
+func (s tracedStorage) RevokeCertificate(id string, reason string, revokedAt time.Time) (r0 models.Certificate, err error) {
+	span := s.start("RevokeCertificate")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.RevokeCertificate(id, reason, revokedAt)
+}
+
+func (s tracedStorage) GetBadges() (r0 []models.Badge, err error) {
+	span := s.start("GetBadges")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetBadges()
+}
+
+func (s tracedStorage) GetBadge(badgeID int) (r0 models.Badge, err error) {
+	span := s.start("GetBadge")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetBadge(badgeID)
+}
+
+func (s tracedStorage) CreateBadge(badge models.Badge) (r0 models.Badge, err error) {
+	span := s.start("CreateBadge")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateBadge(badge)
+}
+
+func (s tracedStorage) UpdateBadge(badge models.Badge) (r0 models.Badge, err error) {
+	span := s.start("UpdateBadge")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateBadge(badge)
+}
+
+func (s tracedStorage) DeleteBadge(badgeID int) (err error) {
+	span := s.start("DeleteBadge")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DeleteBadge(badgeID)
+}
+
+func (s tracedStorage) AwardBadges(userID int, badges []models.UserBadge) (r0 []models.UserBadge, err error) {
+	span := s.start("AwardBadges")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.AwardBadges(userID, badges)
+}
+
+func (s tracedStorage) GetUserBadges(userID int) (r0 []models.UserBadge, err error) {
+	span := s.start("GetUserBadges")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserBadges(userID)
+}
+
+func (s tracedStorage) GetUserPreferences(userID int) (r0 models.UserPreferences, err error) {
+	span := s.start("GetUserPreferences")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserPreferences(userID)
+}
+
+func (s tracedStorage) UpdateUserPreferences(userID int, prefs models.UserPreferences) (err error) {
+	span := s.start("UpdateUserPreferences")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateUserPreferences(userID, prefs)
+}
+
+func (s tracedStorage) GetActivityTimes(userID int, from time.Time, to time.Time) (r0 models.ActivityTimes, err error) {
+	span := s.start("GetActivityTimes")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetActivityTimes(userID, from, to)
+}
+
+func (s tracedStorage) GetStreakReminderCandidates() (r0 []models.StreakReminderCandidate, err error) {
+	span := s.start("GetStreakReminderCandidates")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetStreakReminderCandidates()
+}
+
+func (s tracedStorage) MarkStreakReminderSent(userID int, date string) (err error) {
+	span := s.start("MarkStreakReminderSent")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.MarkStreakReminderSent(userID, date)
+}
+
+func (s tracedStorage) CreateNotification(notification models.Notification) (r0 models.Notification, err error) {
+	span := s.start("CreateNotification")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateNotification(notification)
+}
+
+func (s tracedStorage) CreateNotifications(notifications []models.Notification) (r0 []models.Notification, err error) {
+	span := s.start("CreateNotifications")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateNotifications(notifications)
+}
+
+func (s tracedStorage) GetNotifications(userID int, unreadOnly bool, params models.ListParams) (r0 []models.Notification, r1 int, err error) {
+	span := s.start("GetNotifications")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetNotifications(userID, unreadOnly, params)
+}
+
+func (s tracedStorage) CountUnreadNotifications(userID int) (r0 int, err error) {
+	span := s.start("CountUnreadNotifications")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CountUnreadNotifications(userID)
+}
+
+func (s tracedStorage) MarkNotificationRead(userID int, notificationID int, readAt time.Time) (r0 models.Notification, err error) {
+	span := s.start("MarkNotificationRead")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.MarkNotificationRead(userID, notificationID, readAt)
+}
+
+func (s tracedStorage) MarkAllNotificationsRead(userID int, readAt time.Time) (r0 int, err error) {
+	span := s.start("MarkAllNotificationsRead")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.MarkAllNotificationsRead(userID, readAt)
+}
+
+func (s tracedStorage) GetNotificationPreferences(userID int) (r0 models.NotificationPreferences, err error) {
+	span := s.start("GetNotificationPreferences")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetNotificationPreferences(userID)
+}
+
+func (s tracedStorage) UpdateNotificationPreferences(userID int, prefs models.NotificationPreferences) (err error) {
+	span := s.start("UpdateNotificationPreferences")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.UpdateNotificationPreferences(userID, prefs)
+}
+
+func (s tracedStorage) CreateThread(thread models.DiscussionThread) (r0 models.DiscussionThread, err error) {
+	span := s.start("CreateThread")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateThread(thread)
+}
+
+func (s tracedStorage) GetThreads(courseID int, taskID int, includeHidden bool, params models.ListParams) (r0 []models.DiscussionThread, r1 int, err error) {
+	span := s.start("GetThreads")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetThreads(courseID, taskID, includeHidden, params)
+}
+
+func (s tracedStorage) GetThread(threadID int) (r0 models.DiscussionThread, err error) {
+	span := s.start("GetThread")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetThread(threadID)
+}
+
+func (s tracedStorage) ModerateThread(threadID int, moderation models.ThreadModeration) (r0 models.DiscussionThread, err error) {
+	span := s.start("ModerateThread")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.ModerateThread(threadID, moderation)
+}
+
+func (s tracedStorage) CreateReply(reply models.DiscussionReply) (r0 models.DiscussionReply, err error) {
+	span := s.start("CreateReply")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.CreateReply(reply)
+}
+
+func (s tracedStorage) GetReplies(threadID int, includeHidden bool) (r0 []models.DiscussionReply, err error) {
+	span := s.start("GetReplies")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetReplies(threadID, includeHidden)
+}
+
+func (s tracedStorage) ModerateReply(threadID int, replyID int, isHidden bool) (r0 models.DiscussionReply, err error) {
+	span := s.start("ModerateReply")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.ModerateReply(threadID, replyID, isHidden)
+}
+
+func (s tracedStorage) SetAcceptedReply(threadID int, replyID int) (r0 models.DiscussionThread, err error) {
+	span := s.start("SetAcceptedReply")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.SetAcceptedReply(threadID, replyID)
+}
+
+func (s tracedStorage) GetUncheckedAnswers(limit int) (r0 []models.SubmittedAnswer, err error) {
+	span := s.start("GetUncheckedAnswers")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUncheckedAnswers(limit)
+}
+
+func (s tracedStorage) GetTaskAnswers(taskID int, beforeSubmissionID int, limit int) (r0 []models.SubmittedAnswer, err error) {
+	span := s.start("GetTaskAnswers")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetTaskAnswers(taskID, beforeSubmissionID, limit)
+}
+
+func (s tracedStorage) RecordSimilarityCheck(submissionID int, flags []models.SimilarityFlag) (err error) {
+	span := s.start("RecordSimilarityCheck")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.RecordSimilarityCheck(submissionID, flags)
+}
+
+func (s tracedStorage) GetSimilarityFlags(courseID int, filter models.SimilarityFlagFilter, params models.ListParams) (r0 []models.SimilarityFlag, r1 int, err error) {
+	span := s.start("GetSimilarityFlags")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetSimilarityFlags(courseID, filter, params)
+}
+
+func (s tracedStorage) ReviewSimilarityFlag(flagID int, reviewerID int, review models.SimilarityReview, reviewedAt time.Time) (r0 models.SimilarityFlag, err error) {
+	span := s.start("ReviewSimilarityFlag")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.ReviewSimilarityFlag(flagID, reviewerID, review, reviewedAt)
+}
+
+func (s tracedStorage) AppendLiveEvent(event models.LiveEvent) (r0 models.LiveEvent, err error) {
+	span := s.start("AppendLiveEvent")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.AppendLiveEvent(event)
+}
+
+func (s tracedStorage) GetLiveEventsAfter(afterID int, limit int) (r0 []models.LiveEvent, err error) {
+	span := s.start("GetLiveEventsAfter")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetLiveEventsAfter(afterID, limit)
+}
+
+func (s tracedStorage) GetLastLiveEventID() (r0 int, err error) {
+	span := s.start("GetLastLiveEventID")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetLastLiveEventID()
+}
+
+func (s tracedStorage) DeleteLiveEventsBefore(before time.Time) (r0 int, err error) {
+	span := s.start("DeleteLiveEventsBefore")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.DeleteLiveEventsBefore(before)
+}
+
+func (s tracedStorage) RecordLearningActivities(userID int, activities []models.LearningActivity) (err error) {
+	span := s.start("RecordLearningActivities")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.RecordLearningActivities(userID, activities)
+}
+
+func (s tracedStorage) GetUserActivitySummary(userID int) (r0 []models.TaskActivitySummary, err error) {
+	span := s.start("GetUserActivitySummary")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.GetUserActivitySummary(userID)
+}
+
+func (s tracedStorage) Search(query models.SearchQuery, params models.ListParams) (r0 []models.SearchResult, r1 int, err error) {
+	span := s.start("Search")
+	defer func() { endSpan(span, err) }()
+	return s.Storage.Search(query, params)
+}
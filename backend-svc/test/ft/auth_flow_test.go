@@ -113,6 +113,9 @@ func (suite *FunctionalTestSuite) createDatabaseSchema() error {
 			difficulty TEXT,
 			task_order INTEGER,
 			points INTEGER DEFAULT 10,
+			type TEXT NOT NULL DEFAULT 'code',
+			content TEXT DEFAULT '',
+			solution TEXT DEFAULT '',
 			FOREIGN KEY (course_id) REFERENCES courses (id)
 		)
 	`)
@@ -133,6 +136,455 @@ func (suite *FunctionalTestSuite) createDatabaseSchema() error {
 			UNIQUE(user_id, task_id)
 		)
 	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE learning_activities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			course_id INTEGER NOT NULL,
+			task_id INTEGER NOT NULL,
+			activity_type TEXT NOT NULL,
+			duration_seconds INTEGER NOT NULL DEFAULT 0,
+			details TEXT,
+			occurred_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE learning_activity_summary (
+			user_id INTEGER NOT NULL,
+			task_id INTEGER NOT NULL,
+			course_id INTEGER NOT NULL,
+			time_spent_seconds INTEGER NOT NULL DEFAULT 0,
+			opened_count INTEGER NOT NULL DEFAULT 0,
+			hints_viewed INTEGER NOT NULL DEFAULT 0,
+			submissions_count INTEGER NOT NULL DEFAULT 0,
+			first_activity_at TIMESTAMP NOT NULL,
+			last_activity_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (user_id, task_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE task_submissions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			task_id INTEGER NOT NULL,
+			course_id INTEGER NOT NULL,
+			is_correct BOOLEAN NOT NULL DEFAULT 0,
+			score REAL NOT NULL DEFAULT 0,
+			is_late BOOLEAN NOT NULL DEFAULT 0,
+			penalty_percent REAL NOT NULL DEFAULT 0,
+			submitted_at TIMESTAMP NOT NULL,
+			answer TEXT,
+			similarity_checked BOOLEAN NOT NULL DEFAULT FALSE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE task_skills (
+			task_id INTEGER NOT NULL,
+			skill TEXT NOT NULL,
+			cwe TEXT,
+			weight REAL NOT NULL DEFAULT 1,
+			PRIMARY KEY (task_id, skill)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE task_prerequisites (
+			task_id INTEGER NOT NULL,
+			prerequisite_task_id INTEGER NOT NULL,
+			PRIMARY KEY (task_id, prerequisite_task_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE assignments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			course_id INTEGER NOT NULL,
+			cohort_id INTEGER,
+			title TEXT NOT NULL,
+			description TEXT,
+			opens_at TIMESTAMP NOT NULL,
+			due_at TIMESTAMP NOT NULL,
+			penalty_per_day REAL NOT NULL DEFAULT 0,
+			max_penalty REAL NOT NULL DEFAULT 0,
+			grace_minutes INTEGER NOT NULL DEFAULT 0,
+			close_after_days INTEGER NOT NULL DEFAULT 0,
+			created_by INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE assignment_tasks (
+			assignment_id INTEGER NOT NULL,
+			task_id INTEGER NOT NULL,
+			PRIMARY KEY (assignment_id, task_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE assignment_extensions (
+			assignment_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			due_at TIMESTAMP NOT NULL,
+			reason TEXT,
+			granted_by INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (assignment_id, user_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE assignment_reminders (
+			assignment_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			due_at TIMESTAMP NOT NULL,
+			sent_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (assignment_id, user_id, due_at)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE cohorts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			invite_code TEXT UNIQUE,
+			created_by INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE cohort_members (
+			cohort_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cohort_id, user_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE cohort_teachers (
+			cohort_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			PRIMARY KEY (cohort_id, user_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE cohort_courses (
+			cohort_id INTEGER NOT NULL,
+			course_id INTEGER NOT NULL,
+			PRIMARY KEY (cohort_id, course_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE question_banks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			course_id INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE quiz_questions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			bank_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			text TEXT NOT NULL,
+			points REAL NOT NULL DEFAULT 1,
+			position INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE quiz_question_options (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			question_id INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			is_correct BOOLEAN NOT NULL DEFAULT 0,
+			position INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE quiz_settings (
+			task_id INTEGER PRIMARY KEY,
+			time_limit_minutes INTEGER NOT NULL DEFAULT 0,
+			max_attempts INTEGER NOT NULL DEFAULT 0,
+			passing_score REAL NOT NULL DEFAULT 0,
+			shuffle_questions BOOLEAN NOT NULL DEFAULT 0,
+			shuffle_options BOOLEAN NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE quiz_sources (
+			task_id INTEGER NOT NULL,
+			bank_id INTEGER NOT NULL,
+			question_count INTEGER NOT NULL DEFAULT 0,
+			position INTEGER NOT NULL,
+			PRIMARY KEY (task_id, bank_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE quiz_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			task_id INTEGER NOT NULL,
+			course_id INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			started_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP,
+			submitted_at TIMESTAMP,
+			score REAL NOT NULL DEFAULT 0,
+			points REAL NOT NULL DEFAULT 0,
+			is_passed BOOLEAN NOT NULL DEFAULT 0,
+			is_late BOOLEAN NOT NULL DEFAULT 0,
+			penalty_percent REAL NOT NULL DEFAULT 0,
+			submission_id INTEGER
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE quiz_attempt_questions (
+			attempt_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			question_id INTEGER NOT NULL,
+			question TEXT NOT NULL,
+			answer TEXT,
+			earned REAL,
+			PRIMARY KEY (attempt_id, position)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE certificates (
+			id TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			full_name TEXT NOT NULL,
+			course_id INTEGER NOT NULL,
+			course_title TEXT NOT NULL,
+			score REAL NOT NULL,
+			completed_at TIMESTAMP NOT NULL,
+			issued_at TIMESTAMP NOT NULL,
+			signature TEXT NOT NULL,
+			revoked_at TIMESTAMP,
+			revoke_reason TEXT,
+			UNIQUE (user_id, course_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE badges (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			code TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			description TEXT,
+			icon TEXT,
+			criteria TEXT NOT NULL,
+			is_active INTEGER NOT NULL DEFAULT 1,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE user_badges (
+			user_id INTEGER NOT NULL,
+			badge_id INTEGER NOT NULL,
+			awarded_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (user_id, badge_id)
+		);
+		INSERT INTO badges (code, name, criteria) VALUES
+			('first_task', 'Первый шаг', '{"type":"tasks_completed","threshold":1}'),
+			('course_finished', 'Выпускник', '{"type":"courses_completed","threshold":1}'),
+			('streak_7', 'Неделя без перерыва', '{"type":"streak_days","threshold":7}'),
+			('hard_no_hints', 'Без подсказок', '{"type":"tasks_without_hints","difficulty":"hard"}'),
+			('top_10', 'Десятка лучших', '{"type":"leaderboard_rank","threshold":10}');
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE user_preferences (
+			user_id INTEGER PRIMARY KEY,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			streak_reminders INTEGER NOT NULL DEFAULT 0,
+			streak_reminded_on TEXT
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			title TEXT NOT NULL,
+			message TEXT NOT NULL,
+			payload TEXT,
+			is_read INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			read_at TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE notification_preferences (
+			user_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			email INTEGER NOT NULL,
+			PRIMARY KEY (user_id, type)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE live_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			type TEXT NOT NULL,
+			user_id INTEGER NOT NULL DEFAULT 0,
+			data TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE discussion_threads (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			course_id INTEGER NOT NULL,
+			task_id INTEGER,
+			author_id INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			body TEXT NOT NULL,
+			is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+			is_locked BOOLEAN NOT NULL DEFAULT FALSE,
+			is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+			has_spoiler BOOLEAN NOT NULL DEFAULT FALSE,
+			accepted_reply_id INTEGER,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_activity_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE discussion_replies (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			thread_id INTEGER NOT NULL,
+			author_id INTEGER NOT NULL,
+			body TEXT NOT NULL,
+			is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = suite.db.Exec(`
+		CREATE TABLE similarity_flags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			course_id INTEGER NOT NULL,
+			task_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			score REAL NOT NULL,
+			time_gap_seconds INTEGER NOT NULL DEFAULT 0,
+			submission_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			other_submission_id INTEGER NOT NULL,
+			other_user_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			note TEXT,
+			reviewed_by INTEGER,
+			reviewed_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
 
 	return err
 }
@@ -205,6 +657,15 @@ func (suite *FunctionalTestSuite) seedTestData() error {
 		return err
 	}
 
+	_, err = suite.db.Exec(`
+		UPDATE tasks
+		SET content = 'element.innerHTML = comment', solution = 'element.textContent = comment'
+		WHERE id = 3
+	`)
+	if err != nil {
+		return err
+	}
+
 	_, err = suite.db.Exec(`
 		INSERT INTO user_progress (user_id, task_id, status)
 		VALUES 
@@ -222,6 +683,8 @@ func (suite *FunctionalTestSuite) setupRoutes() {
 		public.POST("/verify-otp", handlers.VerifyOTPHandler)
 		public.GET("/courses", handlers.GetCourses)
 		public.GET("/courses/:id", handlers.GetCourseByID)
+		public.GET("/certificates/verify", handlers.VerifyCertificate)
+		public.GET("/certificates/public-key", handlers.GetCertificatePublicKey)
 	}
 
 	api := suite.router.Group("/api")
@@ -234,6 +697,42 @@ func (suite *FunctionalTestSuite) setupRoutes() {
 		api.GET("/progress/:user_id/submissions", handlers.GetUserSubmissions)
 		api.POST("/progress/:user_id/tasks/:task_id/submit", handlers.SubmitTaskWithAnswer)
 		api.GET("/progress/:user_id/learning-path", handlers.GetUserLearningPath)
+		api.GET("/progress/:user_id/deadlines", handlers.GetUserDeadlines)
+		api.GET("/progress/:user_id/certificates", handlers.GetUserCertificates)
+		api.POST("/progress/:user_id/certificates", handlers.IssueCertificates)
+		api.GET("/progress/:user_id/badges", handlers.GetUserBadges)
+		api.GET("/progress/:user_id/activity", handlers.GetUserActivityHeatmap)
+		api.GET("/account/preferences", handlers.GetUserPreferences)
+		api.PUT("/account/preferences", handlers.UpdateUserPreferences)
+		api.POST("/progress/:user_id/tasks/:task_id/quiz-attempts", handlers.StartQuizAttempt)
+		api.GET("/progress/:user_id/tasks/:task_id/quiz-attempts", handlers.GetQuizAttempts)
+		api.GET("/progress/:user_id/quiz-attempts/:attempt_id", handlers.GetQuizAttempt)
+		api.POST("/progress/:user_id/quiz-attempts/:attempt_id/submit", handlers.SubmitQuizAttempt)
+		api.GET("/search", handlers.Search)
+		api.GET("/notifications", handlers.GetNotifications)
+		api.GET("/notifications/unread-count", handlers.GetUnreadNotificationCount)
+		api.POST("/notifications/read-all", handlers.MarkAllNotificationsRead)
+		api.POST("/notifications/:notification_id/read", handlers.MarkNotificationRead)
+		api.GET("/notifications/preferences", handlers.GetNotificationPreferences)
+		api.PUT("/notifications/preferences", handlers.UpdateNotificationPreferences)
+		api.POST("/activity", handlers.RecordLearningActivity)
+		api.POST("/cohorts/join", handlers.JoinCohort)
+		api.GET("/certificates/:certificate_id", handlers.DownloadCertificate)
+		api.GET("/analytics/users/:user_id/statistics", handlers.GetUserStatistics)
+		api.GET("/courses/:id/discussions", handlers.GetDiscussions)
+		api.POST("/courses/:id/discussions", handlers.CreateDiscussion)
+		api.GET("/discussions/:thread_id", handlers.GetDiscussion)
+		api.POST("/discussions/:thread_id/replies", handlers.CreateDiscussionReply)
+	}
+
+	teacher := api.Group("/teacher")
+	teacher.Use(suite.teacherAuthMiddleware())
+	{
+		teacher.PUT("/discussions/:thread_id", handlers.ModerateDiscussion)
+		teacher.PUT("/discussions/:thread_id/replies/:reply_id", handlers.ModerateDiscussionReply)
+		teacher.PUT("/discussions/:thread_id/accepted-reply", handlers.AcceptDiscussionReply)
+		teacher.GET("/courses/:course_id/similarity-flags", handlers.GetSimilarityFlags)
+		teacher.PUT("/similarity-flags/:flag_id", handlers.ReviewSimilarityFlag)
 	}
 
 	admin := api.Group("/admin")
@@ -242,14 +741,22 @@ func (suite *FunctionalTestSuite) setupRoutes() {
 		admin.GET("/users", handlers.GetAllUsers)
 		admin.GET("/users/:id", handlers.GetUserByID)
 		admin.GET("/analytics/courses/:course_id/statistics", handlers.GetCourseStatistics)
+		admin.GET("/analytics/courses/:course_id/effectiveness", handlers.GetLearningEffectiveness)
+		admin.GET("/badges", handlers.GetBadges)
+		admin.POST("/badges", handlers.CreateBadge)
+		admin.POST("/badges/evaluate", handlers.EvaluateBadges)
 	}
 }
 
 func (suite *FunctionalTestSuite) generateToken() {
 	// Генерируем токен напрямую без запроса к API
 	// Это гарантированно работает и не зависит от корректности handler'а login
-	userID := 2 // ID пользователя user123
+	suite.token = suite.signToken(2) // ID пользователя user123
+	suite.T().Logf("Generated token: %s", suite.token)
+}
 
+// signToken выпускает JWT для пользователя userID
+func (suite *FunctionalTestSuite) signToken(userID int) string {
 	tokenExpiration := time.Now().Add(time.Hour * 24)
 	claims := jwt.MapClaims{
 		"sub": float64(userID),
@@ -261,11 +768,8 @@ func (suite *FunctionalTestSuite) generateToken() {
 	tokenString, err := token.SignedString([]byte(handlers.JWTSecret))
 	if err != nil {
 		suite.T().Fatalf("Failed to generate JWT: %v", err)
-		return
 	}
-
-	suite.token = tokenString
-	suite.T().Logf("Generated token: %s", tokenString)
+	return tokenString
 }
 
 func (suite *FunctionalTestSuite) jwtAuthMiddleware() gin.HandlerFunc {
@@ -336,6 +840,18 @@ func (suite *FunctionalTestSuite) adminAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+func (suite *FunctionalTestSuite) teacherAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		isTeacher, err := handlers.CheckTeacherRights(c.GetInt("userID"))
+		if err != nil || !isTeacher {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{Error: "Teacher access required"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func TestFunctionalTestSuite(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping functional tests in short mode")
@@ -1,11 +1,15 @@
 package ft
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"lmsmodule/backend-svc/handlers"
 	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
 	_ "modernc.org/sqlite"
 	"net/http"
 	_ "testing"
+	"time"
 )
 
 func (suite *FunctionalTestSuite) TestGetCourses() {
@@ -22,6 +26,23 @@ func (suite *FunctionalTestSuite) TestGetCourses() {
 	assert.Len(t, *courses, 3)
 }
 
+func (suite *FunctionalTestSuite) TestGetCoursesPaginated() {
+	t := suite.T()
+
+	resp, err := suite.client.R().
+		SetResult(&[]models.Course{}).
+		SetQueryParams(map[string]string{"page": "2", "limit": "2", "sort": "id"}).
+		Get("/api/courses")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "3", resp.Header().Get("X-Total-Count"))
+
+	courses := resp.Result().(*[]models.Course)
+	assert.Len(t, *courses, 1)
+	assert.Equal(t, 3, (*courses)[0].ID)
+}
+
 func (suite *FunctionalTestSuite) TestGetCourseByID() {
 	t := suite.T()
 
@@ -37,3 +58,267 @@ func (suite *FunctionalTestSuite) TestGetCourseByID() {
 	assert.Equal(t, "SQL Injection", course.VulnerabilityType)
 	assert.NotEmpty(t, course.Tasks)
 }
+
+func (suite *FunctionalTestSuite) TestSearch() {
+	t := suite.T()
+
+	resp, err := suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&[]models.SearchResult{}).
+		SetQueryParam("q", "sql injection").
+		Get("/api/search")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	results := resp.Result().(*[]models.SearchResult)
+	assert.Len(t, *results, 3)
+	assert.Equal(t, models.SearchTypeCourse, (*results)[0].Type)
+	assert.Contains(t, (*results)[0].Snippet, "<mark>SQL</mark>")
+
+	resp, err = suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&[]models.SearchResult{}).
+		SetQueryParam("q", "innerHTML").
+		Get("/api/search")
+
+	assert.NoError(t, err)
+	results = resp.Result().(*[]models.SearchResult)
+	assert.Len(t, *results, 1)
+	assert.Equal(t, 3, (*results)[0].ID)
+
+	resp, err = suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&[]models.SearchResult{}).
+		SetQueryParam("q", "textContent").
+		Get("/api/search")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	results = resp.Result().(*[]models.SearchResult)
+	assert.Empty(t, *results)
+}
+
+func (suite *FunctionalTestSuite) TestCohorts() {
+	t := suite.T()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Группами управляют преподаватели, поэтому SQL групп проверяем через хранилище
+	cohort, err := handlers.Store.CreateCohort(models.Cohort{
+		Name:       "CS-21-1",
+		InviteCode: "JOINCS21",
+		CourseIDs:  []int{1},
+		TeacherIDs: []int{1},
+		CreatedBy:  1,
+	})
+	assert.NoError(t, err)
+	assert.NotZero(t, cohort.ID)
+	defer handlers.Store.DeleteCohort(cohort.ID)
+
+	_, err = handlers.Store.CreateCohort(models.Cohort{Name: "CS-21-1", CourseIDs: []int{}, TeacherIDs: []int{}})
+	assert.ErrorIs(t, err, storage.ErrCohortNameTaken)
+
+	isTeacher, err := handlers.Store.IsCohortTeacher(cohort.ID, 1)
+	assert.NoError(t, err)
+	assert.True(t, isTeacher)
+
+	resp, err := suite.client.R().
+		SetAuthToken(suite.token).
+		SetBody(models.JoinCohortRequest{InviteCode: "wrong"}).
+		Post("/api/cohorts/join")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+	resp, err = suite.client.R().
+		SetAuthToken(suite.token).
+		SetBody(models.JoinCohortRequest{InviteCode: "joincs21"}).
+		SetResult(&models.Cohort{}).
+		Post("/api/cohorts/join")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	joined := resp.Result().(*models.Cohort)
+	assert.Equal(t, cohort.ID, joined.ID)
+	assert.Empty(t, joined.InviteCode)
+
+	resp, err = suite.client.R().
+		SetAuthToken(suite.token).
+		SetBody(models.JoinCohortRequest{InviteCode: "JOINCS21"}).
+		Post("/api/cohorts/join")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode())
+
+	members, total, err := handlers.Store.GetCohortMembers(cohort.ID, models.ListParams{Page: 1, Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, members, 1) {
+		assert.Equal(t, "user123", members[0].Username)
+	}
+
+	ids, err := handlers.Store.ResolveUserIDs([]string{"ADMIN@example.com", "nobody"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"ADMIN@example.com": 1}, ids)
+
+	leaderboard, err := handlers.Store.GetCohortLeaderboard(cohort.ID, 1, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, leaderboard, 1) {
+		assert.Equal(t, 2, leaderboard[0].UserID)
+		assert.Equal(t, 2, leaderboard[0].Completed)
+	}
+
+	stats, err := handlers.Store.GetCourseStatistics(1, models.ListParams{Page: 1, Limit: 20, CohortID: cohort.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.EnrolledStudents)
+	if assert.Len(t, stats.StudentsProgress, 1) {
+		assert.Equal(t, 2, stats.StudentsProgress[0].UserID)
+	}
+
+	_, err = handlers.Store.SubmitTaskAnswer(models.TaskSubmission{UserID: 2, CourseID: 1, TaskID: 1, Answer: "' OR 1=1 --"})
+	assert.NoError(t, err)
+	submissions, total, err := handlers.Store.GetCohortSubmissions(cohort.ID, models.ListParams{Page: 1, Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, submissions, 1) {
+		assert.Equal(t, "user123", submissions[0].Username)
+		assert.Equal(t, 1, submissions[0].TaskID)
+	}
+
+	// Задание группы видят только ее студенты
+	_, err = handlers.Store.CreateAssignment(models.Assignment{
+		CourseID: 2,
+		CohortID: &cohort.ID,
+		Title:    "Not a cohort course",
+		TaskIDs:  []int{3},
+		OpensAt:  now,
+		DueAt:    now.Add(time.Hour),
+	})
+	assert.ErrorIs(t, err, storage.ErrCourseNotInCohort)
+
+	assignment, err := handlers.Store.CreateAssignment(models.Assignment{
+		CourseID: 1,
+		CohortID: &cohort.ID,
+		Title:    "Cohort homework",
+		TaskIDs:  []int{1},
+		OpensAt:  now.Add(-time.Hour),
+		DueAt:    now.Add(48 * time.Hour),
+	})
+	assert.NoError(t, err)
+
+	deadlines, err := handlers.Store.GetUserDeadlines(2)
+	assert.NoError(t, err)
+	assert.Contains(t, deadlineAssignments(deadlines), assignment.ID)
+
+	deadlines, err = handlers.Store.GetUserDeadlines(1)
+	assert.NoError(t, err)
+	assert.NotContains(t, deadlineAssignments(deadlines), assignment.ID)
+
+	assert.NoError(t, handlers.Store.RemoveCohortMember(cohort.ID, 2))
+	assert.ErrorIs(t, handlers.Store.RemoveCohortMember(cohort.ID, 2), storage.ErrCohortMemberNotFound)
+}
+
+func deadlineAssignments(deadlines []models.TaskDeadline) []int {
+	ids := make([]int, 0, len(deadlines))
+	for _, deadline := range deadlines {
+		ids = append(ids, deadline.AssignmentID)
+	}
+	return ids
+}
+
+func (suite *FunctionalTestSuite) TestDiscussions() {
+	t := suite.T()
+	adminToken := suite.signToken(1)
+
+	var spoiler models.DiscussionThread
+	resp, err := suite.client.R().SetAuthToken(adminToken).SetResult(&spoiler).
+		SetBody(map[string]interface{}{"title": "Fix for task 3", "body": "Use `element.textContent = comment` here", "task_id": 3}).
+		Post("/api/courses/2/discussions")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode())
+	assert.True(t, spoiler.HasSpoiler, "solution text marks the thread as spoiler")
+	assert.Equal(t, "admin", spoiler.AuthorName)
+
+	var question models.DiscussionThread
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&question).
+		SetBody(map[string]interface{}{"title": "Where to start?", "body": "Any **hints** on escaping?"}).
+		Post("/api/courses/2/discussions")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode())
+	assert.False(t, question.HasSpoiler)
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).
+		SetBody(map[string]interface{}{"title": "Wrong task", "body": "text", "task_id": 1}).
+		Post("/api/courses/2/discussions")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode(), "task must belong to the course")
+
+	var hidden models.DiscussionThread
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&hidden).
+		Get(fmt.Sprintf("/api/discussions/%d", spoiler.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.True(t, hidden.SpoilerHidden)
+	assert.Empty(t, hidden.Body)
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetBody(map[string]string{"body": "Thanks!"}).
+		Post(fmt.Sprintf("/api/discussions/%d/replies", spoiler.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	var reply models.DiscussionReply
+	resp, err = suite.client.R().SetAuthToken(adminToken).SetResult(&reply).
+		SetBody(map[string]string{"body": "Look at how the comment is inserted into the page."}).
+		Post(fmt.Sprintf("/api/discussions/%d/replies", question.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode())
+
+	var accepted models.DiscussionThread
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetBody(map[string]int{"reply_id": reply.ID}).
+		Put(fmt.Sprintf("/api/teacher/discussions/%d/accepted-reply", question.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(adminToken).SetResult(&accepted).SetBody(map[string]int{"reply_id": reply.ID}).
+		Put(fmt.Sprintf("/api/teacher/discussions/%d/accepted-reply", question.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	if assert.NotNil(t, accepted.AcceptedReplyID) {
+		assert.Equal(t, reply.ID, *accepted.AcceptedReplyID)
+	}
+
+	resp, err = suite.client.R().SetAuthToken(adminToken).
+		SetBody(map[string]bool{"is_locked": true, "is_pinned": true}).
+		Put(fmt.Sprintf("/api/teacher/discussions/%d", question.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetBody(map[string]string{"body": "One more question"}).
+		Post(fmt.Sprintf("/api/discussions/%d/replies", question.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode())
+
+	var threads []models.DiscussionThread
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&threads).Get("/api/courses/2/discussions")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "2", resp.Header().Get("X-Total-Count"))
+	if assert.Len(t, threads, 2) {
+		assert.Equal(t, question.ID, threads[0].ID, "pinned threads come first")
+		assert.Equal(t, 1, threads[0].RepliesCount)
+		assert.True(t, threads[1].SpoilerHidden)
+	}
+
+	resp, err = suite.client.R().SetAuthToken(adminToken).SetBody(map[string]bool{"is_hidden": true}).
+		Put(fmt.Sprintf("/api/teacher/discussions/%d", spoiler.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Get(fmt.Sprintf("/api/discussions/%d", spoiler.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+	_, err = suite.db.Exec("DELETE FROM discussion_replies")
+	assert.NoError(t, err)
+	_, err = suite.db.Exec("DELETE FROM discussion_threads")
+	assert.NoError(t, err)
+	_, err = suite.db.Exec("DELETE FROM notifications")
+	assert.NoError(t, err)
+}
@@ -1,9 +1,16 @@
 package ft
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"lmsmodule/backend-svc/events"
+	"lmsmodule/backend-svc/handlers"
 	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/similarity"
+	"lmsmodule/backend-svc/storage"
 	"net/http"
+	"strings"
+	"time"
 )
 
 func (suite *FunctionalTestSuite) TestGetUserProgress() {
@@ -22,3 +29,576 @@ func (suite *FunctionalTestSuite) TestGetUserProgress() {
 	assert.True(t, progress.Completed[1])
 	assert.True(t, progress.Completed[2])
 }
+
+func (suite *FunctionalTestSuite) TestRecordLearningActivity() {
+	t := suite.T()
+
+	openedAt := time.Now().Add(-10 * time.Minute).UTC()
+	batch := models.ActivityBatchRequest{
+		Events: []models.LearningActivity{
+			{TaskID: 3, ActivityType: models.ActivityTaskOpened, Timestamp: openedAt},
+			{TaskID: 3, ActivityType: models.ActivityTimeOnTask, Duration: 300, Timestamp: openedAt.Add(5 * time.Minute)},
+			{TaskID: 3, ActivityType: models.ActivityHintViewed, Timestamp: openedAt.Add(6 * time.Minute)},
+			{TaskID: 3, ActivityType: models.ActivityTimeOnTask, Duration: 120},
+		},
+	}
+
+	resp, err := suite.client.R().
+		SetAuthToken(suite.token).
+		SetBody(batch).
+		SetResult(&models.ActivityBatchResponse{}).
+		Post("/api/activity")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, 4, resp.Result().(*models.ActivityBatchResponse).Accepted)
+
+	var timeSpent, opened, hints int
+	err = suite.db.QueryRow(
+		"SELECT time_spent_seconds, opened_count, hints_viewed FROM learning_activity_summary WHERE user_id = ? AND task_id = ?",
+		2, 3,
+	).Scan(&timeSpent, &opened, &hints)
+	assert.NoError(t, err)
+	assert.Equal(t, 420, timeSpent)
+	assert.Equal(t, 1, opened)
+	assert.Equal(t, 1, hints)
+
+	resp, err = suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&models.UserStatistics{}).
+		Get("/api/analytics/users/2/statistics")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	stats := resp.Result().(*models.UserStatistics)
+	assert.Equal(t, "7m 00s", stats.TotalTimeSpent)
+	assert.WithinDuration(t, time.Now(), stats.LastActive, time.Minute)
+}
+
+func (suite *FunctionalTestSuite) TestRecordLearningActivityInvalid() {
+	t := suite.T()
+
+	for _, events := range [][]models.LearningActivity{
+		{{TaskID: 3, ActivityType: "scrolled"}},
+		{{TaskID: 3, ActivityType: models.ActivityTimeOnTask}},
+		{{TaskID: 999, ActivityType: models.ActivityTaskOpened}},
+		{{TaskID: 3, CourseID: 1, ActivityType: models.ActivityTaskOpened}},
+	} {
+		resp, err := suite.client.R().
+			SetAuthToken(suite.token).
+			SetBody(models.ActivityBatchRequest{Events: events}).
+			Post("/api/activity")
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode(), events[0].ActivityType)
+	}
+}
+
+func (suite *FunctionalTestSuite) TestSubmitTaskAnswerRecordsAttempt() {
+	t := suite.T()
+
+	resp, err := suite.client.R().
+		SetAuthToken(suite.token).
+		SetBody(models.TaskSubmission{CourseID: 2, Answer: "element.innerText = comment"}).
+		SetResult(&models.TaskSubmissionResponse{}).
+		Post("/api/progress/2/tasks/3/submit")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	result := resp.Result().(*models.TaskSubmissionResponse)
+	assert.False(t, result.IsCorrect)
+	assert.NotZero(t, result.SubmissionID)
+
+	var isCorrect bool
+	var score float64
+	var answer string
+	err = suite.db.QueryRow(
+		"SELECT is_correct, score, answer FROM task_submissions WHERE id = ? AND user_id = ? AND course_id = ?",
+		result.SubmissionID, 2, 2,
+	).Scan(&isCorrect, &score, &answer)
+	assert.NoError(t, err)
+	assert.False(t, isCorrect)
+	assert.Zero(t, score)
+	assert.Equal(t, "element.innerText = comment", answer, "answer text is kept for similarity checks")
+
+	// Отчет доступен только преподавателям и администраторам, поэтому SQL отчета проверяем через хранилище
+	now := time.Now()
+	report, err := handlers.Store.GetLearningEffectiveness(models.EffectivenessParams{
+		CourseID:       2,
+		Period:         models.PeriodWeek,
+		From:           models.PeriodStart(models.PeriodWeek, now),
+		To:             now.Add(time.Minute),
+		InactivityDays: models.DefaultInactivityDays,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "XSS", report.CourseName)
+	assert.Equal(t, 1, report.TotalStudents)
+	assert.Zero(t, report.DropoutRate)
+	if assert.Len(t, report.DifficultTasks, 1) {
+		assert.Equal(t, 3, report.DifficultTasks[0].TaskID)
+		assert.Equal(t, 100.0, report.DifficultTasks[0].FailRate)
+	}
+}
+
+func (suite *FunctionalTestSuite) TestAssignmentDeadlines() {
+	t := suite.T()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Задания создают преподаватели, поэтому SQL заданий проверяем через хранилище
+	late, err := handlers.Store.CreateAssignment(models.Assignment{
+		CourseID:   3,
+		Title:      "CSRF homework",
+		TaskIDs:    []int{4},
+		OpensAt:    now.Add(-72 * time.Hour),
+		DueAt:      now.Add(-36 * time.Hour),
+		LatePolicy: models.LatePolicy{PenaltyPerDay: 10, MaxPenalty: 50},
+		CreatedBy:  1,
+	})
+	assert.NoError(t, err)
+	assert.NotZero(t, late.ID)
+	defer handlers.Store.DeleteAssignment(3, late.ID)
+
+	resp, err := suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&[]models.TaskDeadline{}).
+		Get("/api/progress/2/deadlines")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	deadlines := *resp.Result().(*[]models.TaskDeadline)
+	if assert.Len(t, deadlines, 1) {
+		assert.Equal(t, 4, deadlines[0].TaskID)
+		assert.Equal(t, late.ID, deadlines[0].AssignmentID)
+		assert.False(t, deadlines[0].Extended)
+	}
+
+	// Просрочка на полтора дня - штраф за двое суток
+	result, err := handlers.Store.SubmitTaskAnswer(models.TaskSubmission{UserID: 1, CourseID: 3, TaskID: 4, Answer: "token"})
+	assert.NoError(t, err)
+	assert.True(t, result.IsLate)
+	assert.Equal(t, 20.0, result.Penalty)
+
+	var isLate bool
+	var penalty float64
+	err = suite.db.QueryRow("SELECT is_late, penalty_percent FROM task_submissions WHERE id = ?", result.SubmissionID).Scan(&isLate, &penalty)
+	assert.NoError(t, err)
+	assert.True(t, isLate)
+	assert.Equal(t, 20.0, penalty)
+
+	assert.NoError(t, handlers.Store.GrantExtension(models.AssignmentExtension{
+		AssignmentID: late.ID,
+		UserID:       1,
+		DueAt:        now.Add(12 * time.Hour),
+		GrantedBy:    1,
+	}))
+	userDeadlines, err := handlers.Store.GetUserDeadlines(1)
+	assert.NoError(t, err)
+	if assert.Len(t, userDeadlines, 1) {
+		assert.True(t, userDeadlines[0].Extended)
+		assert.True(t, userDeadlines[0].DueAt.Equal(now.Add(12*time.Hour)))
+	}
+
+	// Напоминание получает только студент курса с продленным сроком в окне напоминаний
+	reminders, err := handlers.Store.GetPendingReminders(now, 24*time.Hour)
+	assert.NoError(t, err)
+	if assert.Len(t, reminders, 1) {
+		assert.Equal(t, 1, reminders[0].UserID)
+		assert.Equal(t, "CSRF", reminders[0].CourseName)
+		assert.Equal(t, 1, reminders[0].RemainingTasks)
+		assert.NoError(t, handlers.Store.MarkReminderSent(reminders[0], now))
+	}
+
+	reminders, err = handlers.Store.GetPendingReminders(now, 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+}
+
+func (suite *FunctionalTestSuite) TestQuizAttempts() {
+	t := suite.T()
+
+	// Банки вопросов и тесты создают преподаватели, поэтому их SQL проверяем через хранилище
+	bank, err := handlers.Store.CreateQuestionBank(models.QuestionBank{
+		CourseID: 3,
+		Title:    "CSRF basics",
+		Questions: []models.QuizQuestion{
+			{Type: models.QuestionSingleChoice, Text: "Which header helps against CSRF?", Points: 1, Options: []models.QuizOption{
+				{Text: "Origin", IsCorrect: true}, {Text: "Accept-Language"},
+			}},
+			{Type: models.QuestionMultipleChoice, Text: "Which are CSRF defenses?", Points: 1, Options: []models.QuizOption{
+				{Text: "Anti-CSRF token", IsCorrect: true}, {Text: "SameSite cookies", IsCorrect: true}, {Text: "Base64"},
+			}},
+			{Type: models.QuestionShortAnswer, Text: "Cookie attribute limiting cross-site requests", Points: 1, Options: []models.QuizOption{
+				{Text: "SameSite"},
+			}},
+			{Type: models.QuestionOrdering, Text: "Order the attack steps", Points: 1, Options: []models.QuizOption{
+				{Text: "Victim logs in"}, {Text: "Victim opens attacker page"}, {Text: "Forged request is sent"},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, bank.Questions, 4) {
+		assert.True(t, bank.Questions[2].Options[0].IsCorrect, "short answers are accepted answers")
+	}
+
+	task, err := handlers.Store.CreateTask(3, models.Task{
+		CourseID: 3,
+		Title:    "CSRF quiz",
+		Points:   20,
+		Type:     models.TaskTypeQuiz,
+		Quiz: &models.QuizSettings{
+			TimeLimitMinutes: 10,
+			MaxAttempts:      2,
+			PassingScore:     100,
+			ShuffleOptions:   true,
+			Sources:          []models.QuizSource{{BankID: bank.ID, Count: 3}},
+		},
+	})
+	assert.NoError(t, err)
+	defer handlers.Store.DeleteTask(3, task.ID)
+
+	stored, err := handlers.Store.GetTaskByID(3, task.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.TaskTypeQuiz, stored.Type)
+	assert.Equal(t, task.Quiz, stored.Quiz)
+	assert.ErrorIs(t, handlers.Store.DeleteQuestionBank(3, bank.ID), storage.ErrQuestionBankInUse)
+
+	_, err = handlers.Store.SubmitTaskAnswer(models.TaskSubmission{UserID: 2, CourseID: 3, TaskID: task.ID, Answer: "Origin"})
+	assert.ErrorIs(t, err, storage.ErrQuizTask)
+
+	attemptsPath := fmt.Sprintf("/api/progress/2/tasks/%d/quiz-attempts", task.ID)
+	resp, err := suite.client.R().SetAuthToken(suite.token).SetResult(&models.QuizAttempt{}).Post(attemptsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	attempt := resp.Result().(*models.QuizAttempt)
+	assert.Len(t, attempt.Questions, 3)
+	assert.NotNil(t, attempt.ExpiresAt)
+	assert.NotContains(t, resp.String(), "is_correct")
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&models.QuizAttempt{}).Post(attemptsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, attempt.ID, resp.Result().(*models.QuizAttempt).ID, "unfinished attempt is resumed")
+
+	// Верно отвечаем на все вопросы, кроме первого
+	full, err := handlers.Store.GetQuizAttempt(attempt.ID)
+	assert.NoError(t, err)
+	var answers []models.QuizAnswer
+	for _, question := range full.Questions[1:] {
+		answer := models.QuizAnswer{QuestionID: question.ID}
+		ordered := make([]int, len(question.Options))
+		for _, option := range question.Options {
+			switch question.Type {
+			case models.QuestionShortAnswer:
+				answer.Text = " " + strings.ToLower(option.Text)
+			case models.QuestionOrdering:
+				ordered[option.Position-1] = option.ID
+			default:
+				if option.IsCorrect {
+					answer.OptionIDs = append(answer.OptionIDs, option.ID)
+				}
+			}
+		}
+		if question.Type == models.QuestionOrdering {
+			answer.OptionIDs = ordered
+		}
+		answers = append(answers, answer)
+	}
+
+	submitPath := fmt.Sprintf("/api/progress/2/quiz-attempts/%d/submit", attempt.ID)
+	resp, err = suite.client.R().
+		SetAuthToken(suite.token).
+		SetBody(models.QuizSubmission{Answers: answers}).
+		SetResult(&models.QuizAttempt{}).
+		Post(submitPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	result := resp.Result().(*models.QuizAttempt)
+	assert.Equal(t, models.QuizAttemptSubmitted, result.Status)
+	assert.Equal(t, 66.67, result.Score)
+	assert.Equal(t, 13.33, result.Points)
+	assert.False(t, result.IsPassed)
+
+	var isCorrect bool
+	var score float64
+	err = suite.db.QueryRow("SELECT is_correct, score FROM task_submissions WHERE id = ?", result.SubmissionID).Scan(&isCorrect, &score)
+	assert.NoError(t, err)
+	assert.False(t, isCorrect)
+	assert.Equal(t, 13.33, score)
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetBody(models.QuizSubmission{Answers: answers}).Post(submitPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode())
+
+	// Вторая попытка - последняя
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&models.QuizAttempt{}).Post(attemptsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	_, err = handlers.Store.SubmitQuizAttempt(resp.Result().(*models.QuizAttempt).ID, nil, time.Now())
+	assert.NoError(t, err)
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Post(attemptsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&[]models.QuizAttempt{}).Get(attemptsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	if attempts := *resp.Result().(*[]models.QuizAttempt); assert.Len(t, attempts, 2) {
+		assert.Equal(t, 66.67, attempts[0].Score)
+		assert.Zero(t, attempts[1].Score)
+	}
+}
+
+func (suite *FunctionalTestSuite) TestActivityHeatmap() {
+	t := suite.T()
+
+	var heatmap models.ActivityHeatmap
+	resp, err := suite.client.R().SetAuthToken(suite.token).SetResult(&heatmap).Get("/api/progress/2/activity")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "UTC", heatmap.Timezone)
+	if assert.Len(t, heatmap.Days, 365) {
+		today := heatmap.Days[364]
+		assert.Equal(t, time.Now().UTC().Format(models.DateLayout), today.Date)
+		assert.GreaterOrEqual(t, today.Completed, 2, "seeded progress is completed today")
+	}
+	assert.True(t, heatmap.Streak.ActiveToday)
+	assert.GreaterOrEqual(t, heatmap.Streak.Current, 1)
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).
+		SetQueryParams(map[string]string{"from": "2025-01-10", "to": "2025-01-01"}).
+		Get("/api/progress/2/activity")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Get("/api/progress/1/activity")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).
+		SetBody(models.UserPreferences{Timezone: "Mars/Olympus"}).
+		Put("/api/account/preferences")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).
+		SetBody(models.UserPreferences{Timezone: "Asia/Tokyo", StreakReminders: true}).
+		Put("/api/account/preferences")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	var prefs models.UserPreferences
+	_, err = suite.client.R().SetAuthToken(suite.token).SetResult(&prefs).Get("/api/account/preferences")
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserPreferences{Timezone: "Asia/Tokyo", StreakReminders: true}, prefs)
+
+	candidates, err := handlers.Store.GetStreakReminderCandidates()
+	assert.NoError(t, err)
+	if assert.Len(t, candidates, 1) {
+		assert.Equal(t, 2, candidates[0].UserID)
+		assert.NoError(t, handlers.Store.MarkStreakReminderSent(2, "2025-03-01"))
+	}
+
+	_, err = suite.client.R().SetAuthToken(suite.token).
+		SetQueryParams(map[string]string{"from": "2025-03-01", "to": "2025-03-07"}).
+		SetResult(&heatmap).Get("/api/progress/2/activity")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", heatmap.Timezone)
+	assert.Len(t, heatmap.Days, 7)
+
+	stats := models.UserStatistics{}
+	_, err = suite.client.R().SetAuthToken(suite.token).SetResult(&stats).Get("/api/analytics/users/2/statistics")
+	assert.NoError(t, err)
+	assert.Equal(t, heatmap.Streak.Longest, stats.Streak.Longest)
+
+	assert.NoError(t, handlers.Store.UpdateUserPreferences(2, models.UserPreferences{Timezone: models.DefaultTimezone}))
+}
+
+func (suite *FunctionalTestSuite) TestNotifications() {
+	t := suite.T()
+
+	_, err := suite.client.R().SetAuthToken(suite.token).Post("/api/notifications/read-all")
+	assert.NoError(t, err)
+
+	foreign, err := handlers.Store.CreateNotification(models.Notification{
+		UserID: 1, Type: models.NotificationRoleChanged, Title: "Role granted: admin", Message: "Granted",
+	})
+	assert.NoError(t, err)
+	for _, title := range []string{"First", "Second"} {
+		_, err := handlers.Store.CreateNotification(models.Notification{
+			UserID:  2,
+			Type:    models.NotificationGradingResult,
+			Title:   title,
+			Message: title + " result",
+			Payload: map[string]interface{}{"task_id": 1},
+		})
+		assert.NoError(t, err)
+	}
+
+	var unread models.UnreadNotificationsResponse
+	resp, err := suite.client.R().SetAuthToken(suite.token).SetResult(&unread).Get("/api/notifications/unread-count")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, 2, unread.UnreadCount)
+
+	var list []models.Notification
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&list).
+		SetQueryParams(map[string]string{"unread": "true", "limit": "1"}).
+		Get("/api/notifications")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "2", resp.Header().Get("X-Total-Count"))
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "Second", list[0].Title, "newest notifications come first")
+		assert.Equal(t, float64(1), list[0].Payload["task_id"])
+
+		var read models.Notification
+		resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&read).
+			Post(fmt.Sprintf("/api/notifications/%d/read", list[0].ID))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+		assert.True(t, read.IsRead)
+		assert.NotNil(t, read.ReadAt)
+	}
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Post(fmt.Sprintf("/api/notifications/%d/read", foreign.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+	var marked models.MarkNotificationsReadResponse
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&marked).Post("/api/notifications/read-all")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, 1, marked.Marked)
+
+	_, err = suite.client.R().SetAuthToken(suite.token).SetResult(&unread).Get("/api/notifications/unread-count")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, unread.UnreadCount)
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).
+		SetBody(models.NotificationPreferences{Email: map[string]bool{"newsletter": true}}).
+		Put("/api/notifications/preferences")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+
+	var prefs models.NotificationPreferences
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&prefs).
+		SetBody(models.NotificationPreferences{Email: map[string]bool{models.NotificationGradingResult: true, models.NotificationDeadline: false}}).
+		Put("/api/notifications/preferences")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.True(t, prefs.Email[models.NotificationGradingResult])
+	assert.False(t, prefs.Email[models.NotificationDeadline])
+	assert.True(t, prefs.Email[models.NotificationAccountStatus], "types without overrides keep defaults")
+	assert.Len(t, prefs.Email, len(models.DefaultEmailNotifications))
+
+	assert.NoError(t, handlers.Store.UpdateNotificationPreferences(2, models.NotificationPreferences{Email: models.DefaultEmailNotifications}))
+}
+
+func (suite *FunctionalTestSuite) TestLiveEventsFanOut() {
+	t := suite.T()
+
+	_, err := handlers.Store.AppendLiveEvent(models.LiveEvent{Type: models.EventProgress, UserID: 2})
+	assert.NoError(t, err)
+
+	publisher, err := events.NewStoreBroker(handlers.Store, time.Hour)
+	assert.NoError(t, err)
+	subscriber, err := events.NewStoreBroker(handlers.Store, time.Hour)
+	assert.NoError(t, err)
+	hub := events.NewHub(subscriber)
+	defer hub.Close()
+	stream, cancel := hub.Subscribe(2)
+	defer cancel()
+
+	assert.NoError(t, events.NewHub(publisher).Publish(models.LiveEvent{
+		Type: models.EventGrading, UserID: 2, Data: map[string]interface{}{"task_id": 1, "is_correct": true},
+	}))
+	assert.NoError(t, events.NewHub(publisher).Publish(models.LiveEvent{Type: models.EventGrading, UserID: 1}))
+
+	delivered, err := subscriber.Poll()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	if assert.Len(t, stream, 1, "events of other users are not delivered") {
+		event := <-stream
+		assert.Equal(t, models.EventGrading, event.Type)
+		assert.Equal(t, true, event.Data["is_correct"])
+		assert.False(t, event.CreatedAt.IsZero())
+	}
+
+	deleted, err := handlers.Store.DeleteLiveEventsBefore(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+	lastID, err := handlers.Store.GetLastLiveEventID()
+	assert.NoError(t, err)
+	assert.Zero(t, lastID)
+}
+
+func (suite *FunctionalTestSuite) TestSimilarityFlags() {
+	t := suite.T()
+	submittedAt := time.Now().UTC().Add(-time.Hour)
+
+	submit := func(userID int, answer string, isCorrect bool, at time.Time) int {
+		result, err := suite.db.Exec(`
+			INSERT INTO task_submissions (user_id, task_id, course_id, is_correct, score, submitted_at, answer)
+			VALUES (?, 2, 1, ?, 0, ?, ?)
+		`, userID, isCorrect, at, answer)
+		suite.Require().NoError(err)
+		id, _ := result.LastInsertId()
+		return int(id)
+	}
+	original := submit(1, `
+		rows, err := db.Query("SELECT id, name FROM users WHERE name = ? AND active = ?", name, true)
+		if err != nil {
+			return err
+		}`, false, submittedAt)
+	copied := submit(2, `
+		// my solution
+		result, e := conn.Query("SELECT id, name FROM users WHERE name = ? AND active = ?", userName, true)
+		if e != nil { return e }`, false, submittedAt.Add(10*time.Minute))
+
+	_, err := similarity.Check(handlers.Store, similarity.DefaultConfig)
+	assert.NoError(t, err)
+
+	adminToken := suite.signToken(1)
+	var flags []models.SimilarityFlag
+	resp, err := suite.client.R().SetAuthToken(adminToken).SetResult(&flags).
+		SetQueryParams(map[string]string{"kind": models.SimilarityKindAnswer, "status": models.SimilarityStatusPending}).
+		Get("/api/teacher/courses/1/similarity-flags")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	if assert.Len(t, flags, 1) {
+		flag := flags[0]
+		assert.Equal(t, copied, flag.SubmissionID)
+		assert.Equal(t, original, flag.OtherSubmissionID)
+		assert.Equal(t, "user123", flag.Username)
+		assert.Equal(t, "admin", flag.OtherUsername)
+		assert.Equal(t, "Advanced SQL Injection", flag.TaskTitle)
+		assert.Equal(t, 600, flag.TimeGapSeconds)
+		assert.GreaterOrEqual(t, flag.Score, similarity.DefaultConfig.Threshold)
+		assert.Contains(t, flag.Answer, "userName")
+
+		var reviewed models.SimilarityFlag
+		resp, err = suite.client.R().SetAuthToken(adminToken).SetResult(&reviewed).
+			SetBody(models.SimilarityReview{Status: models.SimilarityStatusConfirmed, Note: "Same query and condition order"}).
+			Put(fmt.Sprintf("/api/teacher/similarity-flags/%d", flag.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+		assert.Equal(t, models.SimilarityStatusConfirmed, reviewed.Status)
+		if assert.NotNil(t, reviewed.ReviewedBy) {
+			assert.Equal(t, 1, *reviewed.ReviewedBy)
+		}
+	}
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Get("/api/teacher/courses/1/similarity-flags")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	flagged, err := similarity.Check(handlers.Store, similarity.DefaultConfig)
+	assert.NoError(t, err)
+	assert.Zero(t, flagged, "checked submissions are not compared again")
+
+	_, err = suite.db.Exec("DELETE FROM similarity_flags")
+	assert.NoError(t, err)
+	_, err = suite.db.Exec("DELETE FROM task_submissions WHERE id IN (?, ?)", original, copied)
+	assert.NoError(t, err)
+}
@@ -2,8 +2,13 @@ package ft
 
 import (
 	"github.com/stretchr/testify/assert"
+	"lmsmodule/backend-svc/certificate"
+	"lmsmodule/backend-svc/handlers"
 	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
 	"net/http"
+	"strings"
+	"time"
 )
 
 func (suite *FunctionalTestSuite) TestGetUserProfile() {
@@ -58,6 +63,24 @@ func (suite *FunctionalTestSuite) TestGetUserSubmissions() {
 	assert.GreaterOrEqual(t, len(*submissions), 2)
 }
 
+func (suite *FunctionalTestSuite) TestGetUserSubmissionsFiltered() {
+	t := suite.T()
+
+	resp, err := suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&[]models.TaskSubmissionDetails{}).
+		SetQueryParams(map[string]string{"course_id": "1", "limit": "1", "sort": "task_id"}).
+		Get("/api/progress/2/submissions")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "2", resp.Header().Get("X-Total-Count"))
+
+	submissions := resp.Result().(*[]models.TaskSubmissionDetails)
+	assert.Len(t, *submissions, 1)
+	assert.Equal(t, 1, (*submissions)[0].TaskID)
+}
+
 func (suite *FunctionalTestSuite) TestGetUserLearningPath() {
 	t := suite.T()
 
@@ -72,4 +95,197 @@ func (suite *FunctionalTestSuite) TestGetUserLearningPath() {
 	learningPath := resp.Result().(*models.LearningPath)
 	assert.Equal(t, 2, learningPath.UserID)
 	assert.NotEmpty(t, learningPath.Recommendations)
+	assert.NotEmpty(t, learningPath.Skills)
+	for _, task := range learningPath.NextTasks {
+		assert.NotContains(t, []int{1, 2}, task.TaskID, "completed tasks are not recommended")
+	}
+}
+
+func (suite *FunctionalTestSuite) TestLearningPathUsesTaskSkills() {
+	t := suite.T()
+
+	// Эндпоинт настройки навыков доступен только преподавателям, поэтому SQL проверяем через хранилище
+	err := handlers.Store.SetTaskSkills(3, 4, models.TaskSkillsRequest{
+		Skills:        []models.TaskSkill{{Skill: "Session management", CWE: "CWE-352", Weight: 1}},
+		Prerequisites: []int{3},
+	})
+	assert.NoError(t, err)
+	defer handlers.Store.SetTaskSkills(3, 4, models.TaskSkillsRequest{})
+
+	err = handlers.Store.SetTaskSkills(2, 3, models.TaskSkillsRequest{Prerequisites: []int{4}})
+	assert.ErrorIs(t, err, storage.ErrPrerequisiteCycle)
+
+	resp, err := suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&models.LearningPath{}).
+		Get("/api/progress/2/learning-path?strategy=sequential")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	learningPath := resp.Result().(*models.LearningPath)
+	var skills []string
+	for _, skill := range learningPath.Skills {
+		skills = append(skills, skill.SkillName)
+	}
+	assert.Contains(t, skills, "Session management (CWE-352)")
+	assert.Contains(t, skills, "SQL Injection (CWE-89)")
+	for _, task := range learningPath.NextTasks {
+		assert.NotEqual(t, 4, task.TaskID, "task 4 waits for its prerequisite")
+	}
+
+	resp, err = suite.client.R().
+		SetAuthToken(suite.token).
+		Get("/api/progress/2/learning-path?strategy=random")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+}
+
+func (suite *FunctionalTestSuite) TestCertificates() {
+	t := suite.T()
+
+	var certificates []models.Certificate
+	resp, err := suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&certificates).
+		Post("/api/progress/2/certificates")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	if !assert.Len(t, certificates, 1, "only course 1 is completed") {
+		return
+	}
+	cert := certificates[0]
+	assert.Equal(t, "SQL Injection", cert.CourseTitle)
+	assert.Equal(t, "Regular User", cert.FullName)
+	assert.True(t, cert.Score > 0 && cert.Score <= 100)
+
+	resp, err = suite.client.R().
+		SetAuthToken(suite.token).
+		SetResult(&certificates).
+		Post("/api/progress/2/certificates")
+	assert.NoError(t, err)
+	assert.Len(t, certificates, 1, "certificate is issued once")
+
+	user := models.User{}
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&user).Get("/api/profile")
+	assert.NoError(t, err)
+	assert.Len(t, user.Certificates, 1)
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Get("/api/progress/1/certificates")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Get("/api/certificates/" + cert.ID + "?format=pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "application/pdf", resp.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(resp.String(), "%PDF-1.4"))
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Get("/api/certificates/" + cert.ID + "?format=svg")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Contains(t, resp.String(), "Regular User")
+
+	verify := func(query map[string]string) models.CertificateVerification {
+		var verification models.CertificateVerification
+		resp, err := suite.client.R().SetQueryParams(query).SetResult(&verification).Get("/api/certificates/verify")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+		return verification
+	}
+
+	verification := verify(map[string]string{"id": strings.ToLower(cert.ID)})
+	assert.True(t, verification.Valid)
+	assert.Zero(t, verification.Certificate.UserID, "public verification hides the user")
+
+	verification = verify(map[string]string{"code": certificate.VerificationURL(handlers.CertificateVerifyURL, cert)})
+	assert.Equal(t, models.CertificateValid, verification.Status)
+
+	verification = verify(map[string]string{"code": cert.ID + ".forged"})
+	assert.Equal(t, models.CertificateInvalidSignature, verification.Status)
+
+	resp, err = suite.client.R().SetQueryParam("id", "LMS-0000-0000-0000").Get("/api/certificates/verify")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+	_, err = handlers.Store.RevokeCertificate(cert.ID, "Plagiarism", time.Now())
+	assert.NoError(t, err)
+	_, err = handlers.Store.RevokeCertificate(cert.ID, "Plagiarism", time.Now())
+	assert.ErrorIs(t, err, storage.ErrCertificateRevoked)
+
+	verification = verify(map[string]string{"id": cert.ID})
+	assert.False(t, verification.Valid)
+	assert.Equal(t, models.CertificateRevoked, verification.Status)
+	assert.Equal(t, "Plagiarism", verification.Certificate.RevokeReason)
+}
+
+func (suite *FunctionalTestSuite) TestBadges() {
+	t := suite.T()
+	adminToken := suite.signToken(1)
+
+	var badges []models.Badge
+	resp, err := suite.client.R().SetAuthToken(adminToken).SetResult(&badges).Get("/api/admin/badges")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Len(t, badges, 5, "default badges are seeded by the migration")
+
+	resp, err = suite.client.R().SetAuthToken(suite.token).Get("/api/admin/badges")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(adminToken).
+		SetBody(models.Badge{Code: "sqli", Name: "SQLi", Criteria: models.BadgeCriteria{Type: "unknown"}}).
+		Post("/api/admin/badges")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+
+	resp, err = suite.client.R().SetAuthToken(adminToken).
+		SetBody(models.Badge{Code: "first_task", Name: "Duplicate", Criteria: models.BadgeCriteria{Type: "tasks_completed", Threshold: 1}}).
+		Post("/api/admin/badges")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode())
+
+	var created models.Badge
+	resp, err = suite.client.R().SetAuthToken(adminToken).SetResult(&created).
+		SetBody(models.Badge{Code: "sqli", Name: "SQL Injection", IsActive: true, Criteria: models.BadgeCriteria{Type: "courses_completed", Threshold: 1, CourseID: 1}}).
+		Post("/api/admin/badges")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode())
+	assert.Equal(t, 1, created.Criteria.CourseID, "criteria are stored as JSON")
+
+	var result models.BadgeEvaluationResult
+	resp, err = suite.client.R().SetAuthToken(adminToken).SetResult(&result).Post("/api/admin/badges/evaluate")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, 2, result.EvaluatedUsers)
+
+	var awarded []models.UserBadge
+	resp, err = suite.client.R().SetAuthToken(suite.token).SetResult(&awarded).Get("/api/progress/2/badges")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	codes := map[string]bool{}
+	for _, badge := range awarded {
+		codes[badge.Code] = true
+	}
+	for _, code := range []string{"first_task", "course_finished", "top_10", "sqli"} {
+		assert.True(t, codes[code], "existing progress earns %s", code)
+	}
+
+	resp, err = suite.client.R().SetAuthToken(adminToken).SetResult(&result).Post("/api/admin/badges/evaluate")
+	assert.NoError(t, err)
+	assert.Zero(t, result.AwardedBadges, "badges are awarded once")
+
+	user := models.User{}
+	_, err = suite.client.R().SetAuthToken(suite.token).SetResult(&user).Get("/api/profile")
+	assert.NoError(t, err)
+	assert.Len(t, user.Badges, len(awarded))
+
+	stats := models.UserStatistics{}
+	_, err = suite.client.R().SetAuthToken(suite.token).SetResult(&stats).Get("/api/analytics/users/2/statistics")
+	assert.NoError(t, err)
+	assert.Len(t, stats.Badges, len(awarded))
+
+	err = handlers.Store.DeleteBadge(created.ID)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, handlers.Store.DeleteBadge(created.ID), storage.ErrBadgeNotFound)
 }
@@ -0,0 +1,88 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordLearningActivity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockStorage := new(storage.MockStorage)
+	handlers.Store = mockStorage
+
+	router.POST("/activity", func(c *gin.Context) {
+		c.Set("userID", 2)
+		handlers.RecordLearningActivity(c)
+	})
+
+	post := func(events []models.LearningActivity) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.ActivityBatchRequest{Events: events})
+		req, _ := http.NewRequest("POST", "/activity", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Aggregates batch per task", func(t *testing.T) {
+		w := post([]models.LearningActivity{
+			{TaskID: 4, ActivityType: models.ActivityTaskOpened},
+			{TaskID: 4, ActivityType: models.ActivityTimeOnTask, Duration: 90},
+			{TaskID: 4, ActivityType: models.ActivityTimeOnTask, Duration: 30},
+			{TaskID: 4, ActivityType: models.ActivitySubmission, Duration: 500},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		summaries, err := mockStorage.GetUserActivitySummary(2)
+		assert.NoError(t, err)
+
+		var summary models.TaskActivitySummary
+		for _, s := range summaries {
+			if s.TaskID == 4 {
+				summary = s
+			}
+		}
+		assert.Equal(t, 3, summary.CourseID)
+		assert.Equal(t, 120, summary.TimeSpentSeconds)
+		assert.Equal(t, 1, summary.OpenedCount)
+		assert.Equal(t, 1, summary.Submissions)
+		assert.WithinDuration(t, time.Now(), summary.LastActivityAt, time.Minute)
+	})
+
+	t.Run("Statistics use time on task", func(t *testing.T) {
+		stats, err := mockStorage.GetCourseStatistics(3, models.ListParams{})
+		assert.NoError(t, err)
+		assert.Equal(t, "2m 00s", stats.AverageTimeSpent)
+
+		userStats, err := mockStorage.GetUserStatistics(2)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), userStats.LastActive, time.Minute)
+	})
+
+	t.Run("Rejects invalid batches", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		for name, events := range map[string][]models.LearningActivity{
+			"empty":          {},
+			"unknown type":   {{TaskID: 1, ActivityType: "scrolled"}},
+			"missing task":   {{ActivityType: models.ActivityTaskOpened}},
+			"zero duration":  {{TaskID: 1, ActivityType: models.ActivityTimeOnTask}},
+			"future":         {{TaskID: 1, ActivityType: models.ActivityTaskOpened, Timestamp: future}},
+			"unknown task":   {{TaskID: 999, ActivityType: models.ActivityTaskOpened}},
+			"course differs": {{TaskID: 1, CourseID: 2, ActivityType: models.ActivityTaskOpened}},
+		} {
+			w := post(events)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/reminders"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskDeadline(t *testing.T) {
+	due := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	deadline := models.TaskDeadline{
+		OpensAt:    due.AddDate(0, 0, -7),
+		DueAt:      due,
+		LatePolicy: models.LatePolicy{PenaltyPerDay: 15, MaxPenalty: 40, GraceMinutes: 30, CloseAfterDays: 5},
+	}
+
+	tests := []struct {
+		name    string
+		at      time.Time
+		status  string
+		penalty float64
+	}{
+		{"before opening", due.AddDate(0, 0, -8), models.DeadlineNotOpen, 0},
+		{"on time", due.Add(-time.Hour), models.DeadlineOpen, 0},
+		{"within grace period", due.Add(20 * time.Minute), models.DeadlineOpen, 0},
+		{"first day late", due.Add(2 * time.Hour), models.DeadlineLate, 15},
+		{"second day late", due.Add(30 * time.Hour), models.DeadlineLate, 30},
+		{"penalty is capped", due.AddDate(0, 0, 4), models.DeadlineLate, 40},
+		{"closed", due.AddDate(0, 0, 6), models.DeadlineClosed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, deadline.Status(tt.at))
+			assert.Equal(t, tt.penalty, deadline.Penalty(tt.at))
+		})
+	}
+
+	t.Run("Latest deadline wins and extensions apply", func(t *testing.T) {
+		assignments := []models.Assignment{
+			{ID: 1, CourseID: 1, Title: "Week 1", TaskIDs: []int{1, 2}, DueAt: due},
+			{ID: 2, CourseID: 1, Title: "Retake", TaskIDs: []int{2}, DueAt: due.AddDate(0, 0, 7)},
+		}
+		deadlines := models.ResolveDeadlines(assignments, map[int]time.Time{1: due.AddDate(0, 0, 2)})
+
+		if assert.Len(t, deadlines, 2) {
+			assert.Equal(t, 1, deadlines[0].TaskID)
+			assert.True(t, deadlines[0].Extended)
+			assert.Equal(t, due.AddDate(0, 0, 2), deadlines[0].DueAt)
+			assert.Equal(t, 2, deadlines[1].AssignmentID)
+			assert.False(t, deadlines[1].Extended)
+		}
+	})
+}
+
+func TestAssignmentHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	asUser := func(userID int, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", userID)
+			handler(c)
+		}
+	}
+	router.POST("/courses/:course_id/assignments", asUser(1, handlers.CreateAssignment))
+	router.GET("/courses/:course_id/assignments", asUser(1, handlers.GetCourseAssignments))
+	router.PUT("/courses/:course_id/assignments/:assignment_id", asUser(1, handlers.UpdateAssignment))
+	router.DELETE("/courses/:course_id/assignments/:assignment_id", asUser(1, handlers.DeleteAssignment))
+	router.PUT("/courses/:course_id/assignments/:assignment_id/extensions/:user_id", asUser(1, handlers.GrantExtension))
+	router.POST("/progress/:user_id/tasks/:task_id/submit", asUser(1, handlers.SubmitTaskWithAnswer))
+	router.POST("/progress/:user_id/tasks/:task_id/complete", asUser(1, handlers.CompleteTask))
+	router.GET("/progress/:user_id/deadlines", asUser(2, handlers.GetUserDeadlines))
+	router.GET("/admin/progress/:user_id/deadlines", asUser(1, handlers.GetUserDeadlines))
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	now := time.Now().UTC()
+	assignment := models.Assignment{
+		Title:   "SQL injection homework",
+		TaskIDs: []int{2},
+		OpensAt: now.Add(24 * time.Hour),
+		DueAt:   now.Add(72 * time.Hour),
+	}
+
+	t.Run("Validates assignments", func(t *testing.T) {
+		invalid := assignment
+		invalid.DueAt = invalid.OpensAt.Add(-time.Hour)
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/courses/1/assignments", invalid).Code, "opens after due")
+
+		invalid = assignment
+		invalid.TaskIDs = []int{3}
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/courses/1/assignments", invalid).Code, "task of another course")
+
+		invalid = assignment
+		invalid.LatePolicy.PenaltyPerDay = 150
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/courses/1/assignments", invalid).Code, "penalty over 100%")
+
+		assert.Equal(t, http.StatusNotFound, request("POST", "/courses/999/assignments", assignment).Code)
+	})
+
+	w := request("POST", "/courses/1/assignments", assignment)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created models.Assignment
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, 1, created.CourseID)
+	assert.Equal(t, 1, created.CreatedBy)
+	assignmentPath := fmt.Sprintf("/courses/1/assignments/%d", created.ID)
+
+	t.Run("Rejects submissions before opening", func(t *testing.T) {
+		w := request("POST", "/progress/1/tasks/2/submit", models.TaskSubmission{CourseID: 1, Answer: "wrong"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = request("POST", "/progress/1/tasks/2/complete", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code, "assignment task is not completed without a graded answer")
+	})
+
+	t.Run("Applies late penalty", func(t *testing.T) {
+		overdue := assignment
+		overdue.OpensAt = now.Add(-72 * time.Hour)
+		overdue.DueAt = now.Add(-12 * time.Hour)
+		overdue.LatePolicy = models.LatePolicy{PenaltyPerDay: 10}
+		assert.Equal(t, http.StatusOK, request("PUT", assignmentPath, overdue).Code)
+
+		w := request("POST", "/progress/1/tasks/2/submit", models.TaskSubmission{CourseID: 1, Answer: "wrong"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var result models.TaskSubmissionResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.True(t, result.IsLate)
+		assert.Equal(t, 10.0, result.Penalty)
+		assert.NotNil(t, result.DueDate)
+	})
+
+	t.Run("Grants extensions", func(t *testing.T) {
+		w := request("PUT", assignmentPath+"/extensions/1", models.AssignmentExtension{DueAt: now.Add(-24 * time.Hour)})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "earlier than due date")
+
+		w = request("PUT", assignmentPath+"/extensions/999", models.AssignmentExtension{DueAt: now.Add(time.Hour)})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = request("PUT", assignmentPath+"/extensions/1", models.AssignmentExtension{DueAt: now.Add(6 * time.Hour), Reason: " illness "})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = request("GET", "/admin/progress/1/deadlines", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var deadlines []models.TaskDeadline
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &deadlines))
+		if assert.Len(t, deadlines, 1) {
+			assert.True(t, deadlines[0].Extended)
+			assert.WithinDuration(t, now.Add(6*time.Hour), deadlines[0].DueAt, time.Second)
+		}
+
+		assert.Equal(t, http.StatusForbidden, request("GET", "/progress/1/deadlines", nil).Code)
+	})
+
+	t.Run("Sends each reminder once", func(t *testing.T) {
+		var sent []models.DeadlineReminder
+		sender := func(reminder models.DeadlineReminder) error {
+			sent = append(sent, reminder)
+			return nil
+		}
+
+		count, err := reminders.SendDue(handlers.Store, now, 24*time.Hour, sender)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		if assert.Len(t, sent, 1) {
+			assert.Equal(t, 1, sent[0].UserID)
+			assert.Equal(t, "SQL Injection", sent[0].CourseName)
+			assert.Equal(t, 1, sent[0].RemainingTasks)
+		}
+
+		count, err = reminders.SendDue(handlers.Store, now, 24*time.Hour, sender)
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Deletes assignments", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("DELETE", assignmentPath, nil).Code)
+		assert.Equal(t, http.StatusNotFound, request("DELETE", assignmentPath, nil).Code)
+
+		w := request("GET", "/courses/1/assignments", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String())
+	})
+}
@@ -11,7 +11,9 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTestRouter() *gin.Engine {
@@ -35,6 +37,33 @@ func TestRegisterHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("Token carries roles", func(t *testing.T) {
+		router := setupTestRouter()
+		router.POST("/register", handlers.RegisterHandler)
+
+		body, _ := json.Marshal(models.RegisterRequest{
+			Username:  "roles_teacher",
+			Password:  "password123",
+			Email:     "roles_teacher@example.com",
+			FullName:  "Roles Teacher",
+			IsTeacher: true,
+		})
+		req, _ := http.NewRequest("POST", "/register", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var resp models.RegisterResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) {
+			return []byte(handlers.JWTSecret), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"teacher"}, claims["roles"])
+		assert.NotNil(t, claims["sub"])
+	})
 }
 
 func TestLoginHandler(t *testing.T) {
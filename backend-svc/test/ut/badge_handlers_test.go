@@ -0,0 +1,235 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"lmsmodule/backend-svc/achievements"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAchievementCriteria(t *testing.T) {
+	day := func(d, hour int) time.Time { return time.Date(2025, 3, d, hour, 0, 0, 0, time.UTC) }
+	task := func(id, courseID int, difficulty string) models.LearningTask {
+		return models.LearningTask{Task: models.Task{ID: id, CourseID: courseID, Difficulty: difficulty}}
+	}
+	tasks := []models.LearningTask{task(1, 1, "easy"), task(2, 1, "hard"), task(3, 2, "hard")}
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name     string
+		criteria models.BadgeCriteria
+		facts    achievements.Facts
+		achieved bool
+		at       time.Time
+	}{
+		{
+			name:     "First task",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionTasksCompleted, Threshold: 1},
+			facts:    achievements.Facts{Snapshot: models.LearningSnapshot{Tasks: tasks, Completed: map[int]time.Time{2: day(5, 10), 1: day(3, 10)}}},
+			achieved: true,
+			at:       day(3, 10),
+		},
+		{
+			name:     "Tasks filtered by difficulty",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionTasksCompleted, Threshold: 2, Difficulty: "hard"},
+			facts:    achievements.Facts{Snapshot: models.LearningSnapshot{Tasks: tasks, Completed: map[int]time.Time{1: day(3, 10), 2: day(5, 10)}}},
+		},
+		{
+			name:     "Course finished",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionCoursesCompleted, Threshold: 1},
+			facts: achievements.Facts{Completions: []models.CourseCompletion{
+				{CourseID: 1, IsCompleted: true, CompletedAt: &[]time.Time{day(5, 10)}[0]},
+				{CourseID: 2, CompletedTasks: 1},
+			}},
+			achieved: true,
+			at:       day(5, 10),
+		},
+		{
+			name:     "Streak counts completions and attempts",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionStreakDays, Threshold: 3},
+			facts: achievements.Facts{Snapshot: models.LearningSnapshot{
+				Completed: map[int]time.Time{1: day(1, 10), 2: day(3, 9)},
+				Attempts:  []models.SubmissionAttempt{{SubmittedAt: day(2, 23)}, {SubmittedAt: day(3, 8)}},
+			}},
+			achieved: true,
+			at:       day(3, 8),
+		},
+		{
+			name:     "Streak breaks on a missed day",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionStreakDays, Threshold: 3},
+			facts:    achievements.Facts{Snapshot: models.LearningSnapshot{Completed: map[int]time.Time{1: day(1, 10), 2: day(2, 10), 3: day(4, 10)}}},
+		},
+		{
+			name:     "Streak days follow the user time zone",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionStreakDays, Threshold: 2},
+			facts: achievements.Facts{
+				Snapshot: models.LearningSnapshot{Completed: map[int]time.Time{1: day(1, 10), 2: day(1, 22)}},
+				Location: moscow,
+			},
+			achieved: true,
+			at:       day(1, 22),
+		},
+		{
+			name:     "Hard tasks without hints",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionTasksWithoutHints},
+			facts: achievements.Facts{Snapshot: models.LearningSnapshot{
+				Tasks:     tasks,
+				Completed: map[int]time.Time{2: day(4, 10), 3: day(6, 10)},
+				Activity:  []models.TaskActivitySummary{{TaskID: 1, HintsViewed: 3}},
+			}},
+			achieved: true,
+			at:       day(6, 10),
+		},
+		{
+			name:     "Hint viewed on a hard task",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionTasksWithoutHints, CourseID: 2},
+			facts: achievements.Facts{Snapshot: models.LearningSnapshot{
+				Tasks:     tasks,
+				Completed: map[int]time.Time{3: day(6, 10)},
+				Activity:  []models.TaskActivitySummary{{TaskID: 3, HintsViewed: 1}},
+			}},
+		},
+		{
+			name:     "Top ten",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionLeaderboardRank, Threshold: 10},
+			facts:    achievements.Facts{Ranks: map[int]int{0: 4}, Now: day(9, 12)},
+			achieved: true,
+			at:       day(9, 12),
+		},
+		{
+			name:     "Outside top ten",
+			criteria: models.BadgeCriteria{Type: achievements.CriterionLeaderboardRank, Threshold: 10, CourseID: 1},
+			facts:    achievements.Facts{Ranks: map[int]int{0: 4, 1: 11}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, achievements.Validate(tt.criteria))
+			awarded := achievements.Evaluate([]models.Badge{{ID: 1, Code: "badge", IsActive: true, Criteria: tt.criteria}}, tt.facts)
+			if !tt.achieved {
+				assert.Empty(t, awarded)
+				return
+			}
+			if assert.Len(t, awarded, 1) {
+				assert.True(t, tt.at.Equal(awarded[0].AwardedAt), "awarded at %v, expected %v", awarded[0].AwardedAt, tt.at)
+			}
+		})
+	}
+
+	t.Run("Validates criteria", func(t *testing.T) {
+		assert.Error(t, achievements.Validate(models.BadgeCriteria{Type: "unknown"}))
+		assert.Error(t, achievements.Validate(models.BadgeCriteria{Type: achievements.CriterionStreakDays}))
+		assert.Error(t, achievements.Validate(models.BadgeCriteria{Type: achievements.CriterionTasksWithoutHints, Difficulty: "extreme"}))
+	})
+
+	t.Run("Skips inactive badges", func(t *testing.T) {
+		badge := models.Badge{ID: 1, Criteria: models.BadgeCriteria{Type: achievements.CriterionLeaderboardRank, Threshold: 10}}
+		assert.Empty(t, achievements.Evaluate([]models.Badge{badge}, achievements.Facts{Ranks: map[int]int{0: 1}}))
+		assert.Empty(t, achievements.LeaderboardLimits([]models.Badge{badge}))
+	})
+}
+
+func TestBadgeHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	asUser := func(userID int, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", userID)
+			handler(c)
+		}
+	}
+	router.GET("/admin/badges", asUser(1, handlers.GetBadges))
+	router.POST("/admin/badges", asUser(1, handlers.CreateBadge))
+	router.POST("/admin/badges/evaluate", asUser(1, handlers.EvaluateBadges))
+	router.PUT("/admin/badges/:badge_id", asUser(1, handlers.UpdateBadge))
+	router.DELETE("/admin/badges/:badge_id", asUser(1, handlers.DeleteBadge))
+	router.GET("/progress/:user_id/badges", asUser(2, handlers.GetUserBadges))
+	router.GET("/admin/progress/:user_id/badges", asUser(1, handlers.GetUserBadges))
+	router.GET("/profile", asUser(2, handlers.GetUserProfile))
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Configures badges", func(t *testing.T) {
+		invalid := models.Badge{Code: "streak", Name: "Streak", Criteria: models.BadgeCriteria{Type: achievements.CriterionStreakDays}}
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/admin/badges", invalid).Code)
+
+		duplicate := models.Badge{Code: " first_task ", Name: "First", Criteria: models.BadgeCriteria{Type: achievements.CriterionTasksCompleted, Threshold: 1}}
+		assert.Equal(t, http.StatusConflict, request("POST", "/admin/badges", duplicate).Code)
+
+		badge := models.Badge{Code: "xss", Name: "XSS", Criteria: models.BadgeCriteria{Type: achievements.CriterionCoursesCompleted, Threshold: 1, CourseID: 2}}
+		w := request("POST", "/admin/badges", badge)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &badge))
+
+		badge.IsActive = true
+		assert.Equal(t, http.StatusOK, request("PUT", "/admin/badges/"+strconv.Itoa(badge.ID), badge).Code)
+		assert.Equal(t, http.StatusNotFound, request("PUT", "/admin/badges/999", badge).Code)
+
+		assert.Equal(t, http.StatusOK, request("DELETE", "/admin/badges/"+strconv.Itoa(badge.ID), nil).Code)
+		assert.Equal(t, http.StatusNotFound, request("DELETE", "/admin/badges/"+strconv.Itoa(badge.ID), nil).Code)
+	})
+
+	t.Run("Evaluates existing progress", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/admin/badges/evaluate?user_id=abc", nil).Code)
+
+		w := request("POST", "/admin/badges/evaluate?user_id=2", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.BadgeEvaluationResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 1, result.EvaluatedUsers)
+
+		var badges []models.UserBadge
+		w = request("GET", "/progress/2/badges", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &badges))
+		codes := map[string]bool{}
+		for _, badge := range badges {
+			codes[badge.Code] = true
+		}
+		assert.True(t, codes["first_task"])
+		assert.True(t, codes["course_finished"], "user 2 completed course 1")
+		assert.True(t, codes["top_10"])
+		assert.False(t, codes["streak_7"])
+
+		w = request("POST", "/admin/badges/evaluate", nil)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.True(t, result.EvaluatedUsers >= 2)
+
+		w = request("POST", "/admin/badges/evaluate?user_id=2", nil)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Zero(t, result.AwardedBadges, "badges are awarded once")
+
+		var user models.User
+		w = request("GET", "/profile", nil)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+		assert.Len(t, user.Badges, len(badges))
+	})
+
+	t.Run("Restricts access to user badges", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("GET", "/progress/1/badges", nil).Code)
+		assert.Equal(t, http.StatusOK, request("GET", "/admin/progress/2/badges", nil).Code)
+	})
+}
@@ -0,0 +1,184 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"lmsmodule/backend-svc/certificate"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateSigning(t *testing.T) {
+	signer, err := certificate.NewSigner(bytes.Repeat([]byte{7}, 32))
+	assert.NoError(t, err)
+	_, err = certificate.ParseSigner("c2hvcnQ=")
+	assert.ErrorIs(t, err, certificate.ErrInvalidKey)
+
+	id, err := certificate.NewID()
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^LMS-[0-9A-Z]{4}-[0-9A-Z]{4}-[0-9A-Z]{4}$`), id)
+
+	cert := models.Certificate{
+		ID:          id,
+		FullName:    "Регина Борисова",
+		CourseID:    1,
+		CourseTitle: "SQL Injection",
+		Score:       92.5,
+		CompletedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		IssuedAt:    time.Date(2025, 3, 2, 10, 0, 0, 0, time.UTC),
+	}
+	cert.Signature = signer.Sign(cert)
+	assert.True(t, signer.Verify(cert))
+
+	local := cert
+	local.CompletedAt = cert.CompletedAt.In(time.FixedZone("MSK", 3*60*60))
+	assert.True(t, signer.Verify(local), "time zone does not change the payload")
+
+	tampered := cert
+	tampered.Score = 100
+	assert.False(t, signer.Verify(tampered))
+	other, err := certificate.GenerateSigner()
+	assert.NoError(t, err)
+	assert.False(t, other.Verify(cert))
+
+	t.Run("Parses verification codes", func(t *testing.T) {
+		url := certificate.VerificationURL("https://lms.example.com/api/certificates/verify", cert)
+		for _, code := range []string{url, certificate.Code(cert), " " + certificate.Code(cert) + " "} {
+			parsedID, signature, err := certificate.ParseCode(code)
+			assert.NoError(t, err)
+			assert.Equal(t, cert.ID, parsedID)
+			assert.Equal(t, cert.Signature, signature)
+		}
+
+		for _, code := range []string{cert.ID, "." + cert.Signature, "https://lms.example.com/verify?id=" + cert.ID} {
+			_, _, err := certificate.ParseCode(code)
+			assert.ErrorIs(t, err, certificate.ErrInvalidCode, code)
+		}
+	})
+
+	t.Run("Renders documents", func(t *testing.T) {
+		var svg, pdf bytes.Buffer
+		assert.NoError(t, certificate.WriteSVG(&svg, cert, "https://lms.example.com/verify"))
+		assert.Contains(t, svg.String(), "Регина Борисова")
+		assert.Contains(t, svg.String(), "Score: 92.5%")
+
+		assert.NoError(t, certificate.WritePDF(&pdf, cert, "https://lms.example.com/verify"))
+		assert.True(t, strings.HasPrefix(pdf.String(), "%PDF-1.4"))
+		assert.Contains(t, pdf.String(), "(Regina Borisova) Tj", "cyrillic is transliterated for standard fonts")
+		assert.True(t, strings.HasSuffix(pdf.String(), "%%EOF\n"))
+	})
+}
+
+func TestCertificateHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	asUser := func(userID int, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", userID)
+			handler(c)
+		}
+	}
+	router.POST("/progress/:user_id/certificates", asUser(2, handlers.IssueCertificates))
+	router.GET("/progress/:user_id/certificates", asUser(2, handlers.GetUserCertificates))
+	router.GET("/certificates/verify", handlers.VerifyCertificate)
+	router.GET("/certificates/public-key", handlers.GetCertificatePublicKey)
+	router.GET("/certificates/:certificate_id", asUser(2, handlers.DownloadCertificate))
+	router.GET("/admin/certificates/:certificate_id", asUser(1, handlers.DownloadCertificate))
+	router.POST("/admin/certificates/:certificate_id/revoke", asUser(1, handlers.RevokeCertificate))
+	router.GET("/profile", asUser(2, handlers.GetUserProfile))
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, request("POST", "/progress/1/certificates", nil).Code)
+
+	w := request("POST", "/progress/2/certificates", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var certificates []models.Certificate
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &certificates))
+	if !assert.Len(t, certificates, 1, "user 2 completed course 1 before certificates existed") {
+		return
+	}
+	cert := certificates[0]
+	assert.Equal(t, 1, cert.CourseID)
+	assert.Equal(t, "Regular User", cert.FullName)
+
+	t.Run("Lists certificates in profile", func(t *testing.T) {
+		w := request("GET", "/profile", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var user models.User
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+		assert.Len(t, user.Certificates, 1)
+
+		w = request("POST", "/progress/2/certificates", nil)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &certificates))
+		assert.Len(t, certificates, 1, "repeated request does not issue a new certificate")
+	})
+
+	t.Run("Downloads certificate", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/certificates/"+cert.ID+"?format=docx", nil).Code)
+		assert.Equal(t, http.StatusNotFound, request("GET", "/certificates/LMS-0000-0000-0000", nil).Code)
+
+		w := request("GET", "/certificates/"+strings.ToLower(cert.ID)+"?format=svg", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), cert.ID+".svg")
+
+		assert.Equal(t, http.StatusOK, request("GET", "/admin/certificates/"+cert.ID, nil).Code, "admin sees any certificate")
+	})
+
+	t.Run("Verifies and revokes certificate", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/certificates/verify", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/certificates/verify?code=garbage", nil).Code)
+
+		verify := func(query string) models.CertificateVerification {
+			w := request("GET", "/certificates/verify?"+query, nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var verification models.CertificateVerification
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &verification))
+			return verification
+		}
+		assert.True(t, verify("code="+certificate.Code(cert)).Valid)
+
+		w := request("GET", "/certificates/public-key", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var key models.CertificatePublicKey
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &key))
+		assert.Equal(t, certificate.Algorithm, key.Algorithm)
+
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/admin/certificates/"+cert.ID+"/revoke", models.RevokeCertificateRequest{Reason: " "}).Code)
+		assert.Equal(t, http.StatusOK, request("POST", "/admin/certificates/"+cert.ID+"/revoke", models.RevokeCertificateRequest{Reason: "Shared answers"}).Code)
+		assert.Equal(t, http.StatusConflict, request("POST", "/admin/certificates/"+cert.ID+"/revoke", models.RevokeCertificateRequest{Reason: "Shared answers"}).Code)
+
+		verification := verify("id=" + cert.ID)
+		assert.False(t, verification.Valid)
+		assert.Equal(t, models.CertificateRevoked, verification.Status)
+
+		w = request("POST", "/progress/2/certificates", nil)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &certificates))
+		if assert.Len(t, certificates, 1, "revoked certificate is not reissued") {
+			assert.True(t, certificates[0].IsRevoked())
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCohortHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	asUser := func(userID int, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", userID)
+			handler(c)
+		}
+	}
+	router.POST("/teacher/cohorts", asUser(1, handlers.CreateCohort))
+	router.GET("/teacher/cohorts", asUser(1, handlers.GetCohorts))
+	router.GET("/teacher/cohorts/:cohort_id", asUser(1, handlers.GetCohort))
+	router.PUT("/teacher/cohorts/:cohort_id", asUser(1, handlers.UpdateCohort))
+	router.DELETE("/teacher/cohorts/:cohort_id", asUser(1, handlers.DeleteCohort))
+	router.POST("/teacher/cohorts/:cohort_id/invite-code", asUser(1, handlers.RegenerateCohortInviteCode))
+	router.GET("/teacher/cohorts/:cohort_id/members", asUser(1, handlers.GetCohortMembers))
+	router.POST("/teacher/cohorts/:cohort_id/members/import", asUser(1, handlers.ImportCohortMembers))
+	router.DELETE("/teacher/cohorts/:cohort_id/members/:user_id", asUser(1, handlers.RemoveCohortMember))
+	router.GET("/teacher/cohorts/:cohort_id/courses/:course_id/statistics", asUser(1, handlers.GetCohortCourseStatistics))
+	router.GET("/teacher/cohorts/:cohort_id/leaderboard", asUser(1, handlers.GetCohortLeaderboard))
+	router.GET("/teacher/cohorts/:cohort_id/submissions", asUser(1, handlers.GetCohortSubmissions))
+	router.GET("/student/cohorts/:cohort_id", asUser(2, handlers.GetCohort))
+	router.POST("/cohorts/join", asUser(2, handlers.JoinCohort))
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Validates cohorts", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/teacher/cohorts", models.Cohort{Name: "   "}).Code)
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/teacher/cohorts", models.Cohort{Name: "CS-1", TeacherIDs: []int{2}}).Code, "not a teacher")
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/teacher/cohorts", models.Cohort{Name: "CS-1", CourseIDs: []int{999}}).Code, "unknown course")
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/teacher/cohorts", models.Cohort{Name: "CS-1", CourseIDs: []int{1, 1}}).Code, "duplicate course")
+	})
+
+	w := request("POST", "/teacher/cohorts", models.Cohort{Name: " CS-21-1 ", CourseIDs: []int{1}, TeacherIDs: []int{1}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var cohort models.Cohort
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &cohort))
+	assert.Equal(t, "CS-21-1", cohort.Name)
+	assert.Len(t, cohort.InviteCode, 8)
+	cohortPath := fmt.Sprintf("/teacher/cohorts/%d", cohort.ID)
+
+	t.Run("Lists and protects cohorts", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, request("POST", "/teacher/cohorts", models.Cohort{Name: "CS-21-1"}).Code)
+
+		w := request("GET", "/teacher/cohorts?sort=-name", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+
+		assert.Equal(t, http.StatusForbidden, request("GET", fmt.Sprintf("/student/cohorts/%d", cohort.ID), nil).Code)
+		assert.Equal(t, http.StatusNotFound, request("GET", "/teacher/cohorts/999", nil).Code)
+	})
+
+	t.Run("Joins by invite code", func(t *testing.T) {
+		w := request("POST", "/cohorts/join", models.JoinCohortRequest{InviteCode: strings.ToLower(cohort.InviteCode)})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var joined models.Cohort
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &joined))
+		assert.Empty(t, joined.InviteCode)
+		assert.Equal(t, 1, joined.MembersCount)
+
+		assert.Equal(t, http.StatusConflict, request("POST", "/cohorts/join", models.JoinCohortRequest{InviteCode: cohort.InviteCode}).Code)
+
+		w = request("POST", cohortPath+"/invite-code", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusNotFound, request("POST", "/cohorts/join", models.JoinCohortRequest{InviteCode: cohort.InviteCode}).Code)
+	})
+
+	t.Run("Imports members from CSV", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", cohortPath+"/members/import", strings.NewReader("username\nuser123\n EYUBORISOVA@yandex.ru\nghost\n\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var result models.CohortImportResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, []int{1}, result.Added)
+		assert.Equal(t, []int{2}, result.AlreadyMembers)
+		assert.Equal(t, []string{"ghost"}, result.NotFound)
+
+		w = request("GET", cohortPath+"/members?sort=username", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+	})
+
+	t.Run("Scopes reports to the cohort", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, request("GET", cohortPath+"/courses/2/statistics", nil).Code)
+		w := request("GET", cohortPath+"/courses/1/statistics", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var stats models.CourseStatistics
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		assert.Equal(t, 1, stats.CourseID)
+
+		assert.Equal(t, http.StatusBadRequest, request("GET", cohortPath+"/leaderboard?course_id=2", nil).Code)
+		w = request("GET", cohortPath+"/leaderboard?course_id=1&limit=500", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var leaderboard []models.LeaderboardEntry
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &leaderboard))
+		for _, entry := range leaderboard {
+			assert.Contains(t, []int{1, 2}, entry.UserID)
+		}
+
+		assert.Equal(t, http.StatusBadRequest, request("GET", cohortPath+"/submissions?sort=title", nil).Code)
+		assert.Equal(t, http.StatusOK, request("GET", cohortPath+"/submissions", nil).Code)
+	})
+
+	t.Run("Removes members and cohorts", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("DELETE", cohortPath+"/members/2", nil).Code)
+		assert.Equal(t, http.StatusNotFound, request("DELETE", cohortPath+"/members/2", nil).Code)
+
+		assert.Equal(t, http.StatusOK, request("DELETE", cohortPath, nil).Code)
+		assert.Equal(t, http.StatusNotFound, request("GET", cohortPath, nil).Code)
+	})
+}
+
+func TestTeacherCourseStatistics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := new(storage.MockStorage)
+	handlers.Store = store
+
+	assert.NoError(t, store.CreateUser(models.User{Username: "stats_teacher", IsTeacher: true}))
+	teacher, err := store.GetUserByUsername("stats_teacher")
+	assert.NoError(t, err)
+	defer store.DeleteUser(teacher.ID)
+
+	router := gin.New()
+	for path, userID := range map[string]int{"admin": 1, "teacher": teacher.ID, "student": 2} {
+		userID := userID
+		router.GET("/"+path+"/courses/:course_id/statistics", func(c *gin.Context) {
+			c.Set("userID", userID)
+			handlers.GetCourseStatistics(c)
+		})
+	}
+	students := func(path string) []int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var stats models.CourseStatistics
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		var ids []int
+		for _, student := range stats.StudentsProgress {
+			ids = append(ids, student.UserID)
+		}
+		return ids
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/student/courses/1/statistics", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	assert.Empty(t, students("/teacher/courses/1/statistics"), "teacher without cohorts")
+
+	cohort, err := store.CreateCohort(models.Cohort{Name: "Stats cohort", CourseIDs: []int{1}, TeacherIDs: []int{teacher.ID}})
+	assert.NoError(t, err)
+	defer store.DeleteCohort(cohort.ID)
+	_, err = store.AddCohortMembers(cohort.ID, []int{2})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{2}, students("/teacher/courses/1/statistics"))
+	assert.Empty(t, students("/teacher/courses/2/statistics"), "course is not assigned to the cohort")
+	assert.ElementsMatch(t, []int{1, 2}, students("/admin/courses/1/statistics"))
+}
@@ -7,6 +7,7 @@ import (
 	"lmsmodule/backend-svc/storage"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -33,6 +34,68 @@ func TestGetCourses(t *testing.T) {
 	assert.NotEmpty(t, courses)
 }
 
+func TestGetCoursesPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockStorage := new(storage.MockStorage)
+	handlers.Store = mockStorage
+
+	router.GET("/courses", handlers.GetCourses)
+
+	t.Run("Page with total count", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/courses?page=1&limit=2&sort=-id", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+		assert.Equal(t, "1", w.Header().Get("X-Page"))
+		assert.Equal(t, "2", w.Header().Get("X-Limit"))
+
+		var courses []models.Course
+		err := json.Unmarshal(w.Body.Bytes(), &courses)
+		assert.NoError(t, err)
+		assert.Len(t, courses, 2)
+		assert.Equal(t, 3, courses[0].ID)
+	})
+
+	t.Run("Limit applies only with paging", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/courses", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-Limit"))
+		var courses []models.Course
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &courses))
+		assert.Equal(t, w.Header().Get("X-Total-Count"), strconv.Itoa(len(courses)))
+
+		req, _ = http.NewRequest("GET", "/courses?page=1", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, "20", w.Header().Get("X-Limit"))
+	})
+
+	t.Run("Cursor returns next cursor", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/courses?cursor=1&limit=1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-Next-Cursor"))
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"page=0", "limit=abc", "sort=title", "cursor=1&page=2", "from=2024-02-01&to=2024-01-01"} {
+			req, _ := http.NewRequest("GET", "/courses?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
+
 func TestGetCourseByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -0,0 +1,136 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainsSolution(t *testing.T) {
+	solution := "element.textContent = comment"
+	assert.True(t, models.ContainsSolution("try ELEMENT.textContent   =\n comment;", solution))
+	assert.False(t, models.ContainsSolution("use textContent instead", solution))
+	assert.False(t, models.ContainsSolution("the answer is 42", "42"), "short solutions are not matched")
+	assert.False(t, models.ContainsSolution("anything", ""))
+}
+
+func TestDiscussionHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	asUser := func(c *gin.Context) {
+		userID, _ := strconv.Atoi(c.GetHeader("X-User"))
+		c.Set("userID", userID)
+	}
+	router.Use(asUser)
+	router.GET("/courses/:id/discussions", handlers.GetDiscussions)
+	router.POST("/courses/:id/discussions", handlers.CreateDiscussion)
+	router.GET("/discussions/:thread_id", handlers.GetDiscussion)
+	router.POST("/discussions/:thread_id/replies", handlers.CreateDiscussionReply)
+	router.PUT("/teacher/discussions/:thread_id", handlers.ModerateDiscussion)
+	router.PUT("/teacher/discussions/:thread_id/replies/:reply_id", handlers.ModerateDiscussionReply)
+	router.PUT("/teacher/discussions/:thread_id/accepted-reply", handlers.AcceptDiscussionReply)
+
+	request := func(userID int, method, path string, body interface{}, result interface{}) int {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", strconv.Itoa(userID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if result != nil && w.Code < 300 {
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+		}
+		return w.Code
+	}
+	taskID := func(id int) *int { return &id }
+
+	var unsolved, solved, general models.DiscussionThread
+	assert.Equal(t, http.StatusCreated, request(1, "POST", "/courses/2/discussions",
+		models.CreateThreadRequest{Title: "Answer", Body: "element.textContent = comment", TaskID: taskID(3)}, &unsolved))
+	assert.True(t, unsolved.HasSpoiler)
+	assert.Equal(t, http.StatusCreated, request(1, "POST", "/courses/1/discussions",
+		models.CreateThreadRequest{Title: "Answer", Body: `db.Query("SELECT * FROM users WHERE name = ?", name) // prepared statement`, TaskID: taskID(1)}, &solved))
+	assert.True(t, solved.HasSpoiler)
+	assert.Equal(t, http.StatusCreated, request(2, "POST", "/courses/2/discussions",
+		models.CreateThreadRequest{Title: "  Hello  ", Body: "Nice course"}, &general))
+	assert.Equal(t, "Hello", general.Title)
+
+	t.Run("Validation", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request(2, "POST", "/courses/2/discussions",
+			models.CreateThreadRequest{Title: "   ", Body: "text"}, nil))
+		assert.Equal(t, http.StatusNotFound, request(2, "POST", "/courses/99/discussions",
+			models.CreateThreadRequest{Title: "Title", Body: "text"}, nil))
+		assert.Equal(t, http.StatusNotFound, request(2, "POST", "/courses/2/discussions",
+			models.CreateThreadRequest{Title: "Title", Body: "text", TaskID: taskID(1)}, nil))
+		assert.Equal(t, http.StatusBadRequest, request(2, "GET", "/courses/2/discussions?cursor=1", nil, nil))
+		assert.Equal(t, http.StatusNotFound, request(2, "GET", "/discussions/9999", nil, nil))
+	})
+
+	t.Run("Spoiler guard", func(t *testing.T) {
+		var thread models.DiscussionThread
+		assert.Equal(t, http.StatusOK, request(2, "GET", "/discussions/"+strconv.Itoa(unsolved.ID), nil, &thread))
+		assert.True(t, thread.SpoilerHidden)
+		assert.Empty(t, thread.Body)
+		assert.Equal(t, http.StatusForbidden, request(2, "POST", "/discussions/"+strconv.Itoa(unsolved.ID)+"/replies",
+			models.CreateReplyRequest{Body: "me too"}, nil))
+
+		assert.Equal(t, http.StatusOK, request(2, "GET", "/discussions/"+strconv.Itoa(solved.ID), nil, &thread))
+		assert.False(t, thread.SpoilerHidden, "completed task reveals the thread")
+		assert.NotEmpty(t, thread.Body)
+
+		assert.Equal(t, http.StatusOK, request(1, "GET", "/discussions/"+strconv.Itoa(unsolved.ID), nil, &thread))
+		assert.False(t, thread.SpoilerHidden, "moderators always see spoilers")
+
+		assert.Equal(t, http.StatusBadRequest, request(1, "PUT", "/teacher/discussions/"+strconv.Itoa(general.ID),
+			map[string]bool{"has_spoiler": true}, nil), "course threads cannot be spoilers")
+	})
+
+	t.Run("Replies and moderation", func(t *testing.T) {
+		path := "/discussions/" + strconv.Itoa(general.ID)
+		var reply models.DiscussionReply
+		assert.Equal(t, http.StatusCreated, request(1, "POST", path+"/replies", models.CreateReplyRequest{Body: "Welcome!"}, &reply))
+		assert.Equal(t, "admin", reply.AuthorName)
+
+		var thread models.DiscussionThread
+		assert.Equal(t, http.StatusOK, request(1, "PUT", "/teacher"+path+"/accepted-reply", models.AcceptReplyRequest{ReplyID: reply.ID}, &thread))
+		if assert.NotNil(t, thread.AcceptedReplyID) {
+			assert.Equal(t, reply.ID, *thread.AcceptedReplyID)
+		}
+		assert.Equal(t, http.StatusNotFound, request(1, "PUT", "/teacher"+path+"/accepted-reply", models.AcceptReplyRequest{ReplyID: 9999}, nil))
+
+		assert.Equal(t, http.StatusOK, request(1, "PUT", "/teacher"+path+"/replies/"+strconv.Itoa(reply.ID), models.ReplyModeration{IsHidden: true}, nil))
+		assert.Equal(t, http.StatusOK, request(2, "GET", path, nil, &thread))
+		assert.Empty(t, thread.Replies, "hidden replies are not shown to students")
+		assert.Equal(t, http.StatusOK, request(1, "GET", path, nil, &thread))
+		assert.Len(t, thread.Replies, 1)
+
+		assert.Equal(t, http.StatusOK, request(1, "PUT", "/teacher"+path, map[string]bool{"is_locked": true, "is_pinned": true}, nil))
+		assert.Equal(t, http.StatusConflict, request(2, "POST", path+"/replies", models.CreateReplyRequest{Body: "Thanks"}, nil))
+		assert.Equal(t, http.StatusCreated, request(1, "POST", path+"/replies", models.CreateReplyRequest{Body: "Closing"}, nil))
+
+		var threads []models.DiscussionThread
+		assert.Equal(t, http.StatusOK, request(2, "GET", "/courses/2/discussions", nil, &threads))
+		if assert.Len(t, threads, 2) {
+			assert.Equal(t, general.ID, threads[0].ID, "pinned threads come first")
+		}
+
+		assert.Equal(t, http.StatusOK, request(1, "PUT", "/teacher"+path, map[string]bool{"is_hidden": true}, nil))
+		assert.Equal(t, http.StatusNotFound, request(2, "GET", path, nil, nil))
+		assert.Equal(t, http.StatusOK, request(2, "GET", "/courses/2/discussions?task_id=3", nil, &threads))
+		assert.Len(t, threads, 1)
+	})
+}
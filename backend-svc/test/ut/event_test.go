@@ -0,0 +1,267 @@
+package ut
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"lmsmodule/backend-svc/events"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive ждет событие из канала клиента
+func receive(t *testing.T, stream <-chan models.LiveEvent) (models.LiveEvent, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-stream:
+		return event, ok
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not delivered")
+		return models.LiveEvent{}, false
+	}
+}
+
+func TestEventHub(t *testing.T) {
+	hub := events.NewHub(events.NewMemoryBroker())
+	defer hub.Close()
+
+	student, cancelStudent := hub.Subscribe(2)
+	admin, cancelAdmin := hub.Subscribe(1)
+	defer cancelAdmin()
+	assert.Equal(t, 2, hub.Connections())
+
+	assert.NoError(t, hub.Publish(models.LiveEvent{Type: models.EventGrading, UserID: 2, Data: map[string]interface{}{"task_id": 1}}))
+	event, _ := receive(t, student)
+	assert.Equal(t, models.EventGrading, event.Type)
+	assert.NotZero(t, event.ID)
+	assert.False(t, event.CreatedAt.IsZero())
+
+	assert.NoError(t, hub.Publish(models.LiveEvent{Type: models.EventLeaderboard}))
+	event, _ = receive(t, admin)
+	assert.Equal(t, models.EventLeaderboard, event.Type, "events without a user go to everyone")
+	event, _ = receive(t, student)
+	assert.Equal(t, models.EventLeaderboard, event.Type)
+
+	for i := 0; i < events.ClientBuffer+5; i++ {
+		assert.NoError(t, hub.Publish(models.LiveEvent{Type: models.EventProgress, UserID: 2}))
+	}
+	assert.Len(t, student, events.ClientBuffer, "a slow client does not block publishing")
+
+	cancelStudent()
+	cancelStudent()
+	assert.Equal(t, 1, hub.Connections())
+	for range student {
+	}
+}
+
+func TestStoreBrokerFanOut(t *testing.T) {
+	store := new(storage.MockStorage)
+	_, err := store.AppendLiveEvent(models.LiveEvent{Type: models.EventProgress, UserID: 2})
+	require.NoError(t, err)
+
+	first, err := events.NewStoreBroker(store, time.Hour)
+	require.NoError(t, err)
+	second, err := events.NewStoreBroker(store, time.Hour)
+	require.NoError(t, err)
+	firstHub, secondHub := events.NewHub(first), events.NewHub(second)
+	defer firstHub.Close()
+	defer secondHub.Close()
+
+	onFirst, cancelFirst := firstHub.Subscribe(2)
+	defer cancelFirst()
+	onSecond, cancelSecond := secondHub.Subscribe(2)
+	defer cancelSecond()
+
+	assert.NoError(t, firstHub.Publish(models.LiveEvent{Type: models.EventGrading, UserID: 2, Data: map[string]interface{}{"score": 100}}))
+	assert.Len(t, onFirst, 0, "events are delivered when the log is read")
+
+	for _, broker := range []*events.StoreBroker{first, second} {
+		delivered, err := broker.Poll()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, delivered, "events written before the broker started are skipped")
+	}
+	for _, stream := range []<-chan models.LiveEvent{onFirst, onSecond} {
+		event, _ := receive(t, stream)
+		assert.Equal(t, models.EventGrading, event.Type)
+		assert.Equal(t, 100, event.Data["score"])
+	}
+
+	delivered, err := second.Poll()
+	assert.NoError(t, err)
+	assert.Zero(t, delivered)
+
+	stop := second.Start(10 * time.Millisecond)
+	defer stop()
+	assert.NoError(t, firstHub.Publish(models.LiveEvent{Type: models.EventLeaderboard}))
+	event, _ := receive(t, onSecond)
+	assert.Equal(t, models.EventLeaderboard, event.Type)
+
+	deleted, err := store.DeleteLiveEventsBefore(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+}
+
+// reorderedLog - журнал событий, в котором записи становятся видны не по порядку ID,
+// как при одновременных транзакциях
+type reorderedLog struct {
+	events []models.LiveEvent
+}
+
+func (l *reorderedLog) AppendLiveEvent(event models.LiveEvent) (models.LiveEvent, error) {
+	l.events = append(l.events, event)
+	return event, nil
+}
+
+func (l *reorderedLog) GetLiveEventsAfter(afterID, limit int) ([]models.LiveEvent, error) {
+	var result []models.LiveEvent
+	for _, event := range l.events {
+		if event.ID > afterID {
+			result = append(result, event)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (l *reorderedLog) GetLastLiveEventID() (int, error) {
+	last := 0
+	for _, event := range l.events {
+		if event.ID > last {
+			last = event.ID
+		}
+	}
+	return last, nil
+}
+
+func (l *reorderedLog) DeleteLiveEventsBefore(time.Time) (int, error) { return 0, nil }
+
+func TestStoreBrokerDeliversLateCommits(t *testing.T) {
+	log := &reorderedLog{}
+	_, _ = log.AppendLiveEvent(models.LiveEvent{ID: 1})
+	broker, err := events.NewStoreBroker(log, time.Hour)
+	require.NoError(t, err)
+
+	var received []int
+	cancel := broker.Subscribe(func(event models.LiveEvent) { received = append(received, event.ID) })
+	defer cancel()
+
+	// Транзакция события 2 фиксируется позже транзакции события 3
+	_, _ = log.AppendLiveEvent(models.LiveEvent{ID: 3})
+	delivered, err := broker.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	_, _ = log.AppendLiveEvent(models.LiveEvent{ID: 2})
+	delivered, err = broker.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	delivered, err = broker.Poll()
+	require.NoError(t, err)
+	assert.Zero(t, delivered, "events are delivered once")
+	assert.Equal(t, []int{3, 2}, received)
+}
+
+func TestEventHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handlers.Store = new(storage.MockStorage)
+	handlers.Events = nil
+	handlers.InternalEventsToken = ""
+
+	router := gin.New()
+	router.GET("/events/stream", func(c *gin.Context) {
+		c.Set("userID", 2)
+		handlers.StreamEvents(c)
+	})
+	router.POST("/admin/users/:id/promote", func(c *gin.Context) {
+		c.Set("userID", 1)
+		handlers.PromoteToAdmin(c)
+	})
+	router.POST("/internal/events/lab-results", handlers.PublishLabResult)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	handlers.Events = events.NewHub(events.NewMemoryBroker())
+	handlers.InternalEventsToken = "secret"
+	defer func() {
+		handlers.Events.Close()
+		handlers.Events = nil
+		handlers.InternalEventsToken = ""
+	}()
+
+	resp, err = http.Get(server.URL + "/events/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	nextEvent := func() (string, models.LiveEvent) {
+		var name string
+		var event models.LiveEvent
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+				return name, event
+			}
+		}
+	}
+
+	for handlers.Events.Connections() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	promote, err := http.Post(server.URL+"/admin/users/2/promote", "application/json", nil)
+	require.NoError(t, err)
+	promote.Body.Close()
+	assert.NoError(t, new(storage.MockStorage).DemoteFromAdmin(2))
+
+	name, event := nextEvent()
+	assert.Equal(t, models.EventNotification, name)
+	notification := event.Data["notification"].(map[string]interface{})
+	assert.Equal(t, models.NotificationRoleChanged, notification["type"])
+
+	post := func(token string, body interface{}) int {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", server.URL+"/internal/events/lab-results", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Internal-Token", token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	lab := models.LabResultEvent{UserID: 2, SessionID: "abc", Status: "passed", Result: map[string]interface{}{"passed": 3}}
+	assert.Equal(t, http.StatusUnauthorized, post("wrong", lab))
+	assert.Equal(t, http.StatusBadRequest, post("secret", map[string]interface{}{"user_id": 2}))
+	assert.Equal(t, http.StatusAccepted, post("secret", lab))
+
+	name, event = nextEvent()
+	assert.Equal(t, models.EventLabResult, name)
+	assert.Equal(t, 2, event.UserID)
+	assert.Equal(t, "abc", event.Data["session_id"])
+}
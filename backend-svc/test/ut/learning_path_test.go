@@ -0,0 +1,207 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/learningpath"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func learningTask(id, courseID int, courseName, difficulty string, prerequisites ...int) models.LearningTask {
+	return models.LearningTask{
+		Task:          models.Task{ID: id, CourseID: courseID, Title: "Task", Difficulty: difficulty, Order: id},
+		CourseName:    courseName,
+		Prerequisites: prerequisites,
+	}
+}
+
+func testSnapshot() models.LearningSnapshot {
+	now := time.Now()
+	return models.LearningSnapshot{
+		UserID:      2,
+		GeneratedAt: now,
+		Tasks: []models.LearningTask{
+			learningTask(1, 1, "SQL Injection", "easy"),
+			learningTask(2, 1, "SQL Injection", "medium", 1),
+			learningTask(3, 2, "XSS", "easy"),
+			learningTask(4, 2, "XSS", "hard", 3),
+			learningTask(5, 3, "CSRF", "medium"),
+		},
+		Completed: map[int]time.Time{
+			1: now.Add(-48 * time.Hour),
+			2: now.Add(-24 * time.Hour),
+			3: now.Add(-12 * time.Hour),
+		},
+		Attempts: []models.SubmissionAttempt{
+			{TaskID: 3, IsCorrect: false, SubmittedAt: now.Add(-14 * time.Hour)},
+			{TaskID: 3, IsCorrect: false, SubmittedAt: now.Add(-13 * time.Hour)},
+			{TaskID: 3, IsCorrect: true, Score: 10, SubmittedAt: now.Add(-12 * time.Hour)},
+		},
+	}
+}
+
+func TestLearningPathModel(t *testing.T) {
+	m := learningpath.NewModel(testSnapshot())
+
+	t.Run("Skill levels follow completion and failed attempts", func(t *testing.T) {
+		sql := m.Skills["SQL Injection"]
+		assert.Equal(t, "CWE-89", sql.CWE)
+		assert.Equal(t, 1.0, sql.Mastery)
+		level, progress := sql.Level()
+		assert.Equal(t, learningpath.MaxSkillLevel, level)
+		assert.Equal(t, 100.0, progress)
+
+		// Задача 3 решена с третьей попытки (освоение 1/1.5), задача 4 не решена
+		xss := m.Skills["XSS"]
+		assert.InDelta(t, 1.0/3, xss.Mastery, 0.001)
+		level, progress = xss.Level()
+		assert.Equal(t, 1, level)
+		assert.Equal(t, 66.7, progress)
+
+		assert.Zero(t, m.Skills["CSRF"].Mastery)
+	})
+
+	t.Run("Readiness requires prerequisites", func(t *testing.T) {
+		assert.False(t, m.Ready(2), "completed")
+		assert.True(t, m.Ready(4))
+		assert.True(t, m.Ready(5))
+	})
+
+	t.Run("Estimates use peers and personal pace", func(t *testing.T) {
+		snapshot := testSnapshot()
+		snapshot.Tasks[0].AverageTimeSeconds = 600
+		snapshot.Tasks[4].AverageTimeSeconds = 1000
+		// Пользователь решал задачу 1 вдвое дольше среднего и уже потратил 5 минут на задачу 5
+		snapshot.Activity = []models.TaskActivitySummary{
+			{TaskID: 1, TimeSpentSeconds: 1200},
+			{TaskID: 5, TimeSpentSeconds: 300},
+		}
+
+		m := learningpath.NewModel(snapshot)
+		assert.Equal(t, 2.0, m.Pace())
+		assert.Equal(t, 1700, m.EstimateSeconds(5))
+		// Без данных о задаче используется оценка по сложности
+		assert.Equal(t, 90*60, m.EstimateSeconds(4))
+		assert.Zero(t, m.EstimateSeconds(1))
+	})
+}
+
+func TestLearningPathStrategies(t *testing.T) {
+	t.Run("Weakness strategy targets the weakest skill", func(t *testing.T) {
+		path := learningpath.WeaknessStrategy{}.Build(testSnapshot())
+
+		assert.Len(t, path.NextTasks, 2)
+		assert.Equal(t, 5, path.NextTasks[0].TaskID)
+		assert.Equal(t, 1, path.NextTasks[0].Priority)
+		assert.Equal(t, 3, path.Recommendations[0].CourseID)
+		assert.Equal(t, "Strengthen weak skill: CSRF", path.Recommendations[0].Reason)
+		assert.Equal(t, "30m 00s", path.Recommendations[0].EstimatedTime)
+
+		assert.Equal(t, "CSRF (CWE-352)", path.Skills[0].SkillName)
+		assert.Equal(t, 5, path.Skills[0].RecommendedTask)
+	})
+
+	t.Run("Due dates raise priority", func(t *testing.T) {
+		snapshot := testSnapshot()
+		due := snapshot.GeneratedAt.Add(24 * time.Hour)
+		snapshot.Tasks[3].DueAt = &due
+
+		path := learningpath.WeaknessStrategy{UseDueDates: true}.Build(snapshot)
+		assert.Equal(t, 4, path.NextTasks[0].TaskID)
+		assert.Equal(t, due.UTC().Format(time.RFC3339), path.NextTasks[0].DueDate)
+		assert.Equal(t, "Due in less than 3 days", path.Recommendations[0].Reason)
+
+		path = learningpath.WeaknessStrategy{}.Build(snapshot)
+		assert.Equal(t, 5, path.NextTasks[0].TaskID)
+	})
+
+	t.Run("Sequential strategy continues started courses", func(t *testing.T) {
+		path := learningpath.SequentialStrategy{}.Build(testSnapshot())
+		assert.Equal(t, 4, path.NextTasks[0].TaskID)
+		assert.Equal(t, "Next task in your current course", path.Recommendations[0].Reason)
+	})
+
+	t.Run("Strategies are registered by name", func(t *testing.T) {
+		assert.Equal(t, []string{"sequential", "weakness"}, learningpath.Names())
+		strategy, ok := learningpath.Lookup(learningpath.DefaultStrategy)
+		assert.True(t, ok)
+		assert.Equal(t, "weakness", strategy.Name())
+	})
+}
+
+func TestGetUserLearningPathWithMock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	router.GET("/progress/:user_id/learning-path", func(c *gin.Context) {
+		c.Set("userID", 2)
+		handlers.GetUserLearningPath(c)
+	})
+	router.PUT("/courses/:course_id/tasks/:task_id/skills", handlers.SetTaskSkills)
+
+	putSkills := func(path string, request models.TaskSkillsRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(request)
+		req, _ := http.NewRequest("PUT", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Builds path from mock store", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/progress/2/learning-path", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var path models.LearningPath
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &path))
+		assert.Equal(t, 2, path.UserID)
+		assert.NotEmpty(t, path.Skills)
+		for _, task := range path.NextTasks {
+			assert.NotContains(t, []int{1, 2}, task.TaskID)
+		}
+
+		req, _ = http.NewRequest("GET", "/progress/2/learning-path?strategy=random", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Sets task skills and validates prerequisites", func(t *testing.T) {
+		w := putSkills("/courses/2/tasks/3/skills", models.TaskSkillsRequest{
+			Skills:        []models.TaskSkill{{Skill: "DOM XSS", CWE: "cwe-79"}},
+			Prerequisites: []int{1},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var saved models.TaskSkillsRequest
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &saved))
+		assert.Equal(t, "CWE-79", saved.Skills[0].CWE)
+		assert.Equal(t, 1.0, saved.Skills[0].Weight)
+
+		w = putSkills("/courses/1/tasks/1/skills", models.TaskSkillsRequest{Skills: []models.TaskSkill{}, Prerequisites: []int{3}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "cycle")
+
+		w = putSkills("/courses/1/tasks/1/skills", models.TaskSkillsRequest{Skills: []models.TaskSkill{}, Prerequisites: []int{999}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unknown prerequisite")
+
+		w = putSkills("/courses/1/tasks/1/skills", models.TaskSkillsRequest{Skills: []models.TaskSkill{{Skill: "SQLi", CWE: "89"}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "invalid CWE")
+
+		w = putSkills("/courses/2/tasks/1/skills", models.TaskSkillsRequest{Skills: []models.TaskSkill{}})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		assert.Equal(t, http.StatusOK, putSkills("/courses/2/tasks/3/skills", models.TaskSkillsRequest{Skills: []models.TaskSkill{}}).Code)
+	})
+}
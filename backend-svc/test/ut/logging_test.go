@@ -0,0 +1,42 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogging(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(logger.Options{Level: "info", Output: &out})
+
+	router := setupTestRouter()
+	router.Use(logger.RequestIDMiddleware())
+	router.Use(logger.AccessLogMiddleware(log))
+	router.POST("/login", handlers.LoginHandler)
+
+	body, _ := json.Marshal(models.LoginRequest{Username: "logging_nobody", Password: "very-secret-password"})
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+	req.Header.Set(logger.RequestIDHeader, "gateway-req-42")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "gateway-req-42", w.Header().Get(logger.RequestIDHeader))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "gateway-req-42", entry["request_id"])
+	assert.Equal(t, "/login", entry["route"])
+	assert.Equal(t, 401.0, entry["status"])
+	assert.NotContains(t, out.String(), "very-secret-password")
+	assert.NotContains(t, out.String(), "secret-token")
+}
@@ -0,0 +1,70 @@
+package ut
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/metrics"
+	"lmsmodule/backend-svc/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusinessMetrics(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(metrics.HTTP.Middleware())
+	router.GET("/metrics", gin.WrapH(metrics.Registry.Handler()))
+	router.POST("/login", handlers.LoginHandler)
+	router.POST("/progress/:user_id/tasks/:task_id/complete", func(c *gin.Context) {
+		c.Set("userID", 1)
+		handlers.CompleteTask(c)
+	})
+
+	failedLogins := metrics.Logins(metrics.LoginFailed)
+	completions := metrics.TaskCompletions()
+
+	body, _ := json.Marshal(models.LoginRequest{Username: "metrics_nobody", Password: "password123"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body)))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/progress/1/tasks/2/complete", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, failedLogins+1, metrics.Logins(metrics.LoginFailed))
+	assert.Equal(t, completions+1, metrics.TaskCompletions())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	text, _ := io.ReadAll(w.Body)
+	assert.Contains(t, string(text), "# TYPE backend_logins_total counter")
+	assert.Contains(t, string(text), "# TYPE backend_task_completions_total counter")
+	assert.Contains(t, string(text), `backend_http_requests_total{route="/login",method="POST",status="401"}`)
+}
+
+func TestWatchDBStats(t *testing.T) {
+	metrics.WatchDB(func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 4, WaitDuration: 1500 * time.Millisecond}
+	})
+	defer metrics.WatchDB(nil)
+
+	w := httptest.NewRecorder()
+	metrics.Registry.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	text := w.Body.String()
+	assert.Contains(t, text, "backend_db_open_connections 3\n")
+	assert.Contains(t, text, "backend_db_in_use_connections 1\n")
+	assert.Contains(t, text, "backend_db_idle_connections 2\n")
+	assert.Contains(t, text, "backend_db_max_open_connections 25\n")
+	assert.Contains(t, text, "# TYPE backend_db_wait_total counter\nbackend_db_wait_total 4\n")
+	assert.Contains(t, text, "backend_db_wait_duration_seconds_total 1.5\n")
+}
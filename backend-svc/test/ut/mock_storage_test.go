@@ -13,9 +13,10 @@ func TestMockStorage_Courses(t *testing.T) {
 	mockStore := new(storage.MockStorage)
 
 	t.Run("GetCourses", func(t *testing.T) {
-		courses, err := mockStore.GetCourses()
+		courses, total, err := mockStore.GetCourses(models.ListParams{})
 
 		assert.NoError(t, err)
+		assert.Equal(t, 3, total)
 		assert.Equal(t, 3, len(courses))
 		assert.Equal(t, "SQL Injection", courses[0].VulnerabilityType)
 		assert.Equal(t, "XSS", courses[1].VulnerabilityType)
@@ -24,6 +25,25 @@ func TestMockStorage_Courses(t *testing.T) {
 		assert.Empty(t, courses[0].Tasks)
 	})
 
+	t.Run("GetCoursesPaginated", func(t *testing.T) {
+		courses, total, err := mockStore.GetCourses(models.ListParams{Page: 2, Limit: 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Len(t, courses, 1)
+		assert.Equal(t, "CSRF", courses[0].VulnerabilityType)
+
+		courses, _, err = mockStore.GetCourses(models.ListParams{Limit: 2, Cursor: 1})
+		assert.NoError(t, err)
+		assert.Len(t, courses, 2)
+		assert.Equal(t, 2, courses[0].ID)
+
+		courses, _, err = mockStore.GetCourses(models.ListParams{SortBy: "vulnerability_type", SortDesc: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "XSS", courses[0].VulnerabilityType)
+		assert.Equal(t, "CSRF", courses[2].VulnerabilityType)
+	})
+
 	t.Run("GetCourseByID", func(t *testing.T) {
 		course, err := mockStore.GetCourseByID(1)
 
@@ -175,20 +195,27 @@ func TestMockStorage_UserAdministration(t *testing.T) {
 	})
 
 	t.Run("GetAllUsers", func(t *testing.T) {
-		users, err := mockStore.GetAllUsers()
+		users, total, err := mockStore.GetAllUsers(models.ListParams{})
 
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, len(users), 2)
+		assert.Equal(t, len(users), total)
+
+		users, total, err = mockStore.GetAllUsers(models.ListParams{Page: 1, Limit: 1, SortBy: "username", SortDesc: true})
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.GreaterOrEqual(t, total, 2)
+		assert.Equal(t, "user123", users[0].Username)
 	})
 
 	t.Run("GetUsersByRole", func(t *testing.T) {
-		admins, err := mockStore.GetUsersByRole(true)
+		admins, _, err := mockStore.GetUsersByRole(true, models.ListParams{})
 		assert.NoError(t, err)
 		for _, admin := range admins {
 			assert.True(t, admin.IsAdmin)
 		}
 
-		regularUsers, err := mockStore.GetUsersByRole(false)
+		regularUsers, _, err := mockStore.GetUsersByRole(false, models.ListParams{})
 		assert.NoError(t, err)
 		for _, user := range regularUsers {
 			assert.False(t, user.IsAdmin)
@@ -196,20 +223,20 @@ func TestMockStorage_UserAdministration(t *testing.T) {
 	})
 
 	t.Run("SearchUsers", func(t *testing.T) {
-		users, err := mockStore.SearchUsers("admin")
+		users, _, err := mockStore.SearchUsers("admin", models.ListParams{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, users)
 		assert.Equal(t, "admin", users[0].Username)
 
-		users, err = mockStore.SearchUsers("example.com")
+		users, _, err = mockStore.SearchUsers("example.com", models.ListParams{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, users)
 
-		users, err = mockStore.SearchUsers("Regular")
+		users, _, err = mockStore.SearchUsers("Regular", models.ListParams{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, users)
 
-		users, err = mockStore.SearchUsers("nonexistent")
+		users, _, err = mockStore.SearchUsers("nonexistent", models.ListParams{})
 		assert.NoError(t, err)
 		assert.Empty(t, users)
 	})
@@ -0,0 +1,214 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/notifications"
+	"lmsmodule/backend-svc/reminders"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationDelivery(t *testing.T) {
+	store := new(storage.MockStorage)
+	assert.NoError(t, store.UpdateNotificationPreferences(2, models.NotificationPreferences{
+		Email: map[string]bool{models.NotificationGradingResult: true, models.NotificationDeadline: false},
+	}))
+
+	var emailed []string
+	mailer := func(user models.User, n models.Notification) error {
+		emailed = append(emailed, user.Username+":"+n.Type)
+		if n.Type == models.NotificationAccountStatus {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	}
+
+	for _, notificationType := range []string{
+		models.NotificationGradingResult,
+		models.NotificationCoursePublished,
+		models.NotificationDeadline,
+		models.NotificationAccountStatus,
+	} {
+		n, err := notifications.Send(store, mailer, models.Notification{UserID: 2, Type: notificationType, Title: notificationType})
+		assert.NoError(t, err, "email errors do not fail the notification")
+		assert.NotZero(t, n.ID)
+	}
+	assert.Equal(t, []string{"user123:grading_result", "user123:account_status"}, emailed)
+
+	_, err := notifications.Send(store, nil, models.Notification{UserID: 2, Type: models.NotificationGradingResult})
+	assert.NoError(t, err)
+	assert.Len(t, emailed, 2, "no mailer means no email")
+
+	sent := notifications.Broadcast(store, nil, []int{1, 2}, models.Notification{Type: models.NotificationCoursePublished, Title: "New course"})
+	assert.Len(t, sent, 2)
+
+	t.Run("Broadcast keeps going after a failed batch", func(t *testing.T) {
+		userIDs := make([]int, 2*notifications.BroadcastBatchSize+1)
+		for i := range userIDs {
+			userIDs[i] = i + 1
+		}
+		failing := &batchStorage{MockStorage: store, failBatch: 2}
+		sent := notifications.Broadcast(failing, nil, userIDs, models.Notification{Type: models.NotificationCoursePublished})
+		assert.Equal(t, []int{notifications.BroadcastBatchSize, notifications.BroadcastBatchSize, 1}, failing.batches)
+		if assert.Len(t, sent, notifications.BroadcastBatchSize+1) {
+			assert.Equal(t, 1, sent[0].UserID)
+			assert.Equal(t, len(userIDs), sent[len(sent)-1].UserID)
+		}
+	})
+
+	t.Run("Deadline reminders become notifications", func(t *testing.T) {
+		due := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+		send := reminders.NotificationSender(func(n models.Notification) (models.Notification, error) {
+			return notifications.Send(store, mailer, n)
+		})
+		assert.NoError(t, send(models.DeadlineReminder{
+			UserID: 1, AssignmentID: 7, AssignmentTitle: "Week 1", CourseID: 1, CourseName: "SQL Injection",
+			DueAt: due, RemainingTasks: 2,
+		}))
+
+		list, _, err := store.GetNotifications(1, true, models.ListParams{Limit: 1})
+		assert.NoError(t, err)
+		if assert.Len(t, list, 1) {
+			assert.Equal(t, models.NotificationDeadline, list[0].Type)
+			assert.Equal(t, "Assignment deadline reminder: Week 1", list[0].Title)
+			assert.Contains(t, list[0].Message, "2025-03-10 12:00 UTC")
+			assert.Equal(t, 7, list[0].Payload["assignment_id"])
+		}
+		assert.Equal(t, "admin:deadline_reminder", emailed[len(emailed)-1], "deadline emails are on by default")
+	})
+
+	assert.NoError(t, store.UpdateNotificationPreferences(2, models.NotificationPreferences{Email: models.DefaultEmailNotifications}))
+	for _, userID := range []int{1, 2} {
+		_, err := store.MarkAllNotificationsRead(userID, time.Now())
+		assert.NoError(t, err)
+	}
+}
+
+// batchStorage записывает размеры пачек уведомлений и отклоняет пачку с номером failBatch
+type batchStorage struct {
+	*storage.MockStorage
+	failBatch int
+	batches   []int
+}
+
+func (s *batchStorage) CreateNotifications(notifications []models.Notification) ([]models.Notification, error) {
+	s.batches = append(s.batches, len(notifications))
+	if len(s.batches) == s.failBatch {
+		return nil, errors.New("database unavailable")
+	}
+	return s.MockStorage.CreateNotifications(notifications)
+}
+
+func TestNotificationHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	asUser := func(userID int, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", userID)
+			handler(c)
+		}
+	}
+	router.GET("/notifications", asUser(2, handlers.GetNotifications))
+	router.GET("/notifications/unread-count", asUser(2, handlers.GetUnreadNotificationCount))
+	router.POST("/notifications/read-all", asUser(2, handlers.MarkAllNotificationsRead))
+	router.POST("/notifications/:notification_id/read", asUser(2, handlers.MarkNotificationRead))
+	router.GET("/notifications/preferences", asUser(2, handlers.GetNotificationPreferences))
+	router.PUT("/notifications/preferences", asUser(2, handlers.UpdateNotificationPreferences))
+	router.POST("/admin/users/:id/promote", asUser(1, handlers.PromoteToAdmin))
+	router.POST("/admin/users/:id/demote", asUser(1, handlers.DemoteFromAdmin))
+	router.PUT("/admin/users/:id/status", asUser(1, handlers.UpdateUserStatus))
+	router.POST("/progress/:user_id/tasks/:task_id/submit", asUser(2, handlers.SubmitTaskWithAnswer))
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	unreadCount := func() int {
+		var unread models.UnreadNotificationsResponse
+		w := request("GET", "/notifications/unread-count", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &unread))
+		return unread.UnreadCount
+	}
+
+	request("POST", "/notifications/read-all", nil)
+	assert.Equal(t, 0, unreadCount())
+
+	assert.Equal(t, http.StatusOK, request("POST", "/admin/users/2/promote", nil).Code)
+	assert.Equal(t, http.StatusOK, request("POST", "/admin/users/2/demote", nil).Code)
+	assert.Equal(t, http.StatusOK, request("PUT", "/admin/users/2/status", models.UpdateStatusRequest{IsActive: true}).Code)
+	assert.Equal(t, 3, unreadCount())
+
+	w := request("GET", "/notifications?unread=true&limit=2", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+	var list []models.Notification
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, models.NotificationAccountStatus, list[0].Type)
+		assert.Equal(t, "Role revoked: admin", list[1].Title)
+
+		w = request("POST", "/notifications/"+strconv.Itoa(list[0].ID)+"/read", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 2, unreadCount())
+	}
+
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/notifications?unread=maybe", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, request("POST", "/notifications/abc/read", nil).Code)
+	assert.Equal(t, http.StatusNotFound, request("POST", "/notifications/100000/read", nil).Code)
+
+	w = request("POST", "/notifications/read-all", nil)
+	var marked models.MarkNotificationsReadResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &marked))
+	assert.Equal(t, 2, marked.Marked)
+	assert.Equal(t, 0, unreadCount())
+
+	assert.Equal(t, http.StatusBadRequest, request("PUT", "/notifications/preferences", map[string]interface{}{}).Code)
+	assert.Equal(t, http.StatusBadRequest, request("PUT", "/notifications/preferences",
+		models.NotificationPreferences{Email: map[string]bool{"sms": true}}).Code)
+
+	w = request("PUT", "/notifications/preferences", models.NotificationPreferences{Email: map[string]bool{models.NotificationRoleChanged: false}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request("GET", "/notifications/preferences", nil)
+	var prefs models.NotificationPreferences
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &prefs))
+	assert.False(t, prefs.Email[models.NotificationRoleChanged])
+	assert.True(t, prefs.Email[models.NotificationDeadline])
+
+	assert.NoError(t, handlers.Store.UpdateNotificationPreferences(2, models.NotificationPreferences{Email: models.DefaultEmailNotifications}))
+
+	t.Run("Task grades are reported in points", func(t *testing.T) {
+		w := request("POST", "/progress/2/tasks/1/submit", models.TaskSubmission{CourseID: 1, Answer: `db.Query("SELECT * FROM users WHERE name = ?", name) // prepared statement`})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = request("GET", "/notifications?unread=true", nil)
+		var list []models.Notification
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		if assert.Len(t, list, 1) {
+			assert.Equal(t, models.NotificationGradingResult, list[0].Type)
+			assert.Contains(t, list[0].Message, "graded as correct with a score of 0.00 points")
+			assert.Equal(t, "points", list[0].Payload["score_unit"])
+		}
+		request("POST", "/notifications/read-all", nil)
+	})
+}
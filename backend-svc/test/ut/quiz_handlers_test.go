@@ -0,0 +1,296 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuizGrading(t *testing.T) {
+	options := []models.QuizOption{
+		{ID: 1, Text: "A", IsCorrect: true, Position: 1},
+		{ID: 2, Text: "B", IsCorrect: true, Position: 2},
+		{ID: 3, Text: "C", Position: 3},
+		{ID: 4, Text: "D", Position: 4},
+	}
+
+	tests := []struct {
+		name     string
+		question models.QuizQuestion
+		answer   models.QuizAnswer
+		grade    float64
+	}{
+		{"single choice correct", models.QuizQuestion{Type: models.QuestionSingleChoice, Options: options[1:]}, models.QuizAnswer{OptionIDs: []int{2}}, 1},
+		{"single choice with two options", models.QuizQuestion{Type: models.QuestionSingleChoice, Options: options[1:]}, models.QuizAnswer{OptionIDs: []int{2, 3}}, 0},
+		{"multiple choice all correct", models.QuizQuestion{Type: models.QuestionMultipleChoice, Options: options}, models.QuizAnswer{OptionIDs: []int{1, 2}}, 1},
+		{"multiple choice partial", models.QuizQuestion{Type: models.QuestionMultipleChoice, Options: options}, models.QuizAnswer{OptionIDs: []int{2}}, 0.5},
+		{"multiple choice wrong cancels correct", models.QuizQuestion{Type: models.QuestionMultipleChoice, Options: options}, models.QuizAnswer{OptionIDs: []int{1, 3}}, 0},
+		{"multiple choice select all", models.QuizQuestion{Type: models.QuestionMultipleChoice, Options: options}, models.QuizAnswer{OptionIDs: []int{1, 2, 3, 4}}, 0},
+		{"short answer normalized", models.QuizQuestion{Type: models.QuestionShortAnswer, Options: []models.QuizOption{{Text: "Same  Site"}}}, models.QuizAnswer{Text: " same site "}, 1},
+		{"short answer empty", models.QuizQuestion{Type: models.QuestionShortAnswer, Options: []models.QuizOption{{Text: "SameSite"}}}, models.QuizAnswer{}, 0},
+		{"ordering correct", models.QuizQuestion{Type: models.QuestionOrdering, Options: options}, models.QuizAnswer{OptionIDs: []int{1, 2, 3, 4}}, 1},
+		{"ordering half in place", models.QuizQuestion{Type: models.QuestionOrdering, Options: options}, models.QuizAnswer{OptionIDs: []int{1, 2, 4, 3}}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.grade, tt.question.Grade(tt.answer))
+		})
+	}
+
+	t.Run("Selects random questions from banks", func(t *testing.T) {
+		bank := []models.QuizQuestion{
+			{ID: 1, Type: models.QuestionOrdering, Options: options},
+			{ID: 2, Type: models.QuestionSingleChoice, Options: options[1:]},
+			{ID: 3, Type: models.QuestionSingleChoice, Options: options[1:]},
+		}
+		settings := models.QuizSettings{Sources: []models.QuizSource{{BankID: 1, Count: 2}}}
+		questions := settings.SelectQuestions(map[int][]models.QuizQuestion{1: bank}, rand.New(rand.NewSource(1)))
+		assert.Len(t, questions, 2)
+
+		settings.Sources = []models.QuizSource{{BankID: 1}, {BankID: 2}}
+		questions = settings.SelectQuestions(map[int][]models.QuizQuestion{1: bank, 2: bank[:1]}, rand.New(rand.NewSource(1)))
+		assert.Len(t, questions, 3, "question of both banks is taken once")
+		for _, question := range questions {
+			assert.ElementsMatch(t, bankOptions(bank, question.ID), question.Options)
+		}
+		assert.Equal(t, options, bank[0].Options, "bank is not shuffled in place")
+	})
+
+	t.Run("Ignores answers after time limit", func(t *testing.T) {
+		startedAt := time.Now()
+		expiresAt := startedAt.Add(time.Minute)
+		attempt := models.QuizAttempt{
+			StartedAt: startedAt,
+			ExpiresAt: &expiresAt,
+			Questions: []models.QuizAttemptQuestion{
+				{QuizQuestion: models.QuizQuestion{ID: 1, Type: models.QuestionSingleChoice, Points: 3, Options: options[1:]}},
+				{QuizQuestion: models.QuizQuestion{ID: 2, Type: models.QuestionShortAnswer, Points: 1, Options: []models.QuizOption{{Text: "x"}}}},
+			},
+		}
+		answers := []models.QuizAnswer{{QuestionID: 1, OptionIDs: []int{2}}}
+
+		onTime := attempt
+		onTime.Questions = append([]models.QuizAttemptQuestion{}, attempt.Questions...)
+		onTime.Grade(answers, 75, expiresAt.Add(models.QuizGracePeriod))
+		assert.Equal(t, models.QuizAttemptSubmitted, onTime.Status)
+		assert.Equal(t, 75.0, onTime.Score)
+		assert.True(t, onTime.IsPassed)
+
+		attempt.Grade(answers, 75, expiresAt.Add(time.Minute))
+		assert.Equal(t, models.QuizAttemptExpired, attempt.Status)
+		assert.Zero(t, attempt.Score)
+		assert.False(t, attempt.IsPassed)
+	})
+}
+
+func TestOrderingQuestionHidesCorrectOrder(t *testing.T) {
+	items := []models.QuizOption{{Text: "Parse"}, {Text: "Validate"}, {Text: "Escape"}, {Text: "Bind"}, {Text: "Execute"}, {Text: "Log"}}
+	bank := models.QuestionBank{CourseID: 1, Title: "Query pipeline"}
+	for i := 0; i < 5; i++ {
+		bank.Questions = append(bank.Questions, models.QuizQuestion{Type: models.QuestionOrdering, Text: "Order the steps", Options: items})
+	}
+	store := new(storage.MockStorage)
+	bank, err := store.CreateQuestionBank(bank)
+	assert.NoError(t, err)
+	defer store.DeleteQuestionBank(bank.CourseID, bank.ID)
+
+	// Вероятность, что ID всех пяти вопросов случайно идут в верном порядке, - (1/720)^5
+	revealed := 0
+	for _, question := range bank.Questions {
+		student := question.ForStudent()
+		ids := make([]int, 0, len(student.Options))
+		for _, option := range student.Options {
+			assert.Zero(t, option.Position)
+			ids = append(ids, option.ID)
+		}
+		sort.Ints(ids)
+
+		var byID []string
+		for _, id := range ids {
+			for _, option := range student.Options {
+				if option.ID == id {
+					byID = append(byID, option.Text)
+				}
+			}
+		}
+		inOrder := len(byID) == len(items)
+		for i := range byID {
+			inOrder = inOrder && byID[i] == items[i].Text
+		}
+		if inOrder {
+			revealed++
+		}
+
+		correct := make([]int, len(question.Options))
+		for _, option := range question.Options {
+			correct[option.Position-1] = option.ID
+		}
+		assert.Equal(t, 1.0, question.Grade(models.QuizAnswer{OptionIDs: correct}), "graded against the stored order")
+	}
+	assert.Less(t, revealed, len(bank.Questions), "option IDs do not follow the correct order")
+}
+
+func bankOptions(bank []models.QuizQuestion, questionID int) []models.QuizOption {
+	for _, question := range bank {
+		if question.ID == questionID {
+			return question.Options
+		}
+	}
+	return nil
+}
+
+func TestQuizHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	asUser := func(userID int, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", userID)
+			handler(c)
+		}
+	}
+	router.POST("/courses/:course_id/question-banks", asUser(1, handlers.CreateQuestionBank))
+	router.GET("/courses/:course_id/question-banks", asUser(1, handlers.GetQuestionBanks))
+	router.PUT("/courses/:course_id/question-banks/:bank_id", asUser(1, handlers.UpdateQuestionBank))
+	router.DELETE("/courses/:course_id/question-banks/:bank_id", asUser(1, handlers.DeleteQuestionBank))
+	router.POST("/courses/:course_id/tasks", asUser(1, handlers.CreateTask))
+	router.DELETE("/courses/:course_id/tasks/:task_id", asUser(1, handlers.DeleteTask))
+	router.POST("/progress/:user_id/tasks/:task_id/submit", asUser(2, handlers.SubmitTaskWithAnswer))
+	router.POST("/progress/:user_id/tasks/:task_id/complete", asUser(2, handlers.CompleteTask))
+	router.POST("/progress/:user_id/tasks/:task_id/quiz-attempts", asUser(2, handlers.StartQuizAttempt))
+	router.GET("/progress/:user_id/tasks/:task_id/quiz-attempts", asUser(2, handlers.GetQuizAttempts))
+	router.GET("/progress/:user_id/quiz-attempts/:attempt_id", asUser(2, handlers.GetQuizAttempt))
+	router.POST("/progress/:user_id/quiz-attempts/:attempt_id/submit", asUser(2, handlers.SubmitQuizAttempt))
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	question := models.QuizQuestion{Type: models.QuestionSingleChoice, Text: "Safe way to build SQL?", Options: []models.QuizOption{
+		{Text: "Prepared statements", IsCorrect: true}, {Text: "String concatenation"},
+	}}
+
+	t.Run("Validates question banks", func(t *testing.T) {
+		invalid := question
+		invalid.Options = []models.QuizOption{{Text: "Only option", IsCorrect: true}}
+		w := request("POST", "/courses/1/question-banks", models.QuestionBank{Title: "SQL", Questions: []models.QuizQuestion{invalid}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		invalid = question
+		invalid.Type = "essay"
+		w = request("POST", "/courses/1/question-banks", models.QuestionBank{Title: "SQL", Questions: []models.QuizQuestion{invalid}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = request("POST", "/courses/999/question-banks", models.QuestionBank{Title: "SQL", Questions: []models.QuizQuestion{question}})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	w := request("POST", "/courses/1/question-banks", models.QuestionBank{Title: " SQL basics ", Questions: []models.QuizQuestion{question}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var bank models.QuestionBank
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &bank))
+	assert.Equal(t, "SQL basics", bank.Title)
+	if assert.Len(t, bank.Questions, 1) {
+		assert.Equal(t, 1.0, bank.Questions[0].Points, "default question weight")
+	}
+	bankPath := fmt.Sprintf("/courses/1/question-banks/%d", bank.ID)
+
+	t.Run("Validates quiz tasks", func(t *testing.T) {
+		w := request("POST", "/courses/1/tasks", models.Task{Title: "Quiz", Type: models.TaskTypeQuiz})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "missing settings")
+
+		w = request("POST", "/courses/2/tasks", models.Task{Title: "Quiz", Type: models.TaskTypeQuiz, Quiz: &models.QuizSettings{
+			Sources: []models.QuizSource{{BankID: bank.ID}},
+		}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "bank of another course")
+
+		w = request("POST", "/courses/1/tasks", models.Task{Title: "Quiz", Type: "essay"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	w = request("POST", "/courses/1/tasks", models.Task{Title: "SQL quiz", Points: 30, Type: models.TaskTypeQuiz, Quiz: &models.QuizSettings{
+		MaxAttempts: 1,
+		Sources:     []models.QuizSource{{BankID: bank.ID}},
+	}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var task models.Task
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	attemptsPath := fmt.Sprintf("/progress/2/tasks/%d/quiz-attempts", task.ID)
+
+	t.Run("Passes quiz", func(t *testing.T) {
+		w := request("POST", fmt.Sprintf("/progress/2/tasks/%d/submit", task.ID), models.TaskSubmission{CourseID: 1, Answer: "x"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "quiz is not answered as code")
+		completePath := fmt.Sprintf("/progress/2/tasks/%d/complete", task.ID)
+		assert.Equal(t, http.StatusForbidden, request("POST", completePath, nil).Code, "quiz is not completed without an attempt")
+		assert.Equal(t, http.StatusBadRequest, request("POST", "/progress/2/tasks/1/quiz-attempts", nil).Code, "not a quiz")
+		assert.Equal(t, http.StatusForbidden, request("POST", fmt.Sprintf("/progress/1/tasks/%d/quiz-attempts", task.ID), nil).Code)
+
+		w = request("POST", attemptsPath, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "is_correct")
+		var attempt models.QuizAttempt
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &attempt))
+		assert.Nil(t, attempt.ExpiresAt)
+
+		var correct int
+		for _, option := range attempt.Questions[0].Options {
+			if option.Text == "Prepared statements" {
+				correct = option.ID
+			}
+		}
+		w = request("POST", fmt.Sprintf("/progress/2/quiz-attempts/%d/submit", attempt.ID), models.QuizSubmission{
+			Answers: []models.QuizAnswer{{QuestionID: attempt.Questions[0].ID, OptionIDs: []int{correct}}},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &attempt))
+		assert.True(t, attempt.IsPassed)
+		assert.Equal(t, 100.0, attempt.Score)
+		assert.Equal(t, 30.0, attempt.Points)
+
+		progress, err := handlers.Store.GetUserProgress(2)
+		assert.NoError(t, err)
+		assert.True(t, progress.Completed[task.ID])
+		assert.Equal(t, http.StatusOK, request("POST", completePath, nil).Code, "passed quiz is already completed")
+
+		assert.Equal(t, http.StatusForbidden, request("POST", attemptsPath, nil).Code, "attempts exhausted")
+		w = request("GET", attemptsPath, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var attempts []models.QuizAttempt
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &attempts))
+		assert.Len(t, attempts, 1)
+	})
+
+	t.Run("Protects banks used by quizzes", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, request("DELETE", bankPath, nil).Code)
+		assert.Equal(t, http.StatusOK, request("PUT", bankPath, models.QuestionBank{Title: "SQL", Questions: []models.QuizQuestion{question}}).Code)
+
+		request("DELETE", fmt.Sprintf("/courses/1/tasks/%d", task.ID), nil)
+		assert.Equal(t, http.StatusOK, request("DELETE", bankPath, nil).Code)
+		assert.Equal(t, http.StatusNotFound, request("DELETE", bankPath, nil).Code)
+
+		w := request("GET", "/courses/1/question-banks", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String())
+	})
+}
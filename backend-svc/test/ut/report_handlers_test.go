@@ -0,0 +1,144 @@
+package ut
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupEffectivenessRouter(userID int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	router.GET("/courses/:course_id/effectiveness", func(c *gin.Context) {
+		c.Set("userID", userID)
+		handlers.GetLearningEffectiveness(c)
+	})
+	return router
+}
+
+func getEffectiveness(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestEffectivenessScore(t *testing.T) {
+	assert.Equal(t, 100.0, models.EffectivenessScore(100, 100, 0, 100, true))
+	assert.Equal(t, 67.5, models.EffectivenessScore(100, 0, 0, 50, true))
+	// Без попыток сдачи веса остальных слагаемых нормируются
+	assert.Equal(t, 100.0, models.EffectivenessScore(100, 100, 0, 0, false))
+	assert.Equal(t, 0.0, models.EffectivenessScore(0, 0, 100, 0, true))
+}
+
+func TestGetLearningEffectiveness(t *testing.T) {
+	mockStorage := new(storage.MockStorage)
+	assert.NoError(t, mockStorage.DemoteFromAdmin(2))
+
+	// Неверная и верная попытки сдачи задачи 4 курса 3
+	for _, answer := range []string{"wrong", ""} {
+		_, err := mockStorage.SubmitTaskAnswer(models.TaskSubmission{UserID: 2, TaskID: 4, CourseID: 3, Answer: answer})
+		assert.NoError(t, err)
+	}
+
+	t.Run("Builds report for the period", func(t *testing.T) {
+		w := getEffectiveness(setupEffectivenessRouter(1), "/courses/3/effectiveness?period=week")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var report models.LearningEffectiveness
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, models.PeriodWeek, report.Period)
+		assert.Equal(t, "CSRF", report.CourseName)
+		assert.Equal(t, 1, report.TotalStudents)
+		assert.Equal(t, 100.0, report.AverageCompletion)
+		assert.Equal(t, 100.0, report.CompletionRate)
+		assert.Equal(t, 0.0, report.DropoutRate)
+		assert.Equal(t, "2m 00s", report.AverageTimeSpent)
+		assert.WithinDuration(t, report.PeriodEnd.AddDate(0, 0, -7), report.PeriodStart, time.Second)
+		if assert.Len(t, report.DifficultTasks, 1) {
+			assert.Equal(t, 4, report.DifficultTasks[0].TaskID)
+			assert.Equal(t, 50.0, report.DifficultTasks[0].FailRate)
+		}
+	})
+
+	t.Run("Counts inactive students as dropouts", func(t *testing.T) {
+		_, err := mockStorage.SubmitTaskAnswer(models.TaskSubmission{UserID: 2, TaskID: 3, CourseID: 2, Answer: "wrong"})
+		assert.NoError(t, err)
+
+		to := time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+		w := getEffectiveness(setupEffectivenessRouter(1), "/courses/2/effectiveness?period=week&inactive_days=7&to="+to)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var report models.LearningEffectiveness
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, 7, report.InactivityDays)
+		assert.Equal(t, 100.0, report.DropoutRate)
+		// Попытка была раньше начала периода
+		assert.Empty(t, report.DifficultTasks)
+	})
+
+	t.Run("Exports CSV", func(t *testing.T) {
+		w := getEffectiveness(setupEffectivenessRouter(1), "/courses/3/effectiveness?period=term&format=csv")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+		assert.Equal(t, `attachment; filename="effectiveness_course_3_term.csv"`, w.Header().Get("Content-Disposition"))
+
+		reader := csv.NewReader(w.Body)
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"metric", "value"}, records[0])
+		assert.Contains(t, records, []string{"completion_rate", "100"})
+		assert.Contains(t, records, []string{"task_id", "task_title", "fail_rate", "average_score", "average_time"})
+	})
+
+	t.Run("Exports XLSX", func(t *testing.T) {
+		w := getEffectiveness(setupEffectivenessRouter(1), "/courses/3/effectiveness?format=xlsx")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "effectiveness_course_3_month.xlsx")
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		assert.NoError(t, err)
+
+		files := make(map[string]string)
+		for _, f := range archive.File {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			files[f.Name] = string(content)
+		}
+		assert.Contains(t, files, "[Content_Types].xml")
+		assert.Contains(t, files["xl/workbook.xml"], `name="Difficult tasks"`)
+		assert.True(t, strings.Contains(files["xl/worksheets/sheet2.xml"], "Understanding CSRF"))
+	})
+
+	t.Run("Rejects students and invalid parameters", func(t *testing.T) {
+		w := getEffectiveness(setupEffectivenessRouter(2), "/courses/3/effectiveness")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		router := setupEffectivenessRouter(1)
+		for _, query := range []string{"period=year", "format=pdf", "inactive_days=0", "from=2025-02-01&to=2025-01-01"} {
+			w := getEffectiveness(router, "/courses/3/effectiveness?"+query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+
+		w = getEffectiveness(router, "/courses/999/effectiveness")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,109 @@
+package ut
+
+import (
+	"encoding/json"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSearchRouter(userID int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	router.GET("/search", func(c *gin.Context) {
+		c.Set("userID", userID)
+		handlers.Search(c)
+	})
+	return router
+}
+
+func performSearch(router *gin.Engine, query string) (*httptest.ResponseRecorder, []models.SearchResult) {
+	req, _ := http.NewRequest("GET", "/search?"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var results []models.SearchResult
+	_ = json.Unmarshal(w.Body.Bytes(), &results)
+	return w, results
+}
+
+func TestSearch(t *testing.T) {
+	// Моковое хранилище общее для всех тестов пакета: возвращаем пользователю 2 роль студента
+	assert.NoError(t, new(storage.MockStorage).DemoteFromAdmin(2))
+
+	t.Run("Ranks title matches first and highlights terms", func(t *testing.T) {
+		w, results := performSearch(setupSearchRouter(2), "q=sql+injection")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, results)
+		assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+		assert.Equal(t, models.SearchTypeCourse, results[0].Type)
+		assert.Equal(t, 1, results[0].ID)
+		assert.Contains(t, results[0].Snippet, "<mark>SQL</mark> <mark>Injection</mark>")
+		for i := 1; i < len(results); i++ {
+			assert.GreaterOrEqual(t, results[i-1].Score, results[i].Score)
+		}
+	})
+
+	t.Run("Matches task content", func(t *testing.T) {
+		w, results := performSearch(setupSearchRouter(2), "q=innerHTML")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, results, 1)
+		assert.Equal(t, models.SearchTypeTask, results[0].Type)
+		assert.Equal(t, "content", results[0].MatchedField)
+	})
+
+	t.Run("Students never match solution text", func(t *testing.T) {
+		w, results := performSearch(setupSearchRouter(2), "q=textContent")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Administrators match solution text", func(t *testing.T) {
+		w, results := performSearch(setupSearchRouter(1), "q=textContent")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "solution", results[0].MatchedField)
+	})
+
+	t.Run("Users are searchable by administrators only", func(t *testing.T) {
+		_, results := performSearch(setupSearchRouter(1), "q=regular&type=user")
+		assert.Len(t, results, 1)
+		assert.Equal(t, "user123", results[0].Title)
+
+		_, results = performSearch(setupSearchRouter(2), "q=regular")
+		for _, result := range results {
+			assert.NotEqual(t, models.SearchTypeUser, result.Type)
+		}
+
+		w, _ := performSearch(setupSearchRouter(2), "q=regular&type=user")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Snippets are HTML-escaped", func(t *testing.T) {
+		_, results := performSearch(setupSearchRouter(2), "q=users&type=task")
+
+		assert.NotEmpty(t, results)
+		assert.NotContains(t, results[0].Snippet, `"`)
+		assert.Contains(t, results[0].Snippet, "<mark>users</mark>")
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		router := setupSearchRouter(2)
+		for _, query := range []string{"", "q=+", "q=sql&type=lab", "q=sql&cursor=1"} {
+			w, _ := performSearch(router, query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
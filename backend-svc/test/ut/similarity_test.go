@@ -0,0 +1,147 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/similarity"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const sharedAnswer = `
+def fetch(conn, name):
+    cursor = conn.execute("SELECT * FROM users WHERE name = ?", (name,))
+    return cursor.fetchall()`
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t,
+		[]string{"ID", "=", "ID", ".", "ID", "(", "STR", "select", "*", "from", "ID", "where", "ID", "=", "?", "STR", ",", "NUM", ")"},
+		similarity.Tokenize(`rows = db.Query("SELECT * FROM users WHERE id = ?", 42) // fetch`))
+	assert.Equal(t, similarity.Tokenize("a := b + 1"), similarity.Tokenize("total   :=\n\tprice + 7 /* tax */"),
+		"renamed identifiers, numbers, spacing and comments do not matter")
+	assert.Equal(t, []string{"STR", "ID", "ID", "ID", "STR"}, similarity.Tokenize(`'it\'s ok'`))
+	assert.Empty(t, similarity.Tokenize("  -- only a comment"))
+}
+
+func TestSimilarity(t *testing.T) {
+	renamed := `
+def load(db, user):
+    c = db.execute("SELECT * FROM users WHERE name = ?", (user,))
+    return c.fetchall()`
+	assert.Equal(t, 1.0, similarity.Similarity(similarity.Tokenize(sharedAnswer), similarity.Tokenize(renamed)))
+
+	different := `
+query = "SELECT * FROM users WHERE name = '" + name + "'"
+for row in conn.execute(query):
+    print(row)`
+	score := similarity.Similarity(similarity.Tokenize(sharedAnswer), similarity.Tokenize(different))
+	assert.Less(t, score, similarity.DefaultConfig.Threshold)
+	assert.Zero(t, similarity.Similarity(nil, similarity.Tokenize(sharedAnswer)))
+}
+
+func TestSimilarityMatch(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	answer := models.SubmittedAnswer{SubmissionID: 10, UserID: 3, TaskID: 1, CourseID: 1, Answer: sharedAnswer, IsCorrect: true, SubmittedAt: now}
+	peers := []models.SubmittedAnswer{
+		{SubmissionID: 9, UserID: 4, TaskID: 1, Answer: sharedAnswer, IsCorrect: true, SubmittedAt: now.Add(-time.Minute)},
+		{SubmissionID: 8, UserID: 4, TaskID: 1, Answer: "print(1)", IsCorrect: false, SubmittedAt: now.Add(-2 * time.Minute)},
+		{SubmissionID: 7, UserID: 5, TaskID: 1, Answer: sharedAnswer, IsCorrect: false, SubmittedAt: now.Add(-time.Hour)},
+		{SubmissionID: 6, UserID: 3, TaskID: 1, Answer: sharedAnswer, IsCorrect: false, SubmittedAt: now.Add(-time.Hour)},
+	}
+
+	flags := similarity.Match(answer, peers, "", similarity.DefaultConfig)
+	if assert.Len(t, flags, 3) {
+		assert.Equal(t, models.SimilarityKindAnswer, flags[0].Kind)
+		assert.Equal(t, 7, flags[0].OtherSubmissionID)
+		assert.Equal(t, models.SimilarityKindTiming, flags[1].Kind)
+		assert.Equal(t, 9, flags[1].OtherSubmissionID)
+		assert.Equal(t, 60, flags[1].TimeGapSeconds)
+		assert.Equal(t, models.SimilarityKindAnswer, flags[2].Kind)
+		assert.Equal(t, 9, flags[2].OtherSubmissionID)
+		for _, flag := range flags {
+			assert.NotEqual(t, 3, flag.OtherUserID, "own earlier answers are not flagged")
+			assert.Equal(t, models.SimilarityStatusPending, flag.Status)
+		}
+	}
+
+	flags = similarity.Match(answer, peers, sharedAnswer, similarity.DefaultConfig)
+	if assert.Len(t, flags, 1, "answers matching the reference solution are flagged only by timing") {
+		assert.Equal(t, models.SimilarityKindTiming, flags[0].Kind)
+	}
+
+	short := models.SubmittedAnswer{SubmissionID: 11, UserID: 3, TaskID: 2, Answer: "x.textContent = y", IsCorrect: true, SubmittedAt: now}
+	shortPeer := models.SubmittedAnswer{SubmissionID: 5, UserID: 4, TaskID: 2, Answer: "el.textContent = c", IsCorrect: true, SubmittedAt: now.Add(-10 * time.Minute)}
+	assert.Empty(t, similarity.Match(short, []models.SubmittedAnswer{shortPeer}, "", similarity.DefaultConfig),
+		"short answers outside the timing window are not flagged")
+}
+
+func TestSimilarityHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := new(storage.MockStorage)
+	handlers.Store = store
+
+	base := time.Now().UTC().Add(-time.Hour)
+	var submissionIDs []int
+	for i, userID := range []int{1, 2} {
+		result, err := store.SubmitTaskAnswer(models.TaskSubmission{
+			UserID: userID, TaskID: 2, CourseID: 1, Answer: sharedAnswer, SubmittedAt: base.Add(time.Duration(i) * 30 * time.Second),
+		})
+		assert.NoError(t, err)
+		submissionIDs = append(submissionIDs, result.SubmissionID)
+	}
+
+	flagged, err := similarity.Check(store, similarity.DefaultConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, flagged)
+	flagged, err = similarity.Check(store, similarity.DefaultConfig)
+	assert.NoError(t, err)
+	assert.Zero(t, flagged)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userID", 1) })
+	router.GET("/teacher/courses/:course_id/similarity-flags", handlers.GetSimilarityFlags)
+	router.PUT("/teacher/similarity-flags/:flag_id", handlers.ReviewSimilarityFlag)
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("GET", "/teacher/courses/1/similarity-flags?status=pending", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var flags []models.SimilarityFlag
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &flags))
+	if assert.Len(t, flags, 1) {
+		assert.Equal(t, submissionIDs[1], flags[0].SubmissionID)
+		assert.Equal(t, submissionIDs[0], flags[0].OtherSubmissionID)
+		assert.Equal(t, "user123", flags[0].Username)
+		assert.Equal(t, sharedAnswer, flags[0].OtherAnswer)
+
+		path := "/teacher/similarity-flags/" + strconv.Itoa(flags[0].ID)
+		assert.Equal(t, http.StatusBadRequest, request("PUT", path, models.SimilarityReview{Status: "guilty"}).Code)
+		w = request("PUT", path, models.SimilarityReview{Status: models.SimilarityStatusDismissed, Note: " discussed in class "})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var reviewed models.SimilarityFlag
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &reviewed))
+		assert.Equal(t, "discussed in class", reviewed.Note)
+		assert.NotNil(t, reviewed.ReviewedAt)
+	}
+
+	w = request("GET", "/teacher/courses/1/similarity-flags?status=pending", nil)
+	assert.Equal(t, "0", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/teacher/courses/1/similarity-flags?kind=copy", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/teacher/courses/1/similarity-flags?assignment_id=x", nil).Code)
+	assert.Equal(t, http.StatusNotFound, request("PUT", "/teacher/similarity-flags/9999", models.SimilarityReview{Status: models.SimilarityStatusConfirmed}).Code)
+}
@@ -0,0 +1,209 @@
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"lmsmodule/backend-svc/handlers"
+	"lmsmodule/backend-svc/models"
+	"lmsmodule/backend-svc/reminders"
+	"lmsmodule/backend-svc/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityStreaks(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC) }
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		name     string
+		times    models.ActivityTimes
+		now      time.Time
+		location *time.Location
+		expected models.StreakStats
+	}{
+		{
+			name:     "No activity",
+			now:      at(10, 12),
+			location: time.UTC,
+			expected: models.StreakStats{},
+		},
+		{
+			name:     "Active today",
+			times:    models.ActivityTimes{Completed: []time.Time{at(8, 10), at(9, 10)}, Submitted: []time.Time{at(10, 9), at(10, 11)}},
+			now:      at(10, 12),
+			location: time.UTC,
+			expected: models.StreakStats{Current: 3, Longest: 3, LastActiveDate: "2025-03-10", ActiveToday: true},
+		},
+		{
+			name:     "Streak survives until the end of the next day",
+			times:    models.ActivityTimes{Completed: []time.Time{at(1, 10), at(2, 10), at(3, 10), at(8, 10), at(9, 10)}},
+			now:      at(10, 23),
+			location: time.UTC,
+			expected: models.StreakStats{Current: 2, Longest: 3, LastActiveDate: "2025-03-09"},
+		},
+		{
+			name:     "Broken streak",
+			times:    models.ActivityTimes{Submitted: []time.Time{at(7, 10), at(8, 10)}},
+			now:      at(10, 12),
+			location: time.UTC,
+			expected: models.StreakStats{Longest: 2, LastActiveDate: "2025-03-08"},
+		},
+		{
+			name:     "Days follow the user time zone",
+			times:    models.ActivityTimes{Completed: []time.Time{at(8, 10), at(8, 16)}},
+			now:      at(9, 1),
+			location: tokyo,
+			expected: models.StreakStats{Current: 2, Longest: 2, LastActiveDate: "2025-03-09", ActiveToday: true},
+		},
+		{
+			name:     "Future activity is ignored",
+			times:    models.ActivityTimes{Completed: []time.Time{at(9, 10), at(11, 10)}},
+			now:      at(10, 12),
+			location: time.UTC,
+			expected: models.StreakStats{Current: 1, Longest: 1, LastActiveDate: "2025-03-09"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streak := models.ComputeStreak(tt.times, tt.now, tt.location)
+			assert.Equal(t, tt.expected, streak)
+			assert.Equal(t, tt.expected.Current > 0 && !tt.expected.ActiveToday, streak.AtRisk())
+		})
+	}
+
+	t.Run("Builds daily counts", func(t *testing.T) {
+		times := models.ActivityTimes{
+			Completed: []time.Time{at(2, 10), at(5, 10)},
+			Submitted: []time.Time{at(2, 9), at(2, 20), at(3, 15)},
+		}
+		days := models.BuildActivityDays(times, at(2, 0), at(4, 0), tokyo)
+		assert.Equal(t, []models.ActivityDay{
+			{Date: "2025-03-02", Completed: 1, Submissions: 1, Total: 2},
+			{Date: "2025-03-03", Submissions: 1, Total: 1},
+			{Date: "2025-03-04", Submissions: 1, Total: 1},
+		}, days)
+	})
+}
+
+// streakStore подменяет в MockStorage данные для напоминаний о сериях
+type streakStore struct {
+	storage.MockStorage
+	candidates []models.StreakReminderCandidate
+	times      map[int]models.ActivityTimes
+	reminded   map[int]string
+}
+
+func (s *streakStore) GetStreakReminderCandidates() ([]models.StreakReminderCandidate, error) {
+	return s.candidates, nil
+}
+
+func (s *streakStore) GetActivityTimes(userID int, from, to time.Time) (models.ActivityTimes, error) {
+	return s.times[userID], nil
+}
+
+func (s *streakStore) MarkStreakReminderSent(userID int, date string) error {
+	s.reminded[userID] = date
+	return nil
+}
+
+func TestStreakReminders(t *testing.T) {
+	now := time.Date(2025, 3, 10, 19, 30, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	store := &streakStore{
+		candidates: []models.StreakReminderCandidate{
+			{UserID: 1, Email: "at-risk@example.com", Timezone: "UTC"},
+			{UserID: 2, Email: "active@example.com", Timezone: "UTC"},
+			{UserID: 3, Email: "evening-not-yet@example.com", Timezone: "America/New_York"},
+			{UserID: 4, Email: "reminded@example.com", Timezone: "Europe/Moscow", RemindedOn: "2025-03-10"},
+			{UserID: 5, Email: "failing@example.com", Timezone: "Asia/Dubai"},
+		},
+		times: map[int]models.ActivityTimes{
+			1: {Completed: []time.Time{yesterday.AddDate(0, 0, -1), yesterday}},
+			2: {Completed: []time.Time{yesterday}, Submitted: []time.Time{now.Add(-time.Hour)}},
+			3: {Completed: []time.Time{yesterday}},
+			4: {Completed: []time.Time{yesterday}},
+			5: {Completed: []time.Time{yesterday}},
+		},
+		reminded: map[int]string{},
+	}
+
+	var sent []string
+	send := func(candidate models.StreakReminderCandidate, streak models.StreakStats) error {
+		if candidate.UserID == 5 {
+			return errors.New("smtp unavailable")
+		}
+		assert.True(t, streak.AtRisk())
+		sent = append(sent, candidate.Email)
+		return nil
+	}
+
+	count, err := reminders.SendStreakReminders(store, now, send)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"at-risk@example.com"}, sent)
+	assert.Equal(t, map[int]string{1: "2025-03-10"}, store.reminded, "failed reminders are retried on the next run")
+}
+
+func TestActivityHeatmapHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.Store = new(storage.MockStorage)
+
+	asUser := func(userID int, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", userID)
+			handler(c)
+		}
+	}
+	router.GET("/progress/:user_id/activity", asUser(2, handlers.GetUserActivityHeatmap))
+	router.GET("/account/preferences", asUser(2, handlers.GetUserPreferences))
+	router.PUT("/account/preferences", asUser(2, handlers.UpdateUserPreferences))
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, query := range []string{"from=yesterday", "to=2025-13-01", "from=2025-03-02&to=2025-03-01", "from=2020-01-01&to=2025-01-01"} {
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/progress/2/activity?"+query, nil).Code, query)
+	}
+	assert.Equal(t, http.StatusForbidden, request("GET", "/progress/1/activity", nil).Code)
+
+	w := request("GET", "/progress/2/activity", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var heatmap models.ActivityHeatmap
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &heatmap))
+	assert.Len(t, heatmap.Days, 365)
+	assert.GreaterOrEqual(t, heatmap.Streak.Longest, 2, "user 2 completed tasks on two consecutive days")
+
+	assert.Equal(t, http.StatusBadRequest, request("PUT", "/account/preferences", models.UserPreferences{Timezone: "Local"}).Code)
+	w = request("PUT", "/account/preferences", models.UserPreferences{Timezone: " "})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var prefs models.UserPreferences
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &prefs))
+	assert.Equal(t, models.DefaultTimezone, prefs.Timezone, "empty timezone means UTC")
+
+	assert.Equal(t, http.StatusOK, request("PUT", "/account/preferences", models.UserPreferences{Timezone: "Europe/Moscow"}).Code)
+	w = request("GET", "/progress/2/activity?from=2025-03-01&to=2025-03-31", nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &heatmap))
+	assert.Equal(t, "Europe/Moscow", heatmap.Timezone)
+	assert.Len(t, heatmap.Days, 31)
+	assert.Equal(t, "2025-03-01", heatmap.Days[0].Date)
+
+	assert.NoError(t, handlers.Store.UpdateUserPreferences(2, models.UserPreferences{Timezone: models.DefaultTimezone}))
+}
@@ -0,0 +1,59 @@
+package ut
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"lmsmodule/api-gateway/pkg/logger"
+	"lmsmodule/api-gateway/pkg/tracing"
+	"lmsmodule/backend-svc/handlers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerTracing(t *testing.T) {
+	var spansOut, logs bytes.Buffer
+	tracer := tracing.NewTracer("backend-svc", tracing.NewJSONExporter(&spansOut), tracing.Options{})
+
+	router := setupTestRouter()
+	router.Use(logger.RequestIDMiddleware())
+	router.Use(tracing.Middleware(tracer))
+	router.Use(logger.AccessLogMiddleware(logger.New(logger.Options{Level: "info", Output: &logs})))
+	router.GET("/courses/:id", handlers.GetCourseByID)
+
+	req := httptest.NewRequest(http.MethodGet, "/courses/1", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	spans := make(map[string]tracing.SpanData)
+	scanner := bufio.NewScanner(&spansOut)
+	for scanner.Scan() {
+		var span tracing.SpanData
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+		spans[span.Name] = span
+	}
+
+	server, ok := spans["GET /courses/:id"]
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID)
+	assert.Equal(t, 200.0, server.Attributes["http.status_code"])
+
+	storageSpan, ok := spans["storage.GetCourseByID"]
+	require.True(t, ok)
+	assert.Equal(t, server.TraceID, storageSpan.TraceID)
+	assert.Equal(t, server.SpanID, storageSpan.ParentSpanID)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, server.TraceID, entry["trace_id"])
+	assert.Equal(t, server.SpanID, entry["span_id"])
+}
@@ -0,0 +1,9 @@
+ALTER TABLE users
+    DROP INDEX ft_users_search;
+
+ALTER TABLE tasks
+    DROP INDEX ft_tasks_solution,
+    DROP INDEX ft_tasks_search;
+
+ALTER TABLE courses
+    DROP INDEX ft_courses_search;
@@ -0,0 +1,9 @@
+ALTER TABLE courses
+    ADD FULLTEXT INDEX ft_courses_search (vulnerability_type, description);
+
+ALTER TABLE tasks
+    ADD FULLTEXT INDEX ft_tasks_search (title, description, content),
+    ADD FULLTEXT INDEX ft_tasks_solution (solution);
+
+ALTER TABLE users
+    ADD FULLTEXT INDEX ft_users_search (username, email, full_name);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS learning_activity_summary;
+DROP TABLE IF EXISTS learning_activities;
@@ -0,0 +1,33 @@
+CREATE TABLE learning_activities (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    course_id INT NOT NULL,
+    task_id INT NOT NULL,
+    activity_type ENUM('task_opened', 'time_on_task', 'hint_viewed', 'submission') NOT NULL,
+    duration_seconds INT NOT NULL DEFAULT 0,
+    details VARCHAR(1024),
+    occurred_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_learning_activities_user (user_id, occurred_at),
+    INDEX idx_learning_activities_course (course_id, occurred_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE learning_activity_summary (
+    user_id INT NOT NULL,
+    task_id INT NOT NULL,
+    course_id INT NOT NULL,
+    time_spent_seconds INT NOT NULL DEFAULT 0,
+    opened_count INT NOT NULL DEFAULT 0,
+    hints_viewed INT NOT NULL DEFAULT 0,
+    submissions_count INT NOT NULL DEFAULT 0,
+    first_activity_at DATETIME NOT NULL,
+    last_activity_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, task_id),
+    INDEX idx_learning_activity_summary_course (course_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+CREATE TABLE task_submissions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    task_id INT NOT NULL,
+    course_id INT NOT NULL,
+    is_correct BOOLEAN NOT NULL DEFAULT FALSE,
+    score DECIMAL(8,2) NOT NULL DEFAULT 0,
+    submitted_at DATETIME NOT NULL,
+    INDEX idx_task_submissions_user (user_id, submitted_at),
+    INDEX idx_task_submissions_course (course_id, submitted_at),
+    INDEX idx_task_submissions_task (task_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS task_prerequisites;
+DROP TABLE IF EXISTS task_skills;
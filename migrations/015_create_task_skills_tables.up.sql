@@ -0,0 +1,17 @@
+CREATE TABLE task_skills (
+    task_id INT NOT NULL,
+    skill VARCHAR(100) NOT NULL,
+    cwe VARCHAR(16),
+    weight DECIMAL(5,2) NOT NULL DEFAULT 1,
+    PRIMARY KEY (task_id, skill),
+    INDEX idx_task_skills_skill (skill),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_prerequisites (
+    task_id INT NOT NULL,
+    prerequisite_task_id INT NOT NULL,
+    PRIMARY KEY (task_id, prerequisite_task_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (prerequisite_task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+ALTER TABLE task_submissions
+    DROP COLUMN penalty_percent,
+    DROP COLUMN is_late;
+
+DROP TABLE IF EXISTS assignment_reminders;
+DROP TABLE IF EXISTS assignment_extensions;
+DROP TABLE IF EXISTS assignment_tasks;
+DROP TABLE IF EXISTS assignments;
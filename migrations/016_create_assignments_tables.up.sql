@@ -0,0 +1,56 @@
+CREATE TABLE assignments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    course_id INT NOT NULL,
+    cohort_id INT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    opens_at DATETIME NOT NULL,
+    due_at DATETIME NOT NULL,
+    penalty_per_day DECIMAL(5,2) NOT NULL DEFAULT 0,
+    max_penalty DECIMAL(5,2) NOT NULL DEFAULT 0,
+    grace_minutes INT NOT NULL DEFAULT 0,
+    close_after_days INT NOT NULL DEFAULT 0,
+    created_by INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_assignments_course (course_id, due_at),
+    INDEX idx_assignments_due (due_at),
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE assignment_tasks (
+    assignment_id INT NOT NULL,
+    task_id INT NOT NULL,
+    PRIMARY KEY (assignment_id, task_id),
+    INDEX idx_assignment_tasks_task (task_id),
+    FOREIGN KEY (assignment_id) REFERENCES assignments(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE assignment_extensions (
+    assignment_id INT NOT NULL,
+    user_id INT NOT NULL,
+    due_at DATETIME NOT NULL,
+    reason VARCHAR(500),
+    granted_by INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (assignment_id, user_id),
+    INDEX idx_assignment_extensions_due (due_at),
+    FOREIGN KEY (assignment_id) REFERENCES assignments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (granted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE assignment_reminders (
+    assignment_id INT NOT NULL,
+    user_id INT NOT NULL,
+    due_at DATETIME NOT NULL,
+    sent_at DATETIME NOT NULL,
+    PRIMARY KEY (assignment_id, user_id, due_at),
+    FOREIGN KEY (assignment_id) REFERENCES assignments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE task_submissions
+    ADD COLUMN is_late BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN penalty_percent DECIMAL(5,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+ALTER TABLE assignments
+    DROP FOREIGN KEY fk_assignments_cohort;
+
+DROP TABLE IF EXISTS cohort_courses;
+DROP TABLE IF EXISTS cohort_teachers;
+DROP TABLE IF EXISTS cohort_members;
+DROP TABLE IF EXISTS cohorts;
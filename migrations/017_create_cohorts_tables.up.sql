@@ -0,0 +1,39 @@
+CREATE TABLE cohorts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    invite_code VARCHAR(32) NULL UNIQUE,
+    created_by INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE cohort_members (
+    cohort_id INT NOT NULL,
+    user_id INT NOT NULL,
+    joined_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cohort_id, user_id),
+    INDEX idx_cohort_members_user (user_id),
+    FOREIGN KEY (cohort_id) REFERENCES cohorts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE cohort_teachers (
+    cohort_id INT NOT NULL,
+    user_id INT NOT NULL,
+    PRIMARY KEY (cohort_id, user_id),
+    INDEX idx_cohort_teachers_user (user_id),
+    FOREIGN KEY (cohort_id) REFERENCES cohorts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE cohort_courses (
+    cohort_id INT NOT NULL,
+    course_id INT NOT NULL,
+    PRIMARY KEY (cohort_id, course_id),
+    FOREIGN KEY (cohort_id) REFERENCES cohorts(id) ON DELETE CASCADE,
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE
+);
+
+ALTER TABLE assignments
+    ADD CONSTRAINT fk_assignments_cohort FOREIGN KEY (cohort_id) REFERENCES cohorts(id) ON DELETE CASCADE;
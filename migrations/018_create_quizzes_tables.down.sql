@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS quiz_attempt_questions;
+DROP TABLE IF EXISTS quiz_attempts;
+DROP TABLE IF EXISTS quiz_sources;
+DROP TABLE IF EXISTS quiz_settings;
+DROP TABLE IF EXISTS quiz_question_options;
+DROP TABLE IF EXISTS quiz_questions;
+DROP TABLE IF EXISTS question_banks;
+
+ALTER TABLE tasks
+    DROP COLUMN type;
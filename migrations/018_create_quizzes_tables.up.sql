@@ -0,0 +1,86 @@
+ALTER TABLE tasks
+    ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'code' AFTER points;
+
+CREATE TABLE question_banks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    course_id INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_question_banks_course (course_id),
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE
+);
+
+CREATE TABLE quiz_questions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    bank_id INT NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    text TEXT NOT NULL,
+    points DECIMAL(6,2) NOT NULL DEFAULT 1,
+    position INT NOT NULL,
+    INDEX idx_quiz_questions_bank (bank_id, position),
+    FOREIGN KEY (bank_id) REFERENCES question_banks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE quiz_question_options (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    question_id INT NOT NULL,
+    text TEXT NOT NULL,
+    is_correct BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL,
+    INDEX idx_quiz_question_options_question (question_id, position),
+    FOREIGN KEY (question_id) REFERENCES quiz_questions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE quiz_settings (
+    task_id INT PRIMARY KEY,
+    time_limit_minutes INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 0,
+    passing_score DECIMAL(5,2) NOT NULL DEFAULT 0,
+    shuffle_questions BOOLEAN NOT NULL DEFAULT FALSE,
+    shuffle_options BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE quiz_sources (
+    task_id INT NOT NULL,
+    bank_id INT NOT NULL,
+    question_count INT NOT NULL DEFAULT 0,
+    position INT NOT NULL,
+    PRIMARY KEY (task_id, bank_id),
+    INDEX idx_quiz_sources_bank (bank_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (bank_id) REFERENCES question_banks(id)
+);
+
+CREATE TABLE quiz_attempts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    task_id INT NOT NULL,
+    course_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    started_at DATETIME NOT NULL,
+    expires_at DATETIME NULL,
+    submitted_at DATETIME NULL,
+    score DECIMAL(5,2) NOT NULL DEFAULT 0,
+    points DECIMAL(8,2) NOT NULL DEFAULT 0,
+    is_passed BOOLEAN NOT NULL DEFAULT FALSE,
+    is_late BOOLEAN NOT NULL DEFAULT FALSE,
+    penalty_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+    submission_id INT NULL,
+    INDEX idx_quiz_attempts_user_task (user_id, task_id, started_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (submission_id) REFERENCES task_submissions(id) ON DELETE SET NULL
+);
+
+-- Вопросы попытки хранятся копией (JSON), чтобы изменение банка не влияло на начатые попытки
+CREATE TABLE quiz_attempt_questions (
+    attempt_id INT NOT NULL,
+    position INT NOT NULL,
+    question_id INT NOT NULL,
+    question TEXT NOT NULL,
+    answer TEXT NULL,
+    earned DECIMAL(5,4) NULL,
+    PRIMARY KEY (attempt_id, position),
+    FOREIGN KEY (attempt_id) REFERENCES quiz_attempts(id) ON DELETE CASCADE
+);
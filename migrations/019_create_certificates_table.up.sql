@@ -0,0 +1,15 @@
+CREATE TABLE certificates (
+    id VARCHAR(32) PRIMARY KEY,
+    user_id INT NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    course_id INT NOT NULL,
+    course_title VARCHAR(255) NOT NULL,
+    score DECIMAL(5,2) NOT NULL,
+    completed_at DATETIME NOT NULL,
+    issued_at DATETIME NOT NULL,
+    signature VARCHAR(128) NOT NULL,
+    revoked_at DATETIME NULL,
+    revoke_reason TEXT NULL,
+    UNIQUE KEY uq_certificates_user_course (user_id, course_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
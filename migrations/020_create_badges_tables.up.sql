@@ -0,0 +1,27 @@
+CREATE TABLE badges (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NULL,
+    icon VARCHAR(255) NULL,
+    criteria TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_badges_code (code)
+);
+
+CREATE TABLE user_badges (
+    user_id INT NOT NULL,
+    badge_id INT NOT NULL,
+    awarded_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, badge_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (badge_id) REFERENCES badges(id) ON DELETE CASCADE
+);
+
+INSERT INTO badges (code, name, description, criteria) VALUES
+    ('first_task', 'Первый шаг', 'Выполнена первая задача', '{"type":"tasks_completed","threshold":1}'),
+    ('course_finished', 'Выпускник', 'Пройден курс целиком', '{"type":"courses_completed","threshold":1}'),
+    ('streak_7', 'Неделя без перерыва', 'Учеба 7 дней подряд', '{"type":"streak_days","threshold":7}'),
+    ('hard_no_hints', 'Без подсказок', 'Все сложные задачи решены без подсказок', '{"type":"tasks_without_hints","difficulty":"hard"}'),
+    ('top_10', 'Десятка лучших', 'Место в первой десятке общего рейтинга', '{"type":"leaderboard_rank","threshold":10}');
@@ -0,0 +1,2 @@
+DROP INDEX idx_user_progress_user_completed ON user_progress;
+DROP TABLE IF EXISTS user_preferences;
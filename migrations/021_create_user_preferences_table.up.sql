@@ -0,0 +1,9 @@
+CREATE TABLE user_preferences (
+    user_id INT PRIMARY KEY,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    streak_reminders BOOLEAN NOT NULL DEFAULT FALSE,
+    streak_reminded_on VARCHAR(10) NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_progress_user_completed ON user_progress (user_id, completed_at);
@@ -0,0 +1,8 @@
+CREATE TABLE live_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    user_id INT NOT NULL DEFAULT 0,
+    data TEXT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_live_events_created_at (created_at)
+);
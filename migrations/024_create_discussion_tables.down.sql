@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS discussion_replies;
+DROP TABLE IF EXISTS discussion_threads;
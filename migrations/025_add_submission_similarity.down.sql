@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS similarity_flags;
+
+ALTER TABLE task_submissions
+    DROP INDEX idx_task_submissions_task_recent,
+    DROP INDEX idx_task_submissions_similarity,
+    DROP COLUMN similarity_checked,
+    DROP COLUMN answer;
@@ -0,0 +1,29 @@
+ALTER TABLE task_submissions
+    ADD COLUMN answer TEXT NULL,
+    ADD COLUMN similarity_checked BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD INDEX idx_task_submissions_similarity (similarity_checked, id),
+    ADD INDEX idx_task_submissions_task_recent (task_id, id);
+
+CREATE TABLE similarity_flags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    course_id INT NOT NULL,
+    task_id INT NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    score DOUBLE NOT NULL,
+    time_gap_seconds INT NOT NULL DEFAULT 0,
+    submission_id INT NOT NULL,
+    user_id INT NOT NULL,
+    other_submission_id INT NOT NULL,
+    other_user_id INT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    note TEXT NULL,
+    reviewed_by INT NULL,
+    reviewed_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_similarity_flags_course (course_id, status, score),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (submission_id) REFERENCES task_submissions(id) ON DELETE CASCADE,
+    FOREIGN KEY (other_submission_id) REFERENCES task_submissions(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (other_user_id) REFERENCES users(id) ON DELETE CASCADE
+);